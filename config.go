@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/hooks"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/langserver"
+)
+
+// fileConfig is the JSON configuration file passed with --config. It holds settings
+// that are awkward to express as command line flags.
+type fileConfig struct {
+	// Hooks are shell commands run in the workspace after mutating tools complete
+	Hooks []hookConfig `json:"hooks"`
+	// ResultFilter holds default include/exclude globs for reference, implementation and
+	// symbol search results
+	ResultFilter resultFilterConfig `json:"resultFilter"`
+	// ToolTimeouts overrides --tool-timeout for individual tools, e.g. {"references": "5m"}
+	ToolTimeouts map[string]string `json:"toolTimeouts"`
+	// MaxOutput overrides --max-output for individual tools, e.g. {"references": 50000}
+	MaxOutput map[string]int `json:"maxOutput"`
+	// Approval decides whether the mutating tools may write the files they change
+	Approval approvalConfig `json:"approval"`
+	// Tools curates the tools exposed to MCP clients
+	Tools toolsConfig `json:"tools"`
+	// Sandbox lists the directories besides the workspace tools may access with --sandbox
+	Sandbox sandboxConfig `json:"sandbox"`
+	// Settings are given to the language server by section, as editors do, e.g.
+	// {"python.analysis": {"typeCheckingMode": "strict"}}
+	Settings map[string]any `json:"settings"`
+	// Server changes the environment the language server is started in
+	Server serverConfig `json:"server"`
+}
+
+type serverConfig struct {
+	// Env sets environment variables of the language server, e.g. {"GOFLAGS": "-tags=integration"}
+	Env map[string]string `json:"env,omitempty"`
+	// Path are directories put in front of its PATH, e.g. ["$HOME/.nvm/versions/node/v20.11.0/bin"]
+	Path []string `json:"path,omitempty"`
+	// Exec runs it in a container or on another host like --lsp-exec, e.g. "docker:devcontainer"
+	Exec string `json:"exec,omitempty"`
+	// ExecArgs are given to docker exec, podman exec or ssh before the target, e.g. ["--user", "dev"]
+	ExecArgs []string `json:"execArgs,omitempty"`
+	// PathMap maps host directories to the directories the server sees them as, like --path-map,
+	// e.g. {".": "/workspaces/app"}
+	PathMap map[string]string `json:"pathMap,omitempty"`
+}
+
+type toolsConfig struct {
+	// Enable, unless empty, registers only the named tools, e.g. ["references", "definition"]
+	Enable []string `json:"enable,omitempty"`
+	// Disable leaves out the named tools
+	Disable []string `json:"disable,omitempty"`
+	// Overrides changes the name and description a tool is exposed with, by tool name
+	Overrides map[string]toolOverrideConfig `json:"overrides,omitempty"`
+}
+
+type sandboxConfig struct {
+	// Allow are directories, absolute or relative to the workspace, e.g. ["/home/me/go/pkg/mod"]
+	Allow []string `json:"allow,omitempty"`
+}
+
+type toolOverrideConfig struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type approvalConfig struct {
+	// Default is the action for writes no rule applies to: allow (the default), confirm or deny
+	Default string `json:"default,omitempty"`
+	// Rules are tried in order, the first one applying to a file decides its action
+	Rules []approvalRuleConfig `json:"rules,omitempty"`
+}
+
+type approvalRuleConfig struct {
+	// Tools restricts the rule to the named tools, e.g. ["delete_file"]. Defaults to all mutating tools.
+	Tools []string `json:"tools,omitempty"`
+	// Paths restricts the rule to files matching these globs, e.g. ["go.mod", "migrations/"]
+	Paths []string `json:"paths,omitempty"`
+	// Action is allow, confirm or deny
+	Action string `json:"action"`
+}
+
+type resultFilterConfig struct {
+	// Include limits results to files matching these globs, e.g. ["src/**"]
+	Include []string `json:"include,omitempty"`
+	// Exclude leaves out results in files matching these globs, e.g. ["vendor/", "*_test.go"]
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+type hookConfig struct {
+	// Command is run with the system shell, e.g. "go build ./..."
+	Command string `json:"command"`
+	// Tools restricts the hook to the named tools, e.g. ["edit_file"]. Defaults to all mutating tools.
+	Tools []string `json:"tools,omitempty"`
+	// Timeout is a Go duration string such as "30s". Defaults to 2m.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// loadConfigFile reads and validates a JSON configuration file
+func loadConfigFile(path string) (*fileConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %v", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			coreLogger.Error("Failed to close config file: %v", err)
+		}
+	}()
+
+	var fc fileConfig
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&fc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	return &fc, nil
+}
+
+// cloneConfig copies the settings apply changes in place, so that the configuration file
+// can be applied again over the settings of the flags
+func cloneConfig(c langserver.Config) langserver.Config {
+	c.PathMap = slices.Clone(c.PathMap)
+	if c.LSPLauncher != nil {
+		launcher := *c.LSPLauncher
+		c.LSPLauncher = &launcher
+	}
+	return c
+}
+
+// reloadConfig reads the configuration file again and applies it over the settings of
+// the flags
+func (cfg *config) reloadConfig() (langserver.Config, error) {
+	fc, err := loadConfigFile(cfg.configFile)
+	if err != nil {
+		return langserver.Config{}, err
+	}
+	next := &config{Config: cloneConfig(cfg.flags)}
+	if err := fc.apply(next); err != nil {
+		return langserver.Config{}, fmt.Errorf("invalid config file %s: %v", cfg.configFile, err)
+	}
+	return next.Config, nil
+}
+
+// apply copies the settings of the configuration file into cfg
+func (fc *fileConfig) apply(cfg *config) error {
+	for i, h := range fc.Hooks {
+		if h.Command == "" {
+			return fmt.Errorf("hook %d: command is required", i)
+		}
+
+		hook := hooks.Hook{Command: h.Command, Tools: h.Tools}
+		if h.Timeout != "" {
+			timeout, err := time.ParseDuration(h.Timeout)
+			if err != nil {
+				return fmt.Errorf("hook %d: invalid timeout: %v", i, err)
+			}
+			hook.Timeout = timeout
+		}
+		cfg.Hooks = append(cfg.Hooks, hook)
+	}
+
+	for name, t := range fc.ToolTimeouts {
+		timeout, err := time.ParseDuration(t)
+		if err != nil {
+			return fmt.Errorf("tool timeout for %s: %v", name, err)
+		}
+		if cfg.ToolTimeouts == nil {
+			cfg.ToolTimeouts = make(map[string]time.Duration)
+		}
+		cfg.ToolTimeouts[name] = timeout
+	}
+
+	for name, limit := range fc.MaxOutput {
+		if cfg.MaxOutputs == nil {
+			cfg.MaxOutputs = make(map[string]int)
+		}
+		cfg.MaxOutputs[name] = limit
+	}
+
+	action, err := tools.ParseApprovalAction(fc.Approval.Default)
+	if err != nil {
+		return fmt.Errorf("approval: %v", err)
+	}
+	cfg.Approval.Default = action
+	for i, r := range fc.Approval.Rules {
+		if r.Action == "" {
+			return fmt.Errorf("approval rule %d: action is required", i)
+		}
+		action, err := tools.ParseApprovalAction(r.Action)
+		if err != nil {
+			return fmt.Errorf("approval rule %d: %v", i, err)
+		}
+		cfg.Approval.Rules = append(cfg.Approval.Rules, tools.ApprovalRule{Tools: r.Tools, Paths: r.Paths, Action: action})
+	}
+
+	cfg.SandboxAllow = fc.Sandbox.Allow
+
+	cfg.Tools = fc.Tools.Enable
+	cfg.DisabledTools = fc.Tools.Disable
+	for name, o := range fc.Tools.Overrides {
+		if cfg.ToolOverrides == nil {
+			cfg.ToolOverrides = make(map[string]langserver.ToolOverride)
+		}
+		cfg.ToolOverrides[name] = langserver.ToolOverride{Name: o.Name, Description: o.Description}
+	}
+
+	cfg.Include = fc.ResultFilter.Include
+	cfg.Exclude = fc.ResultFilter.Exclude
+	cfg.Settings = fc.Settings
+	cfg.LSPEnv = fc.Server.Env
+	cfg.LSPPath = fc.Server.Path
+	if fc.Server.Exec != "" && cfg.LSPLauncher == nil {
+		launcher, err := lsp.ParseLauncher(fc.Server.Exec)
+		if err != nil {
+			return fmt.Errorf("server: %v", err)
+		}
+		cfg.LSPLauncher = &launcher
+	}
+	if cfg.LSPLauncher != nil {
+		cfg.LSPLauncher.Args = fc.Server.ExecArgs
+	}
+	// In a stable order, for reloads to tell whether it changed
+	for _, host := range slices.Sorted(maps.Keys(fc.Server.PathMap)) {
+		cfg.PathMap = append(cfg.PathMap, langserver.PathMapping{Host: host, Server: fc.Server.PathMap[host]})
+	}
+
+	return nil
+}
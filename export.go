@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/isaacphi/mcp-language-server/langserver"
+)
+
+// exportSCIP runs the export-scip subcommand, which crawls the workspace through the
+// language server and writes a SCIP index of it instead of serving MCP
+func exportSCIP(args []string) error {
+	fs := flag.NewFlagSet("export-scip", flag.ExitOnError)
+	output := fs.String("output", "index.scip", "File to write the SCIP index to")
+	extensions := fs.String("extensions", "", "Comma-separated extensions of the files to crawl, e.g. .go,.mod (empty for every file of a known language)")
+	cfg, err := parseConfig(fs, args)
+	if err != nil {
+		return err
+	}
+	outputPath, err := filepath.Abs(*output)
+	if err != nil {
+		return fmt.Errorf("invalid output path: %v", err)
+	}
+	opts := langserver.ExportOptions{Version: version}
+	if *extensions != "" {
+		opts.Extensions = strings.Split(*extensions, ",")
+	}
+
+	ls, err := langserver.New(cfg.Config)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		ls.Close(ctx)
+	}()
+	if err := ls.Start(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Write to a temporary file so an interrupted export leaves no partial index
+	tmp := outputPath + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create index: %v", err)
+	}
+	defer os.Remove(tmp)
+	w := bufio.NewWriter(f)
+	result, err := ls.ExportSCIP(ctx, w, opts)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, outputPath); err != nil {
+		return fmt.Errorf("failed to write index: %v", err)
+	}
+
+	coreLogger.Info("Wrote %s with %d documents, %d symbols and %d occurrences", outputPath, result.Documents, result.Symbols, result.Occurrences)
+	return nil
+}
@@ -76,8 +76,9 @@ func TestFindReferences(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			// Call the FindReferences tool
-			result, err := tools.FindReferences(ctx, suite.Client, tc.symbolName)
+			// Call the FindReferences tool at the hinted position
+			filePath := filepath.Join(suite.WorkspaceDir, tc.fileHint)
+			result, err := tools.FindReferences(ctx, suite.Client, filePath, tc.lineHint, tc.colHint, 5, tools.Pagination{}, tools.PathFilter{}, tools.ReferenceOptions{})
 			if err != nil {
 				t.Fatalf("Failed to find references for %s: %v. Result: %s", tc.symbolName, err, result)
 			}
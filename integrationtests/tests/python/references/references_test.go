@@ -79,10 +79,16 @@ func TestFindReferences(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			// Call the FindReferences tool
-			result, err := tools.FindReferences(ctx, suite.Client, tc.symbolName)
+			// Resolve the symbol and call the FindReferences tool on its position
+			var result string
+			filePath, line, column, err := tools.ResolveSymbolPosition(ctx, suite.Client, "", tc.symbolName)
 			if err != nil {
-				t.Fatalf("Failed to find references: %v", err)
+				result = err.Error()
+			} else {
+				result, err = tools.FindReferences(ctx, suite.Client, filePath, line, column, 5, tools.Pagination{}, tools.PathFilter{}, tools.ReferenceOptions{})
+				if err != nil {
+					t.Fatalf("Failed to find references: %v", err)
+				}
 			}
 
 			// Check that the result contains relevant information
@@ -55,7 +55,7 @@ func TestRenameSymbol(t *testing.T) {
 
 		// Request to rename SHARED_CONSTANT to UPDATED_CONSTANT at its definition
 		// The constant is defined at line 78, column 13 of types.rs
-		result, err := tools.RenameSymbol(ctx, suite.Client, typesPath, 78, 13, "UPDATED_CONSTANT")
+		result, err := tools.RenameSymbol(ctx, suite.Client, typesPath, 78, 13, "UPDATED_CONSTANT", false)
 		if err != nil {
 			t.Fatalf("RenameSymbol failed: %v", err)
 		}
@@ -125,7 +125,7 @@ fn dummy_function() {
 		time.Sleep(1 * time.Second) // Give time for the file to be processed
 
 		// Request to rename a symbol at a position where no symbol exists (in whitespace)
-		result, err := tools.RenameSymbol(ctx, suite.Client, testFilePath, 4, 1, "NewName")
+		result, err := tools.RenameSymbol(ctx, suite.Client, testFilePath, 4, 1, "NewName", false)
 
 		// The language server might actually succeed with no rename operations
 		// In this case, we check if it reports no occurrences
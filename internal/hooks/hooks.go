@@ -0,0 +1,131 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/logging"
+)
+
+// Create a logger for the hooks component
+var hooksLogger = logging.NewLogger(logging.Hooks)
+
+// DefaultTimeout bounds how long a hook may run when it does not set its own timeout
+const DefaultTimeout = 2 * time.Minute
+
+// Maximum number of output bytes of a hook included in a tool response
+const maxOutputBytes = 8 * 1024
+
+// Hook is a shell command run in the workspace after a mutating tool completes
+type Hook struct {
+	// Command is run with the system shell, e.g. "go build ./..."
+	Command string
+	// Tools restricts the hook to the named tools. Empty means all mutating tools.
+	Tools []string
+	// Timeout bounds the command's run time. Zero means DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Runner runs the configured hooks for a workspace
+type Runner struct {
+	dir   string
+	hooks []Hook
+}
+
+// NewRunner creates a runner that executes hooks in dir
+func NewRunner(dir string, hooks []Hook) *Runner {
+	return &Runner{dir: dir, hooks: hooks}
+}
+
+// Run executes the hooks configured for a tool in order and returns a report of their exit
+// status and output, ready to be appended to the tool response. It returns an empty string
+// if no hooks apply to the tool.
+func (r *Runner) Run(ctx context.Context, tool string) string {
+	if r == nil {
+		return ""
+	}
+
+	var reports []string
+	for _, hook := range r.hooks {
+		if !hook.appliesTo(tool) {
+			continue
+		}
+		reports = append(reports, r.runHook(ctx, hook))
+	}
+
+	if len(reports) == 0 {
+		return ""
+	}
+	return "\n\n" + strings.Join(reports, "\n\n")
+}
+
+func (r *Runner) runHook(ctx context.Context, hook Hook) string {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", hook.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", hook.Command)
+	}
+	cmd.Dir = r.dir
+	// Don't wait for children of the shell that keep the output open after a timeout
+	cmd.WaitDelay = time.Second
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	hooksLogger.Info("Running hook: %s", hook.Command)
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	var status string
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		status = "exited with status 0"
+	case ctx.Err() == context.DeadlineExceeded:
+		status = fmt.Sprintf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		status = fmt.Sprintf("exited with status %d", exitErr.ExitCode())
+	default:
+		status = fmt.Sprintf("failed to run: %v", err)
+	}
+	hooksLogger.Info("Hook %s %s in %s", hook.Command, status, elapsed)
+
+	report := fmt.Sprintf("Hook `%s` %s", hook.Command, status)
+	out := strings.TrimSpace(output.String())
+	if out == "" {
+		return report
+	}
+	if len(out) > maxOutputBytes {
+		// The end of the output usually has the summary and the last errors
+		out = "...\n" + out[len(out)-maxOutputBytes:]
+	}
+	return report + ":\n" + out
+}
+
+func (h Hook) appliesTo(tool string) bool {
+	if len(h.Tools) == 0 {
+		return true
+	}
+	for _, t := range h.Tools {
+		if t == tool {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunnerRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test use sh syntax")
+	}
+
+	dir := t.TempDir()
+	runner := NewRunner(dir, []Hook{
+		{Command: "echo ok > marker.txt && echo built"},
+		{Command: "echo broken >&2; exit 3", Tools: []string{"rename_symbol"}},
+		{Command: "sleep 5", Tools: []string{"slow"}, Timeout: 100 * time.Millisecond},
+	})
+
+	t.Run("runs hooks for all tools in the workspace", func(t *testing.T) {
+		report := runner.Run(context.Background(), "edit_file")
+		assert.Equal(t, "\n\nHook `echo ok > marker.txt && echo built` exited with status 0:\nbuilt", report)
+
+		_, err := os.Stat(filepath.Join(dir, "marker.txt"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("reports exit status and output of failing hooks", func(t *testing.T) {
+		report := runner.Run(context.Background(), "rename_symbol")
+		assert.Contains(t, report, "Hook `echo broken >&2; exit 3` exited with status 3:\nbroken")
+		assert.Equal(t, 2, strings.Count(report, "Hook `"))
+	})
+
+	t.Run("times out long running hooks", func(t *testing.T) {
+		report := runner.Run(context.Background(), "slow")
+		assert.Contains(t, report, "Hook `sleep 5` timed out after 100ms")
+	})
+
+	t.Run("no hooks configured", func(t *testing.T) {
+		var nilRunner *Runner
+		assert.Equal(t, "", nilRunner.Run(context.Background(), "edit_file"))
+		assert.Equal(t, "", NewRunner(dir, nil).Run(context.Background(), "edit_file"))
+	})
+}
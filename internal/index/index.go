@@ -0,0 +1,296 @@
+// Package index answers definition, references and hover queries from a precomputed code
+// intelligence index, in the SCIP or the LSIF format, as language servers answer them.
+// Indexes describe the files as they were when they were made, so their answers go stale
+// as the files change.
+package index
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Index holds the symbols of a workspace and where they occur
+type Index struct {
+	// Path is the file the index was loaded from
+	Path string
+	// ReadFile reads the files the index covers, to convert the columns of indexes that
+	// count another unit than the LSP positions of the language server. It defaults to
+	// os.ReadFile.
+	ReadFile func(path string) ([]byte, error)
+
+	documents map[string]*document
+	symbols   map[string]*symbol
+}
+
+// document holds the occurrences of symbols in a file, ordered by position
+type document struct {
+	path        string
+	occurrences []occurrence
+	// encoding is the unit the columns count, UTF-16 code units if empty
+	encoding protocol.PositionEncodingKind
+}
+
+type occurrence struct {
+	rng        protocol.Range
+	symbol     string
+	definition bool
+}
+
+// symbol is what the index knows about a symbol
+type symbol struct {
+	// name is the symbol's name qualified with its containers, such as "Server.Start",
+	// when the index gives one
+	name        string
+	hover       string
+	definitions []location
+	references  []location
+}
+
+// location is an occurrence of a symbol in a document, with columns as the index gives
+// them
+type location struct {
+	doc *document
+	rng protocol.Range
+}
+
+// Load reads an index, in the SCIP format if the file name ends in .scip and otherwise
+// in the LSIF format. Relative paths in the index are resolved against root unless the
+// index names a project root.
+func Load(path, root string) (*Index, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %v", err)
+	}
+	idx := &Index{
+		Path:      path,
+		ReadFile:  os.ReadFile,
+		documents: make(map[string]*document),
+		symbols:   make(map[string]*symbol),
+	}
+	if strings.HasSuffix(strings.ToLower(path), ".scip") {
+		err = idx.loadSCIP(content, root)
+	} else {
+		err = idx.loadLSIF(content, root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index %s: %v", path, err)
+	}
+	for _, doc := range idx.documents {
+		sort.SliceStable(doc.occurrences, func(i, j int) bool {
+			return comparePositions(doc.occurrences[i].rng.Start, doc.occurrences[j].rng.Start) < 0
+		})
+	}
+	return idx, nil
+}
+
+// Stats returns the number of documents and symbols in the index
+func (idx *Index) Stats() (int, int) {
+	return len(idx.documents), len(idx.symbols)
+}
+
+// document returns the document of a path, creating it
+func (idx *Index) document(path string) *document {
+	path = filepath.Clean(path)
+	doc, ok := idx.documents[path]
+	if !ok {
+		doc = &document{path: path}
+		idx.documents[path] = doc
+	}
+	return doc
+}
+
+// symbol returns the symbol with a key, creating it
+func (idx *Index) symbol(key string) *symbol {
+	sym, ok := idx.symbols[key]
+	if !ok {
+		sym = &symbol{}
+		idx.symbols[key] = sym
+	}
+	return sym
+}
+
+// at returns the symbol of the innermost occurrence containing a position of a file.
+// The position's column is in encoding.
+func (idx *Index) at(path string, pos protocol.Position, encoding protocol.PositionEncodingKind) *symbol {
+	doc, ok := idx.documents[filepath.Clean(path)]
+	if !ok {
+		return nil
+	}
+	if doc.columnEncoding() != encoding {
+		pos = idx.toIndexColumns(doc, pos, encoding)
+	}
+	var found *occurrence
+	for i := range doc.occurrences {
+		occ := &doc.occurrences[i]
+		if comparePositions(occ.rng.Start, pos) > 0 {
+			break
+		}
+		if comparePositions(pos, occ.rng.End) <= 0 && (found == nil || comparePositions(occ.rng.Start, found.rng.Start) >= 0) {
+			found = occ
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	return idx.symbols[found.symbol]
+}
+
+// Definitions returns where the symbol at a position of a file is defined. Columns, of
+// the position and of the locations, are in encoding, the position encoding of the
+// language server, as with the other queries.
+func (idx *Index) Definitions(path string, pos protocol.Position, encoding protocol.PositionEncodingKind) []protocol.Location {
+	sym := idx.at(path, pos, encoding)
+	if sym == nil {
+		return nil
+	}
+	return idx.locations(sym.definitions, encoding)
+}
+
+// References returns where the symbol at a position of a file is referenced, with its
+// definitions if includeDeclaration is set
+func (idx *Index) References(path string, pos protocol.Position, includeDeclaration bool, encoding protocol.PositionEncodingKind) []protocol.Location {
+	sym := idx.at(path, pos, encoding)
+	if sym == nil {
+		return nil
+	}
+	var locs []location
+	for _, ref := range sym.references {
+		if includeDeclaration || !ref.in(sym.definitions) {
+			locs = append(locs, ref)
+		}
+	}
+	if includeDeclaration {
+		for _, def := range sym.definitions {
+			if !def.in(sym.references) {
+				locs = append(locs, def)
+			}
+		}
+	}
+	return idx.locations(locs, encoding)
+}
+
+// Hover returns the documentation of the symbol at a position of a file, as Markdown
+func (idx *Index) Hover(path string, pos protocol.Position, encoding protocol.PositionEncodingKind) string {
+	sym := idx.at(path, pos, encoding)
+	if sym == nil {
+		return ""
+	}
+	return sym.hover
+}
+
+// Lookup returns the definitions of the symbols whose names match, as matches reports
+// for a symbol's name and container
+func (idx *Index) Lookup(matches func(name, container string) bool, encoding protocol.PositionEncodingKind) []protocol.Location {
+	var locs []location
+	for _, sym := range idx.symbols {
+		if sym.name == "" || len(sym.definitions) == 0 {
+			continue
+		}
+		container, name := "", sym.name
+		if i := strings.LastIndex(sym.name, "."); i >= 0 {
+			container, name = sym.name[:i], sym.name[i+1:]
+		}
+		if matches(name, container) {
+			locs = append(locs, sym.definitions...)
+		}
+	}
+	return idx.locations(locs, encoding)
+}
+
+func (l location) in(locs []location) bool {
+	for _, other := range locs {
+		if other.doc == l.doc && other.rng == l.rng {
+			return true
+		}
+	}
+	return false
+}
+
+// locations converts locations to LSP locations with columns in encoding, ordered by path
+// and position
+func (idx *Index) locations(locs []location, encoding protocol.PositionEncodingKind) []protocol.Location {
+	result := make([]protocol.Location, 0, len(locs))
+	for _, l := range locs {
+		rng := l.rng
+		if l.doc.columnEncoding() != encoding {
+			rng = idx.toLSPRange(l.doc, rng, encoding)
+		}
+		result = append(result, protocol.Location{URI: protocol.URIFromPath(l.doc.path), Range: rng})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].URI != result[j].URI {
+			return result[i].URI < result[j].URI
+		}
+		return comparePositions(result[i].Range.Start, result[j].Range.Start) < 0
+	})
+	return result
+}
+
+// lines returns the lines of a document's file, or nil if it can't be read
+func (idx *Index) lines(doc *document) []string {
+	content, err := idx.ReadFile(doc.path)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(content), "\n")
+}
+
+// columnEncoding returns the unit the columns of a document count
+func (doc *document) columnEncoding() protocol.PositionEncodingKind {
+	if doc.encoding == "" {
+		return protocol.UTF16
+	}
+	return doc.encoding
+}
+
+// toLSPRange converts a range with the document's columns to encoding
+func (idx *Index) toLSPRange(doc *document, rng protocol.Range, encoding protocol.PositionEncodingKind) protocol.Range {
+	lines := idx.lines(doc)
+	convert := func(p protocol.Position) protocol.Position {
+		if int(p.Line) < len(lines) {
+			p.Character = protocol.ConvertCharacter(lines[p.Line], p.Character, doc.columnEncoding(), encoding)
+		}
+		return p
+	}
+	return protocol.Range{Start: convert(rng.Start), End: convert(rng.End)}
+}
+
+// toIndexColumns converts a position with columns in encoding to the document's columns
+func (idx *Index) toIndexColumns(doc *document, pos protocol.Position, encoding protocol.PositionEncodingKind) protocol.Position {
+	lines := idx.lines(doc)
+	if int(pos.Line) < len(lines) && !isASCII(lines[pos.Line]) {
+		pos.Character = protocol.ConvertCharacter(lines[pos.Line], pos.Character, encoding, doc.columnEncoding())
+	}
+	return pos
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func comparePositions(a, b protocol.Position) int {
+	if a.Line != b.Line {
+		if a.Line < b.Line {
+			return -1
+		}
+		return 1
+	}
+	if a.Character != b.Character {
+		if a.Character < b.Character {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,301 @@
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// lsifID is the id of an LSIF vertex, which may be a number or a string
+type lsifID string
+
+func (id *lsifID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = lsifID(s)
+		return nil
+	}
+	*id = lsifID(strings.TrimSpace(string(data)))
+	return nil
+}
+
+// lsifEntry is a vertex or an edge of an LSIF dump, with the fields of the vertices and
+// edges read
+type lsifEntry struct {
+	ID    lsifID `json:"id"`
+	Type  string `json:"type"`
+	Label string `json:"label"`
+
+	// metaData
+	ProjectRoot string `json:"projectRoot"`
+	// document
+	URI string `json:"uri"`
+	// range
+	Start *protocol.Position `json:"start"`
+	End   *protocol.Position `json:"end"`
+	// hoverResult
+	Result *struct {
+		Contents json.RawMessage `json:"contents"`
+	} `json:"result"`
+	// moniker
+	Identifier string `json:"identifier"`
+	Kind       string `json:"kind"`
+
+	// edges
+	OutV     lsifID   `json:"outV"`
+	InV      lsifID   `json:"inV"`
+	InVs     []lsifID `json:"inVs"`
+	Document lsifID   `json:"document"`
+	Property string   `json:"property"`
+}
+
+// lsifGraph holds the vertices and edges of an LSIF dump
+type lsifGraph struct {
+	root      string
+	documents map[lsifID]string
+	ranges    map[lsifID]protocol.Range
+	// rangeDocuments are the documents containing each range
+	rangeDocuments map[lsifID]lsifID
+	hovers         map[lsifID]string
+	monikers       map[lsifID]string
+	next           map[lsifID]lsifID
+	// results map vertices to their results by edge label
+	results map[string]map[lsifID]lsifID
+	// items are the ranges and reference results of definition and reference results
+	items map[lsifID][]lsifID
+}
+
+// loadLSIF reads an index in the LSIF format, as JSON lines or a JSON array
+func (idx *Index) loadLSIF(content []byte, root string) error {
+	g := &lsifGraph{
+		root:           root,
+		documents:      make(map[lsifID]string),
+		ranges:         make(map[lsifID]protocol.Range),
+		rangeDocuments: make(map[lsifID]lsifID),
+		hovers:         make(map[lsifID]string),
+		monikers:       make(map[lsifID]string),
+		next:           make(map[lsifID]lsifID),
+		results:        make(map[string]map[lsifID]lsifID),
+		items:          make(map[lsifID][]lsifID),
+	}
+
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []lsifEntry
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return fmt.Errorf("invalid LSIF: %v", err)
+		}
+		for i := range entries {
+			g.add(&entries[i])
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			var entry lsifEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				return fmt.Errorf("invalid LSIF on line %d: %v", line, err)
+			}
+			g.add(&entry)
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	if len(g.documents) == 0 {
+		return fmt.Errorf("no documents in LSIF index")
+	}
+	g.build(idx)
+	return nil
+}
+
+func (g *lsifGraph) add(e *lsifEntry) {
+	if e.Type == "vertex" {
+		switch e.Label {
+		case "metaData":
+			if projectRoot := fileURIPath(e.ProjectRoot); projectRoot != "" {
+				g.root = projectRoot
+			}
+		case "document":
+			g.documents[e.ID] = e.URI
+		case "range":
+			if e.Start != nil && e.End != nil {
+				g.ranges[e.ID] = protocol.Range{Start: *e.Start, End: *e.End}
+			}
+		case "hoverResult":
+			if e.Result != nil {
+				g.hovers[e.ID] = hoverText(e.Result.Contents)
+			}
+		case "moniker":
+			// Local monikers only name a symbol within its document
+			if e.Kind != "local" {
+				g.monikers[e.ID] = e.Identifier
+			}
+		}
+		return
+	}
+
+	switch e.Label {
+	case "contains":
+		for _, in := range e.InVs {
+			g.rangeDocuments[in] = e.OutV
+		}
+	case "next":
+		g.next[e.OutV] = e.InV
+	case "item":
+		g.items[e.OutV] = append(g.items[e.OutV], e.InVs...)
+		for _, in := range e.InVs {
+			if _, ok := g.rangeDocuments[in]; !ok && e.Document != "" {
+				g.rangeDocuments[in] = e.Document
+			}
+		}
+	case "textDocument/definition", "textDocument/references", "textDocument/hover", "moniker":
+		if g.results[e.Label] == nil {
+			g.results[e.Label] = make(map[lsifID]lsifID)
+		}
+		g.results[e.Label][e.OutV] = e.InV
+	}
+}
+
+// result follows the next edges from a vertex until one has a result of a kind
+func (g *lsifGraph) result(id lsifID, label string) (lsifID, bool) {
+	for seen := 0; seen < 100; seen++ {
+		if result, ok := g.results[label][id]; ok {
+			return result, true
+		}
+		next, ok := g.next[id]
+		if !ok {
+			break
+		}
+		id = next
+	}
+	return "", false
+}
+
+// final returns the last vertex of the next edges from a vertex, which identifies its
+// symbol
+func (g *lsifGraph) final(id lsifID) lsifID {
+	for seen := 0; seen < 100; seen++ {
+		next, ok := g.next[id]
+		if !ok {
+			break
+		}
+		id = next
+	}
+	return id
+}
+
+// build adds the documents and symbols of the graph to an index
+func (g *lsifGraph) build(idx *Index) {
+	docs := make(map[lsifID]*document, len(g.documents))
+	for id, uri := range g.documents {
+		path := fileURIPath(uri)
+		if path == "" {
+			path = filepath.Join(g.root, filepath.FromSlash(uri))
+		}
+		docs[id] = idx.document(path)
+	}
+	locationOf := func(rangeID lsifID) (location, bool) {
+		rng, ok := g.ranges[rangeID]
+		doc := docs[g.rangeDocuments[rangeID]]
+		return location{doc: doc, rng: rng}, ok && doc != nil
+	}
+
+	built := make(map[lsifID]bool)
+	for rangeID, docID := range g.rangeDocuments {
+		loc, ok := locationOf(rangeID)
+		if !ok || g.documents[docID] == "" {
+			continue
+		}
+		final := g.final(rangeID)
+		key := "lsif:" + string(final)
+		sym := idx.symbol(key)
+
+		if !built[final] {
+			built[final] = true
+			if result, ok := g.result(rangeID, "textDocument/definition"); ok {
+				for _, item := range g.items[result] {
+					if def, ok := locationOf(item); ok {
+						sym.definitions = append(sym.definitions, def)
+					}
+				}
+			}
+			if result, ok := g.result(rangeID, "textDocument/references"); ok {
+				sym.references = g.referenceItems(result, locationOf, map[lsifID]bool{})
+			}
+			if result, ok := g.result(rangeID, "textDocument/hover"); ok {
+				sym.hover = g.hovers[result]
+			}
+			if result, ok := g.result(rangeID, "moniker"); ok {
+				identifier := g.monikers[result]
+				// Identifiers are qualified with their package, as in "pkg/path:Server.Start"
+				sym.name = identifier[strings.LastIndex(identifier, ":")+1:]
+			}
+		}
+
+		loc.doc.occurrences = append(loc.doc.occurrences, occurrence{
+			rng:        loc.rng,
+			symbol:     key,
+			definition: loc.in(sym.definitions),
+		})
+	}
+}
+
+// referenceItems returns the ranges of a reference result, including those of the
+// reference results it links to
+func (g *lsifGraph) referenceItems(result lsifID, locationOf func(lsifID) (location, bool), seen map[lsifID]bool) []location {
+	if seen[result] {
+		return nil
+	}
+	seen[result] = true
+	var locs []location
+	for _, item := range g.items[result] {
+		if loc, ok := locationOf(item); ok {
+			locs = append(locs, loc)
+		} else if len(g.items[item]) > 0 {
+			locs = append(locs, g.referenceItems(item, locationOf, seen)...)
+		}
+	}
+	return locs
+}
+
+// hoverText converts the contents of a hover result, which may be markup content, a
+// marked string or a list of marked strings, to Markdown
+func hoverText(contents json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(contents, &text); err == nil {
+		return text
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(contents, &list); err == nil {
+		var parts []string
+		for _, item := range list {
+			if part := hoverText(item); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	}
+	var markup struct {
+		Kind     string `json:"kind"`
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	}
+	if err := json.Unmarshal(contents, &markup); err != nil {
+		return ""
+	}
+	if markup.Language != "" {
+		return "```" + markup.Language + "\n" + markup.Value + "\n```"
+	}
+	return markup.Value
+}
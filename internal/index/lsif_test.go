@@ -0,0 +1,70 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadLSIF(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "main.go")
+	uri := string(protocol.URIFromPath(source))
+
+	dump := strings.Join([]string{
+		`{"id":1,"type":"vertex","label":"metaData","version":"0.6.0","projectRoot":"` + string(protocol.URIFromPath(root)) + `"}`,
+		`{"id":2,"type":"vertex","label":"document","uri":"` + uri + `","languageId":"go"}`,
+		`{"id":3,"type":"vertex","label":"resultSet"}`,
+		`{"id":4,"type":"vertex","label":"range","start":{"line":2,"character":5},"end":{"line":2,"character":10}}`,
+		`{"id":5,"type":"vertex","label":"range","start":{"line":6,"character":1},"end":{"line":6,"character":6}}`,
+		`{"id":6,"type":"edge","label":"contains","outV":2,"inVs":[4,5]}`,
+		`{"id":7,"type":"edge","label":"next","outV":4,"inV":3}`,
+		`{"id":8,"type":"edge","label":"next","outV":5,"inV":3}`,
+		`{"id":9,"type":"vertex","label":"definitionResult"}`,
+		`{"id":10,"type":"edge","label":"textDocument/definition","outV":3,"inV":9}`,
+		`{"id":11,"type":"edge","label":"item","outV":9,"inVs":[4],"document":2}`,
+		`{"id":12,"type":"vertex","label":"referenceResult"}`,
+		`{"id":13,"type":"edge","label":"textDocument/references","outV":3,"inV":12}`,
+		`{"id":14,"type":"edge","label":"item","outV":12,"inVs":[4],"document":2,"property":"definitions"}`,
+		`{"id":15,"type":"edge","label":"item","outV":12,"inVs":[5],"document":2,"property":"references"}`,
+		`{"id":16,"type":"vertex","label":"hoverResult","result":{"contents":[{"language":"go","value":"func Start()"},"Start runs the server"]}}`,
+		`{"id":17,"type":"edge","label":"textDocument/hover","outV":3,"inV":16}`,
+		`{"id":18,"type":"vertex","label":"moniker","scheme":"gomod","identifier":"example.com/m:Server.Start","kind":"export"}`,
+		`{"id":19,"type":"edge","label":"moniker","outV":3,"inV":18}`,
+	}, "\n")
+	path := filepath.Join(t.TempDir(), "dump.lsif")
+	require.NoError(t, os.WriteFile(path, []byte(dump), 0644))
+
+	idx, err := Load(path, root)
+	require.NoError(t, err)
+
+	definition := protocol.Location{URI: protocol.DocumentUri(uri), Range: protocol.Range{
+		Start: protocol.Position{Line: 2, Character: 5},
+		End:   protocol.Position{Line: 2, Character: 10},
+	}}
+	reference := protocol.Location{URI: protocol.DocumentUri(uri), Range: protocol.Range{
+		Start: protocol.Position{Line: 6, Character: 1},
+		End:   protocol.Position{Line: 6, Character: 6},
+	}}
+	use := protocol.Position{Line: 6, Character: 3}
+	assert.Equal(t, []protocol.Location{definition}, idx.Definitions(source, use, protocol.UTF16))
+	assert.Equal(t, []protocol.Location{reference}, idx.References(source, use, false, protocol.UTF16))
+	assert.Equal(t, []protocol.Location{definition, reference}, idx.References(source, use, true, protocol.UTF16))
+	assert.Equal(t, "```go\nfunc Start()\n```\n\nStart runs the server", idx.Hover(source, use, protocol.UTF16))
+
+	assert.Equal(t, []protocol.Location{definition}, idx.Lookup(func(name, container string) bool {
+		return name == "Start" && container == "Server"
+	}, protocol.UTF16))
+}
+
+func TestLoadLSIFInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.lsif")
+	require.NoError(t, os.WriteFile(path, []byte("{\"id\":1,\"type\":\"vertex\"\n"), 0644))
+	_, err := Load(path, t.TempDir())
+	assert.ErrorContains(t, err, "line 1")
+}
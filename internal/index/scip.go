@@ -0,0 +1,385 @@
+package index
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Field numbers of the SCIP messages read and written, from scip.proto
+const (
+	scipIndexMetadata  = 1
+	scipIndexDocuments = 2
+
+	scipMetadataToolInfo     = 2
+	scipMetadataProjectRoot  = 3
+	scipMetadataTextEncoding = 4
+
+	scipToolName    = 1
+	scipToolVersion = 2
+
+	scipDocumentRelativePath     = 1
+	scipDocumentOccurrences      = 2
+	scipDocumentSymbols          = 3
+	scipDocumentLanguage         = 4
+	scipDocumentPositionEncoding = 6
+
+	scipOccurrenceRange       = 1
+	scipOccurrenceSymbol      = 2
+	scipOccurrenceSymbolRoles = 3
+
+	scipSymbolSymbol        = 1
+	scipSymbolDocumentation = 3
+	scipSymbolDisplayName   = 6
+
+	// The Definition bit of an occurrence's symbol roles
+	scipRoleDefinition = 0x1
+	// The UTF8CodeUnitOffsetFromLineStart, UTF16CodeUnitOffsetFromLineStart and
+	// UTF32CodeUnitOffsetFromLineStart position encodings, and the UTF8 text encoding
+	scipEncodingUTF8  = 1
+	scipEncodingUTF16 = 2
+	scipEncodingUTF32 = 3
+)
+
+// scipEncodings maps SCIP position encodings to LSP ones
+var scipEncodings = map[uint64]protocol.PositionEncodingKind{
+	scipEncodingUTF8:  protocol.UTF8,
+	scipEncodingUTF16: protocol.UTF16,
+	scipEncodingUTF32: protocol.UTF32,
+}
+
+// loadSCIP reads an index in the SCIP protobuf format
+func (idx *Index) loadSCIP(content []byte, root string) error {
+	var documents [][]byte
+	err := protoFields(content, func(field int, value []byte, _ uint64) error {
+		switch field {
+		case scipIndexMetadata:
+			return protoFields(value, func(field int, value []byte, _ uint64) error {
+				if field == scipMetadataProjectRoot {
+					if projectRoot := fileURIPath(string(value)); projectRoot != "" {
+						root = projectRoot
+					}
+				}
+				return nil
+			})
+		case scipIndexDocuments:
+			documents = append(documents, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Documents are read once the project root, which may come last, is known
+	for _, data := range documents {
+		if err := idx.loadSCIPDocument(data, root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (idx *Index) loadSCIPDocument(data []byte, root string) error {
+	var relativePath string
+	var occurrences, symbols [][]byte
+	encoding := uint64(0)
+	err := protoFields(data, func(field int, value []byte, number uint64) error {
+		switch field {
+		case scipDocumentRelativePath:
+			relativePath = string(value)
+		case scipDocumentOccurrences:
+			occurrences = append(occurrences, value)
+		case scipDocumentSymbols:
+			symbols = append(symbols, value)
+		case scipDocumentPositionEncoding:
+			encoding = number
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if relativePath == "" {
+		return errors.New("document without a path")
+	}
+	doc := idx.document(filepath.Join(root, filepath.FromSlash(relativePath)))
+	doc.encoding = scipEncodings[encoding]
+
+	for _, data := range symbols {
+		var key, displayName string
+		var documentation []string
+		err := protoFields(data, func(field int, value []byte, _ uint64) error {
+			switch field {
+			case scipSymbolSymbol:
+				key = string(value)
+			case scipSymbolDocumentation:
+				documentation = append(documentation, string(value))
+			case scipSymbolDisplayName:
+				displayName = string(value)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		key = scipSymbolKey(doc, key)
+		sym := idx.symbol(key)
+		sym.hover = strings.Join(documentation, "\n\n---\n\n")
+		if sym.name = scipSymbolName(key); sym.name == "" {
+			sym.name = displayName
+		}
+	}
+
+	for _, data := range occurrences {
+		var key string
+		var rng []uint64
+		var roles uint64
+		err := protoFields(data, func(field int, value []byte, number uint64) error {
+			switch field {
+			case scipOccurrenceRange:
+				if value == nil {
+					rng = append(rng, number)
+					return nil
+				}
+				// Packed repeated int32
+				for len(value) > 0 {
+					n, size := binary.Uvarint(value)
+					if size <= 0 {
+						return errors.New("invalid occurrence range")
+					}
+					rng = append(rng, n)
+					value = value[size:]
+				}
+			case scipOccurrenceSymbol:
+				key = string(value)
+			case scipOccurrenceSymbolRoles:
+				roles = number
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		occ, ok := scipOccurrence(rng)
+		if !ok || key == "" {
+			continue
+		}
+		key = scipSymbolKey(doc, key)
+		occ.symbol = key
+		occ.definition = roles&scipRoleDefinition != 0
+		doc.occurrences = append(doc.occurrences, occ)
+
+		sym := idx.symbol(key)
+		loc := location{doc: doc, rng: occ.rng}
+		sym.references = append(sym.references, loc)
+		if occ.definition {
+			sym.definitions = append(sym.definitions, loc)
+		}
+		if sym.name == "" {
+			sym.name = scipSymbolName(key)
+		}
+	}
+	return nil
+}
+
+// scipOccurrence reads the range of an occurrence: [line, start, end] on one line or
+// [startLine, start, endLine, end]
+func scipOccurrence(rng []uint64) (occurrence, bool) {
+	switch len(rng) {
+	case 3:
+		return occurrence{rng: protocol.Range{
+			Start: protocol.Position{Line: uint32(rng[0]), Character: uint32(rng[1])},
+			End:   protocol.Position{Line: uint32(rng[0]), Character: uint32(rng[2])},
+		}}, true
+	case 4:
+		return occurrence{rng: protocol.Range{
+			Start: protocol.Position{Line: uint32(rng[0]), Character: uint32(rng[1])},
+			End:   protocol.Position{Line: uint32(rng[2]), Character: uint32(rng[3])},
+		}}, true
+	default:
+		return occurrence{}, false
+	}
+}
+
+// scipSymbolKey keys local symbols, which are only unique within their document, by
+// document
+func scipSymbolKey(doc *document, symbol string) string {
+	if strings.HasPrefix(symbol, "local ") {
+		return doc.path + "#" + symbol
+	}
+	return symbol
+}
+
+// scipSymbolName reads the qualified name of a global SCIP symbol from its descriptors,
+// such as "Server.Start" for "scip-go gomod example.com/m v1 `example.com/m/pkg`/Server#Start().".
+// Namespaces, parameters and type parameters are left out.
+func scipSymbolName(symbol string) string {
+	// The scheme, package manager, package name and version come first
+	parts := 0
+	i := 0
+	for i < len(symbol) && parts < 4 {
+		if symbol[i] == ' ' {
+			if i+1 < len(symbol) && symbol[i+1] == ' ' {
+				// An escaped space
+				i += 2
+				continue
+			}
+			parts++
+		}
+		i++
+	}
+	if parts < 4 {
+		return ""
+	}
+
+	var names []string
+	rest := symbol[i:]
+	for rest != "" {
+		var name string
+		switch rest[0] {
+		case '(', '[':
+			// A parameter or type parameter
+			closing := byte(')')
+			if rest[0] == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(rest, closing)
+			if end < 0 {
+				return strings.Join(names, ".")
+			}
+			rest = rest[end+1:]
+			continue
+		case '`':
+			end := 1
+			for end < len(rest) {
+				if rest[end] == '`' {
+					if end+1 < len(rest) && rest[end+1] == '`' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			name = strings.ReplaceAll(rest[1:min(end, len(rest))], "``", "`")
+			rest = rest[min(end+1, len(rest)):]
+		default:
+			end := 0
+			for end < len(rest) && !strings.ContainsRune("/#.:!([`", rune(rest[end])) {
+				end++
+			}
+			name = rest[:end]
+			rest = rest[end:]
+		}
+		if rest == "" {
+			break
+		}
+		suffix := rest[0]
+		if suffix == '(' {
+			// A method's disambiguator, followed by a dot
+			if end := strings.IndexByte(rest, ')'); end >= 0 {
+				rest = rest[end+1:]
+			}
+			suffix = '.'
+		}
+		rest = strings.TrimPrefix(rest, string(suffix))
+		if suffix != '/' && name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+// fileURIPath returns the path of a file:// URI, or "" for other URIs
+func fileURIPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return protocol.DocumentUri(uri).Path()
+}
+
+// protoFields calls fn with each field of a protobuf message: length-delimited values
+// are passed as bytes, and varints as numbers with a nil value
+func protoFields(data []byte, fn func(field int, value []byte, number uint64) error) error {
+	for len(data) > 0 {
+		key, size := binary.Uvarint(data)
+		if size <= 0 {
+			return errors.New("invalid protobuf field key")
+		}
+		data = data[size:]
+		field, wireType := int(key>>3), key&7
+
+		switch wireType {
+		case 0: // varint
+			n, size := binary.Uvarint(data)
+			if size <= 0 {
+				return errors.New("invalid protobuf varint")
+			}
+			data = data[size:]
+			if err := fn(field, nil, n); err != nil {
+				return err
+			}
+		case 1: // 64-bit
+			if len(data) < 8 {
+				return errors.New("truncated protobuf field")
+			}
+			data = data[8:]
+		case 2: // length-delimited
+			n, size := binary.Uvarint(data)
+			if size <= 0 || uint64(len(data)-size) < n {
+				return errors.New("truncated protobuf field")
+			}
+			value := data[size : size+int(n)]
+			data = data[size+int(n):]
+			if err := fn(field, value, 0); err != nil {
+				return err
+			}
+		case 5: // 32-bit
+			if len(data) < 4 {
+				return errors.New("truncated protobuf field")
+			}
+			data = data[4:]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", wireType)
+		}
+	}
+	return nil
+}
+
+// protoMessage encodes the fields of a protobuf message
+type protoMessage []byte
+
+func (m protoMessage) bytes(field int, value []byte) protoMessage {
+	m = binary.AppendUvarint(m, uint64(field<<3|2))
+	m = binary.AppendUvarint(m, uint64(len(value)))
+	return append(m, value...)
+}
+
+func (m protoMessage) string(field int, value string) protoMessage {
+	if value == "" {
+		return m
+	}
+	return m.bytes(field, []byte(value))
+}
+
+func (m protoMessage) varint(field int, value uint64) protoMessage {
+	if value == 0 {
+		return m
+	}
+	m = binary.AppendUvarint(m, uint64(field<<3))
+	return binary.AppendUvarint(m, value)
+}
+
+func (m protoMessage) packed(field int, values ...uint64) protoMessage {
+	var packed []byte
+	for _, v := range values {
+		packed = binary.AppendUvarint(packed, v)
+	}
+	return m.bytes(field, packed)
+}
@@ -0,0 +1,132 @@
+package index
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const scipSource = "package shapes\n\n// Área is…\nfunc Área() int { return 1 }\n\nvar x = Área()\n"
+
+func TestLoadSCIP(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "shapes.go"), []byte(scipSource), 0644))
+
+	const fn = "scip-go gomod example.com/shapes v1 `example.com/shapes`/Área()."
+	document := protoMessage(nil).
+		bytes(scipDocumentRelativePath, []byte("shapes.go")).
+		varint(scipDocumentPositionEncoding, scipEncodingUTF8).
+		bytes(scipDocumentOccurrences, protoMessage(nil).
+			packed(scipOccurrenceRange, 3, 5, 10).
+			bytes(scipOccurrenceSymbol, []byte(fn)).
+			varint(scipOccurrenceSymbolRoles, scipRoleDefinition)).
+		bytes(scipDocumentOccurrences, protoMessage(nil).
+			packed(scipOccurrenceRange, 5, 8, 13).
+			bytes(scipOccurrenceSymbol, []byte(fn))).
+		bytes(scipDocumentOccurrences, protoMessage(nil).
+			packed(scipOccurrenceRange, 5, 4, 5).
+			bytes(scipOccurrenceSymbol, []byte("local 0")).
+			varint(scipOccurrenceSymbolRoles, scipRoleDefinition)).
+		bytes(scipDocumentSymbols, protoMessage(nil).
+			bytes(scipSymbolSymbol, []byte(fn)).
+			bytes(scipSymbolDocumentation, []byte("```go\nfunc Área() int\n```")).
+			bytes(scipSymbolDocumentation, []byte("Área is…")))
+	content := protoMessage(nil).
+		bytes(scipIndexDocuments, document).
+		bytes(scipIndexMetadata, protoMessage(nil).bytes(scipMetadataProjectRoot, []byte(string(protocol.URIFromPath(root)))))
+
+	path := filepath.Join(t.TempDir(), "index.scip")
+	require.NoError(t, os.WriteFile(path, content, 0644))
+	idx, err := Load(path, "/elsewhere")
+	require.NoError(t, err)
+	documents, symbols := idx.Stats()
+	assert.Equal(t, 1, documents)
+	assert.Equal(t, 2, symbols)
+
+	source := filepath.Join(root, "shapes.go")
+	uri := protocol.URIFromPath(source)
+	// "Área" is 5 bytes but 4 UTF-16 code units long
+	use := protocol.Position{Line: 5, Character: 9}
+	definition := protocol.Location{URI: uri, Range: protocol.Range{
+		Start: protocol.Position{Line: 3, Character: 5},
+		End:   protocol.Position{Line: 3, Character: 9},
+	}}
+	reference := protocol.Location{URI: uri, Range: protocol.Range{
+		Start: protocol.Position{Line: 5, Character: 8},
+		End:   protocol.Position{Line: 5, Character: 12},
+	}}
+	assert.Equal(t, []protocol.Location{definition}, idx.Definitions(source, use, protocol.UTF16))
+	assert.Equal(t, []protocol.Location{reference}, idx.References(source, use, false, protocol.UTF16))
+	assert.Equal(t, []protocol.Location{definition, reference}, idx.References(source, use, true, protocol.UTF16))
+	assert.Contains(t, idx.Hover(source, use, protocol.UTF16), "Área is…")
+	assert.Empty(t, idx.Definitions(source, protocol.Position{Line: 0, Character: 0}, protocol.UTF16))
+
+	assert.Equal(t, []protocol.Location{definition}, idx.Lookup(func(name, container string) bool {
+		return name == "Área" && container == ""
+	}, protocol.UTF16))
+}
+
+func TestSCIPSymbolName(t *testing.T) {
+	for symbol, name := range map[string]string{
+		"scip-go gomod example.com/m v1 `example.com/m/pkg`/Server#Start().":  "Server.Start",
+		"scip-go gomod example.com/m v1 `example.com/m/pkg`/Server#handlers.": "Server.handlers",
+		"scip-typescript npm pkg 1.0 src/`shapes.ts`/Shape#area().":           "Shape.area",
+		"scip-java maven g a 1 com/example/Box#get(+1).":                      "Box.get",
+		"scip-python python pkg 0.1 mod/helper().(arg)":                       "helper",
+		"local 3": "",
+	} {
+		assert.Equal(t, name, scipSymbolName(symbol), symbol)
+	}
+}
+
+func TestProtoFieldsTruncated(t *testing.T) {
+	content := protoMessage(nil).bytes(scipIndexDocuments, []byte("document"))
+	assert.Error(t, protoFields(content[:len(content)-2], func(int, []byte, uint64) error { return nil }))
+}
+
+func TestWriteSCIP(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "pkg"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pkg", "server.go"), []byte("package pkg\n\nfunc (s *Server) Start() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() { s.Start() }\n"), 0644))
+
+	start := FormatSCIPSymbol("lsp", "pkg/server.go", []SCIPDescriptor{
+		{Name: "Server", Kind: protocol.Struct},
+		{Name: "Start", Kind: protocol.Method},
+	})
+	assert.Equal(t, "lsp . . . `pkg/server.go`/Server#Start().", start)
+	assert.Equal(t, "Server.Start", scipSymbolName(start))
+	assert.Equal(t, "lsp . . . `a.py`/`my name`.get(+1).", FormatSCIPSymbol("lsp", "a.py", []SCIPDescriptor{
+		{Name: "my name", Kind: protocol.Variable},
+		{Name: "get", Kind: protocol.Function, Overload: 1},
+	}))
+
+	definition := protocol.Range{Start: protocol.Position{Line: 2, Character: 17}, End: protocol.Position{Line: 2, Character: 22}}
+	reference := protocol.Range{Start: protocol.Position{Line: 2, Character: 16}, End: protocol.Position{Line: 2, Character: 21}}
+	var buf bytes.Buffer
+	require.NoError(t, WriteSCIP(&buf, root, SCIPTool{Name: "mcp-language-server"}, []SCIPDocument{
+		{RelativePath: "pkg/server.go", Language: "go",
+			Occurrences: []SCIPOccurrence{{Range: definition, Symbol: start, Definition: true}},
+			Symbols:     []SCIPSymbol{{Symbol: start, DisplayName: "Start", Documentation: []string{"Start runs the server"}}},
+		},
+		{RelativePath: "main.go", Language: "go",
+			Occurrences: []SCIPOccurrence{{Range: reference, Symbol: start}},
+		},
+	}, protocol.UTF16))
+
+	path := filepath.Join(t.TempDir(), "index.scip")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+	idx, err := Load(path, "/elsewhere")
+	require.NoError(t, err)
+
+	main := filepath.Join(root, "main.go")
+	assert.Equal(t, []protocol.Location{{URI: protocol.URIFromPath(filepath.Join(root, "pkg", "server.go")), Range: definition}},
+		idx.Definitions(main, protocol.Position{Line: 2, Character: 18}, protocol.UTF16))
+	assert.Equal(t, "Start runs the server", idx.Hover(main, protocol.Position{Line: 2, Character: 18}, protocol.UTF16))
+	assert.Len(t, idx.Lookup(func(name, container string) bool { return name == "Start" && container == "Server" }, protocol.UTF16), 1)
+}
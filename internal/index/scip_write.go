@@ -0,0 +1,171 @@
+package index
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// SCIPDocument is a file of a SCIP index to write
+type SCIPDocument struct {
+	// RelativePath is the path of the file relative to the project root, with slashes
+	RelativePath string
+	// Language is the LSP language identifier of the file, such as "go"
+	Language string
+	// Occurrences are where symbols occur in the file, with columns in the position encoding negotiated with the language server
+	Occurrences []SCIPOccurrence
+	// Symbols are the symbols the file defines
+	Symbols []SCIPSymbol
+}
+
+// SCIPOccurrence is an occurrence of a symbol in a file
+type SCIPOccurrence struct {
+	Range      protocol.Range
+	Symbol     string
+	Definition bool
+}
+
+// SCIPSymbol is what an index tells about a symbol
+type SCIPSymbol struct {
+	Symbol      string
+	DisplayName string
+	// Documentation is Markdown, such as the symbol's hover information
+	Documentation []string
+}
+
+// SCIPTool names the tool that made an index
+type SCIPTool struct {
+	Name    string
+	Version string
+}
+
+// WriteSCIP writes the documents of the project at root as a SCIP index, ordered by path.
+// The columns of their ranges count characters in encoding.
+func WriteSCIP(w io.Writer, root string, tool SCIPTool, documents []SCIPDocument, encoding protocol.PositionEncodingKind) error {
+	metadata := protoMessage(nil).
+		bytes(scipMetadataToolInfo, protoMessage(nil).
+			string(scipToolName, tool.Name).
+			string(scipToolVersion, tool.Version)).
+		string(scipMetadataProjectRoot, string(protocol.URIFromPath(root))).
+		varint(scipMetadataTextEncoding, scipEncodingUTF8)
+	if _, err := w.Write(protoMessage(nil).bytes(scipIndexMetadata, metadata)); err != nil {
+		return err
+	}
+
+	sorted := make([]SCIPDocument, len(documents))
+	copy(sorted, documents)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].RelativePath < sorted[j].RelativePath })
+
+	// Documents are written one at a time, as consecutive values of the repeated field
+	for _, doc := range sorted {
+		if _, err := w.Write(protoMessage(nil).bytes(scipIndexDocuments, encodeSCIPDocument(doc, encoding))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeSCIPDocument(doc SCIPDocument, encoding protocol.PositionEncodingKind) protoMessage {
+	m := protoMessage(nil).
+		string(scipDocumentRelativePath, doc.RelativePath).
+		string(scipDocumentLanguage, doc.Language).
+		varint(scipDocumentPositionEncoding, scipPositionEncoding(encoding))
+
+	occurrences := make([]SCIPOccurrence, len(doc.Occurrences))
+	copy(occurrences, doc.Occurrences)
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return comparePositions(occurrences[i].Range.Start, occurrences[j].Range.Start) < 0
+	})
+	for _, occ := range occurrences {
+		r := occ.Range
+		rng := []uint64{uint64(r.Start.Line), uint64(r.Start.Character), uint64(r.End.Line), uint64(r.End.Character)}
+		if r.Start.Line == r.End.Line {
+			rng = []uint64{uint64(r.Start.Line), uint64(r.Start.Character), uint64(r.End.Character)}
+		}
+		roles := uint64(0)
+		if occ.Definition {
+			roles = scipRoleDefinition
+		}
+		m = m.bytes(scipDocumentOccurrences, protoMessage(nil).
+			packed(scipOccurrenceRange, rng...).
+			string(scipOccurrenceSymbol, occ.Symbol).
+			varint(scipOccurrenceSymbolRoles, roles))
+	}
+
+	for _, sym := range doc.Symbols {
+		info := protoMessage(nil).string(scipSymbolSymbol, sym.Symbol)
+		for _, documentation := range sym.Documentation {
+			info = info.string(scipSymbolDocumentation, documentation)
+		}
+		m = m.bytes(scipDocumentSymbols, info.string(scipSymbolDisplayName, sym.DisplayName))
+	}
+	return m
+}
+
+// SCIPDescriptor is one symbol in the chain of containers leading to a symbol declared
+// in a file
+type SCIPDescriptor struct {
+	Name string
+	Kind protocol.SymbolKind
+	// Overload tells apart functions with the same name in one container, counting from 0
+	Overload int
+}
+
+// FormatSCIPSymbol formats the global SCIP symbol of a symbol declared in a file, given
+// its containers and itself, such as "lsp . . . `pkg/server.go`/Server#Start()." for
+// the Start method of the Server type. The file's path makes the symbol unique.
+func FormatSCIPSymbol(scheme, relativePath string, path []SCIPDescriptor) string {
+	var b strings.Builder
+	b.WriteString(strings.ReplaceAll(scheme, " ", "  "))
+	// No package manager, package name or version
+	b.WriteString(" . . . ")
+	b.WriteString(scipIdentifier(relativePath))
+	b.WriteString("/")
+	for _, d := range path {
+		b.WriteString(scipIdentifier(d.Name))
+		switch d.Kind {
+		case protocol.Namespace, protocol.Module, protocol.Package:
+			b.WriteString("/")
+		case protocol.Class, protocol.Interface, protocol.Struct, protocol.Enum:
+			b.WriteString("#")
+		case protocol.Function, protocol.Method, protocol.Constructor:
+			b.WriteString("(")
+			if d.Overload > 0 {
+				b.WriteString("+" + strconv.Itoa(d.Overload))
+			}
+			b.WriteString(").")
+		default:
+			b.WriteString(".")
+		}
+	}
+	return b.String()
+}
+
+// scipIdentifier writes a name as a SCIP identifier, quoting it with backticks unless it
+// only has identifier characters
+func scipIdentifier(name string) string {
+	simple := name != ""
+	for _, r := range name {
+		if !(r == '_' || r == '+' || r == '-' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			simple = false
+			break
+		}
+	}
+	if simple {
+		return name
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// scipPositionEncoding returns the SCIP position encoding of an LSP one
+func scipPositionEncoding(kind protocol.PositionEncodingKind) uint64 {
+	for encoding, lspKind := range scipEncodings {
+		if lspKind == kind {
+			return encoding
+		}
+	}
+	return scipEncodingUTF16
+}
@@ -1,9 +1,11 @@
 package logging
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"strings"
 	"sync"
@@ -43,31 +45,76 @@ func (l LogLevel) String() string {
 	}
 }
 
-// Component represents a specific part of the application for which logs can be filtered
+// slogFatal is the slog level of fatal messages, above slog.LevelError
+const slogFatal = slog.LevelError + 4
+
+// slogLevel returns the slog level corresponding to a log level
+func (l LogLevel) slogLevel() slog.Level {
+	switch l {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	default:
+		return slogFatal
+	}
+}
+
+// ParseLevel parses a level name such as "debug" or "WARN"
+func ParseLevel(s string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO":
+		return LevelInfo, nil
+	case "WARN":
+		return LevelWarn, nil
+	case "ERROR":
+		return LevelError, nil
+	case "FATAL":
+		return LevelFatal, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+// Component represents a specific part of the application for which logs can be filtered.
+// Components are scoped with dots, and the level of a scope such as "lsp" applies to the
+// components within it, such as "lsp.client", unless they have their own.
 type Component string
 
 const (
 	// Core component for the main application
 	Core Component = "core"
+	// MCP component for the MCP transports
+	MCP Component = "mcp"
 	// LSP component for high-level Language Server Protocol operations
-	LSP Component = "lsp"
+	LSP Component = "lsp.client"
 	// LSPWire component for raw LSP wire protocol messages
-	LSPWire Component = "wire"
+	LSPWire Component = "lsp.wire"
 	// LSPProcess component for logs from the LSP server process itself
-	LSPProcess Component = "lsp-process"
+	LSPProcess Component = "lsp.process"
 	// Watcher component for file system watching
 	Watcher Component = "watcher"
 	// Tools component for LSP tools
 	Tools Component = "tools"
+	// Hooks component for commands run after mutating tools
+	Hooks Component = "hooks"
 )
 
-// DefaultMinLevel is the default minimum log level
+// DefaultMinLevel is the minimum log level of components without a level of their own
 var DefaultMinLevel = LevelInfo
 
-// ComponentLevels tracks the minimum log level for each component
+// ComponentLevels holds the minimum log level of components and scopes that override
+// DefaultMinLevel
 var ComponentLevels = map[Component]LogLevel{}
 
-// Writer is the destination for logs
+// Writer is the destination for logs. It must never be stdout, which carries the MCP
+// stdio transport.
 var Writer io.Writer = os.Stderr
 
 // TestOutput can be set during tests to capture log output
@@ -76,65 +123,29 @@ var TestOutput io.Writer
 // logMu protects concurrent modifications to logging config
 var logMu sync.Mutex
 
+// output is the slog handler writing to Writer and TestOutput
+var output slog.Handler
+
 // Initialize from environment variables
 func init() {
-	// Set default levels for each component
-	ComponentLevels[Core] = DefaultMinLevel
-	ComponentLevels[LSP] = DefaultMinLevel
-	ComponentLevels[Watcher] = DefaultMinLevel
-	ComponentLevels[Tools] = DefaultMinLevel
-	ComponentLevels[LSPProcess] = DefaultMinLevel
-	ComponentLevels[LSPWire] = DefaultMinLevel
-
 	// Parse log level from environment variable
-	if level := os.Getenv("LOG_LEVEL"); level != "" {
-		switch strings.ToUpper(level) {
-		case "DEBUG":
-			DefaultMinLevel = LevelDebug
-		case "INFO":
-			DefaultMinLevel = LevelInfo
-		case "WARN":
-			DefaultMinLevel = LevelWarn
-		case "ERROR":
-			DefaultMinLevel = LevelError
-		case "FATAL":
-			DefaultMinLevel = LevelFatal
-		}
-
-		// Set all components to this level by default
-		for comp := range ComponentLevels {
-			ComponentLevels[comp] = DefaultMinLevel
-		}
+	if level, err := ParseLevel(os.Getenv("LOG_LEVEL")); err == nil {
+		DefaultMinLevel = level
 	}
 
 	// Allow overriding levels for specific components
 	if compLevels := os.Getenv("LOG_COMPONENT_LEVELS"); compLevels != "" {
-		for _, part := range strings.SplitN(compLevels, ",", -1) {
+		for _, part := range strings.Split(compLevels, ",") {
 			compAndLevel := strings.Split(part, ":")
 			if len(compAndLevel) != 2 {
 				continue
 			}
 
-			comp := Component(strings.TrimSpace(compAndLevel[0]))
-			levelStr := strings.ToUpper(strings.TrimSpace(compAndLevel[1]))
-
-			var level LogLevel
-			switch levelStr {
-			case "DEBUG":
-				level = LevelDebug
-			case "INFO":
-				level = LevelInfo
-			case "WARN":
-				level = LevelWarn
-			case "ERROR":
-				level = LevelError
-			case "FATAL":
-				level = LevelFatal
-			default:
+			level, err := ParseLevel(compAndLevel[1])
+			if err != nil {
 				continue
 			}
-
-			ComponentLevels[comp] = level
+			ComponentLevels[Component(strings.TrimSpace(compAndLevel[0]))] = level
 		}
 	}
 
@@ -146,9 +157,99 @@ func init() {
 		}
 	}
 
-	// Configure the standard logger
-	log.SetOutput(Writer)
+	updateOutput()
+}
+
+// updateOutput points the standard logger and the slog output at the current writers.
+// The caller must hold logMu.
+func updateOutput() {
+	w := Writer
+	if TestOutput != nil {
+		w = io.MultiWriter(Writer, TestOutput)
+	}
+
+	log.SetOutput(w)
 	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
+
+	output = slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && len(groups) == 0 && a.Value.Any() == slogFatal {
+				a.Value = slog.StringValue(LevelFatal.String())
+			}
+			return a
+		},
+	})
+}
+
+// minLevel returns the minimum log level of a component: its own, or that of the
+// closest enclosing scope, or DefaultMinLevel
+func minLevel(component Component) LogLevel {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	scope := string(component)
+	for {
+		if level, ok := ComponentLevels[Component(scope)]; ok {
+			return level
+		}
+		i := strings.LastIndex(scope, ".")
+		if i < 0 {
+			return DefaultMinLevel
+		}
+		scope = scope[:i]
+	}
+}
+
+// componentHandler is a slog.Handler that filters records by the level of its component,
+// tags them with it and passes them on to the current output
+type componentHandler struct {
+	component Component
+	// with holds the WithAttrs and WithGroup calls to replay on the output, which
+	// changes when the log destination does
+	with []func(slog.Handler) slog.Handler
+}
+
+func (h *componentHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= minLevel(h.component).slogLevel()
+}
+
+func (h *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	logMu.Lock()
+	out := output
+	logMu.Unlock()
+
+	for _, with := range h.with {
+		out = with(out)
+	}
+	r.AddAttrs(slog.String("component", string(h.component)))
+	return out.Handle(ctx, r)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.withHandler(func(out slog.Handler) slog.Handler { return out.WithAttrs(attrs) })
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return h.withHandler(func(out slog.Handler) slog.Handler { return out.WithGroup(name) })
+}
+
+func (h *componentHandler) withHandler(with func(slog.Handler) slog.Handler) slog.Handler {
+	return &componentHandler{
+		component: h.component,
+		with:      append(h.with[:len(h.with):len(h.with)], with),
+	}
+}
+
+// Slog returns a slog.Logger for the specified component
+func Slog(component Component) *slog.Logger {
+	return slog.New(&componentHandler{component: component})
+}
+
+// NewStdLogger returns a standard library logger that logs each line at the given level
+// for the specified component, for libraries that take a *log.Logger
+func NewStdLogger(component Component, level LogLevel) *log.Logger {
+	return slog.NewLogLogger(&componentHandler{component: component}, level.slogLevel())
 }
 
 // Logger is the interface for component-specific logging
@@ -164,25 +265,20 @@ type Logger interface {
 // ComponentLogger is a logger for a specific component
 type ComponentLogger struct {
 	component Component
+	logger    *slog.Logger
 }
 
 // NewLogger creates a new logger for the specified component
 func NewLogger(component Component) Logger {
 	return &ComponentLogger{
 		component: component,
+		logger:    Slog(component),
 	}
 }
 
 // IsLevelEnabled returns true if the given log level is enabled for this component
 func (l *ComponentLogger) IsLevelEnabled(level LogLevel) bool {
-	logMu.Lock()
-	defer logMu.Unlock()
-
-	minLevel, ok := ComponentLevels[l.component]
-	if !ok {
-		minLevel = DefaultMinLevel
-	}
-	return level >= minLevel
+	return level >= minLevel(l.component)
 }
 
 // log logs a message at the specified level if it meets the threshold
@@ -190,20 +286,7 @@ func (l *ComponentLogger) log(level LogLevel, format string, v ...any) {
 	if !l.IsLevelEnabled(level) {
 		return
 	}
-
-	message := fmt.Sprintf(format, v...)
-	logMessage := fmt.Sprintf("[%s][%s] %s", level, l.component, message)
-
-	if err := log.Output(3, logMessage); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to output log: %v\n", err)
-	}
-
-	// Write to test output if set
-	if TestOutput != nil {
-		if _, err := fmt.Fprintln(TestOutput, logMessage); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to output log to test output: %v\n", err)
-		}
-	}
+	l.logger.Log(context.Background(), level.slogLevel(), fmt.Sprintf(format, v...))
 }
 
 // Debug logs a debug message
@@ -232,7 +315,7 @@ func (l *ComponentLogger) Fatal(format string, v ...any) {
 	os.Exit(1)
 }
 
-// SetLevel sets the minimum log level for a component
+// SetLevel sets the minimum log level for a component, or for every component in a scope
 func SetLevel(component Component, level LogLevel) {
 	logMu.Lock()
 	defer logMu.Unlock()
@@ -245,9 +328,7 @@ func SetGlobalLevel(level LogLevel) {
 	defer logMu.Unlock()
 
 	DefaultMinLevel = level
-	for comp := range ComponentLevels {
-		ComponentLevels[comp] = level
-	}
+	clear(ComponentLevels)
 }
 
 // SetWriter sets the writer for log output
@@ -256,24 +337,49 @@ func SetWriter(w io.Writer) {
 	defer logMu.Unlock()
 
 	Writer = w
-	log.SetOutput(Writer)
+	updateOutput()
 }
 
 // SetupFileLogging configures logging to a file in addition to stderr
 func SetupFileLogging(filePath string) error {
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	file, err := openLogFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
+		return err
 	}
 
-	logMu.Lock()
-	defer logMu.Unlock()
+	SetWriter(io.MultiWriter(os.Stderr, file))
+	return nil
+}
 
-	Writer = io.MultiWriter(os.Stderr, file)
-	log.SetOutput(Writer)
+// Setup configures logging from the command line. A non-empty level sets the level of
+// every component, and logs are written to filePath instead of stderr if it is given.
+func Setup(level, filePath string) error {
+	if level != "" {
+		l, err := ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		SetGlobalLevel(l)
+	}
+
+	if filePath != "" {
+		file, err := openLogFile(filePath)
+		if err != nil {
+			return err
+		}
+		SetWriter(file)
+	}
 	return nil
 }
 
+func openLogFile(filePath string) (*os.File, error) {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return file, nil
+}
+
 // SetupTestLogging configures logging for tests
 func SetupTestLogging(captureOutput io.Writer) {
 	logMu.Lock()
@@ -281,6 +387,7 @@ func SetupTestLogging(captureOutput io.Writer) {
 
 	// Set test output for capturing logs
 	TestOutput = captureOutput
+	updateOutput()
 }
 
 // ResetTestLogging resets logging after tests
@@ -289,4 +396,5 @@ func ResetTestLogging() {
 	defer logMu.Unlock()
 
 	TestOutput = nil
+	updateOutput()
 }
@@ -106,3 +106,36 @@ func TestLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestComponentScopes(t *testing.T) {
+	originalDefault := DefaultMinLevel
+	originalLevels := maps.Clone(ComponentLevels)
+	defer func() {
+		DefaultMinLevel = originalDefault
+		ComponentLevels = originalLevels
+	}()
+
+	SetGlobalLevel(LevelWarn)
+	SetLevel("lsp", LevelDebug)
+	SetLevel(LSPWire, LevelError)
+
+	if !NewLogger(LSP).IsLevelEnabled(LevelDebug) {
+		t.Errorf("Expected %s to inherit the debug level of the lsp scope", LSP)
+	}
+	if NewLogger(LSPWire).IsLevelEnabled(LevelWarn) {
+		t.Errorf("Expected the level of %s to override its scope", LSPWire)
+	}
+	if NewLogger(Tools).IsLevelEnabled(LevelInfo) {
+		t.Errorf("Expected %s to use the global level", Tools)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("debug")
+	if err != nil || level != LevelDebug {
+		t.Errorf("Expected debug level, got %v (%v)", level, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("Expected an error for an unknown level")
+	}
+}
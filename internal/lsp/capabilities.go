@@ -0,0 +1,252 @@
+package lsp
+
+import (
+	"slices"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// providerEnabled reports whether a server capability is on: providers are either a
+// boolean or options, which mean the capability is on
+func providerEnabled(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	default:
+		return true
+	}
+}
+
+// methodProviders tells, for the requests the tools rely on, whether the capabilities a
+// server announced at initialization offer them
+var methodProviders = map[string]func(protocol.ServerCapabilities) bool{
+	"textDocument/hover": func(c protocol.ServerCapabilities) bool {
+		return c.HoverProvider != nil && providerEnabled(c.HoverProvider.Value)
+	},
+	"textDocument/definition": func(c protocol.ServerCapabilities) bool {
+		return c.DefinitionProvider != nil && providerEnabled(c.DefinitionProvider.Value)
+	},
+	"textDocument/typeDefinition": func(c protocol.ServerCapabilities) bool {
+		return c.TypeDefinitionProvider != nil && providerEnabled(c.TypeDefinitionProvider.Value)
+	},
+	"textDocument/implementation": func(c protocol.ServerCapabilities) bool {
+		return c.ImplementationProvider != nil && providerEnabled(c.ImplementationProvider.Value)
+	},
+	"textDocument/references": func(c protocol.ServerCapabilities) bool {
+		return c.ReferencesProvider != nil && providerEnabled(c.ReferencesProvider.Value)
+	},
+	"textDocument/documentSymbol": func(c protocol.ServerCapabilities) bool {
+		return c.DocumentSymbolProvider != nil && providerEnabled(c.DocumentSymbolProvider.Value)
+	},
+	"workspace/symbol": func(c protocol.ServerCapabilities) bool {
+		return c.WorkspaceSymbolProvider != nil && providerEnabled(c.WorkspaceSymbolProvider.Value)
+	},
+	"textDocument/codeAction": func(c protocol.ServerCapabilities) bool {
+		return providerEnabled(c.CodeActionProvider)
+	},
+	"textDocument/rename": func(c protocol.ServerCapabilities) bool {
+		return providerEnabled(c.RenameProvider)
+	},
+	"textDocument/codeLens": func(c protocol.ServerCapabilities) bool {
+		return c.CodeLensProvider != nil
+	},
+	"textDocument/prepareCallHierarchy": func(c protocol.ServerCapabilities) bool {
+		return c.CallHierarchyProvider != nil && providerEnabled(c.CallHierarchyProvider.Value)
+	},
+	"experimental/runnables": func(c protocol.ServerCapabilities) bool {
+		return experimentalCapability(c, "runnables")
+	},
+}
+
+// serverMethods are the extensions to the protocol that servers handle without announcing
+// them in their capabilities, by the name of the server handling them
+var serverMethods = map[string]string{
+	"textDocument/switchSourceHeader": "clangd",
+}
+
+// SupportsMethod reports whether the server handles requests of a method, as announced
+// in its capabilities at initialization or registered since with
+// client/registerCapability. Methods without a capability are assumed to be supported,
+// except for the extensions in serverMethods, which only the server named there handles.
+func (c *Client) SupportsMethod(method string) bool {
+	c.capabilitiesMu.RLock()
+	defer c.capabilitiesMu.RUnlock()
+	for _, registered := range c.registrations {
+		if registered == method {
+			return true
+		}
+	}
+	if server, ok := serverMethods[method]; ok {
+		return c.serverName == server
+	}
+	provider, ok := methodProviders[method]
+	return !ok || provider(c.capabilities)
+}
+
+// saveOptions returns whether the server wants didSave notifications, announced in its
+// capabilities or registered since, and whether they include the text of the document
+func (c *Client) saveOptions() (save, includeText bool) {
+	c.capabilitiesMu.RLock()
+	defer c.capabilitiesMu.RUnlock()
+	save, includeText = saveOptions(c.capabilities)
+	for id, registered := range c.saveIncludeText {
+		if _, ok := c.registrations[id]; ok {
+			save = true
+			includeText = includeText || registered
+		}
+	}
+	return save, includeText
+}
+
+// SupportsCommand reports whether the server announced a workspace/executeCommand
+// command in its capabilities at initialization, such as gopls.run_tests
+func (c *Client) SupportsCommand(command string) bool {
+	c.capabilitiesMu.RLock()
+	defer c.capabilitiesMu.RUnlock()
+	provider := c.capabilities.ExecuteCommandProvider
+	return provider != nil && slices.Contains(provider.Commands, command)
+}
+
+// serverSchemes are the URI schemes of the documents that servers provide with custom
+// requests, see ReadURI
+var serverSchemes = []string{"jdt", "deno"}
+
+// ProvidesScheme reports whether the server provides the documents of a URI scheme, such
+// as jdt: for the classes of Java dependencies: with the custom requests of serverSchemes,
+// or with workspace/textDocumentContent, as announced in its capabilities at
+// initialization or registered since. Such documents are not local files.
+func (c *Client) ProvidesScheme(scheme string) bool {
+	if slices.Contains(serverSchemes, scheme) {
+		return true
+	}
+	if c == nil {
+		return false
+	}
+	c.capabilitiesMu.RLock()
+	defer c.capabilitiesMu.RUnlock()
+	if workspace := c.capabilities.Workspace; workspace != nil && workspace.TextDocumentContent != nil {
+		switch options := workspace.TextDocumentContent.Value.(type) {
+		case protocol.TextDocumentContentOptions:
+			if options.Scheme == scheme {
+				return true
+			}
+		case protocol.TextDocumentContentRegistrationOptions:
+			if options.Scheme == scheme {
+				return true
+			}
+		}
+	}
+	for id, registered := range c.contentSchemes {
+		if _, ok := c.registrations[id]; ok && registered == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCapabilitiesHandler sets a function called after the server registers capabilities
+// dynamically. It must be set before the client is initialized.
+func (c *Client) SetCapabilitiesHandler(handler func()) {
+	c.onCapabilities = handler
+}
+
+// addRegistrations records capabilities the server registered dynamically
+func (c *Client) addRegistrations(registrations []protocol.Registration) {
+	c.capabilitiesMu.Lock()
+	if c.registrations == nil {
+		c.registrations = make(map[string]string)
+	}
+	for _, reg := range registrations {
+		c.registrations[reg.ID] = reg.Method
+		if reg.Method == "textDocument/didSave" {
+			if c.saveIncludeText == nil {
+				c.saveIncludeText = make(map[string]bool)
+			}
+			c.saveIncludeText[reg.ID] = includeTextOption(reg.RegisterOptions)
+		}
+		if reg.Method == "workspace/textDocumentContent" {
+			if c.contentSchemes == nil {
+				c.contentSchemes = make(map[string]string)
+			}
+			options, _ := reg.RegisterOptions.(map[string]any)
+			c.contentSchemes[reg.ID], _ = options["scheme"].(string)
+		}
+	}
+	c.capabilitiesMu.Unlock()
+
+	if c.onCapabilities != nil {
+		c.onCapabilities()
+	}
+}
+
+// removeRegistrations forgets capabilities the server unregistered
+func (c *Client) removeRegistrations(unregistrations []protocol.Unregistration) {
+	var unwatched []string
+	c.capabilitiesMu.Lock()
+	for _, unreg := range unregistrations {
+		delete(c.registrations, unreg.ID)
+		delete(c.saveIncludeText, unreg.ID)
+		delete(c.contentSchemes, unreg.ID)
+		if _, ok := c.fileWatchers[unreg.ID]; ok {
+			delete(c.fileWatchers, unreg.ID)
+			unwatched = append(unwatched, unreg.ID)
+		}
+	}
+	onFileUnwatch := c.onFileUnwatch
+	c.capabilitiesMu.Unlock()
+
+	if onFileUnwatch != nil {
+		for _, id := range unwatched {
+			onFileUnwatch(id)
+		}
+	}
+	if c.onCapabilities != nil {
+		c.onCapabilities()
+	}
+}
+
+// FileWatchHandler is called when file watchers are registered by the server
+type FileWatchHandler func(id string, watchers []protocol.FileSystemWatcher)
+
+// SetFileWatchHandler sets the functions called when the server registers file watchers
+// (workspace/didChangeWatchedFiles) and unregisters them. The watchers registered before
+// are passed to onWatch right away, so none are missed however late it is set.
+func (c *Client) SetFileWatchHandler(onWatch FileWatchHandler, onUnwatch func(id string)) {
+	c.capabilitiesMu.Lock()
+	c.onFileWatch = onWatch
+	c.onFileUnwatch = onUnwatch
+	ids := make([]string, 0, len(c.fileWatchers))
+	for id := range c.fileWatchers {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	watchers := make([][]protocol.FileSystemWatcher, len(ids))
+	for i, id := range ids {
+		watchers[i] = c.fileWatchers[id]
+	}
+	c.capabilitiesMu.Unlock()
+
+	if onWatch != nil {
+		for i, id := range ids {
+			onWatch(id, watchers[i])
+		}
+	}
+}
+
+// addFileWatchers records file watchers the server registered and passes them on to the
+// file watch handler
+func (c *Client) addFileWatchers(id string, watchers []protocol.FileSystemWatcher) {
+	c.capabilitiesMu.Lock()
+	if c.fileWatchers == nil {
+		c.fileWatchers = make(map[string][]protocol.FileSystemWatcher)
+	}
+	c.fileWatchers[id] = watchers
+	onFileWatch := c.onFileWatch
+	c.capabilitiesMu.Unlock()
+
+	if onFileWatch != nil {
+		onFileWatch(id, watchers)
+	}
+}
@@ -0,0 +1,83 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupportsMethod(t *testing.T) {
+	client := &Client{capabilities: protocol.ServerCapabilities{
+		HoverProvider:  &protocol.Or_ServerCapabilities_hoverProvider{Value: true},
+		RenameProvider: false,
+	}}
+	called := false
+	client.SetCapabilitiesHandler(func() { called = true })
+
+	assert.True(t, client.SupportsMethod("textDocument/hover"))
+	assert.False(t, client.SupportsMethod("textDocument/rename"))
+	assert.False(t, client.SupportsMethod("textDocument/references"))
+	// Methods without a capability are assumed to be supported
+	assert.True(t, client.SupportsMethod("workspace/executeCommand"))
+
+	client.addRegistrations([]protocol.Registration{{ID: "1", Method: "textDocument/references"}})
+	assert.True(t, called)
+	assert.True(t, client.SupportsMethod("textDocument/references"))
+}
+
+func TestSupportsCommand(t *testing.T) {
+	client := &Client{}
+	assert.False(t, client.SupportsCommand("gopls.run_tests"))
+
+	client.capabilities.ExecuteCommandProvider = &protocol.ExecuteCommandOptions{
+		Commands: []string{"gopls.run_tests", "gopls.tidy"},
+	}
+	assert.True(t, client.SupportsCommand("gopls.run_tests"))
+	assert.False(t, client.SupportsCommand("rust-analyzer.runSingle"))
+}
+
+func TestExperimentalCapability(t *testing.T) {
+	client := &Client{}
+	assert.False(t, client.SupportsMethod("experimental/runnables"))
+
+	client.capabilities.Experimental = map[string]any{
+		"runnables": map[string]any{"kinds": []any{"cargo"}},
+		"ssr":       true,
+	}
+	assert.True(t, client.SupportsMethod("experimental/runnables"))
+}
+
+func TestServerMethods(t *testing.T) {
+	client := &Client{serverName: "gopls"}
+	assert.False(t, client.SupportsMethod("textDocument/switchSourceHeader"))
+
+	client.serverName = "clangd"
+	assert.True(t, client.SupportsMethod("textDocument/switchSourceHeader"))
+}
+
+func TestFileWatchRegistrations(t *testing.T) {
+	client := &Client{}
+	watchers := []protocol.FileSystemWatcher{{GlobPattern: protocol.GlobPattern{Value: "**/*.go"}}}
+
+	register := []byte(`{"registrations":[{"id":"w1","method":"workspace/didChangeWatchedFiles","registerOptions":{"watchers":[{"globPattern":"**/*.go"}]}}]}`)
+	_, err := HandleRegisterCapability(client, register)
+	assert.NoError(t, err)
+
+	// Watchers registered before the handler is set are passed to it
+	watched := make(map[string][]protocol.FileSystemWatcher)
+	var unwatched []string
+	client.SetFileWatchHandler(func(id string, w []protocol.FileSystemWatcher) {
+		watched[id] = w
+	}, func(id string) {
+		unwatched = append(unwatched, id)
+	})
+	assert.Equal(t, map[string][]protocol.FileSystemWatcher{"w1": watchers}, watched)
+	assert.True(t, client.SupportsMethod("workspace/didChangeWatchedFiles"))
+
+	unregister := []byte(`{"unregisterations":[{"id":"w1","method":"workspace/didChangeWatchedFiles"}]}`)
+	_, err = HandleUnregisterCapability(client, unregister)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"w1"}, unwatched)
+	assert.Empty(t, client.registrations)
+}
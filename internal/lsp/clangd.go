@@ -0,0 +1,15 @@
+package lsp
+
+import (
+	"context"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// SwitchSourceHeader sends clangd's textDocument/switchSourceHeader request, which returns
+// the header of a source file or the source file of a header, or "" if clangd finds none
+func (c *Client) SwitchSourceHeader(ctx context.Context, params protocol.TextDocumentIdentifier) (protocol.DocumentUri, error) {
+	var result protocol.DocumentUri
+	err := c.Call(ctx, "textDocument/switchSourceHeader", params, &result)
+	return result, err
+}
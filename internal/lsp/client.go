@@ -17,10 +17,19 @@ import (
 )
 
 type Client struct {
-	Cmd    *exec.Cmd
-	stdin  io.WriteCloser
-	stdout *bufio.Reader
-	stderr io.ReadCloser
+	// Cmd is the server process, nil for a server the client connected to
+	Cmd *exec.Cmd
+	// The command line starting the server, to recognize servers needing particular
+	// initialization, if known
+	command []string
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	stderr  io.ReadCloser
+	// The end of the server's stderr, see ServerLogs
+	stderrLog stderrLog
+	// Closed when the connection to the server is lost, failing the requests waiting on it
+	disconnected     chan struct{}
+	disconnectedOnce sync.Once
 
 	// Request ID counter
 	nextID atomic.Int32
@@ -28,6 +37,8 @@ type Client struct {
 	// Response handlers
 	handlers   map[string]chan *Message
 	handlersMu sync.RWMutex
+	// Bounds the requests in flight, see SetMaxConcurrentRequests
+	limiter requestLimiter
 
 	// Server request handlers
 	serverRequestHandlers map[string]ServerRequestHandler
@@ -37,19 +48,101 @@ type Client struct {
 	notificationHandlers map[string]NotificationHandler
 	notificationMu       sync.RWMutex
 
-	// Diagnostic cache
-	diagnostics   map[protocol.DocumentUri][]protocol.Diagnostic
-	diagnosticsMu sync.RWMutex
-
-	// Files are currently opened by the LSP
+	// Diagnostic cache, with how many times the diagnostics of each file were reported
+	diagnostics    map[protocol.DocumentUri][]protocol.Diagnostic
+	diagnosticsGen map[protocol.DocumentUri]uint64
+	diagnosticsMu  sync.RWMutex
+	// Called after the server publishes diagnostics for a file
+	onDiagnostics func(uri protocol.DocumentUri)
+
+	// Work done progress reported by the server
+	progress progressTracker
+	// The functions watching the workspace edits the server applies, see WatchEdits, and
+	// checking them before they are applied, see GuardEdits
+	editWatchers   map[int]func(paths []string)
+	editGuards     map[int]func(paths []string) error
+	editWatchersID int
+	editWatchersMu sync.Mutex
+
+	// Files are currently opened by the LSP, and the files being opened, by URI, with a
+	// channel closed once the call opening them is done
 	openFiles   map[string]*OpenFileInfo
+	opening     map[string]chan struct{}
 	openFilesMu sync.RWMutex
+
+	// How the server wants document changes to be sent, whether it can report the
+	// diagnostics of the whole workspace and check renames, and which file operations it
+	// wants to be told about, from its capabilities
+	syncKind             protocol.TextDocumentSyncKind
+	positionEncoding     protocol.PositionEncodingKind
+	workspaceDiagnostics bool
+	prepareRename        bool
+	fileOperations       protocol.FileOperationOptions
+
+	// The capabilities the server announced at initialization, and the methods of those
+	// it registered since by registration ID
+	capabilities   protocol.ServerCapabilities
+	registrations  map[string]string
+	capabilitiesMu sync.RWMutex
+	// The name the server gave in its serverInfo at initialization, such as "clangd"
+	serverName string
+	// Whether the didSave notifications registered since, by registration ID, include
+	// the text of the document
+	saveIncludeText map[string]bool
+	// The URI schemes the workspace/textDocumentContent registrations since provide, by
+	// registration ID
+	contentSchemes map[string]string
+	// Called after the server registers capabilities
+	onCapabilities func()
+	// The file watchers the server registered, by registration ID, and the handlers told
+	// about them, see SetFileWatchHandler
+	fileWatchers  map[string][]protocol.FileSystemWatcher
+	onFileWatch   FileWatchHandler
+	onFileUnwatch func(id string)
+
+	// Whether read-only queries open files, and for how long, see SetQueryOpenMode
+	queryOpenMode QueryOpenMode
+	queryOpenTTL  time.Duration
+	// Maximum number of open documents, see SetMaxOpenFiles
+	maxOpenFiles int
+
+	// The settings given to the server, see SetSettings
+	settings   map[string]any
+	settingsMu sync.RWMutex
+
+	// How to answer the server's message prompts, see SetMessageAction, and the function
+	// told about the messages it shows
+	messageAction string
+	onMessage     func(msg protocol.ShowMessageParams)
+
+	// Workspace root, and which workspace/applyEdit requests from the server to apply
+	workspaceDir    string
+	applyEditPolicy ApplyEditPolicy
+
+	// Records the messages exchanged with the server, if set
+	tracer atomic.Pointer[Tracer]
+	// Records the session to replay later, if set
+	recorder atomic.Pointer[Recorder]
+	// Translates the paths in the messages exchanged with the server, if set
+	pathMap atomic.Pointer[PathMap]
+
+	// Closed when the client is closed, to stop background work
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
 }
 
+// NewClient starts a language server process and returns a client talking to it, which
+// must then be initialized with InitializeLSPClient
 func NewClient(command string, args ...string) (*Client, error) {
-	cmd := exec.Command(command, args...)
-	// Copy env
-	cmd.Env = os.Environ()
+	return NewClientWithOptions(ProcessOptions{}, command, args...)
+}
+
+// NewClientWithOptions is NewClient for a server process started with options changing
+// its working directory and environment, or running it elsewhere
+func NewClientWithOptions(options ProcessOptions, command string, args ...string) (*Client, error) {
+	cmd := options.cmd(command, args)
+	setProcessGroup(cmd)
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
@@ -66,17 +159,10 @@ func NewClient(command string, args ...string) (*Client, error) {
 		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
 	}
 
-	client := &Client{
-		Cmd:                   cmd,
-		stdin:                 stdin,
-		stdout:                bufio.NewReader(stdout),
-		stderr:                stderr,
-		handlers:              make(map[string]chan *Message),
-		notificationHandlers:  make(map[string]NotificationHandler),
-		serverRequestHandlers: make(map[string]ServerRequestHandler),
-		diagnostics:           make(map[protocol.DocumentUri][]protocol.Diagnostic),
-		openFiles:             make(map[string]*OpenFileInfo),
-	}
+	client := newClient(stdin, stdout)
+	client.Cmd = cmd
+	client.command = append([]string{command}, args...)
+	client.stderr = stderr
 
 	// Start the LSP server process
 	if err := cmd.Start(); err != nil {
@@ -89,6 +175,7 @@ func NewClient(command string, args ...string) (*Client, error) {
 		for scanner.Scan() {
 			line := scanner.Text()
 			processLogger.Info("%s", line)
+			client.stderrLog.writeLine(line)
 		}
 		if err := scanner.Err(); err != nil {
 			lspLogger.Error("Error reading LSP server stderr: %v", err)
@@ -101,6 +188,37 @@ func NewClient(command string, args ...string) (*Client, error) {
 	return client, nil
 }
 
+// newClient returns a client talking to a server over stdin and stdout
+func newClient(stdin io.WriteCloser, stdout io.Reader) *Client {
+	return &Client{
+		stdin:                 stdin,
+		stdout:                bufio.NewReader(stdout),
+		handlers:              make(map[string]chan *Message),
+		notificationHandlers:  make(map[string]NotificationHandler),
+		serverRequestHandlers: make(map[string]ServerRequestHandler),
+		diagnostics:           make(map[protocol.DocumentUri][]protocol.Diagnostic),
+		openFiles:             make(map[string]*OpenFileInfo),
+		applyEditPolicy:       ApplyEditWorkspace,
+		queryOpenMode:         QueryOpenAlways,
+		done:                  make(chan struct{}),
+		disconnected:          make(chan struct{}),
+	}
+}
+
+// SetApplyEditPolicy sets which workspace/applyEdit requests from the server are applied
+func (c *Client) SetApplyEditPolicy(policy ApplyEditPolicy) {
+	c.applyEditPolicy = policy
+}
+
+// SetDiagnosticsHandler sets a function called whenever the server publishes diagnostics
+// for a file. It must be set before the client is initialized.
+func (c *Client) SetDiagnosticsHandler(handler func(uri protocol.DocumentUri)) {
+	c.onDiagnostics = handler
+}
+
+// Workspace edits are staged and rolled back as a whole, see utilities.StageWorkspaceEdit
+var transactionalFailureHandling = protocol.Transactional
+
 func (c *Client) RegisterNotificationHandler(method string, handler NotificationHandler) {
 	c.notificationMu.Lock()
 	defer c.notificationMu.Unlock()
@@ -114,11 +232,13 @@ func (c *Client) RegisterServerRequestHandler(method string, handler ServerReque
 }
 
 func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (*protocol.InitializeResult, error) {
+	c.workspaceDir = workspaceDir
+
 	initParams := &protocol.InitializeParams{
 		WorkspaceFoldersInitializeParams: protocol.WorkspaceFoldersInitializeParams{
 			WorkspaceFolders: []protocol.WorkspaceFolder{
 				{
-					URI:  protocol.URI("file://" + workspaceDir),
+					URI:  string(protocol.URIFromPath(workspaceDir)),
 					Name: workspaceDir,
 				},
 			},
@@ -131,9 +251,20 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 				Version: "0.1.0",
 			},
 			RootPath: workspaceDir,
-			RootURI:  protocol.DocumentUri("file://" + workspaceDir),
+			RootURI:  protocol.URIFromPath(workspaceDir),
 			Capabilities: protocol.ClientCapabilities{
+				// Servers counting UTF-8 bytes spare the tools converting columns on every
+				// position, and UTF-16 is the encoding every server supports
+				General: &protocol.GeneralClientCapabilities{
+					PositionEncodings: []protocol.PositionEncodingKind{protocol.UTF8, protocol.UTF16},
+				},
 				Workspace: protocol.WorkspaceClientCapabilities{
+					ApplyEdit: true,
+					WorkspaceEdit: &protocol.WorkspaceEditClientCapabilities{
+						DocumentChanges:    true,
+						ResourceOperations: []protocol.ResourceOperationKind{protocol.Create, protocol.Rename, protocol.Delete},
+						FailureHandling:    &transactionalFailureHandling,
+					},
 					Configuration: true,
 					DidChangeConfiguration: protocol.DidChangeConfigurationClientCapabilities{
 						DynamicRegistration: true,
@@ -142,6 +273,14 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 						DynamicRegistration:    true,
 						RelativePatternSupport: true,
 					},
+					FileOperations: &protocol.FileOperationClientCapabilities{
+						WillCreate: true,
+						DidCreate:  true,
+						WillRename: true,
+						DidRename:  true,
+						WillDelete: true,
+						DidDelete:  true,
+					},
 				},
 				TextDocument: protocol.TextDocumentClientCapabilities{
 					Synchronization: &protocol.TextDocumentSyncClientCapabilities{
@@ -155,12 +294,21 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 						DynamicRegistration: true,
 					},
 					DocumentSymbol: protocol.DocumentSymbolClientCapabilities{},
+					Rename: &protocol.RenameClientCapabilities{
+						PrepareSupport: true,
+					},
 					CodeAction: protocol.CodeActionClientCapabilities{
 						CodeActionLiteralSupport: protocol.ClientCodeActionLiteralOptions{
 							CodeActionKind: protocol.ClientCodeActionKindOptions{
 								ValueSet: []protocol.CodeActionKind{},
 							},
 						},
+						// Refactorings are computed lazily by some servers, such as gopls
+						DisabledSupport: true,
+						DataSupport:     true,
+						ResolveSupport: &protocol.ClientCodeActionResolveOptions{
+							Properties: []string{"edit"},
+						},
 					},
 					PublishDiagnostics: protocol.PublishDiagnosticsClientCapabilities{
 						VersionSupport: true,
@@ -175,7 +323,9 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 						Formats:        []protocol.TokenFormat{},
 					},
 				},
-				Window: protocol.WindowClientCapabilities{},
+				Window: protocol.WindowClientCapabilities{
+					WorkDoneProgress: true,
+				},
 			},
 			InitializationOptions: map[string]any{
 				"codelenses": map[string]bool{
@@ -190,34 +340,64 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 			},
 		},
 	}
+	if isJdtls(c.command) {
+		jdtlsInitializationOptions(initParams.InitializationOptions.(map[string]any))
+	}
 
 	var result protocol.InitializeResult
 	if err := c.Call(ctx, "initialize", initParams, &result); err != nil {
 		return nil, fmt.Errorf("initialize failed: %w", err)
 	}
+	c.syncKind = textDocumentSyncKind(result.Capabilities)
+	c.workspaceDiagnostics = supportsWorkspaceDiagnostics(result.Capabilities)
+	c.prepareRename = supportsPrepareRename(result.Capabilities)
+	c.fileOperations = fileOperations(result.Capabilities)
+	c.positionEncoding = positionEncoding(result.Capabilities)
+	c.capabilitiesMu.Lock()
+	c.capabilities = result.Capabilities
+	if result.ServerInfo != nil {
+		c.serverName = result.ServerInfo.Name
+	}
+	c.capabilitiesMu.Unlock()
+
+	// Register handlers before the initialized notification, after which servers
+	// register capabilities
+	c.RegisterServerRequestHandler("workspace/applyEdit",
+		func(params json.RawMessage) (any, error) { return HandleApplyEdit(c, params) })
+	c.RegisterServerRequestHandler("workspace/configuration",
+		func(params json.RawMessage) (any, error) { return HandleWorkspaceConfiguration(c, params) })
+	c.RegisterServerRequestHandler("client/registerCapability",
+		func(params json.RawMessage) (any, error) { return HandleRegisterCapability(c, params) })
+	c.RegisterServerRequestHandler("client/unregisterCapability",
+		func(params json.RawMessage) (any, error) { return HandleUnregisterCapability(c, params) })
+	c.RegisterServerRequestHandler("window/workDoneProgress/create",
+		func(params json.RawMessage) (any, error) { return HandleWorkDoneProgressCreate(c, params) })
+	c.RegisterServerRequestHandler("window/showMessageRequest",
+		func(params json.RawMessage) (any, error) { return HandleShowMessageRequest(c, params) })
+	c.RegisterNotificationHandler("window/showMessage",
+		func(params json.RawMessage) { HandleServerMessage(c, params) })
+	c.RegisterNotificationHandler("window/logMessage", HandleLogMessage)
+	c.RegisterNotificationHandler("textDocument/publishDiagnostics",
+		func(params json.RawMessage) { HandleDiagnostics(c, params) })
+	c.RegisterNotificationHandler("$/progress",
+		func(params json.RawMessage) { HandleProgress(c, params) })
 
 	if err := c.Notify(ctx, "initialized", struct{}{}); err != nil {
 		return nil, fmt.Errorf("initialized notification failed: %w", err)
 	}
 
-	// Register handlers
-	c.RegisterServerRequestHandler("workspace/applyEdit", HandleApplyEdit)
-	c.RegisterServerRequestHandler("workspace/configuration", HandleWorkspaceConfiguration)
-	c.RegisterServerRequestHandler("client/registerCapability", HandleRegisterCapability)
-	c.RegisterNotificationHandler("window/showMessage", HandleServerMessage)
-	c.RegisterNotificationHandler("textDocument/publishDiagnostics",
-		func(params json.RawMessage) { HandleDiagnostics(c, params) })
-
 	// Notify the LSP server
 	err := c.Initialized(ctx, protocol.InitializedParams{})
 	if err != nil {
 		return nil, fmt.Errorf("initialization failed: %w", err)
 	}
+	if err := c.sendSettings(ctx); err != nil {
+		return nil, fmt.Errorf("failed to send settings: %w", err)
+	}
 
 	// LSP sepecific Initialization
-	path := strings.ToLower(c.Cmd.Path)
 	switch {
-	case strings.Contains(path, "typescript-language-server"):
+	case len(c.command) > 0 && strings.Contains(strings.ToLower(c.command[0]), "typescript-language-server"):
 		err := initializeTypescriptLanguageServer(ctx, c, workspaceDir)
 		if err != nil {
 			return nil, err
@@ -227,7 +407,17 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 	return &result, nil
 }
 
+// Close stops the server process, killing it and the processes it spawned if it doesn't
+// exit within 2 seconds of its stdin being closed, or closes the connection to a server
+// the client connected to. It is safe to call more than once.
 func (c *Client) Close() error {
+	c.closeOnce.Do(func() { c.closeErr = c.close() })
+	return c.closeErr
+}
+
+func (c *Client) close() error {
+	close(c.done)
+
 	// Try to close all open files first
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -235,22 +425,23 @@ func (c *Client) Close() error {
 	// Attempt to close files but continue shutdown regardless
 	c.CloseAllFiles(ctx)
 
+	// A server the client connected to keeps running for other clients
+	if c.Cmd == nil {
+		return c.stdin.Close()
+	}
+
 	// Force kill the LSP process if it doesn't exit within timeout
-	forcedKill := make(chan struct{})
+	exited := make(chan struct{})
 	go func() {
 		select {
 		case <-time.After(2 * time.Second):
 			lspLogger.Warn("LSP process did not exit within timeout, forcing kill")
-			if c.Cmd.Process != nil {
-				if err := c.Cmd.Process.Kill(); err != nil {
-					lspLogger.Error("Failed to kill process: %v", err)
-				} else {
-					lspLogger.Info("Process killed successfully")
-				}
+			if err := killProcessGroup(c.Cmd); err != nil {
+				lspLogger.Error("Failed to kill process: %v", err)
+			} else {
+				lspLogger.Info("Process killed successfully")
 			}
-			close(forcedKill)
-		case <-forcedKill:
-			// Channel closed from completion path
+		case <-exited:
 			return
 		}
 	}()
@@ -262,8 +453,12 @@ func (c *Client) Close() error {
 
 	// Wait for process to exit
 	err := c.Cmd.Wait()
-	close(forcedKill) // Stop the force kill goroutine
+	close(exited) // Stop the force kill goroutine
 
+	// Processes the server spawned may outlive it
+	if killErr := killProcessGroup(c.Cmd); killErr != nil {
+		lspLogger.Error("Failed to kill the processes of the LSP server: %v", killErr)
+	}
 	return err
 }
 
@@ -277,24 +472,71 @@ const (
 
 func (c *Client) WaitForServerReady(ctx context.Context) error {
 	// TODO: wait for specific messages or poll workspace/symbol
-	time.Sleep(time.Second * 1)
-	return nil
+	select {
+	case <-time.After(time.Second * 1):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 type OpenFileInfo struct {
 	Version int32
 	URI     protocol.DocumentUri
+	// The text the server last received, used to compute incremental changes
+	content string
+	// The MCP sessions that opened the file, see WithSession
+	sessions map[string]bool
+	// Whether the file was only opened for queries, see OpenFileForQuery, and when it
+	// was last opened, queried or changed, to close the least recently used files
+	queryOnly bool
+	lastUsed  time.Time
 }
 
 func (c *Client) OpenFile(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	return c.openFile(ctx, filepath, false)
+}
 
-	c.openFilesMu.Lock()
-	if _, exists := c.openFiles[uri]; exists {
+// openFile opens a file, for queries only if queryOnly is set. A file opened otherwise
+// stays open after its queries.
+func (c *Client) openFile(ctx context.Context, filepath string, queryOnly bool) error {
+	uri := string(protocol.URIFromPath(filepath))
+
+	for {
+		c.openFilesMu.Lock()
+		if fileInfo, exists := c.openFiles[uri]; exists {
+			fileInfo.sessions[sessionFromContext(ctx)] = true
+			fileInfo.queryOnly = fileInfo.queryOnly && queryOnly
+			fileInfo.lastUsed = time.Now()
+			c.openFilesMu.Unlock()
+			return nil // Already open
+		}
+		pending, isOpening := c.opening[uri]
+		if !isOpening {
+			break
+		}
 		c.openFilesMu.Unlock()
-		return nil // Already open
+
+		// Another call is opening the file: the server must get a single didOpen, so wait
+		// for it and then join its entry, or open the file ourselves if it failed
+		select {
+		case <-pending:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if c.opening == nil {
+		c.opening = make(map[string]chan struct{})
 	}
+	done := make(chan struct{})
+	c.opening[uri] = done
 	c.openFilesMu.Unlock()
+	defer func() {
+		c.openFilesMu.Lock()
+		delete(c.opening, uri)
+		c.openFilesMu.Unlock()
+		close(done)
+	}()
 
 	// Skip files that do not exist or cannot be read
 	content, err := os.ReadFile(filepath)
@@ -317,18 +559,27 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 
 	c.openFilesMu.Lock()
 	c.openFiles[uri] = &OpenFileInfo{
-		Version: 1,
-		URI:     protocol.DocumentUri(uri),
+		Version:   1,
+		URI:       protocol.DocumentUri(uri),
+		content:   string(content),
+		sessions:  map[string]bool{sessionFromContext(ctx): true},
+		queryOnly: queryOnly,
+		lastUsed:  time.Now(),
 	}
 	c.openFilesMu.Unlock()
 
 	lspLogger.Debug("Opened file: %s", filepath)
 
+	c.evictDocuments(ctx, uri)
+
 	return nil
 }
 
+// NotifyChange sends the content of an open file on disk to the server after it changed,
+// written by a tool or another program, followed by a didSave notification if the server
+// asked for them
 func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(protocol.URIFromPath(filepath))
 
 	content, err := os.ReadFile(filepath)
 	if err != nil {
@@ -342,8 +593,23 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 		return fmt.Errorf("cannot notify change for unopened file: %s", filepath)
 	}
 
+	// The server already has this content
+	if fileInfo.content == string(content) {
+		c.openFilesMu.Unlock()
+		return nil
+	}
+
+	// Only send the changed range if the server supports it
+	var change any = protocol.TextDocumentContentChangeWholeDocument{Text: string(content)}
+	if c.syncKind == protocol.Incremental {
+		change = incrementalChange(fileInfo.content, string(content), c.positionEncoding)
+	}
+	text := string(content)
+
 	// Increment version
 	fileInfo.Version++
+	fileInfo.content = string(content)
+	fileInfo.lastUsed = time.Now()
 	version := fileInfo.Version
 	c.openFilesMu.Unlock()
 
@@ -355,19 +621,35 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 			Version: version,
 		},
 		ContentChanges: []protocol.TextDocumentContentChangeEvent{
-			{
-				Value: protocol.TextDocumentContentChangeWholeDocument{
-					Text: string(content),
-				},
-			},
+			{Value: change},
 		},
 	}
 
-	return c.Notify(ctx, "textDocument/didChange", params)
+	if err := c.Notify(ctx, "textDocument/didChange", params); err != nil {
+		return err
+	}
+	return c.notifySave(ctx, protocol.DocumentUri(uri), text)
+}
+
+// notifySave tells the server that a document changed on disk was saved, for servers that
+// re-run some analyses, such as linters, only on save. It does nothing for servers that
+// didn't ask for didSave notifications, and sends the text if they asked for it.
+func (c *Client) notifySave(ctx context.Context, uri protocol.DocumentUri, text string) error {
+	save, includeText := c.saveOptions()
+	if !save {
+		return nil
+	}
+	params := protocol.DidSaveTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	}
+	if includeText {
+		params.Text = &text
+	}
+	return c.DidSave(ctx, params)
 }
 
 func (c *Client) CloseFile(ctx context.Context, filepath string) error {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(protocol.URIFromPath(filepath))
 
 	c.openFilesMu.Lock()
 	if _, exists := c.openFiles[uri]; !exists {
@@ -394,13 +676,38 @@ func (c *Client) CloseFile(ctx context.Context, filepath string) error {
 }
 
 func (c *Client) IsFileOpen(filepath string) bool {
-	uri := fmt.Sprintf("file://%s", filepath)
+	uri := string(protocol.URIFromPath(filepath))
 	c.openFilesMu.RLock()
 	defer c.openFilesMu.RUnlock()
 	_, exists := c.openFiles[uri]
 	return exists
 }
 
+// FileVersion returns the document version the LSP has for an open file
+func (c *Client) FileVersion(filepath string) (int32, bool) {
+	uri := string(protocol.URIFromPath(filepath))
+	c.openFilesMu.RLock()
+	defer c.openFilesMu.RUnlock()
+	fileInfo, exists := c.openFiles[uri]
+	if !exists {
+		return 0, false
+	}
+	return fileInfo.Version, true
+}
+
+// OpenFileContent returns the text the LSP has for an open file. It differs from the
+// file on disk while changes to the file have not been sent yet.
+func (c *Client) OpenFileContent(filepath string) (string, bool) {
+	uri := string(protocol.URIFromPath(filepath))
+	c.openFilesMu.RLock()
+	defer c.openFilesMu.RUnlock()
+	fileInfo, exists := c.openFiles[uri]
+	if !exists {
+		return "", false
+	}
+	return fileInfo.content, true
+}
+
 // CloseAllFiles closes all currently open files
 func (c *Client) CloseAllFiles(ctx context.Context) {
 	c.openFilesMu.Lock()
@@ -408,8 +715,8 @@ func (c *Client) CloseAllFiles(ctx context.Context) {
 
 	// First collect all URIs that need to be closed
 	for uri := range c.openFiles {
-		// Convert URI back to file path by trimming "file://" prefix
-		filePath := strings.TrimPrefix(uri, "file://")
+		// Convert URI back to file path
+		filePath := protocol.DocumentUri(uri).Path()
 		filesToClose = append(filesToClose, filePath)
 	}
 	c.openFilesMu.Unlock()
@@ -425,9 +732,40 @@ func (c *Client) CloseAllFiles(ctx context.Context) {
 	lspLogger.Debug("Closed %d files", len(filesToClose))
 }
 
+// storeDiagnostics caches the diagnostics reported for a file. The caller must hold
+// diagnosticsMu for writing.
+func (c *Client) storeDiagnostics(uri protocol.DocumentUri, diagnostics []protocol.Diagnostic) {
+	if c.diagnosticsGen == nil {
+		c.diagnosticsGen = make(map[protocol.DocumentUri]uint64)
+	}
+	c.diagnostics[uri] = diagnostics
+	c.diagnosticsGen[uri]++
+}
+
+// DiagnosticsGeneration returns how many times diagnostics were reported for a file, to
+// tell whether fresh ones arrived since an earlier call
+func (c *Client) DiagnosticsGeneration(uri protocol.DocumentUri) uint64 {
+	c.diagnosticsMu.RLock()
+	defer c.diagnosticsMu.RUnlock()
+	return c.diagnosticsGen[uri]
+}
+
 func (c *Client) GetFileDiagnostics(uri protocol.DocumentUri) []protocol.Diagnostic {
 	c.diagnosticsMu.RLock()
 	defer c.diagnosticsMu.RUnlock()
 
 	return c.diagnostics[uri]
 }
+
+// GetAllDiagnostics returns the cached diagnostics of every file the server has
+// published them for
+func (c *Client) GetAllDiagnostics() map[protocol.DocumentUri][]protocol.Diagnostic {
+	c.diagnosticsMu.RLock()
+	defer c.diagnosticsMu.RUnlock()
+
+	diagnostics := make(map[protocol.DocumentUri][]protocol.Diagnostic, len(c.diagnostics))
+	for uri, diags := range c.diagnostics {
+		diagnostics[uri] = diags
+	}
+	return diagnostics
+}
@@ -0,0 +1,51 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ParseAddress splits the address of a listening language server into the network and
+// address net.Dial takes: "unix:" followed by the path of a unix socket, or a TCP
+// "host:port" with an optional "tcp:" prefix
+func ParseAddress(address string) (string, string, error) {
+	network := "tcp"
+	if rest, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, address = "unix", rest
+	} else if rest, ok := strings.CutPrefix(address, "tcp:"); ok {
+		address = rest
+	}
+	if address == "" {
+		return "", "", fmt.Errorf("empty %s address", network)
+	}
+	if network == "tcp" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return "", "", fmt.Errorf("invalid TCP address %q, expected host:port: %v", address, err)
+		}
+	}
+	return network, address, nil
+}
+
+// Connect returns a client talking to a language server already listening on address,
+// see ParseAddress, such as jdtls, OmniSharp or a server in a remote container, instead of
+// one it starts. command, if known, is how the server was started, to recognize servers
+// needing particular initialization. The client must then be initialized with
+// InitializeLSPClient, and closing it leaves the server running.
+func Connect(ctx context.Context, address string, command ...string) (*Client, error) {
+	network, address, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LSP server: %w", err)
+	}
+
+	client := newClient(conn, conn)
+	client.command = command
+	go client.handleMessages()
+	return client, nil
+}
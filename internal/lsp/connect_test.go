@@ -0,0 +1,68 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddress(t *testing.T) {
+	for _, tt := range []struct {
+		address, network, want string
+	}{
+		{"localhost:5007", "tcp", "localhost:5007"},
+		{"tcp:127.0.0.1:5007", "tcp", "127.0.0.1:5007"},
+		{"unix:/tmp/omnisharp.sock", "unix", "/tmp/omnisharp.sock"},
+	} {
+		network, address, err := ParseAddress(tt.address)
+		require.NoError(t, err, tt.address)
+		assert.Equal(t, tt.network, network, tt.address)
+		assert.Equal(t, tt.want, address, tt.address)
+	}
+
+	for _, address := range []string{"", "unix:", "localhost"} {
+		_, _, err := ParseAddress(address)
+		assert.Error(t, err, address)
+	}
+}
+
+func TestConnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// Answers every request with its method, until the client disconnects
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			msg, err := ReadMessage(reader)
+			if err != nil {
+				return
+			}
+			result, _ := json.Marshal(msg.Method)
+			if err := WriteMessage(conn, &Message{JSONRPC: "2.0", ID: msg.ID, Result: result}); err != nil {
+				return
+			}
+		}
+	}()
+
+	client, err := Connect(context.Background(), listener.Addr().String(), "jdtls")
+	require.NoError(t, err)
+	assert.Nil(t, client.Cmd)
+	assert.True(t, isJdtls(client.command))
+
+	var method string
+	require.NoError(t, client.Call(context.Background(), "workspace/symbol", struct{}{}, &method))
+	assert.Equal(t, "workspace/symbol", method)
+	assert.NoError(t, client.Close())
+}
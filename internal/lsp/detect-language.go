@@ -8,6 +8,10 @@ import (
 )
 
 func DetectLanguageID(uri string) protocol.LanguageKind {
+	// jdt:// URIs name compiled classes, whose contents are Java source
+	if strings.HasPrefix(uri, "jdt:") {
+		return protocol.LangJava
+	}
 	ext := strings.ToLower(filepath.Ext(uri))
 	switch ext {
 	case ".abap":
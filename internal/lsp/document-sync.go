@@ -0,0 +1,125 @@
+package lsp
+
+import (
+	"unicode/utf8"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// textDocumentSyncKind returns how the server wants document changes to be sent. The
+// capability is either a TextDocumentSyncKind or a TextDocumentSyncOptions object.
+func textDocumentSyncKind(capabilities protocol.ServerCapabilities) protocol.TextDocumentSyncKind {
+	switch sync := capabilities.TextDocumentSync.(type) {
+	case float64:
+		return protocol.TextDocumentSyncKind(sync)
+	case map[string]any:
+		if change, ok := sync["change"].(float64); ok {
+			return protocol.TextDocumentSyncKind(change)
+		}
+	}
+	return protocol.Full
+}
+
+// saveOptions returns whether the server wants didSave notifications and whether they
+// include the text of the document. The save option is either a boolean or a SaveOptions
+// object.
+func saveOptions(capabilities protocol.ServerCapabilities) (save, includeText bool) {
+	sync, ok := capabilities.TextDocumentSync.(map[string]any)
+	if !ok {
+		return false, false
+	}
+	switch options := sync["save"].(type) {
+	case bool:
+		return options, false
+	case map[string]any:
+		return true, includeTextOption(options)
+	}
+	return false, false
+}
+
+// includeTextOption reports whether save options ask for the text of the document
+func includeTextOption(options any) bool {
+	m, _ := options.(map[string]any)
+	includeText, _ := m["includeText"].(bool)
+	return includeText
+}
+
+// positionEncoding returns the encoding the server chose for the characters of positions
+// among those the client offered. Servers that don't choose use UTF-16.
+func positionEncoding(capabilities protocol.ServerCapabilities) protocol.PositionEncodingKind {
+	if kind := capabilities.PositionEncoding; kind != nil {
+		switch *kind {
+		case protocol.UTF8, protocol.UTF16, protocol.UTF32:
+			return *kind
+		}
+		lspLogger.Warn("Server chose unknown position encoding %q, using utf-16", *kind)
+	}
+	return protocol.UTF16
+}
+
+// PositionEncoding returns the encoding the characters of positions are counted in with
+// this server, UTF-16 until it is initialized
+func (c *Client) PositionEncoding() protocol.PositionEncodingKind {
+	if c == nil || c.positionEncoding == "" {
+		return protocol.UTF16
+	}
+	return c.positionEncoding
+}
+
+// incrementalChange returns a single change that turns oldText into newText, covering
+// only the part of the document between the common prefix and suffix of the two, with
+// characters counted in encoding
+func incrementalChange(oldText, newText string, encoding protocol.PositionEncodingKind) protocol.TextDocumentContentChangePartial {
+	prefix := 0
+	for prefix < len(oldText) && prefix < len(newText) && oldText[prefix] == newText[prefix] {
+		prefix++
+	}
+	// Do not split a multi-byte character
+	for prefix > 0 && prefix < len(oldText) && !utf8.RuneStart(oldText[prefix]) {
+		prefix--
+	}
+
+	suffix := 0
+	for suffix < len(oldText)-prefix && suffix < len(newText)-prefix &&
+		oldText[len(oldText)-1-suffix] == newText[len(newText)-1-suffix] {
+		suffix++
+	}
+	for suffix > 0 && !utf8.RuneStart(oldText[len(oldText)-suffix]) {
+		suffix--
+	}
+	// Nor a \r\n line break, which has no position in between
+	if prefix > 0 && prefix < len(oldText) && oldText[prefix-1] == '\r' && oldText[prefix] == '\n' {
+		prefix--
+	}
+	if end := len(oldText) - suffix; suffix > 0 && end > 0 && oldText[end-1] == '\r' && oldText[end] == '\n' {
+		suffix--
+	}
+
+	return protocol.TextDocumentContentChangePartial{
+		Range: &protocol.Range{
+			Start: offsetToPosition(oldText, prefix, encoding),
+			End:   offsetToPosition(oldText, len(oldText)-suffix, encoding),
+		},
+		Text: newText[prefix : len(newText)-suffix],
+	}
+}
+
+// offsetToPosition converts a byte offset in text into an LSP position, whose character
+// is counted in encoding. Lines end with \n, \r\n or a lone \r, as in LSP.
+func offsetToPosition(text string, offset int, encoding protocol.PositionEncodingKind) protocol.Position {
+	line, lineStart := 0, 0
+	for i := 0; i < offset; i++ {
+		switch text[i] {
+		case '\r':
+			if i+1 < len(text) && text[i+1] == '\n' {
+				continue
+			}
+		case '\n':
+		default:
+			continue
+		}
+		line++
+		lineStart = i + 1
+	}
+	return protocol.Position{Line: uint32(line), Character: uint32(encoding.CharacterLen(text[lineStart:offset]))}
+}
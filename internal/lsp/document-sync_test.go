@@ -0,0 +1,162 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncrementalChange(t *testing.T) {
+	rng := func(startLine, startChar, endLine, endChar uint32) *protocol.Range {
+		return &protocol.Range{
+			Start: protocol.Position{Line: startLine, Character: startChar},
+			End:   protocol.Position{Line: endLine, Character: endChar},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		oldText  string
+		newText  string
+		expected protocol.TextDocumentContentChangePartial
+	}{
+		{
+			name:     "replace word",
+			oldText:  "func main() {}\n",
+			newText:  "func init() {}\n",
+			expected: protocol.TextDocumentContentChangePartial{Range: rng(0, 5, 0, 9), Text: "init"},
+		},
+		{
+			name:     "insert line",
+			oldText:  "a\nc\n",
+			newText:  "a\nb\nc\n",
+			expected: protocol.TextDocumentContentChangePartial{Range: rng(1, 0, 1, 0), Text: "b\n"},
+		},
+		{
+			name:     "delete lines",
+			oldText:  "one\ntwo\nthree\nfour\n",
+			newText:  "one\nfour\n",
+			expected: protocol.TextDocumentContentChangePartial{Range: rng(1, 0, 3, 0), Text: ""},
+		},
+		{
+			name:     "append at end",
+			oldText:  "x := 1",
+			newText:  "x := 1\n",
+			expected: protocol.TextDocumentContentChangePartial{Range: rng(0, 6, 0, 6), Text: "\n"},
+		},
+		{
+			name:     "multi-byte characters",
+			oldText:  "s := \"😀é\"\n",
+			newText:  "s := \"😀è\"\n",
+			expected: protocol.TextDocumentContentChangePartial{Range: rng(0, 8, 0, 9), Text: "è"},
+		},
+		{
+			name:     "crlf",
+			oldText:  "one\r\ntwo\r\nthree\r\n",
+			newText:  "one\r\n2\r\nthree\r\n",
+			expected: protocol.TextDocumentContentChangePartial{Range: rng(1, 0, 1, 3), Text: "2"},
+		},
+		{
+			name:     "crlf inserted line",
+			oldText:  "a\r\nc\r\n",
+			newText:  "a\r\nb\r\nc\r\n",
+			expected: protocol.TextDocumentContentChangePartial{Range: rng(1, 0, 1, 0), Text: "b\r\n"},
+		},
+		{
+			name:     "crlf to lf",
+			oldText:  "a\r\nb\n",
+			newText:  "a\nb\n",
+			expected: protocol.TextDocumentContentChangePartial{Range: rng(0, 1, 1, 0), Text: "\n"},
+		},
+		{
+			name:     "lf to crlf",
+			oldText:  "a\nb\n",
+			newText:  "a\r\nb\n",
+			expected: protocol.TextDocumentContentChangePartial{Range: rng(0, 1, 0, 1), Text: "\r"},
+		},
+		{
+			name:     "lone cr",
+			oldText:  "a\rb\rc",
+			newText:  "a\rb\rd",
+			expected: protocol.TextDocumentContentChangePartial{Range: rng(2, 0, 2, 1), Text: "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, incrementalChange(tt.oldText, tt.newText, protocol.UTF16))
+		})
+	}
+}
+
+func TestPositionEncoding(t *testing.T) {
+	utf8 := protocol.UTF8
+	assert.Equal(t, protocol.UTF8, positionEncoding(protocol.ServerCapabilities{PositionEncoding: &utf8}))
+	assert.Equal(t, protocol.UTF16, positionEncoding(protocol.ServerCapabilities{}))
+
+	// Each client counts characters in its own encoding
+	a, b := &Client{positionEncoding: protocol.UTF8}, &Client{}
+	assert.Equal(t, protocol.UTF8, a.PositionEncoding())
+	assert.Equal(t, protocol.UTF16, b.PositionEncoding())
+	change := incrementalChange("s := \"😀é\"\n", "s := \"😀è\"\n", a.PositionEncoding())
+	assert.Equal(t, protocol.Position{Line: 0, Character: 10}, change.Range.Start)
+}
+
+func TestTextDocumentSyncKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		sync     interface{}
+		expected protocol.TextDocumentSyncKind
+	}{
+		{name: "kind", sync: float64(2), expected: protocol.Incremental},
+		{name: "options", sync: map[string]any{"openClose": true, "change": float64(1)}, expected: protocol.Full},
+		{name: "missing", sync: nil, expected: protocol.Full},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps := protocol.ServerCapabilities{TextDocumentSync: tt.sync}
+			assert.Equal(t, tt.expected, textDocumentSyncKind(caps))
+		})
+	}
+}
+
+func TestSaveOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		sync        interface{}
+		save        bool
+		includeText bool
+	}{
+		{name: "kind", sync: float64(2)},
+		{name: "no save", sync: map[string]any{"change": float64(2)}},
+		{name: "save", sync: map[string]any{"save": true}, save: true},
+		{name: "save options", sync: map[string]any{"save": map[string]any{}}, save: true},
+		{name: "include text", sync: map[string]any{"save": map[string]any{"includeText": true}}, save: true, includeText: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			save, includeText := saveOptions(protocol.ServerCapabilities{TextDocumentSync: tt.sync})
+			assert.Equal(t, tt.save, save)
+			assert.Equal(t, tt.includeText, includeText)
+		})
+	}
+
+	t.Run("registered", func(t *testing.T) {
+		client := &Client{}
+		register := []byte(`{"registrations":[{"id":"s1","method":"textDocument/didSave","registerOptions":{"includeText":true}}]}`)
+		_, err := HandleRegisterCapability(client, register)
+		assert.NoError(t, err)
+		save, includeText := client.saveOptions()
+		assert.True(t, save)
+		assert.True(t, includeText)
+
+		unregister := []byte(`{"unregisterations":[{"id":"s1","method":"textDocument/didSave"}]}`)
+		_, err = HandleUnregisterCapability(client, unregister)
+		assert.NoError(t, err)
+		save, _ = client.saveOptions()
+		assert.False(t, save)
+	})
+}
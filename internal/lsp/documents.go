@@ -0,0 +1,63 @@
+package lsp
+
+import (
+	"context"
+	"sort"
+)
+
+// Language servers keep every open document in memory, along with the analysis they do
+// of it, so opening every file an agent looks at during a crawl of the repository can
+// exhaust the server's memory. The client caps how many documents are open at once and
+// closes the least recently used ones, which the server then reads from disk again.
+
+// SetMaxOpenFiles caps how many documents are open in the server at once. When a file is
+// opened past the cap, the documents least recently opened, queried or changed are
+// closed. Zero or less means no cap. It must be called before the client is used.
+func (c *Client) SetMaxOpenFiles(n int) {
+	c.maxOpenFiles = n
+}
+
+// OpenFileCount returns how many documents are open in the server
+func (c *Client) OpenFileCount() int {
+	c.openFilesMu.RLock()
+	defer c.openFilesMu.RUnlock()
+	return len(c.openFiles)
+}
+
+// evictDocuments closes the least recently used documents while more than the maximum
+// are open. The document keep, which was just opened, is never closed.
+func (c *Client) evictDocuments(ctx context.Context, keep string) {
+	if c.maxOpenFiles <= 0 {
+		return
+	}
+
+	c.openFilesMu.RLock()
+	excess := len(c.openFiles) - c.maxOpenFiles
+	if excess <= 0 {
+		c.openFilesMu.RUnlock()
+		return
+	}
+	candidates := make([]*OpenFileInfo, 0, len(c.openFiles))
+	for uri, fileInfo := range c.openFiles {
+		if uri != keep {
+			candidates = append(candidates, fileInfo)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastUsed.Before(candidates[j].lastUsed)
+	})
+	var filesToClose []string
+	for _, fileInfo := range candidates[:min(excess, len(candidates))] {
+		filesToClose = append(filesToClose, fileInfo.URI.Path())
+	}
+	c.openFilesMu.RUnlock()
+
+	for _, filePath := range filesToClose {
+		if err := c.CloseFile(ctx, filePath); err != nil {
+			lspLogger.Warn("Error closing least recently used file %s: %v", filePath, err)
+		}
+	}
+	if len(filesToClose) > 0 {
+		lspLogger.Debug("Closed %d least recently used files to stay within %d open files", len(filesToClose), c.maxOpenFiles)
+	}
+}
@@ -0,0 +1,74 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvictLeastRecentlyUsedDocuments(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0644))
+		paths = append(paths, path)
+	}
+
+	sent := &bytes.Buffer{}
+	client := &Client{
+		stdin:        nopWriteCloser{sent},
+		openFiles:    make(map[string]*OpenFileInfo),
+		maxOpenFiles: 2,
+	}
+	ctx := context.Background()
+
+	require.NoError(t, client.OpenFile(ctx, paths[0]))
+	require.NoError(t, client.OpenFile(ctx, paths[1]))
+	// Querying a.go again makes b.go the least recently used
+	require.NoError(t, client.OpenFileForQuery(ctx, paths[0]))
+	require.NoError(t, client.OpenFile(ctx, paths[2]))
+
+	assert.Equal(t, 2, client.OpenFileCount())
+	assert.True(t, client.IsFileOpen(paths[0]))
+	assert.False(t, client.IsFileOpen(paths[1]))
+	assert.True(t, client.IsFileOpen(paths[2]))
+	assert.Contains(t, sent.String(), "textDocument/didClose")
+
+	// Reopening an evicted file starts a new document version
+	require.NoError(t, client.OpenFile(ctx, paths[1]))
+	version, ok := client.FileVersion(paths[1])
+	assert.True(t, ok)
+	assert.Equal(t, int32(1), version)
+	assert.Equal(t, 2, client.OpenFileCount())
+}
+
+func TestOpenFileContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0644))
+
+	client := &Client{
+		stdin:     nopWriteCloser{&bytes.Buffer{}},
+		openFiles: make(map[string]*OpenFileInfo),
+	}
+	ctx := context.Background()
+
+	_, ok := client.OpenFileContent(path)
+	assert.False(t, ok)
+
+	require.NoError(t, client.OpenFile(ctx, path))
+	// The server keeps the text it was sent until it is told of the change
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0644))
+	content, ok := client.OpenFileContent(path)
+	assert.True(t, ok)
+	assert.Equal(t, "package main\n", content)
+
+	require.NoError(t, client.NotifyChange(ctx, path))
+	content, _ = client.OpenFileContent(path)
+	assert.Equal(t, "package main\n\nfunc main() {}\n", content)
+}
@@ -0,0 +1,19 @@
+package lsp
+
+import "github.com/isaacphi/mcp-language-server/internal/protocol"
+
+// fileOperations returns the file operations the server's capabilities ask to be told
+// about, or none
+func fileOperations(capabilities protocol.ServerCapabilities) protocol.FileOperationOptions {
+	if capabilities.Workspace == nil || capabilities.Workspace.FileOperations == nil {
+		return protocol.FileOperationOptions{}
+	}
+	return *capabilities.Workspace.FileOperations
+}
+
+// FileOperations returns the file operations the server wants to be told about, such as
+// workspace/willRenameFiles requests before files are moved. The filters of each
+// operation are not applied by the client: servers ignore files they don't care about.
+func (c *Client) FileOperations() protocol.FileOperationOptions {
+	return c.fileOperations
+}
@@ -0,0 +1,28 @@
+package lsp
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// isJdtls reports whether a server command runs jdtls, the Eclipse JDT language server,
+// from its wrapper script or from java with the jdtls launcher and configuration
+func isJdtls(args []string) bool {
+	for _, arg := range args {
+		arg = strings.ToLower(arg)
+		if strings.Contains(filepath.Base(arg), "jdtls") || strings.Contains(arg, "jdt-language-server") || strings.Contains(arg, "org.eclipse.jdt.ls") {
+			return true
+		}
+	}
+	return false
+}
+
+// jdtlsInitializationOptions adds the options jdtls reads at initialization to options.
+// With classFileContentsSupport, definitions and references in the compiled classes of
+// dependencies are reported with jdt:// URIs, whose decompiled source ReadURI requests
+// with java/classFileContents, instead of being left out.
+func jdtlsInitializationOptions(options map[string]any) {
+	options["extendedClientCapabilities"] = map[string]any{
+		"classFileContentsSupport": true,
+	}
+}
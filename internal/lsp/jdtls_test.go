@@ -0,0 +1,19 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsJdtls(t *testing.T) {
+	assert.True(t, isJdtls([]string{"/opt/homebrew/bin/jdtls", "-data", "/tmp/ws"}))
+	assert.True(t, isJdtls([]string{"java", "-Declipse.product=org.eclipse.jdt.ls.core.product", "-jar", "launcher.jar"}))
+	assert.False(t, isJdtls([]string{"gopls"}))
+	assert.False(t, isJdtls([]string{"java", "-jar", "kotlin-language-server.jar"}))
+}
+
+func TestDetectJdtURI(t *testing.T) {
+	assert.Equal(t, protocol.LangJava, DetectLanguageID("jdt://contents/rt.jar/java.lang/String.class?=jdk/%5C/usr%5C/lib%5C/jvm"))
+}
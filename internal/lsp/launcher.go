@@ -0,0 +1,86 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Launcher runs the server somewhere other than the local machine, such as in a
+// devcontainer, through a local program whose stdin and stdout are those of the server
+type Launcher struct {
+	// Kind is "docker" or "podman" to run the server in a running container with exec,
+	// or "ssh" to run it on another host
+	Kind string
+	// Target is the container, or the destination of ssh such as "dev@buildbox"
+	Target string
+	// Args are given to docker exec, podman exec or ssh before the target, e.g.
+	// ["--user", "dev"] or ["-p", "2222"]
+	Args []string
+}
+
+// ParseLauncher parses a launcher written as kind:target, e.g. "docker:devcontainer" or
+// "ssh:dev@buildbox"
+func ParseLauncher(s string) (Launcher, error) {
+	kind, target, ok := strings.Cut(s, ":")
+	if !ok {
+		return Launcher{}, fmt.Errorf("invalid launcher %q, expected docker:<container>, podman:<container> or ssh:<destination>", s)
+	}
+	l := Launcher{Kind: kind, Target: target}
+	return l, l.Validate()
+}
+
+// Validate reports whether the launcher has a known kind and a target
+func (l Launcher) Validate() error {
+	switch l.Kind {
+	case "docker", "podman", "ssh":
+	default:
+		return fmt.Errorf("unknown launcher %q, expected docker, podman or ssh", l.Kind)
+	}
+	if l.Target == "" {
+		return fmt.Errorf("the %s launcher needs a target", l.Kind)
+	}
+	return nil
+}
+
+// command returns the local command running the server command with its arguments
+// through the launcher, in the working directory and with the environment of options.
+// These are applied by a shell where the server runs, which expands the variables in
+// them from its own environment.
+func (l Launcher) command(options ProcessOptions, name string, args []string) []string {
+	var script strings.Builder
+	if options.Dir != "" {
+		fmt.Fprintf(&script, "cd %s && ", expandingQuote(options.Dir))
+	}
+	script.WriteString("exec")
+	if len(options.Env) > 0 || len(options.Path) > 0 {
+		script.WriteString(" env")
+		for _, key := range sortedKeys(options.Env) {
+			script.WriteString(" " + expandingQuote(key+"="+options.Env[key]))
+		}
+		if len(options.Path) > 0 {
+			script.WriteString(" " + expandingQuote("PATH="+strings.Join(options.Path, ":")+":$PATH"))
+		}
+	}
+	for _, word := range append([]string{name}, args...) {
+		script.WriteString(" " + literalQuote(word))
+	}
+
+	if l.Kind == "ssh" {
+		// ssh runs its command with the login shell of the destination
+		return append(append(append([]string{"ssh"}, l.Args...), l.Target), script.String())
+	}
+	command := append(append([]string{l.Kind, "exec", "-i"}, l.Args...), l.Target)
+	return append(command, "sh", "-c", script.String())
+}
+
+// literalQuote quotes a word for a POSIX shell, which then reads it as is
+func literalQuote(word string) string {
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// expandingQuote quotes a word for a POSIX shell, which then expands the $VAR and ${VAR}
+// in it but nothing else
+func expandingQuote(word string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$(", `\$(`)
+	return `"` + replacer.Replace(word) + `"`
+}
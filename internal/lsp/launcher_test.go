@@ -0,0 +1,46 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLauncher(t *testing.T) {
+	launcher, err := ParseLauncher("docker:devcontainer")
+	require.NoError(t, err)
+	assert.Equal(t, Launcher{Kind: "docker", Target: "devcontainer"}, launcher)
+
+	launcher, err = ParseLauncher("ssh:dev@buildbox:2222")
+	require.NoError(t, err)
+	assert.Equal(t, Launcher{Kind: "ssh", Target: "dev@buildbox:2222"}, launcher)
+
+	for _, s := range []string{"devcontainer", "docker:", "kubectl:pod"} {
+		_, err := ParseLauncher(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestLauncherCommand(t *testing.T) {
+	options := ProcessOptions{
+		Dir:  "/workspaces/app",
+		Env:  map[string]string{"GOFLAGS": "-tags=integration", "NODE_OPTIONS": `--title="$(id)"`},
+		Path: []string{"$HOME/.cargo/bin"},
+	}
+	script := `cd "/workspaces/app" && exec env "GOFLAGS=-tags=integration" "NODE_OPTIONS=--title=\"\$(id)\"" "PATH=$HOME/.cargo/bin:$PATH" 'gopls' 'it'\''s'`
+
+	docker := Launcher{Kind: "docker", Target: "devcontainer", Args: []string{"--user", "dev"}}
+	assert.Equal(t,
+		[]string{"docker", "exec", "-i", "--user", "dev", "devcontainer", "sh", "-c", script},
+		docker.command(options, "gopls", []string{"it's"}))
+
+	ssh := Launcher{Kind: "ssh", Target: "dev@buildbox"}
+	assert.Equal(t,
+		[]string{"ssh", "dev@buildbox", script},
+		ssh.command(options, "gopls", []string{"it's"}))
+
+	assert.Equal(t,
+		[]string{"ssh", "dev@buildbox", "exec 'gopls'"},
+		ssh.command(ProcessOptions{}, "gopls", nil))
+}
@@ -0,0 +1,103 @@
+package lsp
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type requestGroupKey struct{}
+
+// WithRequestGroup returns a context whose requests form a group of their own, such as
+// the requests of one tool call. When requests wait for their turn, see
+// SetMaxConcurrentRequests, the groups waiting take turns so that a tool call flooding
+// the server with requests doesn't hold up the others.
+func WithRequestGroup(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestGroupKey{}, new(int))
+}
+
+// SetMaxConcurrentRequests limits the number of requests the server handles at once.
+// Requests beyond it wait, in turns between request groups. Zero means no limit. It must
+// be set before the client is initialized.
+func (c *Client) SetMaxConcurrentRequests(max int) {
+	c.limiter.max = max
+}
+
+// requestLimiter bounds the requests in flight. Waiting requests are queued per group,
+// and the groups are served round robin.
+type requestLimiter struct {
+	mu      sync.Mutex
+	max     int
+	running int
+	// Waiting requests by group, and the groups with waiting requests in turn order
+	queues map[any][]chan struct{}
+	turns  []any
+}
+
+// acquire waits for the request's turn, unless ctx is done first
+func (l *requestLimiter) acquire(ctx context.Context) error {
+	l.mu.Lock()
+	if l.max <= 0 || (l.running < l.max && len(l.turns) == 0) {
+		l.running++
+		l.mu.Unlock()
+		return nil
+	}
+
+	group := ctx.Value(requestGroupKey{})
+	ready := make(chan struct{})
+	if l.queues == nil {
+		l.queues = make(map[any][]chan struct{})
+	}
+	if len(l.queues[group]) == 0 {
+		l.turns = append(l.turns, group)
+	}
+	l.queues[group] = append(l.queues[group], ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		queue := l.queues[group]
+		if i := slices.Index(queue, ready); i >= 0 {
+			l.queues[group] = slices.Delete(queue, i, i+1)
+			if len(l.queues[group]) == 0 {
+				delete(l.queues, group)
+				l.turns = slices.DeleteFunc(l.turns, func(g any) bool { return g == group })
+			}
+		} else {
+			// Its turn came as it gave up
+			l.releaseLocked()
+		}
+		return ctx.Err()
+	}
+}
+
+// release ends a request, letting the next group in turn go on
+func (l *requestLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.releaseLocked()
+}
+
+func (l *requestLimiter) releaseLocked() {
+	l.running--
+	if len(l.turns) == 0 || l.running >= l.max {
+		return
+	}
+
+	group := l.turns[0]
+	l.turns = l.turns[1:]
+	queue := l.queues[group]
+	ready := queue[0]
+	if len(queue) > 1 {
+		l.queues[group] = queue[1:]
+		l.turns = append(l.turns, group)
+	} else {
+		delete(l.queues, group)
+	}
+	l.running++
+	close(ready)
+}
@@ -0,0 +1,61 @@
+package lsp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLimiterTakesTurns(t *testing.T) {
+	l := &requestLimiter{max: 1}
+	require.NoError(t, l.acquire(context.Background()))
+
+	// A batch queues three requests before another call queues one
+	batch := WithRequestGroup(context.Background())
+	other := WithRequestGroup(context.Background())
+	order := make(chan string, 4)
+	wait := func(ctx context.Context, name string) {
+		go func() {
+			if l.acquire(ctx) == nil {
+				order <- name
+			}
+		}()
+		// Let it queue
+		time.Sleep(10 * time.Millisecond)
+	}
+	wait(batch, "batch 1")
+	wait(batch, "batch 2")
+	wait(batch, "batch 3")
+	wait(other, "other")
+
+	var got []string
+	for range 4 {
+		l.release()
+		got = append(got, <-order)
+	}
+	assert.Equal(t, []string{"batch 1", "other", "batch 2", "batch 3"}, got)
+}
+
+func TestRequestLimiterCancel(t *testing.T) {
+	l := &requestLimiter{max: 1}
+	require.NoError(t, l.acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.acquire(ctx), context.DeadlineExceeded)
+	assert.Empty(t, l.turns)
+
+	l.release()
+	assert.NoError(t, l.acquire(context.Background()))
+	assert.Equal(t, 1, l.running)
+}
+
+func TestRequestLimiterUnlimited(t *testing.T) {
+	l := &requestLimiter{}
+	for range 100 {
+		require.NoError(t, l.acquire(context.Background()))
+	}
+}
@@ -0,0 +1,116 @@
+package lsp
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+const (
+	// MessageActionDismiss answers the server's message prompts without choosing an action
+	MessageActionDismiss = "dismiss"
+	// MessageActionFirst answers the server's message prompts with their first action
+	MessageActionFirst = "first"
+)
+
+// SetMessageAction sets how window/showMessageRequest prompts are answered, since there
+// is nobody to show them to: MessageActionDismiss (the default), MessageActionFirst, or
+// the title of the action to choose when a prompt offers it, dismissing the others.
+func (c *Client) SetMessageAction(action string) {
+	c.messageAction = action
+}
+
+// SetMessageHandler sets a function called with the messages worth the user's attention
+// the server shows: errors and warnings, and every prompt along with the action chosen.
+// It must be set before the client is initialized.
+func (c *Client) SetMessageHandler(handler func(msg protocol.ShowMessageParams)) {
+	c.onMessage = handler
+}
+
+// chooseMessageAction returns the action a prompt is answered with, or nil to dismiss it
+func chooseMessageAction(choice string, actions []protocol.MessageActionItem) *protocol.MessageActionItem {
+	switch choice {
+	case "", MessageActionDismiss:
+		return nil
+	case MessageActionFirst:
+		if len(actions) > 0 {
+			return &actions[0]
+		}
+		return nil
+	}
+	for i, action := range actions {
+		if strings.EqualFold(action.Title, choice) {
+			return &actions[i]
+		}
+	}
+	return nil
+}
+
+// logServerMessage logs a message of the server at the level matching its type
+func logServerMessage(kind protocol.MessageType, format string, args ...any) {
+	switch kind {
+	case protocol.Error:
+		lspLogger.Error(format, args...)
+	case protocol.Warning:
+		lspLogger.Warn(format, args...)
+	case protocol.Info:
+		lspLogger.Info(format, args...)
+	default:
+		lspLogger.Debug(format, args...)
+	}
+}
+
+// HandleServerMessage processes window/showMessage notifications from the server
+func HandleServerMessage(client *Client, params json.RawMessage) {
+	var msg protocol.ShowMessageParams
+	if err := json.Unmarshal(params, &msg); err != nil {
+		lspLogger.Error("Error unmarshaling server message: %v", err)
+		return
+	}
+
+	logServerMessage(msg.Type, "Server message: %s", msg.Message)
+	if client.onMessage != nil && msg.Type <= protocol.Warning {
+		client.onMessage(msg)
+	}
+}
+
+// HandleShowMessageRequest answers window/showMessageRequest prompts from the server
+// with the action chosen by SetMessageAction, so servers waiting on them carry on
+func HandleShowMessageRequest(client *Client, params json.RawMessage) (any, error) {
+	var req protocol.ShowMessageRequestParams
+	if err := json.Unmarshal(params, &req); err != nil {
+		lspLogger.Error("Error unmarshaling message request: %v", err)
+		return nil, err
+	}
+
+	chosen := chooseMessageAction(client.messageAction, req.Actions)
+	answer := "dismissed"
+	if chosen != nil {
+		answer = "answered " + chosen.Title
+	}
+	logServerMessage(req.Type, "Server prompt, %s: %s", answer, req.Message)
+	if client.onMessage != nil {
+		client.onMessage(protocol.ShowMessageParams{
+			Type:    req.Type,
+			Message: req.Message + " (" + answer + ")",
+		})
+	}
+
+	// A null result dismisses the prompt
+	if chosen == nil {
+		return nil, nil
+	}
+	return chosen, nil
+}
+
+// HandleLogMessage processes window/logMessage notifications from the server
+func HandleLogMessage(params json.RawMessage) {
+	var msg protocol.LogMessageParams
+	if err := json.Unmarshal(params, &msg); err != nil {
+		lspLogger.Error("Error unmarshaling log message: %v", err)
+		return
+	}
+
+	logServerMessage(msg.Type, "Server log: %s", msg.Message)
+}
@@ -0,0 +1,56 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleShowMessageRequest(t *testing.T) {
+	params, err := json.Marshal(protocol.ShowMessageRequestParams{
+		Type:    protocol.Warning,
+		Message: "Reload the workspace?",
+		Actions: []protocol.MessageActionItem{{Title: "Yes"}, {Title: "No"}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		action   string
+		expected any
+	}{
+		{"", nil},
+		{MessageActionDismiss, nil},
+		{MessageActionFirst, &protocol.MessageActionItem{Title: "Yes"}},
+		{"no", &protocol.MessageActionItem{Title: "No"}},
+		{"Cancel", nil},
+	}
+
+	for _, tt := range tests {
+		var shown []protocol.ShowMessageParams
+		client := &Client{}
+		client.SetMessageAction(tt.action)
+		client.SetMessageHandler(func(msg protocol.ShowMessageParams) { shown = append(shown, msg) })
+
+		result, err := HandleShowMessageRequest(client, params)
+		require.NoError(t, err)
+		if tt.expected == nil {
+			assert.Nil(t, result, "action %q", tt.action)
+		} else {
+			assert.Equal(t, tt.expected, result, "action %q", tt.action)
+		}
+		assert.Len(t, shown, 1)
+	}
+}
+
+func TestHandleServerMessage(t *testing.T) {
+	var shown []protocol.ShowMessageParams
+	client := &Client{}
+	client.SetMessageHandler(func(msg protocol.ShowMessageParams) { shown = append(shown, msg) })
+
+	HandleServerMessage(client, json.RawMessage(`{"type":1,"message":"failed to load the workspace"}`))
+	HandleServerMessage(client, json.RawMessage(`{"type":3,"message":"loaded"}`))
+	assert.Equal(t, []protocol.ShowMessageParams{{Type: protocol.Error, Message: "failed to load the workspace"}}, shown)
+}
@@ -0,0 +1,169 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// PathMapping maps a directory of the host to the directory the server sees it as, such
+// as the workspace mounted at /workspaces/app in a container
+type PathMapping struct {
+	// Host is the absolute path of the directory on the host
+	Host string
+	// Server is the absolute path of the directory where the server runs, with slashes
+	Server string
+}
+
+// PathMap translates the paths and file URIs in the messages exchanged with a server
+// whose filesystem differs from the host's, by the longest directory mapping matching
+// them, so that the server is told paths it can open and the tools show paths the agent
+// can open
+type PathMap []PathMapping
+
+// translateMessage translates the paths in the parameters and result of a message sent to
+// or received from the server
+func (m *PathMap) translateMessage(msg *Message, toServer bool) {
+	if m == nil {
+		return
+	}
+	msg.Params = m.translateJSON(msg.Params, toServer)
+	msg.Result = m.translateJSON(msg.Result, toServer)
+}
+
+// SetPathMap translates the paths in the messages exchanged with the language server from
+// now on. It must be set before the client is initialized.
+func (c *Client) SetPathMap(m PathMap) {
+	c.pathMap.Store(&m)
+}
+
+// ToServer translates a path of the host to the path the server sees, or returns it as is
+// if no mapping matches it
+func (m PathMap) ToServer(path string) string {
+	return m.translate(path, true)
+}
+
+// ToHost translates a path the server sees to the path of the host, or returns it as is
+// if no mapping matches it
+func (m PathMap) ToHost(path string) string {
+	return m.translate(path, false)
+}
+
+// translate translates a path or a file URI from one side to the other
+func (m PathMap) translate(s string, toServer bool) string {
+	uri := strings.HasPrefix(s, "file://")
+	best, rest := -1, ""
+	for i, mapping := range m {
+		from := mapping.from(toServer, uri)
+		if best >= 0 && len(from) <= len(m[best].from(toServer, uri)) {
+			continue
+		}
+		tail, ok := strings.CutPrefix(s, from)
+		if !ok {
+			continue
+		}
+		// Only host paths may use the separator of the host
+		if tail != "" && tail[0] != '/' && (uri || !toServer || tail[0] != filepath.Separator) {
+			continue
+		}
+		best, rest = i, tail
+	}
+	if best < 0 {
+		return s
+	}
+	if !uri {
+		if toServer {
+			rest = filepath.ToSlash(rest)
+		} else {
+			rest = filepath.FromSlash(rest)
+		}
+	}
+	return m[best].to(toServer, uri) + rest
+}
+
+// from returns the directory of the mapping that paths are translated from, as a path or
+// as a file URI
+func (p PathMapping) from(toServer, uri bool) string {
+	if toServer {
+		return p.hostDir(uri)
+	}
+	return p.serverDir(uri)
+}
+
+// to returns the directory of the mapping that paths are translated to
+func (p PathMapping) to(toServer, uri bool) string {
+	return p.from(!toServer, uri)
+}
+
+// hostDir returns the host directory as a path or as a file URI
+func (p PathMapping) hostDir(uri bool) string {
+	if uri {
+		return string(protocol.URIFromPath(p.Host))
+	}
+	return p.Host
+}
+
+// serverDir returns the server directory as a path or as a file URI
+func (p PathMapping) serverDir(uri bool) string {
+	if uri {
+		return (&url.URL{Scheme: "file", Path: p.Server}).String()
+	}
+	return p.Server
+}
+
+// translateJSON translates the paths and file URIs in the strings and object keys of a
+// message's parameters or result, such as the keys of a WorkspaceEdit's changes
+func (m PathMap) translateJSON(raw json.RawMessage, toServer bool) json.RawMessage {
+	if len(m) == 0 || len(raw) == 0 || !m.mentioned(raw, toServer) {
+		return raw
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return raw
+	}
+	translated, err := json.Marshal(m.translateValue(value, toServer))
+	if err != nil {
+		return raw
+	}
+	return translated
+}
+
+// mentioned reports whether a message may hold paths to translate, to spare decoding the
+// others
+func (m PathMap) mentioned(raw json.RawMessage, toServer bool) bool {
+	for _, mapping := range m {
+		path := mapping.from(toServer, false)
+		uriPath := strings.TrimPrefix(mapping.from(toServer, true), "file://")
+		if bytes.Contains(raw, []byte(path)) || bytes.Contains(raw, []byte(uriPath)) {
+			return true
+		}
+	}
+	return false
+}
+
+// translateValue translates the strings in a decoded JSON value
+func (m PathMap) translateValue(value any, toServer bool) any {
+	switch v := value.(type) {
+	case string:
+		return m.translate(v, toServer)
+	case []any:
+		for i, item := range v {
+			v[i] = m.translateValue(item, toServer)
+		}
+		return v
+	case map[string]any:
+		translated := make(map[string]any, len(v))
+		for key, item := range v {
+			translated[m.translate(key, toServer)] = m.translateValue(item, toServer)
+		}
+		return translated
+	default:
+		return value
+	}
+}
@@ -0,0 +1,69 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathMapTranslate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("host paths are Unix paths")
+	}
+	m := PathMap{
+		{Host: "/Users/me/app", Server: "/workspaces/app"},
+		{Host: "/Users/me/app/vendor", Server: "/opt/vendor"},
+		{Host: "/Users/me/go/pkg/mod", Server: "/go/pkg/mod"},
+	}
+
+	assert.Equal(t, "/workspaces/app", m.ToServer("/Users/me/app"))
+	assert.Equal(t, "/workspaces/app/main.go", m.ToServer("/Users/me/app/main.go"))
+	assert.Equal(t, "/opt/vendor/lib/a.go", m.ToServer("/Users/me/app/vendor/lib/a.go"))
+	assert.Equal(t, "file:///workspaces/app/main.go", m.ToServer("file:///Users/me/app/main.go"))
+	assert.Equal(t, "/Users/me/application/main.go", m.ToServer("/Users/me/application/main.go"))
+	assert.Equal(t, "package main", m.ToServer("package main"))
+
+	assert.Equal(t, "/Users/me/app/main.go", m.ToHost("/workspaces/app/main.go"))
+	assert.Equal(t, "file:///Users/me/go/pkg/mod/x@v1/x.go", m.ToHost("file:///go/pkg/mod/x@v1/x.go"))
+	assert.Equal(t, "file:///usr/local/go/src/fmt/print.go", m.ToHost("file:///usr/local/go/src/fmt/print.go"))
+}
+
+func TestPathMapTranslateJSON(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("host paths are Unix paths")
+	}
+	m := PathMap{{Host: "/Users/me/app", Server: "/workspaces/app"}}
+
+	raw := json.RawMessage(`{"changes":{"file:///workspaces/app/a.go":[{"newText":"x","range":{"start":{"line":12345678901,"character":0}}}]},"label":"/workspaces/app"}`)
+	translated := m.translateJSON(raw, false)
+	var edit map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(translated))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&edit))
+	assert.Equal(t, "/Users/me/app", edit["label"])
+	changes := edit["changes"].(map[string]any)
+	require.Contains(t, changes, "file:///Users/me/app/a.go")
+	assert.Contains(t, string(translated), `"line":12345678901`)
+
+	// Messages without mapped paths are left as they are
+	raw = json.RawMessage(`{"uri":"file:///tmp/a.go","text":"<a>"}`)
+	assert.Equal(t, raw, m.translateJSON(raw, true))
+}
+
+func TestPathMapTranslateMessage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("host paths are Unix paths")
+	}
+	m := PathMap{{Host: "/Users/me/app", Server: "/workspaces/app"}}
+	msg := &Message{Params: json.RawMessage(`{"textDocument":{"uri":"file:///Users/me/app/a.go"}}`)}
+	m.translateMessage(msg, true)
+	assert.JSONEq(t, `{"textDocument":{"uri":"file:///workspaces/app/a.go"}}`, string(msg.Params))
+
+	var none *PathMap
+	none.translateMessage(msg, false)
+	assert.JSONEq(t, `{"textDocument":{"uri":"file:///workspaces/app/a.go"}}`, string(msg.Params))
+}
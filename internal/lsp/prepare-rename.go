@@ -0,0 +1,25 @@
+package lsp
+
+import "github.com/isaacphi/mcp-language-server/internal/protocol"
+
+// supportsPrepareRename reports whether the server's capabilities offer
+// textDocument/prepareRename requests
+func supportsPrepareRename(capabilities protocol.ServerCapabilities) bool {
+	switch v := capabilities.RenameProvider.(type) {
+	case protocol.RenameOptions:
+		return v.PrepareProvider
+	case *protocol.RenameOptions:
+		return v != nil && v.PrepareProvider
+	case map[string]any:
+		// Capabilities are decoded without knowing which of its types the provider is
+		prepare, _ := v["prepareProvider"].(bool)
+		return prepare
+	}
+	return false
+}
+
+// SupportsPrepareRename reports whether the server can check that a position can be
+// renamed before renaming it
+func (c *Client) SupportsPrepareRename() bool {
+	return c.prepareRename
+}
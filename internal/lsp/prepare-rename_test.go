@@ -0,0 +1,22 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportsPrepareRename(t *testing.T) {
+	assert.False(t, supportsPrepareRename(protocol.ServerCapabilities{}))
+	assert.False(t, supportsPrepareRename(protocol.ServerCapabilities{RenameProvider: true}))
+	assert.True(t, supportsPrepareRename(protocol.ServerCapabilities{RenameProvider: protocol.RenameOptions{PrepareProvider: true}}))
+
+	var caps protocol.ServerCapabilities
+	require.NoError(t, json.Unmarshal([]byte(`{"renameProvider": {"prepareProvider": true}}`), &caps))
+	assert.True(t, supportsPrepareRename(caps))
+	require.NoError(t, json.Unmarshal([]byte(`{"renameProvider": {}}`), &caps))
+	assert.False(t, supportsPrepareRename(caps))
+}
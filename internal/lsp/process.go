@@ -0,0 +1,88 @@
+package lsp
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ProcessOptions change the environment the server process starts in from the one it
+// would inherit, for servers that need a toolchain the MCP host doesn't have on its PATH,
+// such as a node version managed by nvm or a rustup toolchain
+type ProcessOptions struct {
+	// Dir is the working directory of the server, the current one if empty
+	Dir string
+	// Env sets environment variables of the server, such as GOFLAGS, over those inherited
+	Env map[string]string
+	// Path are directories put in front of the PATH of the server, which are searched
+	// for the server command too
+	Path []string
+	// Launcher, if set, runs the server elsewhere, with Dir, Env and Path applied there
+	Launcher *Launcher
+}
+
+// cmd returns the command starting the server
+func (o ProcessOptions) cmd(name string, args []string) *exec.Cmd {
+	if o.Launcher != nil {
+		command := o.Launcher.command(o, name, args)
+		return exec.Command(command[0], command[1:]...)
+	}
+	if path, err := o.LookPath(name); err == nil {
+		name = path
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Dir = o.Dir
+	cmd.Env = o.environment()
+	return cmd
+}
+
+// environment returns the environment of the server: the inherited one with Env on top
+// and the Path directories in front of PATH
+func (o ProcessOptions) environment() []string {
+	env := os.Environ()
+	for _, key := range sortedKeys(o.Env) {
+		env = append(env, key+"="+o.Env[key])
+	}
+	if len(o.Path) == 0 {
+		return env
+	}
+
+	// The variable is Path on Windows, whose variables are case-insensitive
+	pathKey, path := "PATH", ""
+	for _, kv := range env {
+		if key, value, ok := strings.Cut(kv, "="); ok && strings.EqualFold(key, "PATH") {
+			pathKey, path = key, value
+		}
+	}
+	dirs := slices.Clone(o.Path)
+	if path != "" {
+		dirs = append(dirs, path)
+	}
+	return append(env, pathKey+"="+strings.Join(dirs, string(os.PathListSeparator)))
+}
+
+// LookPath finds the server command like exec.LookPath, searching the Path directories
+// before the inherited PATH if it is a bare name
+func (o ProcessOptions) LookPath(name string) (string, error) {
+	if !strings.ContainsRune(name, filepath.Separator) && !strings.ContainsRune(name, '/') {
+		for _, dir := range o.Path {
+			if found, err := exec.LookPath(filepath.Join(dir, name)); err == nil {
+				return found, nil
+			}
+		}
+	}
+	return exec.LookPath(name)
+}
+
+// sortedKeys returns the keys of an environment in order, for a stable command line
+func sortedKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,65 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lastValue returns the value the last entry for key has in env, which is the one a
+// process sees
+func lastValue(env []string, key string) string {
+	value := ""
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == key {
+			value = v
+		}
+	}
+	return value
+}
+
+func TestProcessOptionsEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("PATH is Path on Windows")
+	}
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	env := ProcessOptions{}.environment()
+	assert.Equal(t, "/usr/bin", lastValue(env, "PATH"))
+	assert.Equal(t, "-mod=mod", lastValue(env, "GOFLAGS"))
+
+	env = ProcessOptions{
+		Env:  map[string]string{"GOFLAGS": "-tags=integration", "NODE_OPTIONS": "--max-old-space-size=4096"},
+		Path: []string{"/opt/node/bin", "/opt/cargo/bin"},
+	}.environment()
+	assert.Equal(t, "-tags=integration", lastValue(env, "GOFLAGS"))
+	assert.Equal(t, "--max-old-space-size=4096", lastValue(env, "NODE_OPTIONS"))
+	assert.Equal(t, "/opt/node/bin:/opt/cargo/bin:/usr/bin", lastValue(env, "PATH"))
+}
+
+func TestProcessOptionsLookPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executables need an extension on Windows")
+	}
+	dir := t.TempDir()
+	server := filepath.Join(dir, "fake-language-server")
+	require.NoError(t, os.WriteFile(server, []byte("#!/bin/sh\n"), 0755))
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := ProcessOptions{}.LookPath("fake-language-server")
+	assert.Error(t, err)
+
+	found, err := ProcessOptions{Path: []string{t.TempDir(), dir}}.LookPath("fake-language-server")
+	require.NoError(t, err)
+	assert.Equal(t, server, found)
+
+	// Paths are not looked up in the Path directories
+	_, err = ProcessOptions{Path: []string{dir}}.LookPath("./fake-language-server")
+	assert.Error(t, err)
+}
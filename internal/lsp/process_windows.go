@@ -0,0 +1,24 @@
+//go:build windows
+
+package lsp
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing on Windows, where only the server process is killed
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the server process
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	err := cmd.Process.Kill()
+	if errors.Is(err, os.ErrProcessDone) {
+		return nil
+	}
+	return err
+}
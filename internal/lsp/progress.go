@@ -0,0 +1,235 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// ProgressEvent is a work done progress notification from the server, such as indexing
+// or a long running search
+type ProgressEvent struct {
+	// Token identifies the operation, chosen by the server or by the client in the
+	// workDoneToken of its request
+	Token any
+	// Title names the operation, e.g. "Indexing"
+	Title string
+	// Message gives details, e.g. "3/25 files"
+	Message string
+	// Percentage is between 0 and 100, or -1 if the server did not report one
+	Percentage int
+	// Done is set on the last event of an operation
+	Done bool
+}
+
+// String formats the event for display, e.g. "Indexing: 3/25 files (12%)"
+func (e ProgressEvent) String() string {
+	s := e.Title
+	if e.Message != "" {
+		if s != "" {
+			s += ": "
+		}
+		s += e.Message
+	}
+	if e.Done {
+		return s + " (done)"
+	}
+	if e.Percentage >= 0 {
+		s += fmt.Sprintf(" (%d%%)", e.Percentage)
+	}
+	return s
+}
+
+// IndexingState tells whether the server is busy with work it reports progress for, such
+// as indexing the workspace
+type IndexingState int
+
+const (
+	// IndexingUnknown means the server hasn't reported any progress yet
+	IndexingUnknown IndexingState = iota
+	// IndexingBusy means operations the server began haven't ended
+	IndexingBusy
+	// IndexingIdle means every operation the server began has ended
+	IndexingIdle
+)
+
+func (s IndexingState) String() string {
+	switch s {
+	case IndexingBusy:
+		return "busy"
+	case IndexingIdle:
+		return "idle"
+	default:
+		return "unknown"
+	}
+}
+
+// progressTracker follows the server's work done progress: the tokens it created, the
+// latest event of each operation in progress, whose title the server only sends with the
+// first notification, and the functions watching progress
+type progressTracker struct {
+	mu       sync.Mutex
+	created  map[any]bool
+	active   map[any]ProgressEvent
+	state    IndexingState
+	idle     chan struct{}
+	watchers map[int]func(ProgressEvent)
+	nextID   int
+}
+
+// Indexing returns the indexing state of the server, and the latest event of each
+// operation in progress ordered by title
+func (c *Client) Indexing() (IndexingState, []ProgressEvent) {
+	c.progress.mu.Lock()
+	defer c.progress.mu.Unlock()
+
+	events := make([]ProgressEvent, 0, len(c.progress.active))
+	for _, event := range c.progress.active {
+		events = append(events, event)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Title < events[j].Title })
+	return c.progress.state, events
+}
+
+// WaitForIndexing waits until the server is no longer busy with operations it reports
+// progress for, or ctx is done
+func (c *Client) WaitForIndexing(ctx context.Context) error {
+	c.progress.mu.Lock()
+	idle := c.progress.idle
+	busy := c.progress.state == IndexingBusy
+	c.progress.mu.Unlock()
+	if !busy {
+		return nil
+	}
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WatchProgress calls fn for every progress notification from the server until the
+// returned function is called
+func (c *Client) WatchProgress(fn func(ProgressEvent)) (stop func()) {
+	c.progress.mu.Lock()
+	defer c.progress.mu.Unlock()
+
+	if c.progress.watchers == nil {
+		c.progress.watchers = make(map[int]func(ProgressEvent))
+	}
+	id := c.progress.nextID
+	c.progress.nextID++
+	c.progress.watchers[id] = fn
+
+	return func() {
+		c.progress.mu.Lock()
+		defer c.progress.mu.Unlock()
+		delete(c.progress.watchers, id)
+	}
+}
+
+// HandleProgress processes $/progress notifications carrying work done progress
+func HandleProgress(client *Client, params json.RawMessage) {
+	var progressParams protocol.ProgressParams
+	if err := json.Unmarshal(params, &progressParams); err != nil {
+		lspLogger.Error("Error unmarshaling progress params: %v", err)
+		return
+	}
+
+	value, err := json.Marshal(progressParams.Value)
+	if err != nil {
+		return
+	}
+	var report struct {
+		Kind       string  `json:"kind"`
+		Title      string  `json:"title"`
+		Message    string  `json:"message"`
+		Percentage *uint32 `json:"percentage"`
+	}
+	if err := json.Unmarshal(value, &report); err != nil || report.Kind == "" {
+		// Partial results use $/progress too, but are not work done progress
+		return
+	}
+
+	event := ProgressEvent{
+		Token:      progressParams.Token.Value,
+		Title:      report.Title,
+		Message:    report.Message,
+		Percentage: -1,
+		Done:       report.Kind == "end",
+	}
+	if report.Percentage != nil {
+		event.Percentage = int(*report.Percentage)
+	}
+
+	token := progressParams.Token.Value
+	tracker := &client.progress
+
+	tracker.mu.Lock()
+	if tracker.active == nil {
+		tracker.active = make(map[any]ProgressEvent)
+	}
+	if report.Kind != "begin" {
+		event.Title = tracker.active[token].Title
+	}
+	delete(tracker.created, token)
+	if event.Done {
+		delete(tracker.active, token)
+	} else {
+		tracker.active[token] = event
+	}
+	tracker.updateState()
+	watchers := make([]func(ProgressEvent), 0, len(tracker.watchers))
+	for _, fn := range tracker.watchers {
+		watchers = append(watchers, fn)
+	}
+	tracker.mu.Unlock()
+
+	lspLogger.Debug("Progress: %s", event)
+
+	for _, fn := range watchers {
+		fn(event)
+	}
+}
+
+// updateState moves the indexing state on after the operations in progress changed,
+// closing the idle channel when the server becomes idle. Tokens created for operations
+// that haven't begun yet count as in progress. It is called with mu held.
+func (t *progressTracker) updateState() {
+	busy := len(t.active)+len(t.created) > 0
+	switch {
+	case busy && t.state != IndexingBusy:
+		t.state = IndexingBusy
+		t.idle = make(chan struct{})
+		lspLogger.Info("Language server is busy")
+	case !busy && t.state == IndexingBusy:
+		t.state = IndexingIdle
+		close(t.idle)
+		lspLogger.Info("Language server is idle")
+	}
+}
+
+// HandleWorkDoneProgressCreate accepts the server's request to report progress, and
+// remembers the token until the operation begins
+func HandleWorkDoneProgressCreate(client *Client, params json.RawMessage) (any, error) {
+	var createParams protocol.WorkDoneProgressCreateParams
+	if err := json.Unmarshal(params, &createParams); err != nil {
+		lspLogger.Error("Error unmarshaling progress create params: %v", err)
+		return nil, err
+	}
+
+	client.progress.mu.Lock()
+	defer client.progress.mu.Unlock()
+	if client.progress.created == nil {
+		client.progress.created = make(map[any]bool)
+	}
+	client.progress.created[createParams.Token.Value] = true
+	client.progress.updateState()
+	return nil, nil
+}
@@ -0,0 +1,70 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleProgress(t *testing.T) {
+	client := &Client{}
+	var events []ProgressEvent
+	stop := client.WatchProgress(func(event ProgressEvent) {
+		events = append(events, event)
+	})
+
+	notifications := []string{
+		`{"token":"rustAnalyzer/Indexing","value":{"kind":"begin","title":"Indexing","percentage":0}}`,
+		`{"token":"rustAnalyzer/Indexing","value":{"kind":"report","message":"3/25 (core)","percentage":12}}`,
+		`{"token":7,"value":[{"partial":"result"}]}`,
+		`{"token":"rustAnalyzer/Indexing","value":{"kind":"end"}}`,
+	}
+	for _, n := range notifications {
+		HandleProgress(client, json.RawMessage(n))
+	}
+	assert.Empty(t, client.progress.active)
+
+	stop()
+	HandleProgress(client, json.RawMessage(`{"token":1,"value":{"kind":"begin","title":"Searching"}}`))
+
+	assert.Equal(t, []ProgressEvent{
+		{Token: "rustAnalyzer/Indexing", Title: "Indexing", Percentage: 0},
+		{Token: "rustAnalyzer/Indexing", Title: "Indexing", Message: "3/25 (core)", Percentage: 12},
+		{Token: "rustAnalyzer/Indexing", Title: "Indexing", Percentage: -1, Done: true},
+	}, events)
+	assert.Equal(t, "Indexing: 3/25 (core) (12%)", events[1].String())
+	assert.Equal(t, "Indexing (done)", events[2].String())
+}
+
+func TestIndexingState(t *testing.T) {
+	client := &Client{}
+	state, _ := client.Indexing()
+	assert.Equal(t, IndexingUnknown, state)
+	assert.NoError(t, client.WaitForIndexing(context.Background()))
+
+	_, err := HandleWorkDoneProgressCreate(client, json.RawMessage(`{"token":"index"}`))
+	assert.NoError(t, err)
+	state, _ = client.Indexing()
+	assert.Equal(t, IndexingBusy, state)
+
+	HandleProgress(client, json.RawMessage(`{"token":"index","value":{"kind":"begin","title":"Indexing"}}`))
+	HandleProgress(client, json.RawMessage(`{"token":"index","value":{"kind":"report","message":"3/25","percentage":12}}`))
+	state, events := client.Indexing()
+	assert.Equal(t, IndexingBusy, state)
+	assert.Equal(t, []ProgressEvent{{Token: "index", Title: "Indexing", Message: "3/25", Percentage: 12}}, events)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.WaitForIndexing(ctx), context.DeadlineExceeded)
+
+	done := make(chan error)
+	go func() { done <- client.WaitForIndexing(context.Background()) }()
+	HandleProgress(client, json.RawMessage(`{"token":"index","value":{"kind":"end"}}`))
+	assert.NoError(t, <-done)
+	state, events = client.Indexing()
+	assert.Equal(t, IndexingIdle, state)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,126 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// QueryOpenMode decides whether read-only queries such as hover or references open the
+// files they are about. Opening a file makes some servers analyse it in full and keep it
+// in memory, which adds up while exploring a large workspace.
+type QueryOpenMode string
+
+const (
+	// QueryOpenAlways opens files for queries and keeps them open
+	QueryOpenAlways QueryOpenMode = "open"
+	// QueryOpenTTL opens files for queries and closes them once they have not been
+	// queried for a while, unless something else opened them too
+	QueryOpenTTL QueryOpenMode = "ttl"
+	// QueryOpenNever queries files without opening them, for servers that answer from
+	// the files on disk
+	QueryOpenNever QueryOpenMode = "skip"
+)
+
+// DefaultQueryOpenTTL is how long a file opened for queries stays open in QueryOpenTTL mode
+const DefaultQueryOpenTTL = 5 * time.Minute
+
+// ParseQueryOpenMode validates a query open mode name. An empty name selects
+// QueryOpenAlways.
+func ParseQueryOpenMode(name string) (QueryOpenMode, error) {
+	switch mode := QueryOpenMode(name); mode {
+	case "":
+		return QueryOpenAlways, nil
+	case QueryOpenAlways, QueryOpenTTL, QueryOpenNever:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown query open mode %q, expected open, ttl or skip", name)
+	}
+}
+
+// SetQueryOpenMode sets how OpenFileForQuery treats files. In QueryOpenTTL mode files
+// opened only for queries are closed after ttl without queries, or DefaultQueryOpenTTL if
+// ttl is not positive. It must be called before the client is used.
+func (c *Client) SetQueryOpenMode(mode QueryOpenMode, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultQueryOpenTTL
+	}
+	c.queryOpenMode = mode
+	c.queryOpenTTL = ttl
+	if mode == QueryOpenTTL {
+		go c.closeIdleQueryFilesLoop()
+	}
+}
+
+// OpenFileForQuery prepares a file for a read-only query according to the query open
+// mode. Files opened with OpenFile stay open whatever the mode. Documents that are not
+// files on disk, such as dependency sources, are queried without opening them.
+func (c *Client) OpenFileForQuery(ctx context.Context, filepath string) error {
+	if protocol.IsNonFileURI(filepath) {
+		return nil
+	}
+	switch c.queryOpenMode {
+	case QueryOpenNever:
+		c.touchFile(filepath)
+		// Still fail for missing files as OpenFile would
+		if _, err := os.Stat(filepath); err != nil {
+			return fmt.Errorf("error reading file: %w", err)
+		}
+		return nil
+	case QueryOpenTTL:
+		return c.openFile(ctx, filepath, true)
+	default:
+		return c.OpenFile(ctx, filepath)
+	}
+}
+
+// touchFile records that an open file was queried
+func (c *Client) touchFile(filepath string) {
+	uri := string(protocol.URIFromPath(filepath))
+	c.openFilesMu.Lock()
+	defer c.openFilesMu.Unlock()
+	if fileInfo, ok := c.openFiles[uri]; ok {
+		fileInfo.lastUsed = time.Now()
+	}
+}
+
+// closeIdleQueryFilesLoop closes idle files opened for queries until the client is closed
+func (c *Client) closeIdleQueryFilesLoop() {
+	ticker := time.NewTicker(max(c.queryOpenTTL/4, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case now := <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			c.closeIdleQueryFiles(ctx, now)
+			cancel()
+		}
+	}
+}
+
+// closeIdleQueryFiles closes the files opened only for queries that have not been
+// queried for the query open TTL as of now
+func (c *Client) closeIdleQueryFiles(ctx context.Context, now time.Time) {
+	c.openFilesMu.RLock()
+	var filesToClose []string
+	for _, fileInfo := range c.openFiles {
+		if fileInfo.queryOnly && now.Sub(fileInfo.lastUsed) >= c.queryOpenTTL {
+			filesToClose = append(filesToClose, fileInfo.URI.Path())
+		}
+	}
+	c.openFilesMu.RUnlock()
+
+	for _, filePath := range filesToClose {
+		if err := c.CloseFile(ctx, filePath); err != nil {
+			lspLogger.Warn("Error closing idle file %s: %v", filePath, err)
+		}
+	}
+	if len(filesToClose) > 0 {
+		lspLogger.Debug("Closed %d files idle for %s", len(filesToClose), c.queryOpenTTL)
+	}
+}
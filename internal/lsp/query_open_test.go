@@ -0,0 +1,67 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQueryOpenMode(t *testing.T) {
+	mode, err := ParseQueryOpenMode("")
+	require.NoError(t, err)
+	assert.Equal(t, QueryOpenAlways, mode)
+
+	mode, err = ParseQueryOpenMode("ttl")
+	require.NoError(t, err)
+	assert.Equal(t, QueryOpenTTL, mode)
+
+	_, err = ParseQueryOpenMode("lazy")
+	assert.Error(t, err)
+}
+
+func TestOpenFileForQuery(t *testing.T) {
+	dir := t.TempDir()
+	queried := filepath.Join(dir, "queried.go")
+	edited := filepath.Join(dir, "edited.go")
+	for _, path := range []string{queried, edited} {
+		require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0644))
+	}
+	ctx := context.Background()
+
+	t.Run("skip", func(t *testing.T) {
+		client := &Client{
+			stdin:         nopWriteCloser{&bytes.Buffer{}},
+			openFiles:     make(map[string]*OpenFileInfo),
+			queryOpenMode: QueryOpenNever,
+		}
+		require.NoError(t, client.OpenFileForQuery(ctx, queried))
+		assert.False(t, client.IsFileOpen(queried))
+		assert.Error(t, client.OpenFileForQuery(ctx, filepath.Join(dir, "missing.go")))
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		client := &Client{
+			stdin:         nopWriteCloser{&bytes.Buffer{}},
+			openFiles:     make(map[string]*OpenFileInfo),
+			queryOpenMode: QueryOpenTTL,
+			queryOpenTTL:  time.Minute,
+		}
+		require.NoError(t, client.OpenFileForQuery(ctx, queried))
+		require.NoError(t, client.OpenFileForQuery(ctx, edited))
+		// Opening a file for an edit keeps it open after the queries
+		require.NoError(t, client.OpenFile(ctx, edited))
+
+		client.closeIdleQueryFiles(ctx, time.Now().Add(30*time.Second))
+		assert.True(t, client.IsFileOpen(queried))
+
+		client.closeIdleQueryFiles(ctx, time.Now().Add(2*time.Minute))
+		assert.False(t, client.IsFileOpen(queried))
+		assert.True(t, client.IsFileOpen(edited))
+	})
+}
@@ -0,0 +1,85 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Recorder writes a recording of a session to replay later, see Replay: the tool calls
+// with their results and every message exchanged with the language server, one JSON
+// object per line. Messages are recorded with the paths of the host, before and after
+// any path mapping, so that a recording doesn't depend on where the server ran.
+type Recorder struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// recordEvent is a line of a recording
+type recordEvent struct {
+	Time time.Time `json:"time"`
+	// Type is "start" when a language server starts, "send" and "receive" for the
+	// messages exchanged with it, and "tool" for a tool call
+	Type      string          `json:"type"`
+	Workspace string          `json:"workspace,omitempty"`
+	Command   []string        `json:"command,omitempty"`
+	Message   *Message        `json:"message,omitempty"`
+	Tool      string          `json:"tool,omitempty"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+}
+
+// NewRecorder returns a recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{encoder: json.NewEncoder(w)}
+}
+
+// SetRecorder records the messages exchanged with the language server from now on
+func (c *Client) SetRecorder(r *Recorder) {
+	c.recorder.Store(r)
+}
+
+// Start records that a language server started with command for workspace. The messages
+// recorded until the next start are exchanged with that server.
+func (r *Recorder) Start(workspace string, command []string) {
+	r.record(recordEvent{Type: "start", Workspace: workspace, Command: command})
+}
+
+// ToolCall records a tool call with its arguments and its result
+func (r *Recorder) ToolCall(name string, arguments, result any) {
+	event := recordEvent{Type: "tool", Tool: name}
+	var err error
+	if event.Arguments, err = json.Marshal(arguments); err != nil {
+		lspLogger.Error("Failed to record the arguments of %s: %v", name, err)
+		return
+	}
+	if event.Result, err = json.Marshal(result); err != nil {
+		lspLogger.Error("Failed to record the result of %s: %v", name, err)
+		return
+	}
+	r.record(event)
+}
+
+// recordSent records a message sent to the language server
+func (r *Recorder) recordSent(msg *Message) {
+	r.record(recordEvent{Type: "send", Message: msg})
+}
+
+// recordReceived records a message received from the language server
+func (r *Recorder) recordReceived(msg *Message) {
+	r.record(recordEvent{Type: "receive", Message: msg})
+}
+
+func (r *Recorder) record(event recordEvent) {
+	if r == nil {
+		return
+	}
+	event.Time = time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.encoder.Encode(event); err != nil {
+		lspLogger.Error("Failed to write the recording: %v", err)
+	}
+}
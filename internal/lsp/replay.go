@@ -0,0 +1,323 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+)
+
+// Recording is a session recorded by a Recorder, to replay without a language server
+type Recording struct {
+	// Workspace and Command are those of the first language server recorded
+	Workspace string
+	Command   []string
+
+	exchanges []*exchange
+	tools     []*recordedTool
+	mu        sync.Mutex
+}
+
+// exchange is a request or notification sent to the server with what the server sent
+// back: the response to the request, and the notifications and requests it sent until
+// the next message it was sent
+type exchange struct {
+	method   string
+	params   string
+	response *Message
+	followUp []*Message
+	used     bool
+}
+
+// recordedTool is a recorded tool call
+type recordedTool struct {
+	name      string
+	arguments string
+	result    json.RawMessage
+	used      bool
+}
+
+// LoadRecording reads a recording written by a Recorder
+func LoadRecording(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer f.Close()
+	recording, err := readRecording(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recording %s: %w", path, err)
+	}
+	return recording, nil
+}
+
+func readRecording(r io.Reader) (*Recording, error) {
+	recording := &Recording{}
+	started := false
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	var current *exchange
+	pending := make(map[string]*exchange)
+	for line := 1; ; line++ {
+		var event recordEvent
+		if err := decoder.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("event %d: %w", line, err)
+		}
+		msg := event.Message
+		switch {
+		case event.Type == "start":
+			if !started {
+				started = true
+				recording.Workspace, recording.Command = event.Workspace, event.Command
+			}
+			// Request IDs start over with each server
+			current = nil
+			pending = make(map[string]*exchange)
+		case event.Type == "tool":
+			recording.tools = append(recording.tools, &recordedTool{
+				name:      event.Tool,
+				arguments: canonicalJSON(event.Arguments),
+				result:    event.Result,
+			})
+		case msg == nil:
+			return nil, fmt.Errorf("event %d: %s event without a message", line, event.Type)
+		case event.Type == "send" && msg.Method != "":
+			current = &exchange{method: msg.Method, params: canonicalJSON(msg.Params)}
+			recording.exchanges = append(recording.exchanges, current)
+			if msg.ID != nil && msg.ID.Value != nil {
+				pending[msg.ID.String()] = current
+			}
+		case event.Type == "receive" && msg.Method != "":
+			if current != nil {
+				current.followUp = append(current.followUp, msg)
+			}
+		case event.Type == "receive" && msg.ID != nil:
+			if request, ok := pending[msg.ID.String()]; ok {
+				request.response = msg
+				delete(pending, msg.ID.String())
+			}
+		}
+	}
+	if !started {
+		return nil, fmt.Errorf("no language server was recorded")
+	}
+	return recording, nil
+}
+
+// volatileParams are the parameters that differ between sessions doing the same thing,
+// left out when matching messages against the recording
+var volatileParams = map[string]bool{
+	"processId":          true,
+	"workDoneToken":      true,
+	"partialResultToken": true,
+}
+
+// canonicalJSON returns a JSON value with its object keys sorted and without volatile
+// parameters, to compare values regardless of how they were written
+func canonicalJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return string(raw)
+	}
+	canonical, err := json.Marshal(withoutVolatile(value))
+	if err != nil {
+		return string(raw)
+	}
+	return string(canonical)
+}
+
+func withoutVolatile(value any) any {
+	switch v := value.(type) {
+	case []any:
+		for i, item := range v {
+			v[i] = withoutVolatile(item)
+		}
+	case map[string]any:
+		for key, item := range v {
+			if volatileParams[key] {
+				delete(v, key)
+			} else {
+				v[key] = withoutVolatile(item)
+			}
+		}
+	}
+	return value
+}
+
+// match returns the recorded exchange for a message sent to the server: the first one
+// not replayed yet with the same method and parameters, or else the last one replayed
+func (r *Recording) match(msg *Message) *exchange {
+	params := canonicalJSON(msg.Params)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var replayed *exchange
+	for _, e := range r.exchanges {
+		if e.method != msg.Method || e.params != params {
+			continue
+		}
+		if !e.used {
+			e.used = true
+			return e
+		}
+		replayed = e
+	}
+	return replayed
+}
+
+// ToolResult returns the recorded result of a tool call with the same name and arguments,
+// the first one not returned yet or else the last one, and whether there is one
+func (r *Recording) ToolResult(name string, arguments any) (json.RawMessage, bool) {
+	raw, err := json.Marshal(arguments)
+	if err != nil {
+		return nil, false
+	}
+	args := canonicalJSON(raw)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var returned *recordedTool
+	for _, t := range r.tools {
+		if t.name != name || t.arguments != args {
+			continue
+		}
+		if !t.used {
+			t.used = true
+			return t.result, true
+		}
+		returned = t
+	}
+	if returned == nil {
+		return nil, false
+	}
+	return returned.result, true
+}
+
+// SameResult reports whether two tool results are the same, regardless of how their JSON
+// was written
+func SameResult(a, b json.RawMessage) bool {
+	return canonicalJSON(a) == canonicalJSON(b)
+}
+
+// Replay returns a client talking to a stand-in for the language server that answers from
+// a recording, for deterministic tests and demos without the server: each message sent to
+// it is matched with a recorded one with the same method and parameters, and gets the
+// response and the notifications and requests the server sent after it. Requests that
+// weren't recorded fail. command, if given, replaces the recorded command to recognize
+// servers needing particular initialization. The client must then be initialized with
+// InitializeLSPClient.
+func Replay(recording *Recording, command ...string) *Client {
+	clientConn, serverConn := net.Pipe()
+	go recording.serve(serverConn)
+
+	client := newClient(clientConn, clientConn)
+	client.command = command
+	if len(command) == 0 {
+		client.command = recording.Command
+	}
+	go client.handleMessages()
+	return client
+}
+
+// serve answers the messages read from conn from the recording until conn is closed
+func (r *Recording) serve(conn net.Conn) {
+	defer conn.Close()
+	// Messages are written in the background, as the client may be writing its answer to
+	// a request of ours while we write
+	out := newOutbox(conn)
+	defer out.close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		msg, err := ReadMessage(reader)
+		if err != nil {
+			return
+		}
+		// Answers to the recorded requests of the server are left unread
+		if msg.Method == "" {
+			continue
+		}
+		if msg.Method == "exit" {
+			return
+		}
+		e := r.match(msg)
+		if e != nil {
+			for _, followUp := range e.followUp {
+				out.push(followUp)
+			}
+		}
+		if msg.ID == nil || msg.ID.Value == nil {
+			continue
+		}
+		response := &Message{JSONRPC: "2.0", ID: msg.ID}
+		switch {
+		case e != nil && e.response != nil:
+			response.Result, response.Error = e.response.Result, e.response.Error
+		case msg.Method == "shutdown":
+			response.Result = json.RawMessage("null")
+		default:
+			lspLogger.Warn("No recorded response to %s", msg.Method)
+			response.Error = &ResponseError{Code: -32803, Message: fmt.Sprintf("no recorded response to %s", msg.Method)}
+		}
+		out.push(response)
+	}
+}
+
+// outbox writes messages in order in the background, without ever blocking the sender
+type outbox struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	messages []*Message
+	closed   bool
+}
+
+func newOutbox(w io.Writer) *outbox {
+	o := &outbox{}
+	o.cond = sync.NewCond(&o.mu)
+	go func() {
+		for {
+			o.mu.Lock()
+			for len(o.messages) == 0 && !o.closed {
+				o.cond.Wait()
+			}
+			messages := o.messages
+			o.messages = nil
+			closed := o.closed
+			o.mu.Unlock()
+
+			for _, msg := range messages {
+				if err := WriteMessage(w, msg); err != nil {
+					return
+				}
+			}
+			if closed {
+				return
+			}
+		}
+	}()
+	return o
+}
+
+func (o *outbox) push(msg *Message) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.closed {
+		o.messages = append(o.messages, msg)
+		o.cond.Signal()
+	}
+}
+
+func (o *outbox) close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.closed = true
+	o.cond.Signal()
+}
@@ -0,0 +1,95 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer answers every request with its method, and every notification with a
+// notification repeating its parameters
+func fakeServer(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		msg, err := ReadMessage(reader)
+		if err != nil {
+			return
+		}
+		reply := &Message{JSONRPC: "2.0", ID: msg.ID}
+		if msg.ID == nil {
+			reply.Method, reply.Params = "test/echo", msg.Params
+		} else {
+			reply.Result, _ = json.Marshal(msg.Method)
+		}
+		if err := WriteMessage(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// session makes a request and a notification, and returns the request's result and the
+// parameters of the notification the server sent back
+func session(t *testing.T, client *Client) (string, string) {
+	echoes := make(chan string, 1)
+	client.RegisterNotificationHandler("test/echo", func(params json.RawMessage) {
+		echoes <- string(params)
+	})
+
+	var method string
+	require.NoError(t, client.Call(context.Background(), "workspace/symbol", map[string]any{"query": "Foo", "workDoneToken": time.Now().String()}, &method))
+	require.NoError(t, client.Notify(context.Background(), "test/notify", map[string]int{"n": 1}))
+	select {
+	case echo := <-echoes:
+		return method, echo
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification from the server")
+		return "", ""
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	recorder.Start("/workspace", []string{"fakels", "--stdio"})
+
+	clientConn, serverConn := net.Pipe()
+	go fakeServer(serverConn)
+	client := newClient(clientConn, clientConn)
+	client.SetRecorder(recorder)
+	go client.handleMessages()
+	method, echo := session(t, client)
+	assert.Equal(t, "workspace/symbol", method)
+	assert.JSONEq(t, `{"n":1}`, echo)
+	recorder.ToolCall("symbol_search", map[string]any{"query": "Foo"}, map[string]any{"content": []string{"Foo"}})
+	require.NoError(t, client.Close())
+
+	recording, err := readRecording(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "/workspace", recording.Workspace)
+	assert.Equal(t, []string{"fakels", "--stdio"}, recording.Command)
+
+	replay := Replay(recording)
+	assert.Equal(t, []string{"fakels", "--stdio"}, replay.command)
+	method, echo = session(t, replay)
+	assert.Equal(t, "workspace/symbol", method)
+	assert.JSONEq(t, `{"n":1}`, echo)
+
+	// Requests that weren't recorded fail
+	err = replay.Call(context.Background(), "workspace/symbol", map[string]string{"query": "Bar"}, &method)
+	assert.ErrorContains(t, err, "no recorded response")
+	require.NoError(t, replay.Close())
+
+	result, ok := recording.ToolResult("symbol_search", map[string]any{"query": "Foo"})
+	require.True(t, ok)
+	assert.True(t, SameResult(json.RawMessage(`{"content": ["Foo"]}`), result))
+	_, ok = recording.ToolResult("symbol_search", map[string]any{"query": "Bar"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,54 @@
+package lsp
+
+import (
+	"context"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// RunnablesParams are the parameters of rust-analyzer's experimental/runnables request.
+// Without a position, the runnables of the whole file are returned.
+type RunnablesParams struct {
+	TextDocument protocol.TextDocumentIdentifier `json:"textDocument"`
+	Position     *protocol.Position              `json:"position,omitempty"`
+}
+
+// Runnable is something rust-analyzer knows how to run, such as a test, a binary or the
+// tests of a module, with the command that runs it
+type Runnable struct {
+	// Label names the runnable, e.g. "test tests::parse" or "run main"
+	Label    string                 `json:"label"`
+	Location *protocol.LocationLink `json:"location,omitempty"`
+	// Kind is "cargo" for cargo commands and "shell" for other programs
+	Kind string       `json:"kind"`
+	Args RunnableArgs `json:"args"`
+}
+
+// RunnableArgs are the arguments of a runnable: CargoArgs and ExecutableArgs for cargo
+// runnables, which run cargo <CargoArgs> -- <ExecutableArgs>, and Program and
+// ProgramArgs for shell runnables
+type RunnableArgs struct {
+	WorkspaceRoot  string            `json:"workspaceRoot,omitempty"`
+	Cwd            string            `json:"cwd,omitempty"`
+	Environment    map[string]string `json:"environment,omitempty"`
+	OverrideCargo  string            `json:"overrideCargo,omitempty"`
+	CargoArgs      []string          `json:"cargoArgs,omitempty"`
+	ExecutableArgs []string          `json:"executableArgs,omitempty"`
+	Program        string            `json:"program,omitempty"`
+	ProgramArgs    []string          `json:"args,omitempty"`
+}
+
+// Runnables sends rust-analyzer's experimental/runnables request, which lists the
+// runnables of a file or those at a position
+func (c *Client) Runnables(ctx context.Context, params RunnablesParams) ([]Runnable, error) {
+	var result []Runnable
+	err := c.Call(ctx, "experimental/runnables", params, &result)
+	return result, err
+}
+
+// experimentalCapability reports whether a server announced an experimental capability,
+// which servers such as rust-analyzer use for their extensions to the protocol
+func experimentalCapability(c protocol.ServerCapabilities, name string) bool {
+	experimental, ok := c.Experimental.(map[string]any)
+	return ok && providerEnabled(experimental[name])
+}
@@ -1,30 +1,19 @@
 package lsp
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
 
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 	"github.com/isaacphi/mcp-language-server/internal/utilities"
 )
 
-// FileWatchHandler is called when file watchers are registered by the server
-type FileWatchHandler func(id string, watchers []protocol.FileSystemWatcher)
-
-// fileWatchHandler holds the current file watch handler
-var fileWatchHandler FileWatchHandler
-
-// RegisterFileWatchHandler registers a handler for file watcher registrations
-func RegisterFileWatchHandler(handler FileWatchHandler) {
-	fileWatchHandler = handler
-}
-
 // Requests
 
-func HandleWorkspaceConfiguration(params json.RawMessage) (any, error) {
-	return []map[string]any{{}}, nil
-}
-
-func HandleRegisterCapability(params json.RawMessage) (any, error) {
+func HandleRegisterCapability(client *Client, params json.RawMessage) (any, error) {
 	var registerParams protocol.RegistrationParams
 	if err := json.Unmarshal(params, &registerParams); err != nil {
 		lspLogger.Error("Error unmarshaling registration params: %v", err)
@@ -50,24 +39,86 @@ func HandleRegisterCapability(params json.RawMessage) (any, error) {
 				continue
 			}
 
-			// Notify file watchers
-			if fileWatchHandler != nil {
-				fileWatchHandler(reg.ID, opts.Watchers)
-			}
+			client.addFileWatchers(reg.ID, opts.Watchers)
 		}
 	}
 
+	client.addRegistrations(registerParams.Registrations)
+	return nil, nil
+}
+
+func HandleUnregisterCapability(client *Client, params json.RawMessage) (any, error) {
+	var unregisterParams protocol.UnregistrationParams
+	if err := json.Unmarshal(params, &unregisterParams); err != nil {
+		lspLogger.Error("Error unmarshaling unregistration params: %v", err)
+		return nil, err
+	}
+
+	for _, unreg := range unregisterParams.Unregisterations {
+		lspLogger.Info("Unregistration received for method: %s, id: %s", unreg.Method, unreg.ID)
+	}
+
+	client.removeRegistrations(unregisterParams.Unregisterations)
 	return nil, nil
 }
 
-func HandleApplyEdit(params json.RawMessage) (any, error) {
+// ApplyEditPolicy decides which workspace/applyEdit requests from the server are applied
+type ApplyEditPolicy string
+
+const (
+	// ApplyEditAllow applies every edit the server sends
+	ApplyEditAllow ApplyEditPolicy = "allow"
+	// ApplyEditWorkspace applies edits that only touch files inside the workspace
+	ApplyEditWorkspace ApplyEditPolicy = "workspace"
+	// ApplyEditDeny rejects every edit the server sends
+	ApplyEditDeny ApplyEditPolicy = "deny"
+)
+
+// ParseApplyEditPolicy validates an apply edit policy name. An empty name selects
+// ApplyEditWorkspace.
+func ParseApplyEditPolicy(name string) (ApplyEditPolicy, error) {
+	switch policy := ApplyEditPolicy(name); policy {
+	case "":
+		return ApplyEditWorkspace, nil
+	case ApplyEditAllow, ApplyEditWorkspace, ApplyEditDeny:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown apply edit policy %q, expected allow, workspace or deny", name)
+	}
+}
+
+func HandleApplyEdit(client *Client, params json.RawMessage) (any, error) {
 	var workspaceEdit protocol.ApplyWorkspaceEditParams
 	if err := json.Unmarshal(params, &workspaceEdit); err != nil {
 		return protocol.ApplyWorkspaceEditResult{Applied: false}, err
 	}
 
-	// Apply the edits
-	err := utilities.ApplyWorkspaceEdit(workspaceEdit.Edit)
+	if err := client.checkApplyEditPolicy(workspaceEdit.Edit); err != nil {
+		lspLogger.Warn("Rejected workspace edit %q from server: %v", workspaceEdit.Label, err)
+		return protocol.ApplyWorkspaceEditResult{
+			Applied:       false,
+			FailureReason: workspaceEditFailure(err),
+		}, nil
+	}
+
+	if err := client.checkEditGuards(utilities.WorkspaceEditPaths(workspaceEdit.Edit)); err != nil {
+		lspLogger.Warn("Rejected workspace edit %q from server: %v", workspaceEdit.Label, err)
+		return protocol.ApplyWorkspaceEditResult{
+			Applied:       false,
+			FailureReason: workspaceEditFailure(err),
+		}, nil
+	}
+
+	// Apply the edits as one transaction, checking the versions of open documents
+	var changed []string
+	tx, err := utilities.StageWorkspaceEdit(workspaceEdit.Edit, client.FileVersion, client.PositionEncoding())
+	if errors.Is(err, utilities.ErrDirectoryChange) {
+		changed = utilities.WorkspaceEditPaths(workspaceEdit.Edit)
+		err = utilities.ApplyWorkspaceEdit(workspaceEdit.Edit, client.PositionEncoding())
+	} else if err == nil {
+		changed = tx.Paths()
+		err = tx.Commit()
+	}
 	if err != nil {
 		lspLogger.Error("Error applying workspace edit: %v", err)
 		return protocol.ApplyWorkspaceEditResult{
@@ -76,41 +127,128 @@ func HandleApplyEdit(params json.RawMessage) (any, error) {
 		}, nil
 	}
 
+	// Send the new contents of open documents back to the server
+	for _, path := range changed {
+		if !client.IsFileOpen(path) {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			// Deleted or renamed away
+			if err := client.CloseFile(context.Background(), path); err != nil {
+				lspLogger.Error("Error closing %s after workspace edit: %v", path, err)
+			}
+			continue
+		}
+		if err := client.NotifyChange(context.Background(), path); err != nil {
+			lspLogger.Error("Error notifying change to %s after workspace edit: %v", path, err)
+		}
+	}
+
+	client.editsApplied(changed)
 	return protocol.ApplyWorkspaceEditResult{
 		Applied: true,
 	}, nil
 }
 
-func workspaceEditFailure(err error) string {
-	if err == nil {
-		return ""
+// WatchEdits calls fn with the paths of the files changed by every workspace edit the
+// server applies with workspace/applyEdit, until the returned function is called. Tools
+// running server commands that apply their changes this way use it to learn what changed.
+func (c *Client) WatchEdits(fn func(paths []string)) (stop func()) {
+	c.editWatchersMu.Lock()
+	defer c.editWatchersMu.Unlock()
+	if c.editWatchers == nil {
+		c.editWatchers = make(map[int]func([]string))
+	}
+	id := c.editWatchersID
+	c.editWatchersID++
+	c.editWatchers[id] = fn
+
+	return func() {
+		c.editWatchersMu.Lock()
+		defer c.editWatchersMu.Unlock()
+		delete(c.editWatchers, id)
 	}
-	return err.Error()
 }
 
-// Notifications
+// GuardEdits calls fn with the paths of the files every workspace edit the server applies
+// with workspace/applyEdit would change, before it is applied, until the returned function
+// is called. An edit fn returns an error for is rejected with it. Tools running server
+// commands use it to hold the server's changes to the rules of their own writes.
+func (c *Client) GuardEdits(fn func(paths []string) error) (stop func()) {
+	c.editWatchersMu.Lock()
+	defer c.editWatchersMu.Unlock()
+	if c.editGuards == nil {
+		c.editGuards = make(map[int]func([]string) error)
+	}
+	id := c.editWatchersID
+	c.editWatchersID++
+	c.editGuards[id] = fn
 
-// HandleServerMessage processes window/showMessage notifications from the server
-func HandleServerMessage(params json.RawMessage) {
-	var msg protocol.ShowMessageParams
-	if err := json.Unmarshal(params, &msg); err != nil {
-		lspLogger.Error("Error unmarshaling server message: %v", err)
-		return
+	return func() {
+		c.editWatchersMu.Lock()
+		defer c.editWatchersMu.Unlock()
+		delete(c.editGuards, id)
 	}
+}
 
-	// Log the message with appropriate level
-	switch msg.Type {
-	case protocol.Error:
-		lspLogger.Error("Server error: %s", msg.Message)
-	case protocol.Warning:
-		lspLogger.Warn("Server warning: %s", msg.Message)
-	case protocol.Info:
-		lspLogger.Info("Server info: %s", msg.Message)
-	default:
-		lspLogger.Debug("Server message: %s", msg.Message)
+// checkEditGuards returns the first error of the functions guarding edits for the files
+// an edit would change
+func (c *Client) checkEditGuards(paths []string) error {
+	c.editWatchersMu.Lock()
+	guards := make([]func([]string) error, 0, len(c.editGuards))
+	for _, fn := range c.editGuards {
+		guards = append(guards, fn)
+	}
+	c.editWatchersMu.Unlock()
+
+	for _, fn := range guards {
+		if err := fn(paths); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// editsApplied tells the functions watching edits about the files an edit changed
+func (c *Client) editsApplied(paths []string) {
+	c.editWatchersMu.Lock()
+	watchers := make([]func([]string), 0, len(c.editWatchers))
+	for _, fn := range c.editWatchers {
+		watchers = append(watchers, fn)
 	}
+	c.editWatchersMu.Unlock()
+
+	for _, fn := range watchers {
+		fn(paths)
+	}
+}
+
+// checkApplyEditPolicy returns an error if the client's policy does not allow an edit
+func (c *Client) checkApplyEditPolicy(edit protocol.WorkspaceEdit) error {
+	switch c.applyEditPolicy {
+	case ApplyEditAllow:
+		return nil
+	case ApplyEditDeny:
+		return fmt.Errorf("server initiated edits are disabled")
+	}
+
+	for _, path := range utilities.WorkspaceEditPaths(edit) {
+		if _, inside := utilities.RelativePath(c.workspaceDir, path); c.workspaceDir == "" || !inside {
+			return fmt.Errorf("edit to %s is outside the workspace", path)
+		}
+	}
+	return nil
 }
 
+func workspaceEditFailure(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Notifications
+
 // HandleDiagnostics processes textDocument/publishDiagnostics notifications
 func HandleDiagnostics(client *Client, params json.RawMessage) {
 	var diagParams protocol.PublishDiagnosticsParams
@@ -121,8 +259,12 @@ func HandleDiagnostics(client *Client, params json.RawMessage) {
 
 	// Save diagnostics in client
 	client.diagnosticsMu.Lock()
-	client.diagnostics[diagParams.URI] = diagParams.Diagnostics
+	client.storeDiagnostics(diagParams.URI, diagParams.Diagnostics)
 	client.diagnosticsMu.Unlock()
 
 	lspLogger.Info("Received diagnostics for %s: %d items", diagParams.URI, len(diagParams.Diagnostics))
+
+	if client.onDiagnostics != nil {
+		client.onDiagnostics(diagParams.URI)
+	}
 }
@@ -0,0 +1,131 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckApplyEditPolicy(t *testing.T) {
+	inside := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			"file:///workspace/src/main.go": {},
+		},
+	}
+	outside := protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.DocumentChange{
+			{
+				RenameFile: &protocol.RenameFile{
+					OldURI: "file:///workspace/a.go",
+					NewURI: "file:///workspace-other/a.go",
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		policy   ApplyEditPolicy
+		edit     protocol.WorkspaceEdit
+		expected bool
+	}{
+		{ApplyEditAllow, outside, true},
+		{ApplyEditWorkspace, inside, true},
+		{ApplyEditWorkspace, outside, false},
+		{ApplyEditDeny, inside, false},
+	}
+
+	for _, tt := range tests {
+		client := &Client{workspaceDir: "/workspace", applyEditPolicy: tt.policy}
+		err := client.checkApplyEditPolicy(tt.edit)
+		assert.Equal(t, tt.expected, err == nil, "policy %s: %v", tt.policy, err)
+	}
+}
+
+func TestParseApplyEditPolicy(t *testing.T) {
+	policy, err := ParseApplyEditPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, ApplyEditWorkspace, policy)
+
+	policy, err = ParseApplyEditPolicy("deny")
+	assert.NoError(t, err)
+	assert.Equal(t, ApplyEditDeny, policy)
+
+	_, err = ParseApplyEditPolicy("sometimes")
+	assert.Error(t, err)
+}
+
+func TestWatchEdits(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.ts")
+	require.NoError(t, os.WriteFile(path, []byte("import { a } from './old'\n"), 0644))
+
+	client := &Client{workspaceDir: dir, applyEditPolicy: ApplyEditAllow}
+	var changed [][]string
+	stop := client.WatchEdits(func(paths []string) { changed = append(changed, paths) })
+
+	params, err := json.Marshal(protocol.ApplyWorkspaceEditParams{Edit: protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			protocol.URIFromPath(path): {{
+				Range:   protocol.Range{Start: protocol.Position{Line: 0, Character: 19}, End: protocol.Position{Line: 0, Character: 24}},
+				NewText: "./new",
+			}},
+		},
+	}})
+	require.NoError(t, err)
+	result, err := HandleApplyEdit(client, params)
+	require.NoError(t, err)
+	assert.True(t, result.(protocol.ApplyWorkspaceEditResult).Applied)
+	assert.Equal(t, [][]string{{path}}, changed)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "import { a } from './new'\n", string(content))
+
+	stop()
+	_, err = HandleApplyEdit(client, params)
+	require.NoError(t, err)
+	assert.Len(t, changed, 1)
+}
+
+func TestGuardEdits(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.ts")
+	require.NoError(t, os.WriteFile(path, []byte("import { a } from './old'\n"), 0644))
+
+	client := &Client{workspaceDir: dir, applyEditPolicy: ApplyEditAllow}
+	var guarded [][]string
+	stop := client.GuardEdits(func(paths []string) error {
+		guarded = append(guarded, paths)
+		return fmt.Errorf("changing %v needs confirmation", paths)
+	})
+
+	params, err := json.Marshal(protocol.ApplyWorkspaceEditParams{Edit: protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			protocol.URIFromPath(path): {{
+				Range:   protocol.Range{Start: protocol.Position{Line: 0, Character: 19}, End: protocol.Position{Line: 0, Character: 24}},
+				NewText: "./new",
+			}},
+		},
+	}})
+	require.NoError(t, err)
+	result, err := HandleApplyEdit(client, params)
+	require.NoError(t, err)
+	assert.False(t, result.(protocol.ApplyWorkspaceEditResult).Applied)
+	assert.Contains(t, result.(protocol.ApplyWorkspaceEditResult).FailureReason, "needs confirmation")
+	assert.Equal(t, [][]string{{path}}, guarded)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "import { a } from './old'\n", string(content))
+
+	stop()
+	result, err = HandleApplyEdit(client, params)
+	require.NoError(t, err)
+	assert.True(t, result.(protocol.ApplyWorkspaceEditResult).Applied)
+	assert.Len(t, guarded, 1)
+}
@@ -0,0 +1,66 @@
+package lsp
+
+import (
+	"context"
+	"slices"
+)
+
+// Several MCP sessions can share one client. Files are opened on behalf of the session
+// in the context, and a session only closes the files no other session has open.
+
+type sessionKey struct{}
+
+// WithSession returns a context whose file operations are made on behalf of an MCP session
+func WithSession(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, sessionID)
+}
+
+// sessionFromContext returns the session set with WithSession, or "" if there is none
+func sessionFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(sessionKey{}).(string)
+	return sessionID
+}
+
+// CloseSessionFiles forgets the files a session opened and closes those that no other
+// session has open
+func (c *Client) CloseSessionFiles(ctx context.Context, sessionID string) {
+	c.openFilesMu.Lock()
+	var filesToClose []string
+	for _, fileInfo := range c.openFiles {
+		if !fileInfo.sessions[sessionID] {
+			continue
+		}
+		delete(fileInfo.sessions, sessionID)
+		if len(fileInfo.sessions) == 0 {
+			filesToClose = append(filesToClose, fileInfo.URI.Path())
+		}
+	}
+	c.openFilesMu.Unlock()
+
+	for _, filePath := range filesToClose {
+		if err := c.CloseFile(ctx, filePath); err != nil {
+			lspLogger.Warn("Error closing file %s for session %s: %v", filePath, sessionID, err)
+		}
+	}
+	if len(filesToClose) > 0 {
+		lspLogger.Debug("Closed %d files for session %s", len(filesToClose), sessionID)
+	}
+}
+
+// OpenFileSessions returns the paths of all files currently open in the LSP, with the
+// sessions that opened each of them
+func (c *Client) OpenFileSessions() map[string][]string {
+	c.openFilesMu.RLock()
+	defer c.openFilesMu.RUnlock()
+
+	files := make(map[string][]string, len(c.openFiles))
+	for _, fileInfo := range c.openFiles {
+		sessions := make([]string, 0, len(fileInfo.sessions))
+		for sessionID := range fileInfo.sessions {
+			sessions = append(sessions, sessionID)
+		}
+		slices.Sort(sessions)
+		files[fileInfo.URI.Path()] = sessions
+	}
+	return files
+}
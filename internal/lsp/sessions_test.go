@@ -0,0 +1,93 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestCloseSessionFiles(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.go")
+	own := filepath.Join(dir, "own.go")
+	for _, path := range []string{shared, own} {
+		require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0644))
+	}
+
+	client := &Client{
+		stdin:     nopWriteCloser{&bytes.Buffer{}},
+		openFiles: make(map[string]*OpenFileInfo),
+	}
+	a := WithSession(context.Background(), "a")
+	b := WithSession(context.Background(), "b")
+
+	require.NoError(t, client.OpenFile(a, shared))
+	require.NoError(t, client.OpenFile(a, own))
+	require.NoError(t, client.OpenFile(b, shared))
+	assert.Equal(t, map[string][]string{shared: {"a", "b"}, own: {"a"}}, client.OpenFileSessions())
+
+	client.CloseSessionFiles(context.Background(), "a")
+	assert.True(t, client.IsFileOpen(shared))
+	assert.False(t, client.IsFileOpen(own))
+
+	client.CloseSessionFiles(context.Background(), "b")
+	assert.False(t, client.IsFileOpen(shared))
+}
+
+// slowWriter holds each write for a while, so that concurrent calls overlap
+type slowWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(10 * time.Millisecond)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) Close() error { return nil }
+
+func (w *slowWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestConcurrentOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0644))
+
+	w := &slowWriter{}
+	client := &Client{
+		stdin:     w,
+		openFiles: make(map[string]*OpenFileInfo),
+	}
+	var wg sync.WaitGroup
+	for _, session := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, client.OpenFile(WithSession(context.Background(), session), path))
+		}()
+	}
+	wg.Wait()
+
+	// The server is told once, and every session has the file open
+	assert.Equal(t, 1, strings.Count(w.String(), "textDocument/didOpen"))
+	assert.Equal(t, map[string][]string{path: {"a", "b", "c", "d"}}, client.OpenFileSessions())
+}
@@ -0,0 +1,131 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// SetSettings sets the settings the server is given through workspace/configuration
+// requests and the workspace/didChangeConfiguration notification sent at initialization.
+// Settings are keyed by section, nested like {"python": {"analysis": {...}}} or dotted
+// like {"python.analysis.typeCheckingMode": "strict"}. It must be set before the client
+// is initialized.
+func (c *Client) SetSettings(settings map[string]any) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+	c.settings = expandSettings(settings)
+}
+
+// ChangeSettings replaces the settings of a running server and tells it with
+// workspace/didChangeConfiguration, after which servers that ask for their settings ask
+// again
+func (c *Client) ChangeSettings(ctx context.Context, settings map[string]any) error {
+	c.SetSettings(settings)
+	return c.sendSettings(ctx)
+}
+
+// MergeSettings returns the settings of base with those of override on top, merging the
+// sections both have. Both can be nested or dotted as SetSettings takes them.
+func MergeSettings(base, override map[string]any) map[string]any {
+	merged := expandSettings(base)
+	mergeSettings(merged, expandSettings(override))
+	return merged
+}
+
+// expandSettings nests the settings with dotted keys into the sections they name
+func expandSettings(settings map[string]any) map[string]any {
+	expanded := make(map[string]any)
+	for key, value := range settings {
+		if nested, ok := value.(map[string]any); ok {
+			value = expandSettings(nested)
+		}
+		parts := strings.Split(key, ".")
+		section := expanded
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := section[part].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				section[part] = next
+			}
+			section = next
+		}
+		last := parts[len(parts)-1]
+		if existing, ok := section[last].(map[string]any); ok {
+			if nested, ok := value.(map[string]any); ok {
+				mergeSettings(existing, nested)
+				continue
+			}
+		}
+		section[last] = value
+	}
+	return expanded
+}
+
+// mergeSettings copies the settings of src into dst, merging the sections both have
+func mergeSettings(dst, src map[string]any) {
+	for key, value := range src {
+		existing, ok := dst[key].(map[string]any)
+		nested, isSection := value.(map[string]any)
+		if ok && isSection {
+			mergeSettings(existing, nested)
+		} else {
+			dst[key] = value
+		}
+	}
+}
+
+// settingsSection returns the settings of a dotted section, all of them for an empty
+// section, or nil if there are none
+func (c *Client) settingsSection(section string) any {
+	c.settingsMu.RLock()
+	defer c.settingsMu.RUnlock()
+	if section == "" {
+		if len(c.settings) == 0 {
+			return nil
+		}
+		return c.settings
+	}
+	var value any = c.settings
+	for _, part := range strings.Split(section, ".") {
+		settings, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		if value, ok = settings[part]; !ok {
+			return nil
+		}
+	}
+	return value
+}
+
+// HandleWorkspaceConfiguration answers workspace/configuration requests with the settings
+// of each section asked for, null for those not configured
+func HandleWorkspaceConfiguration(client *Client, params json.RawMessage) (any, error) {
+	var configParams protocol.ConfigurationParams
+	if err := json.Unmarshal(params, &configParams); err != nil {
+		lspLogger.Error("Error unmarshaling configuration params: %v", err)
+		return nil, err
+	}
+
+	result := make([]any, len(configParams.Items))
+	for i, item := range configParams.Items {
+		result[i] = client.settingsSection(item.Section)
+		lspLogger.Debug("Configuration requested for section %q: %v", item.Section, result[i] != nil)
+	}
+	return result, nil
+}
+
+// sendSettings tells the server about the settings, for servers that read them from
+// workspace/didChangeConfiguration rather than asking for them
+func (c *Client) sendSettings(ctx context.Context) error {
+	c.settingsMu.RLock()
+	settings := c.settings
+	c.settingsMu.RUnlock()
+	if len(settings) == 0 {
+		return nil
+	}
+	return c.DidChangeConfiguration(ctx, protocol.DidChangeConfigurationParams{Settings: settings})
+}
@@ -0,0 +1,52 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleWorkspaceConfiguration(t *testing.T) {
+	client := &Client{}
+	client.SetSettings(map[string]any{
+		"python.analysis": map[string]any{"typeCheckingMode": "strict"},
+		"python": map[string]any{
+			"analysis":   map[string]any{"autoImportCompletions": false},
+			"pythonPath": "/usr/bin/python3",
+		},
+	})
+
+	params := json.RawMessage(`{"items":[{"section":"python.analysis"},{"section":"python.pythonPath"},{"section":"yaml"}]}`)
+	result, err := HandleWorkspaceConfiguration(client, params)
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		map[string]any{"typeCheckingMode": "strict", "autoImportCompletions": false},
+		"/usr/bin/python3",
+		nil,
+	}, result)
+
+	// Without settings every section is null
+	result, err = HandleWorkspaceConfiguration(&Client{}, json.RawMessage(`{"items":[{"section":"gopls"},{}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []any{nil, nil}, result)
+}
+
+func TestMergeSettings(t *testing.T) {
+	base := map[string]any{
+		"python": map[string]any{
+			"analysis":   map[string]any{"typeCheckingMode": "strict"},
+			"pythonPath": "/usr/bin/python3",
+		},
+	}
+	merged := MergeSettings(base, map[string]any{"python.pythonPath": "/src/.venv/bin/python"})
+
+	assert.Equal(t, map[string]any{
+		"python": map[string]any{
+			"analysis":   map[string]any{"typeCheckingMode": "strict"},
+			"pythonPath": "/src/.venv/bin/python",
+		},
+	}, merged)
+	assert.Equal(t, "/usr/bin/python3", base["python"].(map[string]any)["pythonPath"])
+}
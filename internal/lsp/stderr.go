@@ -0,0 +1,83 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// How much of the server's stderr is kept, see ServerLogs
+	stderrLogSize = 64 * 1024
+	// How much of it is shown in error messages
+	stderrErrorSize = 2 * 1024
+)
+
+// stderrLog keeps the end of what the server wrote to stderr
+type stderrLog struct {
+	mu  sync.Mutex
+	buf []byte
+	// Total number of bytes written, including those no longer kept
+	written int64
+}
+
+// writeLine adds a line of output, dropping the oldest lines beyond stderrLogSize
+func (l *stderrLog) writeLine(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf = append(l.buf, line...)
+	l.buf = append(l.buf, '\n')
+	l.written += int64(len(line)) + 1
+	if excess := len(l.buf) - stderrLogSize; excess > 0 {
+		// Keep whole lines
+		if i := strings.IndexByte(string(l.buf[excess:]), '\n'); i >= 0 && excess+i+1 < len(l.buf) {
+			excess += i + 1
+		}
+		l.buf = append(l.buf[:0], l.buf[excess:]...)
+	}
+}
+
+// offset returns the position of the output written so far, for since
+func (l *stderrLog) offset() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.written
+}
+
+// since returns the output written after offset, as far as it is kept
+func (l *stderrLog) since(offset int64) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := l.written - offset
+	if n > int64(len(l.buf)) {
+		n = int64(len(l.buf))
+	}
+	return string(l.buf[int64(len(l.buf))-n:])
+}
+
+// ServerLogs returns the last lines the server wrote to stderr, all those kept if lines
+// is zero
+func (c *Client) ServerLogs(lines int) string {
+	text := c.stderrLog.since(0)
+	if lines > 0 {
+		all := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+		if len(all) > lines {
+			text = strings.Join(all[len(all)-lines:], "\n") + "\n"
+		}
+	}
+	return text
+}
+
+// withServerOutput adds to an error what the server wrote to stderr since offset, which
+// usually explains why a request failed or the server exited
+func (c *Client) withServerOutput(err error, offset int64) error {
+	output := strings.TrimSpace(c.stderrLog.since(offset))
+	if output == "" {
+		return err
+	}
+	if len(output) > stderrErrorSize {
+		output = "..." + output[len(output)-stderrErrorSize:]
+	}
+	return fmt.Errorf("%w\nLanguage server stderr:\n%s", err, output)
+}
@@ -0,0 +1,44 @@
+package lsp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStderrLog(t *testing.T) {
+	client := &Client{}
+	assert.Equal(t, "", client.ServerLogs(10))
+
+	client.stderrLog.writeLine("starting")
+	offset := client.stderrLog.offset()
+	client.stderrLog.writeLine("panic: index out of range")
+	client.stderrLog.writeLine("goroutine 1 [running]")
+
+	assert.Equal(t, "goroutine 1 [running]\n", client.ServerLogs(1))
+	assert.Equal(t, "starting\npanic: index out of range\ngoroutine 1 [running]\n", client.ServerLogs(0))
+
+	err := client.withServerOutput(errors.New("request failed"), offset)
+	assert.Equal(t, "request failed\nLanguage server stderr:\npanic: index out of range\ngoroutine 1 [running]", err.Error())
+	err = client.withServerOutput(errors.New("request failed"), client.stderrLog.offset())
+	assert.Equal(t, "request failed", err.Error())
+}
+
+func TestStderrLogKeepsEnd(t *testing.T) {
+	client := &Client{}
+	line := strings.Repeat("x", 1000)
+	for range 100 {
+		client.stderrLog.writeLine(line)
+	}
+	client.stderrLog.writeLine("last")
+
+	logs := client.ServerLogs(0)
+	assert.LessOrEqual(t, len(logs), stderrLogSize)
+	assert.True(t, strings.HasPrefix(logs, line+"\n"), "only whole lines are kept")
+	assert.True(t, strings.HasSuffix(logs, "\nlast\n"))
+
+	err := client.withServerOutput(errors.New("exited"), 0)
+	assert.LessOrEqual(t, len(err.Error()), stderrErrorSize+100)
+}
@@ -0,0 +1,98 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Tracer writes every message exchanged with the language server to a trace, one line
+// per message, with the time it took to answer each request
+type Tracer struct {
+	mu sync.Mutex
+	w  io.Writer
+	// maxPayload truncates message payloads to this many bytes, zero means no limit
+	maxPayload int
+	// Requests awaiting a response, by the direction they were sent in and their ID
+	pending map[string]pendingRequest
+}
+
+type pendingRequest struct {
+	method string
+	start  time.Time
+}
+
+// NewTracer returns a tracer writing to w that truncates payloads longer than maxPayload
+// bytes, or never truncates them if maxPayload is zero
+func NewTracer(w io.Writer, maxPayload int) *Tracer {
+	return &Tracer{
+		w:          w,
+		maxPayload: maxPayload,
+		pending:    make(map[string]pendingRequest),
+	}
+}
+
+// SetTracer traces the messages exchanged with the language server from now on
+func (c *Client) SetTracer(t *Tracer) {
+	c.tracer.Store(t)
+}
+
+// traceSent records a message sent to the language server
+func (t *Tracer) traceSent(msg *Message) {
+	t.trace("->", msg)
+}
+
+// traceReceived records a message received from the language server
+func (t *Tracer) traceReceived(msg *Message) {
+	t.trace("<-", msg)
+}
+
+func (t *Tracer) trace(direction string, msg *Message) {
+	if t == nil {
+		return
+	}
+
+	now := time.Now()
+	hasID := msg.ID != nil && msg.ID.Value != nil
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var desc string
+	switch {
+	case msg.Method != "" && hasID:
+		// Responses travel in the opposite direction of their request
+		t.pending[direction+msg.ID.String()] = pendingRequest{method: msg.Method, start: now}
+		desc = fmt.Sprintf("request %s id=%s", msg.Method, msg.ID)
+	case msg.Method != "":
+		desc = fmt.Sprintf("notification %s", msg.Method)
+	case hasID:
+		requestDirection := "<-"
+		if direction == "<-" {
+			requestDirection = "->"
+		}
+		key := requestDirection + msg.ID.String()
+		if req, ok := t.pending[key]; ok {
+			delete(t.pending, key)
+			desc = fmt.Sprintf("response %s id=%s (%s)", req.method, msg.ID, now.Sub(req.start).Round(time.Microsecond))
+		} else {
+			desc = fmt.Sprintf("response id=%s", msg.ID)
+		}
+	default:
+		desc = "message"
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		payload = []byte(fmt.Sprintf("failed to marshal message: %v", err))
+	}
+	if t.maxPayload > 0 && len(payload) > t.maxPayload {
+		payload = fmt.Appendf(payload[:t.maxPayload:t.maxPayload], "... (%d more bytes)", len(payload)-t.maxPayload)
+	}
+
+	if _, err := fmt.Fprintf(t.w, "%s %s %s %s\n", now.Format("2006-01-02T15:04:05.000000Z07:00"), direction, desc, payload); err != nil {
+		lspLogger.Error("Failed to write LSP trace: %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracer(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewTracer(&buf, 80)
+
+	request, err := NewRequest(int32(1), "textDocument/hover", map[string]string{"uri": "file:///a.go"})
+	require.NoError(t, err)
+	tracer.traceSent(request)
+	tracer.traceReceived(&Message{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result:  json.RawMessage(`{"contents":"` + strings.Repeat("x", 100) + `"}`),
+	})
+	notification, err := NewNotification("textDocument/didOpen", map[string]string{})
+	require.NoError(t, err)
+	tracer.traceSent(notification)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], ` -> request textDocument/hover id=1 {"jsonrpc":"2.0","id":1,`)
+	assert.Regexp(t, ` <- response textDocument/hover id=1 \([0-9.]+[µm]?s\) `, lines[1])
+	assert.True(t, strings.HasSuffix(lines[1], "... (69 more bytes)"), lines[1])
+	assert.Contains(t, lines[2], " -> notification textDocument/didOpen ")
+	assert.Empty(t, tracer.pending)
+}
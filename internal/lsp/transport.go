@@ -4,11 +4,16 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"strings"
+	"time"
 
 	"github.com/isaacphi/mcp-language-server/internal/logging"
+	"github.com/isaacphi/mcp-language-server/internal/metrics"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
 )
 
 // Create component-specific loggers
@@ -99,14 +104,26 @@ func (c *Client) handleMessages() {
 	for {
 		msg, err := ReadMessage(c.stdout)
 		if err != nil {
-			// Check if this is due to normal shutdown (EOF when closing connection)
-			if strings.Contains(err.Error(), "EOF") {
+			// Check if this is due to normal shutdown (EOF when closing connection, or the
+			// client closing its connection to a server it connected to)
+			if strings.Contains(err.Error(), "EOF") || errors.Is(err, net.ErrClosed) || errors.Is(err, io.ErrClosedPipe) {
 				lspLogger.Info("LSP connection closed (EOF)")
 			} else {
 				lspLogger.Error("Error reading message: %v", err)
 			}
+			select {
+			case <-c.done:
+			default:
+				if output := c.ServerLogs(20); output != "" {
+					lspLogger.Error("LSP server exited unexpectedly, its last output:\n%s", output)
+				}
+			}
+			c.disconnectedOnce.Do(func() { close(c.disconnected) })
 			return
 		}
+		c.tracer.Load().traceReceived(msg)
+		c.pathMap.Load().translateMessage(msg, false)
+		c.recorder.Load().recordReceived(msg)
 
 		// Handle server->client request (has both Method and ID)
 		if msg.Method != "" && msg.ID != nil && msg.ID.Value != nil {
@@ -150,7 +167,7 @@ func (c *Client) handleMessages() {
 			}
 
 			// Send response back to server
-			if err := WriteMessage(c.stdin, response); err != nil {
+			if err := c.write(response); err != nil {
 				lspLogger.Error("Error sending response to server: %v", err)
 			}
 
@@ -191,12 +208,25 @@ func (c *Client) handleMessages() {
 	}
 }
 
+// write sends a message to the server, with its paths translated for the server
+func (c *Client) write(msg *Message) error {
+	c.recorder.Load().recordSent(msg)
+	c.pathMap.Load().translateMessage(msg, true)
+	c.tracer.Load().traceSent(msg)
+	return WriteMessage(c.stdin, msg)
+}
+
 // Call makes a request and waits for the response
 func (c *Client) Call(ctx context.Context, method string, params any, result any) error {
 	id := c.nextID.Add(1)
 
 	lspLogger.Debug("Making call: method=%s id=%v", method, id)
 
+	if err := c.limiter.acquire(ctx); err != nil {
+		return err
+	}
+	defer c.limiter.release()
+
 	msg, err := NewRequest(id, method, params)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -217,20 +247,43 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	}()
 
 	// Send request
-	if err := WriteMessage(c.stdin, msg); err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+	start := time.Now()
+	stderrOffset := c.stderrLog.offset()
+	if err := c.write(msg); err != nil {
+		return c.withServerOutput(fmt.Errorf("failed to send request: %w", err), 0)
 	}
 
 	lspLogger.Debug("Waiting for response to request ID: %v", msg.ID)
 
-	// Wait for response
-	resp := <-ch
+	// Wait for response, or tell the server to stop working on the request if the caller
+	// gave up on it
+	var resp *Message
+	select {
+	case resp = <-ch:
+	case <-c.disconnected:
+		// The response may have come in just before
+		select {
+		case resp = <-ch:
+		default:
+			metrics.LSPRequestErrors.Inc(method)
+			return c.withServerOutput(fmt.Errorf("the language server exited before answering %s", method), 0)
+		}
+	case <-ctx.Done():
+		metrics.LSPRequestErrors.Inc(method)
+		lspLogger.Debug("Cancelling request ID: %v", msg.ID)
+		if err := c.Notify(context.Background(), "$/cancelRequest", protocol.CancelParams{ID: id}); err != nil {
+			lspLogger.Error("Failed to cancel request: %v", err)
+		}
+		return ctx.Err()
+	}
 
 	lspLogger.Debug("Received response for request ID: %v", msg.ID)
+	metrics.LSPRequestDuration.ObserveSince(start, method)
 
 	if resp.Error != nil {
+		metrics.LSPRequestErrors.Inc(method)
 		lspLogger.Error("Request failed: %s (code: %d)", resp.Error.Message, resp.Error.Code)
-		return fmt.Errorf("request failed: %s (code: %d)", resp.Error.Message, resp.Error.Code)
+		return c.withServerOutput(fmt.Errorf("request failed: %w", resp.Error), stderrOffset)
 	}
 
 	if result != nil {
@@ -258,7 +311,7 @@ func (c *Client) Notify(ctx context.Context, method string, params any) error {
 		return fmt.Errorf("failed to create notification: %w", err)
 	}
 
-	if err := WriteMessage(c.stdin, msg); err != nil {
+	if err := c.write(msg); err != nil {
 		return fmt.Errorf("failed to send notification: %w", err)
 	}
 
@@ -0,0 +1,25 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallCancel(t *testing.T) {
+	stdin := &bytes.Buffer{}
+	client := &Client{
+		stdin:    nopWriteCloser{stdin},
+		handlers: make(map[string]chan *Message),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.Call(ctx, "textDocument/references", struct{}{}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, stdin.String(), `"method":"$/cancelRequest","params":{"id":1}`)
+	assert.Empty(t, client.handlers)
+}
@@ -0,0 +1,105 @@
+package lsp
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// ReadURI returns the contents of a document that is not a file on disk, such as the
+// source of a dependency, which servers report with URIs of their own schemes. Entries of
+// jar: and zip: archives are extracted locally. jdt: (Eclipse JDT) and deno: documents
+// are requested with those servers' custom requests, and other schemes with
+// workspace/textDocumentContent.
+func (c *Client) ReadURI(ctx context.Context, uri protocol.DocumentUri) (string, error) {
+	scheme, _, _ := strings.Cut(string(uri), ":")
+	switch scheme {
+	case "jar", "zip":
+		return readArchiveEntry(string(uri))
+	case "jdt":
+		var text string
+		err := c.Call(ctx, "java/classFileContents", protocol.TextDocumentIdentifier{URI: uri}, &text)
+		return text, err
+	case "deno":
+		var text string
+		params := struct {
+			TextDocument protocol.TextDocumentIdentifier `json:"textDocument"`
+		}{protocol.TextDocumentIdentifier{URI: uri}}
+		err := c.Call(ctx, "deno/virtualTextDocument", params, &text)
+		return text, err
+	default:
+		var result struct {
+			Text string `json:"text"`
+		}
+		err := c.Call(ctx, "workspace/textDocumentContent", protocol.TextDocumentContentParams{URI: uri}, &result)
+		return result.Text, err
+	}
+}
+
+// ArchivePath returns the path of the local archive holding the entry a jar: or zip: URI
+// names, such as /home/me/.m2/lib-sources.jar for
+// jar:file:///home/me/.m2/lib-sources.jar!/com/example/A.java, and whether it is one
+func ArchivePath(uri string) (string, bool) {
+	archive, _, err := splitArchiveURI(uri)
+	return archive, err == nil
+}
+
+// splitArchiveURI splits a jar: or zip: URI into the path of its local archive and the
+// entry it names in the archive
+func splitArchiveURI(uri string) (string, string, error) {
+	scheme, rest, _ := strings.Cut(uri, ":")
+	if scheme != "jar" && scheme != "zip" {
+		return "", "", fmt.Errorf("not an archive URI: %s", uri)
+	}
+	archive, entry, ok := strings.Cut(rest, "!/")
+	if !ok {
+		return "", "", fmt.Errorf("no archive entry in %s", uri)
+	}
+	archiveURI, err := protocol.ParseDocumentUri(archive)
+	if err != nil || !archiveURI.IsFile() {
+		return "", "", fmt.Errorf("archive is not a local file: %s", uri)
+	}
+	if unescaped, err := url.PathUnescape(entry); err == nil {
+		entry = unescaped
+	}
+	return archiveURI.Path(), entry, nil
+}
+
+// readArchiveEntry reads an entry of a local archive given by a URI such as
+// jar:file:///home/me/.m2/lib-sources.jar!/com/example/A.java
+func readArchiveEntry(uri string) (string, error) {
+	archive, entry, err := splitArchiveURI(uri)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			lspLogger.Error("Failed to close archive: %v", err)
+		}
+	}()
+
+	f, err := r.Open(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s in archive: %w", entry, err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			lspLogger.Error("Failed to close archive entry: %v", err)
+		}
+	}()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s in archive: %w", entry, err)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,68 @@
+package lsp
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadURIFromArchive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "lib sources.jar")
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+	w := zip.NewWriter(f)
+	entry, err := w.Create("com/example/A.java")
+	require.NoError(t, err)
+	_, err = entry.Write([]byte("class A {}\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	client := &Client{}
+	uri := protocol.DocumentUri("jar:" + string(protocol.URIFromPath(archive)) + "!/com/example/A.java")
+	text, err := client.ReadURI(context.Background(), uri)
+	require.NoError(t, err)
+	assert.Equal(t, "class A {}\n", text)
+
+	_, err = client.ReadURI(context.Background(), protocol.DocumentUri("jar:"+string(protocol.URIFromPath(archive))+"!/B.java"))
+	assert.Error(t, err)
+}
+
+func TestArchivePath(t *testing.T) {
+	path, ok := ArchivePath("jar:file:///home/me/.m2/lib%20sources.jar!/com/example/A.java")
+	assert.True(t, ok)
+	assert.Equal(t, "/home/me/.m2/lib sources.jar", path)
+
+	for _, uri := range []string{
+		"zip:https://example.com/lib.zip!/a.go",
+		"jar:file:///home/me/lib.jar",
+		"jdt://contents/rt.jar/java.lang/String.class",
+	} {
+		_, ok := ArchivePath(uri)
+		assert.False(t, ok, uri)
+	}
+}
+
+func TestProvidesScheme(t *testing.T) {
+	var none *Client
+	assert.True(t, none.ProvidesScheme("jdt"))
+	assert.False(t, none.ProvidesScheme("zip"))
+
+	client := &Client{capabilities: protocol.ServerCapabilities{Workspace: &protocol.WorkspaceOptions{
+		TextDocumentContent: &protocol.Or_WorkspaceOptions_textDocumentContent{Value: protocol.TextDocumentContentOptions{Scheme: "virtual"}},
+	}}}
+	assert.True(t, client.ProvidesScheme("deno"))
+	assert.True(t, client.ProvidesScheme("virtual"))
+	assert.False(t, client.ProvidesScheme("generated"))
+
+	client.addRegistrations([]protocol.Registration{{ID: "c1", Method: "workspace/textDocumentContent", RegisterOptions: map[string]any{"scheme": "generated"}}})
+	assert.True(t, client.ProvidesScheme("generated"))
+	client.removeRegistrations([]protocol.Unregistration{{ID: "c1", Method: "workspace/textDocumentContent"}})
+	assert.False(t, client.ProvidesScheme("generated"))
+}
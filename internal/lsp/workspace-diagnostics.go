@@ -0,0 +1,58 @@
+package lsp
+
+import (
+	"context"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// supportsWorkspaceDiagnostics reports whether the server's capabilities offer
+// workspace/diagnostic requests
+func supportsWorkspaceDiagnostics(capabilities protocol.ServerCapabilities) bool {
+	if capabilities.DiagnosticProvider == nil {
+		return false
+	}
+	switch v := capabilities.DiagnosticProvider.Value.(type) {
+	case protocol.DiagnosticOptions:
+		return v.WorkspaceDiagnostics
+	case protocol.DiagnosticRegistrationOptions:
+		return v.WorkspaceDiagnostics
+	}
+	return false
+}
+
+// SupportsWorkspaceDiagnostics reports whether the server can report the diagnostics of
+// the whole workspace in one workspace/diagnostic request
+func (c *Client) SupportsWorkspaceDiagnostics() bool {
+	return c.workspaceDiagnostics
+}
+
+// PullWorkspaceDiagnostics requests the diagnostics of the whole workspace, stores them
+// in the diagnostic cache and returns them by file. Files whose diagnostics did not change
+// since they were last reported keep their cached diagnostics.
+func (c *Client) PullWorkspaceDiagnostics(ctx context.Context) (map[protocol.DocumentUri][]protocol.Diagnostic, error) {
+	report, err := c.DiagnosticWorkspace(ctx, protocol.WorkspaceDiagnosticParams{
+		PreviousResultIds: []protocol.PreviousResultId{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.diagnosticsMu.Lock()
+	for _, item := range report.Items {
+		if full, ok := item.Value.(protocol.WorkspaceFullDocumentDiagnosticReport); ok {
+			c.storeDiagnostics(full.URI, full.Items)
+		}
+	}
+	c.diagnosticsMu.Unlock()
+
+	return c.GetAllDiagnostics(), nil
+}
+
+// ForgetDiagnostics drops the cached diagnostics of a file, so that the next diagnostics
+// cached for it are known to be fresh
+func (c *Client) ForgetDiagnostics(uri protocol.DocumentUri) {
+	c.diagnosticsMu.Lock()
+	defer c.diagnosticsMu.Unlock()
+	delete(c.diagnostics, uri)
+}
@@ -0,0 +1,20 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupportsWorkspaceDiagnostics(t *testing.T) {
+	assert.False(t, supportsWorkspaceDiagnostics(protocol.ServerCapabilities{}))
+
+	caps := protocol.ServerCapabilities{DiagnosticProvider: &protocol.Or_ServerCapabilities_diagnosticProvider{
+		Value: protocol.DiagnosticOptions{WorkspaceDiagnostics: true},
+	}}
+	assert.True(t, supportsWorkspaceDiagnostics(caps))
+
+	caps.DiagnosticProvider.Value = protocol.DiagnosticRegistrationOptions{}
+	assert.False(t, supportsWorkspaceDiagnostics(caps))
+}
@@ -0,0 +1,264 @@
+// Package metrics collects counters and histograms about tool calls and the language
+// server, and serves them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics exposed by the server
+var (
+	ToolCalls = NewCounterVec("mcp_language_server_tool_calls_total",
+		"Tool calls by tool and outcome (ok, error, timeout or cancelled).", "tool", "outcome")
+	ToolDuration = NewHistogramVec("mcp_language_server_tool_duration_seconds",
+		"Time taken by tool calls.", DefaultBuckets, "tool")
+	LSPRequestDuration = NewHistogramVec("mcp_language_server_lsp_request_duration_seconds",
+		"Time taken by the language server to answer requests, by method.", DefaultBuckets, "method")
+	LSPRequestErrors = NewCounterVec("mcp_language_server_lsp_request_errors_total",
+		"Requests the language server answered with an error or that were abandoned, by method.", "method")
+	LSPRestarts = NewCounterVec("mcp_language_server_lsp_restarts_total",
+		"Times the language server was restarted after being stopped.")
+)
+
+// DefaultBuckets are histogram bucket upper bounds in seconds, from 5ms to 2 minutes
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// collector is a metric that can write itself in the text exposition format
+type collector interface {
+	write(w io.Writer) error
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []collector
+)
+
+func register(c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, c)
+}
+
+// Handler serves all metrics in the Prometheus text exposition format
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := Write(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// Write writes all metrics in the Prometheus text exposition format
+func Write(w io.Writer) error {
+	registryMu.Lock()
+	collectors := append([]collector(nil), registry...)
+	registryMu.Unlock()
+
+	for _, c := range collectors {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metric holds what counters and histograms have in common: a name, help text and the
+// names of their labels
+type metric struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (m *metric) header(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, kind)
+	return err
+}
+
+// key identifies a series by its label values
+func (m *metric) key(values []string) string {
+	if len(values) != len(m.labels) {
+		panic(fmt.Sprintf("metric %s takes %d label values, got %d", m.name, len(m.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelString formats label pairs, e.g. {tool="hover",outcome="ok"}
+func labelString(names, values []string, extra ...string) string {
+	var pairs []string
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// CounterVec is a set of counters partitioned by label values
+type CounterVec struct {
+	metric
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	count  float64
+}
+
+// NewCounterVec creates and registers a counter with the given label names
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		metric: metric{name: name, help: help, labels: labels},
+		series: make(map[string]*counterSeries),
+	}
+	register(c)
+	return c
+}
+
+// Inc adds one to the counter with the given label values
+func (c *CounterVec) Inc(values ...string) {
+	key := c.key(values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{values: slices.Clone(values)}
+		c.series[key] = s
+	}
+	s.count++
+}
+
+func (c *CounterVec) write(w io.Writer) error {
+	if err := c.header(w, "counter"); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.labels) == 0 && len(c.series) == 0 {
+		// A counter without labels has a single series, which starts at zero
+		_, err := fmt.Fprintf(w, "%s 0\n", c.name)
+		return err
+	}
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.name, labelString(c.labels, s.values), formatFloat(s.count)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HistogramVec is a set of histograms partitioned by label values
+type HistogramVec struct {
+	metric
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	// counts holds the number of observations in each bucket, not cumulated
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec creates and registers a histogram with the given bucket upper bounds,
+// in increasing order, and label names
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		metric:  metric{name: name, help: help, labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	register(h)
+	return h
+}
+
+// Observe records a value in the histogram with the given label values
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := h.key(values)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: slices.Clone(values), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+// ObserveSince records the time elapsed since start, in seconds
+func (h *HistogramVec) ObserveSince(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+func (h *HistogramVec) write(w io.Writer) error {
+	if err := h.header(w, "histogram"); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelString(h.labels, s.values, "le", formatFloat(bound)), cumulative); err != nil {
+				return err
+			}
+		}
+		labels := labelString(h.labels, s.values)
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, labelString(h.labels, s.values, "le", "+Inf"), s.count,
+			h.name, labels, formatFloat(s.sum),
+			h.name, labels, s.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
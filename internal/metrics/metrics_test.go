@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterVec(t *testing.T) {
+	c := &CounterVec{
+		metric: metric{name: "calls_total", help: "Calls.", labels: []string{"tool", "outcome"}},
+		series: make(map[string]*counterSeries),
+	}
+	c.Inc("hover", "ok")
+	c.Inc("hover", "ok")
+	c.Inc("references", `a"b`)
+
+	var buf bytes.Buffer
+	require.NoError(t, c.write(&buf))
+	assert.Equal(t, `# HELP calls_total Calls.
+# TYPE calls_total counter
+calls_total{tool="hover",outcome="ok"} 2
+calls_total{tool="references",outcome="a\"b"} 1
+`, buf.String())
+
+	assert.Panics(t, func() { c.Inc("hover") }, "Expected a panic for missing label values")
+
+	restarts := &CounterVec{
+		metric: metric{name: "restarts_total", help: "Restarts."},
+		series: make(map[string]*counterSeries),
+	}
+	buf.Reset()
+	require.NoError(t, restarts.write(&buf))
+	assert.Contains(t, buf.String(), "\nrestarts_total 0\n")
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := &HistogramVec{
+		metric:  metric{name: "duration_seconds", help: "Durations.", labels: []string{"method"}},
+		buckets: []float64{0.1, 1},
+		series:  make(map[string]*histogramSeries),
+	}
+	h.Observe(0.05, "hover")
+	h.Observe(0.5, "hover")
+	h.Observe(1, "hover")
+	h.Observe(3, "hover")
+
+	var buf bytes.Buffer
+	require.NoError(t, h.write(&buf))
+	assert.Equal(t, `# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{method="hover",le="0.1"} 1
+duration_seconds_bucket{method="hover",le="1"} 3
+duration_seconds_bucket{method="hover",le="+Inf"} 4
+duration_seconds_sum{method="hover"} 4.55
+duration_seconds_count{method="hover"} 4
+`, buf.String())
+}
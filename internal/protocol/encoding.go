@@ -0,0 +1,83 @@
+package protocol
+
+// This file converts between the character offsets of LSP positions in the encoding
+// negotiated with a language server and byte and rune offsets. Servers that support it,
+// such as clangd and rust-analyzer, count UTF-8 bytes, which needs no conversion for
+// bytes, and the others UTF-16 code units. Each client negotiates its own encoding, see
+// lsp.Client.PositionEncoding.
+//
+// Like the UTF-16 functions, they take a single line of text without its line ending and
+// clamp offsets past the end of the line to it.
+
+import (
+	"unicode/utf8"
+)
+
+// CharacterLen returns the number of characters of s in the encoding. The empty encoding
+// is UTF-16, the encoding of servers that don't negotiate one.
+func (kind PositionEncodingKind) CharacterLen(s string) int {
+	return int(fromByte(s, len(s), kind))
+}
+
+// ByteToCharacter converts a byte offset in line into a character in the encoding
+func (kind PositionEncodingKind) ByteToCharacter(line string, offset int) uint32 {
+	return fromByte(line, offset, kind)
+}
+
+// CharacterToByte converts a character in the encoding into a byte offset in line
+func (kind PositionEncodingKind) CharacterToByte(line string, character uint32) int {
+	return toByte(line, character, kind)
+}
+
+// RuneToCharacter converts a rune offset in line into a character in the encoding
+func (kind PositionEncodingKind) RuneToCharacter(line string, runes int) uint32 {
+	return ConvertCharacter(line, uint32(max(runes, 0)), UTF32, kind)
+}
+
+// CharacterToRune converts a character in the encoding into a rune offset in line
+func (kind PositionEncodingKind) CharacterToRune(line string, character uint32) int {
+	return int(ConvertCharacter(line, character, kind, UTF32))
+}
+
+// ConvertCharacter converts a character offset in line from one encoding to another.
+// UTF-32 counts runes.
+func ConvertCharacter(line string, character uint32, from, to PositionEncodingKind) uint32 {
+	return fromByte(line, toByte(line, character, from), to)
+}
+
+// toByte converts a character in an encoding into a byte offset in line. A character in
+// the middle of a rune resolves to the start of that rune.
+func toByte(line string, character uint32, kind PositionEncodingKind) int {
+	switch kind {
+	case UTF8:
+		offset := min(int(character), len(line))
+		for offset > 0 && offset < len(line) && !utf8.RuneStart(line[offset]) {
+			offset--
+		}
+		return offset
+	case UTF32:
+		runes := int(character)
+		for i := range line {
+			if runes == 0 {
+				return i
+			}
+			runes--
+		}
+		return len(line)
+	default:
+		return UTF16ToByte(line, character)
+	}
+}
+
+// fromByte converts a byte offset in line into a character in an encoding
+func fromByte(line string, offset int, kind PositionEncodingKind) uint32 {
+	offset = min(max(offset, 0), len(line))
+	switch kind {
+	case UTF8:
+		return uint32(offset)
+	case UTF32:
+		return uint32(utf8.RuneCountInString(line[:offset]))
+	default:
+		return ByteToUTF16(line, offset)
+	}
+}
@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionEncoding(t *testing.T) {
+	// "é" is two bytes and one UTF-16 unit, "😀" is four bytes and two UTF-16 units
+	line := "aé😀b"
+
+	tests := []struct {
+		name   string
+		kind   PositionEncodingKind
+		length int
+		// The character at the end of each rune
+		characters []uint32
+	}{
+		{name: "utf-16", kind: UTF16, length: 5, characters: []uint32{0, 1, 2, 4, 5}},
+		{name: "utf-8", kind: UTF8, length: 8, characters: []uint32{0, 1, 3, 7, 8}},
+	}
+
+	bytes := []int{0, 1, 3, 7, 8}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind := tt.kind
+			assert.Equal(t, tt.length, kind.CharacterLen(line))
+			for runes, character := range tt.characters {
+				assert.Equal(t, character, kind.RuneToCharacter(line, runes))
+				assert.Equal(t, runes, kind.CharacterToRune(line, character))
+				assert.Equal(t, character, kind.ByteToCharacter(line, bytes[runes]))
+				assert.Equal(t, bytes[runes], kind.CharacterToByte(line, character))
+			}
+		})
+	}
+
+	t.Run("inside a rune", func(t *testing.T) {
+		assert.Equal(t, 3, UTF8.CharacterToByte(line, 5))
+		assert.Equal(t, 2, UTF8.CharacterToRune(line, 5))
+	})
+
+	t.Run("unnegotiated", func(t *testing.T) {
+		var kind PositionEncodingKind
+		assert.Equal(t, UTF16.CharacterLen(line), kind.CharacterLen(line))
+	})
+
+	t.Run("between encodings", func(t *testing.T) {
+		assert.Equal(t, uint32(4), ConvertCharacter(line, 7, UTF8, UTF16))
+		assert.Equal(t, uint32(7), ConvertCharacter(line, 4, UTF16, UTF8))
+		assert.Equal(t, uint32(3), ConvertCharacter(line, 4, UTF16, UTF32))
+	})
+}
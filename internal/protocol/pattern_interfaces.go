@@ -2,7 +2,6 @@ package protocol
 
 import (
 	"fmt"
-	"strings"
 )
 
 // PatternInfo is an interface for types that represent glob patterns
@@ -45,9 +44,13 @@ func (g *GlobPattern) AsPattern() (PatternInfo, error) {
 		basePath := ""
 		switch baseURI := v.BaseURI.Value.(type) {
 		case string:
-			basePath = strings.TrimPrefix(baseURI, "file://")
+			uri, err := ParseDocumentUri(baseURI)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BaseURI: %w", err)
+			}
+			basePath = uri.Path()
 		case DocumentUri:
-			basePath = strings.TrimPrefix(string(baseURI), "file://")
+			basePath = baseURI.Path()
 		default:
 			return nil, fmt.Errorf("unknown BaseURI type: %T", v.BaseURI.Value)
 		}
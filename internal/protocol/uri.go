@@ -58,8 +58,9 @@ type URI = string
 // where there is no pointer of type *K or *V on which to call
 // UnmarshalJSON. (See Go issue #28189 for more detail.)
 //
-// Non-empty DocumentUris are valid "file"-scheme URIs.
-// The empty DocumentUri is valid.
+// Non-empty DocumentUris are valid "file"-scheme URIs, or URIs of
+// another scheme such as the jar: and jdt: URIs of dependency sources,
+// which are kept as they are. The empty DocumentUri is valid.
 func (uri *DocumentUri) UnmarshalText(data []byte) (err error) {
 	*uri, err = ParseDocumentUri(string(data))
 	return
@@ -69,8 +70,14 @@ func (uri *DocumentUri) UnmarshalText(data []byte) (err error) {
 //
 // DocumentUri("").Path() returns the empty string.
 //
+// For a URI of another scheme, Path returns the URI unchanged, so that
+// it can still be shown and passed back to [URIFromPath].
+//
 // Path panics if called on a URI that is not a valid filename.
 func (uri DocumentUri) Path() string {
+	if !uri.IsFile() {
+		return string(uri)
+	}
 	filename, err := filename(uri)
 	if err != nil {
 		// e.g. ParseRequestURI failed.
@@ -84,6 +91,31 @@ func (uri DocumentUri) Path() string {
 	return filepath.FromSlash(filename)
 }
 
+// IsFile reports whether the URI names a file on disk, i.e. has the
+// "file" scheme. The empty DocumentUri counts as a file URI.
+func (uri DocumentUri) IsFile() bool {
+	return uri == "" || strings.HasPrefix(string(uri), fileScheme+":")
+}
+
+// IsNonFileURI reports whether s is a URI with a scheme other than
+// "file", such as jar:file:///lib.jar!/A.java, rather than a file path.
+// Windows drive letters are not mistaken for schemes.
+func IsNonFileURI(s string) bool {
+	scheme, _, ok := strings.Cut(s, ":")
+	if !ok || len(scheme) < 2 || scheme == fileScheme {
+		return false
+	}
+	for i, r := range scheme {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Dir returns the URI for the directory containing the receiver.
 func (uri DocumentUri) Dir() DocumentUri {
 	// This function could be more efficiently implemented by avoiding any call
@@ -133,6 +165,12 @@ slow:
 		u.Path = strings.ToUpper(string(u.Path[1])) + u.Path[2:]
 	}
 
+	// A file URI with an authority names a UNC path, such as
+	// file://server/share/x which is \\server\share\x on Windows.
+	if u.Host != "" && u.Host != "localhost" {
+		return "//" + u.Host + u.Path, nil
+	}
+
 	return u.Path, nil
 }
 
@@ -143,10 +181,24 @@ func ParseDocumentUri(s string) (DocumentUri, error) {
 		return "", nil
 	}
 
+	// URIs of other schemes are opaque to us, so keep them as the server sent them
+	if IsNonFileURI(s) {
+		return DocumentUri(s), nil
+	}
+
 	if !strings.HasPrefix(s, "file://") {
 		return "", fmt.Errorf("DocumentUri scheme is not 'file': %s", s)
 	}
 
+	// A URI with an authority followed by a path names a UNC path
+	// (file://server/share/x). Keep the host so it is not read as a local path.
+	host := ""
+	if rest := s[len("file://"):]; !strings.HasPrefix(rest, "/") {
+		if i := strings.IndexByte(rest, '/'); i > 0 {
+			host, s = rest[:i], "file://"+rest[i:]
+		}
+	}
+
 	// VS Code sends URLs with only two slashes,
 	// which are invalid. golang/go#39789.
 	if !strings.HasPrefix(s, "file:///") {
@@ -159,6 +211,9 @@ func ParseDocumentUri(s string) (DocumentUri, error) {
 	if err != nil {
 		return "", err
 	}
+	if host == "localhost" {
+		host = ""
+	}
 
 	// File URIs from Windows may have lowercase drive letters.
 	// Since drive letters are guaranteed to be case insensitive,
@@ -167,7 +222,7 @@ func ParseDocumentUri(s string) (DocumentUri, error) {
 	if isWindowsDriveURIPath(path) {
 		path = path[:1] + strings.ToUpper(string(path[1])) + path[2:]
 	}
-	u := url.URL{Scheme: fileScheme, Path: path}
+	u := url.URL{Scheme: fileScheme, Host: host, Path: path}
 	return DocumentUri(u.String()), nil
 }
 
@@ -177,6 +232,15 @@ func URIFromPath(path string) DocumentUri {
 	if path == "" {
 		return ""
 	}
+	if IsNonFileURI(path) {
+		return DocumentUri(path)
+	}
+	// UNC paths (\\server\share\x) become URIs with the server as the authority
+	if isUNCPath(path) {
+		server, rest, _ := strings.Cut(strings.ReplaceAll(path[2:], `\`, "/"), "/")
+		u := url.URL{Scheme: fileScheme, Host: server, Path: "/" + rest}
+		return DocumentUri(u.String())
+	}
 	if !isWindowsDrivePath(path) {
 		if abs, err := filepath.Abs(path); err == nil {
 			path = abs
@@ -206,6 +270,12 @@ func isWindowsDrivePath(path string) bool {
 	return unicode.IsLetter(rune(path[0])) && path[1] == ':'
 }
 
+// isUNCPath returns true if the file path is a Windows UNC path naming a file
+// on a network share. For example: \\server\share\x.
+func isUNCPath(path string) bool {
+	return len(path) > 2 && strings.HasPrefix(path, `\\`) && path[2] != '\\'
+}
+
 // isWindowsDriveURIPath returns true if the file URI is of the format used by
 // Windows URIs. The url.Parse package does not specially handle Windows paths
 // (see golang/go#6027), so we check if the URI path has a drive prefix (e.g. "/C:").
@@ -0,0 +1,65 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURIFromPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected DocumentUri
+	}{
+		{name: "simple", path: "/tmp/project/main.go", expected: "file:///tmp/project/main.go"},
+		{name: "space", path: "/tmp/my project/main.go", expected: "file:///tmp/my%20project/main.go"},
+		{name: "special chars", path: "/tmp/a#b/c?d%e.go", expected: "file:///tmp/a%23b/c%3Fd%25e.go"},
+		{name: "windows drive", path: `c:/project/main.go`, expected: "file:///C:/project/main.go"},
+		{name: "unc", path: `\\server\share\main.go`, expected: "file://server/share/main.go"},
+		{name: "jar uri", path: "jar:file:///lib.jar!/A.java", expected: "jar:file:///lib.jar!/A.java"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, URIFromPath(tt.path))
+		})
+	}
+}
+
+func TestDocumentUriPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		uri      string
+		expected string
+	}{
+		{name: "simple", uri: "file:///tmp/project/main.go", expected: "/tmp/project/main.go"},
+		{name: "encoded space", uri: "file:///tmp/my%20project/main.go", expected: "/tmp/my project/main.go"},
+		{name: "encoded drive colon", uri: "file:///c%3A/project/main.go", expected: "C:/project/main.go"},
+		{name: "unc", uri: "file://server/share/main.go", expected: "//server/share/main.go"},
+		{name: "localhost", uri: "file://localhost/tmp/main.go", expected: "/tmp/main.go"},
+		{name: "jdt", uri: "jdt://contents/rt.jar/java.lang/String.class?=proj", expected: "jdt://contents/rt.jar/java.lang/String.class?=proj"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uri, err := ParseDocumentUri(tt.uri)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, uri.Path())
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		path := "/tmp/my project/ünïcode & more.go"
+		assert.Equal(t, path, URIFromPath(path).Path())
+	})
+}
+
+func TestIsNonFileURI(t *testing.T) {
+	assert.True(t, IsNonFileURI("jar:file:///lib.jar!/A.java"))
+	assert.True(t, IsNonFileURI("untitled:Untitled-1"))
+	assert.False(t, IsNonFileURI("file:///tmp/main.go"))
+	assert.False(t, IsNonFileURI("/tmp/main.go"))
+	assert.False(t, IsNonFileURI(`C:\project\main.go`))
+	assert.False(t, IsNonFileURI("main.go"))
+}
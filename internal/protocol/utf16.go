@@ -0,0 +1,67 @@
+package protocol
+
+// This file converts between the offsets used by LSP positions, which count UTF-16 code
+// units, and the byte and rune (character) offsets used by Go strings and shown to users.
+//
+// All functions take a single line of text without its line ending. Offsets past the end
+// of the line are clamped to it, and a UTF-16 offset in the middle of a surrogate pair
+// resolves to the start of that character.
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// UTF16Len returns the number of UTF-16 code units needed to encode s
+func UTF16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16Width(r)
+	}
+	return n
+}
+
+// ByteToUTF16 converts a byte offset in line into a UTF-16 offset
+func ByteToUTF16(line string, offset int) uint32 {
+	offset = min(max(offset, 0), len(line))
+	return uint32(UTF16Len(line[:offset]))
+}
+
+// UTF16ToByte converts a UTF-16 offset in line into a byte offset
+func UTF16ToByte(line string, character uint32) int {
+	units := 0
+	for i, r := range line {
+		units += utf16Width(r)
+		if units > int(character) {
+			return i
+		}
+	}
+	return len(line)
+}
+
+// RuneToUTF16 converts a rune offset in line into a UTF-16 offset
+func RuneToUTF16(line string, runes int) uint32 {
+	units := 0
+	for _, r := range line {
+		if runes <= 0 {
+			break
+		}
+		units += utf16Width(r)
+		runes--
+	}
+	return uint32(units)
+}
+
+// UTF16ToRune converts a UTF-16 offset in line into a rune offset
+func UTF16ToRune(line string, character uint32) int {
+	return utf8.RuneCountInString(line[:UTF16ToByte(line, character)])
+}
+
+// utf16Width returns the number of UTF-16 code units in r. Invalid UTF-8 decodes to
+// utf8.RuneError, which takes one unit.
+func utf16Width(r rune) int {
+	if n := utf16.RuneLen(r); n > 0 {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,47 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUTF16Conversions(t *testing.T) {
+	// "é" is two bytes and one UTF-16 unit, "😀" is four bytes and two UTF-16 units
+	line := "aé😀b"
+
+	assert.Equal(t, 5, UTF16Len(line))
+
+	tests := []struct {
+		name  string
+		bytes int
+		runes int
+		utf16 uint32
+	}{
+		{name: "start", bytes: 0, runes: 0, utf16: 0},
+		{name: "after ascii", bytes: 1, runes: 1, utf16: 1},
+		{name: "after two byte rune", bytes: 3, runes: 2, utf16: 2},
+		{name: "after surrogate pair", bytes: 7, runes: 3, utf16: 4},
+		{name: "end", bytes: 8, runes: 4, utf16: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.utf16, ByteToUTF16(line, tt.bytes))
+			assert.Equal(t, tt.bytes, UTF16ToByte(line, tt.utf16))
+			assert.Equal(t, tt.utf16, RuneToUTF16(line, tt.runes))
+			assert.Equal(t, tt.runes, UTF16ToRune(line, tt.utf16))
+		})
+	}
+
+	t.Run("inside surrogate pair", func(t *testing.T) {
+		assert.Equal(t, 3, UTF16ToByte(line, 3))
+		assert.Equal(t, 2, UTF16ToRune(line, 3))
+	})
+
+	t.Run("past end of line", func(t *testing.T) {
+		assert.Equal(t, len(line), UTF16ToByte(line, 100))
+		assert.Equal(t, uint32(5), RuneToUTF16(line, 100))
+		assert.Equal(t, uint32(5), ByteToUTF16(line, 100))
+	})
+}
@@ -0,0 +1,389 @@
+package syntax
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// How long ctags may take to index the workspace
+const ctagsIndexTimeout = 5 * time.Minute
+
+// ctagsArgs make universal-ctags write tags as JSON lines, with the fields the symbols
+// are built from: line, end line, long kind name, scope and signature
+var ctagsArgs = []string{"--output-format=json", "--fields=+neKSZ", "--sort=no", "-f", "-"}
+
+// Symbol kinds by ctags kind, long and single letter names
+var ctagsKinds = map[string]protocol.SymbolKind{
+	"function":    protocol.Function,
+	"f":           protocol.Function,
+	"func":        protocol.Function,
+	"method":      protocol.Method,
+	"constructor": protocol.Constructor,
+	"class":       protocol.Class,
+	"c":           protocol.Class,
+	"struct":      protocol.Struct,
+	"s":           protocol.Struct,
+	"union":       protocol.Struct,
+	"u":           protocol.Struct,
+	"interface":   protocol.Interface,
+	"i":           protocol.Interface,
+	"trait":       protocol.Interface,
+	"enum":        protocol.Enum,
+	"g":           protocol.Enum,
+	"enumerator":  protocol.EnumMember,
+	"e":           protocol.EnumMember,
+	"member":      protocol.Field,
+	"field":       protocol.Field,
+	"property":    protocol.Property,
+	"variable":    protocol.Variable,
+	"v":           protocol.Variable,
+	"constant":    protocol.Constant,
+	"macro":       protocol.Constant,
+	"d":           protocol.Constant,
+	"namespace":   protocol.Namespace,
+	"n":           protocol.Namespace,
+	"module":      protocol.Module,
+	"package":     protocol.Package,
+	"p":           protocol.Package,
+	"typedef":     protocol.TypeParameter,
+	"type":        protocol.TypeParameter,
+	"t":           protocol.TypeParameter,
+}
+
+// ctagsTag is a tag of a universal-ctags index, in its JSON output format
+type ctagsTag struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	End       int    `json:"end"`
+	Kind      string `json:"kind"`
+	Scope     string `json:"scope"`
+	Signature string `json:"signature"`
+}
+
+// Ctags reads symbols from a universal-ctags index of the workspace: a tags file, in the
+// JSON or the classic format, or one ctags generates. Files that changed since the index
+// was made are indexed again if ctags is installed.
+type Ctags struct {
+	root     string
+	tagsFile string
+	command  string
+
+	once    sync.Once
+	loadErr error
+
+	mu      sync.Mutex
+	indexed time.Time
+	tags    map[string][]ctagsTag
+	// The modification times of the files indexed since, by path
+	reindexed map[string]time.Time
+	// The extensions of the indexed files, for which new files are indexed too
+	exts map[string]bool
+}
+
+// NewCtags returns a provider reading the symbols of the files under root from a tags
+// file, or from the index ctags generates if tagsFile is empty or doesn't exist. The
+// index is made when the provider is first used.
+func NewCtags(root, tagsFile string) (Provider, error) {
+	command, _ := exec.LookPath("ctags")
+	if command == "" {
+		if _, err := os.Stat(tagsFile); tagsFile == "" || err != nil {
+			return nil, fmt.Errorf("ctags is not installed and there is no tags file to read")
+		}
+	}
+	return &Ctags{root: root, tagsFile: tagsFile, command: command}, nil
+}
+
+func (c *Ctags) Name() string {
+	return "ctags"
+}
+
+func (c *Ctags) Supports(path string) bool {
+	if err := c.load(); err != nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.tags[path]; ok {
+		return true
+	}
+	return c.command != "" && c.exts[strings.ToLower(filepath.Ext(path))]
+}
+
+func (c *Ctags) Symbols(ctx context.Context, path string, content []byte, encoding protocol.PositionEncodingKind) ([]protocol.DocumentSymbol, error) {
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	if err := c.refresh(ctx, path); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	tags := c.tags[path]
+	c.mu.Unlock()
+	return tagSymbols(tags, strings.Split(string(content), "\n"), encoding), nil
+}
+
+// load reads the tags file, or generates the index, once
+func (c *Ctags) load() error {
+	c.once.Do(func() {
+		c.loadErr = c.index()
+		if c.loadErr != nil {
+			c.loadErr = fmt.Errorf("failed to load ctags index: %v", c.loadErr)
+		}
+	})
+	return c.loadErr
+}
+
+func (c *Ctags) index() error {
+	var tags []ctagsTag
+	base := c.root
+	if info, err := os.Stat(c.tagsFile); c.tagsFile != "" && err == nil {
+		file, err := os.Open(c.tagsFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		tags, err = parseTags(file)
+		if err != nil {
+			return err
+		}
+		base = filepath.Dir(c.tagsFile)
+		c.indexed = info.ModTime()
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), ctagsIndexTimeout)
+		defer cancel()
+		c.indexed = time.Now()
+		output, err := c.run(ctx, "-R", c.root)
+		if err != nil {
+			return err
+		}
+		if tags, err = parseTags(bytes.NewReader(output)); err != nil {
+			return err
+		}
+	}
+
+	c.tags = make(map[string][]ctagsTag)
+	c.reindexed = make(map[string]time.Time)
+	c.exts = make(map[string]bool)
+	for _, tag := range tags {
+		path := tag.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(base, path)
+		}
+		c.tags[path] = append(c.tags[path], tag)
+		c.exts[strings.ToLower(filepath.Ext(path))] = true
+	}
+	return nil
+}
+
+// refresh indexes a file again if it changed since it was indexed and ctags is installed
+func (c *Ctags) refresh(ctx context.Context, path string) error {
+	info, err := os.Stat(path)
+	if err != nil || c.command == "" {
+		return nil
+	}
+	c.mu.Lock()
+	since, ok := c.reindexed[path]
+	if !ok {
+		since = c.indexed
+	}
+	c.mu.Unlock()
+	if !info.ModTime().After(since) {
+		return nil
+	}
+
+	output, err := c.run(ctx, path)
+	if err != nil {
+		return err
+	}
+	tags, err := parseTags(bytes.NewReader(output))
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tags[path] = tags
+	c.reindexed[path] = info.ModTime()
+	return nil
+}
+
+// run runs ctags with its JSON output on stdout
+func (c *Ctags) run(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, c.command, append(ctagsArgs, args...)...)
+	cmd.Dir = c.root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ctags failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// parseTags reads the tags of an index in the JSON format of universal-ctags or in the
+// classic tags format
+func parseTags(r io.Reader) ([]ctagsTag, error) {
+	var tags []ctagsTag
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "" || strings.HasPrefix(line, "!_"):
+			continue
+		case strings.HasPrefix(line, "{"):
+			var tag struct {
+				Type string `json:"_type"`
+				ctagsTag
+			}
+			if err := json.Unmarshal([]byte(line), &tag); err != nil {
+				return nil, fmt.Errorf("invalid tag %q: %v", line, err)
+			}
+			if tag.Type == "tag" {
+				tags = append(tags, tag.ctagsTag)
+			}
+		default:
+			if tag, ok := parseClassicTag(line); ok {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags, scanner.Err()
+}
+
+// parseClassicTag reads a line of a classic tags file:
+// name<TAB>path<TAB>address;"<TAB>kind<TAB>field:value...
+func parseClassicTag(line string) (ctagsTag, bool) {
+	fields := strings.Split(line, "\t")
+	if len(fields) < 3 {
+		return ctagsTag{}, false
+	}
+	tag := ctagsTag{Name: fields[0], Path: fields[1]}
+	if n, err := strconv.Atoi(strings.TrimSuffix(fields[2], ";\"")); err == nil {
+		tag.Line = n
+	}
+	for _, field := range fields[3:] {
+		key, value, ok := strings.Cut(field, ":")
+		switch {
+		case !ok || key == "kind":
+			if !ok {
+				value = key
+			}
+			tag.Kind = value
+		case key == "line":
+			tag.Line, _ = strconv.Atoi(value)
+		case key == "end":
+			tag.End, _ = strconv.Atoi(value)
+		case key == "signature":
+			tag.Signature = value
+		case key == "class" || key == "struct" || key == "namespace" || key == "interface" ||
+			key == "enum" || key == "module" || key == "scope":
+			// The scope is given as kind:name, or as scope:kind:name with --fields=+Z
+			if _, name, ok := strings.Cut(value, ":"); ok && key == "scope" {
+				value = name
+			}
+			tag.Scope = value
+		}
+	}
+	return tag, tag.Line > 0
+}
+
+// tagSymbols nests the tags of a file into document symbols by their scopes
+func tagSymbols(tags []ctagsTag, lines []string, encoding protocol.PositionEncodingKind) []protocol.DocumentSymbol {
+	sorted := make([]ctagsTag, len(tags))
+	copy(sorted, tags)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Line < sorted[j].Line })
+
+	type node struct {
+		symbol   protocol.DocumentSymbol
+		children []*node
+	}
+	var roots []*node
+	scopes := make(map[string]*node)
+	for _, tag := range sorted {
+		kind, ok := ctagsKinds[tag.Kind]
+		if !ok {
+			kind = protocol.Variable
+		}
+		if kind == protocol.Field && tag.Signature != "" {
+			// Members are methods in some languages, such as Python
+			kind = protocol.Method
+		}
+		n := &node{symbol: tagSymbol(tag, kind, lines, encoding)}
+		if parent, ok := scopes[normalizeScope(tag.Scope)]; ok && tag.Scope != "" {
+			if kind == protocol.Function && parent.symbol.Kind != protocol.Namespace && parent.symbol.Kind != protocol.Module {
+				n.symbol.Kind = protocol.Method
+			}
+			parent.children = append(parent.children, n)
+		} else {
+			roots = append(roots, n)
+		}
+		scopes[normalizeScope(qualify(tag.Scope, tag.Name))] = n
+	}
+
+	var build func(nodes []*node) []protocol.DocumentSymbol
+	build = func(nodes []*node) []protocol.DocumentSymbol {
+		symbols := make([]protocol.DocumentSymbol, len(nodes))
+		for i, n := range nodes {
+			symbols[i] = n.symbol
+			symbols[i].Children = build(n.children)
+		}
+		return symbols
+	}
+	return build(roots)
+}
+
+// tagSymbol locates a tag in its file. Its range spans whole lines, to its end line if
+// ctags reported one.
+func tagSymbol(tag ctagsTag, kind protocol.SymbolKind, lines []string, encoding protocol.PositionEncodingKind) protocol.DocumentSymbol {
+	start := max(tag.Line-1, 0)
+	end := max(tag.End-1, start)
+	endCharacter := uint32(0)
+	if end < len(lines) {
+		endCharacter = uint32(encoding.CharacterLen(lines[end]))
+	}
+	selection := protocol.Range{Start: protocol.Position{Line: uint32(start)}, End: protocol.Position{Line: uint32(start)}}
+	if start < len(lines) {
+		if i := strings.Index(lines[start], tag.Name); i >= 0 {
+			selection.Start.Character = encoding.ByteToCharacter(lines[start], i)
+			selection.End.Character = encoding.ByteToCharacter(lines[start], i+len(tag.Name))
+		}
+	}
+
+	symbol := protocol.DocumentSymbol{
+		Name:           tag.Name,
+		Kind:           kind,
+		Range:          protocol.Range{Start: protocol.Position{Line: uint32(start)}, End: protocol.Position{Line: uint32(end), Character: endCharacter}},
+		SelectionRange: selection,
+	}
+	if tag.Signature != "" {
+		symbol.Detail = tag.Name + tag.Signature
+	}
+	return symbol
+}
+
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// normalizeScope writes scopes with dots, whatever separators the language uses
+func normalizeScope(scope string) string {
+	return strings.ReplaceAll(scope, "::", ".")
+}
@@ -0,0 +1,112 @@
+package syntax
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ctagsSource = "class Shape:\n    def area(self):\n        return 0\n\ndef main():\n    pass\n"
+
+func TestParseTags(t *testing.T) {
+	index := strings.Join([]string{
+		`{"_type": "ptag", "name": "JSON_OUTPUT_VERSION", "path": "0.0"}`,
+		`{"_type": "tag", "name": "Shape", "path": "shapes.py", "line": 1, "end": 3, "kind": "class"}`,
+		`{"_type": "tag", "name": "area", "path": "shapes.py", "line": 2, "end": 3, "kind": "member", "scope": "Shape", "scopeKind": "class", "signature": "(self)"}`,
+		"!_TAG_FILE_FORMAT\t2\t/extended format/",
+		"main\tshapes.py\t5;\"\tf\tline:5",
+		"draw\tgeo.cpp\t/^void Shape::draw() {$/;\"\tkind:function\tline:3\tclass:geo::Shape",
+	}, "\n")
+
+	tags, err := parseTags(strings.NewReader(index))
+	require.NoError(t, err)
+	require.Len(t, tags, 4)
+	assert.Equal(t, ctagsTag{Name: "area", Path: "shapes.py", Line: 2, End: 3, Kind: "member", Scope: "Shape", Signature: "(self)"}, tags[1])
+	assert.Equal(t, ctagsTag{Name: "main", Path: "shapes.py", Line: 5, Kind: "f"}, tags[2])
+	assert.Equal(t, ctagsTag{Name: "draw", Path: "geo.cpp", Line: 3, Kind: "function", Scope: "geo::Shape"}, tags[3])
+
+	symbols := tagSymbols(tags[:3], strings.Split(ctagsSource, "\n"), protocol.UTF16)
+	require.Len(t, symbols, 2)
+	shape := symbols[0]
+	assert.Equal(t, "Shape", shape.Name)
+	assert.Equal(t, protocol.Class, shape.Kind)
+	assert.Equal(t, protocol.Range{Start: protocol.Position{Line: 0}, End: protocol.Position{Line: 2, Character: 16}}, shape.Range)
+	assert.Equal(t, protocol.Range{Start: protocol.Position{Line: 0, Character: 6}, End: protocol.Position{Line: 0, Character: 11}}, shape.SelectionRange)
+	require.Len(t, shape.Children, 1)
+	assert.Equal(t, "area", shape.Children[0].Name)
+	assert.Equal(t, protocol.Method, shape.Children[0].Kind)
+	assert.Equal(t, "area(self)", shape.Children[0].Detail)
+	assert.Equal(t, "main", symbols[1].Name)
+	assert.Equal(t, protocol.Function, symbols[1].Kind)
+}
+
+func TestCtagsTagsFile(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "shapes.py")
+	require.NoError(t, os.WriteFile(source, []byte(ctagsSource), 0644))
+	tagsFile := filepath.Join(root, "tags")
+	require.NoError(t, os.WriteFile(tagsFile, []byte("Shape\tshapes.py\t1;\"\tc\nmain\tshapes.py\t5;\"\tf\n"), 0644))
+
+	provider := &Ctags{root: root, tagsFile: tagsFile}
+	assert.True(t, provider.Supports(source))
+	assert.False(t, provider.Supports(filepath.Join(root, "other.py")))
+
+	symbols, err := provider.Symbols(context.Background(), source, []byte(ctagsSource), protocol.UTF16)
+	require.NoError(t, err)
+	require.Len(t, symbols, 2)
+	assert.Equal(t, "Shape", symbols[0].Name)
+	assert.Equal(t, "main", symbols[1].Name)
+}
+
+func TestCtagsIndexes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of ctags")
+	}
+	root := t.TempDir()
+	source := filepath.Join(root, "shapes.py")
+	require.NoError(t, os.WriteFile(source, []byte(ctagsSource), 0644))
+
+	// A stand-in for ctags that reports one function on line 5 of the files it indexes,
+	// and records how often it ran
+	command := filepath.Join(t.TempDir(), "ctags")
+	script := "#!/bin/sh\necho run >> " + filepath.Join(root, "runs") + "\n" +
+		"for last; do :; done\n" +
+		`[ "$last" = "` + root + `" ] && last=shapes.py` + "\n" +
+		`echo "{\"_type\": \"tag\", \"name\": \"main\", \"path\": \"$last\", \"line\": 5, \"kind\": \"function\"}"` + "\n"
+	require.NoError(t, os.WriteFile(command, []byte(script), 0755))
+	runs := func() int {
+		content, _ := os.ReadFile(filepath.Join(root, "runs"))
+		return strings.Count(string(content), "run")
+	}
+
+	provider := &Ctags{root: root, command: command}
+	assert.True(t, provider.Supports(source))
+	// New files of the languages in the index are indexed too
+	assert.True(t, provider.Supports(filepath.Join(root, "new.py")))
+	assert.False(t, provider.Supports(filepath.Join(root, "notes.txt")))
+	assert.Equal(t, 1, runs())
+
+	symbols, err := provider.Symbols(context.Background(), source, []byte(ctagsSource), protocol.UTF16)
+	require.NoError(t, err)
+	require.Len(t, symbols, 1)
+	assert.Equal(t, "main", symbols[0].Name)
+	assert.Equal(t, 1, runs())
+
+	// A file changed since it was indexed is indexed again
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(source, future, future))
+	_, err = provider.Symbols(context.Background(), source, []byte(ctagsSource), protocol.UTF16)
+	require.NoError(t, err)
+	assert.Equal(t, 2, runs())
+	_, err = provider.Symbols(context.Background(), source, []byte(ctagsSource), protocol.UTF16)
+	require.NoError(t, err)
+	assert.Equal(t, 2, runs())
+}
@@ -0,0 +1,38 @@
+// Package syntax reads the symbols declared in source files without a language server,
+// from their syntax alone. Tools fall back on it for files and workspaces the language
+// server has nothing for, marking the results as approximate.
+package syntax
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Provider reads the symbols declared in source files
+type Provider interface {
+	// Name tells where the symbols come from, such as "tree-sitter", to mark results with
+	Name() string
+	// Supports reports whether the provider can read a file
+	Supports(path string) bool
+	// Symbols returns the symbols declared in a file, nested as in document symbols, with
+	// the characters of their ranges counted in encoding
+	Symbols(ctx context.Context, path string, content []byte, encoding protocol.PositionEncodingKind) ([]protocol.DocumentSymbol, error)
+}
+
+// NewProvider returns the provider with a name, "tree-sitter" or "ctags", for the files
+// under root. tagsFile is the index ctags reads, see NewCtags. An empty name selects
+// none and returns nil.
+func NewProvider(name, root, tagsFile string) (Provider, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "tree-sitter":
+		return NewTreeSitter()
+	case "ctags":
+		return NewCtags(root, tagsFile)
+	default:
+		return nil, fmt.Errorf("unknown fallback %q, expected tree-sitter or ctags", name)
+	}
+}
@@ -0,0 +1,370 @@
+//go:build cgo
+
+package syntax
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/c"
+	"github.com/smacker/go-tree-sitter/cpp"
+	"github.com/smacker/go-tree-sitter/csharp"
+	"github.com/smacker/go-tree-sitter/golang"
+	"github.com/smacker/go-tree-sitter/java"
+	"github.com/smacker/go-tree-sitter/javascript"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/ruby"
+	"github.com/smacker/go-tree-sitter/rust"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+)
+
+// grammar is a tree-sitter language with the kinds of the symbols its declarations are
+// read as, by node type
+type grammar struct {
+	language *sitter.Language
+	kinds    map[string]protocol.SymbolKind
+}
+
+var (
+	goGrammar = grammar{golang.GetLanguage(), map[string]protocol.SymbolKind{
+		"function_declaration": protocol.Function,
+		"method_declaration":   protocol.Method,
+		"type_spec":            protocol.Class,
+		"type_alias":           protocol.Class,
+		"const_spec":           protocol.Constant,
+		"var_spec":             protocol.Variable,
+		"field_declaration":    protocol.Field,
+		"method_spec":          protocol.Method,
+		"method_elem":          protocol.Method,
+	}}
+	pythonGrammar = grammar{python.GetLanguage(), map[string]protocol.SymbolKind{
+		"function_definition": protocol.Function,
+		"class_definition":    protocol.Class,
+	}}
+	javascriptKinds = map[string]protocol.SymbolKind{
+		"function_declaration":           protocol.Function,
+		"generator_function_declaration": protocol.Function,
+		"class_declaration":              protocol.Class,
+		"method_definition":              protocol.Method,
+		"field_definition":               protocol.Field,
+		"variable_declarator":            protocol.Variable,
+	}
+	typescriptKinds = merge(javascriptKinds, map[string]protocol.SymbolKind{
+		"abstract_class_declaration": protocol.Class,
+		"interface_declaration":      protocol.Interface,
+		"type_alias_declaration":     protocol.Class,
+		"enum_declaration":           protocol.Enum,
+		"function_signature":         protocol.Function,
+		"method_signature":           protocol.Method,
+		"abstract_method_signature":  protocol.Method,
+		"public_field_definition":    protocol.Field,
+		"property_signature":         protocol.Property,
+		"internal_module":            protocol.Namespace,
+		"module":                     protocol.Module,
+	})
+	javascriptGrammar = grammar{javascript.GetLanguage(), javascriptKinds}
+	typescriptGrammar = grammar{typescript.GetLanguage(), typescriptKinds}
+	tsxGrammar        = grammar{tsx.GetLanguage(), typescriptKinds}
+	rustGrammar       = grammar{rust.GetLanguage(), map[string]protocol.SymbolKind{
+		"function_item":           protocol.Function,
+		"function_signature_item": protocol.Function,
+		"struct_item":             protocol.Struct,
+		"enum_item":               protocol.Enum,
+		"union_item":              protocol.Struct,
+		"trait_item":              protocol.Interface,
+		"impl_item":               protocol.Object,
+		"mod_item":                protocol.Module,
+		"const_item":              protocol.Constant,
+		"static_item":             protocol.Variable,
+		"type_item":               protocol.TypeParameter,
+		"macro_definition":        protocol.Function,
+		"field_declaration":       protocol.Field,
+		"enum_variant":            protocol.EnumMember,
+	}}
+	javaGrammar = grammar{java.GetLanguage(), map[string]protocol.SymbolKind{
+		"class_declaration":           protocol.Class,
+		"record_declaration":          protocol.Class,
+		"interface_declaration":       protocol.Interface,
+		"annotation_type_declaration": protocol.Interface,
+		"enum_declaration":            protocol.Enum,
+		"enum_constant":               protocol.EnumMember,
+		"method_declaration":          protocol.Method,
+		"constructor_declaration":     protocol.Constructor,
+		"field_declaration":           protocol.Field,
+	}}
+	cKinds = map[string]protocol.SymbolKind{
+		"function_definition": protocol.Function,
+		"struct_specifier":    protocol.Struct,
+		"union_specifier":     protocol.Struct,
+		"enum_specifier":      protocol.Enum,
+		"enumerator":          protocol.EnumMember,
+		"type_definition":     protocol.TypeParameter,
+		"field_declaration":   protocol.Field,
+	}
+	cGrammar   = grammar{c.GetLanguage(), cKinds}
+	cppGrammar = grammar{cpp.GetLanguage(), merge(cKinds, map[string]protocol.SymbolKind{
+		"class_specifier":      protocol.Class,
+		"namespace_definition": protocol.Namespace,
+	})}
+	rubyGrammar = grammar{ruby.GetLanguage(), map[string]protocol.SymbolKind{
+		"class":            protocol.Class,
+		"module":           protocol.Module,
+		"method":           protocol.Method,
+		"singleton_method": protocol.Method,
+	}}
+	csharpGrammar = grammar{csharp.GetLanguage(), map[string]protocol.SymbolKind{
+		"namespace_declaration":             protocol.Namespace,
+		"file_scoped_namespace_declaration": protocol.Namespace,
+		"class_declaration":                 protocol.Class,
+		"record_declaration":                protocol.Class,
+		"struct_declaration":                protocol.Struct,
+		"interface_declaration":             protocol.Interface,
+		"enum_declaration":                  protocol.Enum,
+		"enum_member_declaration":           protocol.EnumMember,
+		"method_declaration":                protocol.Method,
+		"constructor_declaration":           protocol.Constructor,
+		"property_declaration":              protocol.Property,
+	}}
+)
+
+// grammars are the bundled grammars by file extension
+var grammars = map[string]grammar{
+	".go":   goGrammar,
+	".py":   pythonGrammar,
+	".pyi":  pythonGrammar,
+	".js":   javascriptGrammar,
+	".jsx":  javascriptGrammar,
+	".mjs":  javascriptGrammar,
+	".cjs":  javascriptGrammar,
+	".ts":   typescriptGrammar,
+	".mts":  typescriptGrammar,
+	".cts":  typescriptGrammar,
+	".tsx":  tsxGrammar,
+	".rs":   rustGrammar,
+	".java": javaGrammar,
+	".c":    cGrammar,
+	".h":    cGrammar,
+	".cc":   cppGrammar,
+	".cpp":  cppGrammar,
+	".cxx":  cppGrammar,
+	".hh":   cppGrammar,
+	".hpp":  cppGrammar,
+	".hxx":  cppGrammar,
+	".rb":   rubyGrammar,
+	".cs":   csharpGrammar,
+}
+
+func merge(a, b map[string]protocol.SymbolKind) map[string]protocol.SymbolKind {
+	merged := make(map[string]protocol.SymbolKind, len(a)+len(b))
+	for k, v := range a {
+		merged[k] = v
+	}
+	for k, v := range b {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Kinds of symbols whose members are listed as their children. The bodies of other
+// symbols, such as functions, are not searched for declarations.
+var containerKinds = map[protocol.SymbolKind]bool{
+	protocol.Class:     true,
+	protocol.Struct:    true,
+	protocol.Interface: true,
+	protocol.Enum:      true,
+	protocol.Object:    true,
+	protocol.Module:    true,
+	protocol.Namespace: true,
+}
+
+// Node types that are a declaration's name when found by following its declarators
+var nameTypes = map[string]bool{
+	"identifier":           true,
+	"field_identifier":     true,
+	"type_identifier":      true,
+	"qualified_identifier": true,
+	"destructor_name":      true,
+	"operator_name":        true,
+	"property_identifier":  true,
+	"constant":             true,
+}
+
+// TreeSitter reads symbols with the tree-sitter grammars bundled for Go, Python,
+// JavaScript, TypeScript, Rust, Java, C, C++, Ruby and C#
+type TreeSitter struct{}
+
+// NewTreeSitter returns the tree-sitter provider
+func NewTreeSitter() (Provider, error) {
+	return TreeSitter{}, nil
+}
+
+func (TreeSitter) Name() string {
+	return "tree-sitter"
+}
+
+func (TreeSitter) Supports(path string) bool {
+	_, ok := grammars[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+func (TreeSitter) Symbols(ctx context.Context, path string, content []byte, encoding protocol.PositionEncodingKind) ([]protocol.DocumentSymbol, error) {
+	g, ok := grammars[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, fmt.Errorf("no grammar for %s", path)
+	}
+
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(g.language)
+	tree, err := parser.ParseCtx(ctx, nil, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	defer tree.Close()
+
+	r := symbolReader{grammar: g, content: content, lines: strings.Split(string(content), "\n"), encoding: encoding}
+	return r.children(tree.RootNode(), 0), nil
+}
+
+// symbolReader turns the declarations in a syntax tree into document symbols
+type symbolReader struct {
+	grammar
+	content  []byte
+	lines    []string
+	encoding protocol.PositionEncodingKind
+}
+
+// children returns the symbols declared under a node, outside of the bodies of the
+// symbols that are not containers. parentKind is the kind of the enclosing symbol.
+func (r symbolReader) children(node *sitter.Node, parentKind protocol.SymbolKind) []protocol.DocumentSymbol {
+	var symbols []protocol.DocumentSymbol
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		symbol, ok := r.symbol(child, parentKind)
+		if !ok {
+			symbols = append(symbols, r.children(child, parentKind)...)
+			continue
+		}
+		if containerKinds[symbol.Kind] {
+			symbol.Children = r.children(child, symbol.Kind)
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// symbol reads the symbol a node declares, if it is a declaration with a name
+func (r symbolReader) symbol(node *sitter.Node, parentKind protocol.SymbolKind) (protocol.DocumentSymbol, bool) {
+	kind, ok := r.kinds[node.Type()]
+	if !ok {
+		return protocol.DocumentSymbol{}, false
+	}
+	nameNode := declarationName(node)
+	if nameNode == nil {
+		return protocol.DocumentSymbol{}, false
+	}
+	name := nameNode.Content(r.content)
+
+	switch node.Type() {
+	case "type_spec":
+		if typ := node.ChildByFieldName("type"); typ != nil {
+			switch typ.Type() {
+			case "struct_type":
+				kind = protocol.Struct
+			case "interface_type":
+				kind = protocol.Interface
+			}
+		}
+	case "method_declaration":
+		// Go methods are named after their receiver, as gopls names them
+		if receiver := goReceiver(node, r.content); receiver != "" {
+			name = "(" + receiver + ")." + name
+		}
+	case "impl_item":
+		name = "impl " + name
+	case "variable_declarator":
+		// Only declarations at the top of a module or a class are symbols
+		if parentKind != 0 && !containerKinds[parentKind] {
+			return protocol.DocumentSymbol{}, false
+		}
+	}
+	if kind == protocol.Function && containerKinds[parentKind] && parentKind != protocol.Module && parentKind != protocol.Namespace {
+		kind = protocol.Method
+	}
+
+	symbol := protocol.DocumentSymbol{
+		Name:           name,
+		Kind:           kind,
+		Range:          r.rangeOf(node),
+		SelectionRange: r.rangeOf(nameNode),
+	}
+	if kind == protocol.Function || kind == protocol.Method || kind == protocol.Constructor {
+		symbol.Detail = r.signature(node)
+	}
+	return symbol, true
+}
+
+// declarationName finds the node naming a declaration: its name field, or the name of
+// its declarator in C-like grammars, or the type a Rust impl block is for
+func declarationName(node *sitter.Node) *sitter.Node {
+	for depth := 0; node != nil && depth < 8; depth++ {
+		if nameTypes[node.Type()] && depth > 0 {
+			return node
+		}
+		if name := node.ChildByFieldName("name"); name != nil {
+			return name
+		}
+		if declarator := node.ChildByFieldName("declarator"); declarator != nil {
+			node = declarator
+			continue
+		}
+		if node.Type() == "impl_item" {
+			return node.ChildByFieldName("type")
+		}
+		return nil
+	}
+	return nil
+}
+
+// goReceiver returns the receiver type of a Go method, such as "*Server", without its
+// type parameters
+func goReceiver(node *sitter.Node, content []byte) string {
+	receiver := node.ChildByFieldName("receiver")
+	if receiver == nil || receiver.NamedChildCount() == 0 {
+		return ""
+	}
+	typ := receiver.NamedChild(0).ChildByFieldName("type")
+	if typ == nil {
+		return ""
+	}
+	name, _, _ := strings.Cut(typ.Content(content), "[")
+	return name
+}
+
+// signature returns the first line of a declaration without its opening brace, to
+// detail functions with as language servers do
+func (r symbolReader) signature(node *sitter.Node) string {
+	text := node.Content(r.content)
+	text, _, _ = strings.Cut(text, "\n")
+	text = strings.TrimSpace(text)
+	text = strings.TrimSuffix(text, "{")
+	return strings.TrimSpace(text)
+}
+
+// rangeOf returns a node's range, with columns in the reader's position encoding
+func (r symbolReader) rangeOf(node *sitter.Node) protocol.Range {
+	return protocol.Range{Start: r.position(node.StartPoint()), End: r.position(node.EndPoint())}
+}
+
+func (r symbolReader) position(p sitter.Point) protocol.Position {
+	character := p.Column
+	if int(p.Row) < len(r.lines) {
+		character = r.encoding.ByteToCharacter(r.lines[p.Row], int(p.Column))
+	}
+	return protocol.Position{Line: p.Row, Character: character}
+}
@@ -0,0 +1,10 @@
+//go:build !cgo
+
+package syntax
+
+import "fmt"
+
+// NewTreeSitter fails in builds without cgo, which the tree-sitter grammars need
+func NewTreeSitter() (Provider, error) {
+	return nil, fmt.Errorf("tree-sitter is not available in builds without cgo")
+}
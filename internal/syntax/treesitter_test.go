@@ -0,0 +1,115 @@
+//go:build cgo
+
+package syntax
+
+import (
+	"context"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outline flattens symbols into "Kind Name" lines, indented by depth
+func outline(symbols []protocol.DocumentSymbol, indent string) []string {
+	var lines []string
+	for _, s := range symbols {
+		lines = append(lines, indent+protocol.TableKindMap[s.Kind]+" "+s.Name)
+		lines = append(lines, outline(s.Children, indent+"  ")...)
+	}
+	return lines
+}
+
+func TestTreeSitterSymbols(t *testing.T) {
+	tests := []struct {
+		path    string
+		source  string
+		outline []string
+	}{
+		{
+			path:   "server.go",
+			source: "package main\n\ntype Server struct {\n\tName string\n}\n\nfunc (s *Server[T]) Start() {\n\tx := 1\n}\n\nfunc main() {}\n",
+			outline: []string{
+				"Struct Server",
+				"  Field Name",
+				"Method (*Server).Start",
+				"Function main",
+			},
+		},
+		{
+			path:   "models.py",
+			source: "class Model(Base):\n    def save(self):\n        def inner(): pass\n\n@cached\ndef load():\n    pass\n",
+			outline: []string{
+				"Class Model",
+				"  Method save",
+				"Function load",
+			},
+		},
+		{
+			path:   "api.ts",
+			source: "interface Api { get(): void }\nnamespace N { export function f(): void {} }\nexport const handler = () => { const local = 1 };\n",
+			outline: []string{
+				"Interface Api",
+				"  Method get",
+				"Namespace N",
+				"  Function f",
+				"Variable handler",
+			},
+		},
+		{
+			path:   "lib.rs",
+			source: "struct S { a: i32 }\ntrait T { fn t(&self); }\nimpl T for S { fn t(&self) {} }\n",
+			outline: []string{
+				"Struct S",
+				"  Field a",
+				"Interface T",
+				"  Method t",
+				"Object impl S",
+				"  Method t",
+			},
+		},
+		{
+			path:   "shapes.cpp",
+			source: "namespace geo { class Shape { public: void draw() {} int sides; }; }\nvoid geo::Shape::move() {}\n",
+			outline: []string{
+				"Namespace geo",
+				"  Class Shape",
+				"    Method draw",
+				"    Field sides",
+				"Function geo::Shape::move",
+			},
+		},
+	}
+
+	provider, err := NewTreeSitter()
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			require.True(t, provider.Supports(tt.path))
+			symbols, err := provider.Symbols(context.Background(), tt.path, []byte(tt.source), protocol.UTF16)
+			require.NoError(t, err)
+			assert.Equal(t, tt.outline, outline(symbols, ""))
+		})
+	}
+
+	assert.False(t, provider.Supports("notes.txt"))
+}
+
+func TestTreeSitterRanges(t *testing.T) {
+	provider, err := NewTreeSitter()
+	require.NoError(t, err)
+
+	// Columns count UTF-16 code units, as LSP positions do by default
+	source := "const s = \"é\"; function greet(name) {\n  return name\n}\n"
+	symbols, err := provider.Symbols(context.Background(), "greet.js", []byte(source), protocol.UTF16)
+	require.NoError(t, err)
+	require.Len(t, symbols, 2)
+
+	greet := symbols[1]
+	assert.Equal(t, "greet", greet.Name)
+	assert.Equal(t, "function greet(name)", greet.Detail)
+	assert.Equal(t, protocol.Position{Line: 0, Character: 24}, greet.SelectionRange.Start)
+	assert.Equal(t, protocol.Position{Line: 0, Character: 15}, greet.Range.Start)
+	assert.Equal(t, protocol.Position{Line: 2, Character: 1}, greet.Range.End)
+}
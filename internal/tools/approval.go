@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+)
+
+// ApprovalAction is what an ApprovalPolicy does with a tool call that changes files
+type ApprovalAction string
+
+const (
+	// ApprovalAllow lets the call change the files
+	ApprovalAllow ApprovalAction = "allow"
+	// ApprovalConfirm lets the call change the files only once it is confirmed
+	ApprovalConfirm ApprovalAction = "confirm"
+	// ApprovalDeny refuses the call
+	ApprovalDeny ApprovalAction = "deny"
+)
+
+// ParseApprovalAction validates an approval action name. An empty name selects
+// ApprovalAllow.
+func ParseApprovalAction(name string) (ApprovalAction, error) {
+	switch action := ApprovalAction(name); action {
+	case "":
+		return ApprovalAllow, nil
+	case ApprovalAllow, ApprovalConfirm, ApprovalDeny:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown approval action %q, expected allow, confirm or deny", name)
+	}
+}
+
+// strictness orders actions from the most to the least permissive
+func (a ApprovalAction) strictness() int {
+	return slices.Index([]ApprovalAction{ApprovalAllow, ApprovalConfirm, ApprovalDeny}, a)
+}
+
+// ApprovalRule applies an action to the calls of some tools that change files matching
+// some globs
+type ApprovalRule struct {
+	// Tools the rule applies to. Empty means every tool that changes files.
+	Tools []string
+	// Paths are globs, as in PathFilter, of the files the rule applies to. Empty means
+	// every file.
+	Paths  []string
+	Action ApprovalAction
+}
+
+// matches reports whether the rule applies to a tool changing a file
+func (r ApprovalRule) matches(root, tool, path string) bool {
+	if len(r.Tools) > 0 && !slices.Contains(r.Tools, tool) {
+		return false
+	}
+	return len(r.Paths) == 0 || PathFilter{Root: root, Include: r.Paths}.Allows(path)
+}
+
+// ApprovalPolicy decides whether the tools may write the files they are about to change.
+// Each file is given the action of the first rule that applies to it, or Default, and a
+// write gets the strictest action of its files. The zero policy allows every write.
+type ApprovalPolicy struct {
+	Root    string
+	Rules   []ApprovalRule
+	Default ApprovalAction
+}
+
+// IsEmpty reports whether the policy allows every write
+func (p ApprovalPolicy) IsEmpty() bool {
+	if p.Default != "" && p.Default != ApprovalAllow {
+		return false
+	}
+	for _, rule := range p.Rules {
+		if rule.Action != ApprovalAllow {
+			return false
+		}
+	}
+	return true
+}
+
+// Decide returns the action for a tool writing files, along with the files that call
+// for it
+func (p ApprovalPolicy) Decide(tool string, paths []string) (ApprovalAction, []string) {
+	decision := ApprovalAllow
+	var files []string
+	for _, path := range paths {
+		action := p.Default
+		if action == "" {
+			action = ApprovalAllow
+		}
+		for _, rule := range p.Rules {
+			if rule.matches(p.Root, tool, path) {
+				action = rule.Action
+				break
+			}
+		}
+		switch {
+		case action.strictness() > decision.strictness():
+			decision, files = action, []string{path}
+		case action == decision && action != ApprovalAllow:
+			files = append(files, path)
+		}
+	}
+	return decision, files
+}
+
+// Approval is the approval state of one tool call: the policy it is subject to, whether
+// it was confirmed, and the files it was stopped from writing for lack of confirmation
+type Approval struct {
+	Policy    ApprovalPolicy
+	Tool      string
+	Confirmed bool
+
+	mu          sync.Mutex
+	unconfirmed []string
+}
+
+// Unconfirmed returns the files the call needed confirmation to write
+func (a *Approval) Unconfirmed() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.unconfirmed
+}
+
+type approvalKey struct{}
+
+// WithApproval returns a context in which the tools check their writes against a's policy
+func WithApproval(ctx context.Context, a *Approval) context.Context {
+	return context.WithValue(ctx, approvalKey{}, a)
+}
+
+// prepareWrite is called by tools before they change files. It checks the write against
+// the context's approval policy, if there is one, and snapshots the files into the
+// context's journal entry so the change can be undone. Directories cover the files in
+// them.
+func prepareWrite(ctx context.Context, paths ...string) error {
+	if a, _ := ctx.Value(approvalKey{}).(*Approval); a != nil && !a.Policy.IsEmpty() {
+		action, files := a.Policy.Decide(a.Tool, expandDirs(paths))
+		switch {
+		case action == ApprovalDeny:
+			return fmt.Errorf("the approval policy doesn't allow %s to change %v", a.Tool, files)
+		case action == ApprovalConfirm && !a.Confirmed:
+			a.mu.Lock()
+			a.unconfirmed = files
+			a.mu.Unlock()
+			return fmt.Errorf("changing %v needs confirmation", files)
+		}
+	}
+	snapshotFiles(ctx, paths...)
+	return nil
+}
+
+// guardServerEdits passes the workspace edits the server applies itself, while a tool runs
+// one of its commands, through prepareWrite, so that they follow the approval policy and
+// can be undone. onReject, if set, is called when an edit is rejected. The returned
+// function stops guarding and returns the error that rejected the first edit, if any.
+func guardServerEdits(ctx context.Context, client *lsp.Client, onReject func()) (stop func() error) {
+	var mu sync.Mutex
+	var rejected error
+	stopGuard := client.GuardEdits(func(paths []string) error {
+		err := prepareWrite(ctx, paths...)
+		if err != nil {
+			mu.Lock()
+			if rejected == nil {
+				rejected = err
+			}
+			mu.Unlock()
+			if onReject != nil {
+				onReject()
+			}
+		}
+		return err
+	})
+	return func() error {
+		stopGuard()
+		mu.Lock()
+		defer mu.Unlock()
+		return rejected
+	}
+}
+
+// expandDirs returns the paths with the files in the directories among them added
+func expandDirs(paths []string) []string {
+	var expanded []string
+	for _, path := range paths {
+		expanded = append(expanded, path)
+		if info, err := os.Lstat(path); err == nil && info.IsDir() {
+			_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+				if err == nil && d.Type().IsRegular() {
+					expanded = append(expanded, p)
+				}
+				return nil
+			})
+		}
+	}
+	return expanded
+}
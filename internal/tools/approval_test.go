@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalPolicyDecide(t *testing.T) {
+	policy := ApprovalPolicy{
+		Root: "/w",
+		Rules: []ApprovalRule{
+			{Paths: []string{"vendor/"}, Action: ApprovalDeny},
+			{Tools: []string{"delete_file"}, Action: ApprovalConfirm},
+			{Paths: []string{"go.mod", "migrations/**"}, Action: ApprovalConfirm},
+			{Paths: []string{"*_test.go"}, Action: ApprovalAllow},
+		},
+		Default: ApprovalAllow,
+	}
+	assert.False(t, policy.IsEmpty())
+	assert.True(t, ApprovalPolicy{Rules: []ApprovalRule{{Action: ApprovalAllow}}}.IsEmpty())
+
+	action, files := policy.Decide("edit_file", []string{"/w/main.go", "/w/main_test.go"})
+	assert.Equal(t, ApprovalAllow, action)
+	assert.Empty(t, files)
+
+	action, files = policy.Decide("rename_symbol", []string{"/w/main.go", "/w/go.mod", "/w/migrations/1.sql"})
+	assert.Equal(t, ApprovalConfirm, action)
+	assert.Equal(t, []string{"/w/go.mod", "/w/migrations/1.sql"}, files)
+
+	action, files = policy.Decide("delete_file", []string{"/w/go.mod", "/w/vendor/x/x.go"})
+	assert.Equal(t, ApprovalDeny, action)
+	assert.Equal(t, []string{"/w/vendor/x/x.go"}, files)
+
+	// The first rule applying to a file decides
+	action, _ = policy.Decide("delete_file", []string{"/w/main_test.go"})
+	assert.Equal(t, ApprovalConfirm, action)
+
+	_, err := ParseApprovalAction("ask")
+	assert.Error(t, err)
+}
+
+func TestApprovalStopsWrites(t *testing.T) {
+	client := &lsp.Client{}
+	dir := t.TempDir()
+	approval := &Approval{
+		Policy: ApprovalPolicy{Root: dir, Rules: []ApprovalRule{
+			{Paths: []string{"generated/"}, Action: ApprovalDeny},
+			{Paths: []string{"*.sql"}, Action: ApprovalConfirm},
+		}},
+		Tool: "create_file",
+	}
+	ctx := WithApproval(context.Background(), approval)
+
+	_, err := CreateFile(ctx, client, filepath.Join(dir, "generated", "x.go"), "", false)
+	assert.ErrorContains(t, err, "doesn't allow create_file")
+	assert.NoFileExists(t, filepath.Join(dir, "generated", "x.go"))
+
+	path := filepath.Join(dir, "schema.sql")
+	_, err = CreateFile(ctx, client, path, "", false)
+	assert.ErrorContains(t, err, "needs confirmation")
+	assert.Equal(t, []string{path}, approval.Unconfirmed())
+	assert.NoFileExists(t, path)
+
+	approval.Confirmed = true
+	_, err = CreateFile(ctx, client, path, "", false)
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+}
+
+func TestGuardServerEdits(t *testing.T) {
+	client := &lsp.Client{}
+	client.SetApplyEditPolicy(lsp.ApplyEditAllow)
+	dir := t.TempDir()
+	allowed := filepath.Join(dir, "index.ts")
+	denied := filepath.Join(dir, "generated", "api.ts")
+	require.NoError(t, os.MkdirAll(filepath.Dir(denied), 0755))
+	for _, path := range []string{allowed, denied} {
+		require.NoError(t, os.WriteFile(path, []byte("import { a } from './old'\n"), 0644))
+	}
+	approval := &Approval{
+		Policy: ApprovalPolicy{Root: dir, Rules: []ApprovalRule{{Paths: []string{"generated/"}, Action: ApprovalDeny}}},
+		Tool:   "ts_rename_file",
+	}
+	journal := NewJournal()
+	entry := journal.Begin("ts_rename_file")
+	ctx := WithApproval(WithJournal(context.Background(), entry), approval)
+
+	applyEdit := func(path string) bool {
+		params, err := json.Marshal(protocol.ApplyWorkspaceEditParams{Edit: protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+				protocol.URIFromPath(path): {{
+					Range:   protocol.Range{Start: protocol.Position{Line: 0, Character: 19}, End: protocol.Position{Line: 0, Character: 24}},
+					NewText: "./new",
+				}},
+			},
+		}})
+		require.NoError(t, err)
+		result, err := lsp.HandleApplyEdit(client, params)
+		require.NoError(t, err)
+		return result.(protocol.ApplyWorkspaceEditResult).Applied
+	}
+
+	// The server's edits follow the approval policy and are recorded for undo
+	stop := guardServerEdits(ctx, client, nil)
+	assert.True(t, applyEdit(allowed))
+	assert.False(t, applyEdit(denied))
+	assert.ErrorContains(t, stop(), "doesn't allow ts_rename_file")
+	journal.Record(entry)
+	assert.Contains(t, journal.List(), allowed+" (modified)")
+	assert.NotContains(t, journal.List(), denied)
+
+	_, err := UndoLastEdit(context.Background(), client, journal, false, false)
+	require.NoError(t, err)
+	content, err := os.ReadFile(allowed)
+	require.NoError(t, err)
+	assert.Equal(t, "import { a } from './old'\n", string(content))
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+)
+
+// Maximum number of targets in a batch query, and how many of them are queried at once
+const (
+	MaxBatchTargets  = 20
+	batchConcurrency = 4
+)
+
+// PositionTarget is a symbol to query in a batch, given either by position or by name.
+// Lines and columns are 1-indexed.
+type PositionTarget struct {
+	FilePath   string
+	Line       int
+	Column     int
+	SymbolName string
+}
+
+// PositionQuery is a query about the symbol at a position, such as FindReferences
+type PositionQuery func(ctx context.Context, filePath string, line, column int) (string, error)
+
+// RunBatch runs a query for each target, several at a time, and merges the results in
+// the order of the targets under a header naming each one. A target that fails is
+// reported in its section without failing the others.
+func RunBatch(ctx context.Context, client *lsp.Client, targets []PositionTarget, query PositionQuery) (string, error) {
+	if len(targets) == 0 {
+		return "", fmt.Errorf("no targets given")
+	}
+	if len(targets) > MaxBatchTargets {
+		return "", fmt.Errorf("too many targets: %d, the maximum is %d", len(targets), MaxBatchTargets)
+	}
+
+	results := make([]string, len(targets))
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			text, err := runTarget(ctx, client, target, query)
+			if err != nil {
+				text = "Error: " + err.Error()
+			}
+			results[i] = fmt.Sprintf("=== Target %d: %s ===\n\n%s\n", i+1, target, text)
+		}()
+	}
+	wg.Wait()
+
+	// A cancelled call fails as a whole, while on timeout each target reports what it
+	// found in time
+	if err := ctx.Err(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return "", err
+	}
+
+	return strings.Join(results, "\n"), nil
+}
+
+func runTarget(ctx context.Context, client *lsp.Client, target PositionTarget, query PositionQuery) (string, error) {
+	filePath, line, column := target.FilePath, target.Line, target.Column
+	if target.SymbolName != "" {
+		var err error
+		filePath, line, column, err = ResolveSymbolPosition(ctx, client, target.FilePath, target.SymbolName)
+		if err != nil {
+			return "", err
+		}
+	} else if filePath == "" {
+		return "", fmt.Errorf("filePath is required unless symbolName is given")
+	} else if line <= 0 || column <= 0 {
+		return "", fmt.Errorf("line and column are required unless symbolName is given")
+	}
+
+	column, note := SnapToIdentifier(ctx, filePath, line, column)
+	text, err := query(ctx, filePath, line, column)
+	if err == nil && note != "" {
+		text = note + "\n\n" + text
+	}
+	return text, err
+}
+
+// String describes the target, e.g. "main.go:L12:C5" or "Server.Start"
+func (t PositionTarget) String() string {
+	if t.SymbolName != "" {
+		if t.FilePath != "" {
+			return fmt.Sprintf("%s in %s", t.SymbolName, t.FilePath)
+		}
+		return t.SymbolName
+	}
+	return fmt.Sprintf("%s:L%d:C%d", t.FilePath, t.Line, t.Column)
+}
@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBatch(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	query := func(ctx context.Context, filePath string, line, column int) (string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if line == 3 {
+			return "", fmt.Errorf("no symbol at position")
+		}
+		return fmt.Sprintf("result for %s line %d", filePath, line), nil
+	}
+
+	var targets []PositionTarget
+	for i := 1; i <= 8; i++ {
+		targets = append(targets, PositionTarget{FilePath: "main.go", Line: i, Column: 1})
+	}
+	targets = append(targets, PositionTarget{FilePath: "main.go"})
+
+	result, err := RunBatch(context.Background(), nil, targets, query)
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "=== Target 1: main.go:L1:C1 ===\n\nresult for main.go line 1\n")
+	assert.Contains(t, result, "=== Target 3: main.go:L3:C1 ===\n\nError: no symbol at position\n")
+	assert.Contains(t, result, "=== Target 9: main.go:L0:C0 ===\n\nError: line and column are required unless symbolName is given\n")
+	assert.Less(t, strings.Index(result, "Target 2:"), strings.Index(result, "Target 8:"), "Expected results in target order")
+	assert.LessOrEqual(t, maxRunning.Load(), int32(batchConcurrency))
+
+	_, err = RunBatch(context.Background(), nil, nil, query)
+	assert.Error(t, err)
+	_, err = RunBatch(context.Background(), nil, make([]PositionTarget, MaxBatchTargets+1), query)
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The commit git blame reports for lines that are not committed yet
+const uncommittedCommit = "0000000000000000000000000000000000000000"
+
+// blameLine is the last commit that changed a line
+type blameLine struct {
+	commit string
+	author string
+	time   time.Time
+}
+
+func (b blameLine) String() string {
+	if b.commit == uncommittedCommit {
+		return "not committed yet"
+	}
+	return fmt.Sprintf("%s %s %s", b.commit[:min(8, len(b.commit))], b.author, b.time.UTC().Format("2006-01-02"))
+}
+
+// blameLines runs git blame on the given 1-indexed lines of a file and returns the last
+// commit that changed each of them
+func blameLines(ctx context.Context, path string, lines []int) (map[int]blameLine, error) {
+	args := []string{"blame", "--line-porcelain"}
+	seen := make(map[int]bool)
+	for _, line := range lines {
+		if !seen[line] {
+			seen[line] = true
+			args = append(args, "-L", fmt.Sprintf("%d,%d", line, line))
+		}
+	}
+	args = append(args, "--", filepath.Base(path))
+
+	output, err := runGit(ctx, filepath.Dir(path), args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlame(output), nil
+}
+
+// parseBlame parses the lines of git blame --line-porcelain output, in which each line of
+// the file is described by a header "<commit> <original line> <final line> [<count>]",
+// fields such as "author <name>", and the line itself prefixed with a tab
+func parseBlame(output []string) map[int]blameLine {
+	result := make(map[int]blameLine)
+	var current blameLine
+	line := 0
+	header := true
+	for _, text := range output {
+		if header {
+			fields := strings.Fields(text)
+			if len(fields) < 3 {
+				continue
+			}
+			current = blameLine{commit: fields[0]}
+			line, _ = strconv.Atoi(fields[2])
+			header = false
+			continue
+		}
+
+		key, value, _ := strings.Cut(text, " ")
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			result[line] = current
+			header = true
+		case key == "author":
+			current.author = value
+		case key == "author-time":
+			if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				current.time = time.Unix(seconds, 0)
+			}
+		}
+	}
+	return result
+}
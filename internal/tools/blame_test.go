@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBlame(t *testing.T) {
+	output := []string{
+		"1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d 3 3 1",
+		"author Ada Lovelace",
+		"author-mail <ada@example.com>",
+		"author-time 1700000000",
+		"author-tz +0000",
+		"summary Add the engine",
+		"filename engine.go",
+		"\tengine.Run()",
+		uncommittedCommit + " 10 12 1",
+		"author Not Committed Yet",
+		"author-time 1800000000",
+		"filename engine.go",
+		"\t",
+	}
+
+	blamed := parseBlame(output)
+	require.Len(t, blamed, 2)
+	assert.Equal(t, "1a2b3c4d Ada Lovelace 2023-11-14", blamed[3].String())
+	assert.Equal(t, "not committed yet", blamed[12].String())
+}
+
+func TestBlameLines(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Ada", "GIT_AUTHOR_EMAIL=ada@example.com", "GIT_AUTHOR_DATE=2024-03-01T12:00:00Z",
+			"GIT_COMMITTER_NAME=Ada", "GIT_COMMITTER_EMAIL=ada@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	path := filepath.Join(root, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0644))
+	git("init", "-q")
+	git("add", "main.go")
+	git("commit", "-q", "-m", "initial")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {}\n\nfunc helper() {}\n"), 0644))
+
+	blamed, err := blameLines(context.Background(), path, []int{3, 5, 3})
+	require.NoError(t, err)
+	require.Len(t, blamed, 2)
+	assert.Equal(t, "Ada", blamed[3].author)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), blamed[3].time.UTC())
+	assert.Equal(t, "not committed yet", blamed[5].String())
+
+	_, err = blameLines(context.Background(), filepath.Join(t.TempDir(), "other.go"), []int{1})
+	assert.Error(t, err)
+}
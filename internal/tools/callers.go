@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// caller is a function that references the symbol, with its call sites
+type caller struct {
+	name      string
+	signature string
+	uri       protocol.DocumentUri
+	start     protocol.Position
+	sites     []protocol.Position
+}
+
+// FindCallers finds the references to the symbol at a position and summarizes the
+// functions they are in, with the signature of each, in a table. References outside of
+// any function are listed as top level.
+func FindCallers(ctx context.Context, client *lsp.Client, filePath string, line, column int, page Pagination, filter PathFilter) (string, error) {
+	err := client.OpenFileForQuery(ctx, filePath)
+	if err != nil {
+		return "", fmt.Errorf("could not open file: %v", err)
+	}
+
+	// Convert the 1-indexed line and character column to an LSP position
+	position := lspPosition(ctx, client, filePath, line, column)
+
+	refs, err := client.References(ctx, protocol.ReferenceParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{
+				URI: protocol.URIFromPath(filePath),
+			},
+			Position: position,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get references: %v", err)
+	}
+
+	refs = filter.filterLocations(refs)
+	if len(refs) == 0 {
+		return "No callers found", nil
+	}
+
+	refs, footer := paginateLocations(refs, page, "call sites")
+	if len(refs) == 0 {
+		return footer, nil
+	}
+
+	var callers []*caller
+	byRange := make(map[protocol.Location]*caller)
+	symbols := make(map[protocol.DocumentUri][]protocol.DocumentSymbolResult)
+	var note string
+	for i, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			// Like the tools formatting results file by file, return what was found if
+			// the call timed out rather than was cancelled
+			if _, err := partialResults(err, i, len(refs)); err != nil {
+				return "", err
+			}
+			note = fmt.Sprintf("Timed out: showing the callers of %d of %d references", i, len(refs))
+			break
+		}
+
+		fileSymbols, ok := symbols[ref.URI]
+		if !ok {
+			fileSymbols, _ = documentSymbols(ctx, client, ref.URI)
+			symbols[ref.URI] = fileSymbols
+		}
+
+		fn := enclosingFunction(fileSymbols, ref.Range.Start)
+		key := protocol.Location{URI: ref.URI}
+		if fn != nil {
+			key.Range = fn.GetRange()
+		}
+		c, ok := byRange[key]
+		if !ok {
+			c = &caller{name: "(top level)", uri: ref.URI}
+			if fn != nil {
+				c.name = fn.GetName()
+				c.start, c.signature = functionSignature(ctx, client, ref.URI, fn)
+			}
+			byRange[key] = c
+			callers = append(callers, c)
+		}
+		c.sites = append(c.sites, ref.Range.Start)
+	}
+
+	sort.SliceStable(callers, func(i, j int) bool {
+		if callers[i].uri != callers[j].uri {
+			return callers[i].uri < callers[j].uri
+		}
+		return positionBefore(callers[i].start, callers[j].start)
+	})
+
+	columns := newFileLines(ctx, client)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Callers: %d functions, %d call sites\n\n", len(callers), len(refs))
+	b.WriteString("| Caller | Signature | File | Call sites |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, c := range callers {
+		var sites []string
+		for _, site := range c.sites {
+			sites = append(sites, fmt.Sprintf("L%d:C%d", site.Line+1, columns.column(c.uri, site)))
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+			escapeTableCell(c.name),
+			escapeTableCell(c.signature),
+			escapeTableCell(c.uri.Path()),
+			strings.Join(sites, ", "),
+		)
+	}
+
+	if note != "" {
+		b.WriteString("\n---\n\n" + note + "\n")
+	}
+	if footer != "" {
+		b.WriteString("\n---\n\n" + footer)
+	}
+	return b.String(), nil
+}
+
+// documentSymbols returns the symbols of a file, or none if neither the server nor the
+// fallback could list them, along with the name of the fallback they come from, if any
+func documentSymbols(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri) ([]protocol.DocumentSymbolResult, string) {
+	if err := client.OpenFileForQuery(ctx, uri.Path()); err != nil {
+		// The fallback may still read the file
+		toolsLogger.Debug("Failed to open file to list its symbols: %v", err)
+	}
+	result, source, err := fetchDocumentSymbols(ctx, client, uri.Path())
+	if err != nil {
+		toolsLogger.Debug("Failed to get document symbols: %v", err)
+		return nil, ""
+	}
+	symbols, err := result.Results()
+	if err != nil {
+		toolsLogger.Debug("Failed to process document symbols: %v", err)
+		return nil, ""
+	}
+	return symbols, source
+}
+
+// isFunctionKind reports whether a symbol kind is a function, method or constructor
+func isFunctionKind(kind protocol.SymbolKind) bool {
+	return kind == protocol.Function || kind == protocol.Method || kind == protocol.Constructor
+}
+
+// enclosingFunction returns the innermost function, method or constructor symbol
+// containing pos, or nil if pos is not in one
+func enclosingFunction(symbols []protocol.DocumentSymbolResult, pos protocol.Position) protocol.DocumentSymbolResult {
+	var found protocol.DocumentSymbolResult
+	for _, sym := range symbols {
+		if !containsPosition(sym.GetRange(), pos) {
+			continue
+		}
+		switch s := sym.(type) {
+		case *protocol.DocumentSymbol:
+			if isFunctionKind(s.Kind) {
+				found = s
+			}
+			children := make([]protocol.DocumentSymbolResult, len(s.Children))
+			for i := range s.Children {
+				children[i] = &s.Children[i]
+			}
+			if inner := enclosingFunction(children, pos); inner != nil {
+				found = inner
+			}
+		case *protocol.SymbolInformation:
+			// Symbol information is flat, so keep the smallest function containing pos
+			if isFunctionKind(s.Kind) && (found == nil || containsPosition(found.GetRange(), s.Location.Range.Start)) {
+				found = s
+			}
+		}
+	}
+	return found
+}
+
+// functionSignature returns where a function's name is and its signature, taken from
+// hover information, the symbol's detail or the first line of its source, in that order
+func functionSignature(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, fn protocol.DocumentSymbolResult) (protocol.Position, string) {
+	start := fn.GetRange().Start
+	var detail string
+	if ds, ok := fn.(*protocol.DocumentSymbol); ok {
+		start = ds.SelectionRange.Start
+		detail = ds.Detail
+	}
+
+	hover, err := client.Hover(ctx, protocol.HoverParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     start,
+		},
+	})
+	if err != nil {
+		toolsLogger.Debug("Failed to get hover for the signature of %s: %v", fn.GetName(), err)
+	} else if signature := hoverSignature(hover.Contents.Value); signature != "" {
+		return start, signature
+	}
+
+	if detail != "" {
+		return start, detail
+	}
+	text, _ := newFileLines(ctx, client).line(uri.Path(), fn.GetRange().Start.Line)
+	return start, strings.TrimSpace(text)
+}
+
+// hoverSignature returns the first line of code in hover contents, which is the signature
+// for most servers, or the first line of text if there is no code block
+func hoverSignature(contents string) string {
+	inCode := false
+	var firstText string
+	for _, line := range strings.Split(contents, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			continue
+		}
+		if trimmed == "" {
+			continue
+		}
+		if inCode {
+			return trimmed
+		}
+		if firstText == "" {
+			firstText = trimmed
+		}
+	}
+	return firstText
+}
+
+// escapeTableCell makes text safe to put in a markdown table cell
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
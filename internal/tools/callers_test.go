@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnclosingFunction(t *testing.T) {
+	symbols := []protocol.DocumentSymbolResult{
+		&protocol.DocumentSymbol{Name: "Config", Kind: protocol.Struct, Range: lineRange(0, 5)},
+		&protocol.DocumentSymbol{
+			Name:  "Server",
+			Kind:  protocol.Class,
+			Range: lineRange(10, 40),
+			Children: []protocol.DocumentSymbol{
+				{Name: "limit", Kind: protocol.Field, Range: lineRange(11, 11)},
+				{
+					Name:  "start",
+					Kind:  protocol.Method,
+					Range: lineRange(12, 30),
+					Children: []protocol.DocumentSymbol{
+						{Name: "retry", Kind: protocol.Function, Range: lineRange(14, 18)},
+						{Name: "count", Kind: protocol.Variable, Range: lineRange(20, 20)},
+					},
+				},
+			},
+		},
+	}
+
+	fn := enclosingFunction(symbols, protocol.Position{Line: 15})
+	require.NotNil(t, fn)
+	assert.Equal(t, "retry", fn.GetName(), "Expected the innermost function")
+
+	fn = enclosingFunction(symbols, protocol.Position{Line: 20})
+	require.NotNil(t, fn)
+	assert.Equal(t, "start", fn.GetName(), "Expected variables to be skipped")
+
+	assert.Nil(t, enclosingFunction(symbols, protocol.Position{Line: 11}))
+	assert.Nil(t, enclosingFunction(symbols, protocol.Position{Line: 3}))
+
+	flat := []protocol.DocumentSymbolResult{
+		&protocol.SymbolInformation{Name: "outer", Kind: protocol.Function, Location: protocol.Location{Range: lineRange(0, 20)}},
+		&protocol.SymbolInformation{Name: "inner", Kind: protocol.Function, Location: protocol.Location{Range: lineRange(5, 10)}},
+	}
+	fn = enclosingFunction(flat, protocol.Position{Line: 7})
+	require.NotNil(t, fn)
+	assert.Equal(t, "inner", fn.GetName())
+}
+
+func TestHoverSignature(t *testing.T) {
+	assert.Equal(t, "func (s *Server) Start(ctx context.Context) error",
+		hoverSignature("```go\nfunc (s *Server) Start(ctx context.Context) error\n```\n\nStart starts the server"))
+	assert.Equal(t, "def start(self) -> None", hoverSignature("\ndef start(self) -> None\n"))
+	assert.Equal(t, "", hoverSignature(""))
+}
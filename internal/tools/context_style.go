@@ -0,0 +1,62 @@
+package tools
+
+import "fmt"
+
+// ContextStyle decides how the lines shown around results are chosen and how the ranges
+// of lines are rendered
+type ContextStyle struct {
+	// Mode chooses the lines: "symbol" (the default) shows the lines around each result
+	// that are within the symbol enclosing it, and the symbol's first line, "function"
+	// shows the whole enclosing symbol and "lines" the lines around each result whatever
+	// symbols they are in
+	Mode string
+	// Before and After, when either is positive, are the numbers of lines shown before
+	// and after each result instead of the contextLines of the tool call
+	Before int
+	After  int
+	// Separator is what is shown between ranges of lines that aren't adjacent: "ellipsis"
+	// ("...", the default), "count" (how many lines were left out) or "none"
+	Separator string
+}
+
+// ValidateContextStyle checks the mode and separator of a style
+func ValidateContextStyle(style ContextStyle) error {
+	switch style.Mode {
+	case "", "symbol", "function", "lines":
+	default:
+		return fmt.Errorf("invalid context mode %q, expected symbol, function or lines", style.Mode)
+	}
+	switch style.Separator {
+	case "", "ellipsis", "count", "none":
+	default:
+		return fmt.Errorf("invalid context separator %q, expected ellipsis, count or none", style.Separator)
+	}
+	if style.Before < 0 || style.After < 0 {
+		return fmt.Errorf("context lines before and after results must not be negative")
+	}
+	return nil
+}
+
+// window returns the numbers of lines shown before and after a result for the
+// contextLines of a tool call
+func (s ContextStyle) window(contextLines int) (before, after int) {
+	if s.Before > 0 || s.After > 0 {
+		return s.Before, s.After
+	}
+	return contextLines, contextLines
+}
+
+// separator returns the line shown in place of skipped lines, or "" for none
+func (s ContextStyle) separator(skipped int) string {
+	switch s.Separator {
+	case "none":
+		return ""
+	case "count":
+		if skipped == 1 {
+			return "... 1 line\n"
+		}
+		return fmt.Sprintf("... %d lines\n", skipped)
+	default:
+		return "...\n"
+	}
+}
@@ -9,11 +9,20 @@ import (
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 )
 
-func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string) (string, error) {
-	symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{
-		Query: symbolName,
-	})
+func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string, filter PathFilter) (string, error) {
+	// The precomputed index answers first when preferred, and otherwise when the
+	// language server fails or finds nothing
+	if options(ctx).Precomputed.first() {
+		if found := options(ctx).Precomputed.definitions(ctx, client, symbolName, filter); found != "" {
+			return found, nil
+		}
+	}
+
+	symbolResult, source, err := fetchWorkspaceSymbols(ctx, client, symbolName)
 	if err != nil {
+		if found := options(ctx).Precomputed.definitions(ctx, client, symbolName, filter); found != "" {
+			return found, nil
+		}
 		return "", fmt.Errorf("failed to fetch symbol: %v", err)
 	}
 
@@ -23,6 +32,7 @@ func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string)
 	}
 
 	var definitions []string
+	columns := newFileLines(ctx, client)
 	for _, symbol := range results {
 		kind := ""
 		container := ""
@@ -64,14 +74,25 @@ func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string)
 		toolsLogger.Debug("Found symbol: %s", symbol.GetName())
 		loc := symbol.GetLocation()
 
-		err := client.OpenFile(ctx, loc.URI.Path())
-		if err != nil {
-			toolsLogger.Error("Error opening file: %v", err)
+		if !filter.Allows(loc.URI.Path()) {
 			continue
 		}
 
+		var definition string
+		var err error
+		if source != "" {
+			// The fallback locates symbols at their whole declarations already
+			loc.Range.Start.Character = 0
+			definition, err = ExtractTextFromLocation(ctx, loc, client.PositionEncoding())
+		} else {
+			if err := client.OpenFileForQuery(ctx, loc.URI.Path()); err != nil {
+				toolsLogger.Error("Error opening file: %v", err)
+				continue
+			}
+			definition, loc, err = GetFullDefinition(ctx, client, loc)
+		}
+
 		banner := "---\n\n"
-		definition, loc, err := GetFullDefinition(ctx, client, loc)
 		locationInfo := fmt.Sprintf(
 			"Symbol: %s\n"+
 				"File: %s\n"+
@@ -79,11 +100,11 @@ func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string)
 				container+
 				"Range: L%d:C%d - L%d:C%d\n\n",
 			symbol.GetName(),
-			strings.TrimPrefix(string(loc.URI), "file://"),
+			loc.URI.Path(),
 			loc.Range.Start.Line+1,
-			loc.Range.Start.Character+1,
+			columns.column(loc.URI, loc.Range.Start),
 			loc.Range.End.Line+1,
-			loc.Range.End.Character+1,
+			columns.column(loc.URI, loc.Range.End),
 		)
 
 		if err != nil {
@@ -97,8 +118,11 @@ func ReadDefinition(ctx context.Context, client *lsp.Client, symbolName string)
 	}
 
 	if len(definitions) == 0 {
+		if found := options(ctx).Precomputed.definitions(ctx, client, symbolName, filter); found != "" {
+			return found, nil
+		}
 		return fmt.Sprintf("%s not found", symbolName), nil
 	}
 
-	return strings.Join(definitions, ""), nil
+	return syntacticNote(source) + strings.Join(definitions, ""), nil
 }
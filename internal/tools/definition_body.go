@@ -0,0 +1,206 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// GetDefinitionBody finds the definition of the symbol at a position and returns its full
+// source, such as a whole function or type, instead of a few lines of context. The range of
+// the definition is that of the innermost document symbol containing it or, if the server
+// has no document symbols, of the smallest folding range starting on its line.
+func GetDefinitionBody(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	err := client.OpenFileForQuery(ctx, filePath)
+	if err != nil {
+		return "", fmt.Errorf("could not open file: %v", err)
+	}
+
+	// Convert the 1-indexed line and character column to an LSP position
+	position := lspPosition(ctx, client, filePath, line, column)
+
+	params := protocol.DefinitionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{
+				URI: protocol.URIFromPath(filePath),
+			},
+			Position: position,
+		},
+	}
+
+	result, err := client.Definition(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to get definition: %v", err)
+	}
+
+	locations, err := ExtractLocationsFromDefinitionResult(result.Value)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse definition locations: %v", err)
+	}
+
+	if len(locations) == 0 {
+		return "No definition found", nil
+	}
+
+	var definitions []string
+	columns := newFileLines(ctx, client)
+	for i, loc := range locations {
+		if err := ctx.Err(); err != nil {
+			note, err := partialResults(err, i, len(locations))
+			if err != nil {
+				return "", err
+			}
+			definitions = append(definitions, note)
+			break
+		}
+
+		definitions = append(definitions, formatDefinitionBody(ctx, client, loc, columns, ""))
+	}
+
+	return strings.Join(definitions, "\n"), nil
+}
+
+// formatDefinitionBody returns the full source of the definition at a location with a
+// header naming its symbol, file and range, preceded by extraHeader if it is set
+func formatDefinitionBody(ctx context.Context, client *lsp.Client, loc protocol.Location, columns fileLines, extraHeader string) string {
+	path := loc.URI.Path()
+	content, err := readFile(ctx, path)
+	var tooLarge *fileTooLargeError
+	if errors.As(err, &tooLarge) {
+		// Only the line of the definition is shown, without reading the file for its body
+		return fmt.Sprintf("---\n\n%sFile: %s\n", extraHeader, path) + formatLargeFile(ctx, path, []protocol.Location{loc}, 0, tooLarge)
+	}
+	if err != nil {
+		return fmt.Sprintf("---\n\n%sFile: %s\nError reading file: %v\n", extraHeader, path, err)
+	}
+	lines := utilities.SplitLines(string(content)).Text()
+
+	if err := client.OpenFileForQuery(ctx, path); err != nil {
+		toolsLogger.Error("Error opening file: %v", err)
+	}
+	name, bodyRange := definitionRange(ctx, client, loc, lines)
+
+	startLine := min(int(bodyRange.Start.Line), len(lines)-1)
+	endLine := min(int(bodyRange.End.Line), len(lines)-1)
+	truncated := false
+	if endLine-startLine+1 > maxReadSourceLines {
+		endLine = startLine + maxReadSourceLines - 1
+		truncated = true
+	}
+
+	header := "---\n\n" + extraHeader
+	if name != "" {
+		header += fmt.Sprintf("Symbol: %s\n", name)
+	}
+	header += fmt.Sprintf("File: %s\nRange: L%d:C%d - L%d:C%d\n",
+		path,
+		bodyRange.Start.Line+1,
+		columns.column(loc.URI, bodyRange.Start),
+		bodyRange.End.Line+1,
+		columns.column(loc.URI, bodyRange.End),
+	)
+	if truncated {
+		header += fmt.Sprintf("Output truncated to %d lines, use read_source to read the rest\n", maxReadSourceLines)
+	}
+
+	body := strings.Join(lines[startLine:endLine+1], "\n")
+	return header + "\n" + addLineNumbers(body, startLine+1)
+}
+
+// definitionRange expands a definition location to the full range of the symbol it
+// defines, and returns the symbol's name if the server reported one. It falls back to the
+// line of the definition if neither document symbols nor folding ranges cover it.
+func definitionRange(ctx context.Context, client *lsp.Client, loc protocol.Location, lines []string) (string, protocol.Range) {
+	symResult, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: loc.URI},
+	})
+	if err == nil {
+		symbols, err := symResult.Results()
+		if err == nil {
+			if sym := innermostSymbol(symbols, loc.Range.Start); sym != nil {
+				r := sym.GetRange()
+				r.Start.Character = 0
+				return sym.GetName(), r
+			}
+		}
+	} else {
+		toolsLogger.Debug("Failed to get document symbols, trying folding ranges: %v", err)
+	}
+
+	folds, err := client.FoldingRange(ctx, protocol.FoldingRangeParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: loc.URI},
+	})
+	if err != nil {
+		toolsLogger.Debug("Failed to get folding ranges: %v", err)
+	} else if r, ok := foldingRangeAt(folds, loc.Range.Start.Line, lines, client.PositionEncoding()); ok {
+		return "", r
+	}
+
+	line := loc.Range.Start.Line
+	return "", protocol.Range{
+		Start: protocol.Position{Line: line},
+		End:   protocol.Position{Line: line, Character: uint32(client.PositionEncoding().CharacterLen(lineAt(lines, line)))},
+	}
+}
+
+// innermostSymbol returns the most deeply nested document symbol whose range contains pos,
+// or nil if none does
+func innermostSymbol(symbols []protocol.DocumentSymbolResult, pos protocol.Position) protocol.DocumentSymbolResult {
+	for _, sym := range symbols {
+		if !containsPosition(sym.GetRange(), pos) {
+			continue
+		}
+		if ds, ok := sym.(*protocol.DocumentSymbol); ok && len(ds.Children) > 0 {
+			children := make([]protocol.DocumentSymbolResult, len(ds.Children))
+			for i := range ds.Children {
+				children[i] = &ds.Children[i]
+			}
+			if child := innermostSymbol(children, pos); child != nil {
+				return child
+			}
+		}
+		return sym
+	}
+	return nil
+}
+
+// foldingRangeAt returns the smallest folding range starting on line. Servers usually
+// end folding ranges before the closing bracket, so a following line made of closing
+// brackets is included. The end character is counted in encoding.
+func foldingRangeAt(folds []protocol.FoldingRange, line uint32, lines []string, encoding protocol.PositionEncodingKind) (protocol.Range, bool) {
+	var best *protocol.FoldingRange
+	for i := range folds {
+		f := &folds[i]
+		if f.StartLine != line || f.Kind == "comment" || f.Kind == "imports" {
+			continue
+		}
+		if best == nil || f.EndLine < best.EndLine {
+			best = f
+		}
+	}
+	if best == nil {
+		return protocol.Range{}, false
+	}
+
+	end := best.EndLine
+	if next := strings.TrimSpace(lineAt(lines, end+1)); next != "" && strings.Trim(next, ")]}>;,") == "" {
+		end++
+	}
+	return protocol.Range{
+		Start: protocol.Position{Line: line},
+		End:   protocol.Position{Line: end, Character: uint32(encoding.CharacterLen(lineAt(lines, end)))},
+	}, true
+}
+
+// lineAt returns a line of a file, or an empty string past its end
+func lineAt(lines []string, line uint32) string {
+	if int(line) >= len(lines) {
+		return ""
+	}
+	return lines[line]
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lineRange(start, end uint32) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: start},
+		End:   protocol.Position{Line: end, Character: 1},
+	}
+}
+
+func TestInnermostSymbol(t *testing.T) {
+	symbols := []protocol.DocumentSymbolResult{
+		&protocol.DocumentSymbol{Name: "Before", Range: lineRange(0, 2)},
+		&protocol.DocumentSymbol{
+			Name:  "Server",
+			Range: lineRange(4, 20),
+			Children: []protocol.DocumentSymbol{
+				{Name: "Server.Start", Range: lineRange(6, 10)},
+				{Name: "Server.Stop", Range: lineRange(12, 18)},
+			},
+		},
+	}
+
+	sym := innermostSymbol(symbols, protocol.Position{Line: 13, Character: 5})
+	require.NotNil(t, sym)
+	assert.Equal(t, "Server.Stop", sym.GetName())
+
+	sym = innermostSymbol(symbols, protocol.Position{Line: 11})
+	require.NotNil(t, sym)
+	assert.Equal(t, "Server", sym.GetName(), "Expected the parent between its children")
+
+	assert.Nil(t, innermostSymbol(symbols, protocol.Position{Line: 3}))
+}
+
+func TestFoldingRangeAt(t *testing.T) {
+	lines := []string{
+		"// Start starts the server",
+		"func Start() {",
+		"\tif ready {",
+		"\t\trun()",
+		"\t}",
+		"}",
+	}
+	folds := []protocol.FoldingRange{
+		{StartLine: 0, EndLine: 0, Kind: "comment"},
+		{StartLine: 1, EndLine: 4},
+		{StartLine: 2, EndLine: 3},
+	}
+
+	r, ok := foldingRangeAt(folds, 1, lines, protocol.UTF16)
+	require.True(t, ok)
+	assert.Equal(t, uint32(1), r.Start.Line)
+	assert.Equal(t, uint32(5), r.End.Line, "Expected the closing bracket to be included")
+
+	r, ok = foldingRangeAt(folds, 2, lines, protocol.UTF16)
+	require.True(t, ok)
+	assert.Equal(t, uint32(4), r.End.Line)
+
+	_, ok = foldingRangeAt(folds, 0, lines, protocol.UTF16)
+	assert.False(t, ok, "Expected comment ranges to be skipped")
+}
@@ -0,0 +1,209 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// Upper bound on the uses of a name whose definition is looked up to find a dependency symbol
+const maxDependencyUsages = 20
+
+// DependencySource finds a symbol defined in a third-party dependency, such as a module
+// in the Go module cache, a package in site-packages or node_modules or a class in a jar,
+// and returns its full source. Many servers only search the project for workspace
+// symbols, so if that search finds nothing outside of the project, the symbol is found
+// by going to the definition of the places its name is used, in filePath if it is set
+// and otherwise anywhere under root, the workspace directory.
+func DependencySource(ctx context.Context, client *lsp.Client, root, filePath, symbolName string) (string, error) {
+	loc, found, err := findDependencySymbol(ctx, client, root, filePath, symbolName)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return fmt.Sprintf("No definition of %s found in a dependency", symbolName), nil
+	}
+
+	var header string
+	if dep := dependencyName(loc.URI.Path()); dep != "" {
+		header = fmt.Sprintf("Dependency: %s\n", dep)
+	}
+	return formatDefinitionBody(ctx, client, loc, newFileLines(ctx, client), header), nil
+}
+
+// findDependencySymbol returns the location of a symbol's definition in a dependency
+func findDependencySymbol(ctx context.Context, client *lsp.Client, root, filePath, symbolName string) (protocol.Location, bool, error) {
+	symbolResult, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: symbolName})
+	if err != nil {
+		toolsLogger.Debug("Failed to search workspace symbols for %s: %v", symbolName, err)
+	} else if results, err := symbolResult.Results(); err == nil {
+		for _, symbol := range results {
+			container := ""
+			if v, ok := symbol.(*protocol.SymbolInformation); ok {
+				container = v.ContainerName
+			}
+			loc := symbol.GetLocation()
+			if symbolNameMatches(symbolName, symbol.GetName(), container) && isDependency(loc.URI.Path(), root) {
+				return loc, true, nil
+			}
+		}
+	}
+
+	// Look for uses of the name as written, e.g. http.Client, and then of its last
+	// component, pointing at the last component either way
+	name := normalizeSymbolName(symbolName)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	columns := newFileLines(ctx, client)
+	literals := []string{symbolName}
+	if name != symbolName {
+		literals = append(literals, name)
+	}
+	for _, literal := range literals {
+		usages, err := findUsages(ctx, columns, root, filePath, literal)
+		if err != nil {
+			return protocol.Location{}, false, err
+		}
+		for _, usage := range usages {
+			if err := ctx.Err(); err != nil {
+				return protocol.Location{}, false, err
+			}
+			usage.Range.Start.Character += uint32(len(literal) - len(name))
+			if loc, ok := dependencyDefinition(ctx, client, columns, root, usage, name); ok {
+				return loc, true, nil
+			}
+		}
+	}
+	return protocol.Location{}, false, nil
+}
+
+// findUsages finds up to maxDependencyUsages occurrences of a literal outside of
+// dependencies, in filePath if it is set and otherwise in the files under root
+func findUsages(ctx context.Context, columns fileLines, root, filePath, literal string) ([]protocol.Location, error) {
+	if filePath == "" {
+		usages, _, err := searchWorkspaceText(ctx, root, literal, maxDependencyUsages, columns.encoding)
+		var own []protocol.Location
+		for _, usage := range usages {
+			if !isDependency(usage.URI.Path(), root) {
+				own = append(own, usage)
+			}
+		}
+		return own, err
+	}
+
+	var usages []protocol.Location
+	uri := protocol.URIFromPath(filePath)
+	for line := uint32(0); len(usages) < maxDependencyUsages; line++ {
+		text, ok := columns.line(filePath, line)
+		if !ok {
+			break
+		}
+		for offset := 0; ; {
+			idx := strings.Index(text[offset:], literal)
+			if idx < 0 {
+				break
+			}
+			start := protocol.Position{Line: line, Character: uint32(columns.encoding.CharacterLen(text[:offset+idx]))}
+			usages = append(usages, protocol.Location{URI: uri, Range: protocol.Range{Start: start, End: start}})
+			offset += idx + len(literal)
+		}
+	}
+	return usages, nil
+}
+
+// dependencyDefinition goes to the definition of the symbol used at a location and
+// returns it if it is in a dependency and defines name
+func dependencyDefinition(ctx context.Context, client *lsp.Client, columns fileLines, root string, usage protocol.Location, name string) (protocol.Location, bool) {
+	if err := client.OpenFileForQuery(ctx, usage.URI.Path()); err != nil {
+		toolsLogger.Debug("Failed to open %s to find a dependency symbol: %v", usage.URI.Path(), err)
+		return protocol.Location{}, false
+	}
+	result, err := client.Definition(ctx, protocol.DefinitionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: usage.URI},
+			Position:     usage.Range.Start,
+		},
+	})
+	if err != nil {
+		toolsLogger.Debug("Failed to get definition to find a dependency symbol: %v", err)
+		return protocol.Location{}, false
+	}
+	locations, err := ExtractLocationsFromDefinitionResult(result.Value)
+	if err != nil {
+		return protocol.Location{}, false
+	}
+	for _, loc := range locations {
+		if !isDependency(loc.URI.Path(), root) {
+			continue
+		}
+		// Servers without sources for a dependency may point at a stub or a whole file
+		if text, ok := columns.line(loc.URI.Path(), loc.Range.Start.Line); ok && !strings.Contains(text, name) {
+			continue
+		}
+		return loc, true
+	}
+	return protocol.Location{}, false
+}
+
+// isDependency reports whether a path is third-party code rather than part of the
+// project under root: a document that is not a file, a file outside of root, or a file
+// in a package directory such as node_modules
+func isDependency(path, root string) bool {
+	if protocol.IsNonFileURI(path) || dependencyName(path) != "" {
+		return true
+	}
+	_, inside := utilities.RelativePath(root, path)
+	return root != "" && !inside
+}
+
+// dependencyName names the dependency a path is in from the layout of the common package
+// caches, e.g. "golang.org/x/tools@v0.1.0" for a file in the Go module cache, or returns
+// "" if the path is not in one. The innermost dependency of nested ones is named.
+func dependencyName(p string) string {
+	if protocol.IsNonFileURI(p) {
+		// Name the archive of entries such as jar:file:///lib/guava.jar!/com/A.java
+		if archive, _, ok := strings.Cut(p, "!/"); ok {
+			return path.Base(archive)
+		}
+		return ""
+	}
+
+	var name string
+	parts := strings.Split(filepath.ToSlash(p), "/")
+	for i := 0; i < len(parts)-1; i++ {
+		next := parts[i+1]
+		switch parts[i] {
+		case "node_modules":
+			name = next
+			if strings.HasPrefix(next, "@") && i+2 < len(parts) {
+				name = next + "/" + parts[i+2]
+			}
+		case "site-packages", "dist-packages":
+			name = strings.TrimSuffix(next, path.Ext(next))
+		case "mod":
+			// The Go module cache, e.g. pkg/mod/golang.org/x/tools@v0.1.0/go/ast
+			if i == 0 || parts[i-1] != "pkg" {
+				continue
+			}
+			for j := i + 1; j < len(parts)-1; j++ {
+				if strings.Contains(parts[j], "@") {
+					name = strings.Join(parts[i+1:j+1], "/")
+					break
+				}
+			}
+		case "registry":
+			// The Cargo registry, e.g. .cargo/registry/src/<index>/serde-1.0.0/src
+			if next == "src" && i+3 < len(parts) {
+				name = parts[i+3]
+			}
+		}
+	}
+	return name
+}
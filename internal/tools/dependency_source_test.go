@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencyName(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/home/me/go/pkg/mod/golang.org/x/tools@v0.1.0/go/ast/ast.go", "golang.org/x/tools@v0.1.0"},
+		{"/project/.venv/lib/python3.12/site-packages/requests/api.py", "requests"},
+		{"/usr/lib/python3/dist-packages/six.py", "six"},
+		{"/project/node_modules/lodash/debounce.js", "lodash"},
+		{"/project/node_modules/@types/node/fs.d.ts", "@types/node"},
+		{"/project/node_modules/a/node_modules/b/index.js", "b"},
+		{"/home/me/.cargo/registry/src/index.crates.io-6f17d22bba15001f/serde-1.0.0/src/lib.rs", "serde-1.0.0"},
+		{"jar:file:///home/me/.m2/guava-sources.jar!/com/google/A.java", "guava-sources.jar"},
+		{"/project/internal/mod/mod.go", ""},
+		{"/project/main.go", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, dependencyName(tt.path))
+		})
+	}
+}
+
+func TestIsDependency(t *testing.T) {
+	assert.False(t, isDependency("/project/main.go", "/project"))
+	assert.True(t, isDependency("/project/node_modules/lodash/debounce.js", "/project"))
+	assert.True(t, isDependency("/usr/local/go/src/net/http/client.go", "/project"))
+	assert.True(t, isDependency("jdt://contents/rt.jar/java.lang/String.class", "/project"))
+}
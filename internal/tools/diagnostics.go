@@ -2,49 +2,24 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
 )
 
 // GetDiagnosticsForFile retrieves diagnostics for a specific file from the language server
 func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath string, contextLines int, showLineNumbers bool) (string, error) {
-	// Override with environment variable if specified
-	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
-		if val, err := strconv.Atoi(envLines); err == nil && val >= 0 {
-			contextLines = val
-		}
-	}
-
-	err := client.OpenFile(ctx, filePath)
-	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
-	}
-
-	// Wait for diagnostics
-	// TODO: wait for notification
-	time.Sleep(time.Second * 3)
-
-	// Convert the file path to URI format
-	uri := protocol.DocumentUri("file://" + filePath)
-
-	// Request fresh diagnostics
-	diagParams := protocol.DocumentDiagnosticParams{
-		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
-	}
-	_, err = client.Diagnostic(ctx, diagParams)
+	diagnostics, uri, err := fetchDiagnostics(ctx, client, filePath)
 	if err != nil {
-		toolsLogger.Error("Failed to get diagnostics: %v", err)
+		return "", err
 	}
 
-	// Get diagnostics from the cache
-	diagnostics := client.GetFileDiagnostics(uri)
-
 	if len(diagnostics) == 0 {
 		return "No diagnostics found for " + filePath, nil
 	}
@@ -60,28 +35,7 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	var diagLocations []protocol.Location
 
 	for _, diag := range diagnostics {
-		severity := getSeverityString(diag.Severity)
-		location := fmt.Sprintf("L%d:C%d",
-			diag.Range.Start.Line+1,
-			diag.Range.Start.Character+1)
-
-		summary := fmt.Sprintf("%s at %s: %s",
-			severity,
-			location,
-			diag.Message)
-
-		// Add source and code if available
-		if diag.Source != "" {
-			summary += fmt.Sprintf(" (Source: %s", diag.Source)
-			if diag.Code != nil {
-				summary += fmt.Sprintf(", Code: %v", diag.Code)
-			}
-			summary += ")"
-		} else if diag.Code != nil {
-			summary += fmt.Sprintf(" (Code: %v)", diag.Code)
-		}
-
-		diagSummaries = append(diagSummaries, summary)
+		diagSummaries = append(diagSummaries, formatDiagnosticSummary(diag))
 
 		// Create a location for this diagnostic to use with line ranges
 		diagLocations = append(diagLocations, protocol.Location{
@@ -91,12 +45,20 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 	}
 
 	// Format content with context
-	fileContent, err := os.ReadFile(filePath)
+	fileContent, err := readFile(ctx, filePath)
+	var tooLarge *fileTooLargeError
+	if errors.As(err, &tooLarge) {
+		result := fileInfo + strings.Join(diagSummaries, "\n") + "\n"
+		if showLineNumbers {
+			result += "\n" + formatLargeFile(ctx, filePath, diagLocations, contextLines, tooLarge)
+		}
+		return result, nil
+	}
 	if err != nil {
 		return fileInfo + "\nError reading file: " + err.Error(), nil
 	}
 
-	lines := strings.Split(string(fileContent), "\n")
+	lines := utilities.SplitLines(string(fileContent)).Text()
 
 	// Collect lines to display
 	var linesToShow map[int]bool
@@ -129,12 +91,132 @@ func GetDiagnosticsForFile(ctx context.Context, client *lsp.Client, filePath str
 
 	// Format the content with ranges
 	if showLineNumbers {
-		result += "\n" + FormatLinesWithRanges(lines, lineRanges)
+		result += "\n" + FormatLinesWithRanges(ctx, filePath, lines, lineRanges)
 	}
 
 	return result, nil
 }
 
+// GetWorkspaceDiagnostics lists the diagnostics the language server has published for
+// every file in the workspace, without requesting fresh ones
+func GetWorkspaceDiagnostics(client *lsp.Client) string {
+	byFile := client.GetAllDiagnostics()
+
+	uris := make([]string, 0, len(byFile))
+	for uri, diags := range byFile {
+		if len(diags) > 0 {
+			uris = append(uris, string(uri))
+		}
+	}
+	if len(uris) == 0 {
+		return "No diagnostics found"
+	}
+	sort.Strings(uris)
+
+	var files []string
+	for _, uriStr := range uris {
+		diags := byFile[protocol.DocumentUri(uriStr)]
+		summaries := make([]string, 0, len(diags))
+		for _, diag := range diags {
+			summaries = append(summaries, formatDiagnosticSummary(diag))
+		}
+		files = append(files, fmt.Sprintf("%s\nDiagnostics in File: %d\n%s\n",
+			protocol.DocumentUri(uriStr).Path(),
+			len(diags),
+			strings.Join(summaries, "\n"),
+		))
+	}
+	return strings.Join(files, "\n")
+}
+
+// fetchDiagnostics opens the file, gives the server time to analyze it and returns
+// the diagnostics it reported
+func fetchDiagnostics(ctx context.Context, client *lsp.Client, filePath string) ([]protocol.Diagnostic, protocol.DocumentUri, error) {
+	err := client.OpenFile(ctx, filePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not open file: %v", err)
+	}
+
+	// Wait for diagnostics
+	// TODO: wait for notification
+	time.Sleep(time.Second * 3)
+
+	// Convert the file path to URI format
+	uri := protocol.URIFromPath(filePath)
+
+	// Request fresh diagnostics
+	diagParams := protocol.DocumentDiagnosticParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	}
+	_, err = client.Diagnostic(ctx, diagParams)
+	if err != nil {
+		toolsLogger.Error("Failed to get diagnostics: %v", err)
+	}
+
+	// Get diagnostics from the cache
+	return client.GetFileDiagnostics(uri), uri, nil
+}
+
+// How long waitForDiagnostics waits for the rest of the files once reports stop arriving,
+// and how often it checks for them
+const (
+	diagnosticsQuietPeriod = time.Second
+	diagnosticsPollPeriod  = 100 * time.Millisecond
+)
+
+// waitForDiagnostics waits until the server reported diagnostics for every file since it
+// was at the given generation, stopped reporting them for a while, or took longer than
+// wait. It returns the files the server did not report diagnostics for.
+func waitForDiagnostics(ctx context.Context, client *lsp.Client, since map[protocol.DocumentUri]uint64, wait time.Duration) []protocol.DocumentUri {
+	deadline := time.Now().Add(wait)
+	lastReported, lastChange := 0, time.Now()
+	for {
+		var pending []protocol.DocumentUri
+		for uri, generation := range since {
+			if client.DiagnosticsGeneration(uri) == generation {
+				pending = append(pending, uri)
+			}
+		}
+		reported := len(since) - len(pending)
+		if len(pending) == 0 || time.Now().After(deadline) {
+			return pending
+		}
+		if reported != lastReported {
+			lastReported, lastChange = reported, time.Now()
+		} else if reported > 0 && time.Since(lastChange) >= diagnosticsQuietPeriod {
+			return pending
+		}
+
+		select {
+		case <-ctx.Done():
+			return pending
+		case <-time.After(diagnosticsPollPeriod):
+		}
+	}
+}
+
+// formatDiagnosticSummary renders a one line summary of a diagnostic
+func formatDiagnosticSummary(diag protocol.Diagnostic) string {
+	summary := fmt.Sprintf("%s at L%d:C%d: %s",
+		getSeverityString(diag.Severity),
+		diag.Range.Start.Line+1,
+		diag.Range.Start.Character+1,
+		diag.Message)
+
+	// Add source and code if available
+	if diag.Source != "" {
+		summary += fmt.Sprintf(" (Source: %s", diag.Source)
+		if diag.Code != nil {
+			summary += fmt.Sprintf(", Code: %v", diag.Code)
+		}
+		summary += ")"
+	} else if diag.Code != nil {
+		summary += fmt.Sprintf(" (Code: %v)", diag.Code)
+	}
+
+	return summary
+}
+
 func getSeverityString(severity protocol.DiagnosticSeverity) string {
 	switch severity {
 	case protocol.SeverityError:
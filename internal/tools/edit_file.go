@@ -1,12 +1,11 @@
 package tools
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"os"
-	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
@@ -14,31 +13,34 @@ import (
 )
 
 type TextEdit struct {
-	StartLine int    `json:"startLine" jsonschema:"required,description=Start line to replace, inclusive"`
-	EndLine   int    `json:"endLine" jsonschema:"required,description=End line to replace, inclusive"`
-	NewText   string `json:"newText" jsonschema:"description=Replacement text. Replace with the new text. Leave blank to remove lines."`
+	StartLine   int    `json:"startLine" jsonschema:"required,description=Start line to replace, inclusive"`
+	EndLine     int    `json:"endLine" jsonschema:"required,description=End line to replace, inclusive"`
+	StartColumn int    `json:"startColumn,omitempty" jsonschema:"description=Start column to replace, inclusive. Requires endColumn."`
+	EndColumn   int    `json:"endColumn,omitempty" jsonschema:"description=End column to replace, exclusive. Requires startColumn."`
+	NewText     string `json:"newText" jsonschema:"description=Replacement text. Replace with the new text. Leave blank to remove lines."`
 }
 
-func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, edits []TextEdit) (string, error) {
+// ApplyTextEdits applies line based edits to a file. With dryRun set the file is left
+// untouched and the unified diff of the edits is returned instead.
+func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, edits []TextEdit, dryRun bool) (string, error) {
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
 		return "", fmt.Errorf("could not open file: %v", err)
 	}
 
-	// Create a sorted copy of edits for reporting
-	sortedEdits := make([]TextEdit, len(edits))
-	copy(sortedEdits, edits)
-	sort.Slice(sortedEdits, func(i, j int) bool {
-		return sortedEdits[i].StartLine < sortedEdits[j].StartLine
-	})
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	lines := splitLines(content)
 
-	// Track lines added and removed for sorted edits
-	linesRemovedSorted := 0
-	linesAddedSorted := 0
-	for _, edit := range sortedEdits {
+	// Track lines added and removed
+	linesRemoved := 0
+	linesAdded := 0
+	for _, edit := range edits {
 		// Calculate lines removed: end - start + 1
 		removedLineCount := edit.EndLine - edit.StartLine + 1
-		linesRemovedSorted += removedLineCount
+		linesRemoved += removedLineCount
 
 		// Calculate lines added: count newlines in the replacement text + 1
 		addedLineCount := 1
@@ -47,22 +49,16 @@ func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, ed
 		} else if edit.NewText == "" {
 			addedLineCount = 0
 		}
-		linesAddedSorted += addedLineCount
+		linesAdded += addedLineCount
 	}
 
-	// Sort edits by line number in descending order to process from bottom to top
-	// This way line numbers don't shift under us as we make edits
-	sort.Slice(edits, func(i, j int) bool {
-		return edits[i].StartLine > edits[j].StartLine
-	})
-
-	// Convert from input format to protocol.TextEdit
+	// Convert from input format to protocol.TextEdit, validating each edit against
+	// the current content of the file
 	var textEdits []protocol.TextEdit
-	for _, edit := range edits {
-		// Get the range covering the requested lines
-		rng, err := getRange(edit.StartLine, edit.EndLine, filePath)
+	for i, edit := range edits {
+		rng, err := getRange(edit, lines, client.PositionEncoding())
 		if err != nil {
-			return "", fmt.Errorf("invalid position: %v", err)
+			return "", fmt.Errorf("invalid edit %d: %v", i+1, err)
 		}
 
 		// Always do a replacement
@@ -72,41 +68,67 @@ func ApplyTextEdits(ctx context.Context, client *lsp.Client, filePath string, ed
 		})
 	}
 
+	for i := range textEdits {
+		for j := i + 1; j < len(textEdits); j++ {
+			if utilities.RangesOverlap(textEdits[i].Range, textEdits[j].Range) {
+				return "", fmt.Errorf("edits %d and %d overlap", i+1, j+1)
+			}
+		}
+	}
+
 	edit := protocol.WorkspaceEdit{
 		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
-			protocol.DocumentUri(filePath): textEdits,
+			protocol.URIFromPath(filePath): textEdits,
 		},
 	}
 
-	if err := utilities.ApplyWorkspaceEdit(edit); err != nil {
-		return "", fmt.Errorf("failed to apply text edits: %v", err)
+	if dryRun {
+		diff, err := utilities.PreviewWorkspaceEdit(edit, client.PositionEncoding())
+		if err != nil {
+			return "", fmt.Errorf("failed to preview text edits: %v", err)
+		}
+		return fmt.Sprintf("Dry run, no files were changed. %d lines would be removed, %d lines added.\n\n%s", linesRemoved, linesAdded, diff), nil
 	}
 
-	return fmt.Sprintf("Successfully applied text edits. %d lines removed, %d lines added.", linesRemovedSorted, linesAddedSorted), nil
-}
-
-// getRange creates a protocol.Range that covers the specified start and end lines
-func getRange(startLine, endLine int, filePath string) (protocol.Range, error) {
-	content, err := os.ReadFile(filePath)
+	if err := prepareWrite(ctx, filePath); err != nil {
+		return "", err
+	}
+	err = utilities.ApplyWorkspaceEdit(edit, client.PositionEncoding())
+	fileWritten(ctx, client, filePath)
 	if err != nil {
-		return protocol.Range{}, fmt.Errorf("failed to read file: %w", err)
+		return "", fmt.Errorf("failed to apply text edits: %v", err)
 	}
 
-	// Detect line ending style
-	var lineEnding string
-	if bytes.Contains(content, []byte("\r\n")) {
-		lineEnding = "\r\n"
-	} else {
-		lineEnding = "\n"
+	// Let the language server see the new content before the next query
+	if err := client.NotifyChange(ctx, filePath); err != nil {
+		toolsLogger.Error("Failed to notify language server of change to %s: %v", filePath, err)
 	}
 
-	// Split lines without the line endings
-	lines := strings.Split(string(content), lineEnding)
+	return fmt.Sprintf("Successfully applied text edits. %d lines removed, %d lines added.", linesRemoved, linesAdded), nil
+}
+
+// splitLines splits file content into lines without their line endings, as LSP
+// positions address them
+func splitLines(content []byte) []string {
+	return utilities.SplitLines(string(content)).All()
+}
+
+// getRange creates a protocol.Range for an edit, checking that it lies within the file.
+// Without columns the range covers the full lines from start to end. Characters are
+// counted in encoding.
+func getRange(edit TextEdit, lines []string, encoding protocol.PositionEncodingKind) (protocol.Range, error) {
+	startLine, endLine := edit.StartLine, edit.EndLine
 
 	// Handle start line positioning
 	if startLine < 1 {
 		return protocol.Range{}, fmt.Errorf("start line must be >= 1, got %d", startLine)
 	}
+	if endLine < startLine {
+		return protocol.Range{}, fmt.Errorf("end line %d is before start line %d", endLine, startLine)
+	}
+	if (edit.StartColumn > 0) != (edit.EndColumn > 0) {
+		return protocol.Range{}, fmt.Errorf("startColumn and endColumn must be given together")
+	}
 
 	// Convert to 0-based line numbers
 	startIdx := startLine - 1
@@ -114,6 +136,10 @@ func getRange(startLine, endLine int, filePath string) (protocol.Range, error) {
 
 	// Handle EOF positioning
 	if startIdx >= len(lines) {
+		if startIdx > len(lines) || edit.StartColumn > 0 {
+			return protocol.Range{}, fmt.Errorf("start line %d is past the end of the file (%d lines)", startLine, len(lines))
+		}
+
 		// For EOF, we want to point to the end of the last content-bearing line
 		lastContentLineIdx := len(lines) - 1
 		if lastContentLineIdx >= 0 && lines[lastContentLineIdx] == "" {
@@ -126,7 +152,7 @@ func getRange(startLine, endLine int, filePath string) (protocol.Range, error) {
 
 		pos := protocol.Position{
 			Line:      uint32(lastContentLineIdx),
-			Character: uint32(len(lines[lastContentLineIdx])),
+			Character: uint32(encoding.CharacterLen(lines[lastContentLineIdx])),
 		}
 
 		return protocol.Range{
@@ -135,12 +161,31 @@ func getRange(startLine, endLine int, filePath string) (protocol.Range, error) {
 		}, nil
 	}
 
-	// Normal range handling
 	if endIdx >= len(lines) {
-		endIdx = len(lines) - 1
+		return protocol.Range{}, fmt.Errorf("end line %d is past the end of the file (%d lines)", endLine, len(lines))
+	}
+
+	// Range within lines
+	if edit.StartColumn > 0 {
+		startLen := utf8.RuneCountInString(lines[startIdx])
+		endLen := utf8.RuneCountInString(lines[endIdx])
+		if edit.StartColumn > startLen+1 {
+			return protocol.Range{}, fmt.Errorf("start column %d is past the end of line %d (%d characters)", edit.StartColumn, startLine, startLen)
+		}
+		if edit.EndColumn > endLen+1 {
+			return protocol.Range{}, fmt.Errorf("end column %d is past the end of line %d (%d characters)", edit.EndColumn, endLine, endLen)
+		}
+		if startLine == endLine && edit.EndColumn < edit.StartColumn {
+			return protocol.Range{}, fmt.Errorf("end column %d is before start column %d", edit.EndColumn, edit.StartColumn)
+		}
+
+		return protocol.Range{
+			Start: protocol.Position{Line: uint32(startIdx), Character: encoding.RuneToCharacter(lines[startIdx], edit.StartColumn-1)},
+			End:   protocol.Position{Line: uint32(endIdx), Character: encoding.RuneToCharacter(lines[endIdx], edit.EndColumn-1)},
+		}, nil
 	}
 
-	// Always use the full line range for consistency
+	// Otherwise use the full line range
 	return protocol.Range{
 		Start: protocol.Position{
 			Line:      uint32(startIdx),
@@ -148,7 +193,7 @@ func getRange(startLine, endLine int, filePath string) (protocol.Range, error) {
 		},
 		End: protocol.Position{
 			Line:      uint32(endIdx),
-			Character: uint32(len(lines[endIdx])), // Go to end of last line
+			Character: uint32(encoding.CharacterLen(lines[endIdx])), // Go to end of last line
 		},
 	}, nil
 }
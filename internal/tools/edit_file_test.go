@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRange(t *testing.T) {
+	lines := splitLines([]byte("package main\n\nfunc main() {}\n"))
+
+	tests := []struct {
+		name     string
+		edit     TextEdit
+		expected protocol.Range
+		wantErr  string
+	}{
+		{
+			name: "full lines",
+			edit: TextEdit{StartLine: 1, EndLine: 3},
+			expected: protocol.Range{
+				Start: protocol.Position{Line: 0, Character: 0},
+				End:   protocol.Position{Line: 2, Character: 14},
+			},
+		},
+		{
+			name: "column range",
+			edit: TextEdit{StartLine: 3, EndLine: 3, StartColumn: 6, EndColumn: 10},
+			expected: protocol.Range{
+				Start: protocol.Position{Line: 2, Character: 5},
+				End:   protocol.Position{Line: 2, Character: 9},
+			},
+		},
+		{
+			name: "append past last line",
+			edit: TextEdit{StartLine: 5, EndLine: 5},
+			expected: protocol.Range{
+				Start: protocol.Position{Line: 2, Character: 14},
+				End:   protocol.Position{Line: 2, Character: 14},
+			},
+		},
+		{name: "zero start line", edit: TextEdit{StartLine: 0, EndLine: 1}, wantErr: "start line must be >= 1"},
+		{name: "end before start", edit: TextEdit{StartLine: 3, EndLine: 2}, wantErr: "is before start line"},
+		{name: "end past file", edit: TextEdit{StartLine: 1, EndLine: 9}, wantErr: "past the end of the file"},
+		{name: "start past file", edit: TextEdit{StartLine: 9, EndLine: 9}, wantErr: "past the end of the file"},
+		{name: "one column", edit: TextEdit{StartLine: 1, EndLine: 1, StartColumn: 2}, wantErr: "must be given together"},
+		{name: "column past line", edit: TextEdit{StartLine: 1, EndLine: 1, StartColumn: 1, EndColumn: 20}, wantErr: "past the end of line 1"},
+		{name: "columns reversed", edit: TextEdit{StartLine: 1, EndLine: 1, StartColumn: 5, EndColumn: 2}, wantErr: "is before start column"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng, err := getRange(tt.edit, lines, protocol.UTF16)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, rng)
+		})
+	}
+}
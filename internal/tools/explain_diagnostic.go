@@ -0,0 +1,299 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// Maximum number of distinct symbols in a diagnostic range whose definitions are included
+const maxExplainedSymbols = 5
+
+var identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// DiagnosticQuery identifies the diagnostics to explain. Lines and columns are 1-indexed.
+// A zero Column matches anywhere on Line, a zero EndLine defaults to Line, and an empty
+// Code matches any diagnostic code.
+type DiagnosticQuery struct {
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+	Code      string
+}
+
+// ExplainDiagnostic builds a consolidated report for the diagnostics matching the query:
+// the surrounding code, related information, the definitions of the symbols involved
+// and the code actions the server offers to fix them
+func ExplainDiagnostic(ctx context.Context, client *lsp.Client, filePath string, query DiagnosticQuery, contextLines int) (string, error) {
+	diagnostics, uri, err := fetchDiagnostics(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	matched := query.match(diagnostics)
+	if len(matched) == 0 {
+		result := fmt.Sprintf("No matching diagnostic found at L%d in %s", query.Line, filePath)
+		if len(diagnostics) > 0 {
+			var summaries []string
+			for _, diag := range diagnostics {
+				summaries = append(summaries, formatDiagnosticSummary(diag))
+			}
+			result += "\n\nDiagnostics in File:\n" + strings.Join(summaries, "\n")
+		}
+		return result, nil
+	}
+
+	fileContent, err := readFile(ctx, filePath)
+	if err != nil {
+		return "", fmt.Errorf("could not read file: %v", err)
+	}
+	lines := utilities.SplitLines(string(fileContent)).Text()
+
+	var reports []string
+	for _, diag := range matched {
+		reports = append(reports, explainOne(ctx, client, uri, lines, diag, contextLines))
+	}
+
+	return strings.Join(reports, "\n"), nil
+}
+
+func explainOne(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, lines []string, diag protocol.Diagnostic, contextLines int) string {
+	var result strings.Builder
+
+	result.WriteString("---\n\n")
+	result.WriteString("Diagnostic: " + formatDiagnosticSummary(diag) + "\n")
+	if diag.CodeDescription != nil && diag.CodeDescription.Href != "" {
+		result.WriteString("Documentation: " + string(diag.CodeDescription.Href) + "\n")
+	}
+
+	// Code around the diagnostic
+	loc := protocol.Location{URI: uri, Range: diag.Range}
+	linesToShow, err := GetLineRangesToDisplay(ctx, client, []protocol.Location{loc}, len(lines), contextLines)
+	if err == nil {
+		for i := int(diag.Range.Start.Line); i <= int(diag.Range.End.Line) && i < len(lines); i++ {
+			linesToShow[i] = true
+		}
+		result.WriteString("\nCode:\n")
+		result.WriteString(FormatLinesWithRanges(ctx, uri.Path(), lines, ConvertLinesToRanges(linesToShow, len(lines))))
+	}
+
+	if len(diag.RelatedInformation) > 0 {
+		result.WriteString("\nRelated Information:\n")
+		result.WriteString(formatRelatedInformation(diag.RelatedInformation))
+	}
+
+	if definitions := definitionsInRange(ctx, client, uri, lines, diag.Range); len(definitions) > 0 {
+		result.WriteString("\nDefinitions:\n")
+		result.WriteString(strings.Join(definitions, "\n"))
+	}
+
+	result.WriteString("\nCode Actions:\n")
+	actions, err := codeActionTitles(ctx, client, uri, diag)
+	if err != nil {
+		toolsLogger.Error("Failed to get code actions: %v", err)
+		result.WriteString("Error getting code actions: " + err.Error() + "\n")
+	} else if len(actions) == 0 {
+		result.WriteString("No code actions available\n")
+	} else {
+		result.WriteString(strings.Join(actions, "\n") + "\n")
+	}
+
+	return result.String()
+}
+
+// definitionsInRange looks up the definitions of the identifiers within a range and
+// returns them formatted with line numbers
+func definitionsInRange(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, lines []string, rng protocol.Range) []string {
+	var definitions []string
+	seenNames := make(map[string]bool)
+	seenLocations := make(map[string]bool)
+
+	encoding := client.PositionEncoding()
+	for lineNum := int(rng.Start.Line); lineNum <= int(rng.End.Line) && lineNum < len(lines); lineNum++ {
+		line := lines[lineNum]
+		start, end := 0, len(line)
+		if lineNum == int(rng.Start.Line) {
+			start = encoding.CharacterToByte(line, rng.Start.Character)
+		}
+		if lineNum == int(rng.End.Line) {
+			end = encoding.CharacterToByte(line, rng.End.Character)
+		}
+		// Zero width diagnostics still refer to the identifier they are attached to
+		if start >= end {
+			end = len(line)
+		}
+
+		for _, match := range identifierPattern.FindAllStringIndex(line[start:end], -1) {
+			if len(seenNames) >= maxExplainedSymbols {
+				return definitions
+			}
+
+			name := line[start+match[0] : start+match[1]]
+			if seenNames[name] {
+				continue
+			}
+
+			result, err := client.Definition(ctx, protocol.DefinitionParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+					Position: protocol.Position{
+						Line:      uint32(lineNum),
+						Character: encoding.ByteToCharacter(line, start+match[0]),
+					},
+				},
+			})
+			if err != nil {
+				continue
+			}
+
+			locations, err := ExtractLocationsFromDefinitionResult(result.Value)
+			if err != nil || len(locations) == 0 {
+				continue
+			}
+			seenNames[name] = true
+
+			for _, loc := range locations {
+				key := fmt.Sprintf("%s:%d:%d", loc.URI, loc.Range.Start.Line, loc.Range.Start.Character)
+				if seenLocations[key] {
+					continue
+				}
+				seenLocations[key] = true
+
+				if err := client.OpenFileForQuery(ctx, loc.URI.Path()); err != nil {
+					toolsLogger.Error("Error opening file: %v", err)
+					continue
+				}
+
+				definition, defLoc, err := GetFullDefinition(ctx, client, loc)
+				if err != nil {
+					toolsLogger.Error("Error getting definition: %v", err)
+					continue
+				}
+
+				definitions = append(definitions, fmt.Sprintf("Symbol: %s\nFile: %s\nRange: L%d:C%d - L%d:C%d\n\n%s",
+					name,
+					defLoc.URI.Path(),
+					defLoc.Range.Start.Line+1,
+					defLoc.Range.Start.Character+1,
+					defLoc.Range.End.Line+1,
+					defLoc.Range.End.Character+1,
+					addLineNumbers(definition, int(defLoc.Range.Start.Line)+1)))
+			}
+		}
+	}
+
+	return definitions
+}
+
+// codeActionTitles lists the code actions the server offers for a diagnostic
+func codeActionTitles(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, diag protocol.Diagnostic) ([]string, error) {
+	actions, err := client.CodeAction(ctx, protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        diag.Range,
+		Context: protocol.CodeActionContext{
+			Diagnostics: []protocol.Diagnostic{diag},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return formatCodeActionTitles(actions), nil
+}
+
+// formatRelatedInformation lists the related information of a diagnostic, one location
+// and message per line
+func formatRelatedInformation(infos []protocol.DiagnosticRelatedInformation) string {
+	var result strings.Builder
+	for _, info := range infos {
+		result.WriteString(fmt.Sprintf("%s:L%d:C%d: %s\n",
+			info.Location.URI.Path(),
+			info.Location.Range.Start.Line+1,
+			info.Location.Range.Start.Character+1,
+			info.Message))
+	}
+	return result.String()
+}
+
+// formatCodeActionTitles numbers the code actions and commands the server offers, with
+// the kind of the actions and whether they are preferred or disabled
+func formatCodeActionTitles(actions []protocol.Or_Result_textDocument_codeAction_Item0_Elem) []string {
+	var titles []string
+	for i, action := range actions {
+		switch v := action.Value.(type) {
+		case protocol.CodeAction:
+			title := fmt.Sprintf("[%d] %s", i+1, v.Title)
+			var details []string
+			if v.Kind != "" {
+				details = append(details, "Kind: "+string(v.Kind))
+			}
+			if v.IsPreferred {
+				details = append(details, "preferred")
+			}
+			if v.Disabled != nil {
+				details = append(details, "disabled: "+v.Disabled.Reason)
+			}
+			if len(details) > 0 {
+				title += " (" + strings.Join(details, ", ") + ")"
+			}
+			titles = append(titles, title)
+		case protocol.Command:
+			titles = append(titles, fmt.Sprintf("[%d] %s (Command: %s)", i+1, v.Title, v.Command))
+		}
+	}
+	return titles
+}
+
+// match returns the diagnostics overlapping the query with its code, if any
+func (q DiagnosticQuery) match(diagnostics []protocol.Diagnostic) []protocol.Diagnostic {
+	queryRange := q.toRange()
+	var matched []protocol.Diagnostic
+	for _, diag := range diagnostics {
+		if !rangesOverlap(diag.Range, queryRange) {
+			continue
+		}
+		if q.Code != "" && (diag.Code == nil || fmt.Sprintf("%v", diag.Code) != q.Code) {
+			continue
+		}
+		matched = append(matched, diag)
+	}
+	return matched
+}
+
+// toRange converts the 1-indexed query into a protocol range
+func (q DiagnosticQuery) toRange() protocol.Range {
+	endLine := q.EndLine
+	if endLine == 0 {
+		endLine = q.Line
+	}
+
+	rng := protocol.Range{
+		Start: protocol.Position{Line: uint32(q.Line - 1)},
+		End:   protocol.Position{Line: uint32(endLine - 1), Character: ^uint32(0)},
+	}
+	if q.Column > 0 {
+		rng.Start.Character = uint32(q.Column - 1)
+		if q.EndLine == 0 && q.EndColumn == 0 {
+			rng.End.Character = rng.Start.Character
+		}
+	}
+	if q.EndColumn > 0 {
+		rng.End.Character = uint32(q.EndColumn - 1)
+	}
+	return rng
+}
+
+// rangesOverlap reports whether two ranges share at least one position. Ranges are
+// treated as inclusive so that a position at the start or end of a diagnostic matches it.
+func rangesOverlap(a, b protocol.Range) bool {
+	return !positionBefore(a.End, b.Start) && !positionBefore(b.End, a.Start)
+}
+
+func positionBefore(a, b protocol.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnosticQueryMatch(t *testing.T) {
+	at := func(line, start, end uint32) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Position{Line: line, Character: start},
+			End:   protocol.Position{Line: line, Character: end},
+		}
+	}
+	unused := protocol.Diagnostic{Range: at(4, 1, 4), Message: "x declared and not used", Code: "UnusedVar"}
+	undefined := protocol.Diagnostic{Range: at(4, 8, 11), Message: "undefined: foo", Code: "UndeclaredName"}
+	other := protocol.Diagnostic{Range: at(9, 0, 3), Message: "missing return"}
+	diagnostics := []protocol.Diagnostic{unused, undefined, other}
+
+	tests := []struct {
+		name     string
+		query    DiagnosticQuery
+		expected []protocol.Diagnostic
+	}{
+		{name: "line", query: DiagnosticQuery{Line: 5}, expected: []protocol.Diagnostic{unused, undefined}},
+		{name: "column", query: DiagnosticQuery{Line: 5, Column: 10}, expected: []protocol.Diagnostic{undefined}},
+		{name: "column at the end", query: DiagnosticQuery{Line: 5, Column: 5}, expected: []protocol.Diagnostic{unused}},
+		{name: "code", query: DiagnosticQuery{Line: 5, Code: "UnusedVar"}, expected: []protocol.Diagnostic{unused}},
+		{name: "range of lines", query: DiagnosticQuery{Line: 5, EndLine: 10}, expected: diagnostics},
+		{name: "no match", query: DiagnosticQuery{Line: 7}},
+		{name: "code without match", query: DiagnosticQuery{Line: 10, Code: "UnusedVar"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.query.match(diagnostics))
+		})
+	}
+}
+
+func TestFormatRelatedInformation(t *testing.T) {
+	infos := []protocol.DiagnosticRelatedInformation{
+		{
+			Location: protocol.Location{URI: "file:///src/a.go", Range: protocol.Range{Start: protocol.Position{Line: 2, Character: 4}}},
+			Message:  "other declaration of x",
+		},
+		{
+			Location: protocol.Location{URI: "file:///src/b.go", Range: protocol.Range{Start: protocol.Position{Line: 0, Character: 0}}},
+			Message:  "imported here",
+		},
+	}
+	assert.Equal(t, "/src/a.go:L3:C5: other declaration of x\n/src/b.go:L1:C1: imported here\n", formatRelatedInformation(infos))
+}
+
+func TestFormatCodeActionTitles(t *testing.T) {
+	actions := []protocol.Or_Result_textDocument_codeAction_Item0_Elem{
+		{Value: protocol.CodeAction{Title: "Remove variable x", Kind: protocol.QuickFix, IsPreferred: true}},
+		{Value: protocol.CodeAction{Title: "Extract function", Kind: "refactor.extract", Disabled: &protocol.CodeActionDisabled{Reason: "no selection"}}},
+		{Value: protocol.CodeAction{Title: "Organize imports"}},
+		{Value: protocol.Command{Title: "Run go mod tidy", Command: "gopls.tidy"}},
+	}
+	assert.Equal(t, []string{
+		"[1] Remove variable x (Kind: quickfix, preferred)",
+		"[2] Extract function (Kind: refactor.extract, disabled: no selection)",
+		"[3] Organize imports",
+		"[4] Run go mod tidy (Command: gopls.tidy)",
+	}, formatCodeActionTitles(actions))
+}
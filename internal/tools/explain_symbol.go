@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Most files listed in the reference summary of explain_symbol
+const maxExplainedReferenceFiles = 10
+
+// ExplainSymbol gathers what agents usually look up one request at a time about the
+// symbol at a position: its hover information, the source of its definition, the source
+// of its type's definition and how many references it has. A part the server can't
+// provide is reported in its section without failing the others.
+func ExplainSymbol(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	if err := client.OpenFileForQuery(ctx, filePath); err != nil {
+		return "", fmt.Errorf("could not open file: %v", err)
+	}
+	position := lspPosition(ctx, client, filePath, line, column)
+	uri := protocol.URIFromPath(filePath)
+
+	var result strings.Builder
+
+	result.WriteString("Hover:\n")
+	hover, err := GetHoverInfo(ctx, client, filePath, line, column)
+	if err != nil {
+		hover = "Error getting hover information: " + err.Error()
+	}
+	result.WriteString(strings.TrimSpace(hover) + "\n")
+
+	result.WriteString("\nDefinition:\n")
+	definition, err := GetDefinitionBody(ctx, client, filePath, line, column)
+	if err != nil {
+		definition = "Error getting definition: " + err.Error()
+	}
+	result.WriteString(strings.TrimSpace(definition) + "\n")
+
+	result.WriteString("\nType Definition:\n")
+	result.WriteString(strings.TrimSpace(explainTypeDefinition(ctx, client, uri, position)) + "\n")
+
+	result.WriteString("\nReferences:\n")
+	refs, err := serverReferences(ctx, client, uri, position, false)
+	if err != nil || len(refs) == 0 {
+		if indexed := options(ctx).Precomputed.references(filePath, position, false, client.PositionEncoding()); len(indexed) > 0 {
+			refs, err = indexed, nil
+		}
+	}
+	if err != nil {
+		result.WriteString("Error getting references: " + err.Error() + "\n")
+	} else {
+		result.WriteString(summarizeReferences(refs) + "\n")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// explainTypeDefinition returns the source of the definition of the type of the symbol at
+// a position, unless the symbol is a type itself
+func explainTypeDefinition(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, position protocol.Position) string {
+	if !client.SupportsMethod("textDocument/typeDefinition") {
+		return "The language server doesn't support type definitions"
+	}
+	params := protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position:     position,
+	}
+	result, err := client.TypeDefinition(ctx, protocol.TypeDefinitionParams{TextDocumentPositionParams: params})
+	if err != nil {
+		return "Error getting type definition: " + err.Error()
+	}
+	locations, err := ExtractLocationsFromDefinitionResult(result.Value)
+	if err != nil {
+		return "Error getting type definition: " + err.Error()
+	}
+	if len(locations) == 0 {
+		return "No type definition found"
+	}
+
+	// A type's type definition is the type itself, which the definition already shows
+	if definition, err := client.Definition(ctx, protocol.DefinitionParams{TextDocumentPositionParams: params}); err == nil {
+		if definitions, err := ExtractLocationsFromDefinitionResult(definition.Value); err == nil && sameLocations(locations, definitions) {
+			return "Same as the definition"
+		}
+	}
+
+	var bodies []string
+	columns := newFileLines(ctx, client)
+	for _, loc := range locations {
+		bodies = append(bodies, formatDefinitionBody(ctx, client, loc, columns, ""))
+	}
+	return strings.Join(bodies, "\n")
+}
+
+// sameLocations reports whether two lists hold the same locations, in any order
+func sameLocations(a, b []protocol.Location) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[protocol.Location]int)
+	for _, loc := range a {
+		seen[loc]++
+	}
+	for _, loc := range b {
+		if seen[loc] == 0 {
+			return false
+		}
+		seen[loc]--
+	}
+	return true
+}
+
+// summarizeReferences counts references by file, the files with the most first
+func summarizeReferences(refs []protocol.Location) string {
+	if len(refs) == 0 {
+		return "No references found"
+	}
+	byFile := groupLocationsByFile(refs)
+	paths := make([]string, 0, len(byFile))
+	counts := make(map[string]int, len(byFile))
+	for uri, fileRefs := range byFile {
+		path := uri.Path()
+		paths = append(paths, path)
+		counts[path] = len(fileRefs)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if counts[paths[i]] != counts[paths[j]] {
+			return counts[paths[i]] > counts[paths[j]]
+		}
+		return paths[i] < paths[j]
+	})
+
+	var b strings.Builder
+	if len(refs) == 1 {
+		b.WriteString("1 reference")
+	} else {
+		fmt.Fprintf(&b, "%d references", len(refs))
+	}
+	fmt.Fprintf(&b, " in %s\n", pluralFiles(len(paths)))
+	for _, path := range paths[:min(len(paths), maxExplainedReferenceFiles)] {
+		fmt.Fprintf(&b, "%s: %d\n", path, counts[path])
+	}
+	if len(paths) > maxExplainedReferenceFiles {
+		fmt.Fprintf(&b, "... and %s not listed\n", pluralFiles(len(paths)-maxExplainedReferenceFiles))
+	}
+	b.WriteString("Use the references tool to see them in context.")
+	return b.String()
+}
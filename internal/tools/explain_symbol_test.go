@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeReferences(t *testing.T) {
+	at := func(file string, line uint32) protocol.Location {
+		return protocol.Location{URI: protocol.URIFromPath("/src/" + file), Range: protocol.Range{Start: protocol.Position{Line: line}}}
+	}
+	assert.Equal(t, "No references found", summarizeReferences(nil))
+	assert.Equal(t, "3 references in 2 files\n/src/b.go: 2\n/src/a.go: 1\nUse the references tool to see them in context.",
+		summarizeReferences([]protocol.Location{at("a.go", 1), at("b.go", 2), at("b.go", 5)}))
+
+	var many []protocol.Location
+	for i := range maxExplainedReferenceFiles + 2 {
+		many = append(many, at(fmt.Sprintf("f%02d.go", i), 0))
+	}
+	assert.Contains(t, summarizeReferences(many), "... and 2 files not listed")
+}
+
+func TestSameLocations(t *testing.T) {
+	a := protocol.Location{URI: "file:///a.go"}
+	b := protocol.Location{URI: "file:///b.go"}
+	assert.True(t, sameLocations([]protocol.Location{a, b}, []protocol.Location{b, a}))
+	assert.False(t, sameLocations([]protocol.Location{a, a}, []protocol.Location{a, b}))
+	assert.False(t, sameLocations([]protocol.Location{a}, nil))
+}
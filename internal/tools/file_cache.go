@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// DefaultFileCacheBytes is a limit on the total size of the files held by a FileCache
+const DefaultFileCacheBytes = 64 << 20
+
+// FileCache holds file contents keyed by path, up to a total size, evicting the least
+// recently used files first. A cached file is only returned while its modification time
+// and size are those it had when it was read. A nil cache reads files from disk every time.
+type FileCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	entries  map[string]*list.Element
+	// lru orders the entries from most to least recently used
+	lru *list.List
+	// readURI reads documents that are not files on disk, see SetURIReader
+	readURI func(uri string) ([]byte, error)
+}
+
+type fileCacheEntry struct {
+	path    string
+	modTime time.Time
+	size    int64
+	content []byte
+}
+
+// NewFileCache returns a cache holding at most maxBytes of file contents
+func NewFileCache(maxBytes int) *FileCache {
+	return &FileCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// SetURIReader sets how documents that are not files on disk are read, such as the
+// sources of dependencies in jar: URIs, which are passed to ReadFile as URIs
+func (c *FileCache) SetURIReader(read func(uri string) ([]byte, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readURI = read
+}
+
+// ReadFile returns the contents of a file, from the cache if the file did not change since
+// it was cached. The returned slice is shared and must not be modified.
+func (c *FileCache) ReadFile(path string) ([]byte, error) {
+	if c == nil {
+		if protocol.IsNonFileURI(path) {
+			return nil, fmt.Errorf("cannot read %s: not a file", path)
+		}
+		return os.ReadFile(path)
+	}
+	if protocol.IsNonFileURI(path) {
+		return c.readNonFile(path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		c.Invalidate(path)
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[path]; ok {
+		entry := elem.Value.(*fileCacheEntry)
+		if entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+			c.lru.MoveToFront(elem)
+			c.mu.Unlock()
+			return entry.content, nil
+		}
+		c.remove(elem)
+	}
+	c.mu.Unlock()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Files too large to share the cache with others are not kept
+	if len(content) > c.maxBytes/4 {
+		return content, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(&fileCacheEntry{
+		path:    path,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		content: content,
+	})
+	return content, nil
+}
+
+// readNonFile returns the contents of a document that is not a file on disk. These are
+// dependency sources that don't change, so they are cached until evicted.
+func (c *FileCache) readNonFile(uri string) ([]byte, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[uri]; ok {
+		c.lru.MoveToFront(elem)
+		c.mu.Unlock()
+		return elem.Value.(*fileCacheEntry).content, nil
+	}
+	read := c.readURI
+	c.mu.Unlock()
+
+	if read == nil {
+		return nil, fmt.Errorf("cannot read %s: not a file", uri)
+	}
+	content, err := read(uri)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > c.maxBytes/4 {
+		return content, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(&fileCacheEntry{path: uri, size: int64(len(content)), content: content})
+	return content, nil
+}
+
+// add caches an entry, replacing any entry for its path and evicting the least recently
+// used entries while the cache is too large. The caller must hold mu.
+func (c *FileCache) add(entry *fileCacheEntry) {
+	if elem, ok := c.entries[entry.path]; ok {
+		c.remove(elem)
+	}
+	c.entries[entry.path] = c.lru.PushFront(entry)
+	c.size += len(entry.content)
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Invalidate drops a file from the cache
+func (c *FileCache) Invalidate(path string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[path]; ok {
+		c.remove(elem)
+	}
+}
+
+// remove drops an entry. The caller must hold mu.
+func (c *FileCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*fileCacheEntry)
+	delete(c.entries, entry.path)
+	c.size -= len(entry.content)
+}
+
+// readFile reads a file through the cache of the context's options, unless it is over
+// their LargeFileBytes
+func readFile(ctx context.Context, path string) ([]byte, error) {
+	return options(ctx).readFile(path)
+}
+
+// readFile reads a file through Files, unless it is over LargeFileBytes
+func (o *Options) readFile(path string) ([]byte, error) {
+	if err := o.checkFileSize(path); err != nil {
+		return nil, err
+	}
+	return o.Files.ReadFile(path)
+}
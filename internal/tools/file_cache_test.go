@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCache(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	require.NoError(t, os.WriteFile(a, []byte("aaaaaaaaa"), 0644))
+	require.NoError(t, os.WriteFile(b, []byte("bbbbbbbbbb"), 0644))
+
+	cache := NewFileCache(40)
+
+	content, err := cache.ReadFile(a)
+	require.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaa", string(content))
+
+	// A change of size or modification time is noticed without invalidation
+	require.NoError(t, os.WriteFile(a, []byte("aaaaaaaaaa"), 0644))
+	content, err = cache.ReadFile(a)
+	require.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", string(content))
+
+	// A change that keeps both is only noticed once the file is invalidated
+	info, err := os.Stat(a)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(a, []byte("AAAAAAAAAA"), 0644))
+	require.NoError(t, os.Chtimes(a, time.Time{}, info.ModTime()))
+	content, err = cache.ReadFile(a)
+	require.NoError(t, err)
+	assert.Equal(t, "aaaaaaaaaa", string(content))
+	cache.Invalidate(a)
+	content, err = cache.ReadFile(a)
+	require.NoError(t, err)
+	assert.Equal(t, "AAAAAAAAAA", string(content))
+
+	// Reading past the size limit evicts the least recently used file
+	_, err = cache.ReadFile(b)
+	require.NoError(t, err)
+	for _, name := range []string{"c.txt", "d.txt", "e.txt"} {
+		_, err = cache.ReadFile(writeFile(t, dir, name, "cccccccccc"))
+		require.NoError(t, err)
+	}
+	assert.NotContains(t, cache.entries, a)
+	assert.Contains(t, cache.entries, b)
+	assert.Equal(t, 40, cache.size)
+
+	// Files too large to share the cache are read but not kept
+	large := writeFile(t, dir, "large.txt", "0123456789abcdef")
+	content, err = cache.ReadFile(large)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", string(content))
+	assert.NotContains(t, cache.entries, large)
+
+	// Deleted files are dropped
+	require.NoError(t, os.Remove(b))
+	_, err = cache.ReadFile(b)
+	assert.Error(t, err)
+	assert.NotContains(t, cache.entries, b)
+}
+
+func TestFileCacheNonFileURI(t *testing.T) {
+	cache := NewFileCache(40)
+	uri := "jar:file:///lib.jar!/A.java"
+
+	_, err := cache.ReadFile(uri)
+	assert.Error(t, err)
+
+	reads := 0
+	cache.SetURIReader(func(got string) ([]byte, error) {
+		reads++
+		assert.Equal(t, uri, got)
+		return []byte("class A"), nil
+	})
+	for range 2 {
+		content, err := cache.ReadFile(uri)
+		require.NoError(t, err)
+		assert.Equal(t, "class A", string(content))
+	}
+	assert.Equal(t, 1, reads)
+}
+
+func TestNilFileCache(t *testing.T) {
+	var cache *FileCache
+	path := writeFile(t, t.TempDir(), "a.txt", "aaa")
+
+	content, err := cache.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "aaa", string(content))
+	cache.Invalidate(path)
+
+	_, err = cache.ReadFile("jar:file:///lib.jar!/A.java")
+	assert.Error(t, err)
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
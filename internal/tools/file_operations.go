@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// CreateFile creates a file with the given content, along with its missing parent
+// directories. Servers that handle file operations are asked for edits with
+// workspace/willCreateFiles first, which are written as one transaction, and are told of
+// the new file with workspace/didCreateFiles, which some use to fill in boilerplate such
+// as a Java package declaration. With dryRun set nothing is changed and the unified diff
+// of the new file and the edits is returned.
+func CreateFile(ctx context.Context, client *lsp.Client, path, content string, dryRun bool) (string, error) {
+	if _, err := os.Lstat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("could not read %s: %v", path, err)
+	}
+
+	params := protocol.CreateFilesParams{Files: []protocol.FileCreate{{URI: string(protocol.URIFromPath(path))}}}
+	operations := client.FileOperations()
+
+	var edit protocol.WorkspaceEdit
+	if operations.WillCreate != nil {
+		var err error
+		edit, err = client.WillCreateFiles(ctx, params)
+		if err != nil {
+			return "", fmt.Errorf("failed to get the edits for the new file: %v", err)
+		}
+	}
+	tx, err := utilities.StageWorkspaceEdit(edit, client.FileVersion, client.PositionEncoding())
+	if err != nil {
+		return "", fmt.Errorf("failed to apply changes: %v", err)
+	}
+	if dryRun {
+		return fmt.Sprintf("Dry run, no files were changed. Creating %s would make these changes:\n%s%s",
+			path, utilities.UnifiedDiff(path, path, "", content), tx.Diff()), nil
+	}
+
+	if err := prepareWrite(ctx, append(tx.Paths(), path)...); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to apply changes: %v", err)
+	}
+	for _, p := range tx.Paths() {
+		fileWritten(ctx, client, p)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	fileWritten(ctx, client, path)
+
+	if operations.DidCreate != nil {
+		if err := client.DidCreateFiles(ctx, params); err != nil {
+			toolsLogger.Warn("Failed to notify the server of the creation of %s: %v", path, err)
+		}
+	}
+
+	lines := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return fmt.Sprintf("Successfully created file %s (%d lines).", path, lines) + updatedFilesNote(tx.Paths(), nil), nil
+}
+
+// DeleteFile deletes a file, or a directory with everything in it if recursive is set.
+// Servers that handle file operations are asked for edits with workspace/willDeleteFiles
+// first, which are written as one transaction, and are told of the deletion with
+// workspace/didDeleteFiles. With dryRun set nothing is changed and the unified diff of
+// the edits is returned.
+func DeleteFile(ctx context.Context, client *lsp.Client, path string, recursive, dryRun bool) (string, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %v", path, err)
+	}
+	kind := "file"
+	if info.IsDir() {
+		kind = "directory"
+		if !recursive {
+			return "", fmt.Errorf("%s is a directory, set recursive to delete it with everything in it", path)
+		}
+	}
+
+	params := protocol.DeleteFilesParams{Files: []protocol.FileDelete{{URI: string(protocol.URIFromPath(path))}}}
+	operations := client.FileOperations()
+
+	var edit protocol.WorkspaceEdit
+	if operations.WillDelete != nil {
+		edit, err = client.WillDeleteFiles(ctx, params)
+		if err != nil {
+			return "", fmt.Errorf("failed to get the edits for the deletion: %v", err)
+		}
+	}
+	tx, err := utilities.StageWorkspaceEdit(edit, client.FileVersion, client.PositionEncoding())
+	if err != nil {
+		return "", fmt.Errorf("failed to apply changes: %v", err)
+	}
+
+	deleted := func(p string) bool {
+		return p == path || strings.HasPrefix(p, path+string(filepath.Separator))
+	}
+	if dryRun {
+		if len(tx.Paths()) == 0 {
+			return fmt.Sprintf("Dry run, no files were changed. Deleting %s %s would not change any other files.", kind, path), nil
+		}
+		return fmt.Sprintf("Dry run, no files were changed. Deleting %s %s would update %d files:\n%s\n%s",
+			kind, path, len(tx.Paths()), strings.Join(tx.Paths(), "\n"), tx.Diff()), nil
+	}
+
+	if err := prepareWrite(ctx, append(tx.Paths(), path)...); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to apply changes: %v", err)
+	}
+	for _, p := range tx.Paths() {
+		if !deleted(p) {
+			fileWritten(ctx, client, p)
+		}
+	}
+
+	if !info.IsDir() {
+		if err := client.CloseFile(ctx, path); err != nil {
+			toolsLogger.Debug("Failed to close %s before deleting it: %v", path, err)
+		}
+	}
+	if err := os.RemoveAll(path); err != nil {
+		if len(tx.Paths()) > 0 {
+			return "", fmt.Errorf("failed to delete %s, after updating %d files for the deletion: %v", path, len(tx.Paths()), err)
+		}
+		return "", fmt.Errorf("failed to delete %s: %v", path, err)
+	}
+	options(ctx).Files.Invalidate(path)
+	client.ForgetDiagnostics(protocol.URIFromPath(path))
+
+	if operations.DidDelete != nil {
+		if err := client.DidDeleteFiles(ctx, params); err != nil {
+			toolsLogger.Warn("Failed to notify the server of the deletion of %s: %v", path, err)
+		}
+	}
+
+	return fmt.Sprintf("Successfully deleted %s %s.", kind, path) + updatedFilesNote(tx.Paths(), deleted), nil
+}
+
+// updatedFilesNote lists the other files a file operation updated, leaving out those
+// skip reports
+func updatedFilesNote(paths []string, skip func(string) bool) string {
+	var updated []string
+	for _, p := range paths {
+		if skip == nil || !skip(p) {
+			updated = append(updated, p)
+		}
+	}
+	if len(updated) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nUpdated %d files:\n%s\n", len(updated), strings.Join(updated, "\n"))
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFile(t *testing.T) {
+	ctx := context.Background()
+	client := &lsp.Client{}
+	path := filepath.Join(t.TempDir(), "pkg", "new.go")
+
+	result, err := CreateFile(ctx, client, path, "package pkg\n\nfunc F() {}", true)
+	require.NoError(t, err)
+	assert.Contains(t, result, "Dry run, no files were changed. Creating "+path)
+	assert.Contains(t, result, "+func F() {}")
+	assert.NoDirExists(t, filepath.Dir(path))
+
+	result, err = CreateFile(ctx, client, path, "package pkg\n\nfunc F() {}", false)
+	require.NoError(t, err)
+	assert.Equal(t, "Successfully created file "+path+" (3 lines).", result)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "package pkg\n\nfunc F() {}", string(content))
+
+	_, err = CreateFile(ctx, client, path, "", false)
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestDeleteFile(t *testing.T) {
+	ctx := context.Background()
+	client := &lsp.Client{}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pkg", "old.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("package pkg\n"), 0644))
+
+	result, err := DeleteFile(ctx, client, path, false, true)
+	require.NoError(t, err)
+	assert.Contains(t, result, "Dry run, no files were changed.")
+	assert.FileExists(t, path)
+
+	result, err = DeleteFile(ctx, client, path, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, "Successfully deleted file "+path+".", result)
+	assert.NoFileExists(t, path)
+
+	_, err = DeleteFile(ctx, client, filepath.Join(dir, "pkg"), false, false)
+	assert.ErrorContains(t, err, "set recursive")
+	_, err = DeleteFile(ctx, client, filepath.Join(dir, "pkg"), true, false)
+	require.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(dir, "pkg"))
+
+	_, err = DeleteFile(ctx, client, path, false, false)
+	assert.Error(t, err)
+}
+
+func TestUpdatedFilesNote(t *testing.T) {
+	assert.Equal(t, "", updatedFilesNote(nil, nil))
+	skip := func(p string) bool { return p == "/w/old.go" }
+	assert.Equal(t, "\nUpdated 1 files:\n/w/main.go\n", updatedFilesNote([]string{"/w/main.go", "/w/old.go"}, skip))
+}
@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// Maximum number of constant declarations holding the key whose references are looked up
+const maxKeyDeclarations = 10
+
+// FindKeyUsages finds where a string key such as a config key or string constant is used.
+// Symbol references alone miss stringly-typed usage in YAML, JSON, templates and so on, so
+// the literal is searched for as text across the workspace, and when the literal is the value
+// of a declaration (e.g. const KeyName = "key") the references to that declaration are merged in.
+func FindKeyUsages(ctx context.Context, client *lsp.Client, workspaceDir, key string, contextLines int) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("key must not be empty")
+	}
+
+	textMatches, truncated, err := searchWorkspaceText(ctx, workspaceDir, key, maxTextMatches, client.PositionEncoding())
+	if err != nil {
+		return "", fmt.Errorf("failed to search workspace: %v", err)
+	}
+
+	sources := make(map[string][]string)
+	var locations []protocol.Location
+	addLocation := func(loc protocol.Location, source string) {
+		id := fmt.Sprintf("%s:%d:%d", loc.URI, loc.Range.Start.Line, loc.Range.Start.Character)
+		if _, ok := sources[id]; !ok {
+			locations = append(locations, loc)
+		}
+		for _, s := range sources[id] {
+			if s == source {
+				return
+			}
+		}
+		sources[id] = append(sources[id], source)
+	}
+
+	for _, loc := range textMatches {
+		addLocation(loc, "text")
+	}
+
+	declarations := findKeyDeclarations(textMatches, key, client.PositionEncoding())
+	for _, decl := range declarations {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		filePath := decl.URI.Path()
+		if err := client.OpenFileForQuery(ctx, filePath); err != nil {
+			toolsLogger.Debug("Could not open %s to look up references: %v", filePath, err)
+			continue
+		}
+
+		refs, err := client.References(ctx, protocol.ReferenceParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: decl.URI},
+				Position:     decl.Range.Start,
+			},
+			Context: protocol.ReferenceContext{
+				IncludeDeclaration: true,
+			},
+		})
+		if err != nil {
+			// Expected for file types the language server does not handle
+			toolsLogger.Debug("Failed to get references for %s:%d: %v", filePath, decl.Range.Start.Line+1, err)
+			continue
+		}
+
+		for _, ref := range refs {
+			addLocation(ref, "reference")
+		}
+	}
+
+	if len(locations) == 0 {
+		return fmt.Sprintf("No usages found for key: %s", key), nil
+	}
+
+	// Group usages by file
+	locsByFile := groupLocationsByFile(locations)
+
+	uris := make([]string, 0, len(locsByFile))
+	for uri := range locsByFile {
+		uris = append(uris, string(uri))
+	}
+	sort.Strings(uris)
+
+	header := fmt.Sprintf("Key: %s\nUsages: %d in %d files\n", key, len(locations), len(uris))
+	if len(declarations) > 0 {
+		var declStrings []string
+		for _, decl := range declarations {
+			declStrings = append(declStrings, fmt.Sprintf("%s:L%d:C%d",
+				decl.URI.Path(),
+				decl.Range.Start.Line+1,
+				decl.Range.Start.Character+1))
+		}
+		header += "Declared at: " + strings.Join(declStrings, ", ") + "\n"
+	}
+	if truncated {
+		header += fmt.Sprintf("Text search stopped after %d matches\n", maxTextMatches)
+	}
+
+	allUsages := []string{header}
+	for i, uriStr := range uris {
+		if err := ctx.Err(); err != nil {
+			note, err := partialResults(err, i, len(uris))
+			if err != nil {
+				return "", err
+			}
+			allUsages = append(allUsages, note)
+			break
+		}
+		uri := protocol.DocumentUri(uriStr)
+		fileLocs := locsByFile[uri]
+		sort.Slice(fileLocs, func(i, j int) bool {
+			return positionBefore(fileLocs[i].Range.Start, fileLocs[j].Range.Start)
+		})
+		filePath := protocol.DocumentUri(uriStr).Path()
+
+		fileInfo := fmt.Sprintf("---\n\n%s\nUsages in File: %d\n", filePath, len(fileLocs))
+
+		var locStrings []string
+		for _, loc := range fileLocs {
+			id := fmt.Sprintf("%s:%d:%d", loc.URI, loc.Range.Start.Line, loc.Range.Start.Character)
+			locStrings = append(locStrings, fmt.Sprintf("L%d:C%d (%s)",
+				loc.Range.Start.Line+1,
+				loc.Range.Start.Character+1,
+				strings.Join(sources[id], ", ")))
+		}
+		fileInfo += "At: " + strings.Join(locStrings, ", ") + "\n"
+
+		fileContent, err := os.ReadFile(filePath)
+		if err != nil {
+			allUsages = append(allUsages, fileInfo+"\nError reading file: "+err.Error())
+			continue
+		}
+		lines := utilities.SplitLines(string(fileContent)).Text()
+
+		// Files the language server does not know about have no symbols to
+		// expand the context to, so only show the surrounding lines
+		var linesToShow map[int]bool
+		if client.IsFileOpen(filePath) {
+			linesToShow, err = GetLineRangesToDisplay(ctx, client, fileLocs, len(lines), contextLines)
+			if err != nil {
+				continue
+			}
+		} else {
+			linesToShow = make(map[int]bool)
+			before, after := options(ctx).Context.window(contextLines)
+			for _, loc := range fileLocs {
+				refLine := int(loc.Range.Start.Line)
+				for i := refLine - before; i <= refLine+after; i++ {
+					if i >= 0 && i < len(lines) {
+						linesToShow[i] = true
+					}
+				}
+			}
+		}
+
+		lineRanges := ConvertLinesToRanges(linesToShow, len(lines))
+		allUsages = append(allUsages, fileInfo+"\n"+FormatLinesWithRanges(ctx, filePath, lines, lineRanges))
+	}
+
+	return strings.Join(allUsages, "\n"), nil
+}
+
+// findKeyDeclarations returns the positions of identifiers that are assigned the key as a
+// string literal, e.g. `const Name = "key"`, `Name: "key"` or `NAME = 'key'`, with their
+// characters in the given encoding
+func findKeyDeclarations(textMatches []protocol.Location, key string, encoding protocol.PositionEncodingKind) []protocol.Location {
+	pattern := regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\s*(?::=|=|:)\s*["'` + "`" + `]` + regexp.QuoteMeta(key) + `["'` + "`" + `]`)
+
+	fileLines := make(map[protocol.DocumentUri][]string)
+	seen := make(map[string]bool)
+	var declarations []protocol.Location
+
+	for _, match := range textMatches {
+		lines, ok := fileLines[match.URI]
+		if !ok {
+			content, err := os.ReadFile(match.URI.Path())
+			if err != nil {
+				continue
+			}
+			lines = utilities.SplitLines(string(content)).All()
+			fileLines[match.URI] = lines
+		}
+
+		lineNum := int(match.Range.Start.Line)
+		if lineNum >= len(lines) {
+			continue
+		}
+
+		line := lines[lineNum]
+		for _, m := range pattern.FindAllStringSubmatchIndex(line, -1) {
+			id := fmt.Sprintf("%s:%d:%d", match.URI, lineNum, m[2])
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			declarations = append(declarations, protocol.Location{
+				URI: match.URI,
+				Range: protocol.Range{
+					Start: protocol.Position{Line: uint32(lineNum), Character: encoding.ByteToCharacter(line, m[2])},
+					End:   protocol.Position{Line: uint32(lineNum), Character: encoding.ByteToCharacter(line, m[3])},
+				},
+			})
+			if len(declarations) >= maxKeyDeclarations {
+				return declarations
+			}
+		}
+	}
+
+	return declarations
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchWorkspaceTextAndDeclarations(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"config.go":                "package config\n\nconst TimeoutKey = \"server.timeout\"\n",
+		"settings.yaml":            "server.timeout: 30\nother: server.timeout\n",
+		"node_modules/dep/x.js":    "const k = 'server.timeout'\n",
+		".hidden/ignored.txt":      "server.timeout\n",
+		"unrelated/readme.md":      "nothing to see here\n",
+		"binary.dat":               "server.timeout\x00\x01",
+		"templates/page.html.tmpl": "{{ .Get \"server.timeout\" }}\n",
+		"labels.go":                "var labels = L{\"été\", Timeout: \"server.timeout\"}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	matches, truncated, err := searchWorkspaceText(context.Background(), root, "server.timeout", maxTextMatches, protocol.UTF16)
+	require.NoError(t, err)
+	assert.False(t, truncated)
+
+	found := make(map[string][]protocol.Position)
+	for _, m := range matches {
+		rel, err := filepath.Rel(root, m.URI.Path())
+		require.NoError(t, err)
+		found[rel] = append(found[rel], m.Range.Start)
+	}
+	assert.Equal(t, map[string][]protocol.Position{
+		"config.go":                {{Line: 2, Character: 20}},
+		"labels.go":                {{Line: 0, Character: 32}},
+		"settings.yaml":            {{Line: 0, Character: 0}, {Line: 1, Character: 7}},
+		"templates/page.html.tmpl": {{Line: 0, Character: 9}},
+	}, found)
+
+	// Characters count UTF-16 code units, not bytes
+	declarations := findKeyDeclarations(matches, "server.timeout", protocol.UTF16)
+	require.Len(t, declarations, 2)
+	assert.Equal(t, "config.go", filepath.Base(declarations[0].URI.Path()))
+	assert.Equal(t, protocol.Position{Line: 2, Character: 6}, declarations[0].Range.Start)
+	assert.Equal(t, "labels.go", filepath.Base(declarations[1].URI.Path()))
+	assert.Equal(t, protocol.Position{Line: 0, Character: 22}, declarations[1].Range.Start)
+
+	_, truncated, err = searchWorkspaceText(context.Background(), root, "server.timeout", 2, protocol.UTF16)
+	require.NoError(t, err)
+	assert.True(t, truncated)
+}
@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// Default and maximum number of rounds of fixes applied by FixDiagnostics
+const (
+	DefaultFixRounds = 3
+	maxFixRounds     = 10
+)
+
+// quickFix is a code action chosen to fix a diagnostic, with its edits by file
+type quickFix struct {
+	diag  protocol.Diagnostic
+	title string
+	edits map[protocol.DocumentUri][]protocol.TextEdit
+}
+
+// FixDiagnostics applies the quick fixes the language server offers for the diagnostics
+// of a file, then checks the file again and repeats for up to rounds rounds, since fixes
+// can uncover or cause other diagnostics. Fixes whose edits overlap a fix already chosen
+// in the same round are left for the next one. It returns the fixes applied, the
+// diagnostics that remain and the diff of every file changed. With dryRun set no files are
+// written and the fixes of the first round are returned with their diff, as later rounds
+// depend on the server checking the fixed file.
+func FixDiagnostics(ctx context.Context, client *lsp.Client, filePath string, rounds int, dryRun bool) (string, error) {
+	if rounds <= 0 {
+		rounds = DefaultFixRounds
+	}
+	rounds = min(rounds, maxFixRounds)
+
+	var applied []string
+	// Contents of each changed file before the first fix, in the order they changed
+	var changed []string
+	originals := make(map[string]string)
+
+	diagnostics, uri, err := fetchDiagnostics(ctx, client, filePath)
+	if err != nil {
+		return "", err
+	}
+	for round := 1; round <= rounds && len(diagnostics) > 0; round++ {
+		fixes := chooseQuickFixes(ctx, client, uri, diagnostics)
+		if len(fixes) == 0 {
+			break
+		}
+
+		merged := protocol.WorkspaceEdit{Changes: make(map[protocol.DocumentUri][]protocol.TextEdit)}
+		for _, fix := range fixes {
+			for fixURI, edits := range fix.edits {
+				merged.Changes[fixURI] = append(merged.Changes[fixURI], edits...)
+			}
+			applied = append(applied, fmt.Sprintf("Round %d: %s (fixes %s)", round, fix.title, formatDiagnosticSummary(fix.diag)))
+		}
+
+		tx, err := utilities.StageWorkspaceEdit(merged, client.FileVersion, client.PositionEncoding())
+		if err != nil {
+			return "", fmt.Errorf("failed to apply fixes: %v", err)
+		}
+		if dryRun {
+			return fmt.Sprintf("Dry run, no files were changed. Applying %d fixes would make these changes:\n%s\n\n%s", len(applied), strings.Join(applied, "\n"), tx.Diff()), nil
+		}
+		for _, path := range tx.Paths() {
+			if _, ok := originals[path]; ok {
+				continue
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read file: %w", err)
+			}
+			originals[path] = string(content)
+			changed = append(changed, path)
+		}
+		if err := prepareWrite(ctx, tx.Paths()...); err != nil {
+			return "", err
+		}
+		err = tx.Commit()
+		for _, path := range tx.Paths() {
+			fileWritten(ctx, client, path)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to apply fixes: %v", err)
+		}
+
+		// Let the language server see the new content before checking again
+		for _, path := range tx.Paths() {
+			if !client.IsFileOpen(path) {
+				continue
+			}
+			if err := client.NotifyChange(ctx, path); err != nil {
+				toolsLogger.Error("Failed to notify language server of change to %s: %v", path, err)
+			}
+		}
+
+		diagnostics, _, err = fetchDiagnostics(ctx, client, filePath)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var result strings.Builder
+	if len(applied) == 0 {
+		result.WriteString("No fixes applied\n")
+	} else {
+		fmt.Fprintf(&result, "Applied %d fixes:\n%s\n", len(applied), strings.Join(applied, "\n"))
+	}
+
+	if len(diagnostics) == 0 {
+		result.WriteString("\nNo diagnostics remaining\n")
+	} else {
+		var summaries []string
+		for _, diag := range diagnostics {
+			summaries = append(summaries, formatDiagnosticSummary(diag))
+		}
+		fmt.Fprintf(&result, "\nRemaining diagnostics (%d):\n%s\n", len(diagnostics), strings.Join(summaries, "\n"))
+	}
+
+	if len(changed) > 0 {
+		result.WriteString("\nDiff:\n")
+		for _, path := range changed {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read file: %w", err)
+			}
+			result.WriteString(utilities.UnifiedDiff(path, path, originals[path], string(content)))
+		}
+	}
+
+	return result.String(), nil
+}
+
+// chooseQuickFixes picks a quick fix for each diagnostic that has one, preferring the
+// server's preferred fix, and skips fixes that conflict with those already chosen
+func chooseQuickFixes(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, diagnostics []protocol.Diagnostic) []quickFix {
+	var fixes []quickFix
+	for _, diag := range diagnostics {
+		fix, ok := quickFixFor(ctx, client, uri, diag)
+		if !ok || conflicts(fix, fixes) {
+			continue
+		}
+		fixes = append(fixes, fix)
+	}
+	return fixes
+}
+
+// quickFixFor returns the quick fix for a diagnostic, if the server offers one that only
+// edits text. Fixes that run commands or create, rename or delete files are skipped.
+func quickFixFor(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, diag protocol.Diagnostic) (quickFix, bool) {
+	actions, err := client.CodeAction(ctx, protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        diag.Range,
+		Context: protocol.CodeActionContext{
+			Diagnostics: []protocol.Diagnostic{diag},
+			Only:        []protocol.CodeActionKind{protocol.QuickFix},
+		},
+	})
+	if err != nil {
+		toolsLogger.Error("Failed to get code actions: %v", err)
+		return quickFix{}, false
+	}
+
+	var candidates []protocol.CodeAction
+	for _, action := range actions {
+		ca, ok := action.Value.(protocol.CodeAction)
+		if !ok || ca.Disabled != nil || !strings.HasPrefix(string(ca.Kind), string(protocol.QuickFix)) {
+			continue
+		}
+		if ca.IsPreferred {
+			candidates = append([]protocol.CodeAction{ca}, candidates...)
+		} else {
+			candidates = append(candidates, ca)
+		}
+	}
+
+	for _, ca := range candidates {
+		if ca.Edit == nil && ca.Command == nil && ca.Data != nil {
+			resolved, err := client.ResolveCodeAction(ctx, ca)
+			if err != nil {
+				toolsLogger.Debug("Failed to resolve code action %q: %v", ca.Title, err)
+				continue
+			}
+			ca = resolved
+		}
+		if ca.Edit == nil || ca.Command != nil {
+			continue
+		}
+		edits, ok := textEditsByFile(*ca.Edit)
+		if !ok || len(edits) == 0 {
+			continue
+		}
+		return quickFix{diag: diag, title: ca.Title, edits: edits}, true
+	}
+	return quickFix{}, false
+}
+
+// textEditsByFile returns the text edits of a workspace edit by file, or false if the
+// workspace edit also creates, renames or deletes files
+func textEditsByFile(edit protocol.WorkspaceEdit) (map[protocol.DocumentUri][]protocol.TextEdit, bool) {
+	edits := make(map[protocol.DocumentUri][]protocol.TextEdit)
+	for uri, textEdits := range edit.Changes {
+		edits[uri] = append(edits[uri], textEdits...)
+	}
+	for _, change := range edit.DocumentChanges {
+		if change.TextDocumentEdit == nil {
+			return nil, false
+		}
+		uri := change.TextDocumentEdit.TextDocument.URI
+		for _, e := range change.TextDocumentEdit.Edits {
+			textEdit, err := e.AsTextEdit()
+			if err != nil {
+				return nil, false
+			}
+			edits[uri] = append(edits[uri], textEdit)
+		}
+	}
+	return edits, true
+}
+
+// conflicts reports whether any edit of a fix overlaps an edit of the other fixes
+func conflicts(fix quickFix, others []quickFix) bool {
+	for _, other := range others {
+		for uri, edits := range fix.edits {
+			for _, a := range edits {
+				for _, b := range other.edits[uri] {
+					if utilities.RangesOverlap(a.Range, b.Range) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextEditsByFile(t *testing.T) {
+	uri := protocol.DocumentUri("file:///a.go")
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			uri: {{Range: lineRange(1, 1), NewText: "x"}},
+		},
+		DocumentChanges: []protocol.DocumentChange{
+			{TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri},
+				},
+				Edits: []protocol.Or_TextDocumentEdit_edits_Elem{
+					{Value: protocol.TextEdit{Range: lineRange(3, 3), NewText: "y"}},
+				},
+			}},
+		},
+	}
+
+	edits, ok := textEditsByFile(edit)
+	require.True(t, ok)
+	assert.Len(t, edits[uri], 2)
+
+	edit.DocumentChanges = append(edit.DocumentChanges, protocol.DocumentChange{
+		CreateFile: &protocol.CreateFile{URI: "file:///b.go"},
+	})
+	_, ok = textEditsByFile(edit)
+	assert.False(t, ok, "Expected file operations to be rejected")
+}
+
+func TestConflicts(t *testing.T) {
+	uri := protocol.DocumentUri("file:///a.go")
+	chosen := []quickFix{{edits: map[protocol.DocumentUri][]protocol.TextEdit{
+		uri: {{Range: lineRange(5, 6)}},
+	}}}
+
+	overlapping := quickFix{edits: map[protocol.DocumentUri][]protocol.TextEdit{
+		uri: {{Range: lineRange(6, 8)}},
+	}}
+	assert.True(t, conflicts(overlapping, chosen))
+
+	elsewhere := quickFix{edits: map[protocol.DocumentUri][]protocol.TextEdit{
+		uri:            {{Range: lineRange(10, 12)}},
+		"file:///b.go": {{Range: lineRange(5, 6)}},
+	}}
+	assert.False(t, conflicts(elsewhere, chosen))
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// generator is code Generate can produce, with the code action kinds servers offer it as
+// and the starts of the titles that identify it among the actions of broader kinds
+type generator struct {
+	kinds  []protocol.CodeActionKind
+	titles []string
+}
+
+// Generators are the kinds of code Generate can produce. Servers differ in the kinds and
+// titles of these actions: gopls fills a struct literal with "Fill S" as
+// refactor.rewrite.fillStruct, while rust-analyzer offers "Fill struct fields" as a quick
+// fix, so both are matched.
+var Generators = map[string]generator{
+	"fill_struct": {
+		kinds:  []protocol.CodeActionKind{protocol.RefactorRewrite + ".fillStruct"},
+		titles: []string{"fill struct", "add missing fields", "add missing properties", "add missing attributes"},
+	},
+	"implement_interface": {
+		titles: []string{"declare missing methods", "implement interface", "implement missing members",
+			"implement all members", "implement inherited abstract", "add unimplemented methods", "implement methods"},
+	},
+	"fill_switch": {
+		kinds:  []protocol.CodeActionKind{protocol.RefactorRewrite + ".fillSwitch"},
+		titles: []string{"add cases for", "fill match arms", "add missing case", "add missing switch cases"},
+	},
+}
+
+// matches reports whether a code action produces the generator's code
+func (g generator) matches(ca protocol.CodeAction) bool {
+	for _, kind := range g.kinds {
+		if ca.Kind == kind || strings.HasPrefix(string(ca.Kind), string(kind)+".") {
+			return true
+		}
+	}
+	title := strings.ToLower(ca.Title)
+	for _, prefix := range g.titles {
+		if strings.HasPrefix(title, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Generate produces code at a position with the server's code actions: it fills in the
+// fields of a struct literal, declares the missing methods of a type so that it
+// implements an interface, or adds the missing cases of a switch or match. If the server
+// offers several such actions at the position, the first one whose title contains title
+// is applied. With dryRun set no files are written and the unified diff is returned.
+func Generate(ctx context.Context, client *lsp.Client, filePath string, line, column int, kind, title string, dryRun bool) (string, error) {
+	gen, ok := Generators[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown kind %q, expected fill_struct, implement_interface or fill_switch", kind)
+	}
+
+	// gopls offers older versions of these actions as plain refactor.rewrite actions, and
+	// other servers offer them as quick fixes for the diagnostics at the position
+	pos := lspPosition(ctx, client, filePath, line, column)
+	only := []protocol.CodeActionKind{protocol.QuickFix, protocol.RefactorRewrite}
+	chosen, err := findRefactoring(ctx, client, filePath, protocol.Range{Start: pos, End: pos}, only, gen.matches, title)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate %s at L%d:C%d: %v", strings.ReplaceAll(kind, "_", " "), line, column, err)
+	}
+	return applyRefactoring(ctx, client, chosen, "", dryRun)
+}
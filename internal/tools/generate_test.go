@@ -0,0 +1,24 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratorMatches(t *testing.T) {
+	fillStruct := Generators["fill_struct"]
+	assert.True(t, fillStruct.matches(protocol.CodeAction{Title: "Fill Config", Kind: "refactor.rewrite.fillStruct"}))
+	assert.True(t, fillStruct.matches(protocol.CodeAction{Title: "Fill struct fields", Kind: protocol.QuickFix}))
+	assert.False(t, fillStruct.matches(protocol.CodeAction{Title: "Fill match arms", Kind: protocol.QuickFix}))
+
+	implement := Generators["implement_interface"]
+	assert.True(t, implement.matches(protocol.CodeAction{Title: "Declare missing methods of io.Reader", Kind: protocol.QuickFix}))
+	assert.True(t, implement.matches(protocol.CodeAction{Title: "Implement missing members", Kind: protocol.QuickFix}))
+	assert.False(t, implement.matches(protocol.CodeAction{Title: "Fill Config", Kind: "refactor.rewrite.fillStruct"}))
+
+	fillSwitch := Generators["fill_switch"]
+	assert.True(t, fillSwitch.matches(protocol.CodeAction{Title: "Add cases for Kind", Kind: "refactor.rewrite.fillSwitch"}))
+	assert.True(t, fillSwitch.matches(protocol.CodeAction{Title: "Fill match arms", Kind: protocol.QuickFix}))
+}
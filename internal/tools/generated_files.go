@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"io"
+	"os"
+	"regexp"
+)
+
+// How much of the start of a file is checked for binary content and generated-file markers
+const fileHeadBytes = 8000
+
+// generatedMarker matches the comments code generators put at the top of the files they
+// write, such as Go's "// Code generated by stringer; DO NOT EDIT." or "@generated". The
+// marker must start its line, after comment characters, so that mentions in code don't
+// count.
+var generatedMarker = regexp.MustCompile(`(?im)^[^\w\n]*(code generated .*do not edit|@generated\b|<auto-?generated|this file (is|was|has been) (automatically|auto-?) ?generated|generated by the protocol buffer compiler|do not edit\b)`)
+
+// machineFileKind returns "binary" or "generated" for binary files and files with a
+// generated-file marker near their start, from their content, and "" for other files
+func machineFileKind(content []byte) string {
+	if len(content) > fileHeadBytes {
+		content = content[:fileHeadBytes]
+	}
+	switch {
+	case isBinary(content):
+		return "binary"
+	case generatedMarker.Match(content):
+		return "generated"
+	default:
+		return ""
+	}
+}
+
+// readFileHead reads the start of a file, enough for machineFileKind
+func readFileHead(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	head := make([]byte, fileHeadBytes)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
@@ -0,0 +1,29 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMachineFileKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"go generated", "// Code generated by stringer -type=Kind; DO NOT EDIT.\n\npackage x\n", "generated"},
+		{"protobuf", "// Generated by the protocol buffer compiler.  DO NOT EDIT!\n", "generated"},
+		{"at generated", "/**\n * @generated SignedSource<<abc>>\n */\n", "generated"},
+		{"csharp", "//------\n// <auto-generated>\n//------\n", "generated"},
+		{"python", "# This file was automatically generated by SWIG.\n", "generated"},
+		{"binary", "ELF\x00\x01\x02", "binary"},
+		{"mention in code", "package x\n\nconst doc = \"Code generated ... DO NOT EDIT\"\n", ""},
+		{"plain", "package x\n\nfunc f() {}\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, machineFileKind([]byte(tt.content)))
+		})
+	}
+}
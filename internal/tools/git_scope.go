@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitScope restricts results to the files changed in git, so that reviews of a branch
+// only see the code they are about
+type GitScope string
+
+const (
+	// ScopeAll doesn't restrict results
+	ScopeAll GitScope = "all"
+	// ScopeDiff restricts results to the files changed on the current branch: committed
+	// since it forked from the default branch, uncommitted or untracked
+	ScopeDiff GitScope = "diff"
+	// ScopeStaged restricts results to the files staged for the next commit
+	ScopeStaged GitScope = "staged"
+)
+
+// Branches the current branch is compared with in ScopeDiff, the first that exists wins
+var defaultBranches = []string{"origin/HEAD", "origin/main", "origin/master", "main", "master"}
+
+// ParseGitScope validates a scope name. An empty name selects ScopeAll.
+func ParseGitScope(name string) (GitScope, error) {
+	switch scope := GitScope(name); scope {
+	case "":
+		return ScopeAll, nil
+	case ScopeAll, ScopeDiff, ScopeStaged:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("unknown scope %q, expected all, diff or staged", name)
+	}
+}
+
+// ChangedFiles returns the absolute paths of the files under root, the workspace
+// directory, that are in a scope, or nil for ScopeAll. Deleted files are left out.
+func ChangedFiles(ctx context.Context, root string, scope GitScope) (map[string]bool, error) {
+	var names []string
+	switch scope {
+	case ScopeAll:
+		return nil, nil
+	case ScopeStaged:
+		staged, err := runGit(ctx, root, "diff", "--cached", "--name-only", "--relative", "--diff-filter=d", "-z")
+		if err != nil {
+			return nil, err
+		}
+		names = staged
+	case ScopeDiff:
+		// Comparing the working tree with the fork point covers both committed and
+		// uncommitted changes
+		changed, err := runGit(ctx, root, "diff", "--name-only", "--relative", "--diff-filter=d", "-z", forkPoint(ctx, root))
+		if err != nil {
+			return nil, err
+		}
+		untracked, err := runGit(ctx, root, "ls-files", "--others", "--exclude-standard", "-z")
+		if err != nil {
+			return nil, err
+		}
+		names = append(changed, untracked...)
+	default:
+		return nil, fmt.Errorf("unknown scope %q", scope)
+	}
+
+	files := make(map[string]bool, len(names))
+	for _, name := range names {
+		files[filepath.Join(root, filepath.FromSlash(name))] = true
+	}
+	return files, nil
+}
+
+// forkPoint returns the commit the current branch forked from the default branch at,
+// HEAD if there is no default branch, or the empty tree if there are no commits yet
+func forkPoint(ctx context.Context, root string) string {
+	for _, branch := range defaultBranches {
+		if base, err := runGit(ctx, root, "merge-base", "HEAD", branch); err == nil && len(base) == 1 {
+			return base[0]
+		}
+	}
+	if _, err := runGit(ctx, root, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		return "HEAD"
+	}
+	// git hash-object -t tree /dev/null
+	return "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+}
+
+// runGit runs git in dir and returns the fields of its output, separated by NUL with -z
+// and by newlines otherwise
+func runGit(ctx context.Context, dir string, args ...string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	sep := "\n"
+	for _, arg := range args {
+		if arg == "-z" {
+			sep = "\x00"
+		}
+	}
+	var fields []string
+	for _, field := range strings.Split(stdout.String(), sep) {
+		if field = strings.TrimSuffix(field, "\r"); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	abs := func(names ...string) map[string]bool {
+		files := make(map[string]bool)
+		for _, name := range names {
+			files[filepath.Join(root, filepath.FromSlash(name))] = true
+		}
+		return files
+	}
+
+	git("init", "-q", "-b", "main")
+	write("main.go", "package main\n")
+	write("old.go", "package main\n")
+	write("pkg/util.go", "package pkg\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	git("checkout", "-q", "-b", "feature")
+	write("pkg/util.go", "package pkg\n\nfunc Util() {}\n")
+	git("commit", "-q", "-am", "add Util")
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("staged.go", "package main\n")
+	git("add", "staged.go")
+	write("untracked.go", "package main\n")
+	git("rm", "-q", "old.go")
+
+	all, err := ChangedFiles(ctx, root, ScopeAll)
+	require.NoError(t, err)
+	assert.Nil(t, all)
+
+	diff, err := ChangedFiles(ctx, root, ScopeDiff)
+	require.NoError(t, err)
+	assert.Equal(t, abs("pkg/util.go", "main.go", "staged.go", "untracked.go"), diff)
+
+	staged, err := ChangedFiles(ctx, root, ScopeStaged)
+	require.NoError(t, err)
+	assert.Equal(t, abs("staged.go"), staged)
+
+	// Paths are relative to the workspace, which may be a subdirectory of the repository
+	sub, err := ChangedFiles(ctx, filepath.Join(root, "pkg"), ScopeDiff)
+	require.NoError(t, err)
+	assert.Equal(t, abs("pkg/util.go"), sub)
+}
+
+func TestChangedFilesOutsideRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	_, err := ChangedFiles(context.Background(), t.TempDir(), ScopeDiff)
+	assert.Error(t, err)
+}
+
+func TestParseGitScope(t *testing.T) {
+	scope, err := ParseGitScope("")
+	require.NoError(t, err)
+	assert.Equal(t, ScopeAll, scope)
+
+	scope, err = ParseGitScope("staged")
+	require.NoError(t, err)
+	assert.Equal(t, ScopeStaged, scope)
+
+	_, err = ParseGitScope("branch")
+	assert.Error(t, err)
+}
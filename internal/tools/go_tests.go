@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// GoTestCommand is the gopls command running tests, which reports the output of go test
+// as work done progress
+const GoTestCommand = "gopls.run_tests"
+
+// goTestRuns numbers the progress tokens of the GoTest calls
+var goTestRuns atomic.Int64
+
+// goTestArgs are the arguments of GoTestCommand
+type goTestArgs struct {
+	URI        protocol.DocumentUri `json:"URI"`
+	Tests      []string             `json:"Tests"`
+	Benchmarks []string             `json:"Benchmarks"`
+}
+
+// GoTest runs the Go test, benchmark, fuzz test or example enclosing a position with
+// gopls, which runs go test -run for it in the package of the file, and returns the output
+// of go test. The output reaches the MCP client as progress notifications while the test
+// runs, as gopls reports it as work done progress for a token chosen here.
+func GoTest(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
+	if !strings.HasSuffix(filePath, "_test.go") {
+		return "", fmt.Errorf("%s is not a Go test file", filePath)
+	}
+	uri := protocol.URIFromPath(filePath)
+	symbols, _ := documentSymbols(ctx, client, uri)
+	fn := enclosingFunction(symbols, lspPosition(ctx, client, filePath, line, column))
+	if fn == nil {
+		return "", fmt.Errorf("no function at L%d:C%d of %s", line, column, filePath)
+	}
+
+	name := fn.GetName()
+	args, err := newGoTestArgs(uri, name)
+	if err != nil {
+		return "", err
+	}
+	arg, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+
+	// Collect the output gopls reports for the token until the run ends
+	output := newGoTestOutput(fmt.Sprintf("mcp-language-server/go_test/%d", goTestRuns.Add(1)))
+	stop := client.WatchProgress(output.add)
+	defer stop()
+
+	params := protocol.ExecuteCommandParams{
+		Command:   GoTestCommand,
+		Arguments: []json.RawMessage{arg},
+	}
+	params.WorkDoneToken = protocol.ProgressToken{Value: output.token}
+	if _, err := client.ExecuteCommand(ctx, params); err != nil {
+		return "", fmt.Errorf("failed to run %s: %v", name, err)
+	}
+
+	note := ""
+	select {
+	case <-output.done:
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", ctx.Err()
+		}
+		note = "\n---\n\nTimed out: the test is still running, showing its output so far"
+	}
+	return fmt.Sprintf("Ran %s in the package of %s:\n\n%s\n%s", name, filePath, output.text(), note), nil
+}
+
+// newGoTestArgs returns the arguments running the function name of a test file, which
+// must be a test, benchmark, fuzz test or example
+func newGoTestArgs(uri protocol.DocumentUri, name string) (goTestArgs, error) {
+	args := goTestArgs{URI: uri}
+	switch {
+	case strings.HasPrefix(name, "Benchmark"):
+		args.Benchmarks = []string{name}
+	case strings.HasPrefix(name, "Test"), strings.HasPrefix(name, "Fuzz"), strings.HasPrefix(name, "Example"):
+		args.Tests = []string{name}
+	default:
+		return goTestArgs{}, fmt.Errorf("%s is not a test, benchmark, fuzz test or example", name)
+	}
+	return args, nil
+}
+
+// goTestOutput collects the output of go test that gopls reports as the progress of a
+// token, and is done once the run ends
+type goTestOutput struct {
+	token  string
+	done   chan struct{}
+	once   sync.Once
+	mu     sync.Mutex
+	output strings.Builder
+}
+
+func newGoTestOutput(token string) *goTestOutput {
+	return &goTestOutput{token: token, done: make(chan struct{})}
+}
+
+// add records a progress event, ignoring those of other tokens
+func (o *goTestOutput) add(event lsp.ProgressEvent) {
+	if event.Token != o.token {
+		return
+	}
+	o.mu.Lock()
+	if event.Done && o.output.Len() > 0 && !strings.HasSuffix(o.output.String(), "\n") {
+		o.output.WriteString("\n")
+	}
+	o.output.WriteString(event.Message)
+	o.mu.Unlock()
+	if event.Done {
+		// The end of the run carries its outcome, such as "tests passed"
+		o.once.Do(func() { close(o.done) })
+	}
+}
+
+// text returns the output collected so far
+func (o *goTestOutput) text() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	text := strings.TrimRight(o.output.String(), "\n")
+	if text == "" {
+		return "(no output)"
+	}
+	return text
+}
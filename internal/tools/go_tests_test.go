@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGoTestArgs(t *testing.T) {
+	uri := protocol.DocumentUri("file:///src/main_test.go")
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "TestMain", expected: `{"URI":"file:///src/main_test.go","Tests":["TestMain"],"Benchmarks":null}`},
+		{name: "FuzzParse", expected: `{"URI":"file:///src/main_test.go","Tests":["FuzzParse"],"Benchmarks":null}`},
+		{name: "ExampleParse", expected: `{"URI":"file:///src/main_test.go","Tests":["ExampleParse"],"Benchmarks":null}`},
+		{name: "BenchmarkParse", expected: `{"URI":"file:///src/main_test.go","Tests":null,"Benchmarks":["BenchmarkParse"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := newGoTestArgs(uri, tt.name)
+			require.NoError(t, err)
+			arg, err := json.Marshal(args)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(arg))
+		})
+	}
+
+	_, err := newGoTestArgs(uri, "helper")
+	assert.ErrorContains(t, err, "helper is not a test")
+}
+
+func TestGoTestOutput(t *testing.T) {
+	output := newGoTestOutput("mcp-language-server/go_test/1")
+	assert.Equal(t, "(no output)", output.text())
+
+	output.add(lsp.ProgressEvent{Token: "mcp-language-server/go_test/1", Title: "Running TestMain"})
+	output.add(lsp.ProgressEvent{Token: "mcp-language-server/go_test/1", Message: "=== RUN   TestMain\n"})
+	output.add(lsp.ProgressEvent{Token: "indexing", Message: "3/25 files"})
+	output.add(lsp.ProgressEvent{Token: "mcp-language-server/go_test/1", Message: "--- PASS: TestMain (0.00s)"})
+	select {
+	case <-output.done:
+		t.Fatal("done before the end of the run")
+	default:
+	}
+
+	// The end of the run goes on a line of its own
+	output.add(lsp.ProgressEvent{Token: "mcp-language-server/go_test/1", Message: "tests passed", Done: true})
+	<-output.done
+	assert.Equal(t, "=== RUN   TestMain\n--- PASS: TestMain (0.00s)\ntests passed", output.text())
+
+	// Events after the end don't close done again
+	output.add(lsp.ProgressEvent{Token: "mcp-language-server/go_test/1", Done: true})
+}
@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// DefaultMaxGrepMatches is how many matches Grep returns unless told otherwise
+const DefaultMaxGrepMatches = 100
+
+// Matched lines longer than this are cut short in Grep's output
+const maxGrepLineLength = 300
+
+// GrepOptions changes how Grep matches and presents results
+type GrepOptions struct {
+	// Literal matches the pattern as plain text rather than as a regular expression
+	Literal bool
+	// IgnoreCase matches regardless of case
+	IgnoreCase bool
+	// Symbols names the symbol enclosing each match, from the language server's document
+	// symbols, for files in languages it knows
+	Symbols bool
+	// MaxResults is the maximum number of matches to return, zero for no limit
+	MaxResults int
+}
+
+// grepMatch is a line matching a Grep pattern
+type grepMatch struct {
+	line int
+	// The column of the match in runes, as editors show it
+	column int
+	text   string
+	// The position of the match as the language server counts it
+	position protocol.Position
+}
+
+// Grep searches the text of the files under root, the workspace directory, for a regular
+// expression (RE2 syntax), skipping the files the workspace watcher ignores, such as
+// gitignored and binary files. It works whether or not the language server has indexed
+// the workspace or knows the language, and can name the symbol enclosing each match.
+func Grep(ctx context.Context, client *lsp.Client, root, pattern string, opts GrepOptions, filter PathFilter) (string, error) {
+	expr := pattern
+	if opts.Literal {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if opts.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %v", err)
+	}
+
+	var paths []string
+	byFile := make(map[string][]grepMatch)
+	total := 0
+	truncated := false
+	err = walkWorkspaceFiles(ctx, root, func(path string) error {
+		if !filter.Allows(path) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || isBinary(content) || !re.Match(content) {
+			return nil
+		}
+
+		for i, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSuffix(line, "\r")
+			loc := re.FindStringIndex(line)
+			if loc == nil {
+				continue
+			}
+			if opts.MaxResults > 0 && total == opts.MaxResults {
+				truncated = true
+				return filepath.SkipAll
+			}
+			character := client.PositionEncoding().CharacterLen(line[:loc[0]])
+			if len(byFile[path]) == 0 {
+				paths = append(paths, path)
+			}
+			byFile[path] = append(byFile[path], grepMatch{
+				line:     i + 1,
+				column:   utf8.RuneCountInString(line[:loc[0]]) + 1,
+				text:     line,
+				position: protocol.Position{Line: uint32(i), Character: uint32(character)},
+			})
+			total++
+		}
+		return nil
+	})
+	if err != nil && (!errors.Is(err, context.DeadlineExceeded) || total == 0) {
+		return "", err
+	}
+	if total == 0 {
+		return fmt.Sprintf("No matches found for %q", pattern), nil
+	}
+
+	result := formatGrep(ctx, client, paths, byFile, opts)
+	if err != nil {
+		result += fmt.Sprintf("\n---\n\nTimed out: showing the %d matches found before the search stopped", total)
+	} else if truncated {
+		result += fmt.Sprintf("\n---\n\nShowing the first %d matches. Narrow the pattern, or the files with include and exclude, to see the rest.", total)
+	}
+	return result, nil
+}
+
+// formatGrep formats the matches of Grep by file, in the order the files were searched
+func formatGrep(ctx context.Context, client *lsp.Client, paths []string, byFile map[string][]grepMatch, opts GrepOptions) string {
+	var b strings.Builder
+	for _, path := range paths {
+		matches := byFile[path]
+		fmt.Fprintf(&b, "---\n\n%s\nMatches in File: %d\n\n", path, len(matches))
+
+		var symbols []protocol.DocumentSymbolResult
+		if opts.Symbols && lsp.DetectLanguageID(path) != "" && ctx.Err() == nil {
+			symbols, _ = documentSymbols(ctx, client, protocol.URIFromPath(path))
+		}
+		for _, match := range matches {
+			text := strings.TrimSpace(match.text)
+			if len(text) > maxGrepLineLength {
+				text = truncateString(text, maxGrepLineLength) + "..."
+			}
+			if name := enclosingSymbolName(symbols, match.position); name != "" {
+				fmt.Fprintf(&b, "L%d:C%d (in %s): %s\n", match.line, match.column, name, text)
+			} else {
+				fmt.Fprintf(&b, "L%d:C%d: %s\n", match.line, match.column, text)
+			}
+		}
+	}
+	return b.String()
+}
+
+// truncateString cuts s to at most n bytes without splitting a character
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// enclosingSymbolName returns the qualified name of the innermost symbol containing pos,
+// such as Server.Start, or "" if pos is not in a symbol
+func enclosingSymbolName(symbols []protocol.DocumentSymbolResult, pos protocol.Position) string {
+	for _, sym := range symbols {
+		if !containsPosition(sym.GetRange(), pos) {
+			continue
+		}
+		switch s := sym.(type) {
+		case *protocol.DocumentSymbol:
+			children := make([]protocol.DocumentSymbolResult, len(s.Children))
+			for i := range s.Children {
+				children[i] = &s.Children[i]
+			}
+			if inner := enclosingSymbolName(children, pos); inner != "" {
+				return s.Name + "." + inner
+			}
+			return s.Name
+		case *protocol.SymbolInformation:
+			// Symbol information is flat, so find the smallest symbol containing pos
+			var found *protocol.SymbolInformation
+			for _, other := range symbols {
+				if si, ok := other.(*protocol.SymbolInformation); ok && containsPosition(si.Location.Range, pos) &&
+					(found == nil || containsPosition(found.Location.Range, si.Location.Range.Start)) {
+					found = si
+				}
+			}
+			if found.ContainerName != "" && !strings.Contains(found.Name, ".") {
+				return found.ContainerName + "." + found.Name
+			}
+			return found.Name
+		}
+	}
+	return ""
+}
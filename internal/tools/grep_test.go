@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeGrepWorkspace(t *testing.T) string {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":     "generated/\n",
+		"main.go":        "package main\n\n// TODO: handle errors\nfunc main() {\n\tprintln(\"héllo 👋\") // todo\n}\n",
+		"notes.txt":      "nothing to do here\r\nTODO: write notes\r\n",
+		"generated/x.go": "// TODO: generated\n",
+		"image.bin":      "TODO\x00\x01",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return root
+}
+
+func TestGrep(t *testing.T) {
+	root := writeGrepWorkspace(t)
+	ctx := context.Background()
+
+	result, err := Grep(ctx, nil, root, `TODO: \w+`, GrepOptions{}, PathFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, "---\n\n"+filepath.Join(root, "main.go")+"\nMatches in File: 1\n\nL3:C4: // TODO: handle errors\n"+
+		"---\n\n"+filepath.Join(root, "notes.txt")+"\nMatches in File: 1\n\nL2:C1: TODO: write notes\n", result)
+
+	t.Run("ignore case", func(t *testing.T) {
+		result, err := Grep(ctx, nil, root, "todo", GrepOptions{IgnoreCase: true}, PathFilter{})
+		require.NoError(t, err)
+		// Columns count runes, not the UTF-16 code units of the language server
+		assert.Contains(t, result, "Matches in File: 2\n\nL3:C4: // TODO: handle errors\nL5:C24: println(\"héllo 👋\") // todo\n")
+	})
+
+	t.Run("literal", func(t *testing.T) {
+		result, err := Grep(ctx, nil, root, "main()", GrepOptions{Literal: true}, PathFilter{})
+		require.NoError(t, err)
+		assert.Contains(t, result, "L4:C6: func main() {\n")
+
+		result, err = Grep(ctx, nil, root, "main()", GrepOptions{}, PathFilter{})
+		require.NoError(t, err)
+		assert.Contains(t, result, "L1:C9: package main\n")
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		result, err := Grep(ctx, nil, root, "TODO", GrepOptions{}, PathFilter{Root: root, Include: []string{"*.txt"}})
+		require.NoError(t, err)
+		assert.NotContains(t, result, "main.go")
+		assert.Contains(t, result, "notes.txt")
+	})
+
+	t.Run("max results", func(t *testing.T) {
+		result, err := Grep(ctx, nil, root, "TODO", GrepOptions{MaxResults: 1}, PathFilter{})
+		require.NoError(t, err)
+		assert.Contains(t, result, "Matches in File: 1\n")
+		assert.NotContains(t, result, "notes.txt")
+		assert.Contains(t, result, "Showing the first 1 matches.")
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		result, err := Grep(ctx, nil, root, "FIXME", GrepOptions{}, PathFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, `No matches found for "FIXME"`, result)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := Grep(ctx, nil, root, "(", GrepOptions{}, PathFilter{})
+		assert.ErrorContains(t, err, "invalid pattern")
+	})
+}
+
+func TestEnclosingSymbolName(t *testing.T) {
+	span := func(start, end uint32) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: start}, End: protocol.Position{Line: end}}
+	}
+	symbols := []protocol.DocumentSymbolResult{
+		&protocol.DocumentSymbol{Name: "Server", Range: span(0, 20), Children: []protocol.DocumentSymbol{
+			{Name: "Start", Range: span(2, 8)},
+		}},
+		&protocol.DocumentSymbol{Name: "main", Range: span(22, 30)},
+	}
+	assert.Equal(t, "Server.Start", enclosingSymbolName(symbols, protocol.Position{Line: 4}))
+	assert.Equal(t, "Server", enclosingSymbolName(symbols, protocol.Position{Line: 10}))
+	assert.Equal(t, "main", enclosingSymbolName(symbols, protocol.Position{Line: 25}))
+	assert.Equal(t, "", enclosingSymbolName(symbols, protocol.Position{Line: 21}))
+
+	flat := []protocol.DocumentSymbolResult{
+		&protocol.SymbolInformation{Name: "Server", Location: protocol.Location{Range: span(0, 20)}},
+		&protocol.SymbolInformation{Name: "Start", ContainerName: "Server", Location: protocol.Location{Range: span(2, 8)}},
+	}
+	assert.Equal(t, "Server.Start", enclosingSymbolName(flat, protocol.Position{Line: 4}))
+	assert.Equal(t, "Server", enclosingSymbolName(flat, protocol.Position{Line: 10}))
+}
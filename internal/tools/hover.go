@@ -11,37 +11,34 @@ import (
 
 // GetHoverInfo retrieves hover information (type, documentation) for a symbol at the specified position
 func GetHoverInfo(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
-	// Open the file if not already open
-	err := client.OpenFile(ctx, filePath)
-	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
-	}
+	// Convert the 1-indexed line and character column to an LSP position
+	position := lspPosition(ctx, client, filePath, line, column)
+	uri := protocol.URIFromPath(filePath)
 
-	params := protocol.HoverParams{}
-
-	// Convert 1-indexed line/column to 0-indexed for LSP protocol
-	position := protocol.Position{
-		Line:      uint32(line - 1),
-		Character: uint32(column - 1),
-	}
-	uri := protocol.DocumentUri("file://" + filePath)
-	params.TextDocument = protocol.TextDocumentIdentifier{
-		URI: uri,
+	// The precomputed index answers first when preferred, and otherwise when the
+	// language server fails or has nothing
+	if options(ctx).Precomputed.first() {
+		if text := options(ctx).Precomputed.hover(filePath, position, client.PositionEncoding()); text != "" {
+			return options(ctx).Precomputed.note() + text, nil
+		}
 	}
-	params.Position = position
 
-	// Execute the hover request
-	hoverResult, err := client.Hover(ctx, params)
+	hoverText, err := serverHover(ctx, client, uri, position)
+	if err != nil || hoverText == "" {
+		if text := options(ctx).Precomputed.hover(filePath, position, client.PositionEncoding()); text != "" {
+			return options(ctx).Precomputed.note() + text, nil
+		}
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to get hover information: %v", err)
+		return "", err
 	}
 
 	var result strings.Builder
 
 	// Process the hover contents based on Markup content
-	if hoverResult.Contents.Value == "" {
+	if hoverText == "" {
 		// Extract the line where the hover was requested
-		lineText, err := ExtractTextFromLocation(protocol.Location{
+		lineText, err := ExtractTextFromLocation(ctx, protocol.Location{
 			URI: uri,
 			Range: protocol.Range{
 				Start: protocol.Position{
@@ -53,14 +50,35 @@ func GetHoverInfo(ctx context.Context, client *lsp.Client, filePath string, line
 					Character: 0,
 				},
 			},
-		})
+		}, client.PositionEncoding())
 		if err != nil {
 			toolsLogger.Warn("failed to extract line at position: %v", err)
 		}
 		result.WriteString(fmt.Sprintf("No hover information available for this position on the following line:\n%s", lineText))
 	} else {
-		result.WriteString(hoverResult.Contents.Value)
+		result.WriteString(hoverText)
 	}
 
 	return result.String(), nil
 }
+
+// serverHover asks the language server for the hover information at a position
+func serverHover(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, position protocol.Position) (string, error) {
+	// Open the file if not already open
+	if err := client.OpenFileForQuery(ctx, uri.Path()); err != nil {
+		return "", fmt.Errorf("could not open file: %v", err)
+	}
+
+	params := protocol.HoverParams{}
+	params.TextDocument = protocol.TextDocumentIdentifier{
+		URI: uri,
+	}
+	params.Position = position
+
+	// Execute the hover request
+	hoverResult, err := client.Hover(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to get hover information: %v", err)
+	}
+	return hoverResult.Contents.Value, nil
+}
@@ -2,26 +2,25 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"sort"
 	"strings"
 
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
 )
 
-func GetImplementation(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
-	err := client.OpenFile(ctx, filePath)
+func GetImplementation(ctx context.Context, client *lsp.Client, filePath string, line, column int, contextLines int, filter PathFilter) (string, error) {
+	err := client.OpenFileForQuery(ctx, filePath)
 	if err != nil {
 		return "", fmt.Errorf("could not open file: %v", err)
 	}
 
-	position := protocol.Position{
-		Line:      uint32(line - 1),
-		Character: uint32(column - 1),
-	}
-	uri := protocol.DocumentUri("file://" + filePath)
+	// Convert the 1-indexed line and character column to an LSP position
+	position := lspPosition(ctx, client, filePath, line, column)
+	uri := protocol.URIFromPath(filePath)
 
 	params := protocol.ImplementationParams{
 		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
@@ -46,11 +45,13 @@ func GetImplementation(ctx context.Context, client *lsp.Client, filePath string,
 		return "No implementations found", nil
 	}
 
-	locationsByFile := make(map[protocol.DocumentUri][]protocol.Location)
-	for _, loc := range locations {
-		locationsByFile[loc.URI] = append(locationsByFile[loc.URI], loc)
+	locations = filter.filterLocations(locations)
+	if len(locations) == 0 {
+		return "No implementations found matching the include/exclude filters", nil
 	}
 
+	locationsByFile := groupLocationsByFile(locations)
+
 	uris := make([]string, 0, len(locationsByFile))
 	for uri := range locationsByFile {
 		uris = append(uris, string(uri))
@@ -58,10 +59,19 @@ func GetImplementation(ctx context.Context, client *lsp.Client, filePath string,
 	sort.Strings(uris)
 
 	var allImplementations []string
-	for _, uriStr := range uris {
+	columns := newFileLines(ctx, client)
+	for i, uriStr := range uris {
+		if err := ctx.Err(); err != nil {
+			note, err := partialResults(err, i, len(uris))
+			if err != nil {
+				return "", err
+			}
+			allImplementations = append(allImplementations, note)
+			break
+		}
 		uri := protocol.DocumentUri(uriStr)
 		fileLocs := locationsByFile[uri]
-		filePath := strings.TrimPrefix(uriStr, "file://")
+		filePath := protocol.DocumentUri(uriStr).Path()
 
 		fileInfo := fmt.Sprintf("---\n\nFile: %s\n", filePath)
 
@@ -69,7 +79,7 @@ func GetImplementation(ctx context.Context, client *lsp.Client, filePath string,
 		for _, loc := range fileLocs {
 			locStr := fmt.Sprintf("L%d:C%d",
 				loc.Range.Start.Line+1,
-				loc.Range.Start.Character+1)
+				columns.column(uri, loc.Range.Start))
 			locStrings = append(locStrings, locStr)
 		}
 
@@ -77,21 +87,26 @@ func GetImplementation(ctx context.Context, client *lsp.Client, filePath string,
 			fileInfo += "At: " + strings.Join(locStrings, ", ") + "\n\n"
 		}
 
-		fileContent, err := os.ReadFile(filePath)
+		fileContent, err := readFile(ctx, filePath)
+		var tooLarge *fileTooLargeError
+		if errors.As(err, &tooLarge) {
+			allImplementations = append(allImplementations, fileInfo+formatLargeFile(ctx, filePath, fileLocs, contextLines, tooLarge))
+			continue
+		}
 		if err != nil {
 			allImplementations = append(allImplementations, fileInfo+"Error reading file: "+err.Error())
 			continue
 		}
 
-		lines := strings.Split(string(fileContent), "\n")
+		lines := utilities.SplitLines(string(fileContent)).Text()
 
-		linesToShow, err := GetLineRangesToDisplay(ctx, client, fileLocs, len(lines), 5)
+		linesToShow, err := GetLineRangesToDisplay(ctx, client, fileLocs, len(lines), contextLines)
 		if err != nil {
 			continue
 		}
 
 		lineRanges := ConvertLinesToRanges(linesToShow, len(lines))
-		formattedOutput := fileInfo + FormatLinesWithRanges(lines, lineRanges)
+		formattedOutput := fileInfo + FormatLinesWithRanges(ctx, filePath, lines, lineRanges)
 		allImplementations = append(allImplementations, formattedOutput)
 	}
 
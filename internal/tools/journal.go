@@ -0,0 +1,305 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// Limits on the edits a Journal keeps. The oldest edits are dropped first.
+const (
+	maxJournalEntries = 20
+	maxJournalBytes   = 64 << 20
+)
+
+// Journal records what the files changed by mutating tool calls held before each call, so
+// that the most recent calls can be undone without relying on version control. Edits the
+// language server applies itself, when running a command, are not recorded.
+type Journal struct {
+	mu      sync.Mutex
+	entries []*JournalEntry
+	nextID  int
+}
+
+// NewJournal returns an empty journal
+func NewJournal() *Journal {
+	return &Journal{nextID: 1}
+}
+
+// JournalEntry is the record of one tool call in a Journal
+type JournalEntry struct {
+	ID   int
+	Tool string
+	Time time.Time
+
+	mu    sync.Mutex
+	files []fileSnapshot
+}
+
+// fileSnapshot is a file as it was before a tool call changed it, and a hash of what the
+// call left, to tell whether it changed again since
+type fileSnapshot struct {
+	path    string
+	exists  bool
+	content []byte
+	mode    os.FileMode
+	after   [sha256.Size]byte
+	removed bool
+}
+
+type journalKey struct{}
+
+// Begin starts the record of a tool call. Pass it to the call with WithJournal and hand
+// it to Record once the call is done.
+func (j *Journal) Begin(tool string) *JournalEntry {
+	return &JournalEntry{Tool: tool, Time: time.Now()}
+}
+
+// WithJournal returns a context in which the mutating tools snapshot the files they
+// change into e
+func WithJournal(ctx context.Context, e *JournalEntry) context.Context {
+	return context.WithValue(ctx, journalKey{}, e)
+}
+
+// Record adds the record of a finished tool call to the journal, if the call changed any
+// files
+func (j *Journal) Record(e *JournalEntry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.files) == 0 {
+		return
+	}
+	for i := range e.files {
+		e.files[i].after, e.files[i].removed = currentHash(e.files[i].path)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e.ID = j.nextID
+	j.nextID++
+	j.entries = append(j.entries, e)
+
+	size := 0
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		size += j.entries[i].size()
+		if size > maxJournalBytes || len(j.entries)-i > maxJournalEntries {
+			j.entries = j.entries[i+1:]
+			break
+		}
+	}
+}
+
+// size is the number of bytes of file content an entry holds
+func (e *JournalEntry) size() int {
+	size := 0
+	for _, f := range e.files {
+		size += len(f.content)
+	}
+	return size
+}
+
+// currentHash returns the hash of a file's content, or whether it does not exist
+func currentHash(path string) ([sha256.Size]byte, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, true
+	}
+	return sha256.Sum256(content), false
+}
+
+// snapshotFiles records the current state of files in the context's journal entry, if
+// there is one, before a tool changes them. Directories are recorded file by file. Only
+// the first snapshot of a file in a call is kept.
+func snapshotFiles(ctx context.Context, paths ...string) {
+	e, _ := ctx.Value(journalKey{}).(*JournalEntry)
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, path := range paths {
+		info, err := os.Lstat(path)
+		if err == nil && info.IsDir() {
+			_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+				if err == nil && d.Type().IsRegular() {
+					e.snapshot(p)
+				}
+				return nil
+			})
+			continue
+		}
+		e.snapshot(path)
+	}
+}
+
+// snapshot records the current state of a file, unless it was already recorded
+func (e *JournalEntry) snapshot(path string) {
+	for _, f := range e.files {
+		if f.path == path {
+			return
+		}
+	}
+	f := fileSnapshot{path: path}
+	if info, err := os.Stat(path); err == nil {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			toolsLogger.Warn("Failed to snapshot %s, it can't be restored by undo: %v", path, err)
+			return
+		}
+		f.exists, f.content, f.mode = true, content, info.Mode().Perm()
+	}
+	e.files = append(e.files, f)
+}
+
+// describe returns how the call changed a file: modified, created or deleted
+func (f fileSnapshot) describe() string {
+	switch {
+	case !f.exists:
+		return "created"
+	case f.removed:
+		return "deleted"
+	default:
+		return "modified"
+	}
+}
+
+// List returns the recorded edits, most recent first
+func (j *Journal) List() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.entries) == 0 {
+		return "No edits recorded."
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d edits recorded, most recent first:\n", len(j.entries))
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		e := j.entries[i]
+		fmt.Fprintf(&sb, "\n#%d %s at %s, %d files:\n", e.ID, e.Tool, e.Time.Format(time.TimeOnly), len(e.files))
+		for _, f := range e.files {
+			fmt.Fprintf(&sb, "  %s (%s)\n", f.path, f.describe())
+		}
+	}
+	return sb.String()
+}
+
+// UndoLastEdit restores the files changed by the most recent recorded tool call to what
+// they held before it and removes the journal entry. Files created by the call are
+// deleted. Unless force is set, nothing is restored if any of the files changed since
+// the call, so that later work is not lost. With dryRun set nothing is restored and the
+// unified diff of the undo is returned.
+func UndoLastEdit(ctx context.Context, client *lsp.Client, j *Journal, force, dryRun bool) (string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.entries) == 0 {
+		return "", fmt.Errorf("there are no recorded edits to undo")
+	}
+	e := j.entries[len(j.entries)-1]
+
+	if !force {
+		var changed []string
+		for _, f := range e.files {
+			if hash, removed := currentHash(f.path); hash != f.after || removed != f.removed {
+				changed = append(changed, f.path)
+			}
+		}
+		if len(changed) > 0 {
+			return "", fmt.Errorf("these files changed since edit #%d (%s), set force to overwrite them: %s",
+				e.ID, e.Tool, strings.Join(changed, ", "))
+		}
+	}
+
+	if dryRun {
+		var diff strings.Builder
+		for i := len(e.files) - 1; i >= 0; i-- {
+			diff.WriteString(e.files[i].undoDiff())
+		}
+		return fmt.Sprintf("Dry run, no files were changed. Undoing edit #%d (%s at %s) would make these changes:\n%s",
+			e.ID, e.Tool, e.Time.Format(time.TimeOnly), diff.String()), nil
+	}
+
+	var sb strings.Builder
+	var errs []error
+	for i := len(e.files) - 1; i >= 0; i-- {
+		f := e.files[i]
+		if err := f.restore(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", f.path, err))
+			continue
+		}
+		restoredFile(ctx, client, f)
+		if f.exists {
+			fmt.Fprintf(&sb, "%s (restored)\n", f.path)
+		} else {
+			fmt.Fprintf(&sb, "%s (removed)\n", f.path)
+		}
+	}
+	j.entries = j.entries[:len(j.entries)-1]
+
+	result := fmt.Sprintf("Undid edit #%d (%s at %s):\n%s", e.ID, e.Tool, e.Time.Format(time.TimeOnly), sb.String())
+	if len(errs) > 0 {
+		return "", fmt.Errorf("failed to restore some files: %v\n%s", errors.Join(errs...), result)
+	}
+	return result, nil
+}
+
+// undoDiff returns the unified diff restoring the file would make, from its current
+// content, as empty if it doesn't exist, to the snapshot
+func (f fileSnapshot) undoDiff() string {
+	current, err := os.ReadFile(f.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Sprintf("%s: could not read the current content: %v\n", f.path, err)
+	}
+	if !f.exists {
+		if info, err := os.Stat(f.path); err == nil && info.IsDir() {
+			return fmt.Sprintf("%s: the directory would be removed\n", f.path)
+		}
+	}
+	return utilities.UnifiedDiff(f.path, f.path, string(current), string(f.content))
+}
+
+// restore puts a file back as it was in the snapshot
+func (f fileSnapshot) restore() error {
+	if !f.exists {
+		// The call created it, as a file or a directory moved there
+		return os.RemoveAll(f.path)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(f.path, f.content, f.mode); err != nil {
+		return err
+	}
+	return os.Chmod(f.path, f.mode)
+}
+
+// restoredFile tells the language server about a file undo restored or removed
+func restoredFile(ctx context.Context, client *lsp.Client, f fileSnapshot) {
+	fileWritten(ctx, client, f.path)
+	if !f.exists {
+		client.ForgetDiagnostics(protocol.URIFromPath(f.path))
+	}
+	if !client.IsFileOpen(f.path) {
+		return
+	}
+	if !f.exists {
+		if err := client.CloseFile(ctx, f.path); err != nil {
+			toolsLogger.Debug("Failed to close %s after removing it: %v", f.path, err)
+		}
+		return
+	}
+	if err := client.NotifyChange(ctx, f.path); err != nil {
+		toolsLogger.Error("Failed to notify language server of change to %s: %v", f.path, err)
+	}
+}
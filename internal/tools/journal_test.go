@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalUndo(t *testing.T) {
+	ctx := context.Background()
+	client := &lsp.Client{}
+	journal := NewJournal()
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "main.go")
+	created := filepath.Join(dir, "pkg", "new.go")
+	require.NoError(t, os.WriteFile(existing, []byte("package main\n"), 0644))
+
+	assert.Equal(t, "No edits recorded.", journal.List())
+	_, err := UndoLastEdit(ctx, client, journal, false, false)
+	assert.ErrorContains(t, err, "no recorded edits")
+
+	entry := journal.Begin("edit_file")
+	editCtx := WithJournal(ctx, entry)
+	snapshotFiles(editCtx, existing)
+	require.NoError(t, os.WriteFile(existing, []byte("package main\n\nfunc main() {}\n"), 0644))
+	_, err = CreateFile(editCtx, client, created, "package pkg\n", false)
+	require.NoError(t, err)
+	journal.Record(entry)
+
+	// Calls that change nothing are not recorded
+	journal.Record(journal.Begin("edit_file"))
+
+	list := journal.List()
+	assert.Contains(t, list, "1 edits recorded")
+	assert.Contains(t, list, existing+" (modified)")
+	assert.Contains(t, list, created+" (created)")
+
+	// Later changes are not overwritten without force
+	require.NoError(t, os.WriteFile(existing, []byte("package main\n\nfunc main() { println() }\n"), 0644))
+	_, err = UndoLastEdit(ctx, client, journal, false, false)
+	assert.ErrorContains(t, err, "changed since edit #1")
+
+	// A dry run shows the undo without restoring anything
+	preview, err := UndoLastEdit(ctx, client, journal, true, true)
+	require.NoError(t, err)
+	assert.Contains(t, preview, "Dry run, no files were changed. Undoing edit #1")
+	assert.Contains(t, preview, "-func main() { println() }")
+	assert.Contains(t, preview, "-package pkg")
+	assert.FileExists(t, created)
+	assert.Contains(t, journal.List(), "1 edits recorded")
+
+	result, err := UndoLastEdit(ctx, client, journal, true, false)
+	require.NoError(t, err)
+	assert.Contains(t, result, "Undid edit #1 (edit_file")
+	content, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+	assert.NoFileExists(t, created)
+	assert.Equal(t, "No edits recorded.", journal.List())
+}
+
+func TestJournalUndoDelete(t *testing.T) {
+	ctx := context.Background()
+	client := &lsp.Client{}
+	journal := NewJournal()
+	dir := filepath.Join(t.TempDir(), "pkg")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package pkg\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.go"), []byte("package sub\n"), 0600))
+
+	entry := journal.Begin("delete_file")
+	_, err := DeleteFile(WithJournal(ctx, entry), client, dir, true, false)
+	require.NoError(t, err)
+	journal.Record(entry)
+	assert.Contains(t, journal.List(), filepath.Join(dir, "sub", "b.go")+" (deleted)")
+
+	_, err = UndoLastEdit(ctx, client, journal, false, false)
+	require.NoError(t, err)
+	info, err := os.Stat(filepath.Join(dir, "sub", "b.go"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.FileExists(t, filepath.Join(dir, "a.go"))
+}
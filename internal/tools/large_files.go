@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Lines read from large files are cut to this many bytes, as minified bundles can hold
+// megabytes on a single line
+const maxLargeFileLineBytes = 1000
+
+// fileTooLargeError is returned by readFile for files over the LargeFileBytes limit
+type fileTooLargeError struct {
+	size  int64
+	limit int64
+}
+
+func (e *fileTooLargeError) Error() string {
+	return fmt.Sprintf("file is %s, over the %s limit for reading whole files", formatSize(e.size), formatSize(e.limit))
+}
+
+// checkFileSize returns a fileTooLargeError for files over the LargeFileBytes of the options
+func (o *Options) checkFileSize(path string) error {
+	if o.LargeFileBytes <= 0 || protocol.IsNonFileURI(path) {
+		return nil
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > o.LargeFileBytes {
+		return &fileTooLargeError{size: info.Size(), limit: o.LargeFileBytes}
+	}
+	return nil
+}
+
+// formatLargeFile formats the lines around locations in a file that was too large to
+// read whole, without expanding them to the symbols enclosing them, after a note saying so
+func formatLargeFile(ctx context.Context, filePath string, locations []protocol.Location, contextLines int, tooLarge *fileTooLargeError) string {
+	note := "Note: only the lines around the results are shown, the " + tooLarge.Error() + "\n"
+
+	wanted := make(map[int]bool)
+	before, after := options(ctx).Context.window(contextLines)
+	for _, loc := range locations {
+		line := int(loc.Range.Start.Line)
+		for i := max(line-before, 0); i <= line+after; i++ {
+			wanted[i] = true
+		}
+	}
+	lines, err := readLines(filePath, wanted)
+	if err != nil {
+		return note + "\nError reading file: " + err.Error()
+	}
+
+	last := 0
+	found := make(map[int]bool, len(lines))
+	for line := range lines {
+		found[line] = true
+		last = max(last, line)
+	}
+	ranges := ConvertLinesToRanges(found, last+1)
+	return note + "\n" + formatLines(ctx, filePath, func(i int) string { return lines[i] }, ranges)
+}
+
+// readLines reads some 0-indexed lines of a file, without their line endings, scanning
+// the file only up to the last of them and keeping no other line in memory. Lines past
+// the end of the file are left out, and long lines are cut to maxLargeFileLineBytes.
+func readLines(path string, wanted map[int]bool) (map[int]string, error) {
+	last := -1
+	for line := range wanted {
+		last = max(last, line)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	lines := make(map[int]string, len(wanted))
+	for n := 0; n <= last; n++ {
+		text, ok, err := readLine(r, wanted[n])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if wanted[n] {
+			lines[n] = text
+		}
+	}
+	return lines, nil
+}
+
+// readLine reads the next line, returning its text if keep is set, and whether there was
+// a line before the end of the file
+func readLine(r *bufio.Reader, keep bool) (string, bool, error) {
+	var text []byte
+	read, cut := false, false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		read = read || len(chunk) > 0
+		if keep && !cut {
+			if room := maxLargeFileLineBytes - len(text); len(chunk) > room {
+				// Cut at the start of a character
+				for room > 0 && !utf8.RuneStart(chunk[room]) {
+					room--
+				}
+				text = append(text, chunk[:room]...)
+				cut = true
+			} else {
+				text = append(text, chunk...)
+			}
+		}
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF):
+			if !read {
+				return "", false, nil
+			}
+		case err != nil:
+			return "", false, err
+		}
+		break
+	}
+
+	line := strings.TrimSuffix(strings.TrimSuffix(string(text), "\n"), "\r")
+	if cut {
+		line += " ... (line cut)"
+	}
+	return line, true, nil
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.js")
+	long := strings.Repeat("é", maxLargeFileLineBytes)
+	require.NoError(t, os.WriteFile(path, []byte("one\r\ntwo\n"+long+"\nfour"), 0644))
+
+	lines, err := readLines(path, map[int]bool{1: true, 2: true, 3: true, 10: true})
+	require.NoError(t, err)
+	assert.Equal(t, "two", lines[1])
+	assert.Equal(t, strings.Repeat("é", maxLargeFileLineBytes/2)+" ... (line cut)", lines[2])
+	assert.Equal(t, "four", lines[3])
+	assert.NotContains(t, lines, 0)
+	assert.NotContains(t, lines, 10)
+}
+
+func TestLargeFileFormatting(t *testing.T) {
+	ctx := WithOptions(context.Background(), &Options{LargeFileBytes: 64})
+
+	path := filepath.Join(t.TempDir(), "generated.go")
+	var content strings.Builder
+	for i := 1; i <= 20; i++ {
+		content.WriteString("var x = 1 // line\n")
+	}
+	require.NoError(t, os.WriteFile(path, []byte(content.String()), 0644))
+
+	_, err := readFile(ctx, path)
+	var tooLarge *fileTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+
+	loc := protocol.Location{URI: protocol.URIFromPath(path), Range: protocol.Range{Start: protocol.Position{Line: 9}}}
+	result := formatLargeFile(ctx, path, []protocol.Location{loc}, 1, tooLarge)
+	assert.Equal(t, "Note: only the lines around the results are shown, the file is 360 B, over the 64 B limit for reading whole files\n\n"+
+		"```go\n 9|var x = 1 // line\n10|var x = 1 // line\n11|var x = 1 // line\n```\n", result)
+
+	source, err := ReadSource(ctx, nil, path, 19, 30, "")
+	require.NoError(t, err)
+	assert.Equal(t, "File: "+path+"\nLines: 19-20\n"+
+		"Note: only the lines requested were read, the file is 360 B, over the 64 B limit for reading whole files\n\n"+
+		"19|var x = 1 // line\n20|var x = 1 // line\n", source)
+
+	_, err = ReadSource(ctx, nil, path, 25, 0, "")
+	assert.Error(t, err)
+}
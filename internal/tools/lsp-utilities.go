@@ -3,12 +3,11 @@ package tools
 import (
 	"context"
 	"fmt"
-	"net/url"
-	"os"
 	"strings"
 
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
 )
 
 // Gets the full code block surrounding the start of the input location
@@ -60,19 +59,16 @@ func GetFullDefinition(ctx context.Context, client *lsp.Client, startLocation pr
 
 	if found {
 		// Convert URI to filesystem path
-		filePath, err := url.PathUnescape(strings.TrimPrefix(string(startLocation.URI), "file://"))
-		if err != nil {
-			return "", protocol.Location{}, fmt.Errorf("failed to unescape URI: %w", err)
-		}
+		filePath := startLocation.URI.Path()
 
 		// Read the file to get the full lines of the definition
 		// because we may have a start and end column
-		content, err := os.ReadFile(filePath)
+		content, err := readFile(ctx, filePath)
 		if err != nil {
 			return "", protocol.Location{}, fmt.Errorf("failed to read file: %w", err)
 		}
 
-		lines := strings.Split(string(content), "\n")
+		lines := utilities.SplitLines(string(content)).All()
 
 		// Extend start to beginning of line
 		symbolRange.Start.Character = 0
@@ -138,41 +134,35 @@ func GetFullDefinition(ctx context.Context, client *lsp.Client, startLocation pr
 	return "", protocol.Location{}, fmt.Errorf("symbol not found")
 }
 
-// GetLineRangesToDisplay determines which lines should be displayed for a set of locations
+// GetLineRangesToDisplay determines which lines should be displayed for a set of locations,
+// as chosen by the Context style
 func GetLineRangesToDisplay(ctx context.Context, client *lsp.Client, locations []protocol.Location, totalLines int, contextLines int) (map[int]bool, error) {
 	// Set to track which lines need to be displayed
 	linesToShow := make(map[int]bool)
+	before, after := options(ctx).Context.window(contextLines)
 
 	// For each location, get its container and add relevant lines
 	for _, loc := range locations {
-		// Use GetFullDefinition to find container
-		_, containerLoc, err := GetFullDefinition(ctx, client, loc)
-		if err != nil {
-			// If container not found, just use the location's line
-			refLine := int(loc.Range.Start.Line)
-			linesToShow[refLine] = true
-
-			// Add context lines
-			for i := refLine - contextLines; i <= refLine+contextLines; i++ {
-				if i >= 0 && i < totalLines {
-					linesToShow[i] = true
+		refLine := int(loc.Range.Start.Line)
+		linesToShow[refLine] = true
+
+		containerStart, containerEnd := 0, totalLines-1
+		if options(ctx).Context.Mode != "lines" {
+			// Use GetFullDefinition to find container
+			if _, containerLoc, err := GetFullDefinition(ctx, client, loc); err == nil {
+				containerStart = int(containerLoc.Range.Start.Line)
+				containerEnd = int(containerLoc.Range.End.Line)
+				linesToShow[containerStart] = true
+				if options(ctx).Context.Mode == "function" {
+					for i := containerStart; i <= containerEnd && i < totalLines; i++ {
+						linesToShow[i] = true
+					}
 				}
 			}
-			continue
 		}
 
-		// Add container start and end lines
-		containerStart := int(containerLoc.Range.Start.Line)
-		containerEnd := int(containerLoc.Range.End.Line)
-		linesToShow[containerStart] = true
-		// linesToShow[containerEnd] = true
-
-		// Add the reference line
-		refLine := int(loc.Range.Start.Line)
-		linesToShow[refLine] = true
-
-		// Add context lines around the reference
-		for i := refLine - contextLines; i <= refLine+contextLines; i++ {
+		// Add context lines around the reference, within its container if it has one
+		for i := refLine - before; i <= refLine+after; i++ {
 			if i >= 0 && i < totalLines && i >= containerStart && i <= containerEnd {
 				linesToShow[i] = true
 			}
@@ -182,7 +172,8 @@ func GetLineRangesToDisplay(ctx context.Context, client *lsp.Client, locations [
 	return linesToShow, nil
 }
 
-// ExtractLocationsFromDefinitionResult extracts a slice of Location from various Definition union types
+// ExtractLocationsFromDefinitionResult extracts a slice of Location from various Definition union types,
+// without duplicates and sorted by path and position
 func ExtractLocationsFromDefinitionResult(result any) ([]protocol.Location, error) {
 	var locations []protocol.Location
 
@@ -215,5 +206,5 @@ func ExtractLocationsFromDefinitionResult(result any) ([]protocol.Location, erro
 		return nil, fmt.Errorf("unexpected result type: %T", v)
 	}
 
-	return locations, nil
+	return uniqueLocations(locations), nil
 }
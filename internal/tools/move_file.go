@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// MoveFile moves or renames a file or directory and updates the code referring to it,
+// such as imports, with the edits the language server returns for a
+// workspace/willRenameFiles request. The edits are written first, as one transaction, then
+// the file is moved and the server is told with workspace/didRenameFiles. Servers that
+// don't handle file operations just see the file move. With dryRun set nothing is
+// changed and the unified diff of the edits is returned.
+func MoveFile(ctx context.Context, client *lsp.Client, oldPath, newPath string, dryRun bool) (string, error) {
+	info, err := os.Stat(oldPath)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %v", oldPath, err)
+	}
+	if _, err := os.Lstat(newPath); err == nil {
+		return "", fmt.Errorf("%s already exists", newPath)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("could not read %s: %v", newPath, err)
+	}
+	if oldPath == newPath || strings.HasPrefix(newPath, oldPath+string(filepath.Separator)) {
+		return "", fmt.Errorf("cannot move %s into itself", oldPath)
+	}
+
+	rename := protocol.RenameFilesParams{Files: []protocol.FileRename{{
+		OldURI: string(protocol.URIFromPath(oldPath)),
+		NewURI: string(protocol.URIFromPath(newPath)),
+	}}}
+	operations := client.FileOperations()
+
+	var edit protocol.WorkspaceEdit
+	if operations.WillRename != nil {
+		edit, err = client.WillRenameFiles(ctx, rename)
+		if err != nil {
+			return "", fmt.Errorf("failed to get the edits for the move: %v", err)
+		}
+	}
+	tx, err := utilities.StageWorkspaceEdit(edit, client.FileVersion, client.PositionEncoding())
+	if err != nil {
+		return "", fmt.Errorf("failed to apply changes: %v", err)
+	}
+
+	// Edits to the moved file are made before it moves, so list them at its new path
+	moved := func(path string) string {
+		if path == oldPath || strings.HasPrefix(path, oldPath+string(filepath.Separator)) {
+			return newPath + strings.TrimPrefix(path, oldPath)
+		}
+		return path
+	}
+	var updated strings.Builder
+	for _, path := range tx.Paths() {
+		fmt.Fprintf(&updated, "%s\n", moved(path))
+	}
+	kind := "file"
+	if info.IsDir() {
+		kind = "directory"
+	}
+
+	if dryRun {
+		if len(tx.Paths()) == 0 {
+			return fmt.Sprintf("Dry run, no files were changed. Moving %s %s to %s would not change any other files.", kind, oldPath, newPath), nil
+		}
+		return fmt.Sprintf("Dry run, no files were changed. Moving %s %s to %s would update %d files:\n%s\n%s",
+			kind, oldPath, newPath, len(tx.Paths()), updated.String(), tx.Diff()), nil
+	}
+
+	if err := prepareWrite(ctx, append(append(tx.Paths(), oldPath), movedFiles(oldPath, moved)...)...); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to apply changes: %v", err)
+	}
+	for _, path := range tx.Paths() {
+		fileWritten(ctx, client, moved(path))
+	}
+
+	// The server would otherwise keep the document open under its old name
+	if !info.IsDir() {
+		if err := client.CloseFile(ctx, oldPath); err != nil {
+			toolsLogger.Debug("Failed to close %s before moving it: %v", oldPath, err)
+		}
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		if len(tx.Paths()) > 0 {
+			return "", fmt.Errorf("failed to move %s, after updating %d files for the move: %v", oldPath, len(tx.Paths()), err)
+		}
+		return "", fmt.Errorf("failed to move %s: %v", oldPath, err)
+	}
+	options(ctx).Files.Invalidate(oldPath)
+	if !info.IsDir() {
+		fileWritten(ctx, client, newPath)
+	}
+
+	if operations.DidRename != nil {
+		if err := client.DidRenameFiles(ctx, rename); err != nil {
+			toolsLogger.Warn("Failed to notify the server of the move of %s: %v", oldPath, err)
+		}
+	}
+
+	if len(tx.Paths()) == 0 {
+		return fmt.Sprintf("Successfully moved %s %s to %s. No other files needed changes.", kind, oldPath, newPath), nil
+	}
+	return fmt.Sprintf("Successfully moved %s %s to %s.\nUpdated %d files:\n%s", kind, oldPath, newPath, len(tx.Paths()), updated.String()), nil
+}
+
+// movedFiles returns where the files at or under oldPath end up after a move
+func movedFiles(oldPath string, moved func(string) string) []string {
+	var paths []string
+	_ = filepath.WalkDir(oldPath, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			paths = append(paths, moved(path))
+		}
+		return nil
+	})
+	return paths
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveFile(t *testing.T) {
+	ctx := context.Background()
+	// A client whose server doesn't handle file operations, so no other files change
+	client := &lsp.Client{}
+
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "a.go")
+	require.NoError(t, os.WriteFile(oldPath, []byte("package a\n"), 0644))
+	newPath := filepath.Join(dir, "pkg", "b.go")
+
+	t.Run("dry run", func(t *testing.T) {
+		result, err := MoveFile(ctx, client, oldPath, newPath, true)
+		require.NoError(t, err)
+		assert.Contains(t, result, "Dry run, no files were changed.")
+		assert.FileExists(t, oldPath)
+		assert.NoFileExists(t, newPath)
+	})
+
+	t.Run("move", func(t *testing.T) {
+		result, err := MoveFile(ctx, client, oldPath, newPath, false)
+		require.NoError(t, err)
+		assert.Equal(t, "Successfully moved file "+oldPath+" to "+newPath+". No other files needed changes.", result)
+		assert.NoFileExists(t, oldPath)
+		content, err := os.ReadFile(newPath)
+		require.NoError(t, err)
+		assert.Equal(t, "package a\n", string(content))
+	})
+
+	t.Run("target exists", func(t *testing.T) {
+		other := filepath.Join(dir, "c.go")
+		require.NoError(t, os.WriteFile(other, []byte("package c\n"), 0644))
+		_, err := MoveFile(ctx, client, other, newPath, false)
+		assert.ErrorContains(t, err, "already exists")
+	})
+
+	t.Run("into itself", func(t *testing.T) {
+		_, err := MoveFile(ctx, client, filepath.Join(dir, "pkg"), filepath.Join(dir, "pkg", "sub"), false)
+		assert.ErrorContains(t, err, "into itself")
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := MoveFile(ctx, client, filepath.Join(dir, "missing.go"), filepath.Join(dir, "d.go"), false)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,52 @@
+package tools
+
+import "context"
+
+// DefaultLargeFileBytes is the LargeFileBytes of tool calls without options
+const DefaultLargeFileBytes = 16 << 20
+
+// Options are the settings and indexes the tools use that belong to a server. Each server
+// passes its own with the context of the tool calls, see WithOptions, so that servers in
+// the same process don't share them.
+type Options struct {
+	// LargeFileBytes is the size above which files, such as generated code and bundles,
+	// are not read whole to format results: readFile refuses them, and the tools showing
+	// lines around results read only those lines. Zero means no limit.
+	LargeFileBytes int64
+	// Context is the style of the lines shown around results by every tool
+	Context ContextStyle
+	// Files caches the files read while formatting results, so that the same files are
+	// not read again for every reference in them or on every call, or is nil to read them
+	// from disk every time. It is invalidated by the tools that write files, and should be
+	// by the workspace watcher. See NewFileCache.
+	Files *FileCache
+	// SymbolIndex is the symbol cache the tools record document and workspace symbols in,
+	// or nil when there is none. See OpenSymbolCache.
+	SymbolIndex *SymbolCache
+	// Fallback reads symbols from the syntax of files when the language server has none
+	// for them, typically because it doesn't handle their language, or is nil when there
+	// is none. See NewSyntacticFallback.
+	Fallback *SyntacticFallback
+	// Precomputed answers definition, references and hover from a precomputed SCIP or
+	// LSIF index, or is nil when there is none. See NewPrecomputedIndex.
+	Precomputed *PrecomputedIndex
+}
+
+// defaultOptions are the options of tool calls whose context has none
+var defaultOptions = &Options{LargeFileBytes: DefaultLargeFileBytes}
+
+type optionsKey struct{}
+
+// WithOptions returns a context in which the tools use opts. The options must not change
+// while calls use them.
+func WithOptions(ctx context.Context, opts *Options) context.Context {
+	return context.WithValue(ctx, optionsKey{}, opts)
+}
+
+// options returns the options of the context, or the defaults if it has none
+func options(ctx context.Context) *Options {
+	if opts, _ := ctx.Value(optionsKey{}).(*Options); opts != nil {
+		return opts
+	}
+	return defaultOptions
+}
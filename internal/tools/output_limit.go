@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Separator between the sections of a tool's output, most of which show one file
+const sectionSeparator = "\n---\n\n"
+
+var (
+	// Matches the "At: L19:C15, L24:C20" header listing the positions a section is about
+	atPositionRe = regexp.MustCompile(`L(\d+):C\d+`)
+	// Matches the count in the header of a section of references
+	referenceCountRe = regexp.MustCompile(`(?m)^References in File: (\d+)$`)
+)
+
+// outputSection is a section of a tool's output with its code block, if it has one
+type outputSection struct {
+	text string
+	// The text before and after the code, including the fences
+	head, tail string
+	code       []string
+	// Line numbers of the positions the section is about, from its "At:" header
+	hits map[int]bool
+}
+
+// LimitOutput keeps a tool's output within limit characters, or returns it unchanged if
+// limit is not positive. Output is made of sections separated by "---" lines, most of
+// them showing code around results in one file. The code of the longest sections is
+// shortened first, keeping the lines closest to the results. If that is not enough,
+// sections with code are left out from the last one up, and a note lists them with how
+// to fetch them. offset is the offset a paginated tool was called with, for the note to
+// say where the omitted references start.
+func LimitOutput(text string, limit, offset int) string {
+	if limit <= 0 || len(text) <= limit {
+		return text
+	}
+
+	var sections []*outputSection
+	for _, part := range strings.Split(text, sectionSeparator) {
+		sections = append(sections, parseSection(part))
+	}
+
+	// The note about omitted sections needs room too
+	budget := max(limit-200, limit/2)
+	length := func() int {
+		n := len(sectionSeparator) * (len(sections) - 1)
+		for _, s := range sections {
+			n += len(s.text)
+		}
+		return n
+	}
+
+	// Cap the length of every section at the largest cap that fits the budget, shortening
+	// the code of the sections longer than it
+	if excess := length() - budget; excess > 0 {
+		sorted := make([]int, len(sections))
+		for i, s := range sections {
+			sorted[i] = len(s.text)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+		capLength := 0
+		for i, cut := 0, 0; i < len(sorted); i++ {
+			next := 0
+			if i+1 < len(sorted) {
+				next = sorted[i+1]
+			}
+			// Cutting the i+1 longest sections down to next removes this much more
+			if cut+(i+1)*(sorted[i]-next) >= excess {
+				capLength = sorted[i] - (excess-cut+i)/(i+1)
+				break
+			}
+			cut += (i + 1) * (sorted[i] - next)
+		}
+		for _, s := range sections {
+			if len(s.text) > capLength {
+				s.shorten(capLength)
+			}
+		}
+	}
+
+	// Leave out sections with code from the end until the rest fits
+	var omitted []*outputSection
+	for i := len(sections) - 1; i > 0 && length() > budget; i-- {
+		if sections[i].code == nil {
+			continue
+		}
+		omitted = append([]*outputSection{sections[i]}, omitted...)
+		sections = append(sections[:i], sections[i+1:]...)
+	}
+
+	parts := make([]string, len(sections))
+	for i, s := range sections {
+		parts[i] = s.text
+	}
+	result := strings.Join(parts, sectionSeparator)
+	if len(omitted) > 0 {
+		result += sectionSeparator + omittedNote(sections, omitted, offset)
+	}
+	if len(result) > limit {
+		// Too many sections without code to leave any out, cut the output at a line break
+		cut := strings.LastIndex(result[:max(limit-100, limit/2)], "\n")
+		if cut < 0 {
+			cut = max(limit-100, limit/2)
+			for cut > 0 && !utf8.RuneStart(result[cut]) {
+				cut--
+			}
+		}
+		result = result[:cut] + fmt.Sprintf("\n\nOutput truncated: showing %d of %d characters", cut, len(text))
+	}
+	return result
+}
+
+// parseSection splits a section of output around its code block
+func parseSection(text string) *outputSection {
+	s := &outputSection{text: text}
+	start := strings.Index(text, "```")
+	if start < 0 || (start > 0 && text[start-1] != '\n') {
+		return s
+	}
+	bodyStart := strings.Index(text[start:], "\n")
+	if bodyStart < 0 {
+		// A fence at the end of the text opens no code block
+		return s
+	}
+	fenceEnd := start + strings.IndexFunc(text[start:], func(r rune) bool { return r != '`' })
+	fence := text[start:fenceEnd]
+	bodyStart += start + 1
+	end := strings.Index(text[bodyStart:], "\n"+fence+"\n")
+	if end < 0 {
+		return s
+	}
+	end += bodyStart
+
+	s.head = text[:bodyStart]
+	s.code = strings.Split(text[bodyStart:end], "\n")
+	s.tail = text[end:]
+	s.hits = make(map[int]bool)
+	for _, line := range strings.Split(s.head, "\n") {
+		if strings.HasPrefix(line, "At:") || strings.HasPrefix(line, "  L") {
+			for _, m := range atPositionRe.FindAllStringSubmatch(line, -1) {
+				n, _ := strconv.Atoi(m[1])
+				s.hits[n] = true
+			}
+		}
+	}
+	return s
+}
+
+// shorten cuts the code of a section down so that the section fits in length characters,
+// keeping the lines closest to its results, or the first lines if it has none. At least
+// the lines of the results themselves are kept.
+func (s *outputSection) shorten(length int) {
+	if s.code == nil {
+		return
+	}
+
+	type codeLine struct {
+		index, distance int
+	}
+	var lines []codeLine
+	for i, text := range s.code {
+		numberText, _, ok := strings.Cut(text, "|")
+		number, err := strconv.Atoi(strings.TrimSpace(numberText))
+		if !ok || err != nil {
+			// The "..." between ranges of lines
+			continue
+		}
+		distance := i
+		if len(s.hits) > 0 {
+			distance = -1
+			for hit := range s.hits {
+				if d := max(hit-number, number-hit); distance < 0 || d < distance {
+					distance = d
+				}
+			}
+		}
+		lines = append(lines, codeLine{i, distance})
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].distance < lines[j].distance })
+
+	kept := make(map[int]bool)
+	available := length - len(s.head) - len(s.tail) - len("...\n")
+	for n, line := range lines {
+		cost := len(s.code[line.index]) + len("\n...")
+		if available < cost && (n > 0 && line.distance > 0) {
+			break
+		}
+		available -= cost
+		kept[line.index] = true
+	}
+
+	var code []string
+	previous := -1
+	omitted := 0
+	for i, text := range s.code {
+		if !kept[i] {
+			if !strings.HasPrefix(text, "...") {
+				omitted++
+			}
+			continue
+		}
+		if previous >= 0 && i != previous+1 || previous < 0 && i > 0 {
+			code = append(code, "...")
+		}
+		code = append(code, text)
+		previous = i
+	}
+	if previous < len(s.code)-1 {
+		code = append(code, "...")
+	}
+	if omitted == 0 {
+		return
+	}
+	s.code = code
+	s.text = s.head + strings.Join(code, "\n") + s.tail
+}
+
+// omittedNote describes the sections left out of the output and how to fetch them
+func omittedNote(kept, omitted []*outputSection, offset int) string {
+	names := make([]string, len(omitted))
+	for i, s := range omitted {
+		name, _, _ := strings.Cut(s.text, "\n")
+		names[i] = strings.TrimPrefix(name, "File: ")
+	}
+	note := fmt.Sprintf("Output truncated: %d files omitted: %s.", len(omitted), strings.Join(names, ", "))
+
+	// References are paginated, so the omitted ones can be fetched with an offset, unless
+	// they are grouped by kind rather than listed in order
+	shown, paged := 0, offset >= 0
+	for _, s := range kept {
+		if strings.HasPrefix(s.text, "## ") {
+			paged = false
+		}
+		if m := referenceCountRe.FindStringSubmatch(s.text); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			shown += n
+		}
+	}
+	for _, s := range omitted {
+		if !referenceCountRe.MatchString(s.text) {
+			paged = false
+		}
+	}
+	if paged {
+		return note + fmt.Sprintf(" Use offset %d to see them.", offset+shown)
+	}
+	return note + " Read them with read_source, or narrow the query with include and exclude."
+}
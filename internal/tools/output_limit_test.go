@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// referenceSection builds a section of references output with the given number of code
+// lines and a reference on hit
+func referenceSection(path string, lines, hit int) string {
+	var code []string
+	for i := 1; i <= lines; i++ {
+		code = append(code, fmt.Sprintf("%d|\tline %d of %s", i, i, path))
+	}
+	return fmt.Sprintf("---\n\n%s\nReferences in File: 1\nAt: L%d:C2\n\n```go\n%s\n```\n", path, hit, strings.Join(code, "\n"))
+}
+
+func TestLimitOutput(t *testing.T) {
+	t.Run("within the limit", func(t *testing.T) {
+		text := referenceSection("/ws/a.go", 5, 3)
+		assert.Equal(t, text, LimitOutput(text, len(text), 0))
+		assert.Equal(t, text, LimitOutput(text, 0, 0))
+	})
+
+	t.Run("code is shortened around the results first", func(t *testing.T) {
+		text := strings.Join([]string{
+			referenceSection("/ws/a.go", 40, 30),
+			referenceSection("/ws/b.go", 3, 2),
+		}, "\n")
+		result := LimitOutput(text, len(text)/2, 0)
+
+		assert.LessOrEqual(t, len(result), len(text)/2)
+		assert.Contains(t, result, "30|\tline 30 of /ws/a.go")
+		assert.NotContains(t, result, "1|\tline 1 of /ws/a.go\n")
+		assert.Contains(t, result, "```go\n...\n")
+		// The short section is left alone
+		assert.Contains(t, result, referenceSection("/ws/b.go", 3, 2))
+		assert.NotContains(t, result, "omitted")
+	})
+
+	t.Run("files are omitted once code can't be shortened further", func(t *testing.T) {
+		var sections []string
+		for i := range 30 {
+			sections = append(sections, referenceSection(fmt.Sprintf("/ws/f%02d.go", i), 1, 1))
+		}
+		sections = append(sections, "---\n\nShowing 30 of 80 references. Use offset 40 to see more.")
+		text := strings.Join(sections, "\n")
+		result := LimitOutput(text, 2000, 10)
+
+		assert.LessOrEqual(t, len(result), 2000)
+		assert.Contains(t, result, "/ws/f00.go")
+		assert.Contains(t, result, "Showing 30 of 80 references")
+		assert.Contains(t, result, "files omitted: /ws/f")
+		assert.Contains(t, result, "/ws/f29.go.")
+
+		shown := strings.Count(result, "References in File: 1")
+		assert.Contains(t, result, fmt.Sprintf("Use offset %d to see them.", 10+shown))
+	})
+
+	t.Run("offsets are not suggested for grouped references", func(t *testing.T) {
+		var sections []string
+		sections = append(sections, "Summary: reads: 30", "---\n\n## reads (30)\n")
+		for i := range 30 {
+			sections = append(sections, referenceSection(fmt.Sprintf("/ws/f%02d.go", i), 1, 1))
+		}
+		result := LimitOutput(strings.Join(sections, "\n"), 2000, 0)
+
+		assert.LessOrEqual(t, len(result), 2000)
+		assert.Contains(t, result, "## reads (30)")
+		assert.NotContains(t, result, "Use offset")
+		assert.Contains(t, result, "Read them with read_source")
+	})
+
+	t.Run("output without sections is cut", func(t *testing.T) {
+		text := strings.Repeat("| caller | file.go | L1:C1 |\n", 200)
+		result := LimitOutput(text, 1000, 0)
+
+		assert.LessOrEqual(t, len(result), 1000)
+		assert.Regexp(t, fmt.Sprintf(`\| L1:C1 \|\n\nOutput truncated: showing \d+ of %d characters$`, len(text)), result)
+	})
+
+	t.Run("fence at the end of the output", func(t *testing.T) {
+		text := strings.Repeat("a", 300) + "\n```"
+		result := LimitOutput(text, 100, 0)
+
+		assert.LessOrEqual(t, len(result), 100)
+		assert.Contains(t, result, "Output truncated")
+	})
+}
@@ -0,0 +1,233 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// apiSymbol is an exported symbol of a package with its exported members
+type apiSymbol struct {
+	name    string
+	kind    protocol.SymbolKind
+	detail  string
+	path    string
+	line    int
+	members []*apiSymbol
+}
+
+// Kinds of symbols listed as members of an exported type
+var apiMemberKinds = []protocol.SymbolKind{
+	protocol.Method, protocol.Constructor, protocol.Function, protocol.Field, protocol.Property, protocol.EnumMember, protocol.Constant,
+}
+
+// Sections of a package's API, by the group of kinds of their symbols
+var apiSections = []struct{ title, group string }{
+	{"Types", "types"},
+	{"Functions", "functions"},
+	{"Variables and constants", "variables"},
+	{"Modules", "modules"},
+}
+
+// PackageAPI lists the exported API of a package: the exported types, with their exported
+// fields and methods, functions, variables and constants declared in the source files of
+// dir, with their signatures as the language server details them. Test files are left
+// out. With recursive, the files in the subdirectories of dir are included too. Whether a
+// symbol is exported follows the language's convention: a capital letter in Go, and no
+// leading underscore elsewhere.
+func PackageAPI(ctx context.Context, client *lsp.Client, root, dir string, recursive bool, filter PathFilter) (string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("could not read directory: %v", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+
+	var files []string
+	err = walkWorkspaceFiles(ctx, root, func(path string) error {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+		if !recursive && strings.Contains(rel, string(filepath.Separator)) {
+			return nil
+		}
+		if lsp.DetectLanguageID(path) == "" || isTestFile(path, root) || !filter.Allows(path) {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return fmt.Sprintf("No source files found in %s", dir), nil
+	}
+
+	var symbols []*apiSymbol
+	// Go declares methods outside of their types, possibly in other files of the package
+	methods := make(map[string][]*apiSymbol)
+	var note string
+	// The files whose symbols come from the fallback, by fallback
+	syntactic := make(map[string][]string)
+	for i, path := range files {
+		if err := ctx.Err(); err != nil {
+			note, err = partialResults(err, i, len(files))
+			if err != nil {
+				return "", err
+			}
+			break
+		}
+		rel, _ := filepath.Rel(dir, path)
+		fileSymbols, source := documentSymbols(ctx, client, protocol.URIFromPath(path))
+		if source != "" {
+			syntactic[source] = append(syntactic[source], filepath.ToSlash(rel))
+		}
+		for _, sym := range exportedSymbols(path, filepath.ToSlash(rel), fileSymbols) {
+			if receiver, method, ok := strings.Cut(sym.name, ")."); ok && strings.HasPrefix(receiver, "(") {
+				sym.name = method
+				receiver = strings.TrimLeft(receiver, "(*")
+				receiver, _, _ = strings.Cut(receiver, "[")
+				methods[receiver] = append(methods[receiver], sym)
+				continue
+			}
+			symbols = append(symbols, sym)
+		}
+	}
+
+	// Methods of unexported types are left out with their types
+	for _, sym := range symbols {
+		sym.members = append(sym.members, methods[sym.name]...)
+	}
+	if len(symbols) == 0 {
+		return fmt.Sprintf("No exported symbols found in %d files in %s", len(files), dir), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Exported API of %s (%s)\n", dir, pluralFiles(len(files)))
+	listed := make(map[*apiSymbol]bool)
+	for _, section := range apiSections {
+		var inSection []*apiSymbol
+		for _, sym := range symbols {
+			if !listed[sym] && slices.Contains(symbolKindGroups[section.group], sym.kind) {
+				inSection = append(inSection, sym)
+			}
+		}
+		writeAPISection(&b, section.title, inSection, listed)
+	}
+	var other []*apiSymbol
+	for _, sym := range symbols {
+		if !listed[sym] {
+			other = append(other, sym)
+		}
+	}
+	writeAPISection(&b, "Other", other, listed)
+
+	for source, paths := range syntactic {
+		fmt.Fprintf(&b, "\nThe language server had no symbols for %s, so theirs come from the syntax of the files (%s) and are approximate: %s\n",
+			pluralFiles(len(paths)), source, strings.Join(paths, ", "))
+	}
+	if note != "" {
+		b.WriteString("\n" + note + "\n")
+	}
+	return b.String(), nil
+}
+
+// writeAPISection writes a titled section of exported symbols, if there are any, and
+// marks them as listed
+func writeAPISection(b *strings.Builder, title string, symbols []*apiSymbol, listed map[*apiSymbol]bool) {
+	if len(symbols) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	for _, sym := range symbols {
+		listed[sym] = true
+		b.WriteString(formatAPISymbol(sym) + "\n")
+		for _, member := range sym.members {
+			b.WriteString("  " + formatAPISymbol(member) + "\n")
+		}
+	}
+}
+
+// formatAPISymbol formats a symbol as "Name (Kind): detail [file L12]"
+func formatAPISymbol(sym *apiSymbol) string {
+	text := fmt.Sprintf("%s (%s)", sym.name, protocol.TableKindMap[sym.kind])
+	if sym.detail != "" {
+		text += ": " + sym.detail
+	}
+	return text + fmt.Sprintf(" [%s L%d]", sym.path, sym.line)
+}
+
+// exportedSymbols returns the exported top level symbols of a file, with their exported
+// members, given its document symbols. rel is the path the symbols are shown with.
+func exportedSymbols(path, rel string, symbols []protocol.DocumentSymbolResult) []*apiSymbol {
+	var result []*apiSymbol
+	// Symbol information is flat, members name their container instead of being nested
+	byName := make(map[string]*apiSymbol)
+	var flatMembers []*protocol.SymbolInformation
+	for _, sym := range symbols {
+		switch s := sym.(type) {
+		case *protocol.DocumentSymbol:
+			if !isExported(path, s.Name) {
+				continue
+			}
+			top := &apiSymbol{name: s.Name, kind: s.Kind, detail: s.Detail, path: rel, line: int(s.SelectionRange.Start.Line) + 1}
+			for _, child := range s.Children {
+				if isExported(path, child.Name) && slices.Contains(apiMemberKinds, child.Kind) {
+					top.members = append(top.members, &apiSymbol{
+						name: child.Name, kind: child.Kind, detail: child.Detail, path: rel, line: int(child.SelectionRange.Start.Line) + 1,
+					})
+				}
+			}
+			result = append(result, top)
+		case *protocol.SymbolInformation:
+			if s.ContainerName != "" {
+				flatMembers = append(flatMembers, s)
+				continue
+			}
+			if !isExported(path, s.Name) {
+				continue
+			}
+			top := &apiSymbol{name: s.Name, kind: s.Kind, path: rel, line: int(s.Location.Range.Start.Line) + 1}
+			byName[s.Name] = top
+			result = append(result, top)
+		}
+	}
+	for _, s := range flatMembers {
+		if container, ok := byName[s.ContainerName]; ok && isExported(path, s.Name) && slices.Contains(apiMemberKinds, s.Kind) {
+			container.members = append(container.members, &apiSymbol{
+				name: s.Name, kind: s.Kind, path: rel, line: int(s.Location.Range.Start.Line) + 1,
+			})
+		}
+	}
+	return result
+}
+
+// isExported reports whether a symbol declared in the file at path is exported, by the
+// conventions of the file's language. Go methods named like (*Server).Start are exported
+// if the method is.
+func isExported(path, name string) bool {
+	if _, method, ok := strings.Cut(name, ")."); ok {
+		name = method
+	}
+	switch lsp.DetectLanguageID(path) {
+	case protocol.LangGo:
+		r, _ := utf8.DecodeRuneInString(name)
+		return unicode.IsUpper(r)
+	case protocol.LangPython:
+		// Special methods such as __init__ are part of a class's API
+		return !strings.HasPrefix(name, "_") || strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__")
+	default:
+		return name != "" && !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#")
+	}
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsExported(t *testing.T) {
+	assert.True(t, isExported("a.go", "Server"))
+	assert.False(t, isExported("a.go", "server"))
+	assert.True(t, isExported("a.go", "(*server).Start"))
+	assert.False(t, isExported("a.go", "(*Server).start"))
+	assert.True(t, isExported("a.py", "__init__"))
+	assert.False(t, isExported("a.py", "_helper"))
+	assert.True(t, isExported("a.ts", "render"))
+	assert.False(t, isExported("a.ts", "#count"))
+}
+
+func TestExportedSymbols(t *testing.T) {
+	at := func(line uint32) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: line}, End: protocol.Position{Line: line}}
+	}
+
+	symbols := []protocol.DocumentSymbolResult{
+		&protocol.DocumentSymbol{Name: "Server", Kind: protocol.Struct, Detail: "struct{...}", SelectionRange: at(4), Children: []protocol.DocumentSymbol{
+			{Name: "Addr", Kind: protocol.Field, Detail: "string", SelectionRange: at(5)},
+			{Name: "conn", Kind: protocol.Field, Detail: "net.Conn", SelectionRange: at(6)},
+		}},
+		&protocol.DocumentSymbol{Name: "(*Server).Start", Kind: protocol.Method, Detail: "func() error", SelectionRange: at(9)},
+		&protocol.DocumentSymbol{Name: "helper", Kind: protocol.Function, SelectionRange: at(12)},
+	}
+	result := exportedSymbols("server.go", "server.go", symbols)
+	require.Len(t, result, 2)
+	assert.Equal(t, "Server (Struct): struct{...} [server.go L5]", formatAPISymbol(result[0]))
+	require.Len(t, result[0].members, 1)
+	assert.Equal(t, "Addr (Field): string [server.go L6]", formatAPISymbol(result[0].members[0]))
+	assert.Equal(t, "(*Server).Start", result[1].name)
+
+	flat := []protocol.DocumentSymbolResult{
+		&protocol.SymbolInformation{Name: "Client", Kind: protocol.Class, Location: protocol.Location{Range: at(0)}},
+		&protocol.SymbolInformation{Name: "send", Kind: protocol.Method, ContainerName: "Client", Location: protocol.Location{Range: at(2)}},
+		&protocol.SymbolInformation{Name: "_retry", Kind: protocol.Method, ContainerName: "Client", Location: protocol.Location{Range: at(4)}},
+		&protocol.SymbolInformation{Name: "_internal", Kind: protocol.Function, Location: protocol.Location{Range: at(8)}},
+	}
+	result = exportedSymbols("client.py", "client.py", flat)
+	require.Len(t, result, 1)
+	assert.Equal(t, "Client", result[0].name)
+	require.Len(t, result[0].members, 1)
+	assert.Equal(t, "send (Method) [client.py L3]", formatAPISymbol(result[0].members[0]))
+}
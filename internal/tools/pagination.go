@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Pagination limits the results returned by tools that can produce large result sets.
+// Zero values mean no limit.
+type Pagination struct {
+	// MaxResults is the maximum number of results to return
+	MaxResults int
+	// Offset is the number of results to skip
+	Offset int
+	// MaxPerFile is the maximum number of results to return for a single file
+	MaxPerFile int
+}
+
+// paginateLocations sorts locations by file and position, then applies the pagination
+// window and per-file limit. It returns the locations to show and, if anything was left
+// out, a summary footer describing what was omitted.
+func paginateLocations(locations []protocol.Location, page Pagination, noun string) ([]protocol.Location, string) {
+	sorted := make([]protocol.Location, len(locations))
+	copy(sorted, locations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].URI != sorted[j].URI {
+			return sorted[i].URI < sorted[j].URI
+		}
+		return positionBefore(sorted[i].Range.Start, sorted[j].Range.Start)
+	})
+
+	total := len(sorted)
+	totalFiles := countFiles(sorted)
+
+	start := min(page.Offset, total)
+	end := total
+	if page.MaxResults > 0 && start+page.MaxResults < end {
+		end = start + page.MaxResults
+	}
+	window := sorted[start:end]
+
+	shown := window
+	truncatedInFiles := 0
+	if page.MaxPerFile > 0 {
+		shown = make([]protocol.Location, 0, len(window))
+		perFile := make(map[protocol.DocumentUri]int)
+		for _, loc := range window {
+			perFile[loc.URI]++
+			if perFile[loc.URI] > page.MaxPerFile {
+				truncatedInFiles++
+				continue
+			}
+			shown = append(shown, loc)
+		}
+	}
+
+	if len(shown) == total {
+		return shown, ""
+	}
+
+	footer := fmt.Sprintf("Showing %d of %d %s", len(shown), total, noun)
+	if omittedFiles := totalFiles - countFiles(shown); omittedFiles > 0 {
+		footer += fmt.Sprintf("; %d files omitted", omittedFiles)
+	}
+	if truncatedInFiles > 0 {
+		footer += fmt.Sprintf("; %d %s omitted by the per-file limit of %d", truncatedInFiles, noun, page.MaxPerFile)
+	}
+	if end < total {
+		footer += fmt.Sprintf(". Use offset %d to see more.", end)
+	}
+
+	return shown, footer
+}
+
+func countFiles(locations []protocol.Location) int {
+	files := make(map[protocol.DocumentUri]bool)
+	for _, loc := range locations {
+		files[loc.URI] = true
+	}
+	return len(files)
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginateLocations(t *testing.T) {
+	loc := func(uri string, line uint32) protocol.Location {
+		return protocol.Location{
+			URI:   protocol.DocumentUri(uri),
+			Range: protocol.Range{Start: protocol.Position{Line: line}},
+		}
+	}
+
+	// Deliberately out of order to check that results are sorted first
+	locations := []protocol.Location{
+		loc("file:///b.go", 3),
+		loc("file:///a.go", 7),
+		loc("file:///c.go", 1),
+		loc("file:///a.go", 2),
+		loc("file:///a.go", 5),
+	}
+
+	tests := []struct {
+		name           string
+		page           Pagination
+		expectedLines  []uint32
+		expectedFooter string
+	}{
+		{
+			name:          "no limits",
+			page:          Pagination{},
+			expectedLines: []uint32{2, 5, 7, 3, 1},
+		},
+		{
+			name:           "max results",
+			page:           Pagination{MaxResults: 2},
+			expectedLines:  []uint32{2, 5},
+			expectedFooter: "Showing 2 of 5 references; 2 files omitted. Use offset 2 to see more.",
+		},
+		{
+			name:           "offset",
+			page:           Pagination{MaxResults: 2, Offset: 2},
+			expectedLines:  []uint32{7, 3},
+			expectedFooter: "Showing 2 of 5 references; 1 files omitted. Use offset 4 to see more.",
+		},
+		{
+			name:           "last page",
+			page:           Pagination{MaxResults: 2, Offset: 4},
+			expectedLines:  []uint32{1},
+			expectedFooter: "Showing 1 of 5 references; 2 files omitted",
+		},
+		{
+			name:           "offset past end",
+			page:           Pagination{Offset: 10},
+			expectedLines:  []uint32{},
+			expectedFooter: "Showing 0 of 5 references; 3 files omitted",
+		},
+		{
+			name:           "per file limit",
+			page:           Pagination{MaxPerFile: 1},
+			expectedLines:  []uint32{2, 3, 1},
+			expectedFooter: "Showing 3 of 5 references; 2 references omitted by the per-file limit of 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shown, footer := paginateLocations(locations, tt.page, "references")
+			lines := make([]uint32, 0, len(shown))
+			for _, l := range shown {
+				lines = append(lines, l.Range.Start.Line)
+			}
+			assert.Equal(t, tt.expectedLines, lines)
+			assert.Equal(t, tt.expectedFooter, footer)
+		})
+	}
+}
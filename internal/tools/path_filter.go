@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// PathFilter scopes results to the files matching any Include glob and none of the Exclude
+// globs. An empty Include matches every file. Globs follow gitignore conventions:
+//   - a pattern ending in "/" matches a directory anywhere in the path, e.g. "vendor/"
+//   - a pattern without "/" matches the file name or any directory name, e.g. "*_test.go"
+//   - any other pattern is matched against the path relative to Root, and "**" matches
+//     any number of directories, e.g. "internal/**/*.go"
+//
+// Files, unless it is nil, further limits the filter to a set of absolute paths, such as
+// the files changed in git (see ChangedFiles).
+type PathFilter struct {
+	Root    string
+	Include []string
+	Exclude []string
+	Files   map[string]bool
+}
+
+// IsEmpty reports whether the filter lets every path through
+func (f PathFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && f.Files == nil
+}
+
+// Allows reports whether a file path passes the filter
+func (f PathFilter) Allows(path string) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	if f.Files != nil && !f.Files[filepath.Clean(path)] {
+		return false
+	}
+
+	rel := path
+	if f.Root != "" {
+		if r, inside := utilities.RelativePath(f.Root, path); inside {
+			rel = r
+		}
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, pattern := range f.Exclude {
+		if matchPathGlob(pattern, rel) {
+			return false
+		}
+	}
+
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, pattern := range f.Include {
+		if matchPathGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLocations returns the locations whose file passes the filter
+func (f PathFilter) filterLocations(locations []protocol.Location) []protocol.Location {
+	if f.IsEmpty() {
+		return locations
+	}
+
+	var filtered []protocol.Location
+	for _, loc := range locations {
+		if f.Allows(loc.URI.Path()) {
+			filtered = append(filtered, loc)
+		}
+	}
+	return filtered
+}
+
+// matchPathGlob matches a slash separated relative path against a glob
+func matchPathGlob(pattern, path string) bool {
+	pattern = filepath.ToSlash(pattern)
+	segments := strings.Split(path, "/")
+
+	// Directory pattern: match any directory in the path
+	if strings.HasSuffix(pattern, "/") {
+		dirPattern := strings.TrimSuffix(pattern, "/")
+		if strings.Contains(dirPattern, "/") {
+			dirs := strings.Join(segments[:len(segments)-1], "/")
+			return globToRegexp(strings.TrimPrefix(dirPattern, "/") + "/**").MatchString(dirs + "/")
+		}
+		re := globToRegexp(dirPattern)
+		for _, dir := range segments[:len(segments)-1] {
+			if re.MatchString(dir) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Name pattern: match the file name or any directory name
+	if !strings.Contains(pattern, "/") {
+		re := globToRegexp(pattern)
+		for _, segment := range segments {
+			if re.MatchString(segment) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return globToRegexp(strings.TrimPrefix(pattern, "/")).MatchString(path)
+}
+
+// globToRegexp converts a glob with "**", "*", "?" and "[...]" into an anchored regexp
+func globToRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		// Malformed character classes match nothing
+		return regexp.MustCompile(`[^\s\S]`)
+	}
+	return re
+}
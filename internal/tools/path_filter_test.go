@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPathGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"vendor/", "vendor/github.com/x/y.go", true},
+		{"vendor/", "internal/vendor/y.go", true},
+		{"vendor/", "vendor.go", false},
+		{"node_modules/", "web/node_modules/react/index.js", true},
+		{"*_test.go", "internal/tools/utilities_test.go", true},
+		{"*_test.go", "internal/tools/utilities.go", false},
+		{"internal", "internal/tools/x.go", true},
+		{"internal/**/*.go", "internal/tools/x.go", true},
+		{"internal/**/*.go", "internal/x.go", true},
+		{"internal/**/*.go", "cmd/internal/x.go", false},
+		{"internal/*.go", "internal/tools/x.go", false},
+		{"/cmd/**", "cmd/generate/main.go", true},
+		{"**/testdata/**", "a/b/testdata/c.txt", true},
+		{"internal/tools/", "internal/tools/x.go", true},
+		{"internal/tools/", "internal/lsp/x.go", false},
+		{"file?.go", "file1.go", true},
+		{"file[0-9].go", "file1.go", true},
+		{"file[!0-9].go", "file1.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchPathGlob(tt.pattern, tt.path))
+		})
+	}
+}
+
+func TestPathFilterAllows(t *testing.T) {
+	filter := PathFilter{
+		Root:    "/workspace",
+		Include: []string{"internal/**"},
+		Exclude: []string{"*_test.go"},
+	}
+
+	assert.True(t, filter.Allows("/workspace/internal/tools/x.go"))
+	assert.False(t, filter.Allows("/workspace/internal/tools/x_test.go"))
+	assert.False(t, filter.Allows("/workspace/main.go"))
+	assert.False(t, filter.Allows("/elsewhere/internal/x.go"))
+
+	assert.True(t, PathFilter{}.Allows("/anything/at/all.go"))
+	assert.True(t, PathFilter{Exclude: []string{"vendor/"}}.Allows("/workspace/main.go"))
+
+	changed := PathFilter{Root: "/workspace", Exclude: []string{"*_test.go"}, Files: map[string]bool{
+		"/workspace/main.go":      true,
+		"/workspace/main_test.go": true,
+	}}
+	assert.True(t, changed.Allows("/workspace/main.go"))
+	assert.False(t, changed.Allows("/workspace/main_test.go"))
+	assert.False(t, changed.Allows("/workspace/other.go"))
+	assert.False(t, PathFilter{Files: map[string]bool{}}.Allows("/workspace/main.go"))
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package tools
+
+import "github.com/isaacphi/mcp-language-server/internal/utilities"
+
+// pathKey returns a key identifying the file at path, the same through symlinks
+func pathKey(path string) string {
+	return utilities.CanonicalPath(path)
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package tools
+
+import (
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// pathKey returns a key identifying the file at path, the same through symlinks. Windows
+// file systems are case insensitive, so paths differing only in case name the same file.
+func pathKey(path string) string {
+	return strings.ToLower(utilities.CanonicalPath(path))
+}
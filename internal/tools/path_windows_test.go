@@ -0,0 +1,23 @@
+//go:build windows
+
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupLocationsByFileIgnoresCase(t *testing.T) {
+	locations := []protocol.Location{
+		{URI: protocol.URIFromPath(`C:\Project\main.go`)},
+		{URI: protocol.URIFromPath(`c:\project\MAIN.go`)},
+		{URI: protocol.URIFromPath(`C:\Project\other.go`)},
+	}
+
+	byFile := groupLocationsByFile(locations)
+	assert.Len(t, byFile, 2)
+	assert.Len(t, byFile[locations[0].URI], 2)
+	assert.Equal(t, `C:\Project\main.go`, locations[0].URI.Path())
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"unicode"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// Tools take and show 1-indexed character columns, while LSP positions count UTF-16 code
+// units or, with servers that support it, UTF-8 bytes. The helpers here convert between
+// the two using the line the position is on and the encoding of the client, and leave
+// the column unchanged if the file cannot be read.
+
+// fileLines caches the lines of files read while converting positions
+type fileLines struct {
+	encoding protocol.PositionEncodingKind
+	options  *Options
+	files    map[string][]string
+}
+
+// newFileLines returns a cache converting positions in the encoding of client, reading
+// files with the context's options
+func newFileLines(ctx context.Context, client *lsp.Client) fileLines {
+	return fileLines{encoding: client.PositionEncoding(), options: options(ctx), files: make(map[string][]string)}
+}
+
+// line returns a line of a file without its line ending, and whether it exists
+func (f fileLines) line(path string, line uint32) (string, bool) {
+	lines, ok := f.files[path]
+	if !ok {
+		if content, err := f.options.readFile(path); err == nil {
+			lines = utilities.SplitLines(string(content)).All()
+		}
+		f.files[path] = lines
+	}
+	if int(line) >= len(lines) {
+		return "", false
+	}
+	return lines[line], true
+}
+
+// position converts a 1-indexed line and character column in a file into an LSP position
+func (f fileLines) position(path string, line, column int) protocol.Position {
+	pos := protocol.Position{Line: uint32(line - 1), Character: uint32(column - 1)}
+	if text, ok := f.line(path, pos.Line); ok {
+		pos.Character = f.encoding.RuneToCharacter(text, column-1)
+	}
+	return pos
+}
+
+// column converts the character of an LSP position into a 1-indexed character column
+func (f fileLines) column(uri protocol.DocumentUri, pos protocol.Position) int {
+	if text, ok := f.line(uri.Path(), pos.Line); ok {
+		return f.encoding.CharacterToRune(text, pos.Character) + 1
+	}
+	return int(pos.Character) + 1
+}
+
+// lspPosition converts a 1-indexed line and character column in a file into an LSP position
+func lspPosition(ctx context.Context, client *lsp.Client, filePath string, line, column int) protocol.Position {
+	return newFileLines(ctx, client).position(filePath, line, column)
+}
+
+// SnapToIdentifier moves a 1-indexed column that doesn't land on an identifier to the
+// start of the nearest identifier on its line, the one before it on a tie, as columns
+// given by models are often off by one or point at the whitespace before a name. It
+// returns the column to use and, if it moved, a note saying which position was used.
+func SnapToIdentifier(ctx context.Context, filePath string, line, column int) (int, string) {
+	if line < 1 || column < 1 {
+		return column, ""
+	}
+	text, ok := newFileLines(ctx, nil).line(filePath, uint32(line-1))
+	if !ok {
+		return column, ""
+	}
+	runes := []rune(text)
+	at := column - 1
+	if at < len(runes) && isIdentifierRune(runes[at]) {
+		return column, ""
+	}
+
+	start := -1
+	for d := 1; start < 0 && (at-d >= 0 || at+d < len(runes)); d++ {
+		if i := at - d; i >= 0 && i < len(runes) && isIdentifierRune(runes[i]) {
+			start = i
+			for start > 0 && isIdentifierRune(runes[start-1]) {
+				start--
+			}
+		} else if i := at + d; i < len(runes) && isIdentifierRune(runes[i]) {
+			start = i
+		}
+	}
+	if start < 0 {
+		return column, ""
+	}
+	end := start
+	for end < len(runes) && isIdentifierRune(runes[end]) {
+		end++
+	}
+	note := fmt.Sprintf("Note: L%d:C%d is not on an identifier, used L%d:C%d (%s) instead", line, column, line, start+1, string(runes[start:end]))
+	return start + 1, note
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
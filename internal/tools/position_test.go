@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapToIdentifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("func  Foo(bar int) {\n\t}\n"), 0644))
+
+	tests := []struct {
+		name   string
+		line   int
+		column int
+		want   int
+		note   string
+	}{
+		{"on an identifier", 1, 8, 8, ""},
+		{"whitespace before a name", 1, 5, 1, "Note: L1:C5 is not on an identifier, used L1:C1 (func) instead"},
+		{"closer to the next name", 1, 6, 7, "Note: L1:C6 is not on an identifier, used L1:C7 (Foo) instead"},
+		{"just past a name", 1, 10, 7, "Note: L1:C10 is not on an identifier, used L1:C7 (Foo) instead"},
+		{"no identifier on the line", 2, 2, 2, ""},
+		{"past the end of the file", 5, 1, 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column, note := SnapToIdentifier(context.Background(), path, tt.line, tt.column)
+			assert.Equal(t, tt.want, column)
+			assert.Equal(t, tt.note, note)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/index"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// PrecomputedIndex is an index loaded from a file, used before or after the language
+// server
+type PrecomputedIndex struct {
+	index *index.Index
+	// prefer asks the index before the language server, rather than when the server
+	// fails or finds nothing
+	prefer bool
+}
+
+// NewPrecomputedIndex returns a precomputed index, reading the files it covers the way
+// the tools read them with a LargeFileBytes limit. The index is not changed, so that it
+// can be shared by precomputed indexes with different limits.
+func NewPrecomputedIndex(idx *index.Index, prefer bool, largeFileBytes int64) *PrecomputedIndex {
+	reader := *idx
+	reader.ReadFile = (&Options{LargeFileBytes: largeFileBytes}).readFile
+	return &PrecomputedIndex{index: &reader, prefer: prefer}
+}
+
+// first reports whether the index is asked before the language server
+func (p *PrecomputedIndex) first() bool {
+	return p != nil && p.prefer
+}
+
+// note marks the results of a tool that come from the index
+func (p *PrecomputedIndex) note() string {
+	return fmt.Sprintf("Note: these results come from the precomputed index %s rather than the language server. They reflect the files when the index was made and may be stale.\n\n", p.index.Path)
+}
+
+// references returns the references to the symbol at a position from the index, with
+// characters counted in encoding
+func (p *PrecomputedIndex) references(path string, pos protocol.Position, includeDeclaration bool, encoding protocol.PositionEncodingKind) []protocol.Location {
+	if p == nil {
+		return nil
+	}
+	return uniqueLocations(p.index.References(path, pos, includeDeclaration, encoding))
+}
+
+// hover returns the documentation of the symbol at a position from the index
+func (p *PrecomputedIndex) hover(path string, pos protocol.Position, encoding protocol.PositionEncodingKind) string {
+	if p == nil {
+		return ""
+	}
+	return p.index.Hover(path, pos, encoding)
+}
+
+// definitions shows the definitions of the symbols named symbolName in the index, or
+// returns an empty string if it has none. Names qualified as "Type.Method" or
+// "Type::Method" match the end of the symbols' containers.
+func (p *PrecomputedIndex) definitions(ctx context.Context, client *lsp.Client, symbolName string, filter PathFilter) string {
+	if p == nil {
+		return ""
+	}
+	wanted := strings.ReplaceAll(symbolName, "::", ".")
+	locs := p.index.Lookup(func(name, container string) bool {
+		qualified := name
+		if container != "" {
+			qualified = container + "." + name
+		}
+		if strings.Contains(wanted, ".") {
+			return qualified == wanted || strings.HasSuffix(qualified, "."+wanted)
+		}
+		return name == wanted
+	}, client.PositionEncoding())
+
+	var definitions []string
+	columns := newFileLines(ctx, client)
+	for _, loc := range locs {
+		if !filter.Allows(loc.URI.Path()) {
+			continue
+		}
+		loc = declaration(ctx, client, loc)
+		definition, err := ExtractTextFromLocation(ctx, loc, client.PositionEncoding())
+		if err != nil {
+			toolsLogger.Error("Error getting definition: %v", err)
+			continue
+		}
+		definitions = append(definitions, fmt.Sprintf(
+			"---\n\nSymbol: %s\nFile: %s\nRange: L%d:C%d - L%d:C%d\n\n%s\n",
+			symbolName,
+			loc.URI.Path(),
+			loc.Range.Start.Line+1,
+			columns.column(loc.URI, loc.Range.Start),
+			loc.Range.End.Line+1,
+			columns.column(loc.URI, loc.Range.End),
+			addLineNumbers(definition, int(loc.Range.Start.Line)+1),
+		))
+	}
+	if len(definitions) == 0 {
+		return ""
+	}
+	return p.note() + strings.Join(definitions, "")
+}
+
+// declaration widens the location of a symbol's name to the whole lines of the innermost
+// symbol declared around it, as the document symbols give it, or to the name's line
+func declaration(ctx context.Context, client *lsp.Client, loc protocol.Location) protocol.Location {
+	found := loc.Range
+	if content, err := readFile(ctx, loc.URI.Path()); err == nil {
+		if line, ok := utilities.SplitLines(string(content)).Line(int(found.End.Line)); ok {
+			found.End.Character = uint32(client.PositionEncoding().CharacterLen(line))
+		}
+	}
+	symbols, _ := documentSymbols(ctx, client, loc.URI)
+	for len(symbols) > 0 {
+		var inner []protocol.DocumentSymbolResult
+		for _, sym := range symbols {
+			if !containsPosition(sym.GetRange(), loc.Range.Start) {
+				continue
+			}
+			found = sym.GetRange()
+			if ds, ok := sym.(*protocol.DocumentSymbol); ok {
+				for i := range ds.Children {
+					inner = append(inner, &ds.Children[i])
+				}
+			}
+			break
+		}
+		symbols = inner
+	}
+	found.Start.Character = 0
+	return protocol.Location{URI: loc.URI, Range: found}
+}
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/index"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrecomputedIndex(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.go")
+	uri := string(protocol.URIFromPath(source))
+	dump := strings.Join([]string{
+		`{"id":1,"type":"vertex","label":"document","uri":"` + uri + `"}`,
+		`{"id":2,"type":"vertex","label":"range","start":{"line":0,"character":5},"end":{"line":0,"character":8}}`,
+		`{"id":3,"type":"vertex","label":"range","start":{"line":1,"character":0},"end":{"line":1,"character":3}}`,
+		`{"id":4,"type":"edge","label":"contains","outV":1,"inVs":[2,3]}`,
+		`{"id":5,"type":"vertex","label":"resultSet"}`,
+		`{"id":6,"type":"edge","label":"next","outV":2,"inV":5}`,
+		`{"id":7,"type":"edge","label":"next","outV":3,"inV":5}`,
+		`{"id":8,"type":"vertex","label":"referenceResult"}`,
+		`{"id":9,"type":"edge","label":"textDocument/references","outV":5,"inV":8}`,
+		`{"id":10,"type":"edge","label":"item","outV":8,"inVs":[2,3],"document":1}`,
+		`{"id":11,"type":"vertex","label":"hoverResult","result":{"contents":"func run()"}}`,
+		`{"id":12,"type":"edge","label":"textDocument/hover","outV":5,"inV":11}`,
+	}, "\n")
+	path := filepath.Join(dir, "dump.lsif")
+	require.NoError(t, os.WriteFile(path, []byte(dump), 0644))
+	idx, err := index.Load(path, dir)
+	require.NoError(t, err)
+
+	precomputed := NewPrecomputedIndex(idx, true, DefaultLargeFileBytes)
+	assert.True(t, precomputed.first())
+	assert.Len(t, precomputed.references(source, protocol.Position{Line: 1, Character: 1}, true, protocol.UTF16), 2)
+	assert.Equal(t, "func run()", precomputed.hover(source, protocol.Position{Line: 0, Character: 6}, protocol.UTF16))
+	assert.Contains(t, precomputed.note(), path)
+
+	var none *PrecomputedIndex
+	assert.False(t, none.first())
+	assert.Empty(t, none.references(source, protocol.Position{}, true, protocol.UTF16))
+	assert.Empty(t, none.hover(source, protocol.Position{}, protocol.UTF16))
+}
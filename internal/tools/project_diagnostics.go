@@ -0,0 +1,231 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// When the server can't report the diagnostics of the whole workspace, files are opened
+// diagnosticsBatchSize at a time, for up to maxDiagnosticsFiles files, and each batch is
+// given up to diagnosticsBatchWait to be analysed
+const (
+	diagnosticsBatchSize = 20
+	maxDiagnosticsFiles  = 1000
+	diagnosticsBatchWait = 3 * time.Second
+)
+
+// Maximum number of diagnostics listed after the summary
+const maxListedDiagnostics = 200
+
+// ProjectDiagnostics summarizes the diagnostics of every file under root, the workspace
+// directory, with counts by severity and by directory followed by the diagnostics from
+// most to least severe. The diagnostics are pulled with a workspace/diagnostic request if
+// the server supports it, and otherwise collected by opening the files in batches.
+func ProjectDiagnostics(ctx context.Context, client *lsp.Client, root string, filter PathFilter) (string, error) {
+	allows := func(path string) bool {
+		_, inside := utilities.RelativePath(root, path)
+		return inside && filter.Allows(path)
+	}
+
+	var collected map[protocol.DocumentUri][]protocol.Diagnostic
+	var note string
+	pulled := false
+	if client.SupportsWorkspaceDiagnostics() {
+		if _, err := client.PullWorkspaceDiagnostics(ctx); err != nil {
+			toolsLogger.Warn("Failed to pull workspace diagnostics, opening files instead: %v", err)
+		} else {
+			pulled = true
+		}
+	}
+	if !pulled {
+		var err error
+		collected, note, err = collectDiagnostics(ctx, client, root, allows)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Files that were open already, or that the server reported on its own, are covered
+	// by the cache
+	byFile := make(map[string][]protocol.Diagnostic)
+	for uri, diags := range client.GetAllDiagnostics() {
+		if uri.IsFile() && allows(uri.Path()) {
+			byFile[uri.Path()] = diags
+		}
+	}
+	for uri, diags := range collected {
+		byFile[uri.Path()] = diags
+	}
+
+	return formatProjectDiagnostics(root, byFile, note), nil
+}
+
+// collectDiagnostics opens the files under root that allows accepts and are not open
+// already, a batch at a time, and returns the diagnostics reported for them before they
+// are closed again. On timeout it returns the diagnostics collected so far with a note.
+func collectDiagnostics(ctx context.Context, client *lsp.Client, root string, allows func(path string) bool) (map[protocol.DocumentUri][]protocol.Diagnostic, string, error) {
+	var paths []string
+	var note string
+	err := walkWorkspaceFiles(ctx, root, func(path string) error {
+		if lsp.DetectLanguageID(path) == "" || !allows(path) || client.IsFileOpen(path) {
+			return nil
+		}
+		if len(paths) == maxDiagnosticsFiles {
+			note = fmt.Sprintf("---\n\nOnly the first %d files were checked", maxDiagnosticsFiles)
+			return filepath.SkipAll
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	collected := make(map[protocol.DocumentUri][]protocol.Diagnostic)
+	for start := 0; start < len(paths); start += diagnosticsBatchSize {
+		if err := ctx.Err(); err != nil {
+			timeoutNote, err := partialResults(err, start, len(paths))
+			if err != nil {
+				return nil, "", err
+			}
+			return collected, timeoutNote, nil
+		}
+
+		opened := make(map[protocol.DocumentUri]uint64)
+		for _, path := range paths[start:min(start+diagnosticsBatchSize, len(paths))] {
+			// Diagnostics cached from an earlier time the file was open may be stale
+			uri := protocol.URIFromPath(path)
+			client.ForgetDiagnostics(uri)
+			generation := client.DiagnosticsGeneration(uri)
+			if err := client.OpenFile(ctx, path); err != nil {
+				toolsLogger.Debug("Failed to open %s to collect its diagnostics: %v", path, err)
+				continue
+			}
+			opened[uri] = generation
+		}
+
+		waitForDiagnostics(ctx, client, opened, diagnosticsBatchWait)
+		for uri := range opened {
+			collected[uri] = client.GetFileDiagnostics(uri)
+			if err := client.CloseFile(ctx, uri.Path()); err != nil {
+				toolsLogger.Debug("Failed to close %s after collecting its diagnostics: %v", uri.Path(), err)
+			}
+		}
+	}
+	return collected, note, nil
+}
+
+// severityCounts counts diagnostics by severity
+type severityCounts [4]int
+
+func (c *severityCounts) add(severity protocol.DiagnosticSeverity) {
+	c[diagnosticSeverity(severity)-1]++
+}
+
+func (c severityCounts) String() string {
+	return fmt.Sprintf("%d errors, %d warnings, %d info, %d hints", c[0], c[1], c[2], c[3])
+}
+
+// diagnosticSeverity returns the severity of a diagnostic, taking a missing one as an
+// error like most editors do
+func diagnosticSeverity(severity protocol.DiagnosticSeverity) protocol.DiagnosticSeverity {
+	if severity < protocol.SeverityError || severity > protocol.SeverityHint {
+		return protocol.SeverityError
+	}
+	return severity
+}
+
+// formatProjectDiagnostics formats the diagnostics of files by path, with paths shown
+// relative to root
+func formatProjectDiagnostics(root string, byFile map[string][]protocol.Diagnostic, note string) string {
+	type fileDiagnostic struct {
+		path string
+		diag protocol.Diagnostic
+	}
+	var all []fileDiagnostic
+	var total severityCounts
+	byDir := make(map[string]*severityCounts)
+	files := 0
+	for path, diags := range byFile {
+		if len(diags) == 0 {
+			continue
+		}
+		files++
+		if rel, inside := utilities.RelativePath(root, path); inside {
+			path = filepath.ToSlash(rel)
+		}
+		dir := filepath.ToSlash(filepath.Dir(path))
+		if byDir[dir] == nil {
+			byDir[dir] = &severityCounts{}
+		}
+		for _, diag := range diags {
+			diag.Severity = diagnosticSeverity(diag.Severity)
+			total.add(diag.Severity)
+			byDir[dir].add(diag.Severity)
+			all = append(all, fileDiagnostic{path, diag})
+		}
+	}
+
+	if len(all) == 0 {
+		if note != "" {
+			return "No diagnostics found\n\n" + note
+		}
+		return "No diagnostics found"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Summary: %s in %d files\n\n", total, files)
+
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		// Directories with the most errors first, then the most warnings and so on
+		x, y := byDir[dirs[i]], byDir[dirs[j]]
+		for k := range x {
+			if x[k] != y[k] {
+				return x[k] > y[k]
+			}
+		}
+		return dirs[i] < dirs[j]
+	})
+	b.WriteString("| Directory | Errors | Warnings | Info | Hints |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, dir := range dirs {
+		c := byDir[dir]
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %d |\n", escapeTableCell(dir), c[0], c[1], c[2], c[3])
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		x, y := all[i], all[j]
+		if x.diag.Severity != y.diag.Severity {
+			return x.diag.Severity < y.diag.Severity
+		}
+		if x.path != y.path {
+			return x.path < y.path
+		}
+		return positionBefore(x.diag.Range.Start, y.diag.Range.Start)
+	})
+	b.WriteString("\n---\n\n")
+	for i, fd := range all {
+		if i == maxListedDiagnostics {
+			fmt.Fprintf(&b, "... and %d more\n", len(all)-maxListedDiagnostics)
+			break
+		}
+		fmt.Fprintf(&b, "%s: %s\n", fd.path, formatDiagnosticSummary(fd.diag))
+	}
+
+	if note != "" {
+		b.WriteString("\n" + note + "\n")
+	}
+	return b.String()
+}
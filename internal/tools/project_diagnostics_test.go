@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatProjectDiagnostics(t *testing.T) {
+	diag := func(line uint32, severity protocol.DiagnosticSeverity, message string) protocol.Diagnostic {
+		return protocol.Diagnostic{
+			Range:    protocol.Range{Start: protocol.Position{Line: line}},
+			Severity: severity,
+			Message:  message,
+		}
+	}
+
+	byFile := map[string][]protocol.Diagnostic{
+		"/project/main.go": {diag(4, protocol.SeverityWarning, "unused variable")},
+		"/project/internal/a.go": {
+			diag(9, protocol.SeverityError, "undefined: x"),
+			diag(2, protocol.SeverityHint, "simplify"),
+		},
+		"/project/internal/b.go": {diag(0, 0, "missing severity")},
+		"/project/clean.go":      {},
+	}
+
+	expected := `Summary: 2 errors, 1 warnings, 0 info, 1 hints in 3 files
+
+| Directory | Errors | Warnings | Info | Hints |
+| --- | --- | --- | --- | --- |
+| internal | 2 | 0 | 0 | 1 |
+| . | 0 | 1 | 0 | 0 |
+
+---
+
+internal/a.go: ERROR at L10:C1: undefined: x
+internal/b.go: ERROR at L1:C1: missing severity
+main.go: WARNING at L5:C1: unused variable
+internal/a.go: HINT at L3:C1: simplify
+`
+	assert.Equal(t, expected, formatProjectDiagnostics("/project", byFile, ""))
+
+	assert.Equal(t, "No diagnostics found", formatProjectDiagnostics("/project", map[string][]protocol.Diagnostic{}, ""))
+}
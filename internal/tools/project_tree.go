@@ -0,0 +1,185 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+)
+
+// DefaultTreeDepth is how many levels of directories ProjectTree shows unless told otherwise
+const DefaultTreeDepth = 3
+
+// Most files listed in one directory of a tree, the rest are summed up
+const maxTreeFiles = 50
+
+// TreeOptions changes what ProjectTree shows
+type TreeOptions struct {
+	// Depth is how many levels below the directory are shown, zero for all of them.
+	// Deeper files are counted in the directory containing them.
+	Depth int
+	// Packages lists the directories with source files in them instead of a tree
+	Packages bool
+}
+
+// treeNode is a directory of a project tree with the totals of the files under it
+type treeNode struct {
+	files    int
+	size     int64
+	children map[string]*treeNode
+	// The files directly in the directory, with their sizes
+	entries   map[string]int64
+	languages map[string]bool
+}
+
+func newTreeNode() *treeNode {
+	return &treeNode{children: make(map[string]*treeNode), entries: make(map[string]int64), languages: make(map[string]bool)}
+}
+
+// ProjectTree shows the files under dir, a directory in root, the workspace directory, so
+// that it can be explored before asking about symbols. Files the workspace watcher
+// ignores, such as gitignored ones, are left out, as are those not passing filter. It
+// shows either a tree of directories and files, with the number and size of the files in
+// each directory, or a list of the directories holding source files.
+func ProjectTree(ctx context.Context, root, dir string, opts TreeOptions, filter PathFilter) (string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("could not read directory: %v", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+
+	tree := newTreeNode()
+	// Walk the whole workspace so that its .gitignore applies in subdirectories too
+	err = walkWorkspaceFiles(ctx, root, func(path string) error {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || !filter.Allows(path) {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil
+		}
+		language := string(lsp.DetectLanguageID(path))
+
+		node := tree
+		parts := strings.Split(rel, string(filepath.Separator))
+		for _, part := range parts[:len(parts)-1] {
+			node.files++
+			node.size += info.Size()
+			child, ok := node.children[part]
+			if !ok {
+				child = newTreeNode()
+				node.children[part] = child
+			}
+			node = child
+		}
+		node.files++
+		node.size += info.Size()
+		node.entries[parts[len(parts)-1]] = info.Size()
+		if language != "" {
+			node.languages[language] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if tree.files == 0 {
+		return fmt.Sprintf("No files found in %s", dir), nil
+	}
+
+	var b strings.Builder
+	if opts.Packages {
+		fmt.Fprintf(&b, "%s (%s)\n\n", dir, treeTotals(tree))
+		writePackages(&b, tree, ".")
+		return b.String(), nil
+	}
+	fmt.Fprintf(&b, "%s%c (%s)\n", dir, filepath.Separator, treeTotals(tree))
+	writeTree(&b, tree, 1, opts.Depth)
+	return b.String(), nil
+}
+
+// writeTree writes the subdirectories and files of a node, indented by its depth
+func writeTree(b *strings.Builder, node *treeNode, depth, maxDepth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, name := range sortedKeys(node.children) {
+		child := node.children[name]
+		fmt.Fprintf(b, "%s%s/ (%s)\n", indent, name, treeTotals(child))
+		if maxDepth <= 0 || depth < maxDepth {
+			writeTree(b, child, depth+1, maxDepth)
+		}
+	}
+
+	files := sortedKeys(node.entries)
+	for _, name := range files[:min(len(files), maxTreeFiles)] {
+		fmt.Fprintf(b, "%s%s (%s)\n", indent, name, formatSize(node.entries[name]))
+	}
+	if len(files) > maxTreeFiles {
+		var rest int64
+		for _, name := range files[maxTreeFiles:] {
+			rest += node.entries[name]
+		}
+		fmt.Fprintf(b, "%s... and %d more files (%s)\n", indent, len(files)-maxTreeFiles, formatSize(rest))
+	}
+}
+
+// writePackages writes the directories under node, at path, that hold source files, with
+// the number and size of the files directly in them and their languages
+func writePackages(b *strings.Builder, node *treeNode, path string) {
+	if len(node.languages) > 0 {
+		var size int64
+		for _, s := range node.entries {
+			size += s
+		}
+		fmt.Fprintf(b, "%s (%s, %s, %s)\n", filepath.ToSlash(path), pluralFiles(len(node.entries)), formatSize(size),
+			strings.Join(sortedKeys(node.languages), ", "))
+	}
+	for _, name := range sortedKeys(node.children) {
+		writePackages(b, node.children[name], filepath.Join(path, name))
+	}
+}
+
+// treeTotals describes the number and size of the files under a node
+func treeTotals(node *treeNode) string {
+	return pluralFiles(node.files) + ", " + formatSize(node.size)
+}
+
+// pluralFiles formats a number of files
+func pluralFiles(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
+
+// formatSize formats a number of bytes for people, e.g. 512 B or 1.5 KB
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size)
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		value /= unit
+		if value < unit || suffix == "GB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return ""
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
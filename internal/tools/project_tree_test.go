@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectTree(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":               "build/\n",
+		"main.go":                  "package main\n",
+		"README.md":                strings.Repeat("x", 2048),
+		"internal/tools/a.go":      "package tools\n",
+		"internal/tools/b.go":      "package tools\n",
+		"internal/tools/deep/c.go": "package deep\n",
+		"build/out.go":             "package out\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	ctx := context.Background()
+
+	result, err := ProjectTree(ctx, root, root, TreeOptions{Depth: 2}, PathFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, root+string(filepath.Separator)+" (5 files, 2.1 KB)\n"+
+		"  internal/ (3 files, 41 B)\n"+
+		"    tools/ (3 files, 41 B)\n"+
+		"  README.md (2.0 KB)\n"+
+		"  main.go (13 B)\n", result)
+
+	t.Run("all levels", func(t *testing.T) {
+		result, err := ProjectTree(ctx, root, root, TreeOptions{}, PathFilter{})
+		require.NoError(t, err)
+		assert.Contains(t, result, "      deep/ (1 file, 13 B)\n        c.go (13 B)\n      a.go (14 B)\n")
+	})
+
+	t.Run("subdirectory", func(t *testing.T) {
+		dir := filepath.Join(root, "internal", "tools")
+		result, err := ProjectTree(ctx, root, dir, TreeOptions{Depth: 1}, PathFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, dir+string(filepath.Separator)+" (3 files, 41 B)\n  deep/ (1 file, 13 B)\n  a.go (14 B)\n  b.go (14 B)\n", result)
+	})
+
+	t.Run("packages", func(t *testing.T) {
+		result, err := ProjectTree(ctx, root, root, TreeOptions{Packages: true}, PathFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, root+" (5 files, 2.1 KB)\n\n"+
+			". (2 files, 2.0 KB, go, markdown)\n"+
+			"internal/tools (2 files, 28 B, go)\n"+
+			"internal/tools/deep (1 file, 13 B, go)\n", result)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		result, err := ProjectTree(ctx, root, root, TreeOptions{}, PathFilter{Root: root, Exclude: []string{"internal/"}})
+		require.NoError(t, err)
+		assert.NotContains(t, result, "internal")
+	})
+
+	t.Run("not a directory", func(t *testing.T) {
+		_, err := ProjectTree(ctx, root, filepath.Join(root, "main.go"), TreeOptions{}, PathFilter{})
+		assert.ErrorContains(t, err, "is not a directory")
+	})
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 KB", formatSize(1536))
+	assert.Equal(t, "3.0 MB", formatSize(3*1024*1024))
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// The interpreters of a virtualenv, relative to its directory, on Unix and on Windows
+var venvInterpreters = []string{
+	filepath.Join("bin", "python"),
+	filepath.Join("bin", "python3"),
+	filepath.Join("Scripts", "python.exe"),
+}
+
+// ResolvePythonInterpreter returns the interpreter a path names: the path itself if it is
+// a file, or the interpreter of the virtualenv if it is a directory, such as .venv
+func ResolvePythonInterpreter(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %v", path, err)
+	}
+	if !info.IsDir() {
+		return path, nil
+	}
+	for _, name := range venvInterpreters {
+		interpreter := filepath.Join(path, name)
+		if info, err := os.Stat(interpreter); err == nil && !info.IsDir() {
+			return interpreter, nil
+		}
+	}
+	return "", fmt.Errorf("%s is a directory without a Python interpreter, expected a virtualenv with bin/python or Scripts/python.exe", path)
+}
+
+// pythonInfoScript prints what DescribePythonInterpreter shows as JSON
+const pythonInfoScript = `import json, sys, sysconfig
+print(json.dumps({"executable": sys.executable, "version": sys.version.split()[0], "prefix": sys.prefix, "venv": sys.prefix != sys.base_prefix, "sitePackages": sysconfig.get_paths()["purelib"]}))`
+
+// pythonInfo is what a Python interpreter reports about itself
+type pythonInfo struct {
+	Executable   string `json:"executable"`
+	Version      string `json:"version"`
+	Prefix       string `json:"prefix"`
+	Venv         bool   `json:"venv"`
+	SitePackages string `json:"sitePackages"`
+}
+
+// DescribePythonInterpreter runs a Python interpreter to report its version, whether it
+// belongs to a virtualenv and where its packages are installed, which is where the
+// language server looks for the sources of imported packages
+func DescribePythonInterpreter(ctx context.Context, interpreter string) (string, error) {
+	cmd := exec.CommandContext(ctx, interpreter, "-c", pythonInfoScript)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("failed to run %s: %s", interpreter, msg)
+		}
+		return "", fmt.Errorf("failed to run %s: %v", interpreter, err)
+	}
+	var info pythonInfo
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		return "", fmt.Errorf("unexpected output from %s: %v", interpreter, err)
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Interpreter: %s\n", interpreter)
+	if info.Executable != "" && info.Executable != interpreter {
+		fmt.Fprintf(&result, "Executable: %s\n", info.Executable)
+	}
+	fmt.Fprintf(&result, "Version: Python %s\n", info.Version)
+	if info.Venv {
+		fmt.Fprintf(&result, "Virtualenv: %s\n", info.Prefix)
+	} else {
+		fmt.Fprintf(&result, "Virtualenv: none, prefix %s\n", info.Prefix)
+	}
+	fmt.Fprintf(&result, "Site-packages: %s\n", info.SitePackages)
+	return result.String(), nil
+}
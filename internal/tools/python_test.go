@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePythonInterpreter(t *testing.T) {
+	dir := t.TempDir()
+	venv := filepath.Join(dir, ".venv")
+	interpreter := filepath.Join(venv, "bin", "python3")
+	require.NoError(t, os.MkdirAll(filepath.Dir(interpreter), 0755))
+	require.NoError(t, os.WriteFile(interpreter, nil, 0755))
+
+	resolved, err := ResolvePythonInterpreter(venv)
+	require.NoError(t, err)
+	assert.Equal(t, interpreter, resolved)
+
+	resolved, err = ResolvePythonInterpreter(interpreter)
+	require.NoError(t, err)
+	assert.Equal(t, interpreter, resolved)
+
+	_, err = ResolvePythonInterpreter(dir)
+	assert.ErrorContains(t, err, "without a Python interpreter")
+	_, err = ResolvePythonInterpreter(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestDescribePythonInterpreter(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 is not installed")
+	}
+	text, err := DescribePythonInterpreter(context.Background(), python)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Interpreter: "+python+"\n")
+	assert.Contains(t, text, "Version: Python 3.")
+	assert.Contains(t, text, "Site-packages: ")
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+)
+
+// FileReadRequest selects a slice of a file for ReadFiles. Lines are 1-indexed and
+// inclusive; SymbolName selects the full range of a symbol instead of a line range.
+type FileReadRequest struct {
+	FilePath   string `json:"filePath"`
+	StartLine  int    `json:"startLine,omitempty"`
+	EndLine    int    `json:"endLine,omitempty"`
+	SymbolName string `json:"symbolName,omitempty"`
+}
+
+// ReadFiles reads several files, or slices of them, in one call. All file contents are
+// captured before any symbol is resolved or output is formatted, so the result reflects a
+// single point in time. Each file is returned with the document version the language
+// server has for it and a hash of its contents, so that later reads can detect changes.
+// Files over the LargeFileBytes limit are not read whole, so only the lines returned are
+// read, when they are formatted, and they come without a hash. The caller is responsible for holding off concurrent edits while this runs.
+func ReadFiles(ctx context.Context, client *lsp.Client, requests []FileReadRequest) (string, error) {
+	if len(requests) == 0 {
+		return "", fmt.Errorf("no files requested")
+	}
+
+	type snapshot struct {
+		content  []byte
+		version  string
+		hash     string
+		tooLarge *fileTooLargeError
+		err      error
+	}
+
+	// Capture every file first. A file requested more than once is read only once
+	// so that all of its slices come from the same contents.
+	snapshots := make(map[string]*snapshot)
+	for _, req := range requests {
+		if _, ok := snapshots[req.FilePath]; ok {
+			continue
+		}
+
+		snap := &snapshot{}
+		if err := options(ctx).checkFileSize(req.FilePath); errors.As(err, &snap.tooLarge) {
+			snap.version = fileVersion(client, req.FilePath)
+			snapshots[req.FilePath] = snap
+			continue
+		}
+		snap.content, snap.err = os.ReadFile(req.FilePath)
+		if snap.err == nil {
+			sum := sha256.Sum256(snap.content)
+			snap.hash = hex.EncodeToString(sum[:])[:16]
+			snap.version = fileVersion(client, req.FilePath)
+		}
+		snapshots[req.FilePath] = snap
+	}
+
+	var results []string
+	for _, req := range requests {
+		snap := snapshots[req.FilePath]
+		if snap.err != nil {
+			results = append(results, fmt.Sprintf("---\n\nFile: %s\nError reading file: %v\n", req.FilePath, snap.err))
+			continue
+		}
+		if snap.tooLarge != nil {
+			text, err := readLargeSource(ctx, client, req.FilePath, req.StartLine, req.EndLine, req.SymbolName, snap.tooLarge)
+			if err != nil {
+				results = append(results, fmt.Sprintf("---\n\nFile: %s\nError: %v\n", req.FilePath, err))
+				continue
+			}
+			header, body, _ := strings.Cut(text, "\n\n")
+			results = append(results, fmt.Sprintf("---\n\n%s\nVersion: %s\n\n%s", header, snap.version, body))
+			continue
+		}
+
+		header, body, err := formatSource(ctx, client, req.FilePath, snap.content, req.StartLine, req.EndLine, req.SymbolName)
+		if err != nil {
+			results = append(results, fmt.Sprintf("---\n\nFile: %s\nError: %v\n", req.FilePath, err))
+			continue
+		}
+
+		header += fmt.Sprintf("Version: %s\nSHA256: %s\n", snap.version, snap.hash)
+		results = append(results, "---\n\n"+header+"\n"+body)
+	}
+
+	return strings.Join(results, "\n"), nil
+}
+
+// fileVersion returns the document version the language server has for a file, for
+// ReadFiles to show
+func fileVersion(client *lsp.Client, filePath string) string {
+	if version, ok := client.FileVersion(filePath); ok {
+		return fmt.Sprintf("%d", version)
+	}
+	return "not open in language server"
+}
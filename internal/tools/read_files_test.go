@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFilesMixedBatch(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(main, []byte("package main\n\nfunc main() {}\n"), 0644))
+	missing := filepath.Join(dir, "missing.go")
+
+	result, err := ReadFiles(context.Background(), &lsp.Client{}, []FileReadRequest{
+		{FilePath: main, StartLine: 3},
+		{FilePath: missing},
+		{FilePath: main, StartLine: 10},
+		{FilePath: main, EndLine: 1},
+	})
+	require.NoError(t, err)
+
+	files := strings.Split(result, "---\n\n")[1:]
+	require.Len(t, files, 4)
+	assert.Equal(t, "File: "+main+"\nLines: 3-3 of 3\nVersion: not open in language server\nSHA256: 55a60bb97151b2b4\n\n3|func main() {}\n\n", files[0])
+	assert.Contains(t, files[1], "File: "+missing+"\nError reading file: ")
+	assert.Equal(t, "File: "+main+"\nError: startLine 10 is past the end of the file (3 lines)\n\n", files[2])
+	assert.Equal(t, "File: "+main+"\nLines: 1-1 of 3\nVersion: not open in language server\nSHA256: 55a60bb97151b2b4\n\n1|package main\n", files[3])
+
+	_, err = ReadFiles(context.Background(), &lsp.Client{}, nil)
+	assert.Error(t, err)
+}
+
+func TestReadFilesLimits(t *testing.T) {
+	dir := t.TempDir()
+	var content strings.Builder
+	for i := 1; i <= maxReadSourceLines+10; i++ {
+		content.WriteString("var x = 1\n")
+	}
+	long := filepath.Join(dir, "long.go")
+	require.NoError(t, os.WriteFile(long, []byte(content.String()), 0644))
+
+	// Files are cut to maxReadSourceLines lines
+	result, err := ReadFiles(context.Background(), &lsp.Client{}, []FileReadRequest{{FilePath: long}})
+	require.NoError(t, err)
+	assert.Contains(t, result, fmt.Sprintf("Lines: 1-%d of %d\n", maxReadSourceLines, maxReadSourceLines+10))
+	assert.Contains(t, result, fmt.Sprintf("Output truncated to %d lines", maxReadSourceLines))
+	assert.Contains(t, result, fmt.Sprintf("%d|var x = 1", maxReadSourceLines))
+	assert.NotContains(t, result, fmt.Sprintf("%d|var x = 1", maxReadSourceLines+1))
+
+	// Files over the LargeFileBytes limit are read only where requested, without a hash
+	small := filepath.Join(dir, "small.go")
+	require.NoError(t, os.WriteFile(small, []byte("package small\n"), 0644))
+	ctx := WithOptions(context.Background(), &Options{LargeFileBytes: 64})
+	result, err = ReadFiles(ctx, &lsp.Client{}, []FileReadRequest{
+		{FilePath: long, StartLine: 5, EndLine: 6},
+		{FilePath: small},
+	})
+	require.NoError(t, err)
+	files := strings.Split(result, "---\n\n")[1:]
+	require.Len(t, files, 2)
+	assert.Equal(t, "File: "+long+"\nLines: 5-6\n"+
+		"Note: only the lines requested were read, the file is 9.9 KB, over the 64 B limit for reading whole files\n"+
+		"Version: not open in language server\n\n5|var x = 1\n6|var x = 1\n\n", files[0])
+	assert.Contains(t, files[1], "SHA256: ")
+}
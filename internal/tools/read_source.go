@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// Maximum number of lines returned by a single read_source call
+const maxReadSourceLines = 1000
+
+// ReadSource returns a slice of a file with line numbers. The slice is either the 1-indexed,
+// inclusive range startLine-endLine or, if symbolName is set, the full range of that symbol
+// as reported by the language server's document symbols.
+func ReadSource(ctx context.Context, client *lsp.Client, filePath string, startLine, endLine int, symbolName string) (string, error) {
+	content, err := readFile(ctx, filePath)
+	var tooLarge *fileTooLargeError
+	if errors.As(err, &tooLarge) {
+		return readLargeSource(ctx, client, filePath, startLine, endLine, symbolName, tooLarge)
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not read file: %v", err)
+	}
+
+	header, body, err := formatSource(ctx, client, filePath, content, startLine, endLine, symbolName)
+	if err != nil {
+		return "", err
+	}
+	return header + "\n" + body, nil
+}
+
+// formatSource returns the header and numbered lines of a slice of content
+func formatSource(ctx context.Context, client *lsp.Client, filePath string, content []byte, startLine, endLine int, symbolName string) (string, string, error) {
+	lines := utilities.SplitLines(string(content)).Text()
+
+	if symbolName != "" {
+		symbolRange, _, err := findDocumentSymbol(ctx, client, filePath, symbolName)
+		if err != nil {
+			return "", "", err
+		}
+		startLine = int(symbolRange.Start.Line) + 1
+		endLine = int(symbolRange.End.Line) + 1
+	}
+
+	if startLine <= 0 {
+		startLine = 1
+	}
+	if endLine <= 0 || endLine > len(lines) {
+		endLine = len(lines)
+	}
+	if startLine > len(lines) {
+		return "", "", fmt.Errorf("startLine %d is past the end of the file (%d lines)", startLine, len(lines))
+	}
+	if endLine < startLine {
+		return "", "", fmt.Errorf("endLine %d is before startLine %d", endLine, startLine)
+	}
+
+	truncated := false
+	if endLine-startLine+1 > maxReadSourceLines {
+		endLine = startLine + maxReadSourceLines - 1
+		truncated = true
+	}
+
+	header := fmt.Sprintf("File: %s\n", filePath)
+	if symbolName != "" {
+		header += fmt.Sprintf("Symbol: %s\n", symbolName)
+	}
+	header += fmt.Sprintf("Lines: %d-%d of %d\n", startLine, endLine, len(lines))
+	if truncated {
+		header += fmt.Sprintf("Output truncated to %d lines, request a later startLine to read more\n", maxReadSourceLines)
+	}
+
+	text := strings.Join(lines[startLine-1:endLine], "\n")
+	return header, addLineNumbers(text, startLine), nil
+}
+
+// readLargeSource is ReadSource for files too large to read whole, which reads only the
+// lines returned and so can't tell how many lines the file has
+func readLargeSource(ctx context.Context, client *lsp.Client, filePath string, startLine, endLine int, symbolName string, tooLarge *fileTooLargeError) (string, error) {
+	if symbolName != "" {
+		symbolRange, _, err := findDocumentSymbol(ctx, client, filePath, symbolName)
+		if err != nil {
+			return "", err
+		}
+		startLine = int(symbolRange.Start.Line) + 1
+		endLine = int(symbolRange.End.Line) + 1
+	}
+
+	if startLine <= 0 {
+		startLine = 1
+	}
+	if endLine > 0 && endLine < startLine {
+		return "", fmt.Errorf("endLine %d is before startLine %d", endLine, startLine)
+	}
+	truncated := false
+	if endLine <= 0 || endLine-startLine+1 > maxReadSourceLines {
+		truncated = true
+		endLine = startLine + maxReadSourceLines - 1
+	}
+
+	wanted := make(map[int]bool, endLine-startLine+1)
+	for i := startLine - 1; i < endLine; i++ {
+		wanted[i] = true
+	}
+	found, err := readLines(filePath, wanted)
+	if err != nil {
+		return "", fmt.Errorf("could not read file: %v", err)
+	}
+	if len(found) == 0 {
+		return "", fmt.Errorf("startLine %d is past the end of the file", startLine)
+	}
+	lines := make([]string, 0, len(found))
+	for i := startLine - 1; i < endLine; i++ {
+		line, ok := found[i]
+		if !ok {
+			truncated = false
+			break
+		}
+		lines = append(lines, line)
+	}
+	endLine = startLine + len(lines) - 1
+
+	header := fmt.Sprintf("File: %s\n", filePath)
+	if symbolName != "" {
+		header += fmt.Sprintf("Symbol: %s\n", symbolName)
+	}
+	header += fmt.Sprintf("Lines: %d-%d\n", startLine, endLine)
+	header += "Note: only the lines requested were read, the " + tooLarge.Error() + "\n"
+	if truncated {
+		header += fmt.Sprintf("Output truncated to %d lines, request a later startLine to read more\n", maxReadSourceLines)
+	}
+	return header + "\n" + addLineNumbers(strings.Join(lines, "\n"), startLine), nil
+}
@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSourceLineRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {\n\tprintln(1)\n}\n"), 0644))
+
+	tests := []struct {
+		name      string
+		startLine int
+		endLine   int
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:      "middle of file",
+			startLine: 3,
+			endLine:   4,
+			expected:  "File: " + path + "\nLines: 3-4 of 5\n\n3|func main() {\n4|\tprintln(1)\n",
+		},
+		{
+			name:     "defaults to whole file",
+			expected: "File: " + path + "\nLines: 1-5 of 5\n\n1|package main\n2|\n3|func main() {\n4|\tprintln(1)\n5|}\n",
+		},
+		{
+			name:      "end clamped to file length",
+			startLine: 5,
+			endLine:   50,
+			expected:  "File: " + path + "\nLines: 5-5 of 5\n\n5|}\n",
+		},
+		{
+			name:      "start past end of file",
+			startLine: 10,
+			expectErr: true,
+		},
+		{
+			name:      "end before start",
+			startLine: 4,
+			endLine:   2,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ReadSource(context.Background(), nil, path, tt.startLine, tt.endLine, "")
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestReadSourceCRLF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\r\n\r\nfunc main() {}\r\n"), 0644))
+
+	result, err := ReadSource(context.Background(), nil, path, 0, 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, "File: "+path+"\nLines: 1-3 of 3\n\n1|package main\n2|\n3|func main() {}\n", result)
+}
@@ -0,0 +1,333 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// ExtractKinds are the things Extract can extract code into, with the code action kind
+// servers offer each as
+var ExtractKinds = map[string]protocol.CodeActionKind{
+	"function": protocol.RefactorExtract + ".function",
+	"method":   protocol.RefactorExtract + ".method",
+	"variable": protocol.RefactorExtract + ".variable",
+	"constant": protocol.RefactorExtract + ".constant",
+}
+
+// Matches identifiers in code
+var identifierRe = regexp.MustCompile(`[\p{L}_][\p{L}\p{N}_]*`)
+
+// Selection is a range of a file given by 1-indexed lines and character columns. The
+// end column is exclusive, and zero selects up to the end of the end line.
+type Selection struct {
+	StartLine, StartColumn int
+	EndLine, EndColumn     int
+}
+
+// lspRange converts a selection in a file into an LSP range in the encoding of client
+func (sel Selection) lspRange(ctx context.Context, client *lsp.Client, filePath string) protocol.Range {
+	lines := newFileLines(ctx, client)
+	end := lines.position(filePath, sel.EndLine, max(sel.EndColumn, 1))
+	if sel.EndColumn <= 0 {
+		text, _ := lines.line(filePath, end.Line)
+		end.Character = uint32(lines.encoding.CharacterLen(text))
+	}
+	return protocol.Range{Start: lines.position(filePath, sel.StartLine, max(sel.StartColumn, 1)), End: end}
+}
+
+// refactoring is a code action chosen to refactor code, with the others of its kind the
+// server offered
+type refactoring struct {
+	action protocol.CodeAction
+	others []string
+}
+
+// Extract extracts the code in a range of a file into a new function, method, variable or
+// constant, using the refactoring the language server offers for it, and names it
+// newName if given rather than the placeholder name the server picks. If the server
+// offers several extractions of the kind, such as into different scopes, the first one
+// whose title contains title is applied. With dryRun set no files are written and the
+// unified diff of the refactoring is returned.
+func Extract(ctx context.Context, client *lsp.Client, filePath string, sel Selection, kind, newName, title string, dryRun bool) (string, error) {
+	actionKind, ok := ExtractKinds[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown kind %q, expected function, method, variable or constant", kind)
+	}
+
+	// Some servers offer every extraction as refactor.extract and tell them apart by title
+	match := func(ca protocol.CodeAction) bool {
+		return ca.Kind == actionKind || strings.HasPrefix(string(ca.Kind), string(actionKind)+".") ||
+			ca.Kind == protocol.RefactorExtract && strings.Contains(strings.ToLower(ca.Title), kind)
+	}
+	only := []protocol.CodeActionKind{protocol.RefactorExtract}
+	chosen, err := findRefactoring(ctx, client, filePath, sel.lspRange(ctx, client, filePath), only, match, title)
+	if err != nil {
+		return "", fmt.Errorf("cannot extract %s: %v", kind, err)
+	}
+	return applyRefactoring(ctx, client, chosen, newName, dryRun)
+}
+
+// Inline replaces the variable, constant or function call at a position with its value
+// or body, using the refactoring the language server offers for it, and removes the
+// declaration when the server's refactoring does so. If the server offers several
+// inlinings at the position, the first one whose title contains title is applied. With
+// dryRun set no files are written and the unified diff of the refactoring is returned.
+func Inline(ctx context.Context, client *lsp.Client, filePath string, line, column int, title string, dryRun bool) (string, error) {
+	match := func(ca protocol.CodeAction) bool {
+		return ca.Kind == protocol.RefactorInline || strings.HasPrefix(string(ca.Kind), string(protocol.RefactorInline)+".")
+	}
+	pos := lspPosition(ctx, client, filePath, line, column)
+	only := []protocol.CodeActionKind{protocol.RefactorInline}
+	chosen, err := findRefactoring(ctx, client, filePath, protocol.Range{Start: pos, End: pos}, only, match, title)
+	if err != nil {
+		return "", fmt.Errorf("cannot inline at L%d:C%d: %v", line, column, err)
+	}
+	return applyRefactoring(ctx, client, chosen, "", dryRun)
+}
+
+// FixAll applies the fixes the language server offers for all the problems of a kind in a
+// file at once, its source.fixAll code actions, such as the TypeScript server's fixes for
+// missing awaits and unreachable code or the autofixes of ESLint and Ruff. If the server
+// offers several, the first one whose title contains title is applied. With dryRun set
+// no files are written and the unified diff of the fixes is returned.
+func FixAll(ctx context.Context, client *lsp.Client, filePath, title string, dryRun bool) (string, error) {
+	content, err := readFile(ctx, filePath)
+	if err != nil {
+		return "", fmt.Errorf("could not read file: %v", err)
+	}
+	whole := Selection{StartLine: 1, StartColumn: 1, EndLine: len(utilities.SplitLines(string(content)).All())}
+
+	match := func(ca protocol.CodeAction) bool {
+		return ca.Kind == protocol.SourceFixAll || strings.HasPrefix(string(ca.Kind), string(protocol.SourceFixAll)+".")
+	}
+	only := []protocol.CodeActionKind{protocol.SourceFixAll}
+	chosen, err := findRefactoring(ctx, client, filePath, whole.lspRange(ctx, client, filePath), only, match, title)
+	if err != nil {
+		return "", fmt.Errorf("cannot fix all in %s: %v", filePath, err)
+	}
+	return applyRefactoring(ctx, client, chosen, "", dryRun)
+}
+
+// findRefactoring asks the server for the code actions of the given kinds over a range
+// and picks the first one match accepts whose title contains title, preferring the
+// server's preferred actions. The diagnostics in the range are passed along, since
+// servers offer some actions only as fixes for them. Actions the server disabled are
+// only reported, with their reason.
+func findRefactoring(ctx context.Context, client *lsp.Client, filePath string, rng protocol.Range, only []protocol.CodeActionKind, match func(protocol.CodeAction) bool, title string) (refactoring, error) {
+	if err := client.OpenFile(ctx, filePath); err != nil {
+		return refactoring{}, fmt.Errorf("could not open file: %v", err)
+	}
+	// Servers offer some refactorings only when asked explicitly, as by a user
+	trigger := protocol.CodeActionInvoked
+	uri := protocol.URIFromPath(filePath)
+	diagnostics := []protocol.Diagnostic{}
+	for _, diag := range client.GetFileDiagnostics(uri) {
+		if !positionBefore(diag.Range.End, rng.Start) && !positionBefore(rng.End, diag.Range.Start) {
+			diagnostics = append(diagnostics, diag)
+		}
+	}
+	actions, err := client.CodeAction(ctx, protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        rng,
+		Context: protocol.CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        only,
+			TriggerKind: &trigger,
+		},
+	})
+	if err != nil {
+		return refactoring{}, fmt.Errorf("failed to get code actions: %v", err)
+	}
+
+	var candidates []protocol.CodeAction
+	var disabled []string
+	for _, action := range actions {
+		ca, ok := action.Value.(protocol.CodeAction)
+		if !ok || !match(ca) {
+			continue
+		}
+		if ca.Disabled != nil {
+			disabled = append(disabled, fmt.Sprintf("%s (%s)", ca.Title, ca.Disabled.Reason))
+			continue
+		}
+		candidates = append(candidates, ca)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].IsPreferred && !candidates[j].IsPreferred })
+
+	for i, ca := range candidates {
+		if title != "" && !strings.Contains(strings.ToLower(ca.Title), strings.ToLower(title)) {
+			continue
+		}
+		var others []string
+		for j, other := range candidates {
+			if j != i {
+				others = append(others, other.Title)
+			}
+		}
+		return refactoring{action: ca, others: others}, nil
+	}
+
+	switch {
+	case len(candidates) > 0:
+		var titles []string
+		for _, ca := range candidates {
+			titles = append(titles, ca.Title)
+		}
+		return refactoring{}, fmt.Errorf("no refactoring titled %q, the server offers: %s", title, strings.Join(titles, "; "))
+	case len(disabled) > 0:
+		return refactoring{}, fmt.Errorf("the server can't apply it here: %s", strings.Join(disabled, "; "))
+	default:
+		return refactoring{}, fmt.Errorf("the server offers no such refactoring for this range")
+	}
+}
+
+// applyRefactoring applies the edits of a refactoring, resolving them first if the server
+// computes them lazily, and renames the symbol it creates to newName if given. With
+// dryRun set no files are written and the unified diff is returned instead.
+func applyRefactoring(ctx context.Context, client *lsp.Client, chosen refactoring, newName string, dryRun bool) (string, error) {
+	ca := chosen.action
+	if ca.Edit == nil && ca.Data != nil {
+		resolved, err := client.ResolveCodeAction(ctx, ca)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve code action %q: %v", ca.Title, err)
+		}
+		ca = resolved
+	}
+
+	var othersNote string
+	if len(chosen.others) > 0 {
+		othersNote = fmt.Sprintf("\nThe server also offers: %s. Pass part of one of these titles as title to apply it instead.\n", strings.Join(chosen.others, "; "))
+	}
+
+	if ca.Edit == nil {
+		if ca.Command == nil {
+			return "", fmt.Errorf("code action %q has no edits", ca.Title)
+		}
+		// The server applies the edits itself, with a workspace/applyEdit request
+		if dryRun {
+			return "", fmt.Errorf("code action %q is applied by a server command and can't be previewed", ca.Title)
+		}
+		stopGuard := guardServerEdits(ctx, client, nil)
+		_, err := client.ExecuteCommand(ctx, protocol.ExecuteCommandParams{
+			Command:   ca.Command.Command,
+			Arguments: ca.Command.Arguments,
+		})
+		if rejected := stopGuard(); rejected != nil {
+			return "", rejected
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to execute command of code action %q: %v", ca.Title, err)
+		}
+		result := fmt.Sprintf("Applied %q with a server command.", ca.Title)
+		if newName != "" {
+			result += " Its changes can't be seen before they are made, so the new symbol was not named; use rename_symbol to name it."
+		}
+		return result + "\n" + othersNote, nil
+	}
+
+	edits, ok := textEditsByFile(*ca.Edit)
+	if !ok {
+		return "", fmt.Errorf("code action %q creates, renames or deletes files, which is not supported", ca.Title)
+	}
+
+	// How the result describes naming the new symbol, once applied and in a dry run
+	var nameNote, dryRunNameNote string
+	if newName != "" {
+		originals := make(map[protocol.DocumentUri]string)
+		for uri := range edits {
+			content, err := os.ReadFile(uri.Path())
+			if err != nil {
+				return "", fmt.Errorf("failed to read file: %v", err)
+			}
+			originals[uri] = string(content)
+		}
+		if generated := generatedName(edits, originals); generated != "" {
+			renameInEdits(edits, generated, newName)
+			nameNote = fmt.Sprintf(" and named the new symbol '%s'", newName)
+			dryRunNameNote = fmt.Sprintf(" and naming the new symbol '%s'", newName)
+		} else {
+			nameNote = "; the name the server gave the new symbol could not be found, use rename_symbol to rename it"
+			dryRunNameNote = ", keeping the server's name for the new symbol as it could not be found,"
+		}
+	}
+
+	tx, err := utilities.StageWorkspaceEdit(protocol.WorkspaceEdit{Changes: edits}, client.FileVersion, client.PositionEncoding())
+	if err != nil {
+		return "", fmt.Errorf("failed to apply changes: %v", err)
+	}
+	if dryRun {
+		return fmt.Sprintf("Dry run, no files were changed. Applying %q%s would make these changes:\n%s%s", ca.Title, dryRunNameNote, tx.Diff(), othersNote), nil
+	}
+
+	diff := tx.Diff()
+	if err := prepareWrite(ctx, tx.Paths()...); err != nil {
+		return "", err
+	}
+	err = tx.Commit()
+	for _, path := range tx.Paths() {
+		fileWritten(ctx, client, path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to apply changes: %v", err)
+	}
+	for _, path := range tx.Paths() {
+		if !client.IsFileOpen(path) {
+			continue
+		}
+		if err := client.NotifyChange(ctx, path); err != nil {
+			toolsLogger.Error("Failed to notify language server of change to %s: %v", path, err)
+		}
+	}
+	return fmt.Sprintf("Applied %q%s.\nDiff:\n%s%s", ca.Title, nameNote, diff, othersNote), nil
+}
+
+// generatedName guesses the name a server gave the symbol a refactoring creates: the
+// identifier in the inserted text that was not in the files before, and that occurs most
+// often, since the new symbol is both declared and used. It returns "" if there is none.
+func generatedName(edits map[protocol.DocumentUri][]protocol.TextEdit, originals map[protocol.DocumentUri]string) string {
+	counts := make(map[string]int)
+	var order []string
+	for _, uri := range sortedKeys(edits) {
+		for _, edit := range edits[uri] {
+			for _, name := range identifierRe.FindAllString(edit.NewText, -1) {
+				if indexIdentifier(originals[uri], name) >= 0 {
+					continue
+				}
+				if counts[name] == 0 {
+					order = append(order, name)
+				}
+				counts[name]++
+			}
+		}
+	}
+
+	best := ""
+	for _, name := range order {
+		if counts[name] > counts[best] {
+			best = name
+		}
+	}
+	return best
+}
+
+// renameInEdits replaces the identifier name with newName in the text the edits insert
+func renameInEdits(edits map[protocol.DocumentUri][]protocol.TextEdit, name, newName string) {
+	for _, textEdits := range edits {
+		for i, edit := range textEdits {
+			textEdits[i].NewText = identifierRe.ReplaceAllStringFunc(edit.NewText, func(found string) string {
+				if found == name {
+					return newName
+				}
+				return found
+			})
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedName(t *testing.T) {
+	uri := protocol.DocumentUri("file:///w/main.go")
+	original := "package main\n\nfunc main() {\n\ta := 1\n\tb := a + 2\n\tprintln(b)\n}\n"
+	edits := map[protocol.DocumentUri][]protocol.TextEdit{
+		uri: {
+			{NewText: "b := newFunction(a)"},
+			{NewText: "\n\nfunc newFunction(a int) int {\n\tb := a + 2\n\treturn b\n}\n"},
+		},
+	}
+	originals := map[protocol.DocumentUri]string{uri: original}
+
+	name := generatedName(edits, originals)
+	assert.Equal(t, "newFunction", name)
+
+	renameInEdits(edits, name, "addTwo")
+	assert.Equal(t, "b := addTwo(a)", edits[uri][0].NewText)
+	assert.Equal(t, "\n\nfunc addTwo(a int) int {\n\tb := a + 2\n\treturn b\n}\n", edits[uri][1].NewText)
+
+	// Nothing new was inserted
+	assert.Equal(t, "", generatedName(map[protocol.DocumentUri][]protocol.TextEdit{uri: {{NewText: "a + b"}}}, originals))
+}
+
+func TestSelectionRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nvar s = \"héllo\" + x\n"), 0644))
+
+	sel := Selection{StartLine: 3, StartColumn: 9, EndLine: 3, EndColumn: 16}
+	assert.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 2, Character: 8},
+		End:   protocol.Position{Line: 2, Character: 15},
+	}, sel.lspRange(context.Background(), nil, path))
+
+	sel = Selection{StartLine: 3, EndLine: 3}
+	assert.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 2, Character: 0},
+		End:   protocol.Position{Line: 2, Character: 19},
+	}, sel.lspRange(context.Background(), nil, path))
+}
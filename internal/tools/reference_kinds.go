@@ -0,0 +1,188 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// referenceKind classifies a reference to help judge the impact of changing a symbol
+type referenceKind int
+
+const (
+	refDeclaration referenceKind = iota
+	refWrite
+	refRead
+	refImport
+	refTest
+	refOther
+)
+
+// referenceKinds lists the kinds in the order their sections are presented
+var referenceKinds = []referenceKind{refDeclaration, refWrite, refRead, refImport, refTest, refOther}
+
+func (k referenceKind) String() string {
+	switch k {
+	case refDeclaration:
+		return "Declarations"
+	case refWrite:
+		return "Writes"
+	case refRead:
+		return "Reads"
+	case refImport:
+		return "Imports"
+	case refTest:
+		return "Test files"
+	default:
+		return "Other references"
+	}
+}
+
+// formatReferencesByKind formats references in a section per kind, preceded by a
+// summary of how many references there are of each kind
+func formatReferencesByKind(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, position protocol.Position, refs []protocol.Location, contextLines int, root string, opts ReferenceOptions) ([]string, error) {
+	byKind := classifyReferences(ctx, client, uri, position, refs, root)
+
+	var counts []string
+	for _, kind := range referenceKinds {
+		if n := len(byKind[kind]); n > 0 {
+			counts = append(counts, fmt.Sprintf("%s: %d", kind, n))
+		}
+	}
+	sections := []string{"Summary: " + strings.Join(counts, ", ")}
+
+	formatted, total := 0, countFiles(refs)
+	for _, kind := range referenceKinds {
+		kindRefs := byKind[kind]
+		if len(kindRefs) == 0 {
+			continue
+		}
+		files, err := formatReferenceFiles(ctx, client, kindRefs, contextLines, opts)
+		if err != nil {
+			note, err := partialResults(err, formatted, total)
+			if err != nil {
+				return nil, err
+			}
+			return append(sections, note), nil
+		}
+		sections = append(sections, fmt.Sprintf("---\n\n## %s (%d)\n", kind, len(kindRefs)))
+		sections = append(sections, files...)
+		formatted += countFiles(kindRefs)
+	}
+	return sections, nil
+}
+
+// classifyReferences sorts references by kind. Declarations are the definitions of the
+// symbol, imports and test files are recognised from the text and path of a reference,
+// and the remaining references are reads or writes as reported by document highlights.
+// Test directories are only recognised below root, the workspace directory.
+func classifyReferences(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, position protocol.Position, refs []protocol.Location, root string) map[referenceKind][]protocol.Location {
+	declarations := make(map[protocol.Location]bool)
+	result, err := client.Definition(ctx, protocol.DefinitionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     position,
+		},
+	})
+	if err != nil {
+		toolsLogger.Debug("Failed to get definition to classify references: %v", err)
+	} else if locations, err := ExtractLocationsFromDefinitionResult(result.Value); err == nil {
+		for _, loc := range locations {
+			declarations[loc] = true
+		}
+	}
+
+	highlights := make(map[protocol.DocumentUri]map[protocol.Range]protocol.DocumentHighlightKind)
+	columns := newFileLines(ctx, client)
+	byKind := make(map[referenceKind][]protocol.Location)
+	for _, ref := range refs {
+		kind := refOther
+		text, _ := columns.line(ref.URI.Path(), ref.Range.Start.Line)
+		switch {
+		case declarations[ref]:
+			kind = refDeclaration
+		case isImportLine(text):
+			kind = refImport
+		case isTestFile(ref.URI.Path(), root):
+			kind = refTest
+		default:
+			fileHighlights, ok := highlights[ref.URI]
+			if !ok {
+				fileHighlights = documentHighlights(ctx, client, ref)
+				highlights[ref.URI] = fileHighlights
+			}
+			switch fileHighlights[ref.Range] {
+			case protocol.Write:
+				kind = refWrite
+			case protocol.Read:
+				kind = refRead
+			}
+		}
+		byKind[kind] = append(byKind[kind], ref)
+	}
+	return byKind
+}
+
+// documentHighlights returns the kinds of the highlights of the symbol referenced at a
+// location, across the location's file
+func documentHighlights(ctx context.Context, client *lsp.Client, ref protocol.Location) map[protocol.Range]protocol.DocumentHighlightKind {
+	kinds := make(map[protocol.Range]protocol.DocumentHighlightKind)
+	if err := client.OpenFileForQuery(ctx, ref.URI.Path()); err != nil {
+		toolsLogger.Debug("Failed to open file to classify references: %v", err)
+		return kinds
+	}
+	highlights, err := client.DocumentHighlight(ctx, protocol.DocumentHighlightParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: ref.URI},
+			Position:     ref.Range.Start,
+		},
+	})
+	if err != nil {
+		toolsLogger.Debug("Failed to get document highlights to classify references: %v", err)
+		return kinds
+	}
+	for _, h := range highlights {
+		kinds[h.Range] = h.Kind
+	}
+	return kinds
+}
+
+// isImportLine reports whether a line looks like an import statement in one of the
+// common languages
+func isImportLine(line string) bool {
+	line = strings.TrimSpace(line)
+	for _, prefix := range []string{"import ", "import(", "from ", "#include ", "use ", "require(", "require ", "using "} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTestFile reports whether a path follows the test file naming conventions of one of
+// the common languages or is in a test directory below root
+func isTestFile(path, root string) bool {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+	switch {
+	case strings.HasSuffix(name, "_test"), strings.HasPrefix(name, "test_"),
+		strings.HasSuffix(name, ".test"), strings.HasSuffix(name, ".spec"),
+		strings.HasSuffix(name, "Test") && ext == ".java":
+		return true
+	}
+	if rel, _ := utilities.RelativePath(root, path); root != "" {
+		path = rel
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if dir == "test" || dir == "tests" || dir == "__tests__" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsImportLine(t *testing.T) {
+	assert.True(t, isImportLine(`import "fmt"`))
+	assert.True(t, isImportLine("from foo import bar"))
+	assert.True(t, isImportLine("  #include \"foo.h\""))
+	assert.True(t, isImportLine("use crate::foo::Bar;"))
+	assert.False(t, isImportLine("imported := true"))
+	assert.False(t, isImportLine("x := fromValue(y)"))
+}
+
+func TestIsTestFile(t *testing.T) {
+	root := filepath.Join("/work", "tests", "project")
+	for path, expected := range map[string]bool{
+		"server/server_test.go":     true,
+		"pkg/test_server.py":        true,
+		"src/server.spec.ts":        true,
+		"src/server.test.js":        true,
+		"src/ServerTest.java":       true,
+		"tests/integration/util.rs": true,
+		"src/__tests__/util.js":     true,
+		"server/server.go":          false,
+		"src/testing.ts":            false,
+	} {
+		assert.Equal(t, expected, isTestFile(filepath.Join(root, path), root), path)
+	}
+}
@@ -2,37 +2,97 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"sort"
-	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
 )
 
-func FindReferences(ctx context.Context, client *lsp.Client, filePath string, line, column int) (string, error) {
-	// Get context lines from environment variable
-	contextLines := 5
-	if envLines := os.Getenv("LSP_CONTEXT_LINES"); envLines != "" {
-		if val, err := strconv.Atoi(envLines); err == nil && val >= 0 {
-			contextLines = val
+// ReferenceOptions changes which references are returned and how they are presented
+type ReferenceOptions struct {
+	// IncludeDeclaration includes the declaration of the symbol in the references
+	IncludeDeclaration bool
+	// GroupByKind presents references in sections by kind: declarations, writes, reads,
+	// imports and references in test files
+	GroupByKind bool
+	// Blame annotates each reference with the last commit that changed its line, from
+	// git blame
+	Blame bool
+	// IncludeGenerated shows the lines around references in binary and generated files,
+	// which are otherwise only counted
+	IncludeGenerated bool
+}
+
+func FindReferences(ctx context.Context, client *lsp.Client, filePath string, line, column int, contextLines int, page Pagination, filter PathFilter, opts ReferenceOptions) (string, error) {
+	// Convert the 1-indexed line and character column to an LSP position
+	position := lspPosition(ctx, client, filePath, line, column)
+	uri := protocol.URIFromPath(filePath)
+
+	// The precomputed index answers first when preferred, and otherwise when the
+	// language server fails or finds nothing
+	var refs []protocol.Location
+	var err error
+	note := ""
+	if options(ctx).Precomputed.first() {
+		refs = options(ctx).Precomputed.references(filePath, position, opts.IncludeDeclaration, client.PositionEncoding())
+	}
+	if len(refs) > 0 {
+		note = options(ctx).Precomputed.note()
+	} else {
+		refs, err = serverReferences(ctx, client, uri, position, opts.IncludeDeclaration)
+		if err != nil || len(refs) == 0 {
+			if indexed := options(ctx).Precomputed.references(filePath, position, opts.IncludeDeclaration, client.PositionEncoding()); len(indexed) > 0 {
+				refs, note, err = indexed, options(ctx).Precomputed.note(), nil
+			}
+		}
+		if err != nil {
+			return "", err
 		}
 	}
 
-	// Open the file if not already open
-	err := client.OpenFile(ctx, filePath)
+	if len(refs) == 0 {
+		return "No references found", nil
+	}
+
+	refs = filter.filterLocations(refs)
+	if len(refs) == 0 {
+		return "No references found matching the include/exclude filters", nil
+	}
+
+	refs, footer := paginateLocations(refs, page, "references")
+	if len(refs) == 0 {
+		return footer, nil
+	}
+
+	var allReferences []string
+	if opts.GroupByKind {
+		allReferences, err = formatReferencesByKind(ctx, client, uri, position, refs, contextLines, filter.Root, opts)
+	} else {
+		allReferences, err = formatReferenceFiles(ctx, client, refs, contextLines, opts)
+	}
 	if err != nil {
-		return "", fmt.Errorf("could not open file: %v", err)
+		return "", err
+	}
+
+	if footer != "" {
+		allReferences = append(allReferences, "---\n\n"+footer)
 	}
 
-	// Convert 1-indexed line/column to 0-indexed for LSP protocol
-	position := protocol.Position{
-		Line:      uint32(line - 1),
-		Character: uint32(column - 1),
+	return note + strings.Join(allReferences, "\n"), nil
+}
+
+// serverReferences asks the language server for the references to the symbol at a
+// position
+func serverReferences(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, position protocol.Position, includeDeclaration bool) ([]protocol.Location, error) {
+	// Open the file if not already open
+	if err := client.OpenFileForQuery(ctx, uri.Path()); err != nil {
+		return nil, fmt.Errorf("could not open file: %v", err)
 	}
-	uri := protocol.DocumentUri("file://" + filePath)
 
 	// Use LSP references request with correct params structure
 	refsParams := protocol.ReferenceParams{
@@ -43,24 +103,26 @@ func FindReferences(ctx context.Context, client *lsp.Client, filePath string, li
 			Position: position,
 		},
 		Context: protocol.ReferenceContext{
-			IncludeDeclaration: false,
+			IncludeDeclaration: includeDeclaration,
 		},
 	}
 
 	refs, err := client.References(ctx, refsParams)
 	if err != nil {
-		return "", fmt.Errorf("failed to get references: %v", err)
+		return nil, fmt.Errorf("failed to get references: %v", err)
 	}
+	return uniqueLocations(refs), nil
+}
 
-	if len(refs) == 0 {
-		return "No references found", nil
-	}
+// Number of files formatReferenceFiles reads and formats at once
+const referenceWorkers = 8
 
+// formatReferenceFiles formats references grouped by file, with the lines around each
+// reference, and with opts.Blame the last commit that changed each reference. Files are
+// read and formatted concurrently and returned in path order.
+func formatReferenceFiles(ctx context.Context, client *lsp.Client, refs []protocol.Location, contextLines int, opts ReferenceOptions) ([]string, error) {
 	// Group references by file
-	refsByFile := make(map[protocol.DocumentUri][]protocol.Location)
-	for _, ref := range refs {
-		refsByFile[ref.URI] = append(refsByFile[ref.URI], ref)
-	}
+	refsByFile := groupLocationsByFile(refs)
 
 	// Get sorted list of URIs
 	uris := make([]string, 0, len(refsByFile))
@@ -69,58 +131,164 @@ func FindReferences(ctx context.Context, client *lsp.Client, filePath string, li
 	}
 	sort.Strings(uris)
 
+	// Format each file's references, leaving files empty once the context is done
+	formatted := make([]string, len(uris))
+	done := make([]bool, len(uris))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(referenceWorkers, len(uris)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					continue
+				}
+				uri := protocol.DocumentUri(uris[i])
+				formatted[i] = formatReferenceFile(ctx, client, uri, refsByFile[uri], contextLines, opts)
+				done[i] = true
+			}
+		}()
+	}
+	for i := range uris {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
 	var allReferences []string
-	// Process each file's references in sorted order
-	for _, uriStr := range uris {
-		uri := protocol.DocumentUri(uriStr)
-		fileRefs := refsByFile[uri]
-		filePath := strings.TrimPrefix(uriStr, "file://")
-
-		// Format file header
-		fileInfo := fmt.Sprintf("---\n\n%s\nReferences in File: %d\n",
-			filePath,
-			len(fileRefs),
-		)
-
-		// Format locations with context
-		fileContent, err := os.ReadFile(filePath)
-		if err != nil {
-			// Log error but continue with other files
-			allReferences = append(allReferences, fileInfo+"\nError reading file: "+err.Error())
-			continue
+	for i := range uris {
+		if formatted[i] != "" {
+			allReferences = append(allReferences, formatted[i])
 		}
+	}
 
-		lines := strings.Split(string(fileContent), "\n")
-
-		// Track reference locations for header display
-		var locStrings []string
-		for _, ref := range fileRefs {
-			locStr := fmt.Sprintf("L%d:C%d",
-				ref.Range.Start.Line+1,
-				ref.Range.Start.Character+1)
-			locStrings = append(locStrings, locStr)
+	if err := ctx.Err(); err != nil {
+		completed := 0
+		for _, d := range done {
+			if d {
+				completed++
+			}
+		}
+		if completed < len(uris) {
+			note, err := partialResults(err, completed, len(uris))
+			if err != nil {
+				return nil, err
+			}
+			allReferences = append(allReferences, note)
 		}
+	}
 
-		// Collect lines to display using the utility function
-		linesToShow, err := GetLineRangesToDisplay(ctx, client, fileRefs, len(lines), contextLines)
-		if err != nil {
-			// Log error but continue with other files
-			continue
+	return allReferences, nil
+}
+
+// formatReferenceFile formats the references in one file with the lines around them, or
+// returns an empty string if the lines to show could not be determined. Unless
+// opts.IncludeGenerated is set, references in binary and generated files are only counted.
+func formatReferenceFile(ctx context.Context, client *lsp.Client, uri protocol.DocumentUri, fileRefs []protocol.Location, contextLines int, opts ReferenceOptions) string {
+	filePath := uri.Path()
+	columns := newFileLines(ctx, client)
+
+	// Format file header
+	fileInfo := fmt.Sprintf("---\n\n%s\nReferences in File: %d\n",
+		filePath,
+		len(fileRefs),
+	)
+
+	// Format locations with context, from the text the server computed them from
+	fileContent, err := documentContent(ctx, client, filePath)
+	var tooLarge *fileTooLargeError
+	if err != nil && !errors.As(err, &tooLarge) {
+		// Report the error in place of the file, the other files are still shown
+		return fileInfo + "\nError reading file: " + err.Error()
+	}
+	if !opts.IncludeGenerated {
+		head := fileContent
+		if tooLarge != nil {
+			head, _ = readFileHead(filePath)
+		}
+		if kind := machineFileKind(head); kind != "" {
+			return fileInfo + fmt.Sprintf("Lines not shown in this %s file, set includeGenerated to show them\n", kind)
 		}
+	}
+
+	lines := utilities.SplitLines(string(fileContent)).Text()
+	if tooLarge == nil {
+		columns.files[filePath] = lines
+	}
 
-		// Convert to line ranges using the utility function
-		lineRanges := ConvertLinesToRanges(linesToShow, len(lines))
+	// Track reference locations for header display
+	var locStrings []string
+	for _, ref := range fileRefs {
+		locStr := fmt.Sprintf("L%d:C%d",
+			ref.Range.Start.Line+1,
+			columns.column(uri, ref.Range.Start))
+		locStrings = append(locStrings, locStr)
+	}
 
-		// Format with locations in header
+	if tooLarge != nil {
 		formattedOutput := fileInfo
 		if len(locStrings) > 0 {
 			formattedOutput += "At: " + strings.Join(locStrings, ", ") + "\n"
 		}
+		return formattedOutput + formatLargeFile(ctx, filePath, fileRefs, contextLines, tooLarge)
+	}
+
+	// Collect lines to display using the utility function
+	linesToShow, err := GetLineRangesToDisplay(ctx, client, fileRefs, len(lines), contextLines)
+	if err != nil {
+		// Leave the file out, the other files are still shown
+		return ""
+	}
+
+	// Convert to line ranges using the utility function
+	lineRanges := ConvertLinesToRanges(linesToShow, len(lines))
+
+	// Format with locations in header
+	formattedOutput := fileInfo
+	if opts.Blame {
+		formattedOutput += formatBlame(ctx, filePath, fileRefs, locStrings)
+	} else if len(locStrings) > 0 {
+		formattedOutput += "At: " + strings.Join(locStrings, ", ") + "\n"
+	}
+
+	// Format the content with ranges
+	formattedOutput += "\n" + FormatLinesWithRanges(ctx, filePath, lines, lineRanges)
+	return formattedOutput
+}
+
+// documentContent returns the content of a file as the language server has it: the text
+// it was last sent if the file is open, which may not have been written to disk yet, and
+// otherwise the file on disk. Snippets shown around results then match the positions the
+// server computed.
+func documentContent(ctx context.Context, client *lsp.Client, path string) ([]byte, error) {
+	if content, ok := client.OpenFileContent(path); ok {
+		return []byte(content), nil
+	}
+	return readFile(ctx, path)
+}
 
-		// Format the content with ranges
-		formattedOutput += "\n" + FormatLinesWithRanges(lines, lineRanges)
-		allReferences = append(allReferences, formattedOutput)
+// formatBlame lists the locations of the references in a file, each with the last commit
+// that changed its line
+func formatBlame(ctx context.Context, filePath string, fileRefs []protocol.Location, locStrings []string) string {
+	refLines := make([]int, len(fileRefs))
+	for i, ref := range fileRefs {
+		refLines[i] = int(ref.Range.Start.Line) + 1
+	}
+	blamed, err := blameLines(ctx, filePath, refLines)
+	if err != nil {
+		toolsLogger.Debug("Failed to blame %s: %v", filePath, err)
+		return "At: " + strings.Join(locStrings, ", ") + "\nBlame unavailable: " + err.Error() + "\n"
 	}
 
-	return strings.Join(allReferences, "\n"), nil
+	var b strings.Builder
+	b.WriteString("At:\n")
+	for i, loc := range locStrings {
+		if line, ok := blamed[refLines[i]]; ok {
+			fmt.Fprintf(&b, "  %s %s\n", loc, line)
+		} else {
+			fmt.Fprintf(&b, "  %s\n", loc)
+		}
+	}
+	return b.String()
 }
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// RelativePaths rewrites the absolute paths under root in a tool's output relative to
+// root, including those under the target of root if it is a symlink, which language
+// servers report. Paths inside file:// URIs and paths that merely end with root are left
+// alone.
+func RelativePaths(text, root string) string {
+	if root == "" {
+		return text
+	}
+	text = relativeTo(text, root)
+	if real := utilities.CanonicalPath(root); real != filepath.Clean(root) {
+		text = relativeTo(text, real)
+	}
+	return text
+}
+
+// relativeTo rewrites the absolute paths under root in text relative to root
+func relativeTo(text, root string) string {
+	prefix := strings.TrimSuffix(filepath.Clean(root), string(filepath.Separator)) + string(filepath.Separator)
+
+	var b strings.Builder
+	for {
+		i := strings.Index(text, prefix)
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		b.WriteString(text[:i])
+		if i > 0 && isPathChar(text[i-1]) || strings.HasSuffix(text[:i], "file://") {
+			b.WriteString(prefix)
+		}
+		text = text[i+len(prefix):]
+	}
+}
+
+// isPathChar reports whether c may precede the workspace directory within a longer path
+func isPathChar(c byte) bool {
+	return c == '/' || c == '\\' || c == '.' || c == '_' || c == '-' || c == ':' ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
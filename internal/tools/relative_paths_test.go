@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelativePaths(t *testing.T) {
+	root := filepath.FromSlash("/home/me/project")
+	abs := func(p string) string { return filepath.FromSlash(p) }
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "file header",
+			text:     "---\n\n" + abs("/home/me/project/internal/x.go") + "\nReferences in File: 1\n",
+			expected: "---\n\n" + abs("internal/x.go") + "\nReferences in File: 1\n",
+		},
+		{
+			name:     "several paths on a line",
+			text:     abs("/home/me/project/a.go") + ": L3, " + abs("/home/me/project/b/c.go") + ": L4",
+			expected: "a.go: L3, " + abs("b/c.go") + ": L4",
+		},
+		{
+			name:     "root trailing separator",
+			text:     abs("/home/me/project/a.go"),
+			expected: "a.go",
+		},
+		{
+			name:     "outside of the workspace",
+			text:     abs("/home/me/other/a.go") + " " + abs("/mnt/home/me/project/a.go"),
+			expected: abs("/home/me/other/a.go") + " " + abs("/mnt/home/me/project/a.go"),
+		},
+		{
+			name:     "the workspace itself",
+			text:     "Workspace: " + root,
+			expected: "Workspace: " + root,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RelativePaths(tt.text, root))
+		})
+	}
+
+	assert.Equal(t, "file:///home/me/project/a.go", RelativePaths("file:///home/me/project/a.go", "/home/me/project"))
+	assert.Equal(t, "b.go", RelativePaths("/home/me/project/b.go", "/home/me/project/"))
+	assert.Equal(t, "/home/me/project/b.go", RelativePaths("/home/me/project/b.go", ""))
+}
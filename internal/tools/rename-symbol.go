@@ -2,9 +2,12 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
@@ -12,20 +15,18 @@ import (
 )
 
 // RenameSymbol renames a symbol (variable, function, class, etc.) at the specified position
-// It uses the LSP rename functionality to handle all references across files
-func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line, column int, newName string) (string, error) {
+// It uses the LSP rename functionality to handle all references across files.
+// With dryRun set no files are written and the unified diff of the rename is returned.
+func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line, column int, newName string, dryRun bool) (string, error) {
 	// Open the file if not already open
 	err := client.OpenFile(ctx, filePath)
 	if err != nil {
 		return "", fmt.Errorf("could not open file: %v", err)
 	}
 
-	// Convert 1-indexed line/column to 0-indexed for LSP protocol
-	uri := protocol.DocumentUri("file://" + filePath)
-	position := protocol.Position{
-		Line:      uint32(line - 1),
-		Character: uint32(column - 1),
-	}
+	// Convert the 1-indexed line and character column to an LSP position
+	uri := protocol.URIFromPath(filePath)
+	position := lspPosition(ctx, client, filePath, line, column)
 
 	// Create the rename parameters
 	params := protocol.RenameParams{
@@ -36,8 +37,16 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 		NewName:  newName,
 	}
 
-	// Skip the PrepareRename check as it might not be supported by all language servers
-	// Execute the rename directly
+	// Check that the symbol can be renamed first, if the server can tell, for a clear
+	// error rather than whatever the rename fails with
+	target, err := prepareRename(ctx, client, filePath, position)
+	if err != nil {
+		return "", err
+	}
+	symbol := "symbol"
+	if target != nil {
+		symbol = target.String()
+	}
 
 	// Execute the rename operation
 	workspaceEdit, err := client.Rename(ctx, params)
@@ -58,6 +67,7 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 		Locations string
 	}
 	var allChanges []FileChanges
+	columns := newFileLines(ctx, client)
 
 	// Count changes in Changes field
 	if workspaceEdit.Changes != nil {
@@ -67,7 +77,7 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 			var locs strings.Builder
 			for i, change := range edits {
 				locs.WriteString(
-					fmt.Sprintf("L%d:C%d", change.Range.Start.Line+1, change.Range.Start.Character+1),
+					fmt.Sprintf("L%d:C%d", change.Range.Start.Line+1, columns.column(uri, change.Range.Start)),
 				)
 				if i != len(edits)-1 {
 					locs.WriteString(", ")
@@ -87,7 +97,7 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 			for i, edit := range change.TextDocumentEdit.Edits {
 				textEdit, err := edit.AsTextEdit()
 				if err == nil {
-					locs.WriteString(fmt.Sprintf("L%d:C%d", textEdit.Range.Start.Line+1, textEdit.Range.Start.Character+1))
+					locs.WriteString(fmt.Sprintf("L%d:C%d", textEdit.Range.Start.Line+1, columns.column(change.TextDocumentEdit.TextDocument.URI, textEdit.Range.Start)))
 					if i != len(change.TextDocumentEdit.Edits)-1 {
 						locs.WriteString(", ")
 					}
@@ -112,16 +122,146 @@ func RenameSymbol(ctx context.Context, client *lsp.Client, filePath string, line
 		locationsBuilder.WriteString(fmt.Sprintf("%s: %s\n", change.URI, change.Locations))
 	}
 
-	// Apply the workspace edit to files:workspaceEdit
-	if err := utilities.ApplyWorkspaceEdit(workspaceEdit); err != nil {
-		return "", fmt.Errorf("failed to apply changes: %v", err)
-	}
-
 	if fileCount == 0 || changeCount == 0 {
 		return "Failed to rename symbol. 0 occurrences found.", nil
 	}
 
+	// Stage every change before writing so that a failure part way through a
+	// multi-file rename leaves the project as it was
+	tx, err := utilities.StageWorkspaceEdit(workspaceEdit, client.FileVersion, client.PositionEncoding())
+	if tx != nil && !dryRun {
+		defer func() {
+			for _, path := range tx.Paths() {
+				fileWritten(ctx, client, path)
+			}
+		}()
+	}
+	if errors.Is(err, utilities.ErrDirectoryChange) && !dryRun {
+		// Renames that move directories cannot be staged, apply them directly
+		if err := prepareWrite(ctx, utilities.WorkspaceEditPaths(workspaceEdit)...); err != nil {
+			return "", err
+		}
+		if err := utilities.ApplyWorkspaceEdit(workspaceEdit, client.PositionEncoding()); err != nil {
+			return "", fmt.Errorf("failed to apply changes: %v", err)
+		}
+	} else if err != nil {
+		return "", fmt.Errorf("failed to apply changes: %v", err)
+	} else if dryRun {
+		return fmt.Sprintf("Dry run, no files were changed. Renaming %s to '%s' would update %d occurrences across %d files:\n%s\n%s",
+			symbol, newName, changeCount, fileCount, locationsBuilder.String(), tx.Diff()), nil
+	} else {
+		if err := prepareWrite(ctx, tx.Paths()...); err != nil {
+			return "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return "", fmt.Errorf("failed to apply changes: %v", err)
+		}
+	}
+
 	// Generate a summary of changes made
-	return fmt.Sprintf("Successfully renamed symbol to '%s'.\nUpdated %d occurrences across %d files:\n%s",
-		newName, changeCount, fileCount, locationsBuilder.String()), nil
+	return fmt.Sprintf("Successfully renamed %s to '%s'.\nUpdated %d occurrences across %d files:\n%s",
+		symbol, newName, changeCount, fileCount, locationsBuilder.String()), nil
+}
+
+// renameTarget is the text a rename replaces at the position it was asked for
+type renameTarget struct {
+	name  string
+	line  int
+	start int
+	end   int
+}
+
+// String describes the target as "'oldName' at L3:C5-C12"
+func (t renameTarget) String() string {
+	if t.name == "" {
+		return fmt.Sprintf("symbol at L%d:C%d", t.line, t.start)
+	}
+	return fmt.Sprintf("'%s' at L%d:C%d-C%d", t.name, t.line, t.start, t.end)
+}
+
+// prepareRename asks the server whether the symbol at a position can be renamed and
+// returns the text the rename would replace. It returns nil without asking if the server
+// cannot check renames.
+func prepareRename(ctx context.Context, client *lsp.Client, filePath string, position protocol.Position) (*renameTarget, error) {
+	if !client.SupportsPrepareRename() {
+		return nil, nil
+	}
+
+	result, err := client.PrepareRename(ctx, protocol.PrepareRenameParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)},
+			Position:     position,
+		},
+	})
+	lines := newFileLines(ctx, client)
+	text, _ := lines.line(filePath, position.Line)
+	word, wordRange := identifierAt(text, position, lines.encoding)
+	at := fmt.Sprintf("L%d:C%d", position.Line+1, lines.column(protocol.URIFromPath(filePath), position))
+	if word != "" {
+		at = fmt.Sprintf("'%s' at %s", word, at)
+	}
+	if err != nil {
+		// Servers explain why a symbol cannot be renamed in the error, such as that it is
+		// a builtin or declared in a dependency
+		var responseErr *lsp.ResponseError
+		if errors.As(err, &responseErr) {
+			return nil, fmt.Errorf("cannot rename %s: %s", at, responseErr.Message)
+		}
+		return nil, fmt.Errorf("failed to check rename of %s: %v", at, err)
+	}
+
+	var rng protocol.Range
+	name := ""
+	switch v := result.Value.(type) {
+	case protocol.Range:
+		rng = v
+	case protocol.PrepareRenamePlaceholder:
+		rng, name = v.Range, v.Placeholder
+	case protocol.PrepareRenameDefaultBehavior:
+		if word == "" {
+			return nil, fmt.Errorf("cannot rename %s: there is no identifier there", at)
+		}
+		rng, name = wordRange, word
+	default:
+		return nil, fmt.Errorf("cannot rename %s: the language server reports nothing there that can be renamed", at)
+	}
+
+	if name == "" && rng.Start.Line == rng.End.Line {
+		if text, ok := lines.line(filePath, rng.Start.Line); ok {
+			name = text[lines.encoding.CharacterToByte(text, rng.Start.Character):lines.encoding.CharacterToByte(text, rng.End.Character)]
+		}
+	}
+	uri := protocol.URIFromPath(filePath)
+	return &renameTarget{
+		name:  name,
+		line:  int(rng.Start.Line) + 1,
+		start: lines.column(uri, rng.Start),
+		end:   lines.column(uri, rng.End),
+	}, nil
+}
+
+// identifierAt returns the identifier a position is on in a line of text, if any, with
+// its range, with characters counted in encoding
+func identifierAt(text string, pos protocol.Position, encoding protocol.PositionEncodingKind) (string, protocol.Range) {
+	isIdent := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	offset := encoding.CharacterToByte(text, pos.Character)
+	start, end := offset, offset
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:start])
+		if !isIdent(r) {
+			break
+		}
+		start -= size
+	}
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !isIdent(r) {
+			break
+		}
+		end += size
+	}
+	return text[start:end], protocol.Range{
+		Start: protocol.Position{Line: pos.Line, Character: encoding.ByteToCharacter(text, start)},
+		End:   protocol.Position{Line: pos.Line, Character: encoding.ByteToCharacter(text, end)},
+	}
 }
@@ -0,0 +1,29 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentifierAt(t *testing.T) {
+	text := "\tn := len(héllo_2)"
+	at := func(character uint32) protocol.Position { return protocol.Position{Line: 4, Character: character} }
+
+	word, rng := identifierAt(text, at(7), protocol.UTF16)
+	assert.Equal(t, "len", word)
+	assert.Equal(t, protocol.Range{Start: at(6), End: at(9)}, rng)
+
+	word, rng = identifierAt(text, at(12), protocol.UTF16)
+	assert.Equal(t, "héllo_2", word)
+	assert.Equal(t, protocol.Range{Start: at(10), End: at(17)}, rng)
+
+	word, _ = identifierAt(text, at(3), protocol.UTF16)
+	assert.Equal(t, "", word)
+}
+
+func TestRenameTargetString(t *testing.T) {
+	assert.Equal(t, "'count' at L3:C5-C10", renameTarget{name: "count", line: 3, start: 5, end: 10}.String())
+	assert.Equal(t, "symbol at L3:C5", renameTarget{line: 3, start: 5, end: 10}.String())
+}
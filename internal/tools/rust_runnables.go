@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Only the end of a runnable's output is kept past this many bytes, as the end holds the
+// test summary and the failures
+const maxRunnableOutputBytes = 64 << 10
+
+// RustRunnables lists the runnables rust-analyzer finds in a file, or at a 1-indexed
+// position if line is positive, such as the tests, binaries and test modules there, with
+// the command running each. If run is set, the runnable with that label is run instead,
+// and its output, stdout and stderr interleaved, is returned with its exit status.
+func RustRunnables(ctx context.Context, client *lsp.Client, filePath string, line, column int, run string) (string, error) {
+	params := lsp.RunnablesParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)},
+	}
+	where := filePath
+	if line > 0 {
+		position := lspPosition(ctx, client, filePath, line, max(column, 1))
+		params.Position = &position
+		where = fmt.Sprintf("%s at L%d:C%d", filePath, line, max(column, 1))
+	}
+	runnables, err := client.Runnables(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to get runnables: %v", err)
+	}
+
+	if run == "" {
+		return formatRunnables(runnables, where), nil
+	}
+	for _, r := range runnables {
+		if r.Label == run {
+			return runRunnable(ctx, r)
+		}
+	}
+	if len(runnables) == 0 {
+		return "", fmt.Errorf("no runnable labeled %q: there are no runnables in %s", run, where)
+	}
+	labels := make([]string, len(runnables))
+	for i, r := range runnables {
+		labels[i] = fmt.Sprintf("%q", r.Label)
+	}
+	return "", fmt.Errorf("no runnable labeled %q in %s, expected one of %s", run, where, strings.Join(labels, ", "))
+}
+
+// formatRunnables lists runnables with where they are and the command running them
+func formatRunnables(runnables []lsp.Runnable, where string) string {
+	if len(runnables) == 0 {
+		return fmt.Sprintf("No runnables found in %s", where)
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "Runnables in %s:\n", where)
+	for _, r := range runnables {
+		result.WriteString("\n- " + r.Label)
+		if r.Location != nil {
+			fmt.Fprintf(&result, " (%s:%d)", r.Location.TargetURI.Path(), r.Location.TargetRange.Start.Line+1)
+		}
+		if name, args, _, err := runnableCommand(r); err == nil {
+			fmt.Fprintf(&result, "\n  %s", commandLine(name, args))
+		}
+	}
+	result.WriteString("\n\nPass a label as run to run it.")
+	return result.String()
+}
+
+// runnableCommand returns the program, arguments and working directory running a runnable
+func runnableCommand(r lsp.Runnable) (string, []string, string, error) {
+	dir := r.Args.Cwd
+	if dir == "" {
+		dir = r.Args.WorkspaceRoot
+	}
+	switch r.Kind {
+	case "cargo":
+		name := r.Args.OverrideCargo
+		if name == "" {
+			name = "cargo"
+		}
+		args := append([]string(nil), r.Args.CargoArgs...)
+		if len(r.Args.ExecutableArgs) > 0 {
+			args = append(append(args, "--"), r.Args.ExecutableArgs...)
+		}
+		return name, args, dir, nil
+	case "shell":
+		if r.Args.Program == "" {
+			return "", nil, "", fmt.Errorf("shell runnable %q has no program", r.Label)
+		}
+		return r.Args.Program, r.Args.ProgramArgs, dir, nil
+	default:
+		return "", nil, "", fmt.Errorf("runnable %q has unsupported kind %q", r.Label, r.Kind)
+	}
+}
+
+// commandLine shows a command the way it would be typed in a shell
+func commandLine(name string, args []string) string {
+	words := make([]string, 0, len(args)+1)
+	for _, word := range append([]string{name}, args...) {
+		if word == "" || strings.ContainsAny(word, " \t\"'\\$") {
+			word = fmt.Sprintf("%q", word)
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
+
+// runRunnable runs a runnable and returns its output and exit status. If the context ends
+// first, the runnable is killed and its output so far returned.
+func runRunnable(ctx context.Context, r lsp.Runnable) (string, error) {
+	name, args, dir, err := runnableCommand(r)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	for key, value := range r.Args.Environment {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	// Don't wait long for output from processes the runnable left behind
+	cmd.WaitDelay = time.Second
+	// With the same writer for both, exec writes to it from one goroutine at a time
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	status := "exit status 0"
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", ctx.Err()
+		}
+		status = "timed out, the runnable was killed"
+	case errors.As(err, &exitErr):
+		status = exitErr.String()
+	case err != nil:
+		return "", fmt.Errorf("failed to run %s: %v", commandLine(name, args), err)
+	}
+
+	text := strings.TrimRight(output.String(), "\n")
+	if len(text) > maxRunnableOutputBytes {
+		text = "... (output cut, showing the end)\n" + text[len(text)-maxRunnableOutputBytes:]
+	}
+	if text == "" {
+		text = "(no output)"
+	}
+	return fmt.Sprintf("Ran %s: %s\nResult: %s\n\n%s", r.Label, commandLine(name, args), status, text), nil
+}
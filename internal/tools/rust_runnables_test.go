@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunnableCommand(t *testing.T) {
+	test := lsp.Runnable{
+		Label: "test tests::parse",
+		Kind:  "cargo",
+		Args: lsp.RunnableArgs{
+			WorkspaceRoot:  "/src/app",
+			CargoArgs:      []string{"test", "--package", "app", "--lib"},
+			ExecutableArgs: []string{"tests::parse", "--exact", "--nocapture"},
+		},
+	}
+	name, args, dir, err := runnableCommand(test)
+	require.NoError(t, err)
+	assert.Equal(t, "cargo", name)
+	assert.Equal(t, []string{"test", "--package", "app", "--lib", "--", "tests::parse", "--exact", "--nocapture"}, args)
+	assert.Equal(t, "/src/app", dir)
+
+	test.Args.OverrideCargo = "cross"
+	test.Args.Cwd = "/src/app/crates/core"
+	name, _, dir, err = runnableCommand(test)
+	require.NoError(t, err)
+	assert.Equal(t, "cross", name)
+	assert.Equal(t, "/src/app/crates/core", dir)
+
+	_, _, _, err = runnableCommand(lsp.Runnable{Label: "debug", Kind: "lldb"})
+	assert.Error(t, err)
+}
+
+func TestFormatRunnables(t *testing.T) {
+	runnables := []lsp.Runnable{
+		{
+			Label:    "test tests::parse",
+			Location: &protocol.LocationLink{TargetURI: protocol.URIFromPath("/src/lib.rs"), TargetRange: protocol.Range{Start: protocol.Position{Line: 11}}},
+			Kind:     "cargo",
+			Args:     lsp.RunnableArgs{CargoArgs: []string{"test"}, ExecutableArgs: []string{"tests::parse", "--exact"}},
+		},
+		{
+			Label: "run main",
+			Kind:  "shell",
+			Args:  lsp.RunnableArgs{Program: "./run script.sh", ProgramArgs: []string{"--release"}},
+		},
+	}
+	assert.Equal(t, "Runnables in /src/lib.rs:\n\n"+
+		"- test tests::parse (/src/lib.rs:12)\n  cargo test -- tests::parse --exact\n"+
+		"- run main\n  \"./run script.sh\" --release\n\n"+
+		"Pass a label as run to run it.", formatRunnables(runnables, "/src/lib.rs"))
+	assert.Equal(t, "No runnables found in /src/lib.rs", formatRunnables(nil, "/src/lib.rs"))
+}
+
+func TestRunRunnable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("runs sh")
+	}
+	text, err := runRunnable(context.Background(), lsp.Runnable{
+		Label: "check",
+		Kind:  "shell",
+		Args: lsp.RunnableArgs{
+			Program:     "sh",
+			ProgramArgs: []string{"-c", "echo $GREETING; echo failed >&2; exit 3"},
+			Environment: map[string]string{"GREETING": "hello"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Ran check: sh -c \"echo $GREETING; echo failed >&2; exit 3\"\nResult: exit status 3\n\nhello\nfailed", text)
+}
@@ -0,0 +1,26 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// SwitchSourceHeader returns the header of a C or C++ source file, or the source file of a
+// header, as found by clangd from the file names next to it and from its index
+func SwitchSourceHeader(ctx context.Context, client *lsp.Client, filePath string) (string, error) {
+	if err := client.OpenFileForQuery(ctx, filePath); err != nil {
+		return "", fmt.Errorf("could not open file: %v", err)
+	}
+
+	uri, err := client.SwitchSourceHeader(ctx, protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)})
+	if err != nil {
+		return "", fmt.Errorf("failed to switch between source and header: %v", err)
+	}
+	if uri == "" {
+		return fmt.Sprintf("No corresponding source or header file found for %s", filePath), nil
+	}
+	return fmt.Sprintf("Corresponding file of %s: %s", filePath, uri.Path()), nil
+}
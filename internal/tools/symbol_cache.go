@@ -0,0 +1,375 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Version of the symbol cache file format. Files of other versions are ignored.
+const symbolCacheVersion = 1
+
+// Limits on the entries kept by a SymbolCache, evicting the least recently used first
+const (
+	maxCachedOutlines = 20000
+	maxCachedQueries  = 1000
+)
+
+// SymbolCache keeps the document symbols of files and the results of workspace symbol
+// queries on disk, so that after a restart the tools can answer from it while the
+// language server is still indexing. Entries are only used while the files they come
+// from have the content they were recorded with. Its methods do nothing on a nil cache.
+type SymbolCache struct {
+	mu    sync.Mutex
+	path  string
+	dirty bool
+	data  symbolCacheData
+}
+
+type symbolCacheData struct {
+	Version int `json:"version"`
+	// Server is the language server command the symbols come from
+	Server   string                   `json:"server"`
+	Outlines map[string]cachedOutline `json:"outlines"`
+	Queries  map[string]cachedQuery   `json:"queries"`
+}
+
+// cachedOutline holds the document symbols of a file with the hash of its content
+type cachedOutline struct {
+	Hash    string                                         `json:"hash"`
+	Symbols protocol.Or_Result_textDocument_documentSymbol `json:"symbols"`
+	Used    time.Time                                      `json:"used"`
+}
+
+// cachedQuery holds the results of a workspace symbol query with the hashes of the
+// files they are in
+type cachedQuery struct {
+	Hashes  map[string]string                   `json:"hashes"`
+	Symbols protocol.Or_Result_workspace_symbol `json:"symbols"`
+	Used    time.Time                           `json:"used"`
+}
+
+// OpenSymbolCache loads the symbol cache kept in a file for a language server command.
+// A missing or unreadable file, or one written for another server, starts an empty cache.
+func OpenSymbolCache(path, server string) *SymbolCache {
+	c := &SymbolCache{path: path}
+	content, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(content, &c.data)
+	}
+	switch {
+	case err != nil && !os.IsNotExist(err):
+		toolsLogger.Warn("Ignoring symbol cache %s: %v", path, err)
+		c.data = symbolCacheData{}
+	case err == nil && (c.data.Version != symbolCacheVersion || c.data.Server != server):
+		toolsLogger.Info("Ignoring symbol cache %s written by another version or server", path)
+		c.data = symbolCacheData{}
+	case err == nil:
+		toolsLogger.Info("Loaded %d outlines and %d queries from symbol cache %s",
+			len(c.data.Outlines), len(c.data.Queries), path)
+	}
+	c.data.Version = symbolCacheVersion
+	c.data.Server = server
+	if c.data.Outlines == nil {
+		c.data.Outlines = make(map[string]cachedOutline)
+	}
+	if c.data.Queries == nil {
+		c.data.Queries = make(map[string]cachedQuery)
+	}
+	return c
+}
+
+// Save writes the cache to its file if it changed since it was loaded or last saved
+func (c *SymbolCache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	content, err := json.Marshal(c.data)
+	if err != nil {
+		return fmt.Errorf("failed to encode symbol cache: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create symbol cache directory: %v", err)
+	}
+	// Write to a temporary file first so that a crash never leaves a truncated cache
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("failed to write symbol cache: %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write symbol cache: %v", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// fileHash returns the hash of a file's content, or "" if it can't be read
+func fileHash(ctx context.Context, path string) string {
+	content, err := readFile(ctx, path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// outline returns the cached document symbols of a file, if the file didn't change
+func (c *SymbolCache) outline(ctx context.Context, path string) (protocol.Or_Result_textDocument_documentSymbol, bool) {
+	if c == nil {
+		return protocol.Or_Result_textDocument_documentSymbol{}, false
+	}
+	hash := fileHash(ctx, path)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.data.Outlines[path]
+	if !ok || hash == "" || entry.Hash != hash {
+		return protocol.Or_Result_textDocument_documentSymbol{}, false
+	}
+	entry.Used = time.Now()
+	c.data.Outlines[path] = entry
+	return entry.Symbols, true
+}
+
+// recordOutline caches the document symbols of a file
+func (c *SymbolCache) recordOutline(ctx context.Context, path string, symbols protocol.Or_Result_textDocument_documentSymbol) {
+	if c == nil {
+		return
+	}
+	hash := fileHash(ctx, path)
+	if hash == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data.Outlines[path] = cachedOutline{Hash: hash, Symbols: symbols, Used: time.Now()}
+	evictOldest(c.data.Outlines, maxCachedOutlines, func(e cachedOutline) time.Time { return e.Used })
+	c.dirty = true
+}
+
+// query returns the cached results of a workspace symbol query, if none of the files
+// they are in changed
+func (c *SymbolCache) query(ctx context.Context, query string) (protocol.Or_Result_workspace_symbol, bool) {
+	if c == nil {
+		return protocol.Or_Result_workspace_symbol{}, false
+	}
+	c.mu.Lock()
+	entry, ok := c.data.Queries[query]
+	c.mu.Unlock()
+	if !ok {
+		return protocol.Or_Result_workspace_symbol{}, false
+	}
+	for path, hash := range entry.Hashes {
+		if fileHash(ctx, path) != hash {
+			return protocol.Or_Result_workspace_symbol{}, false
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.Used = time.Now()
+	c.data.Queries[query] = entry
+	return entry.Symbols, true
+}
+
+// recordQuery caches the results of a workspace symbol query
+func (c *SymbolCache) recordQuery(ctx context.Context, query string, symbols protocol.Or_Result_workspace_symbol) {
+	if c == nil {
+		return
+	}
+	results, err := symbols.Results()
+	if err != nil {
+		return
+	}
+	hashes := make(map[string]string)
+	for _, symbol := range results {
+		path := symbol.GetLocation().URI.Path()
+		if _, ok := hashes[path]; ok {
+			continue
+		}
+		hash := fileHash(ctx, path)
+		if hash == "" {
+			// Results in documents that are not files can't be checked for changes
+			return
+		}
+		hashes[path] = hash
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data.Queries[query] = cachedQuery{Hashes: hashes, Symbols: symbols, Used: time.Now()}
+	evictOldest(c.data.Queries, maxCachedQueries, func(e cachedQuery) time.Time { return e.Used })
+	c.dirty = true
+}
+
+// searchOutlines looks for a name among the cached document symbols of the files that
+// didn't change, as symbol_search would in the workspace: names containing the query,
+// ignoring case, match
+func (c *SymbolCache) searchOutlines(ctx context.Context, query string) []protocol.SymbolInformation {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	paths := make([]string, 0, len(c.data.Outlines))
+	for path := range c.data.Outlines {
+		paths = append(paths, path)
+	}
+	c.mu.Unlock()
+	slices.Sort(paths)
+
+	var found []protocol.SymbolInformation
+	for _, path := range paths {
+		outline, ok := c.outline(ctx, path)
+		if !ok {
+			continue
+		}
+		symbols, err := outline.Results()
+		if err != nil {
+			continue
+		}
+		found = append(found, matchingSymbols(protocol.URIFromPath(path), symbols, query)...)
+	}
+	return found
+}
+
+// matchingSymbols returns the symbols of a file whose qualified names contain the query,
+// ignoring case, located at their whole declarations
+func matchingSymbols(uri protocol.DocumentUri, symbols []protocol.DocumentSymbolResult, query string) []protocol.SymbolInformation {
+	want := strings.ToLower(normalizeSymbolName(query))
+	var found []protocol.SymbolInformation
+	var search func(symbols []protocol.DocumentSymbolResult, container string)
+	search = func(symbols []protocol.DocumentSymbolResult, container string) {
+		for _, sym := range symbols {
+			switch v := sym.(type) {
+			case *protocol.DocumentSymbol:
+				name := qualifiedName(container, v.Name)
+				if strings.Contains(strings.ToLower(normalizeSymbolName(name)), want) {
+					found = append(found, protocol.SymbolInformation{
+						Name:          v.Name,
+						Kind:          v.Kind,
+						ContainerName: container,
+						Location:      protocol.Location{URI: uri, Range: v.Range},
+					})
+				}
+				children := make([]protocol.DocumentSymbolResult, len(v.Children))
+				for i := range v.Children {
+					children[i] = &v.Children[i]
+				}
+				search(children, name)
+			case *protocol.SymbolInformation:
+				name := qualifiedName(v.ContainerName, v.Name)
+				if strings.Contains(strings.ToLower(normalizeSymbolName(name)), want) {
+					found = append(found, *v)
+				}
+			}
+		}
+	}
+	search(symbols, "")
+	return found
+}
+
+// evictOldest removes the least recently used entries of a map beyond max
+func evictOldest[E any](entries map[string]E, max int, used func(E) time.Time) {
+	if len(entries) <= max {
+		return
+	}
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return used(entries[a]).Compare(used(entries[b]))
+	})
+	for _, key := range keys[:len(entries)-max] {
+		delete(entries, key)
+	}
+}
+
+// indexing reports whether the language server is still indexing the workspace, when
+// the symbol cache answers in its place
+func indexing(client *lsp.Client) bool {
+	state, _ := client.Indexing()
+	return state == lsp.IndexingBusy
+}
+
+// fetchDocumentSymbols asks the server for a file's document symbols and records them in
+// the options(ctx).SymbolIndex. While the server is indexing, or when it fails or finds no symbols,
+// the cached symbols of the file are used if it didn't change, and then the options(ctx).Fallback.
+// It also returns the name of the fallback the symbols come from, or "" for the server.
+func fetchDocumentSymbols(ctx context.Context, client *lsp.Client, path string) (protocol.Or_Result_textDocument_documentSymbol, string, error) {
+	if indexing(client) {
+		if cached, ok := options(ctx).SymbolIndex.outline(ctx, path); ok {
+			toolsLogger.Debug("Using cached document symbols of %s while the server is indexing", path)
+			return cached, "", nil
+		}
+	}
+
+	result, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(path)},
+	})
+	if symbols, _ := result.Results(); err == nil && len(symbols) > 0 {
+		options(ctx).SymbolIndex.recordOutline(ctx, path, result)
+		return result, "", nil
+	}
+	if cached, ok := options(ctx).SymbolIndex.outline(ctx, path); ok {
+		toolsLogger.Debug("Using cached document symbols of %s", path)
+		return cached, "", nil
+	}
+	if symbols, ok := options(ctx).Fallback.documentSymbols(ctx, path, client.PositionEncoding()); ok {
+		toolsLogger.Debug("Using the symbols %s read from %s", options(ctx).Fallback.Name(), path)
+		return protocol.Or_Result_textDocument_documentSymbol{Value: symbols}, options(ctx).Fallback.Name(), nil
+	}
+	return result, "", err
+}
+
+// fetchWorkspaceSymbols asks the server for the workspace symbols matching a query and
+// records them in the options(ctx).SymbolIndex. While the server is indexing, the cached results of
+// the query are used if their files didn't change, and the names in the cached document
+// symbols if the server fails or finds nothing. The Fallback searches the workspace when
+// nothing else finds the query; the name of the fallback is returned with its results.
+func fetchWorkspaceSymbols(ctx context.Context, client *lsp.Client, query string) (protocol.Or_Result_workspace_symbol, string, error) {
+	busy := indexing(client)
+	if busy {
+		if cached, ok := options(ctx).SymbolIndex.query(ctx, query); ok {
+			toolsLogger.Debug("Using cached workspace symbols for %q while the server is indexing", query)
+			return cached, "", nil
+		}
+	}
+
+	result, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: query})
+	symbols, _ := result.Results()
+	if err == nil && len(symbols) > 0 {
+		options(ctx).SymbolIndex.recordQuery(ctx, query, result)
+		return result, "", nil
+	}
+	if cached, ok := options(ctx).SymbolIndex.query(ctx, query); ok && err != nil {
+		toolsLogger.Debug("Using cached workspace symbols for %q", query)
+		return cached, "", nil
+	}
+	if busy || err != nil {
+		if found := options(ctx).SymbolIndex.searchOutlines(ctx, query); len(found) > 0 {
+			toolsLogger.Debug("Found %q in cached document symbols while the server is indexing", query)
+			return protocol.Or_Result_workspace_symbol{Value: found}, "", nil
+		}
+	}
+	if found := options(ctx).Fallback.workspaceSymbols(ctx, query, client.PositionEncoding()); len(found) > 0 {
+		toolsLogger.Debug("Found %q with %s", query, options(ctx).Fallback.Name())
+		return protocol.Or_Result_workspace_symbol{Value: found}, options(ctx).Fallback.Name(), nil
+	}
+	return result, "", err
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolCache(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(source, []byte("package main\n\ntype Server struct{}\n\nfunc (s *Server) Start() {}\n"), 0644))
+	cacheFile := filepath.Join(dir, "cache", "symbols.json")
+
+	outline := protocol.Or_Result_textDocument_documentSymbol{Value: []protocol.DocumentSymbol{{
+		Name: "Server",
+		Kind: protocol.Struct,
+		Children: []protocol.DocumentSymbol{{
+			Name:           "Start",
+			Kind:           protocol.Method,
+			Range:          protocol.Range{Start: protocol.Position{Line: 4}, End: protocol.Position{Line: 4, Character: 27}},
+			SelectionRange: protocol.Range{Start: protocol.Position{Line: 4, Character: 17}},
+		}},
+	}}}
+	query := protocol.Or_Result_workspace_symbol{Value: []protocol.SymbolInformation{{
+		Name:     "Server",
+		Kind:     protocol.Struct,
+		Location: protocol.Location{URI: protocol.URIFromPath(source)},
+	}}}
+
+	cache := OpenSymbolCache(cacheFile, "gopls")
+	cache.recordOutline(context.Background(), source, outline)
+	cache.recordQuery(context.Background(), "Server", query)
+	require.NoError(t, cache.Save())
+
+	// A cache written for another server is ignored
+	other := OpenSymbolCache(cacheFile, "clangd")
+	_, ok := other.outline(context.Background(), source)
+	assert.False(t, ok)
+
+	reopened := OpenSymbolCache(cacheFile, "gopls")
+	cached, ok := reopened.outline(context.Background(), source)
+	require.True(t, ok)
+	symbols, err := cached.Results()
+	require.NoError(t, err)
+	require.Len(t, symbols, 1)
+	assert.Equal(t, "Server", symbols[0].GetName())
+
+	cachedQuery, ok := reopened.query(context.Background(), "Server")
+	require.True(t, ok)
+	results, err := cachedQuery.Results()
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	found := reopened.searchOutlines(context.Background(), "server.start")
+	require.Len(t, found, 1)
+	assert.Equal(t, "Start", found[0].Name)
+	assert.Equal(t, "Server", found[0].ContainerName)
+	assert.Equal(t, uint32(4), found[0].Location.Range.Start.Line)
+
+	// Entries are dropped once the file changes
+	require.NoError(t, os.WriteFile(source, []byte("package main\n\ntype Client struct{}\n"), 0644))
+	_, ok = reopened.outline(context.Background(), source)
+	assert.False(t, ok)
+	_, ok = reopened.query(context.Background(), "Server")
+	assert.False(t, ok)
+	assert.Empty(t, reopened.searchOutlines(context.Background(), "Start"))
+
+	// A nil cache does nothing
+	var disabled *SymbolCache
+	disabled.recordOutline(context.Background(), source, outline)
+	_, ok = disabled.outline(context.Background(), source)
+	assert.False(t, ok)
+	assert.NoError(t, disabled.Save())
+}
+
+func TestEvictOldest(t *testing.T) {
+	now := time.Now()
+	entries := map[string]time.Time{
+		"a": now.Add(-time.Hour),
+		"b": now,
+		"c": now.Add(-time.Minute),
+	}
+	evictOldest(entries, 2, func(used time.Time) time.Time { return used })
+	assert.Len(t, entries, 2)
+	assert.NotContains(t, entries, "a")
+}
@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+)
+
+// ResolveSymbolPosition finds the 1-indexed position of a symbol's name so that position
+// based tools can be addressed by name instead of exact coordinates. If filePath is set the
+// symbol is looked up among that file's document symbols, otherwise in the whole workspace.
+// Qualified names such as "Type.Method" or "Type::method" are matched against the symbol's
+// container.
+func ResolveSymbolPosition(ctx context.Context, client *lsp.Client, filePath, symbolName string) (string, int, int, error) {
+	if filePath != "" {
+		return resolveDocumentSymbol(ctx, client, filePath, symbolName)
+	}
+	return resolveWorkspaceSymbol(ctx, client, symbolName)
+}
+
+func resolveDocumentSymbol(ctx context.Context, client *lsp.Client, filePath, symbolName string) (string, int, int, error) {
+	_, nameRange, err := findDocumentSymbol(ctx, client, filePath, symbolName)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	line, column := locateName(ctx, filePath, nameRange, symbolName)
+	return filePath, line, column, nil
+}
+
+// findDocumentSymbol looks up a symbol among a file's document symbols and returns its
+// full range along with the range of its name
+func findDocumentSymbol(ctx context.Context, client *lsp.Client, filePath, symbolName string) (protocol.Range, protocol.Range, error) {
+	err := client.OpenFileForQuery(ctx, filePath)
+	if err != nil {
+		return protocol.Range{}, protocol.Range{}, fmt.Errorf("could not open file: %v", err)
+	}
+
+	symResult, _, err := fetchDocumentSymbols(ctx, client, filePath)
+	if err != nil {
+		return protocol.Range{}, protocol.Range{}, fmt.Errorf("failed to get document symbols: %v", err)
+	}
+
+	symbols, err := symResult.Results()
+	if err != nil {
+		return protocol.Range{}, protocol.Range{}, fmt.Errorf("failed to process document symbols: %v", err)
+	}
+
+	var fullRange, nameRange protocol.Range
+	var search func(symbols []protocol.DocumentSymbolResult, container string) bool
+	search = func(symbols []protocol.DocumentSymbolResult, container string) bool {
+		for _, sym := range symbols {
+			switch v := sym.(type) {
+			case *protocol.DocumentSymbol:
+				if symbolNameMatches(symbolName, v.Name, container) {
+					fullRange, nameRange = v.Range, v.SelectionRange
+					return true
+				}
+				children := make([]protocol.DocumentSymbolResult, len(v.Children))
+				for i := range v.Children {
+					children[i] = &v.Children[i]
+				}
+				if search(children, qualifiedName(container, v.Name)) {
+					return true
+				}
+			case *protocol.SymbolInformation:
+				if symbolNameMatches(symbolName, v.Name, v.ContainerName) {
+					fullRange, nameRange = v.Location.Range, v.Location.Range
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if !search(symbols, "") {
+		return protocol.Range{}, protocol.Range{}, fmt.Errorf("symbol %s not found in %s", symbolName, filePath)
+	}
+
+	return fullRange, nameRange, nil
+}
+
+func resolveWorkspaceSymbol(ctx context.Context, client *lsp.Client, symbolName string) (string, int, int, error) {
+	symbolResult, _, err := fetchWorkspaceSymbols(ctx, client, symbolName)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("failed to fetch symbol: %v", err)
+	}
+
+	results, err := symbolResult.Results()
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("failed to parse results: %v", err)
+	}
+
+	for _, symbol := range results {
+		container := ""
+		if v, ok := symbol.(*protocol.SymbolInformation); ok {
+			container = v.ContainerName
+		}
+		if !symbolNameMatches(symbolName, symbol.GetName(), container) {
+			continue
+		}
+
+		loc := symbol.GetLocation()
+		filePath := loc.URI.Path()
+		if err := client.OpenFileForQuery(ctx, filePath); err != nil {
+			toolsLogger.Error("Error opening file: %v", err)
+			continue
+		}
+
+		line, column := locateName(ctx, filePath, loc.Range, symbolName)
+		toolsLogger.Debug("Resolved symbol %s to %s:%d:%d", symbolName, filePath, line, column)
+		return filePath, line, column, nil
+	}
+
+	return "", 0, 0, fmt.Errorf("symbol %s not found", symbolName)
+}
+
+// symbolNameMatches reports whether a symbol reported by the server is the one requested.
+// Servers report methods in different shapes, e.g. "(*Type).Method" (gopls), "Type::method"
+// (clangd) or "method" with a container name. All of these match "Type.Method" and "Method".
+func symbolNameMatches(query, name, container string) bool {
+	name = normalizeSymbolName(name)
+	query = normalizeSymbolName(query)
+
+	if name == query {
+		return true
+	}
+
+	if strings.Contains(query, ".") {
+		return container != "" && qualifiedName(normalizeSymbolName(container), name) == query
+	}
+
+	// An unqualified query matches the last component of a qualified name
+	return strings.HasSuffix(name, "."+query)
+}
+
+func normalizeSymbolName(name string) string {
+	name = strings.ReplaceAll(name, "::", ".")
+	name = strings.ReplaceAll(name, "(*", "")
+	name = strings.ReplaceAll(name, "(", "")
+	name = strings.ReplaceAll(name, ")", "")
+	return name
+}
+
+func qualifiedName(container, name string) string {
+	if container == "" {
+		return name
+	}
+	return container + "." + name
+}
+
+// locateName returns the 1-indexed position of the symbol's own name within a range.
+// Some servers report the range of the whole declaration, so the name is searched for
+// from the start of the range, falling back to the start itself.
+func locateName(ctx context.Context, filePath string, rng protocol.Range, symbolName string) (int, int) {
+	line, column := int(rng.Start.Line)+1, int(rng.Start.Character)+1
+
+	name := normalizeSymbolName(symbolName)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+
+	content, err := readFile(ctx, filePath)
+	if err != nil {
+		return line, column
+	}
+	lines := utilities.SplitLines(string(content)).All()
+
+	for i := int(rng.Start.Line); i <= int(rng.End.Line) && i < len(lines); i++ {
+		text := lines[i]
+		offset := 0
+		if i == int(rng.Start.Line) {
+			offset = min(int(rng.Start.Character), len(text))
+		}
+		if idx := indexIdentifier(text[offset:], name); idx >= 0 {
+			return i + 1, offset + idx + 1
+		}
+	}
+
+	return line, column
+}
+
+// indexIdentifier returns the index of the first occurrence of name in text that is not
+// part of a longer identifier, or -1
+func indexIdentifier(text, name string) int {
+	isIdentChar := func(c byte) bool {
+		return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	}
+
+	for start := 0; start <= len(text)-len(name); {
+		idx := strings.Index(text[start:], name)
+		if idx < 0 {
+			return -1
+		}
+		idx += start
+		end := idx + len(name)
+		if (idx == 0 || !isIdentChar(text[idx-1])) && (end == len(text) || !isIdentChar(text[end])) {
+			return idx
+		}
+		start = idx + 1
+	}
+	return -1
+}
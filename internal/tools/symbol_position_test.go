@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbolNameMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		symbol    string
+		container string
+		expected  bool
+	}{
+		{"exact match", "Foo", "Foo", "", true},
+		{"different name", "Foo", "Bar", "", false},
+		{"gopls pointer receiver", "Type.Method", "(*Type).Method", "", true},
+		{"gopls value receiver", "Type.Method", "(Type).Method", "", true},
+		{"unqualified method", "Method", "(*Type).Method", "", true},
+		{"clangd qualified name", "Class.method", "Class::method", "", true},
+		{"container name", "Class.method", "method", "Class", true},
+		{"wrong container", "Other.method", "method", "Class", false},
+		{"qualified query without container", "Class.method", "method", "", false},
+		{"suffix of longer name", "Method", "OtherMethod", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, symbolNameMatches(tt.query, tt.symbol, tt.container))
+		})
+	}
+}
+
+func TestIndexIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		ident    string
+		expected int
+	}{
+		{"simple", "func Foo() {", "Foo", 5},
+		{"skips longer identifiers", "func FooBar(x Foo) {", "Foo", 14},
+		{"skips suffix matches", "var myFoo, Foo int", "Foo", 11},
+		{"not found", "func FooBar() {", "Foo", -1},
+		{"at end of line", "type Foo", "Foo", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, indexIdentifier(tt.text, tt.ident))
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// DefaultMaxSymbols is how many symbols SearchSymbols returns unless told otherwise
+const DefaultMaxSymbols = 50
+
+// symbolKindGroups are the names of groups of symbol kinds SearchSymbols can be limited to
+var symbolKindGroups = map[string][]protocol.SymbolKind{
+	"types":     {protocol.Class, protocol.Interface, protocol.Struct, protocol.Enum, protocol.TypeParameter},
+	"functions": {protocol.Function, protocol.Method, protocol.Constructor},
+	"variables": {protocol.Variable, protocol.Constant, protocol.Field, protocol.Property, protocol.EnumMember},
+	"modules":   {protocol.Module, protocol.Namespace, protocol.Package},
+}
+
+// ParseSymbolKinds converts symbol kind names such as "class" or "function", or the names
+// of groups of them ("types", "functions", "variables" and "modules"), to symbol kinds
+func ParseSymbolKinds(names []string) ([]protocol.SymbolKind, error) {
+	var kinds []protocol.SymbolKind
+	for _, name := range names {
+		if group, ok := symbolKindGroups[strings.ToLower(name)]; ok {
+			kinds = append(kinds, group...)
+			continue
+		}
+		found := false
+		for kind, kindName := range protocol.TableKindMap {
+			if strings.EqualFold(kindName, name) {
+				kinds = append(kinds, kind)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown symbol kind %q", name)
+		}
+	}
+	return kinds, nil
+}
+
+// rankedSymbol is a workspace symbol with how well it matches the query
+type rankedSymbol struct {
+	name, container string
+	kind            protocol.SymbolKind
+	location        protocol.Location
+	score           int
+}
+
+// SearchSymbols searches the workspace for symbols matching a fuzzy query, as editors do
+// when jumping to a symbol: the letters of the query must appear in order in the name,
+// and names where they start words, as in "gfd" for GetFullDefinition, rank first. The
+// server's workspace symbols are ranked rather than trusted as they are, since servers
+// match queries differently. Results can be limited to kinds of symbols and to the files
+// passing filter, and are paginated by page.
+func SearchSymbols(ctx context.Context, client *lsp.Client, query string, kinds []protocol.SymbolKind, page Pagination, filter PathFilter) (string, error) {
+	results, source, err := workspaceSymbols(ctx, client, query)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 && len([]rune(query)) > 1 {
+		// Servers that only match prefixes or substrings miss abbreviations, so ask for
+		// the symbols starting with the first letter and rank those instead
+		results, source, err = workspaceSymbols(ctx, client, string([]rune(query)[:1]))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var ranked []rankedSymbol
+	for _, result := range results {
+		symbol := rankedSymbol{name: result.GetName(), location: result.GetLocation()}
+		switch v := result.(type) {
+		case *protocol.SymbolInformation:
+			symbol.kind, symbol.container = v.Kind, v.ContainerName
+		case *protocol.WorkspaceSymbol:
+			symbol.kind, symbol.container = v.Kind, v.ContainerName
+		}
+		if len(kinds) > 0 && !slices.Contains(kinds, symbol.kind) {
+			continue
+		}
+		if !filter.Allows(symbol.location.URI.Path()) {
+			continue
+		}
+		score, ok := fuzzyScore(query, symbol.name)
+		if !ok {
+			continue
+		}
+		symbol.score = score
+		ranked = append(ranked, symbol)
+	}
+	if len(ranked) == 0 {
+		return fmt.Sprintf("No symbols found matching %q", query), nil
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		x, y := ranked[i], ranked[j]
+		if x.score != y.score {
+			return x.score > y.score
+		}
+		if len(x.name) != len(y.name) {
+			return len(x.name) < len(y.name)
+		}
+		if x.name != y.name {
+			return x.name < y.name
+		}
+		return x.location.URI < y.location.URI
+	})
+
+	total := len(ranked)
+	start := min(page.Offset, total)
+	end := total
+	if page.MaxResults > 0 {
+		end = min(start+page.MaxResults, total)
+	}
+
+	var b strings.Builder
+	b.WriteString(syntacticNote(source))
+	columns := newFileLines(ctx, client)
+	for i, symbol := range ranked[start:end] {
+		detail := protocol.TableKindMap[symbol.kind]
+		if symbol.container != "" {
+			detail += ", in " + symbol.container
+		}
+		loc := symbol.location
+		fmt.Fprintf(&b, "%d. %s (%s) %s L%d:C%d\n", start+i+1, symbol.name, detail,
+			loc.URI.Path(), loc.Range.Start.Line+1, columns.column(loc.URI, loc.Range.Start))
+	}
+	if start > 0 || end < total {
+		fmt.Fprintf(&b, "\nShowing %d of %d symbols", end-start, total)
+		if end < total {
+			fmt.Fprintf(&b, ". Use offset %d to see more.", end)
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// workspaceSymbols asks the server for the workspace symbols matching a query, and
+// returns them with the name of the fallback they come from, if any
+func workspaceSymbols(ctx context.Context, client *lsp.Client, query string) ([]protocol.WorkspaceSymbolResult, string, error) {
+	symbolResult, source, err := fetchWorkspaceSymbols(ctx, client, query)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to search workspace symbols: %v", err)
+	}
+	results, err := symbolResult.Results()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse results: %v", err)
+	}
+	return results, source, nil
+}
+
+// Scores of the parts of a fuzzy match
+const (
+	matchScore       = 1
+	caseMatchScore   = 1
+	wordStartScore   = 8
+	consecutiveScore = 5
+	exactScore       = 100
+	prefixScore      = 20
+	// Longest leading gap penalized, so that names are not buried by their prefixes
+	maxLeadingPenalty = 3
+)
+
+// fuzzyScore reports whether the letters of query appear in name in order, ignoring
+// case, and scores the best such match. Letters matching the start of a word in name,
+// such as the S in HandleServer or handle_server, and runs of consecutive letters score
+// higher, and skipped letters cost a point each.
+func fuzzyScore(query, name string) (int, bool) {
+	q, n := []rune(query), []rune(name)
+	if len(q) == 0 {
+		return 0, true
+	}
+	if len(q) > len(n) {
+		return 0, false
+	}
+
+	bonus := func(i, j int) int {
+		score := matchScore
+		if q[i] == n[j] {
+			score += caseMatchScore
+		}
+		if isWordStart(n, j) {
+			score += wordStartScore
+		}
+		return score
+	}
+
+	// best[j] is the best score of the query so far with its last letter matching n[j]
+	const none = -1 << 30
+	best := make([]int, len(n))
+	for j := range n {
+		best[j] = none
+		if unicode.ToLower(q[0]) == unicode.ToLower(n[j]) {
+			best[j] = bonus(0, j) - min(j, maxLeadingPenalty)
+		}
+	}
+	for i := 1; i < len(q); i++ {
+		next := make([]int, len(n))
+		for j := range n {
+			next[j] = none
+			if unicode.ToLower(q[i]) != unicode.ToLower(n[j]) {
+				continue
+			}
+			for k := 0; k < j; k++ {
+				if best[k] == none {
+					continue
+				}
+				score := best[k] + bonus(i, j)
+				if k == j-1 {
+					score += consecutiveScore
+				} else {
+					score -= j - k - 1
+				}
+				next[j] = max(next[j], score)
+			}
+		}
+		best = next
+	}
+
+	score := none
+	for _, s := range best {
+		score = max(score, s)
+	}
+	if score == none {
+		return 0, false
+	}
+	switch {
+	case strings.EqualFold(query, name):
+		score += exactScore
+	case len(n) >= len(q) && strings.EqualFold(query, string(n[:len(q)])):
+		score += prefixScore
+	}
+	return score, true
+}
+
+// isWordStart reports whether the letter at i in name starts a word: the first letter, an
+// upper case letter after a lower case one, or a letter after a separator such as _ or .
+func isWordStart(name []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := name[i-1], name[i]
+	switch {
+	case unicode.IsUpper(cur) && unicode.IsLower(prev):
+		return true
+	case unicode.IsLetter(cur) || unicode.IsDigit(cur):
+		return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+	}
+	return false
+}
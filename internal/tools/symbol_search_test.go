@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	t.Run("matches", func(t *testing.T) {
+		for _, tt := range []struct {
+			query, name string
+			matches     bool
+		}{
+			{"gfd", "GetFullDefinition", true},
+			{"hs", "handle_server", true},
+			{"Server", "(*Server).Start", true},
+			{"", "Anything", true},
+			{"sg", "GetServer", false},
+			{"longer", "long", false},
+		} {
+			_, ok := fuzzyScore(tt.query, tt.name)
+			assert.Equal(t, tt.matches, ok, "%q in %q", tt.query, tt.name)
+		}
+	})
+
+	t.Run("ranking", func(t *testing.T) {
+		rank := func(query string, names ...string) []string {
+			sort.SliceStable(names, func(i, j int) bool {
+				x, _ := fuzzyScore(query, names[i])
+				y, _ := fuzzyScore(query, names[j])
+				return x > y
+			})
+			return names
+		}
+
+		// Word starts beat letters in the middle of words
+		assert.Equal(t, []string{"GetFullDefinition", "getfield"}, rank("gfd", "getfield", "GetFullDefinition"))
+		assert.Equal(t, []string{"handle_server", "hashes"}, rank("hs", "hashes", "handle_server"))
+		// Exact matches beat prefixes, which beat other matches
+		assert.Equal(t, []string{"Client", "ClientConfig", "NewClient"}, rank("client", "NewClient", "ClientConfig", "Client"))
+	})
+}
+
+func TestParseSymbolKinds(t *testing.T) {
+	kinds, err := ParseSymbolKinds([]string{"functions", "Struct"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []protocol.SymbolKind{protocol.Function, protocol.Method, protocol.Constructor, protocol.Struct}, kinds)
+
+	kinds, err = ParseSymbolKinds([]string{"typeparameter"})
+	require.NoError(t, err)
+	assert.Equal(t, []protocol.SymbolKind{protocol.TypeParameter}, kinds)
+
+	_, err = ParseSymbolKinds([]string{"widget"})
+	assert.Error(t, err)
+}
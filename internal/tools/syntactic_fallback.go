@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/syntax"
+)
+
+// Most files the fallback reads when searching the workspace for a name
+const maxFallbackFiles = 20000
+
+// SyntacticFallback reads the symbols of the files of a workspace with a syntax provider,
+// keeping them while the files don't change
+type SyntacticFallback struct {
+	root     string
+	provider syntax.Provider
+
+	mu       sync.Mutex
+	outlines map[string]fallbackOutline
+}
+
+type fallbackOutline struct {
+	hash     string
+	encoding protocol.PositionEncodingKind
+	symbols  []protocol.DocumentSymbol
+}
+
+// NewSyntacticFallback returns a fallback reading the files under root with provider
+func NewSyntacticFallback(root string, provider syntax.Provider) *SyntacticFallback {
+	return &SyntacticFallback{
+		root:     root,
+		provider: provider,
+		outlines: make(map[string]fallbackOutline),
+	}
+}
+
+// Name tells where the fallback's symbols come from
+func (f *SyntacticFallback) Name() string {
+	return f.provider.Name()
+}
+
+// documentSymbols returns the symbols declared in a file, with characters counted in
+// encoding, if the provider reads its language and finds any
+func (f *SyntacticFallback) documentSymbols(ctx context.Context, path string, encoding protocol.PositionEncodingKind) ([]protocol.DocumentSymbol, bool) {
+	if f == nil || !f.provider.Supports(path) {
+		return nil, false
+	}
+	content, err := readFile(ctx, path)
+	if err != nil {
+		return nil, false
+	}
+	hash := fileHash(ctx, path)
+
+	f.mu.Lock()
+	cached, ok := f.outlines[path]
+	f.mu.Unlock()
+	if ok && cached.hash == hash && cached.encoding == encoding {
+		return cached.symbols, len(cached.symbols) > 0
+	}
+
+	symbols, err := f.provider.Symbols(ctx, path, content, encoding)
+	if err != nil {
+		toolsLogger.Debug("Failed to read symbols of %s with %s: %v", path, f.Name(), err)
+		return nil, false
+	}
+	f.mu.Lock()
+	f.outlines[path] = fallbackOutline{hash: hash, encoding: encoding, symbols: symbols}
+	f.mu.Unlock()
+	return symbols, len(symbols) > 0
+}
+
+// workspaceSymbols searches the files of the workspace the provider reads for the
+// symbols whose names contain the query, with characters counted in encoding
+func (f *SyntacticFallback) workspaceSymbols(ctx context.Context, query string, encoding protocol.PositionEncodingKind) []protocol.SymbolInformation {
+	if f == nil {
+		return nil
+	}
+	var found []protocol.SymbolInformation
+	files := 0
+	err := walkWorkspaceFiles(ctx, f.root, func(path string) error {
+		if !f.provider.Supports(path) {
+			return nil
+		}
+		if files++; files > maxFallbackFiles {
+			return fmt.Errorf("more than %d files to search", maxFallbackFiles)
+		}
+		symbols, ok := f.documentSymbols(ctx, path, encoding)
+		if !ok {
+			return nil
+		}
+		results := make([]protocol.DocumentSymbolResult, len(symbols))
+		for i := range symbols {
+			results[i] = &symbols[i]
+		}
+		found = append(found, matchingSymbols(protocol.URIFromPath(path), results, query)...)
+		return nil
+	})
+	if err != nil {
+		toolsLogger.Debug("Stopped searching the workspace with %s: %v", f.Name(), err)
+	}
+	return found
+}
+
+// syntacticNote marks the results of a tool that come from the fallback rather than the
+// language server, given where they come from
+func syntacticNote(source string) string {
+	if source == "" {
+		return ""
+	}
+	return fmt.Sprintf("Note: the language server had nothing for this, so these results come from the syntax of the files (%s). They are approximate: names are matched as written, without resolving types or imports.\n\n", source)
+}
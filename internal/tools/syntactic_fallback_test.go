@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineProvider reads a symbol from each "def NAME" line of .txt files
+type lineProvider struct {
+	reads int
+}
+
+func (p *lineProvider) Name() string { return "lines" }
+
+func (p *lineProvider) Supports(path string) bool { return filepath.Ext(path) == ".txt" }
+
+func (p *lineProvider) Symbols(ctx context.Context, path string, content []byte, encoding protocol.PositionEncodingKind) ([]protocol.DocumentSymbol, error) {
+	p.reads++
+	var symbols []protocol.DocumentSymbol
+	for i, line := range strings.Split(string(content), "\n") {
+		if name, ok := strings.CutPrefix(line, "def "); ok {
+			rng := protocol.Range{Start: protocol.Position{Line: uint32(i)}, End: protocol.Position{Line: uint32(i), Character: uint32(len(line))}}
+			symbols = append(symbols, protocol.DocumentSymbol{Name: name, Kind: protocol.Function, Range: rng, SelectionRange: rng})
+		}
+	}
+	return symbols, nil
+}
+
+func TestSyntacticFallback(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	require.NoError(t, os.WriteFile(a, []byte("def parseConfig\ndef run\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.txt"), []byte("def loadConfig\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.md"), []byte("def parseConfig\n"), 0644))
+
+	provider := &lineProvider{}
+	fallback := NewSyntacticFallback(dir, provider)
+
+	found := fallback.workspaceSymbols(context.Background(), "config", protocol.UTF16)
+	var names []string
+	for _, symbol := range found {
+		names = append(names, symbol.Name)
+	}
+	assert.ElementsMatch(t, []string{"parseConfig", "loadConfig"}, names)
+	assert.Equal(t, 2, provider.reads)
+
+	// Files are only read again once they change
+	symbols, ok := fallback.documentSymbols(context.Background(), a, protocol.UTF16)
+	require.True(t, ok)
+	assert.Len(t, symbols, 2)
+	assert.Equal(t, 2, provider.reads)
+
+	require.NoError(t, os.WriteFile(a, []byte("def main\n"), 0644))
+	symbols, ok = fallback.documentSymbols(context.Background(), a, protocol.UTF16)
+	require.True(t, ok)
+	assert.Equal(t, "main", symbols[0].Name)
+	assert.Equal(t, 3, provider.reads)
+
+	_, ok = fallback.documentSymbols(context.Background(), filepath.Join(dir, "c.md"), protocol.UTF16)
+	assert.False(t, ok)
+
+	var disabled *SyntacticFallback
+	assert.Empty(t, disabled.workspaceSymbols(context.Background(), "config", protocol.UTF16))
+	assert.Empty(t, syntacticNote(""))
+	assert.Contains(t, syntacticNote("lines"), "(lines)")
+}
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// Upper bound on the number of matches collected by a workspace text search
+const maxTextMatches = 500
+
+// searchWorkspaceText finds the occurrences of a literal in the text files under root,
+// skipping the directories and files the workspace watcher ignores, with their characters
+// in the given encoding. The second return value reports whether the search stopped early
+// because maxMatches was reached.
+func searchWorkspaceText(ctx context.Context, root, literal string, maxMatches int, encoding protocol.PositionEncodingKind) ([]protocol.Location, bool, error) {
+	var matches []protocol.Location
+	truncated := false
+	needle := []byte(literal)
+
+	err := walkWorkspaceFiles(ctx, root, func(path string) error {
+		content, err := os.ReadFile(path)
+		if err != nil || !bytes.Contains(content, needle) || isBinary(content) {
+			return nil
+		}
+
+		uri := protocol.URIFromPath(path)
+		for lineNum, line := range bytes.Split(content, []byte("\n")) {
+			offset := 0
+			for {
+				idx := bytes.Index(line[offset:], needle)
+				if idx < 0 {
+					break
+				}
+				start := offset + idx
+				character := encoding.CharacterLen(string(line[:start]))
+				matches = append(matches, protocol.Location{
+					URI: uri,
+					Range: protocol.Range{
+						Start: protocol.Position{Line: uint32(lineNum), Character: uint32(character)},
+						End:   protocol.Position{Line: uint32(lineNum), Character: uint32(character + encoding.CharacterLen(literal))},
+					},
+				})
+				if len(matches) >= maxMatches {
+					truncated = true
+					return filepath.SkipAll
+				}
+				offset = start + len(needle)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return matches, truncated, nil
+}
+
+// isBinary guesses whether content is binary by looking for NUL bytes near its start
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// TSRenameFileCommand is the typescript-language-server command updating the imports of
+// a file that moves, which it applies with a workspace/applyEdit request
+const TSRenameFileCommand = "_typescript.applyRenameFile"
+
+// How long to wait for the server to apply the edits of TSRenameFileCommand once the
+// command returned, as some versions of the server apply them after answering, and send
+// nothing when no file needs changes
+var tsRenameEditWait = 2 * time.Second
+
+// tsRenameFileArgs are the arguments of TSRenameFileCommand
+type tsRenameFileArgs struct {
+	SourceURI protocol.DocumentUri `json:"sourceUri"`
+	TargetURI protocol.DocumentUri `json:"targetUri"`
+}
+
+// TSRenameFile moves or renames a file or directory with typescript-language-server's
+// _typescript.applyRenameFile command, which has the TypeScript server update the imports
+// and references to it across the project, as the editor does when a file is renamed in
+// its explorer. The server applies the edits itself, before the file is moved, so they
+// can't be previewed.
+func TSRenameFile(ctx context.Context, client *lsp.Client, oldPath, newPath string) (string, error) {
+	info, err := os.Stat(oldPath)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %v", oldPath, err)
+	}
+	if _, err := os.Lstat(newPath); err == nil {
+		return "", fmt.Errorf("%s already exists", newPath)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("could not read %s: %v", newPath, err)
+	}
+	if oldPath == newPath || strings.HasPrefix(newPath, oldPath+string(filepath.Separator)) {
+		return "", fmt.Errorf("cannot move %s into itself", oldPath)
+	}
+	if err := prepareWrite(ctx, oldPath, newPath); err != nil {
+		return "", err
+	}
+
+	arg, err := json.Marshal(tsRenameFileArgs{
+		SourceURI: protocol.URIFromPath(oldPath),
+		TargetURI: protocol.URIFromPath(newPath),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Collect the files the server's edits change
+	var mu sync.Mutex
+	var updated []string
+	applied := make(chan struct{}, 1)
+	stop := client.WatchEdits(func(paths []string) {
+		mu.Lock()
+		for _, path := range paths {
+			if !slices.Contains(updated, path) {
+				updated = append(updated, path)
+			}
+		}
+		mu.Unlock()
+		select {
+		case applied <- struct{}{}:
+		default:
+		}
+	})
+	defer stop()
+	// The files the server updates are checked and recorded like the moved file
+	stopGuard := guardServerEdits(ctx, client, func() {
+		select {
+		case applied <- struct{}{}:
+		default:
+		}
+	})
+	defer stopGuard()
+
+	if _, err := client.ExecuteCommand(ctx, protocol.ExecuteCommandParams{
+		Command:   TSRenameFileCommand,
+		Arguments: []json.RawMessage{arg},
+	}); err != nil {
+		return "", fmt.Errorf("failed to get the edits for the move: %v", err)
+	}
+	select {
+	case <-applied:
+	case <-time.After(tsRenameEditWait):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	stop()
+	if err := stopGuard(); err != nil {
+		return "", err
+	}
+
+	// The server would otherwise keep the document open under its old name
+	if !info.IsDir() {
+		if err := client.CloseFile(ctx, oldPath); err != nil {
+			toolsLogger.Debug("Failed to close %s before moving it: %v", oldPath, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err := os.Rename(oldPath, newPath); err != nil {
+		if len(updated) > 0 {
+			return "", fmt.Errorf("failed to move %s, after the server updated %d files for the move: %v", oldPath, len(updated), err)
+		}
+		return "", fmt.Errorf("failed to move %s: %v", oldPath, err)
+	}
+	options(ctx).Files.Invalidate(oldPath)
+	if !info.IsDir() {
+		fileWritten(ctx, client, newPath)
+	}
+
+	kind := "file"
+	if info.IsDir() {
+		kind = "directory"
+	}
+	if len(updated) == 0 {
+		return fmt.Sprintf("Successfully moved %s %s to %s. The server updated no other files.", kind, oldPath, newPath), nil
+	}
+	var list strings.Builder
+	for _, path := range updated {
+		if path == oldPath || strings.HasPrefix(path, oldPath+string(filepath.Separator)) {
+			path = newPath + strings.TrimPrefix(path, oldPath)
+		}
+		fileWritten(ctx, client, path)
+		fmt.Fprintf(&list, "%s\n", path)
+	}
+	return fmt.Sprintf("Successfully moved %s %s to %s.\nThe server updated %d files:\n%s", kind, oldPath, newPath, len(updated), list.String()), nil
+}
@@ -1,24 +1,27 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
 )
 
-func ExtractTextFromLocation(loc protocol.Location) (string, error) {
-	path := strings.TrimPrefix(string(loc.URI), "file://")
+func ExtractTextFromLocation(ctx context.Context, loc protocol.Location, encoding protocol.PositionEncodingKind) (string, error) {
+	path := loc.URI.Path()
 
-	content, err := os.ReadFile(path)
+	content, err := readFile(ctx, path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
-	lines := strings.Split(string(content), "\n")
+	lines := utilities.SplitLines(string(content)).All()
 
 	startLine := int(loc.Range.Start.Line)
 	endLine := int(loc.Range.End.Line)
@@ -29,8 +32,8 @@ func ExtractTextFromLocation(loc protocol.Location) (string, error) {
 	// Handle single-line case
 	if startLine == endLine {
 		line := lines[startLine]
-		startChar := int(loc.Range.Start.Character)
-		endChar := int(loc.Range.End.Character)
+		startChar := encoding.CharacterToByte(line, loc.Range.Start.Character)
+		endChar := encoding.CharacterToByte(line, loc.Range.End.Character)
 
 		if startChar < 0 || startChar > len(line) || endChar < 0 || endChar > len(line) {
 			return "", fmt.Errorf("invalid character range: %v", loc.Range)
@@ -44,7 +47,7 @@ func ExtractTextFromLocation(loc protocol.Location) (string, error) {
 
 	// First line
 	firstLine := lines[startLine]
-	startChar := int(loc.Range.Start.Character)
+	startChar := encoding.CharacterToByte(firstLine, loc.Range.Start.Character)
 	if startChar < 0 || startChar > len(firstLine) {
 		return "", fmt.Errorf("invalid start character: %v", loc.Range.Start)
 	}
@@ -58,7 +61,7 @@ func ExtractTextFromLocation(loc protocol.Location) (string, error) {
 
 	// Last line
 	lastLine := lines[endLine]
-	endChar := int(loc.Range.End.Character)
+	endChar := encoding.CharacterToByte(lastLine, loc.Range.End.Character)
 	if endChar < 0 || endChar > len(lastLine) {
 		return "", fmt.Errorf("invalid end character: %v", loc.Range.End)
 	}
@@ -68,6 +71,54 @@ func ExtractTextFromLocation(loc protocol.Location) (string, error) {
 	return result.String(), nil
 }
 
+// groupLocationsByFile groups locations by the file they are in. URIs naming the same file
+// are grouped under the first one seen, so a file reached through a symlink and through its
+// target, or through differently cased paths on case-insensitive file systems, is shown once.
+func groupLocationsByFile(locations []protocol.Location) map[protocol.DocumentUri][]protocol.Location {
+	byFile := make(map[protocol.DocumentUri][]protocol.Location)
+	seen := make(map[string]protocol.DocumentUri)
+	for _, loc := range locations {
+		key := pathKey(loc.URI.Path())
+		uri, ok := seen[key]
+		if !ok {
+			uri = loc.URI
+			seen[key] = uri
+		}
+		byFile[uri] = append(byFile[uri], loc)
+	}
+	return byFile
+}
+
+// uniqueLocations sorts locations by path and then position, and drops those repeating an
+// earlier location: the same range in a URI naming the same file. Servers report some
+// locations more than once, such as references through macro expansions or re-exports.
+func uniqueLocations(locations []protocol.Location) []protocol.Location {
+	type key struct {
+		path string
+		rng  protocol.Range
+	}
+	seen := make(map[key]bool, len(locations))
+	unique := make([]protocol.Location, 0, len(locations))
+	for _, loc := range locations {
+		k := key{pathKey(loc.URI.Path()), loc.Range}
+		if !seen[k] {
+			seen[k] = true
+			unique = append(unique, loc)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		a, b := unique[i], unique[j]
+		if pa, pb := pathKey(a.URI.Path()), pathKey(b.URI.Path()); pa != pb {
+			return pa < pb
+		}
+		if a.Range.Start != b.Range.Start {
+			return positionBefore(a.Range.Start, b.Range.Start)
+		}
+		return positionBefore(a.Range.End, b.Range.End)
+	})
+	return unique
+}
+
 func containsPosition(r protocol.Range, p protocol.Position) bool {
 	if r.Start.Line > p.Line || r.End.Line < p.Line {
 		return false
@@ -81,9 +132,10 @@ func containsPosition(r protocol.Range, p protocol.Position) bool {
 	return true
 }
 
-// addLineNumbers adds line numbers to each line of text with proper padding, starting from startLine
+// addLineNumbers adds line numbers to each line of text with proper padding, starting from startLine.
+// Carriage returns left over from CRLF line endings are dropped.
 func addLineNumbers(text string, startLine int) string {
-	lines := strings.Split(text, "\n")
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
 	// Calculate padding width based on the number of digits in the last line number
 	lastLineNum := startLine + len(lines)
 	padding := len(strconv.Itoa(lastLineNum))
@@ -139,24 +191,45 @@ func ConvertLinesToRanges(linesToShow map[int]bool, totalLines int) []LineRange
 	return ranges
 }
 
-// FormatLinesWithRanges formats file content using line ranges
-func FormatLinesWithRanges(lines []string, ranges []LineRange) string {
+// FormatLinesWithRanges formats file content using line ranges, in a fenced markdown
+// code block tagged with the language of filePath, in the context style of the context's
+// options
+func FormatLinesWithRanges(ctx context.Context, filePath string, lines []string, ranges []LineRange) string {
+	return formatLines(ctx, filePath, func(i int) string { return lines[i] }, ranges)
+}
+
+// formatLines formats line ranges like FormatLinesWithRanges, getting each line shown
+// from line
+func formatLines(ctx context.Context, filePath string, line func(i int) string, ranges []LineRange) string {
 	if len(ranges) == 0 {
 		return ""
 	}
+	ranges = mergeLineRanges(ranges)
+
+	// The fence must be longer than any run of backticks in the lines it encloses
+	longest := 0
+	for _, r := range ranges {
+		for i := r.Start; i <= r.End; i++ {
+			longest = max(longest, longestBacktickRun(line(i)))
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
 
 	var result strings.Builder
+	result.WriteString(fence + markdownLanguage(filePath) + "\n")
 	lastEnd := -1
 
 	for _, r := range ranges {
 		// Add skipped lines indicator
 		if lastEnd != -1 && r.Start > lastEnd+1 {
-			// skipped := r.Start - lastEnd - 1
-			result.WriteString("...\n")
+			result.WriteString(options(ctx).Context.separator(r.Start - lastEnd - 1))
 		}
 
 		// Extract lines for this range
-		rangeLines := lines[r.Start : r.End+1]
+		rangeLines := make([]string, 0, r.End-r.Start+1)
+		for i := r.Start; i <= r.End; i++ {
+			rangeLines = append(rangeLines, line(i))
+		}
 
 		// Add line numbers using the existing function
 		numberedText := addLineNumbers(strings.Join(rangeLines, "\n"), r.Start+1)
@@ -165,5 +238,66 @@ func FormatLinesWithRanges(lines []string, ranges []LineRange) string {
 		lastEnd = r.End
 	}
 
+	result.WriteString(fence + "\n")
 	return result.String()
 }
+
+// mergeLineRanges sorts line ranges and merges those that overlap or touch, so that no
+// line is shown twice
+func mergeLineRanges(ranges []LineRange) []LineRange {
+	sorted := make([]LineRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	merged := sorted[:1]
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start <= last.End+1 {
+			last.End = max(last.End, r.End)
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// longestBacktickRun returns the length of the longest run of backticks in s
+func longestBacktickRun(s string) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// markdownLanguage returns the markdown code block language of a file, which is its
+// language identifier except for the few that markdown renderers know by other names
+func markdownLanguage(filePath string) string {
+	switch language := lsp.DetectLanguageID(filePath); language {
+	case protocol.LangTypeScriptReact:
+		return "tsx"
+	case protocol.LangJavaScriptReact:
+		return "jsx"
+	case protocol.LangShellScript:
+		return "sh"
+	case protocol.LangGitCommit, protocol.LangGitRebase:
+		return ""
+	default:
+		return string(language)
+	}
+}
+
+// partialResults decides what a tool formatting its results file by file does once its
+// context is done. It fails if the call was cancelled or nothing was formatted yet, and
+// otherwise returns a note to end the results formatted before the timeout with.
+func partialResults(err error, formatted, total int) (string, error) {
+	if !errors.Is(err, context.DeadlineExceeded) || formatted == 0 {
+		return "", err
+	}
+	return fmt.Sprintf("---\n\nTimed out: showing results from %d of %d files", formatted, total), nil
+}
@@ -1,13 +1,16 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Save original ReadFile function
@@ -191,6 +194,61 @@ func TestExtractTextFromLocation_FileError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestGroupLocationsByFile(t *testing.T) {
+	locations := []protocol.Location{
+		{URI: "file:///project/a.go", Range: protocol.Range{Start: protocol.Position{Line: 1}}},
+		{URI: "file:///project/b.go", Range: protocol.Range{Start: protocol.Position{Line: 2}}},
+		{URI: "file:///project/a.go", Range: protocol.Range{Start: protocol.Position{Line: 3}}},
+	}
+
+	byFile := groupLocationsByFile(locations)
+	assert.Len(t, byFile, 2)
+	assert.Equal(t, []protocol.Location{locations[0], locations[2]}, byFile["file:///project/a.go"])
+	assert.Equal(t, []protocol.Location{locations[1]}, byFile["file:///project/b.go"])
+}
+
+func TestUniqueLocations(t *testing.T) {
+	at := func(uri protocol.DocumentUri, line, start, end uint32) protocol.Location {
+		return protocol.Location{URI: uri, Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: start},
+			End:   protocol.Position{Line: line, Character: end},
+		}}
+	}
+	locations := []protocol.Location{
+		at("file:///project/b.go", 4, 1, 5),
+		at("file:///project/a.go", 9, 0, 3),
+		at("file:///project/b.go", 4, 1, 5),
+		at("file:///project/a.go", 2, 6, 8),
+		at("file:///project/a.go", 2, 6, 7),
+	}
+
+	assert.Equal(t, []protocol.Location{
+		at("file:///project/a.go", 2, 6, 7),
+		at("file:///project/a.go", 2, 6, 8),
+		at("file:///project/a.go", 9, 0, 3),
+		at("file:///project/b.go", 4, 1, 5),
+	}, uniqueLocations(locations))
+}
+
+func TestGroupLocationsThroughSymlinks(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	target := filepath.Join(dir, "project")
+	require.NoError(t, os.Mkdir(target, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(target, "a.go"), []byte("package a\n"), 0644))
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	real := protocol.Location{URI: protocol.URIFromPath(filepath.Join(target, "a.go"))}
+	linked := protocol.Location{URI: protocol.URIFromPath(filepath.Join(link, "a.go"))}
+	byFile := groupLocationsByFile([]protocol.Location{linked, real})
+	assert.Len(t, byFile, 1)
+	assert.Len(t, byFile[linked.URI], 2)
+	assert.Len(t, uniqueLocations([]protocol.Location{linked, real}), 1)
+}
+
 func TestContainsPosition(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -324,6 +382,12 @@ func TestAddLineNumbers(t *testing.T) {
 			startLine: 998,
 			expected:  " 998|line1\n 999|line2\n1000|line3\n",
 		},
+		{
+			name:      "CRLF line endings",
+			text:      "line1\r\nline2\r\n",
+			startLine: 1,
+			expected:  "1|line1\n2|line2\n3|\n",
+		},
 		{
 			name:      "Empty string",
 			text:      "",
@@ -416,19 +480,25 @@ func TestFormatLinesWithRanges(t *testing.T) {
 			name:     "Single range",
 			lines:    []string{"line1", "line2", "line3", "line4", "line5"},
 			ranges:   []LineRange{{Start: 1, End: 3}},
-			expected: "2|line2\n3|line3\n4|line4\n",
+			expected: "```go\n" + "2|line2\n3|line3\n4|line4\n" + "```\n",
 		},
 		{
 			name:     "Multiple ranges with gap",
 			lines:    []string{"line1", "line2", "line3", "line4", "line5", "line6", "line7"},
 			ranges:   []LineRange{{Start: 0, End: 1}, {Start: 4, End: 6}},
-			expected: "1|line1\n2|line2\n...\n5|line5\n6|line6\n7|line7\n",
+			expected: "```go\n" + "1|line1\n2|line2\n...\n5|line5\n6|line6\n7|line7\n" + "```\n",
 		},
 		{
 			name:     "Adjacent ranges get combined - no gap in output",
 			lines:    []string{"line1", "line2", "line3", "line4", "line5"},
 			ranges:   []LineRange{{Start: 0, End: 2}, {Start: 3, End: 4}},
-			expected: "1|line1\n2|line2\n3|line3\n4|line4\n5|line5\n",
+			expected: "```go\n" + "1|line1\n2|line2\n3|line3\n4|line4\n5|line5\n" + "```\n",
+		},
+		{
+			name:     "Overlapping ranges are shown once",
+			lines:    []string{"line1", "line2", "line3", "line4", "line5"},
+			ranges:   []LineRange{{Start: 2, End: 4}, {Start: 0, End: 3}},
+			expected: "```go\n" + "1|line1\n2|line2\n3|line3\n4|line4\n5|line5\n" + "```\n",
 		},
 		{
 			name: "Real-world example",
@@ -443,14 +513,63 @@ func TestFormatLinesWithRanges(t *testing.T) {
 				"}",
 			},
 			ranges:   []LineRange{{Start: 4, End: 6}},
-			expected: "5|func main() {\n6|    s := \"Hello, World!\"\n7|    fmt.Println(s)\n",
+			expected: "```go\n" + "5|func main() {\n6|    s := \"Hello, World!\"\n7|    fmt.Println(s)\n" + "```\n",
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := FormatLinesWithRanges(tc.lines, tc.ranges)
+			result := FormatLinesWithRanges(context.Background(), "main.go", tc.lines, tc.ranges)
 			assert.Equal(t, tc.expected, result, "Expected formatted output to match")
 		})
 	}
+
+	t.Run("Language from the file extension", func(t *testing.T) {
+		result := FormatLinesWithRanges(context.Background(), "/src/App.tsx", []string{"<App />"}, []LineRange{{Start: 0, End: 0}})
+		assert.Equal(t, "```tsx\n1|<App />\n```\n", result)
+
+		result = FormatLinesWithRanges(context.Background(), "/src/notes.unknown", []string{"text"}, []LineRange{{Start: 0, End: 0}})
+		assert.Equal(t, "```\n1|text\n```\n", result)
+	})
+
+	t.Run("Fence longer than backticks in the lines", func(t *testing.T) {
+		lines := []string{"// Example:", "// ```go", "s := `raw`"}
+		result := FormatLinesWithRanges(context.Background(), "main.go", lines, []LineRange{{Start: 0, End: 2}})
+		assert.Equal(t, "````go\n1|// Example:\n2|// ```go\n3|s := `raw`\n````\n", result)
+	})
+}
+
+func TestPartialResults(t *testing.T) {
+	note, err := partialResults(context.DeadlineExceeded, 3, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "---\n\nTimed out: showing results from 3 of 10 files", note)
+
+	_, err = partialResults(context.DeadlineExceeded, 0, 10)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Expected an error when nothing was formatted")
+
+	_, err = partialResults(context.Canceled, 3, 10)
+	assert.ErrorIs(t, err, context.Canceled, "Expected cancelled calls to fail")
+}
+
+func TestFormatLinesWithRangesSeparator(t *testing.T) {
+	lines := []string{"line1", "line2", "line3", "line4", "line5"}
+	ranges := []LineRange{{Start: 0, End: 0}, {Start: 4, End: 4}}
+
+	ctx := WithOptions(context.Background(), &Options{Context: ContextStyle{Separator: "count"}})
+	assert.Equal(t, "```go\n1|line1\n... 3 lines\n5|line5\n```\n", FormatLinesWithRanges(ctx, "main.go", lines, ranges))
+
+	ctx = WithOptions(context.Background(), &Options{Context: ContextStyle{Separator: "none"}})
+	assert.Equal(t, "```go\n1|line1\n5|line5\n```\n", FormatLinesWithRanges(ctx, "main.go", lines, ranges))
+}
+
+func TestContextWindow(t *testing.T) {
+	before, after := ContextStyle{}.window(3)
+	assert.Equal(t, []int{3, 3}, []int{before, after})
+
+	before, after = ContextStyle{After: 8}.window(3)
+	assert.Equal(t, []int{0, 8}, []int{before, after})
+
+	assert.Error(t, ValidateContextStyle(ContextStyle{Mode: "file"}))
+	assert.Error(t, ValidateContextStyle(ContextStyle{Separator: "dots"}))
+	assert.NoError(t, ValidateContextStyle(ContextStyle{Mode: "function", Separator: "count"}))
 }
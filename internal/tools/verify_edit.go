@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// How long VerifyEdits waits for the language server to report the diagnostics of the
+// changed files
+const verifyEditsWait = 5 * time.Second
+
+// Writes records the files the mutating tools write during a call, with the diagnostics
+// the language server had reported for each of them before it was first written
+type Writes struct {
+	mu    sync.Mutex
+	files []writtenFile
+}
+
+type writtenFile struct {
+	path string
+	// The diagnostics of the file before the write, and how many times they had been
+	// reported, see lsp.Client.DiagnosticsGeneration
+	before     []protocol.Diagnostic
+	generation uint64
+}
+
+type writesKey struct{}
+
+// WithWrites returns a context in which the files written by tools are recorded in w
+func WithWrites(ctx context.Context, w *Writes) context.Context {
+	return context.WithValue(ctx, writesKey{}, w)
+}
+
+// fileWritten drops a file a tool wrote from the file cache and records it in the
+// context's Writes, if there is one
+func fileWritten(ctx context.Context, client *lsp.Client, path string) {
+	options(ctx).Files.Invalidate(path)
+
+	w, _ := ctx.Value(writesKey{}).(*Writes)
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, f := range w.files {
+		if f.path == path {
+			return
+		}
+	}
+	uri := protocol.URIFromPath(path)
+	w.files = append(w.files, writtenFile{
+		path:       path,
+		before:     client.GetFileDiagnostics(uri),
+		generation: client.DiagnosticsGeneration(uri),
+	})
+}
+
+// VerifyEdits waits for the language server to report the diagnostics of the files
+// written during a call and compares them with those it reported before, so that the
+// caller learns right away whether an edit broke something. It returns a section to
+// append to the tool response listing the diagnostics introduced and resolved, or an
+// empty string if no files were written.
+func VerifyEdits(ctx context.Context, client *lsp.Client, w *Writes) string {
+	w.mu.Lock()
+	files := append([]writtenFile(nil), w.files...)
+	w.mu.Unlock()
+	if len(files) == 0 {
+		return ""
+	}
+
+	since := make(map[protocol.DocumentUri]uint64, len(files))
+	for _, f := range files {
+		// Servers only report diagnostics for the files they have open
+		if !client.IsFileOpen(f.path) {
+			if err := client.OpenFile(ctx, f.path); err != nil {
+				toolsLogger.Debug("Failed to open %s to verify an edit: %v", f.path, err)
+				continue
+			}
+		}
+		since[protocol.URIFromPath(f.path)] = f.generation
+	}
+	pending := waitForDiagnostics(ctx, client, since, verifyEditsWait)
+
+	var introduced, resolved, unchecked []string
+	for _, f := range files {
+		if _, err := os.Stat(f.path); os.IsNotExist(err) {
+			// Deleted, or renamed by rename_symbol
+			continue
+		}
+		uri := protocol.URIFromPath(f.path)
+		if _, ok := since[uri]; !ok || slices.Contains(pending, uri) {
+			unchecked = append(unchecked, f.path)
+			continue
+		}
+		added, removed := diffDiagnostics(f.before, client.GetFileDiagnostics(uri))
+		for _, diag := range added {
+			introduced = append(introduced, fmt.Sprintf("%s: %s", f.path, formatDiagnosticSummary(diag)))
+		}
+		for _, diag := range removed {
+			resolved = append(resolved, fmt.Sprintf("%s: %s", f.path, formatDiagnosticSummary(diag)))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\nDiagnostics of the changed files:")
+	switch {
+	case len(introduced) == 0 && len(resolved) == 0 && len(unchecked) < len(files):
+		b.WriteString(" none introduced or resolved")
+	case len(introduced) > 0 || len(resolved) > 0:
+		if len(introduced) > 0 {
+			fmt.Fprintf(&b, "\nIntroduced (%d):\n%s", len(introduced), strings.Join(introduced, "\n"))
+		}
+		if len(resolved) > 0 {
+			fmt.Fprintf(&b, "\nResolved (%d):\n%s", len(resolved), strings.Join(resolved, "\n"))
+		}
+	}
+	if len(unchecked) > 0 {
+		fmt.Fprintf(&b, "\nThe language server did not report diagnostics in time for: %s", strings.Join(unchecked, ", "))
+	}
+	return b.String()
+}
+
+// diffDiagnostics returns the diagnostics of after that were not in before, and those of
+// before that are no longer in after. Diagnostics are compared without their ranges,
+// which edits shift.
+func diffDiagnostics(before, after []protocol.Diagnostic) (added, removed []protocol.Diagnostic) {
+	key := func(d protocol.Diagnostic) string {
+		return fmt.Sprintf("%d|%s|%v|%s", d.Severity, d.Source, d.Code, d.Message)
+	}
+	remaining := make(map[string]int)
+	for _, d := range before {
+		remaining[key(d)]++
+	}
+	for _, d := range after {
+		if remaining[key(d)] > 0 {
+			remaining[key(d)]--
+			continue
+		}
+		added = append(added, d)
+	}
+
+	seen := make(map[string]int)
+	for _, d := range after {
+		seen[key(d)]++
+	}
+	for _, d := range before {
+		if seen[key(d)] > 0 {
+			seen[key(d)]--
+			continue
+		}
+		removed = append(removed, d)
+	}
+	return added, removed
+}
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffDiagnostics(t *testing.T) {
+	at := func(line uint32) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: line}, End: protocol.Position{Line: line}}
+	}
+	unused := protocol.Diagnostic{Range: at(3), Severity: protocol.SeverityWarning, Message: "x declared and not used"}
+	undefined := protocol.Diagnostic{Range: at(7), Severity: protocol.SeverityError, Message: "undefined: y"}
+	mismatch := protocol.Diagnostic{Range: at(9), Severity: protocol.SeverityError, Message: "cannot use s (variable of type string) as int value"}
+
+	t.Run("ranges are ignored", func(t *testing.T) {
+		moved := unused
+		moved.Range = at(5)
+		added, removed := diffDiagnostics([]protocol.Diagnostic{unused}, []protocol.Diagnostic{moved})
+		assert.Empty(t, added)
+		assert.Empty(t, removed)
+	})
+
+	t.Run("introduced and resolved", func(t *testing.T) {
+		added, removed := diffDiagnostics(
+			[]protocol.Diagnostic{unused, undefined},
+			[]protocol.Diagnostic{unused, mismatch},
+		)
+		assert.Equal(t, []protocol.Diagnostic{mismatch}, added)
+		assert.Equal(t, []protocol.Diagnostic{undefined}, removed)
+	})
+
+	t.Run("duplicates are counted", func(t *testing.T) {
+		added, removed := diffDiagnostics(
+			[]protocol.Diagnostic{undefined},
+			[]protocol.Diagnostic{undefined, undefined},
+		)
+		assert.Len(t, added, 1)
+		assert.Empty(t, removed)
+	})
+}
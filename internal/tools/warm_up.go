@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// DefaultWarmUpFiles is how many files WarmUp opens by default
+const DefaultWarmUpFiles = 50
+
+// chooseWarmUpFiles chooses up to max files to warm up the server with among the files of
+// the workspace, in walk order: those of the language with the most files, which the
+// server is most likely for, taking one file per directory first so that every package
+// gets loaded, then a second one, and so on. It also returns the language.
+func chooseWarmUpFiles(paths []string, max int) ([]string, string) {
+	counts := make(map[string]int)
+	language := ""
+	for _, path := range paths {
+		id := string(lsp.DetectLanguageID(path))
+		if id == "" {
+			continue
+		}
+		counts[id]++
+		if counts[id] > counts[language] || (counts[id] == counts[language] && id < language) {
+			language = id
+		}
+	}
+	if language == "" {
+		return nil, ""
+	}
+
+	var dirs []string
+	byDir := make(map[string][]string)
+	for _, path := range paths {
+		if string(lsp.DetectLanguageID(path)) != language {
+			continue
+		}
+		dir := filepath.Dir(path)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], path)
+	}
+
+	var chosen []string
+	for round := 0; len(chosen) < max; round++ {
+		added := false
+		for _, dir := range dirs {
+			if round < len(byDir[dir]) && len(chosen) < max {
+				chosen = append(chosen, byDir[dir][round])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	return chosen, language
+}
+
+// WarmUp gets the server to index the workspace before the tools need it, so that the
+// first query doesn't wait for it: it opens up to maxFiles representative files of the
+// workspace under root, see chooseWarmUpFiles, asks for the workspace symbols, and waits
+// for the indexing the server reports progress for to finish. The files are opened for
+// queries, so they are closed again as the query open mode says. If ctx ends first, what
+// was done so far is reported.
+func WarmUp(ctx context.Context, client *lsp.Client, root string, maxFiles int) (string, error) {
+	start := time.Now()
+	paths, err := ListWorkspaceFiles(ctx, root)
+	if err != nil && ctx.Err() == nil {
+		return "", fmt.Errorf("failed to list the workspace files: %v", err)
+	}
+	files, language := chooseWarmUpFiles(paths, maxFiles)
+
+	var result strings.Builder
+	opened := 0
+	for _, path := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := client.OpenFileForQuery(ctx, path); err != nil {
+			toolsLogger.Debug("Failed to open %s to warm up the server: %v", path, err)
+			continue
+		}
+		opened++
+	}
+	if language == "" {
+		result.WriteString("Found no source files to open.\n")
+	} else {
+		fmt.Fprintf(&result, "Opened %d %s files.\n", opened, language)
+	}
+
+	if ctx.Err() == nil {
+		symbols, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: ""})
+		if err != nil {
+			toolsLogger.Debug("Failed to get the workspace symbols to warm up the server: %v", err)
+			result.WriteString("The server failed to list the workspace symbols.\n")
+		} else if results, err := symbols.Results(); err == nil {
+			fmt.Fprintf(&result, "The server listed %d workspace symbols.\n", len(results))
+		}
+	}
+
+	if err := client.WaitForIndexing(ctx); err != nil {
+		state, events := client.Indexing()
+		fmt.Fprintf(&result, "The server is still %s after %s:\n", state, time.Since(start).Round(time.Millisecond))
+		for _, event := range events {
+			fmt.Fprintf(&result, "- %s\n", event)
+		}
+	} else {
+		fmt.Fprintf(&result, "The server finished indexing after %s.\n", time.Since(start).Round(time.Millisecond))
+	}
+	return result.String(), nil
+}
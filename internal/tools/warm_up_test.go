@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChooseWarmUpFiles(t *testing.T) {
+	paths := []string{
+		"/ws/README.md",
+		"/ws/cmd/main.go",
+		"/ws/internal/a/a.go",
+		"/ws/internal/a/b.go",
+		"/ws/internal/a/c.go",
+		"/ws/internal/b/d.go",
+		"/ws/web/app.ts",
+		"/ws/web/index.ts",
+	}
+
+	files, language := chooseWarmUpFiles(paths, 4)
+	assert.Equal(t, "go", language)
+	assert.Equal(t, []string{"/ws/cmd/main.go", "/ws/internal/a/a.go", "/ws/internal/b/d.go", "/ws/internal/a/b.go"}, files)
+
+	files, _ = chooseWarmUpFiles(paths, 10)
+	assert.Len(t, files, 5)
+
+	files, language = chooseWarmUpFiles([]string{"/ws/LICENSE"}, 10)
+	assert.Empty(t, files)
+	assert.Empty(t, language)
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/watcher"
+)
+
+// Number of lines in each page of a workspace file resource
+const FileResourcePageLines = 1000
+
+// walkWorkspaceFiles calls fn for every file under root, skipping the directories and
+// files the workspace watcher ignores: dot files, excluded directories and extensions,
+// gitignored paths and files larger than the watcher's size limit.
+func walkWorkspaceFiles(ctx context.Context, root string, fn func(path string) error) error {
+	config := watcher.DefaultWatcherConfig()
+	gitignore, err := watcher.NewGitignoreMatcher(root)
+	if err != nil {
+		toolsLogger.Warn("Failed to load gitignore for %s: %v", root, err)
+		gitignore = nil
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable entries instead of aborting the walk
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || config.ExcludedDirs[name] ||
+				(gitignore != nil && gitignore.ShouldIgnore(path, true))) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(name))
+		if strings.HasPrefix(name, ".") || config.ExcludedFileExtensions[ext] || config.LargeBinaryExtensions[ext] ||
+			(gitignore != nil && gitignore.ShouldIgnore(path, false)) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || info.Size() > config.MaxFileSize {
+			return nil
+		}
+
+		return fn(path)
+	})
+}
+
+// ListWorkspaceFiles returns the paths of the files under root that are not ignored,
+// in lexical order
+func ListWorkspaceFiles(ctx context.Context, root string) ([]string, error) {
+	var files []string
+	err := walkWorkspaceFiles(ctx, root, func(path string) error {
+		files = append(files, path)
+		return nil
+	})
+	return files, err
+}
+
+// ReadFilePage returns one page of a text file, where pages are 1-indexed and hold
+// FileResourcePageLines lines each, together with the number of pages in the file
+func ReadFilePage(filePath string, page int) (string, int, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not read file: %v", err)
+	}
+	if isBinary(content) {
+		return "", 0, fmt.Errorf("%s is a binary file", filePath)
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	// A trailing newline does not start another line
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	pages := max((len(lines)+FileResourcePageLines-1)/FileResourcePageLines, 1)
+	if page < 1 || page > pages {
+		return "", pages, fmt.Errorf("page %d is out of range, the file has %d pages", page, pages)
+	}
+
+	start := (page - 1) * FileResourcePageLines
+	end := min(start+FileResourcePageLines, len(lines))
+	return strings.Join(lines[start:end], ""), pages, nil
+}
@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListWorkspaceFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":          "generated/\n",
+		"main.go":             "package main\n",
+		"pkg/util.go":         "package pkg\n",
+		"generated/out.go":    "package generated\n",
+		"node_modules/x.js":   "x\n",
+		".hidden/settings.go": "package hidden\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	listed, err := ListWorkspaceFiles(context.Background(), root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "main.go"), filepath.Join(root, "pkg", "util.go")}, listed)
+}
+
+func TestReadFilePage(t *testing.T) {
+	var lines []string
+	for i := 1; i <= FileResourcePageLines+2; i++ {
+		lines = append(lines, fmt.Sprint(i))
+	}
+	path := filepath.Join(t.TempDir(), "long.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	text, pages, err := ReadFilePage(path, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, strings.Join(lines[:FileResourcePageLines], "\n")+"\n", text)
+
+	text, _, err = ReadFilePage(path, 2)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%d\n%d\n", FileResourcePageLines+1, FileResourcePageLines+2), text)
+
+	_, _, err = ReadFilePage(path, 3)
+	assert.ErrorContains(t, err, "out of range")
+}
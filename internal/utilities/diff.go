@@ -0,0 +1,202 @@
+package utilities
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of unchanged lines shown around each change in a unified diff
+const diffContextLines = 3
+
+// diffOp is one line of an edit script
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// UnifiedDiff returns a unified diff turning oldContent into newContent, or an empty string
+// if they are equal. oldName and newName are used in the file headers.
+func UnifiedDiff(oldName, newName, oldContent, newContent string) string {
+	if oldContent == newContent {
+		return ""
+	}
+
+	oldLines := splitDiffLines(oldContent)
+	newLines := splitDiffLines(newContent)
+	ops := diffLines(oldLines, newLines)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+
+	// Group changes into hunks, merging those whose context would overlap
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := max(i-diffContextLines, 0)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContextLines {
+				break
+			}
+		}
+		end = min(end+diffContextLines, len(ops))
+
+		writeHunk(&sb, ops, start, end)
+		i = end
+	}
+
+	return sb.String()
+}
+
+// writeHunk writes ops[start:end] as a hunk with its header
+func writeHunk(sb *strings.Builder, ops []diffOp, start, end int) {
+	// Line numbers of the first line of the hunk in the old and new content
+	oldLine, newLine := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			oldLine++
+		}
+		if op.kind != '-' {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+
+	// An empty range is numbered by the line before it
+	if oldCount == 0 {
+		oldLine--
+	}
+	if newCount == 0 {
+		newLine--
+	}
+
+	sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount))
+	for _, op := range ops[start:end] {
+		sb.WriteByte(op.kind)
+		if strings.HasSuffix(op.text, "\n") {
+			sb.WriteString(op.text)
+		} else {
+			sb.WriteString(op.text + "\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitDiffLines splits content into lines, keeping the line endings
+func splitDiffLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script between two sets of lines
+func diffLines(a, b []string) []diffOp {
+	// Lines shared at the start and end are left out of the search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// myersDiff computes a shortest edit script between two sets of lines using Myers' algorithm
+func myersDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD
+	v := make([]int, 2*maxD+2)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= maxD && !found; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// Walk the trace backwards to recover the edit script
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, diffOp{'+', b[y]})
+			} else {
+				x--
+				ops = append(ops, diffOp{'-', a[x]})
+			}
+		}
+	}
+
+	// Reverse into forward order
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,54 @@
+package utilities
+
+import (
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name:     "No changes",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name:     "Single line change",
+			old:      "a\nb\nc\n",
+			new:      "a\nB\nc\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "Separate hunks",
+			old:      "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			new:      "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			expected: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			name:     "Insertion into empty file",
+			old:      "",
+			new:      "hello\n",
+			expected: "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+hello\n",
+		},
+		{
+			name:     "Missing newline at end of file",
+			old:      "a\nb",
+			new:      "a\nc",
+			expected: "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := UnifiedDiff("old", "new", tt.old, tt.new)
+			if result != tt.expected {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
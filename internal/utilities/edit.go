@@ -1,9 +1,10 @@
 package utilities
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -20,9 +21,64 @@ var (
 	osRename    = os.Rename
 )
 
-// ApplyTextEdits applies a sequence of text edits to a file specified by URI
-func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
-	path := strings.TrimPrefix(string(uri), "file://")
+// writeFileAtomic replaces the contents of a file by writing a temporary file in the same
+// directory and renaming it over the original, so the file is never left partially written.
+// A symlink is followed to the file it points to, and files with other hard links or whose
+// owner can't be kept are written in place instead.
+var writeFileAtomic = func(path string, data []byte) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	perm := os.FileMode(0644)
+	info, err := os.Stat(path)
+	if err == nil {
+		perm = info.Mode().Perm()
+		if hardLinked(info) {
+			return os.WriteFile(path, data, perm)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if info != nil {
+		if err := chownLike(tmpPath, info); err != nil {
+			_ = os.Remove(tmpPath)
+			return os.WriteFile(path, data, perm)
+		}
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// ApplyTextEdits applies a sequence of text edits to a file specified by URI, with the
+// characters of their positions counted in encoding
+func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit, encoding protocol.PositionEncodingKind) error {
+	path := uri.Path()
 
 	// Read the file content
 	content, err := osReadFile(path)
@@ -30,25 +86,34 @@ func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// Detect line ending style
-	var lineEnding string
-	if bytes.Contains(content, []byte("\r\n")) {
-		lineEnding = "\r\n"
-	} else {
-		lineEnding = "\n"
+	newContent, err := editContent(content, edits, encoding)
+	if err != nil {
+		return err
 	}
 
-	// Track if file ends with a newline
-	endsWithNewline := len(content) > 0 && bytes.HasSuffix(content, []byte(lineEnding))
+	if err := writeFileAtomic(path, newContent); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
 
-	// Split into lines without the endings
-	lines := strings.Split(string(content), lineEnding)
+// editContent returns the result of applying a sequence of text edits to file content.
+// Lines keep their own line endings, and lines the edits add use the file's usual one.
+func editContent(content []byte, edits []protocol.TextEdit, encoding protocol.PositionEncodingKind) ([]byte, error) {
+	split := SplitLines(string(content))
+	lineEnding := split.LineEnding()
+	lines := split.All()
+	endings := make([]string, len(lines))
+	for i := range lines {
+		endings[i] = split.Ending(i)
+	}
 
 	// Check for overlapping edits
 	for i, edit1 := range edits {
 		for j := i + 1; j < len(edits); j++ {
 			if RangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
+				return nil, fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
 			}
 		}
 	}
@@ -65,40 +130,59 @@ func ApplyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
 
 	// Apply each edit
 	for _, edit := range sortedEdits {
-		newLines, err := ApplyTextEdit(lines, edit, lineEnding)
+		edit.NewText = strings.ReplaceAll(edit.NewText, "\r\n", "\n")
+		newLines, err := ApplyTextEdit(lines, edit, lineEnding, encoding)
 		if err != nil {
-			return fmt.Errorf("failed to apply edit: %w", err)
+			return nil, fmt.Errorf("failed to apply edit: %w", err)
 		}
+		endings = editEndings(endings, len(newLines), edit.Range, lineEnding)
 		lines = newLines
 	}
 
-	// Join lines with proper line endings
+	// Join lines with their line endings
 	var newContent strings.Builder
 	for i, line := range lines {
-		if i > 0 {
-			newContent.WriteString(lineEnding)
-		}
 		newContent.WriteString(line)
+		if i < len(lines)-1 {
+			newContent.WriteString(endings[i])
+		}
 	}
 
 	// Only add a newline if the original file had one and we haven't already added it
-	if endsWithNewline && !strings.HasSuffix(newContent.String(), lineEnding) {
+	if split.EndsWithNewline() && !strings.HasSuffix(newContent.String(), "\n") {
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := osWriteFile(path, []byte(newContent.String()), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
+	return []byte(newContent.String()), nil
+}
 
-	return nil
+// editEndings returns the line endings of lines after ApplyTextEdit replaced the lines of
+// a range with count lines in all. Lines outside the range keep their endings, the last
+// line the edit leaves takes the ending of the range's end line, and the others get the
+// given line ending.
+func editEndings(endings []string, count int, rng protocol.Range, lineEnding string) []string {
+	startLine := int(rng.Start.Line)
+	endLine := min(int(rng.End.Line), len(endings)-1)
+	after := endings[endLine+1:]
+	edited := count - startLine - len(after)
+
+	result := make([]string, 0, count)
+	result = append(result, endings[:startLine]...)
+	for i := 0; i < edited; i++ {
+		if i == edited-1 {
+			result = append(result, endings[endLine])
+		} else {
+			result = append(result, lineEnding)
+		}
+	}
+	return append(result, after...)
 }
 
-// ApplyTextEdit applies a single text edit to a set of lines
-func ApplyTextEdit(lines []string, edit protocol.TextEdit, lineEnding string) ([]string, error) {
+// ApplyTextEdit applies a single text edit to a set of lines, with the characters of its
+// range counted in encoding
+func ApplyTextEdit(lines []string, edit protocol.TextEdit, lineEnding string, encoding protocol.PositionEncodingKind) ([]string, error) {
 	startLine := int(edit.Range.Start.Line)
 	endLine := int(edit.Range.End.Line)
-	startChar := int(edit.Range.Start.Character)
-	endChar := int(edit.Range.End.Character)
 
 	// Validate positions
 	if startLine < 0 || startLine >= len(lines) {
@@ -116,16 +200,12 @@ func ApplyTextEdit(lines []string, edit protocol.TextEdit, lineEnding string) ([
 
 	// Get the prefix of the start line
 	startLineContent := lines[startLine]
-	if startChar < 0 || startChar > len(startLineContent) {
-		startChar = len(startLineContent)
-	}
+	startChar := encoding.CharacterToByte(startLineContent, edit.Range.Start.Character)
 	prefix := startLineContent[:startChar]
 
 	// Get the suffix of the end line
 	endLineContent := lines[endLine]
-	if endChar < 0 || endChar > len(endLineContent) {
-		endChar = len(endLineContent)
-	}
+	endChar := encoding.CharacterToByte(endLineContent, edit.Range.End.Character)
 	suffix := endLineContent[endChar:]
 
 	// Handle the edit
@@ -171,9 +251,9 @@ func ApplyTextEdit(lines []string, edit protocol.TextEdit, lineEnding string) ([
 }
 
 // ApplyDocumentChange applies a DocumentChange (create/rename/delete operations)
-func ApplyDocumentChange(change protocol.DocumentChange) error {
+func ApplyDocumentChange(change protocol.DocumentChange, encoding protocol.PositionEncodingKind) error {
 	if change.CreateFile != nil {
-		path := strings.TrimPrefix(string(change.CreateFile.URI), "file://")
+		path := change.CreateFile.URI.Path()
 		if change.CreateFile.Options != nil {
 			if change.CreateFile.Options.Overwrite {
 				// Proceed with overwrite
@@ -189,7 +269,7 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 	}
 
 	if change.DeleteFile != nil {
-		path := strings.TrimPrefix(string(change.DeleteFile.URI), "file://")
+		path := change.DeleteFile.URI.Path()
 		if change.DeleteFile.Options != nil && change.DeleteFile.Options.Recursive {
 			if err := osRemoveAll(path); err != nil {
 				return fmt.Errorf("failed to delete directory recursively: %w", err)
@@ -202,8 +282,8 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 	}
 
 	if change.RenameFile != nil {
-		oldPath := strings.TrimPrefix(string(change.RenameFile.OldURI), "file://")
-		newPath := strings.TrimPrefix(string(change.RenameFile.NewURI), "file://")
+		oldPath := change.RenameFile.OldURI.Path()
+		newPath := change.RenameFile.NewURI.Path()
 		if change.RenameFile.Options != nil {
 			if !change.RenameFile.Options.Overwrite {
 				if _, err := osStat(newPath); err == nil {
@@ -225,17 +305,32 @@ func ApplyDocumentChange(change protocol.DocumentChange) error {
 				return fmt.Errorf("invalid edit type: %w", err)
 			}
 		}
-		return ApplyTextEdits(change.TextDocumentEdit.TextDocument.URI, textEdits)
+		return ApplyTextEdits(change.TextDocumentEdit.TextDocument.URI, textEdits, encoding)
 	}
 
 	return nil
 }
 
-// ApplyWorkspaceEdit applies the given WorkspaceEdit to the filesystem
-func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
+// ApplyWorkspaceEdit applies the given WorkspaceEdit to the filesystem. All changes are
+// staged first and written as one transaction, so a failure leaves every file as it was.
+func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit, encoding protocol.PositionEncodingKind) error {
+	tx, err := StageWorkspaceEdit(edit, nil, encoding)
+	if errors.Is(err, ErrDirectoryChange) {
+		// Directories cannot be staged, apply the changes one by one instead
+		coreLogger.Warn("Workspace edit changes directories, applying it without rollback")
+		return applyWorkspaceEditSequentially(edit, encoding)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyWorkspaceEditSequentially applies each change of a WorkspaceEdit in turn
+func applyWorkspaceEditSequentially(edit protocol.WorkspaceEdit, encoding protocol.PositionEncodingKind) error {
 	// Handle Changes field
 	for uri, textEdits := range edit.Changes {
-		if err := ApplyTextEdits(uri, textEdits); err != nil {
+		if err := ApplyTextEdits(uri, textEdits, encoding); err != nil {
 			return fmt.Errorf("failed to apply text edits: %w", err)
 		}
 	}
@@ -243,7 +338,7 @@ func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
 	// Handle DocumentChanges field
 	for _, change := range edit.DocumentChanges {
 		coreLogger.Warn("Document change: %v", spew.Sdump(change))
-		if err := ApplyDocumentChange(change); err != nil {
+		if err := ApplyDocumentChange(change, encoding); err != nil {
 			return fmt.Errorf("failed to apply document change: %w", err)
 		}
 	}
@@ -264,3 +359,29 @@ func RangesOverlap(r1, r2 protocol.Range) bool {
 	}
 	return true
 }
+
+// WorkspaceEditPaths returns every path a WorkspaceEdit touches
+func WorkspaceEditPaths(edit protocol.WorkspaceEdit) []string {
+	var uris []protocol.DocumentUri
+	for uri := range edit.Changes {
+		uris = append(uris, uri)
+	}
+	for _, change := range edit.DocumentChanges {
+		switch {
+		case change.TextDocumentEdit != nil:
+			uris = append(uris, change.TextDocumentEdit.TextDocument.URI)
+		case change.CreateFile != nil:
+			uris = append(uris, change.CreateFile.URI)
+		case change.DeleteFile != nil:
+			uris = append(uris, change.DeleteFile.URI)
+		case change.RenameFile != nil:
+			uris = append(uris, change.RenameFile.OldURI, change.RenameFile.NewURI)
+		}
+	}
+
+	paths := make([]string, len(uris))
+	for i, uri := range uris {
+		paths[i] = uri.Path()
+	}
+	return paths
+}
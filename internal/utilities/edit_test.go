@@ -3,7 +3,9 @@ package utilities
 import (
 	"errors"
 	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -27,6 +29,7 @@ func setupMockFileSystem(_ *testing.T, mfs *mockFileSystem) func() {
 	originalRemove := osRemove
 	originalRemoveAll := osRemoveAll
 	originalRename := osRename
+	originalWriteFileAtomic := writeFileAtomic
 
 	// Replace with mocks
 	osReadFile = func(filename string) ([]byte, error) {
@@ -50,6 +53,10 @@ func setupMockFileSystem(_ *testing.T, mfs *mockFileSystem) func() {
 		return nil
 	}
 
+	writeFileAtomic = func(path string, data []byte) error {
+		return osWriteFile(path, data, 0644)
+	}
+
 	osStat = func(name string) (os.FileInfo, error) {
 		if err, ok := mfs.errors[name+"_stat"]; ok {
 			return nil, err
@@ -104,6 +111,7 @@ func setupMockFileSystem(_ *testing.T, mfs *mockFileSystem) func() {
 		osRemove = originalRemove
 		osRemoveAll = originalRemoveAll
 		osRename = originalRename
+		writeFileAtomic = originalWriteFileAtomic
 	}
 }
 
@@ -301,6 +309,20 @@ func TestApplyTextEdit(t *testing.T) {
 			expected:   []string{"This was test line"},
 			expectErr:  false,
 		},
+		{
+			name:  "Replace text - non-ASCII line",
+			lines: []string{"s := \"😀é\" + x"},
+			edit: protocol.TextEdit{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 13},
+					End:   protocol.Position{Line: 0, Character: 14},
+				},
+				NewText: "y",
+			},
+			lineEnding: "\n",
+			expected:   []string{"s := \"😀é\" + y"},
+			expectErr:  false,
+		},
 		{
 			name:  "Insert text - single line",
 			lines: []string{"This is a test line"},
@@ -445,7 +467,7 @@ func TestApplyTextEdit(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := ApplyTextEdit(tt.lines, tt.edit, tt.lineEnding)
+			result, err := ApplyTextEdit(tt.lines, tt.edit, tt.lineEnding, protocol.UTF16)
 			if tt.expectErr {
 				if err == nil {
 					t.Errorf("Expected error but got none")
@@ -644,7 +666,7 @@ func TestApplyTextEdits(t *testing.T) {
 			cleanup := setupMockFileSystem(t, mfs)
 			defer cleanup()
 
-			err := ApplyTextEdits(tt.uri, tt.edits)
+			err := ApplyTextEdits(tt.uri, tt.edits, protocol.UTF16)
 			if tt.expectErr {
 				if err == nil {
 					t.Errorf("Expected error but got none")
@@ -896,7 +918,7 @@ func TestApplyDocumentChange(t *testing.T) {
 			cleanup := setupMockFileSystem(t, mfs)
 			defer cleanup()
 
-			err := ApplyDocumentChange(tt.change)
+			err := ApplyDocumentChange(tt.change, protocol.UTF16)
 			if tt.expectErr {
 				if err == nil {
 					t.Errorf("Expected error but got none")
@@ -1100,7 +1122,7 @@ func TestApplyWorkspaceEdit(t *testing.T) {
 			cleanup := setupMockFileSystem(t, mfs)
 			defer cleanup()
 
-			err := ApplyWorkspaceEdit(tt.edit)
+			err := ApplyWorkspaceEdit(tt.edit, protocol.UTF16)
 			if tt.expectErr {
 				if err == nil {
 					t.Errorf("Expected error but got none")
@@ -1114,3 +1136,130 @@ func TestApplyWorkspaceEdit(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "script.sh")
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new")); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "new" {
+		t.Errorf("content = %q, want %q", content, "new")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("mode = %v, want %v", info.Mode().Perm(), os.FileMode(0755))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the written file in %s, found %d entries", dir, len(entries))
+	}
+}
+
+func TestWriteFileAtomicLinks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writing through a symlink changes the file it points to and keeps the link
+	link := filepath.Join(dir, "link.yaml")
+	if err := os.Symlink(path, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := writeFileAtomic(link, []byte("through the link")); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("%s is no longer a symlink", link)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "through the link" {
+		t.Errorf("content = %q, want %q", content, "through the link")
+	}
+
+	// Other hard links to the file see the new contents, where the link count is known
+	if runtime.GOOS == "windows" {
+		return
+	}
+	hardLink := filepath.Join(dir, "hard.yaml")
+	if err := os.Link(path, hardLink); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+	if err := writeFileAtomic(path, []byte("shared")); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	if content, _ := os.ReadFile(hardLink); string(content) != "shared" {
+		t.Errorf("hard link content = %q, want %q", content, "shared")
+	}
+}
+
+func TestPreviewWorkspaceEdit(t *testing.T) {
+	mfs := &mockFileSystem{
+		files: map[string][]byte{
+			"/test/file.txt":    []byte("Line 1\nLine 2\nLine 3\n"),
+			"/test/oldname.txt": []byte("Moved\n"),
+		},
+	}
+	cleanup := setupMockFileSystem(t, mfs)
+	defer cleanup()
+
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentUri][]protocol.TextEdit{
+			"file:///test/file.txt": {
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 1, Character: 0},
+						End:   protocol.Position{Line: 1, Character: 6},
+					},
+					NewText: "Modified",
+				},
+			},
+		},
+		DocumentChanges: []protocol.DocumentChange{
+			{
+				RenameFile: &protocol.RenameFile{
+					OldURI: "file:///test/oldname.txt",
+					NewURI: "file:///test/newname.txt",
+				},
+			},
+		},
+	}
+
+	diff, err := PreviewWorkspaceEdit(edit, protocol.UTF16)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "--- /test/file.txt\n+++ /test/file.txt\n@@ -1,3 +1,3 @@\n Line 1\n-Line 2\n+Modified\n Line 3\n" +
+		"--- /dev/null\n+++ /test/newname.txt\n@@ -0,0 +1,1 @@\n+Moved\n" +
+		"--- /test/oldname.txt\n+++ /dev/null\n@@ -1,1 +0,0 @@\n-Moved\n"
+	if diff != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, diff)
+	}
+
+	// Nothing is written
+	if string(mfs.files["/test/file.txt"]) != "Line 1\nLine 2\nLine 3\n" {
+		t.Errorf("File was modified: %s", string(mfs.files["/test/file.txt"]))
+	}
+	if _, ok := mfs.files["/test/newname.txt"]; ok {
+		t.Errorf("Renamed file was created")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package utilities
+
+import "os"
+
+// hardLinked reports whether the file has other hard links that replacing it would
+// detach from its contents. The link count is only known on unix.
+func hardLinked(info os.FileInfo) bool {
+	return false
+}
+
+// chownLike gives the file at path the owner and group of the file described by info.
+// Ownership is left as it is outside unix.
+func chownLike(path string, info os.FileInfo) error {
+	return nil
+}
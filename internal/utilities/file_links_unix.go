@@ -0,0 +1,24 @@
+//go:build unix
+
+package utilities
+
+import (
+	"os"
+	"syscall"
+)
+
+// hardLinked reports whether the file has other hard links that replacing it would
+// detach from its contents
+func hardLinked(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && stat.Nlink > 1
+}
+
+// chownLike gives the file at path the owner and group of the file described by info
+func chownLike(path string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return os.Lchown(path, int(stat.Uid), int(stat.Gid))
+}
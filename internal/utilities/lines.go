@@ -0,0 +1,100 @@
+package utilities
+
+import "strings"
+
+// Lines is file content split into lines. Lines may end in "\n" or "\r\n", in any mix,
+// and are kept without their endings so that columns and displayed text never include a
+// carriage return.
+type Lines struct {
+	lines   []string
+	endings []string
+}
+
+// SplitLines indexes the lines of content
+func SplitLines(content string) Lines {
+	l := Lines{}
+	for {
+		i := strings.IndexByte(content, '\n')
+		if i < 0 {
+			break
+		}
+		line, ending := content[:i], "\n"
+		if strings.HasSuffix(line, "\r") {
+			line, ending = line[:len(line)-1], "\r\n"
+		}
+		l.lines = append(l.lines, line)
+		l.endings = append(l.endings, ending)
+		content = content[i+1:]
+	}
+	// The last line has no ending, and is empty when the content ends in a line ending
+	l.lines = append(l.lines, content)
+	l.endings = append(l.endings, "")
+	return l
+}
+
+// All returns every line as LSP positions address them, including the empty line after a
+// final line ending
+func (l Lines) All() []string {
+	return l.lines
+}
+
+// Text returns the lines of the content as displayed. A final line ending does not start
+// another line, and empty content is a single empty line.
+func (l Lines) Text() []string {
+	if l.EndsWithNewline() {
+		return l.lines[:len(l.lines)-1]
+	}
+	return l.lines
+}
+
+// Len counts the lines of Text
+func (l Lines) Len() int {
+	return len(l.Text())
+}
+
+// Line returns a line without its ending, and whether it exists. The empty line after a
+// final line ending exists, as LSP positions may point to it.
+func (l Lines) Line(i int) (string, bool) {
+	if i < 0 || i >= len(l.lines) {
+		return "", false
+	}
+	return l.lines[i], true
+}
+
+// Ending returns the line ending of a line, "" for the last
+func (l Lines) Ending(i int) string {
+	if i < 0 || i >= len(l.endings) {
+		return ""
+	}
+	return l.endings[i]
+}
+
+// EndsWithNewline reports whether the content ends in a line ending
+func (l Lines) EndsWithNewline() bool {
+	return len(l.lines) > 1 && l.lines[len(l.lines)-1] == ""
+}
+
+// LineEnding returns the line ending most lines use, "\n" if there are none or as many
+// of each
+func (l Lines) LineEnding() string {
+	crlf := 0
+	for _, ending := range l.endings {
+		if ending == "\r\n" {
+			crlf++
+		}
+	}
+	if crlf*2 > len(l.endings)-1 {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// String joins the lines back with their own endings
+func (l Lines) String() string {
+	var b strings.Builder
+	for i, line := range l.lines {
+		b.WriteString(line)
+		b.WriteString(l.endings[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package utilities
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		all        []string
+		text       []string
+		lineEnding string
+	}{
+		{
+			name:       "Empty content",
+			content:    "",
+			all:        []string{""},
+			text:       []string{""},
+			lineEnding: "\n",
+		},
+		{
+			name:       "Final newline",
+			content:    "a\nb\n",
+			all:        []string{"a", "b", ""},
+			text:       []string{"a", "b"},
+			lineEnding: "\n",
+		},
+		{
+			name:       "No final newline",
+			content:    "a\nb",
+			all:        []string{"a", "b"},
+			text:       []string{"a", "b"},
+			lineEnding: "\n",
+		},
+		{
+			name:       "CRLF",
+			content:    "a\r\nb\r\n",
+			all:        []string{"a", "b", ""},
+			text:       []string{"a", "b"},
+			lineEnding: "\r\n",
+		},
+		{
+			name:       "Mixed line endings",
+			content:    "a\r\nb\nc\r\nd",
+			all:        []string{"a", "b", "c", "d"},
+			text:       []string{"a", "b", "c", "d"},
+			lineEnding: "\r\n",
+		},
+		{
+			name:       "Lone carriage return",
+			content:    "a\rb\n",
+			all:        []string{"a\rb", ""},
+			text:       []string{"a\rb"},
+			lineEnding: "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := SplitLines(tt.content)
+			if !reflect.DeepEqual(lines.All(), tt.all) {
+				t.Errorf("All() = %q, want %q", lines.All(), tt.all)
+			}
+			if !reflect.DeepEqual(lines.Text(), tt.text) {
+				t.Errorf("Text() = %q, want %q", lines.Text(), tt.text)
+			}
+			if lines.Len() != len(tt.text) {
+				t.Errorf("Len() = %d, want %d", lines.Len(), len(tt.text))
+			}
+			if lines.LineEnding() != tt.lineEnding {
+				t.Errorf("LineEnding() = %q, want %q", lines.LineEnding(), tt.lineEnding)
+			}
+			if lines.String() != tt.content {
+				t.Errorf("String() = %q, want %q", lines.String(), tt.content)
+			}
+		})
+	}
+}
+
+func TestEditContentLineEndings(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		edit     protocol.TextEdit
+		expected string
+	}{
+		{
+			name:    "Mixed endings are kept",
+			content: "a\r\nb\nc\r\n",
+			edit: protocol.TextEdit{
+				Range:   protocol.Range{Start: protocol.Position{Line: 1, Character: 0}, End: protocol.Position{Line: 1, Character: 1}},
+				NewText: "B",
+			},
+			expected: "a\r\nB\nc\r\n",
+		},
+		{
+			name:    "Inserted lines use the usual ending",
+			content: "a\r\nb\r\n",
+			edit: protocol.TextEdit{
+				Range:   protocol.Range{Start: protocol.Position{Line: 0, Character: 1}, End: protocol.Position{Line: 0, Character: 1}},
+				NewText: "1\n2\r\n3",
+			},
+			expected: "a1\r\n2\r\n3\r\nb\r\n",
+		},
+		{
+			name:    "Deleted lines take their endings",
+			content: "a\nb\r\nc\r\n",
+			edit: protocol.TextEdit{
+				Range:   protocol.Range{Start: protocol.Position{Line: 0, Character: 1}, End: protocol.Position{Line: 1, Character: 1}},
+				NewText: "",
+			},
+			expected: "a\r\nc\r\n",
+		},
+		{
+			name:    "Columns past a carriage return",
+			content: "ab\r\ncd",
+			edit: protocol.TextEdit{
+				Range:   protocol.Range{Start: protocol.Position{Line: 0, Character: 2}, End: protocol.Position{Line: 0, Character: 2}},
+				NewText: "!",
+			},
+			expected: "ab!\r\ncd",
+		},
+		{
+			name:    "No final newline",
+			content: "a\r\nb",
+			edit: protocol.TextEdit{
+				Range:   protocol.Range{Start: protocol.Position{Line: 1, Character: 1}, End: protocol.Position{Line: 1, Character: 1}},
+				NewText: "c",
+			},
+			expected: "a\r\nbc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := editContent([]byte(tt.content), []protocol.TextEdit{tt.edit}, protocol.UTF16)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("editContent() = %q, want %q", string(result), tt.expected)
+			}
+		})
+	}
+}
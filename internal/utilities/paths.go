@@ -0,0 +1,38 @@
+package utilities
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// CanonicalPath returns path cleaned and with its symlinks resolved, so that a file reached
+// through a symlink and through its target has a single path. Paths that can't be
+// resolved, such as those of files that don't exist, are only cleaned. Symlinks are
+// resolved on every call, as they can change while the server runs.
+func CanonicalPath(path string) string {
+	path = filepath.Clean(path)
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	return real
+}
+
+// RelativePath returns the path of a file relative to root and whether the file is inside
+// root. When the paths don't match as given, their symlinks are resolved, so that the files
+// a language server reports under the target of a symlinked workspace are inside it.
+func RelativePath(root, path string) (string, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err == nil && isInside(rel) {
+		return rel, true
+	}
+	if realRel, err := filepath.Rel(CanonicalPath(root), CanonicalPath(path)); err == nil && isInside(realRel) {
+		return realRel, true
+	}
+	return rel, false
+}
+
+// isInside reports whether a relative path stays inside the directory it is relative to
+func isInside(rel string) bool {
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
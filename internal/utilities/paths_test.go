@@ -0,0 +1,69 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRelativePath(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "target")
+	if err := os.MkdirAll(filepath.Join(target, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "pkg", "a.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if got := CanonicalPath(filepath.Join(link, "pkg", "..", "pkg", "a.go")); got != filepath.Join(target, "pkg", "a.go") {
+		t.Errorf("CanonicalPath() = %q, want the path in the target", got)
+	}
+
+	tests := []struct {
+		name   string
+		root   string
+		path   string
+		rel    string
+		inside bool
+	}{
+		{"Same root", target, filepath.Join(target, "pkg", "a.go"), filepath.Join("pkg", "a.go"), true},
+		{"Root through symlink", link, filepath.Join(target, "pkg", "a.go"), filepath.Join("pkg", "a.go"), true},
+		{"Path through symlink", target, filepath.Join(link, "pkg", "a.go"), filepath.Join("pkg", "a.go"), true},
+		{"Outside", filepath.Join(target, "pkg"), filepath.Join(dir, "other.go"), filepath.Join("..", "..", "other.go"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rel, inside := RelativePath(tt.root, tt.path)
+			if rel != tt.rel || inside != tt.inside {
+				t.Errorf("RelativePath() = %q, %v, want %q, %v", rel, inside, tt.rel, tt.inside)
+			}
+		})
+	}
+
+	// A symlink pointed elsewhere resolves to its new target
+	other := filepath.Join(dir, "other")
+	if err := os.MkdirAll(filepath.Join(other, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(other, "pkg", "a.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(other, link); err != nil {
+		t.Fatal(err)
+	}
+	if got := CanonicalPath(filepath.Join(link, "pkg", "a.go")); got != filepath.Join(other, "pkg", "a.go") {
+		t.Errorf("CanonicalPath() = %q, want the path in the new target", got)
+	}
+}
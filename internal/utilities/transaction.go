@@ -0,0 +1,327 @@
+package utilities
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// ErrDirectoryChange is returned when a WorkspaceEdit creates, renames or deletes a
+// directory, which cannot be staged in memory
+var ErrDirectoryChange = errors.New("workspace edit changes a directory")
+
+// VersionLookup returns the version of a document the language server has open
+type VersionLookup func(path string) (int32, bool)
+
+// fileState is the contents of a file on disk when it was first read by a transaction
+type fileState struct {
+	exists  bool
+	content []byte
+	hash    [sha256.Size]byte
+}
+
+// EditTransaction holds the file contents a WorkspaceEdit produces without having
+// written any of them. Commit checks that no file changed on disk since the edit was
+// staged and then writes every file, restoring them all if any write fails.
+type EditTransaction struct {
+	versions VersionLookup
+	encoding protocol.PositionEncodingKind
+	// Paths in the order they were first touched
+	order []string
+	// Contents of each touched path before the edit
+	originals map[string]fileState
+	// Contents of each touched path after the edit. A nil entry means the file is deleted.
+	contents map[string]*string
+}
+
+// StageWorkspaceEdit computes the result of a WorkspaceEdit in memory. If versions is
+// set, text document edits for a specific document version are rejected when the
+// language server has a different version of the document open. The characters of
+// positions are counted in encoding.
+func StageWorkspaceEdit(edit protocol.WorkspaceEdit, versions VersionLookup, encoding protocol.PositionEncodingKind) (*EditTransaction, error) {
+	tx := &EditTransaction{
+		versions:  versions,
+		encoding:  encoding,
+		originals: make(map[string]fileState),
+		contents:  make(map[string]*string),
+	}
+
+	// Handle Changes field, in a stable order
+	uris := make([]string, 0, len(edit.Changes))
+	for uri := range edit.Changes {
+		uris = append(uris, string(uri))
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		if err := tx.applyTextEdits(protocol.DocumentUri(uri), edit.Changes[protocol.DocumentUri(uri)]); err != nil {
+			return nil, fmt.Errorf("failed to apply text edits: %w", err)
+		}
+	}
+
+	// Handle DocumentChanges field
+	for _, change := range edit.DocumentChanges {
+		if err := tx.applyDocumentChange(change); err != nil {
+			return nil, fmt.Errorf("failed to apply document change: %w", err)
+		}
+	}
+
+	return tx, nil
+}
+
+// PreviewWorkspaceEdit returns the unified diff of the changes a WorkspaceEdit would make
+// without writing anything to disk
+func PreviewWorkspaceEdit(edit protocol.WorkspaceEdit, encoding protocol.PositionEncodingKind) (string, error) {
+	tx, err := StageWorkspaceEdit(edit, nil, encoding)
+	if err != nil {
+		return "", err
+	}
+	return tx.Diff(), nil
+}
+
+// readOriginal reads a file from disk and records its state the first time it is seen
+func (tx *EditTransaction) readOriginal(path string) (fileState, error) {
+	if state, ok := tx.originals[path]; ok {
+		return state, nil
+	}
+
+	if info, err := osStat(path); err == nil && info.IsDir() {
+		return fileState{}, fmt.Errorf("%w: %s", ErrDirectoryChange, path)
+	}
+
+	state, err := readFileState(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	tx.originals[path] = state
+	return state, nil
+}
+
+// readFileState returns the current state of a file on disk
+func readFileState(path string) (fileState, error) {
+	content, err := osReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return fileState{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	return fileState{exists: true, content: content, hash: sha256.Sum256(content)}, nil
+}
+
+// read returns the contents of a path as staged so far and whether it exists
+func (tx *EditTransaction) read(path string) (string, bool, error) {
+	if content, ok := tx.contents[path]; ok {
+		if content == nil {
+			return "", false, nil
+		}
+		return *content, true, nil
+	}
+
+	state, err := tx.readOriginal(path)
+	if err != nil {
+		return "", false, err
+	}
+	return string(state.content), state.exists, nil
+}
+
+// set stages the new contents of a path, or its deletion if content is nil
+func (tx *EditTransaction) set(path string, content *string) {
+	if _, ok := tx.contents[path]; !ok {
+		tx.order = append(tx.order, path)
+	}
+	tx.contents[path] = content
+}
+
+func (tx *EditTransaction) applyTextEdits(uri protocol.DocumentUri, edits []protocol.TextEdit) error {
+	path := uri.Path()
+	content, exists, err := tx.read(path)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("failed to read file: %s does not exist", path)
+	}
+
+	newContent, err := editContent([]byte(content), edits, tx.encoding)
+	if err != nil {
+		return err
+	}
+	text := string(newContent)
+	tx.set(path, &text)
+	return nil
+}
+
+func (tx *EditTransaction) applyDocumentChange(change protocol.DocumentChange) error {
+	if change.CreateFile != nil {
+		path := change.CreateFile.URI.Path()
+		_, exists, err := tx.read(path)
+		if err != nil {
+			return err
+		}
+		options := change.CreateFile.Options
+		if !exists || options == nil || options.Overwrite || !options.IgnoreIfExists {
+			empty := ""
+			tx.set(path, &empty)
+		}
+	}
+
+	if change.DeleteFile != nil {
+		path := change.DeleteFile.URI.Path()
+		if _, exists, err := tx.read(path); err != nil {
+			return err
+		} else if !exists {
+			return fmt.Errorf("failed to delete file: %s does not exist", path)
+		}
+		tx.set(path, nil)
+	}
+
+	if change.RenameFile != nil {
+		oldPath := change.RenameFile.OldURI.Path()
+		newPath := change.RenameFile.NewURI.Path()
+		content, exists, err := tx.read(oldPath)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("failed to rename file: %s does not exist", oldPath)
+		}
+		if _, exists, err := tx.read(newPath); err != nil {
+			return err
+		} else if exists && (change.RenameFile.Options == nil || !change.RenameFile.Options.Overwrite) {
+			return fmt.Errorf("target file already exists and overwrite is not allowed: %s", newPath)
+		}
+		tx.set(newPath, &content)
+		tx.set(oldPath, nil)
+	}
+
+	if change.TextDocumentEdit != nil {
+		document := change.TextDocumentEdit.TextDocument
+		if document.Version > 0 && tx.versions != nil {
+			path := document.URI.Path()
+			if version, ok := tx.versions(path); ok && version != document.Version {
+				return fmt.Errorf("edit is for version %d of %s but the document is at version %d", document.Version, path, version)
+			}
+		}
+
+		textEdits := make([]protocol.TextEdit, len(change.TextDocumentEdit.Edits))
+		for i, edit := range change.TextDocumentEdit.Edits {
+			var err error
+			textEdits[i], err = edit.AsTextEdit()
+			if err != nil {
+				return fmt.Errorf("invalid edit type: %w", err)
+			}
+		}
+		return tx.applyTextEdits(document.URI, textEdits)
+	}
+
+	return nil
+}
+
+// Paths returns the paths the transaction changes, in the order they were first touched
+func (tx *EditTransaction) Paths() []string {
+	var paths []string
+	for _, path := range tx.order {
+		if tx.changes(path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// changes reports whether committing would change a path on disk
+func (tx *EditTransaction) changes(path string) bool {
+	original := tx.originals[path]
+	content := tx.contents[path]
+	if content == nil {
+		return original.exists
+	}
+	return !original.exists || *content != string(original.content)
+}
+
+// Diff returns the unified diff of the transaction. File creations, renames and deletions
+// are shown as diffs against /dev/null.
+func (tx *EditTransaction) Diff() string {
+	var sb strings.Builder
+	for _, path := range tx.Paths() {
+		original := tx.originals[path]
+		oldName, newName := path, path
+		if !original.exists {
+			oldName = "/dev/null"
+		}
+
+		newContent := ""
+		if content := tx.contents[path]; content != nil {
+			newContent = *content
+		} else {
+			newName = "/dev/null"
+		}
+
+		if string(original.content) == newContent {
+			// Created or deleted an empty file
+			sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+			continue
+		}
+		sb.WriteString(UnifiedDiff(oldName, newName, string(original.content), newContent))
+	}
+	return sb.String()
+}
+
+// Commit writes the staged changes. It fails without writing anything if a file changed
+// on disk since it was staged, and restores every file it already wrote if a later
+// write fails.
+func (tx *EditTransaction) Commit() error {
+	paths := tx.Paths()
+
+	// Verify that the files still hold the contents the edit was computed from
+	for _, path := range paths {
+		current, err := readFileState(path)
+		if err != nil {
+			return err
+		}
+		original := tx.originals[path]
+		if current.exists != original.exists || current.hash != original.hash {
+			return fmt.Errorf("%s changed on disk since the edit was prepared", path)
+		}
+	}
+
+	var written []string
+	for _, path := range paths {
+		var err error
+		if content := tx.contents[path]; content != nil {
+			err = writeFileAtomic(path, []byte(*content))
+		} else {
+			err = osRemove(path)
+		}
+		if err != nil {
+			if rollbackErr := tx.rollback(written); rollbackErr != nil {
+				return fmt.Errorf("failed to write %s: %v (rollback failed: %v)", path, err, rollbackErr)
+			}
+			return fmt.Errorf("failed to write %s, all changes were rolled back: %w", path, err)
+		}
+		written = append(written, path)
+	}
+
+	return nil
+}
+
+// rollback restores the original contents of the given paths
+func (tx *EditTransaction) rollback(paths []string) error {
+	var errs []error
+	for i := len(paths) - 1; i >= 0; i-- {
+		path := paths[i]
+		original := tx.originals[path]
+		if original.exists {
+			if err := writeFileAtomic(path, original.content); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			}
+		} else if err := osRemove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
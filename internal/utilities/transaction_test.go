@@ -0,0 +1,158 @@
+package utilities
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+)
+
+// renameEdit replaces "old" with "new" on the first line of each file
+func renameEdit(uris ...protocol.DocumentUri) protocol.WorkspaceEdit {
+	edit := protocol.WorkspaceEdit{Changes: map[protocol.DocumentUri][]protocol.TextEdit{}}
+	for _, uri := range uris {
+		edit.Changes[uri] = []protocol.TextEdit{
+			{
+				Range: protocol.Range{
+					Start: protocol.Position{Line: 0, Character: 0},
+					End:   protocol.Position{Line: 0, Character: 3},
+				},
+				NewText: "new",
+			},
+		}
+	}
+	return edit
+}
+
+func TestEditTransactionRollback(t *testing.T) {
+	mfs := &mockFileSystem{
+		files: map[string][]byte{
+			"/test/a.txt": []byte("old a"),
+			"/test/b.txt": []byte("old b"),
+		},
+		errors: map[string]error{},
+	}
+	cleanup := setupMockFileSystem(t, mfs)
+	defer cleanup()
+
+	tx, err := StageWorkspaceEdit(renameEdit("file:///test/a.txt", "file:///test/b.txt"), nil, protocol.UTF16)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The second write fails, so the first one must be undone
+	mfs.errors["/test/b.txt_write"] = errors.New("disk full")
+	err = tx.Commit()
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("Expected rollback error, got: %v", err)
+	}
+
+	if string(mfs.files["/test/a.txt"]) != "old a" {
+		t.Errorf("a.txt was not rolled back, content: %s", string(mfs.files["/test/a.txt"]))
+	}
+	if string(mfs.files["/test/b.txt"]) != "old b" {
+		t.Errorf("b.txt was modified, content: %s", string(mfs.files["/test/b.txt"]))
+	}
+}
+
+func TestEditTransactionRollbackRename(t *testing.T) {
+	mfs := &mockFileSystem{
+		files: map[string][]byte{
+			"/test/old.txt":   []byte("content"),
+			"/test/other.txt": []byte("old other"),
+		},
+		errors: map[string]error{},
+	}
+	cleanup := setupMockFileSystem(t, mfs)
+	defer cleanup()
+
+	edit := renameEdit("file:///test/other.txt")
+	edit.DocumentChanges = []protocol.DocumentChange{
+		{
+			RenameFile: &protocol.RenameFile{
+				OldURI: "file:///test/old.txt",
+				NewURI: "file:///test/new.txt",
+			},
+		},
+	}
+
+	tx, err := StageWorkspaceEdit(edit, nil, protocol.UTF16)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Removing the old name fails after the new name and other.txt were written
+	mfs.errors["/test/old.txt_remove"] = errors.New("permission denied")
+	if err := tx.Commit(); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if _, ok := mfs.files["/test/new.txt"]; ok {
+		t.Errorf("Renamed file was not removed on rollback")
+	}
+	if string(mfs.files["/test/old.txt"]) != "content" {
+		t.Errorf("Original file was lost, content: %s", string(mfs.files["/test/old.txt"]))
+	}
+	if string(mfs.files["/test/other.txt"]) != "old other" {
+		t.Errorf("other.txt was not rolled back, content: %s", string(mfs.files["/test/other.txt"]))
+	}
+}
+
+func TestEditTransactionPreconditions(t *testing.T) {
+	t.Run("File changed after staging", func(t *testing.T) {
+		mfs := &mockFileSystem{
+			files: map[string][]byte{
+				"/test/a.txt": []byte("old a"),
+				"/test/b.txt": []byte("old b"),
+			},
+		}
+		cleanup := setupMockFileSystem(t, mfs)
+		defer cleanup()
+
+		tx, err := StageWorkspaceEdit(renameEdit("file:///test/a.txt", "file:///test/b.txt"), nil, protocol.UTF16)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		mfs.files["/test/b.txt"] = []byte("old b, edited elsewhere")
+		err = tx.Commit()
+		if err == nil || !strings.Contains(err.Error(), "changed on disk") {
+			t.Fatalf("Expected changed on disk error, got: %v", err)
+		}
+
+		// Nothing was written
+		if string(mfs.files["/test/a.txt"]) != "old a" {
+			t.Errorf("a.txt was modified, content: %s", string(mfs.files["/test/a.txt"]))
+		}
+	})
+
+	t.Run("Document version mismatch", func(t *testing.T) {
+		mfs := &mockFileSystem{
+			files: map[string][]byte{
+				"/test/a.txt": []byte("old a"),
+			},
+		}
+		cleanup := setupMockFileSystem(t, mfs)
+		defer cleanup()
+
+		edit := protocol.WorkspaceEdit{
+			DocumentChanges: []protocol.DocumentChange{
+				{
+					TextDocumentEdit: &protocol.TextDocumentEdit{
+						TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+							Version:                3,
+							TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: "file:///test/a.txt"},
+						},
+					},
+				},
+			},
+		}
+		versions := func(path string) (int32, bool) { return 4, true }
+
+		_, err := StageWorkspaceEdit(edit, versions, protocol.UTF16)
+		if err == nil || !strings.Contains(err.Error(), "version 3") {
+			t.Fatalf("Expected version error, got: %v", err)
+		}
+	})
+}
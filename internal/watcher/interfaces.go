@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 )
 
@@ -18,8 +19,15 @@ type LSPClient interface {
 	// NotifyChange notifies the server of a file change
 	NotifyChange(ctx context.Context, path string) error
 
+	// CloseFile closes a file in the editor
+	CloseFile(ctx context.Context, path string) error
+
 	// DidChangeWatchedFiles sends watched file events to the server
 	DidChangeWatchedFiles(ctx context.Context, params protocol.DidChangeWatchedFilesParams) error
+
+	// SetFileWatchHandler sets the functions told about the file watchers the server
+	// registers and unregisters
+	SetFileWatchHandler(onWatch lsp.FileWatchHandler, onUnwatch func(id string))
 }
 
 // WatcherConfig holds basic configuration for the watcher
@@ -38,6 +46,10 @@ type WatcherConfig struct {
 
 	// MaxFileSize is the maximum size of a file to open
 	MaxFileSize int64
+
+	// OnFileEvent, if set, is called with the path of every file or directory that
+	// changes in the workspace, whether or not the server watches it
+	OnFileEvent func(path string)
 }
 
 // DefaultWatcherConfig returns a configuration with sensible defaults
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 	"github.com/isaacphi/mcp-language-server/internal/watcher"
 )
@@ -23,6 +24,8 @@ type MockLSPClient struct {
 	notifyErrors   map[string]error
 	changeErrors   map[string]error
 	eventsReceived chan struct{}
+	// Number of didChangeWatchedFiles notifications sent
+	notifications int
 }
 
 // NewMockLSPClient creates a new mock LSP client for testing
@@ -57,6 +60,15 @@ func (m *MockLSPClient) OpenFile(ctx context.Context, path string) error {
 	return nil
 }
 
+// CloseFile mocks closing a file in the editor
+func (m *MockLSPClient) CloseFile(ctx context.Context, path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.openedFiles, path)
+	return nil
+}
+
 // NotifyChange mocks notifying the server of a file change
 func (m *MockLSPClient) NotifyChange(ctx context.Context, path string) error {
 	m.mu.Lock()
@@ -68,7 +80,7 @@ func (m *MockLSPClient) NotifyChange(ctx context.Context, path string) error {
 
 	// Record this as a change event
 	m.events = append(m.events, FileEvent{
-		URI:  "file://" + path,
+		URI:  string(protocol.URIFromPath(path)),
 		Type: protocol.FileChangeType(protocol.Changed),
 	})
 
@@ -87,6 +99,7 @@ func (m *MockLSPClient) DidChangeWatchedFiles(ctx context.Context, params protoc
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.notifications++
 	for _, change := range params.Changes {
 		uri := string(change.URI)
 
@@ -111,6 +124,11 @@ func (m *MockLSPClient) DidChangeWatchedFiles(ctx context.Context, params protoc
 	return nil
 }
 
+// SetFileWatchHandler mocks setting the handlers of file watcher registrations, which
+// the tests make directly on the watcher
+func (m *MockLSPClient) SetFileWatchHandler(onWatch lsp.FileWatchHandler, onUnwatch func(id string)) {
+}
+
 // GetEvents returns a copy of all recorded events
 func (m *MockLSPClient) GetEvents() []FileEvent {
 	m.mu.Lock()
@@ -136,11 +154,19 @@ func (m *MockLSPClient) CountEvents(uri string, eventType protocol.FileChangeTyp
 	return count
 }
 
+// CountNotifications returns the number of didChangeWatchedFiles notifications sent
+func (m *MockLSPClient) CountNotifications() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.notifications
+}
+
 // ResetEvents clears the recorded events
 func (m *MockLSPClient) ResetEvents() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.events = []FileEvent{}
+	m.notifications = 0
 }
 
 // WaitForEvent waits for at least one event to be received or context to be done
@@ -2,6 +2,7 @@ package testing
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -432,4 +433,31 @@ func TestRapidChangesDebouncing(t *testing.T) {
 			t.Errorf("Expected at most 2 change events due to debouncing, got %d", count)
 		}
 	})
+
+	// Test that events for several files are sent in one notification
+	t.Run("BatchedChanges", func(t *testing.T) {
+		// Reset events
+		mockClient.ResetEvents()
+
+		var paths []string
+		for i := range 3 {
+			path := filepath.Join(testDir, fmt.Sprintf("batch%d.txt", i))
+			if err := os.WriteFile(path, []byte("Batched content"), 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+			paths = append(paths, path)
+		}
+
+		// Wait longer than the debounce time
+		time.Sleep(config.DebounceTime + 200*time.Millisecond)
+
+		for _, path := range paths {
+			if count := mockClient.CountEvents("file://"+path, protocol.FileChangeType(protocol.Created)); count != 1 {
+				t.Errorf("Expected 1 create event for %s, got %d", path, count)
+			}
+		}
+		if count := mockClient.CountNotifications(); count != 1 {
+			t.Errorf("Expected the events to be sent in 1 notification, got %d", count)
+		}
+	})
 }
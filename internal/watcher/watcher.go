@@ -2,16 +2,16 @@ package watcher
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/isaacphi/mcp-language-server/internal/logging"
-	"github.com/isaacphi/mcp-language-server/internal/lsp"
 	"github.com/isaacphi/mcp-language-server/internal/protocol"
 )
 
@@ -23,12 +23,15 @@ type WorkspaceWatcher struct {
 	client        LSPClient
 	workspacePath string
 
-	config      *WatcherConfig
-	debounceMap map[string]*time.Timer
-	debounceMu  sync.Mutex
+	config *WatcherConfig
+
+	// File events waiting to be sent to the server, and the timer that sends them
+	pendingEvents map[string]protocol.FileChangeType
+	flushTimer    *time.Timer
+	debounceMu    sync.Mutex
 
 	// File watchers registered by the server
-	registrations  []protocol.FileSystemWatcher
+	registrations  []registeredWatcher
 	registrationMu sync.RWMutex
 
 	// Gitignore matcher
@@ -45,18 +48,26 @@ func NewWorkspaceWatcherWithConfig(client LSPClient, config *WatcherConfig) *Wor
 	return &WorkspaceWatcher{
 		client:        client,
 		config:        config,
-		debounceMap:   make(map[string]*time.Timer),
-		registrations: []protocol.FileSystemWatcher{},
+		pendingEvents: make(map[string]protocol.FileChangeType),
+		registrations: []registeredWatcher{},
 	}
 }
 
+// registeredWatcher is a file watcher with the ID of the registration that added it
+type registeredWatcher struct {
+	id string
+	protocol.FileSystemWatcher
+}
+
 // AddRegistrations adds file watchers to track
 func (w *WorkspaceWatcher) AddRegistrations(ctx context.Context, id string, watchers []protocol.FileSystemWatcher) {
 	w.registrationMu.Lock()
 	defer w.registrationMu.Unlock()
 
 	// Add new watchers
-	w.registrations = append(w.registrations, watchers...)
+	for _, watcher := range watchers {
+		w.registrations = append(w.registrations, registeredWatcher{id: id, FileSystemWatcher: watcher})
+	}
 
 	// Log registration information
 	watcherLogger.Info("Added %d file watcher registrations (id: %s), total: %d",
@@ -153,6 +164,17 @@ func (w *WorkspaceWatcher) AddRegistrations(ctx context.Context, id string, watc
 	}()
 }
 
+// RemoveRegistrations removes the file watchers of a registration the server withdrew
+func (w *WorkspaceWatcher) RemoveRegistrations(id string) {
+	w.registrationMu.Lock()
+	defer w.registrationMu.Unlock()
+
+	w.registrations = slices.DeleteFunc(w.registrations, func(reg registeredWatcher) bool {
+		return reg.id == id
+	})
+	watcherLogger.Info("Removed file watcher registrations (id: %s), total: %d", id, len(w.registrations))
+}
+
 // WatchWorkspace sets up file watching for a workspace
 func (w *WorkspaceWatcher) WatchWorkspace(ctx context.Context, workspacePath string) {
 	w.workspacePath = workspacePath
@@ -167,9 +189,9 @@ func (w *WorkspaceWatcher) WatchWorkspace(ctx context.Context, workspacePath str
 	}
 
 	// Register handler for file watcher registrations from the server
-	lsp.RegisterFileWatchHandler(func(id string, watchers []protocol.FileSystemWatcher) {
+	w.client.SetFileWatchHandler(func(id string, watchers []protocol.FileSystemWatcher) {
 		w.AddRegistrations(ctx, id, watchers)
-	})
+	}, w.RemoveRegistrations)
 
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -220,7 +242,11 @@ func (w *WorkspaceWatcher) WatchWorkspace(ctx context.Context, workspacePath str
 				return
 			}
 
-			uri := fmt.Sprintf("file://%s", event.Name)
+			uri := string(protocol.URIFromPath(event.Name))
+
+			if w.config.OnFileEvent != nil {
+				w.config.OnFileEvent(event.Name)
+			}
 
 			// Check if this is a file (not a directory) and should be excluded
 			isFile := false
@@ -488,7 +514,6 @@ func (w *WorkspaceWatcher) matchesPattern(path string, pattern protocol.GlobPatt
 	}
 
 	// For relative patterns
-	basePath = strings.TrimPrefix(basePath, "file://")
 	basePath = filepath.ToSlash(basePath)
 
 	// Make path relative to basePath for matching
@@ -505,39 +530,75 @@ func (w *WorkspaceWatcher) matchesPattern(path string, pattern protocol.GlobPatt
 	return isMatch
 }
 
-// debounceHandleFileEvent handles file events with debouncing to reduce notifications
+// debounceHandleFileEvent queues a file event. Events that arrive within the debounce time
+// of the first queued event are sent together, so a git checkout or code generator that
+// touches many files produces a single notification rather than one per file.
 func (w *WorkspaceWatcher) debounceHandleFileEvent(ctx context.Context, uri string, changeType protocol.FileChangeType) {
 	w.debounceMu.Lock()
 	defer w.debounceMu.Unlock()
 
-	// Create a unique key based on URI and change type
-	key := fmt.Sprintf("%s:%d", uri, changeType)
+	if previous, ok := w.pendingEvents[uri]; ok {
+		changeType = coalesceFileChange(previous, changeType)
+	}
+	w.pendingEvents[uri] = changeType
 
-	// Cancel existing timer if any
-	if timer, exists := w.debounceMap[key]; exists {
-		timer.Stop()
+	if w.flushTimer == nil {
+		w.flushTimer = time.AfterFunc(w.config.DebounceTime, func() {
+			w.flushFileEvents(ctx)
+		})
 	}
+}
 
-	// Create new timer
-	w.debounceMap[key] = time.AfterFunc(w.config.DebounceTime, func() {
-		w.handleFileEvent(ctx, uri, changeType)
+// coalesceFileChange combines two events for the same file into the one the server
+// should see
+func coalesceFileChange(previous, next protocol.FileChangeType) protocol.FileChangeType {
+	switch {
+	case previous == protocol.Created && next == protocol.Changed:
+		// Still a new file as far as the server knows
+		return protocol.Created
+	case previous == protocol.Deleted && next == protocol.Created:
+		// Replaced, e.g. by an editor saving through a rename
+		return protocol.Changed
+	default:
+		return next
+	}
+}
 
-		// Cleanup timer after execution
-		w.debounceMu.Lock()
-		delete(w.debounceMap, key)
-		w.debounceMu.Unlock()
-	})
+// flushFileEvents sends all queued file events
+func (w *WorkspaceWatcher) flushFileEvents(ctx context.Context) {
+	w.debounceMu.Lock()
+	events := w.pendingEvents
+	w.pendingEvents = make(map[string]protocol.FileChangeType)
+	w.flushTimer = nil
+	w.debounceMu.Unlock()
+
+	uris := make([]string, 0, len(events))
+	for uri := range events {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	var changes []protocol.FileEvent
+	for _, uri := range uris {
+		if w.syncOpenFile(ctx, uri, events[uri]) {
+			continue
+		}
+		changes = append(changes, protocol.FileEvent{URI: protocol.DocumentUri(uri), Type: events[uri]})
+	}
+
+	if len(changes) == 0 {
+		return
+	}
+
+	watcherLogger.Debug("Notifying %d file events", len(changes))
+	if err := w.client.DidChangeWatchedFiles(ctx, protocol.DidChangeWatchedFilesParams{Changes: changes}); err != nil {
+		watcherLogger.Error("Error notifying LSP server about file events: %v", err)
+	}
 }
 
 // handleFileEvent sends file change notifications
 func (w *WorkspaceWatcher) handleFileEvent(ctx context.Context, uri string, changeType protocol.FileChangeType) {
-	// If the file is open and it's a change event, use didChange notification
-	filePath := uri[7:] // Remove "file://" prefix
-	if changeType == protocol.FileChangeType(protocol.Changed) && w.client.IsFileOpen(filePath) {
-		err := w.client.NotifyChange(ctx, filePath)
-		if err != nil {
-			watcherLogger.Error("Error notifying change: %v", err)
-		}
+	if w.syncOpenFile(ctx, uri, changeType) {
 		return
 	}
 
@@ -547,6 +608,39 @@ func (w *WorkspaceWatcher) handleFileEvent(ctx context.Context, uri string, chan
 	}
 }
 
+// syncOpenFile brings a document the server has open up to date with a change made on
+// disk. It reports whether the event was fully handled, in which case no watched file
+// event needs to be sent.
+func (w *WorkspaceWatcher) syncOpenFile(ctx context.Context, uri string, changeType protocol.FileChangeType) bool {
+	filePath := protocol.DocumentUri(uri).Path()
+	if !w.client.IsFileOpen(filePath) {
+		return false
+	}
+
+	switch changeType {
+	case protocol.Changed:
+		if err := w.client.NotifyChange(ctx, filePath); err != nil {
+			watcherLogger.Error("Error notifying change: %v", err)
+		}
+		return true
+	case protocol.Created:
+		// A file that is created while open was replaced, e.g. by an editor saving
+		// through a rename. Reopen it so the server has the new contents.
+		if err := w.client.CloseFile(ctx, filePath); err != nil {
+			watcherLogger.Error("Error closing replaced file: %v", err)
+		} else if err := w.client.OpenFile(ctx, filePath); err != nil {
+			watcherLogger.Error("Error reopening replaced file: %v", err)
+		}
+	case protocol.Deleted:
+		// Close the document so the server stops reporting on its old contents,
+		// and still send the deletion so it can update its view of the workspace
+		if err := w.client.CloseFile(ctx, filePath); err != nil {
+			watcherLogger.Error("Error closing deleted file: %v", err)
+		}
+	}
+	return false
+}
+
 // notifyFileEvent sends a didChangeWatchedFiles notification for a file event
 func (w *WorkspaceWatcher) notifyFileEvent(ctx context.Context, uri string, changeType protocol.FileChangeType) error {
 	watcherLogger.Debug("Notifying file event: %s (type: %d)", uri, changeType)
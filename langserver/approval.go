@@ -0,0 +1,131 @@
+package langserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ApprovalPolicy decides whether the mutating tools may write the files they change:
+// each write is allowed, denied or needs confirmation, by tool and by path glob
+type ApprovalPolicy = tools.ApprovalPolicy
+
+// ApprovalRule applies an action to the writes of some tools to some files
+type ApprovalRule = tools.ApprovalRule
+
+// How long a confirmation token stays valid
+const confirmationTTL = 10 * time.Minute
+
+// confirmations holds the tokens issued to tool calls that need confirmation. A call
+// repeated with the same arguments and its token is allowed to write, once.
+type confirmations struct {
+	mu      sync.Mutex
+	pending map[string]pendingConfirmation
+}
+
+type pendingConfirmation struct {
+	tool    string
+	args    string
+	expires time.Time
+}
+
+// confirmationArgs returns the arguments of a tool call other than confirm, in a form
+// that can be compared
+func confirmationArgs(request mcp.CallToolRequest) string {
+	args := make(map[string]any)
+	for name, value := range request.GetArguments() {
+		if name != "confirm" {
+			args[name] = value
+		}
+	}
+	data, _ := json.Marshal(args)
+	return string(data)
+}
+
+// issue returns a new token confirming a tool call
+func (c *confirmations) issue(request mcp.CallToolRequest) string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending == nil {
+		c.pending = make(map[string]pendingConfirmation)
+	}
+	now := time.Now()
+	for t, p := range c.pending {
+		if now.After(p.expires) {
+			delete(c.pending, t)
+		}
+	}
+	c.pending[token] = pendingConfirmation{
+		tool:    request.Params.Name,
+		args:    confirmationArgs(request),
+		expires: now.Add(confirmationTTL),
+	}
+	return token
+}
+
+// use consumes the token a tool call was repeated with
+func (c *confirmations) use(token string, request mcp.CallToolRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.pending[token]
+	if !ok || time.Now().After(p.expires) {
+		return fmt.Errorf("unknown or expired confirmation token %q", token)
+	}
+	if p.tool != request.Params.Name || p.args != confirmationArgs(request) {
+		return fmt.Errorf("confirmation token %q was issued for a different call, repeat the call with the same arguments", token)
+	}
+	delete(c.pending, token)
+	return nil
+}
+
+// editMiddleware wraps a tool that changes files: the files it changes are recorded in
+// the edit journal, and its writes are checked against the approval policy. Writes that
+// need confirmation are stopped and the call returns a token; repeating the call with the
+// token as its confirm argument applies it. Clients that ask the user before each tool
+// call thereby show the user the confirmation.
+func (s *Server) editMiddleware(tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if !s.config.Approval.IsEmpty() {
+		tool.InputSchema.Properties["confirm"] = map[string]any{
+			"type":        "string",
+			"description": "The confirmation token returned by an earlier call with the same arguments that needed confirmation under the approval policy. Only pass it once the change was approved.",
+		}
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Requests carry the tool's own name even if it is exposed under another
+		approval := &tools.Approval{Policy: s.config.Approval, Tool: request.Params.Name}
+		if token := request.GetString("confirm", ""); token != "" {
+			if err := s.confirmations.use(token, request); err != nil {
+				return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+			}
+			approval.Confirmed = true
+		}
+
+		entry := s.journal.Begin(request.Params.Name)
+		defer s.journal.Record(entry)
+		result, err := next(tools.WithApproval(tools.WithJournal(ctx, entry), approval), request)
+
+		if files := approval.Unconfirmed(); len(files) > 0 {
+			coreLogger.Info("Call of %s needs confirmation to change %d files", request.Params.Name, len(files))
+			token := s.confirmations.issue(request)
+			return mcp.NewToolResultError(fmt.Sprintf(
+				"The approval policy requires confirmation for %s to change these files, so it stopped before writing them:\n%s\n"+
+					"Ask the user to approve the change, reviewing it with dryRun if the tool supports it, then call %s again with the same arguments and confirm set to %q. The token expires in %s.",
+				tool.Name, strings.Join(files, "\n"), tool.Name, token, confirmationTTL)), nil
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,186 @@
+package langserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// targetsParam is the schema of the targets argument of the batch tools
+var targetsParam = mcp.WithArray("targets",
+	mcp.Required(),
+	mcp.Description(fmt.Sprintf("The symbols to query, each by filePath, line and column or by symbolName (at most %d)", tools.MaxBatchTargets)),
+	mcp.Items(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"filePath": map[string]any{
+				"type":        "string",
+				"description": "The path to the file containing the symbol. Required unless symbolName is given, in which case the symbol is looked up in this file only.",
+			},
+			"line": map[string]any{
+				"type":        "number",
+				"description": "The line number where the symbol is located, one-indexed. Required unless symbolName is given.",
+			},
+			"column": map[string]any{
+				"type":        "number",
+				"description": "The column number where the symbol is located, one-indexed. Required unless symbolName is given.",
+			},
+			"symbolName": map[string]any{
+				"type":        "string",
+				"description": "The name of the symbol, as an alternative to line and column",
+			},
+		},
+	}),
+)
+
+// registerBatchTools adds variants of the position based tools that query several
+// symbols concurrently in one call
+func (s *Server) registerBatchTools() {
+	referencesBatchTool := mcp.NewTool("references_batch",
+		mcp.WithDescription("Find the references of several symbols in one call. The queries run concurrently and the results are returned in the order of the targets, as the references tool would return them."),
+		targetsParam,
+		mcp.WithNumber("contextLines",
+			mcp.Description("Lines of context to include around each result. Defaults to the server's configured value."),
+		),
+		mcp.WithNumber("maxResults",
+			mcp.Description("Maximum number of references to return per target (default 100, 0 for no limit)"),
+		),
+		mcp.WithBoolean("includeDeclaration",
+			mcp.Description("Include the declaration of each symbol in its references (default false)"),
+		),
+		mcp.WithBoolean("blame",
+			mcp.Description("Annotate each reference with the commit, author and date that last changed its line, from git blame (default false)"),
+		),
+		mcp.WithBoolean("includeGenerated",
+			mcp.Description("Show the lines around references in binary and generated files, which are otherwise only counted (default false)"),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only return results in files matching these globs, e.g. [\"internal/**\", \"*.go\"]. Replaces the server's configured includes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Leave out results in files matching these globs, e.g. [\"vendor/\", \"*_test.go\"]. Added to the server's configured excludes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		scopeParam,
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(referencesBatchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		targets, err := s.targetsArg(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		contextLines, err := s.contextLinesArg(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		page, err := paginationArgs(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		filter, err := s.pathFilterArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		opts := tools.ReferenceOptions{
+			IncludeDeclaration: request.GetBool("includeDeclaration", false),
+			Blame:              request.GetBool("blame", false),
+			IncludeGenerated:   request.GetBool("includeGenerated", false),
+		}
+
+		coreLogger.Debug("Executing references_batch for %d targets", len(targets))
+		text, err := tools.RunBatch(ctx, s.lspClient, targets, func(ctx context.Context, filePath string, line, column int) (string, error) {
+			return tools.FindReferences(ctx, s.lspClient, filePath, line, column, contextLines, page, filter, opts)
+		})
+		if err != nil {
+			coreLogger.Error("Failed to find references: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find references: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	hoverBatchTool := mcp.NewTool("hover_batch",
+		mcp.WithDescription("Get hover information for several symbols in one call. The queries run concurrently and the results are returned in the order of the targets."),
+		targetsParam,
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(hoverBatchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		targets, err := s.targetsArg(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing hover_batch for %d targets", len(targets))
+		text, err := tools.RunBatch(ctx, s.lspClient, targets, func(ctx context.Context, filePath string, line, column int) (string, error) {
+			return tools.GetHoverInfo(ctx, s.lspClient, filePath, line, column)
+		})
+		if err != nil {
+			coreLogger.Error("Failed to get hover information: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get hover information: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	definitionBodyBatchTool := mcp.NewTool("definition_body_batch",
+		mcp.WithDescription("Go to the definitions of several symbols in one call and return their complete source. The queries run concurrently and the results are returned in the order of the targets."),
+		targetsParam,
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(definitionBodyBatchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		targets, err := s.targetsArg(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing definition_body_batch for %d targets", len(targets))
+		text, err := tools.RunBatch(ctx, s.lspClient, targets, func(ctx context.Context, filePath string, line, column int) (string, error) {
+			return tools.GetDefinitionBody(ctx, s.lspClient, filePath, line, column)
+		})
+		if err != nil {
+			coreLogger.Error("Failed to get definition bodies: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get definition bodies: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+}
+
+// targetsArg returns the targets argument of a batch tool call
+func (s *Server) targetsArg(request mcp.CallToolRequest) ([]tools.PositionTarget, error) {
+	targetsArray, ok := request.GetArguments()["targets"].([]any)
+	if !ok {
+		return nil, fmt.Errorf("targets must be an array")
+	}
+
+	var targets []tools.PositionTarget
+	for _, item := range targetsArray {
+		targetMap, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("each target must be an object")
+		}
+
+		filePath, _ := targetMap["filePath"].(string)
+		filePath = s.resolvePath(filePath)
+		line, _ := targetMap["line"].(float64)
+		column, _ := targetMap["column"].(float64)
+		symbolName, _ := targetMap["symbolName"].(string)
+
+		targets = append(targets, tools.PositionTarget{
+			FilePath:   filePath,
+			Line:       int(line),
+			Column:     int(column),
+			SymbolName: symbolName,
+		})
+	}
+	return targets, nil
+}
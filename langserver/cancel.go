@@ -0,0 +1,80 @@
+package langserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Tool handlers are not given the JSON-RPC ID of their request, so a hook records it in
+// the request's metadata under this key
+const requestIDMetaKey = "mcp-language-server/requestId"
+
+// callKey identifies an in-flight tool call. Request IDs are only unique per session.
+type callKey struct {
+	session string
+	id      string
+}
+
+func newCallKey(ctx context.Context, id any) callKey {
+	key := callKey{id: fmt.Sprint(id)}
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		key.session = session.SessionID()
+	}
+	return key
+}
+
+// recordRequestID stores the ID of a tool call request where trackCall can find it
+func recordRequestID(ctx context.Context, id any, request *mcp.CallToolRequest) {
+	if request.Params.Meta == nil {
+		request.Params.Meta = &mcp.Meta{}
+	}
+	if request.Params.Meta.AdditionalFields == nil {
+		request.Params.Meta.AdditionalFields = make(map[string]any)
+	}
+	request.Params.Meta.AdditionalFields[requestIDMetaKey] = id
+}
+
+// trackCall returns a context that is cancelled when the client cancels the tool call,
+// and a function to call once the call is done
+func (s *Server) trackCall(ctx context.Context, request mcp.CallToolRequest) (context.Context, func()) {
+	if request.Params.Meta == nil || request.Params.Meta.AdditionalFields[requestIDMetaKey] == nil {
+		return ctx, func() {}
+	}
+	key := newCallKey(ctx, request.Params.Meta.AdditionalFields[requestIDMetaKey])
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.callsMu.Lock()
+	if s.calls == nil {
+		s.calls = make(map[callKey]context.CancelFunc)
+	}
+	s.calls[key] = cancel
+	s.callsMu.Unlock()
+
+	return ctx, func() {
+		s.callsMu.Lock()
+		delete(s.calls, key)
+		s.callsMu.Unlock()
+		cancel()
+	}
+}
+
+// handleCancelled cancels the tool call named by a notifications/cancelled from the
+// client, which stops its language server requests and file reads
+func (s *Server) handleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	id, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+	key := newCallKey(ctx, id)
+
+	s.callsMu.Lock()
+	cancel, ok := s.calls[key]
+	s.callsMu.Unlock()
+	if ok {
+		coreLogger.Debug("Cancelling tool call %s", key.id)
+		cancel()
+	}
+}
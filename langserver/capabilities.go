@@ -0,0 +1,172 @@
+package langserver
+
+import (
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolMethods are the language server requests tools can't work without. Tools are only
+// registered while the server supports their request, see lsp.Client.SupportsMethod.
+var toolMethods = map[string]string{
+	"definition":            "workspace/symbol",
+	"symbol_search":         "workspace/symbol",
+	"definition_body":       "textDocument/definition",
+	"definition_body_batch": "textDocument/definition",
+	"dependency_source":     "textDocument/definition",
+	"references":            "textDocument/references",
+	"references_batch":      "textDocument/references",
+	"find_key_usages":       "textDocument/references",
+	"callers":               "textDocument/references",
+	"hover":                 "textDocument/hover",
+	"hover_batch":           "textDocument/hover",
+	"explain_symbol":        "textDocument/hover",
+	"implementation":        "textDocument/implementation",
+	"type_definition":       "textDocument/typeDefinition",
+	"rename_symbol":         "textDocument/rename",
+	"package_api":           "textDocument/documentSymbol",
+	"extract":               "textDocument/codeAction",
+	"inline":                "textDocument/codeAction",
+	"generate":              "textDocument/codeAction",
+	"fix_diagnostics":       "textDocument/codeAction",
+	"fix_all":               "textDocument/codeAction",
+	"rust_runnables":        "experimental/runnables",
+	"switch_source_header":  "textDocument/switchSourceHeader",
+}
+
+// toolCommands are the workspace/executeCommand commands tools run, for the tools specific
+// to a language server. Tools are only registered while the server supports their command,
+// see lsp.Client.SupportsCommand.
+var toolCommands = map[string]string{
+	"go_test":        tools.GoTestCommand,
+	"ts_rename_file": tools.TSRenameFileCommand,
+}
+
+// languageTools are the tools specific to a language that are only registered for its
+// language servers
+var languageTools = map[string]func(s *Server) bool{
+	"python_interpreter": (*Server).isPythonServer,
+}
+
+// fallbackTools are the tools the syntactic fallback answers for when the language
+// server can't, so they are registered whatever the server supports while it is on
+var fallbackTools = map[string]bool{
+	"definition":    true,
+	"symbol_search": true,
+	"package_api":   true,
+}
+
+// indexTools are the tools the precomputed index answers for, so they are registered
+// whatever the server supports while an index is loaded
+var indexTools = map[string]bool{
+	"definition": true,
+	"references": true,
+	"hover":      true,
+}
+
+// gatedTools holds the tools that depend on a capability of the language server, and
+// whether each is registered
+type gatedTools struct {
+	mu         sync.Mutex
+	tools      []gatedTool
+	registered map[string]bool
+}
+
+type gatedTool struct {
+	name string
+	// method or command is what the tool needs the server to support
+	method  string
+	command string
+	tool    server.ServerTool
+}
+
+// supported reports whether the server supports what the tool needs
+func (g gatedTool) supported(client *lsp.Client) bool {
+	if g.command != "" {
+		return client.SupportsCommand(g.command)
+	}
+	return client.SupportsMethod(g.method)
+}
+
+// requirement names what the tool needs the server to support, for logging
+func (g gatedTool) requirement() string {
+	if g.command != "" {
+		return "the " + g.command + " command"
+	}
+	return g.method
+}
+
+// registerTool adds a tool to the MCP server. Tools listed in toolMethods are held back
+// while the language server doesn't support their request.
+func (s *Server) registerTool(name string, tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if forLanguage, ok := languageTools[name]; ok && !forLanguage(s) {
+		coreLogger.Debug("Not registering %s, which is for another language", name)
+		return
+	}
+	method, gatedByMethod := toolMethods[name]
+	command, gatedByCommand := toolCommands[name]
+	if !gatedByMethod && !gatedByCommand || (s.fallback != nil && fallbackTools[name]) || (s.config.Index != "" && indexTools[name]) {
+		s.mcpServer.AddTool(tool, handler)
+		return
+	}
+
+	s.lspMu.RLock()
+	client := s.lspClient
+	s.lspMu.RUnlock()
+
+	s.gated.mu.Lock()
+	defer s.gated.mu.Unlock()
+	g := gatedTool{
+		name:    name,
+		method:  method,
+		command: command,
+		tool:    server.ServerTool{Tool: tool, Handler: handler},
+	}
+	s.gated.tools = append(s.gated.tools, g)
+	if client != nil && !g.supported(client) {
+		coreLogger.Info("Language server doesn't support %s, not registering %s until it does", g.requirement(), name)
+		return
+	}
+	if s.gated.registered == nil {
+		s.gated.registered = make(map[string]bool)
+	}
+	s.gated.registered[tool.Name] = true
+	s.mcpServer.AddTool(tool, handler)
+}
+
+// refreshTools registers the tools whose request the language server now supports and
+// removes those it no longer does, after it registered capabilities. The MCP server
+// tells clients the list of tools changed.
+func (s *Server) refreshTools(client *lsp.Client) {
+	s.gated.mu.Lock()
+	defer s.gated.mu.Unlock()
+	if s.gated.registered == nil {
+		s.gated.registered = make(map[string]bool)
+	}
+
+	var add []server.ServerTool
+	var remove []string
+	for _, g := range s.gated.tools {
+		exposed := g.tool.Tool.Name
+		supported := g.supported(client)
+		switch {
+		case supported && !s.gated.registered[exposed]:
+			coreLogger.Info("Language server now supports %s, registering %s", g.requirement(), g.name)
+			add = append(add, g.tool)
+			s.gated.registered[exposed] = true
+		case !supported && s.gated.registered[exposed]:
+			coreLogger.Info("Language server no longer supports %s, removing %s", g.requirement(), g.name)
+			remove = append(remove, exposed)
+			delete(s.gated.registered, exposed)
+		}
+	}
+	if len(add) > 0 {
+		s.mcpServer.AddTools(add...)
+	}
+	if len(remove) > 0 {
+		s.mcpServer.DeleteTools(remove...)
+	}
+}
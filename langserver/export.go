@@ -0,0 +1,232 @@
+package langserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/index"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// The scheme of the symbols of exported indexes
+const exportScheme = "mcp-language-server"
+
+// How many files are crawled between progress messages
+const exportProgressInterval = 100
+
+// ExportOptions changes what ExportSCIP crawls
+type ExportOptions struct {
+	// Extensions limits the crawl to files with these extensions, such as ".go". By
+	// default the files of every language with an LSP identifier are crawled, and those
+	// the language server has no symbols for are left out.
+	Extensions []string
+	// Version is recorded in the index as the version of the tool that made it
+	Version string
+}
+
+// ExportResult counts what ExportSCIP wrote
+type ExportResult struct {
+	Documents   int
+	Symbols     int
+	Occurrences int
+}
+
+// ExportSCIP crawls the workspace through the language server and writes a SCIP index of
+// it to w: the symbols each file declares with their hover information, and the
+// references to them. Indexes written this way can be loaded with Config.Index. Start
+// must have been called.
+func (s *Server) ExportSCIP(ctx context.Context, w io.Writer, opts ExportOptions) (ExportResult, error) {
+	if err := s.acquireLSP(); err != nil {
+		return ExportResult{}, err
+	}
+	defer s.releaseLSP()
+	client := s.lspClient
+
+	files, err := tools.ListWorkspaceFiles(ctx, s.config.WorkspaceDir)
+	if err != nil {
+		return ExportResult{}, fmt.Errorf("failed to list workspace files: %v", err)
+	}
+
+	e := &exporter{
+		root:      s.config.WorkspaceDir,
+		client:    client,
+		documents: make(map[string]*index.SCIPDocument),
+	}
+	crawled := 0
+	for _, path := range files {
+		if !exportable(path, opts.Extensions) {
+			continue
+		}
+		if err := e.exportFile(ctx, path); err != nil {
+			if ctx.Err() != nil {
+				return ExportResult{}, ctx.Err()
+			}
+			coreLogger.Warn("Failed to export %s: %v", path, err)
+		}
+		if crawled++; crawled%exportProgressInterval == 0 {
+			coreLogger.Info("Exported %d files", crawled)
+		}
+	}
+
+	documents := make([]index.SCIPDocument, 0, len(e.documents))
+	result := ExportResult{}
+	for _, doc := range e.documents {
+		documents = append(documents, *doc)
+		result.Symbols += len(doc.Symbols)
+		result.Occurrences += len(doc.Occurrences)
+	}
+	result.Documents = len(documents)
+
+	tool := index.SCIPTool{Name: exportScheme, Version: opts.Version}
+	if err := index.WriteSCIP(w, s.config.WorkspaceDir, tool, documents, client.PositionEncoding()); err != nil {
+		return ExportResult{}, fmt.Errorf("failed to write index: %v", err)
+	}
+	return result, nil
+}
+
+// exportable reports whether a file is crawled, by its extension
+func exportable(path string, extensions []string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if len(extensions) == 0 {
+		return lsp.DetectLanguageID(path) != ""
+	}
+	for _, e := range extensions {
+		if strings.ToLower(e) == ext || "."+strings.ToLower(e) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// exporter collects the documents of an index as the workspace is crawled
+type exporter struct {
+	root      string
+	client    *lsp.Client
+	documents map[string]*index.SCIPDocument
+}
+
+// document returns the document of a file in the workspace, or nil for other files
+func (e *exporter) document(path string) *index.SCIPDocument {
+	rel, err := filepath.Rel(e.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+	doc, ok := e.documents[rel]
+	if !ok {
+		doc = &index.SCIPDocument{RelativePath: rel, Language: string(lsp.DetectLanguageID(path))}
+		e.documents[rel] = doc
+	}
+	return doc
+}
+
+// exportFile adds the symbols a file declares, and the references to them, to the index
+func (e *exporter) exportFile(ctx context.Context, path string) error {
+	if err := e.client.OpenFile(ctx, path); err != nil {
+		return err
+	}
+	defer func() {
+		if err := e.client.CloseFile(ctx, path); err != nil {
+			coreLogger.Debug("Failed to close %s: %v", path, err)
+		}
+	}()
+
+	uri := protocol.URIFromPath(path)
+	result, err := e.client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get document symbols: %v", err)
+	}
+	symbols, err := result.Results()
+	if err != nil {
+		return fmt.Errorf("failed to process document symbols: %v", err)
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+	doc := e.document(path)
+	if doc == nil {
+		return nil
+	}
+
+	var visit func(symbols []protocol.DocumentSymbolResult, parents []index.SCIPDescriptor) error
+	visit = func(symbols []protocol.DocumentSymbolResult, parents []index.SCIPDescriptor) error {
+		overloads := make(map[string]int)
+		for _, sym := range symbols {
+			var selection protocol.Range
+			var kind protocol.SymbolKind
+			var children []protocol.DocumentSymbolResult
+			descriptors := parents[:len(parents):len(parents)]
+			switch v := sym.(type) {
+			case *protocol.DocumentSymbol:
+				selection, kind = v.SelectionRange, v.Kind
+				for i := range v.Children {
+					children = append(children, &v.Children[i])
+				}
+			case *protocol.SymbolInformation:
+				// Flat symbols only name their container
+				selection, kind = v.Location.Range, v.Kind
+				if v.ContainerName != "" {
+					descriptors = append(descriptors, index.SCIPDescriptor{Name: v.ContainerName, Kind: protocol.Class})
+				}
+			}
+
+			name := sym.GetName()
+			descriptors = append(descriptors, index.SCIPDescriptor{Name: name, Kind: kind, Overload: overloads[name]})
+			overloads[name]++
+			symbol := index.FormatSCIPSymbol(exportScheme, doc.RelativePath, descriptors)
+			if err := e.exportSymbol(ctx, doc, uri, selection, symbol, name); err != nil {
+				return err
+			}
+			if err := visit(children, descriptors); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return visit(symbols, nil)
+}
+
+// exportSymbol adds a symbol declared at a range of a document, with its hover
+// information and the references to it
+func (e *exporter) exportSymbol(ctx context.Context, doc *index.SCIPDocument, uri protocol.DocumentUri, selection protocol.Range, symbol, name string) error {
+	doc.Occurrences = append(doc.Occurrences, index.SCIPOccurrence{Range: selection, Symbol: symbol, Definition: true})
+	info := index.SCIPSymbol{Symbol: symbol, DisplayName: name}
+
+	position := protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position:     selection.Start,
+	}
+	hover, err := e.client.Hover(ctx, protocol.HoverParams{TextDocumentPositionParams: position})
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err == nil && hover.Contents.Value != "" {
+		info.Documentation = []string{hover.Contents.Value}
+	}
+	doc.Symbols = append(doc.Symbols, info)
+
+	refs, err := e.client.References(ctx, protocol.ReferenceParams{TextDocumentPositionParams: position})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		coreLogger.Debug("Failed to get references to %s: %v", name, err)
+		return nil
+	}
+	for _, ref := range refs {
+		if ref.URI == uri && ref.Range == selection {
+			continue
+		}
+		if refDoc := e.document(ref.URI.Path()); refDoc != nil {
+			refDoc.Occurrences = append(refDoc.Occurrences, index.SCIPOccurrence{Range: ref.Range, Symbol: symbol})
+		}
+	}
+	return nil
+}
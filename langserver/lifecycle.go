@@ -0,0 +1,459 @@
+package langserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/metrics"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/internal/watcher"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// readURITimeout bounds how long the language server may take to return the contents of
+// a document that is not a file, such as a dependency source
+const readURITimeout = 30 * time.Second
+
+// processOptions are the options the language server process is started with
+func (c *Config) processOptions() lsp.ProcessOptions {
+	options := lsp.ProcessOptions{Dir: c.LSPDir, Env: c.LSPEnv, Path: c.LSPPath, Launcher: c.LSPLauncher}
+	if c.LSPLauncher != nil {
+		// The working directory is one where the server runs
+		options.Dir = lsp.PathMap(c.PathMap).ToServer(c.LSPDir)
+	}
+	return options
+}
+
+// startLSP spawns, or connects to, and initializes the language server and its workspace
+// watcher. The caller must hold lspMu for writing.
+func (s *Server) startLSP() error {
+	var client *lsp.Client
+	var err error
+	var command []string
+	if s.config.LSPCommand != "" {
+		command = append([]string{s.config.LSPCommand}, s.config.LSPArgs...)
+	}
+	if s.replay != nil {
+		client = lsp.Replay(s.replay, command...)
+		// The recording has the paths of the workspace it was made in
+		if s.replay.Workspace != "" && s.replay.Workspace != s.config.WorkspaceDir {
+			client.SetPathMap(lsp.PathMap{{Host: s.config.WorkspaceDir, Server: s.replay.Workspace}})
+		}
+	} else if s.config.LSPAddress != "" {
+		client, err = lsp.Connect(s.ctx, s.config.LSPAddress, command...)
+	} else {
+		client, err = lsp.NewClientWithOptions(s.config.processOptions(), s.config.LSPCommand, s.config.LSPArgs...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create LSP client: %v", err)
+	}
+	if len(s.config.PathMap) > 0 {
+		client.SetPathMap(s.config.PathMap)
+	}
+	client.SetApplyEditPolicy(s.applyEditPolicy)
+	client.SetQueryOpenMode(s.queryOpenMode, s.config.QueryOpenTTL)
+	client.SetMessageAction(s.config.MessageAction)
+	client.SetSettings(s.settings())
+	client.SetMessageHandler(s.serverMessage)
+	client.SetMaxOpenFiles(s.config.MaxOpenFiles)
+	client.SetMaxConcurrentRequests(s.config.MaxConcurrentRequests)
+	if s.tracer != nil {
+		client.SetTracer(s.tracer)
+	}
+	if s.recorder != nil {
+		s.recorder.Start(s.config.WorkspaceDir, command)
+		client.SetRecorder(s.recorder)
+	}
+	client.SetDiagnosticsHandler(s.diagnosticsChanged)
+	client.SetCapabilitiesHandler(func() { s.refreshTools(client) })
+
+	initResult, err := client.InitializeLSPClient(s.ctx, s.config.WorkspaceDir)
+	if err != nil {
+		if closeErr := client.Close(); closeErr != nil {
+			coreLogger.Error("Failed to close LSP client: %v", closeErr)
+		}
+		return fmt.Errorf("initialize failed: %v", err)
+	}
+
+	coreLogger.Debug("Server capabilities: %+v", initResult.Capabilities)
+
+	watcherCtx, watcherCancel := context.WithCancel(s.ctx)
+	watcherConfig := watcher.DefaultWatcherConfig()
+	watcherConfig.OnFileEvent = s.files.Invalidate
+	workspaceWatcher := watcher.NewWorkspaceWatcherWithConfig(client, watcherConfig)
+	go workspaceWatcher.WatchWorkspace(watcherCtx, s.config.WorkspaceDir)
+
+	// Tool calls only get the client once it is ready, and a client that never gets
+	// ready is not left behind for the next call to use
+	if err := client.WaitForServerReady(s.ctx); err != nil {
+		watcherCancel()
+		if closeErr := client.Close(); closeErr != nil {
+			coreLogger.Error("Failed to close LSP client: %v", closeErr)
+		}
+		return fmt.Errorf("language server not ready: %v", err)
+	}
+
+	// Results can point into dependencies the server reports with URIs of its own
+	s.files.SetURIReader(func(uri string) ([]byte, error) {
+		ctx, cancel := context.WithTimeout(s.ctx, readURITimeout)
+		defer cancel()
+		text, err := client.ReadURI(ctx, protocol.DocumentUri(uri))
+		return []byte(text), err
+	})
+
+	s.lspClient = client
+	s.workspaceWatcher = workspaceWatcher
+	s.watcherCancel = watcherCancel
+	s.refreshTools(client)
+
+	// Reopen the files the previous server instance had open so that
+	// diagnostics and references behave as they did before the restart
+	for path, sessions := range s.resyncFiles {
+		for _, sessionID := range sessions {
+			if err := client.OpenFile(lsp.WithSession(s.ctx, sessionID), path); err != nil {
+				coreLogger.Warn("Failed to reopen %s after restart: %v", path, err)
+				break
+			}
+		}
+	}
+	s.resyncFiles = nil
+
+	s.lastUsed.Store(time.Now().UnixNano())
+	return nil
+}
+
+// stopLSP shuts down the running language server, remembering which files were
+// open so they can be resynced on restart. The caller must hold lspMu for writing.
+func (s *Server) stopLSP(ctx context.Context) {
+	if s.lspClient == nil {
+		return
+	}
+
+	s.resyncFiles = s.lspClient.OpenFileSessions()
+	if s.watcherCancel != nil {
+		s.watcherCancel()
+	}
+	shutdownLSPClient(ctx, s.lspClient)
+
+	s.lspClient = nil
+	s.workspaceWatcher = nil
+	s.watcherCancel = nil
+}
+
+// How long Close waits for the tool calls in progress to finish
+const drainTimeout = 2 * time.Second
+
+// lockWithin locks l unless ctx is done first, and reports whether it did
+func lockWithin(ctx context.Context, l sync.Locker) bool {
+	locked := make(chan struct{})
+	go func() {
+		l.Lock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		return true
+	case <-ctx.Done():
+		// Release the lock once it is taken, as nobody holds it for us
+		go func() {
+			<-locked
+			l.Unlock()
+		}()
+		return false
+	}
+}
+
+// shutdownLSPClient runs the shutdown/exit sequence for a client, bounded by timeouts
+// so that an unresponsive server cannot block us
+func shutdownLSPClient(ctx context.Context, client *lsp.Client) {
+	coreLogger.Info("Closing open files")
+	client.CloseAllFiles(ctx)
+
+	// Create a shorter timeout context for the shutdown request
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer shutdownCancel()
+
+	// Run shutdown in a goroutine with timeout to avoid blocking if LSP doesn't respond
+	shutdownDone := make(chan struct{})
+	go func() {
+		coreLogger.Info("Sending shutdown request")
+		if err := client.Shutdown(shutdownCtx); err != nil {
+			coreLogger.Error("Shutdown request failed: %v", err)
+		}
+		close(shutdownDone)
+	}()
+
+	// Wait for shutdown with timeout
+	select {
+	case <-shutdownDone:
+		coreLogger.Info("Shutdown request completed")
+	case <-time.After(1 * time.Second):
+		coreLogger.Warn("Shutdown request timed out, proceeding with exit")
+	}
+
+	coreLogger.Info("Sending exit notification")
+	if err := client.Exit(ctx); err != nil {
+		coreLogger.Error("Exit notification failed: %v", err)
+	}
+
+	coreLogger.Info("Closing LSP client")
+	if err := client.Close(); err != nil {
+		coreLogger.Error("Failed to close LSP client: %v", err)
+	}
+}
+
+// acquireLSP ensures the language server is running, respawning it if it was
+// stopped for inactivity, and holds a read lock on it until releaseLSP is called
+func (s *Server) acquireLSP() error {
+	for {
+		s.lspMu.RLock()
+		if s.lspClient != nil {
+			return nil
+		}
+		s.lspMu.RUnlock()
+
+		s.lspMu.Lock()
+		if s.ctx.Err() != nil {
+			s.lspMu.Unlock()
+			return fmt.Errorf("the server is shutting down")
+		}
+		if s.lspClient == nil {
+			coreLogger.Info("Restarting language server")
+			metrics.LSPRestarts.Inc()
+			if err := s.startLSP(); err != nil {
+				s.lspMu.Unlock()
+				return err
+			}
+		}
+		s.lspMu.Unlock()
+	}
+}
+
+// releaseLSP releases the lock taken by acquireLSP and records the activity
+func (s *Server) releaseLSP() {
+	s.lastUsed.Store(time.Now().UnixNano())
+	s.lspMu.RUnlock()
+}
+
+// lspMiddleware makes sure every tool call runs against a live language server within its
+// timeout, opens files on behalf of the calling MCP session, reports the server's
+// progress, shows paths relative to the workspace if configured to, keeps the result
+// within the output cap and records metrics about the call
+func (s *Server) lspMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, outcome, err := s.runTool(ctx, request, next)
+		s.relativizeResult(result)
+		s.limitOutput(request, result)
+		s.recordToolCall(request, result)
+		metrics.ToolCalls.Inc(request.Params.Name, outcome)
+		metrics.ToolDuration.ObserveSince(start, request.Params.Name)
+		return result, err
+	}
+}
+
+// runTool runs a tool call for lspMiddleware and returns its outcome for the metrics: ok,
+// error, timeout or cancelled
+func (s *Server) runTool(ctx context.Context, request mcp.CallToolRequest, next server.ToolHandlerFunc) (*mcp.CallToolResult, string, error) {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		ctx = lsp.WithSession(ctx, session.SessionID())
+	}
+	ctx = lsp.WithRequestGroup(ctx)
+	ctx, done := s.trackCall(ctx, request)
+	defer done()
+
+	if err := s.acquireLSP(); err != nil {
+		coreLogger.Error("Language server unavailable: %v", err)
+		return mcp.NewToolResultError(fmt.Sprintf("language server unavailable: %v", err)), "error", nil
+	}
+	defer s.releaseLSP()
+	ctx = tools.WithOptions(ctx, s.toolOptions.Load())
+
+	stop := s.forwardProgress(ctx, s.lspClient, request)
+	defer stop()
+
+	timeout := s.toolTimeout(request.Params.Name)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ctx, notes := withCallNotes(ctx)
+	result, err := next(ctx, request)
+	if list := notes.list(); result != nil && len(list) > 0 {
+		contents := make([]mcp.Content, 0, len(list)+len(result.Content))
+		for _, note := range list {
+			contents = append(contents, mcp.NewTextContent(note))
+		}
+		result.Content = append(contents, result.Content...)
+	}
+	if err == nil && result != nil && !result.IsError {
+		if note := indexingNote(s.lspClient); note != "" {
+			result.Content = append(result.Content, mcp.NewTextContent(note))
+		}
+		return result, "ok", nil
+	}
+	switch {
+	case timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		coreLogger.Warn("Tool %s timed out after %s", request.Params.Name, timeout)
+		message := fmt.Sprintf("%s timed out after %s waiting for the language server", request.Params.Name, timeout)
+		if note := indexingNote(s.lspClient); note != "" {
+			message += "\n" + note
+		}
+		return mcp.NewToolResultError(message), "timeout", nil
+	case ctx.Err() != nil:
+		return result, "cancelled", err
+	default:
+		return result, "error", err
+	}
+}
+
+// callNotes collects the notes about how the arguments of a tool call were read, such as
+// a position moved onto an identifier, which runTool puts before the call's result
+type callNotes struct {
+	mu    sync.Mutex
+	notes []string
+}
+
+type callNotesKey struct{}
+
+// withCallNotes returns a context that addCallNote collects notes in
+func withCallNotes(ctx context.Context) (context.Context, *callNotes) {
+	notes := &callNotes{}
+	return context.WithValue(ctx, callNotesKey{}, notes), notes
+}
+
+// addCallNote adds a note to the result of the tool call running with ctx
+func addCallNote(ctx context.Context, note string) {
+	if notes, ok := ctx.Value(callNotesKey{}).(*callNotes); ok {
+		notes.mu.Lock()
+		defer notes.mu.Unlock()
+		notes.notes = append(notes.notes, note)
+	}
+}
+
+func (n *callNotes) list() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.notes...)
+}
+
+// limitOutput shortens the text of a tool result to the tool's output cap
+func (s *Server) limitOutput(request mcp.CallToolRequest, result *mcp.CallToolResult) {
+	limit, ok := s.config.MaxOutputs[request.Params.Name]
+	if !ok {
+		limit = s.config.MaxOutput
+	}
+	if limit <= 0 || result == nil {
+		return
+	}
+
+	// Only references can be paged through past the files that are left out
+	offset := -1
+	if request.Params.Name == "references" {
+		offset = request.GetInt("offset", 0)
+	}
+	for i, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			text.Text = tools.LimitOutput(text.Text, limit, offset)
+			result.Content[i] = text
+		}
+	}
+}
+
+// toolTimeout returns how long the named tool may run, or zero if it may run indefinitely
+func (s *Server) toolTimeout(name string) time.Duration {
+	if timeout, ok := s.config.ToolTimeouts[name]; ok {
+		return timeout
+	}
+	return s.config.ToolTimeout
+}
+
+// How long the warm-up at startup may wait for the language server to index
+const warmUpTimeout = 10 * time.Minute
+
+// warmUp gets the language server to index the workspace after it started, see
+// Config.WarmUp, holding it like a tool call does
+func (s *Server) warmUp() {
+	if err := s.acquireLSP(); err != nil {
+		coreLogger.Warn("Failed to warm up the language server: %v", err)
+		return
+	}
+	defer s.releaseLSP()
+
+	ctx, cancel := context.WithTimeout(tools.WithOptions(s.ctx, s.toolOptions.Load()), warmUpTimeout)
+	defer cancel()
+	coreLogger.Info("Warming up the language server with up to %d files", s.config.WarmUp)
+	text, err := tools.WarmUp(ctx, s.lspClient, s.config.WorkspaceDir, s.config.WarmUp)
+	if err != nil {
+		coreLogger.Warn("Failed to warm up the language server: %v", err)
+		return
+	}
+	coreLogger.Info("Warmed up the language server:\n%s", text)
+}
+
+// How often the symbol cache is saved while it changes
+const symbolCacheSaveInterval = time.Minute
+
+// saveSymbolCache saves the symbol cache periodically, so that little is lost if the
+// process is killed before Close saves it
+func (s *Server) saveSymbolCache(cache *tools.SymbolCache) {
+	ticker := time.NewTicker(symbolCacheSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := cache.Save(); err != nil {
+				coreLogger.Warn("Failed to save the symbol cache: %v", err)
+			}
+		}
+	}
+}
+
+// monitorIdle stops the language server once no tool has been called for the
+// configured idle timeout
+func (s *Server) monitorIdle() {
+	interval := s.config.IdleTimeout / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if time.Since(time.Unix(0, s.lastUsed.Load())) < s.config.IdleTimeout {
+				continue
+			}
+
+			// Tool calls in flight hold a read lock, so skip this tick if any are running
+			if !s.lspMu.TryLock() {
+				continue
+			}
+			if s.lspClient != nil && time.Since(time.Unix(0, s.lastUsed.Load())) >= s.config.IdleTimeout {
+				coreLogger.Info("Language server idle for %s, shutting it down", s.config.IdleTimeout)
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				s.stopLSP(ctx)
+				cancel()
+			}
+			s.lspMu.Unlock()
+		}
+	}
+}
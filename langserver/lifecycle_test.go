@@ -0,0 +1,183 @@
+package langserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLanguageServer accepts connections on a unix socket and answers the initialize
+// request of each one with no capabilities and every other request with null, recording
+// the connections, the methods of the messages received and the files opened
+type fakeLanguageServer struct {
+	address string
+
+	mu          sync.Mutex
+	connections int
+	methods     []string
+	opened      []string
+}
+
+func newFakeLanguageServer(t *testing.T) *fakeLanguageServer {
+	socket := filepath.Join(t.TempDir(), "lsp.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeLanguageServer{address: "unix:" + socket}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.connections++
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeLanguageServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		msg, err := lsp.ReadMessage(reader)
+		if err != nil || msg.Method == "exit" {
+			return
+		}
+		f.mu.Lock()
+		f.methods = append(f.methods, msg.Method)
+		f.mu.Unlock()
+		if msg.Method == "textDocument/didOpen" {
+			var params struct {
+				TextDocument struct {
+					URI string `json:"uri"`
+				} `json:"textDocument"`
+			}
+			if err := json.Unmarshal(msg.Params, &params); err == nil {
+				f.mu.Lock()
+				f.opened = append(f.opened, params.TextDocument.URI)
+				f.mu.Unlock()
+			}
+		}
+		if msg.ID == nil || msg.Method == "" {
+			continue
+		}
+		reply := &lsp.Message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage("null")}
+		if msg.Method == "initialize" {
+			reply.Result = json.RawMessage(`{"capabilities":{}}`)
+		}
+		if err := lsp.WriteMessage(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// received returns how many messages with a method the server received
+func (f *fakeLanguageServer) received(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, m := range f.methods {
+		if m == method {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *fakeLanguageServer) stats() (int, []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connections, append([]string(nil), f.opened...)
+}
+
+// running returns the client of the running language server, or nil if it is stopped
+func (s *Server) running() *lsp.Client {
+	s.lspMu.RLock()
+	defer s.lspMu.RUnlock()
+	return s.lspClient
+}
+
+func TestIdleShutdownAndRespawn(t *testing.T) {
+	workspace := t.TempDir()
+	file := filepath.Join(workspace, "main.go")
+	require.NoError(t, os.WriteFile(file, []byte("package main\n"), 0644))
+
+	fake := newFakeLanguageServer(t)
+	s, err := New(Config{WorkspaceDir: workspace, LSPAddress: fake.address, IdleTimeout: 10 * time.Millisecond})
+	require.NoError(t, err)
+	require.NoError(t, s.Start())
+	defer s.Close(context.Background())
+
+	first := s.running()
+	require.NotNil(t, first)
+	require.NoError(t, first.OpenFile(lsp.WithSession(context.Background(), "session"), file))
+
+	// The idle monitor stops the server, remembering the file it had open
+	require.Eventually(t, func() bool { return s.running() == nil }, 5*time.Second, 50*time.Millisecond)
+	s.lspMu.RLock()
+	assert.Contains(t, s.resyncFiles, file)
+	assert.Nil(t, s.workspaceWatcher)
+	s.lspMu.RUnlock()
+
+	// The next tool call starts it again and reopens the file
+	require.NoError(t, s.acquireLSP())
+	second := s.lspClient
+	s.releaseLSP()
+	require.NotNil(t, second)
+	assert.NotSame(t, first, second)
+	assert.True(t, second.IsFileOpen(file))
+
+	assert.Eventually(t, func() bool {
+		connections, opened := fake.stats()
+		return connections == 2 && len(opened) == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStartFailureLeavesNoClient(t *testing.T) {
+	fake := newFakeLanguageServer(t)
+	s, err := New(Config{WorkspaceDir: t.TempDir(), LSPAddress: fake.address})
+	require.NoError(t, err)
+
+	// The language server fails to start once the server is shutting down
+	s.cancelFunc()
+	s.lspMu.Lock()
+	err = s.startLSP()
+	s.lspMu.Unlock()
+	require.Error(t, err)
+	assert.Nil(t, s.running())
+	assert.Nil(t, s.workspaceWatcher)
+	assert.Nil(t, s.watcherCancel)
+	assert.ErrorContains(t, s.acquireLSP(), "shutting down")
+}
+
+func TestServersKeepTheirOwnFileCache(t *testing.T) {
+	fake := newFakeLanguageServer(t)
+	var servers []*Server
+	for range 2 {
+		s, err := New(Config{WorkspaceDir: t.TempDir(), LSPAddress: fake.address})
+		require.NoError(t, err)
+		require.NoError(t, s.Start())
+		defer s.Close(context.Background())
+		servers = append(servers, s)
+	}
+
+	// The tools of each server read through the cache its own watcher invalidates
+	assert.NotSame(t, servers[0].files, servers[1].files)
+	assert.Same(t, servers[0].files, servers[0].toolOptions.Load().Files)
+	assert.Same(t, servers[1].files, servers[1].toolOptions.Load().Files)
+}
@@ -0,0 +1,26 @@
+package langserver
+
+import (
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// serverMessage forwards a message the language server shows, such as a failure to load
+// the workspace, to MCP clients as a log message
+func (s *Server) serverMessage(msg protocol.ShowMessageParams) {
+	if s.mcpServer == nil {
+		return
+	}
+	level := mcp.LoggingLevelInfo
+	switch msg.Type {
+	case protocol.Error:
+		level = mcp.LoggingLevelError
+	case protocol.Warning:
+		level = mcp.LoggingLevelWarning
+	}
+	s.mcpServer.SendNotificationToAllClients("notifications/message", map[string]any{
+		"level":  level,
+		"logger": "language-server",
+		"data":   msg.Message,
+	})
+}
@@ -0,0 +1,33 @@
+package langserver
+
+import (
+	"path/filepath"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// resolvePath makes a file path given to a tool absolute, taking relative paths as
+// relative to the workspace directory. URIs of documents that are not files are returned
+// as they are.
+func (s *Server) resolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) || protocol.IsNonFileURI(path) {
+		return path
+	}
+	return filepath.Join(s.config.WorkspaceDir, path)
+}
+
+// relativizeResult rewrites the paths in a tool result relative to the workspace
+// directory if the server is configured to show relative paths
+func (s *Server) relativizeResult(result *mcp.CallToolResult) {
+	if !s.config.RelativePaths || result == nil {
+		return
+	}
+	for i, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			text.Text = tools.RelativePaths(text.Text, s.config.WorkspaceDir)
+			result.Content[i] = text
+		}
+	}
+}
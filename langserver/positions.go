@@ -0,0 +1,93 @@
+package langserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// positionArgNames are the arguments holding lines and columns, at the top level of a
+// tool call or in the objects of its arrays, such as the targets of the batch tools and
+// the edits of edit_file
+var positionArgNames = map[string]bool{
+	"line":        true,
+	"column":      true,
+	"startLine":   true,
+	"endLine":     true,
+	"startColumn": true,
+	"endColumn":   true,
+}
+
+// zeroIndexedTool makes a tool take 0-indexed lines and columns, as some MCP clients pass
+// positions the way LSP numbers them, by describing them as 0-indexed and adding one to
+// them before the tool reads them. Results keep showing 1-indexed positions.
+func zeroIndexedTool(tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	tool.Description = zeroIndexedDescription(tool.Description)
+	for name, property := range tool.InputSchema.Properties {
+		tool.InputSchema.Properties[name] = zeroIndexedSchema(property)
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		request.Params.Arguments = shiftPositions(request.GetArguments(), false)
+		return next(ctx, request)
+	}
+}
+
+// shiftPositions returns a copy of an argument value with one added to the positions in it
+func shiftPositions(value any, position bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		shifted := make(map[string]any, len(v))
+		for name, arg := range v {
+			shifted[name] = shiftPositions(arg, positionArgNames[name])
+		}
+		return shifted
+	case []any:
+		shifted := make([]any, len(v))
+		for i, arg := range v {
+			shifted[i] = shiftPositions(arg, false)
+		}
+		return shifted
+	case float64:
+		if position {
+			return v + 1
+		}
+	case int:
+		if position {
+			return v + 1
+		}
+	}
+	return value
+}
+
+// zeroIndexedSchema returns a copy of a JSON schema with its descriptions saying positions
+// are 0-indexed
+func zeroIndexedSchema(schema any) any {
+	switch v := schema.(type) {
+	case map[string]any:
+		rewritten := make(map[string]any, len(v))
+		for key, value := range v {
+			if description, ok := value.(string); ok && key == "description" {
+				rewritten[key] = zeroIndexedDescription(description)
+			} else {
+				rewritten[key] = zeroIndexedSchema(value)
+			}
+		}
+		return rewritten
+	case []any:
+		rewritten := make([]any, len(v))
+		for i, value := range v {
+			rewritten[i] = zeroIndexedSchema(value)
+		}
+		return rewritten
+	}
+	return schema
+}
+
+var zeroIndexedReplacer = strings.NewReplacer("1-indexed", "0-indexed", "one-indexed", "zero-indexed")
+
+func zeroIndexedDescription(description string) string {
+	return zeroIndexedReplacer.Replace(description)
+}
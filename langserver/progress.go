@@ -0,0 +1,58 @@
+package langserver
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// forwardProgress sends the language server's work done progress, such as indexing,
+// to the MCP client as progress notifications for the tool call, until the returned
+// function is called. Nothing is sent if the client did not ask for progress.
+func (s *Server) forwardProgress(ctx context.Context, client *lsp.Client, request mcp.CallToolRequest) (stop func()) {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return func() {}
+	}
+	token := request.Params.Meta.ProgressToken
+
+	// MCP progress must increase with every notification, while the language server
+	// reports progress per operation, so each event counts as one step. Notifications
+	// are handled concurrently, hence the lock keeping the steps in order.
+	var mu sync.Mutex
+	progress := 0
+	return client.WatchProgress(func(event lsp.ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress++
+		err := s.mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+			"message":       event.String(),
+		})
+		if err != nil {
+			coreLogger.Debug("Failed to send progress notification: %v", err)
+		}
+	})
+}
+
+// indexingNote tells the agent when the language server is still busy, for example
+// indexing the workspace, since results such as references may be incomplete until it
+// is done
+func indexingNote(client *lsp.Client) string {
+	state, events := client.Indexing()
+	if state != lsp.IndexingBusy {
+		return ""
+	}
+	operations := make([]string, 0, len(events))
+	for _, event := range events {
+		operations = append(operations, event.String())
+	}
+	note := "Note: the language server is still busy, results may be incomplete"
+	if len(operations) > 0 {
+		note += " (" + strings.Join(operations, ", ") + ")"
+	}
+	return note
+}
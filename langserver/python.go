@@ -0,0 +1,102 @@
+package langserver
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// pythonServers are the commands of the Python language servers, for which the
+// python_interpreter tool is registered even without PythonInterpreter
+var pythonServers = []string{
+	"pyright-langserver",
+	"basedpyright-langserver",
+	"pylsp",
+	"jedi-language-server",
+	"pyrefly",
+	"ty",
+}
+
+// isPythonServer reports whether the language server is a Python one, or is given a
+// Python interpreter
+func (s *Server) isPythonServer() bool {
+	if s.config.PythonInterpreter != "" {
+		return true
+	}
+	name := strings.TrimSuffix(filepath.Base(s.config.LSPCommand), filepath.Ext(s.config.LSPCommand))
+	return slices.Contains(pythonServers, name)
+}
+
+// pythonSettings are the settings pointing Python language servers at an interpreter:
+// pyright and basedpyright read python.pythonPath, and pylsp the environment of jedi
+func pythonSettings(interpreter string) map[string]any {
+	return map[string]any{
+		"python.pythonPath":              interpreter,
+		"pylsp.plugins.jedi.environment": interpreter,
+	}
+}
+
+// settings returns the settings the language server is given: those configured, with the
+// Python interpreter chosen on top
+func (s *Server) settings() map[string]any {
+	s.pythonMu.Lock()
+	interpreter := s.python
+	s.pythonMu.Unlock()
+	if interpreter == "" {
+		return s.config.Settings
+	}
+	return lsp.MergeSettings(s.config.Settings, pythonSettings(interpreter))
+}
+
+// pythonInterpreter reports the Python interpreter the language server is pointed at
+// or, if path is set, points it at the interpreter or virtualenv path names instead and
+// tells the server, which analyses the workspace again with it
+func (s *Server) pythonInterpreter(ctx context.Context, path string) (string, error) {
+	if path != "" {
+		interpreter, err := tools.ResolvePythonInterpreter(s.resolvePath(path))
+		if err != nil {
+			return "", err
+		}
+		description, err := tools.DescribePythonInterpreter(ctx, interpreter)
+		if err != nil {
+			return "", err
+		}
+
+		s.pythonMu.Lock()
+		s.python = interpreter
+		s.pythonMu.Unlock()
+		if err := s.lspClient.ChangeSettings(ctx, s.settings()); err != nil {
+			return "", fmt.Errorf("failed to send the new settings to the language server: %v", err)
+		}
+		coreLogger.Info("Pointed the language server at the Python interpreter %s", interpreter)
+		return "The language server now resolves imports with this interpreter, and analyses the workspace again:\n\n" + description, nil
+	}
+
+	s.pythonMu.Lock()
+	interpreter := s.python
+	s.pythonMu.Unlock()
+	if interpreter != "" {
+		description, err := tools.DescribePythonInterpreter(ctx, interpreter)
+		if err != nil {
+			return "", err
+		}
+		return "The language server resolves imports with this interpreter:\n\n" + description, nil
+	}
+
+	// Servers left to choose usually take the interpreter on PATH
+	note := "No Python interpreter is configured, so the language server chooses one itself, usually the first python on PATH."
+	for _, name := range []string{"python3", "python"} {
+		if found, err := exec.LookPath(name); err == nil {
+			if description, err := tools.DescribePythonInterpreter(ctx, found); err == nil {
+				return note + " That is:\n\n" + description + "\nPass path to point the server at a virtualenv or another interpreter.", nil
+			}
+		}
+	}
+	return note + " None was found on PATH. Pass path to point the server at a virtualenv or an interpreter.", nil
+}
@@ -0,0 +1,34 @@
+package langserver
+
+import (
+	"encoding/json"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// recordToolCall records a tool call and its result when recording the session, and
+// compares the result with the recorded one when replaying a recording
+func (s *Server) recordToolCall(request mcp.CallToolRequest, result *mcp.CallToolResult) {
+	name, arguments := request.Params.Name, request.Params.Arguments
+	if s.recorder != nil {
+		s.recorder.ToolCall(name, arguments, result)
+	}
+	if s.replay == nil {
+		return
+	}
+
+	recorded, ok := s.replay.ToolResult(name, arguments)
+	if !ok {
+		coreLogger.Warn("Replay: %s was not called with these arguments in the recording", name)
+		return
+	}
+	replayed, err := json.Marshal(result)
+	if err != nil {
+		coreLogger.Error("Replay: failed to marshal the result of %s: %v", name, err)
+		return
+	}
+	if !lsp.SameResult(recorded, replayed) {
+		coreLogger.Warn("Replay: the result of %s differs from the recording\nrecorded: %s\nreplayed: %s", name, recorded, replayed)
+	}
+}
@@ -0,0 +1,192 @@
+package langserver
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+)
+
+// reloadFixedFields are the settings that can't change while the server runs, as they
+// are read once when it starts
+var reloadFixedFields = map[string]bool{
+	"WorkspaceDir":    true,
+	"IdleTimeout":     true,
+	"SymbolCache":     true,
+	"Fallback":        true,
+	"CtagsFile":       true,
+	"Index":           true,
+	"IndexMode":       true,
+	"TraceLSP":        true,
+	"TraceMaxPayload": true,
+	"Record":          true,
+	"Replay":          true,
+}
+
+// reloadRestartFields are the settings the language server is started with, which
+// restart it when they change
+var reloadRestartFields = map[string]bool{
+	"LSPCommand":            true,
+	"LSPArgs":               true,
+	"LSPAddress":            true,
+	"LSPDir":                true,
+	"LSPEnv":                true,
+	"LSPPath":               true,
+	"LSPLauncher":           true,
+	"PathMap":               true,
+	"ApplyEdits":            true,
+	"ReadOnly":              true,
+	"MessageAction":         true,
+	"QueryOpen":             true,
+	"QueryOpenTTL":          true,
+	"MaxOpenFiles":          true,
+	"MaxConcurrentRequests": true,
+}
+
+// reloadToolFields are the settings deciding which tools are registered and how, which
+// register the tools again when they change
+var reloadToolFields = map[string]bool{
+	"Tools":         true,
+	"DisabledTools": true,
+	"ToolOverrides": true,
+	"ReadOnly":      true,
+	"Sandbox":       true,
+	"ZeroIndexed":   true,
+	// The language specific tools are registered for the servers of their language
+	"LSPCommand":        true,
+	"PythonInterpreter": true,
+}
+
+// reloadSettingsFields are the settings given to the language server, which are sent to
+// it again when they change
+var reloadSettingsFields = map[string]bool{
+	"Settings":          true,
+	"PythonInterpreter": true,
+}
+
+// changedFields returns the names of the settings that differ between two configurations
+func changedFields(old, next Config) []string {
+	var changed []string
+	oldValue, nextValue := reflect.ValueOf(old), reflect.ValueOf(next)
+	for i := 0; i < oldValue.NumField(); i++ {
+		if !reflect.DeepEqual(oldValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			changed = append(changed, oldValue.Type().Field(i).Name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// reloadEffects returns what applying the changed settings takes: restarting the language
+// server, registering the tools again and sending the settings to the server again. It
+// returns an error if one of the settings can't change while the server runs.
+func reloadEffects(changed []string) (restart, reregister, resend bool, err error) {
+	for _, name := range changed {
+		if reloadFixedFields[name] {
+			return false, false, false, fmt.Errorf("%s can't be changed without restarting", name)
+		}
+		restart = restart || reloadRestartFields[name]
+		reregister = reregister || reloadToolFields[name]
+		resend = resend || reloadSettingsFields[name]
+	}
+	return restart, reregister, resend, nil
+}
+
+// Reload applies a changed configuration without ending the MCP sessions. The settings
+// read by tool calls, such as ContextLines, Include and Hooks, apply from the next call,
+// the tools are registered again when the tool settings change, and the language server is
+// restarted only when the settings it is started with change, reopening the files it
+// had open. It returns the names of the settings that changed. Settings read once at
+// startup, such as WorkspaceDir and Index, can't change, and a configuration changing
+// them is rejected.
+func (s *Server) Reload(config Config) ([]string, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	next, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	next.cancelFunc()
+
+	changed := changedFields(s.config, next.config)
+	restart, reregister, resend, err := reloadEffects(changed)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	if reregister && s.mcpServer != nil {
+		next.knownTools = s.knownTools
+		if err := next.checkToolConfig(); err != nil {
+			return nil, err
+		}
+	}
+	coreLogger.Info("Reloading the configuration, changed: %v", changed)
+
+	// Tool calls hold lspMu for reading, so none runs while the configuration changes
+	s.lspMu.Lock()
+	oldConfig := s.config
+	s.config = next.config
+	s.applyEditPolicy = next.applyEditPolicy
+	s.queryOpenMode = next.queryOpenMode
+	s.hookRunner = next.hookRunner
+	s.reloadToolOptions()
+	if resend {
+		s.pythonMu.Lock()
+		s.python = next.python
+		s.pythonMu.Unlock()
+	}
+	if restart && s.lspClient != nil {
+		coreLogger.Info("Restarting the language server with the new configuration")
+		s.stopLSP(s.ctx)
+		err = s.startLSP()
+	} else if resend && s.lspClient != nil {
+		err = s.lspClient.ChangeSettings(s.ctx, s.settings())
+	}
+	s.lspMu.Unlock()
+	if err != nil {
+		return changed, fmt.Errorf("failed to apply the new configuration to the language server: %v", err)
+	}
+
+	if reregister && s.mcpServer != nil {
+		s.reregisterTools(oldConfig)
+	}
+	return changed, nil
+}
+
+// reregisterTools removes the tools registered under an old configuration and registers
+// them under the current one. The MCP server tells clients the list of tools changed.
+func (s *Server) reregisterTools(old Config) {
+	var names []string
+	for name := range s.knownTools {
+		if override := old.ToolOverrides[name]; override.Name != "" {
+			name = override.Name
+		}
+		names = append(names, name)
+	}
+	s.mcpServer.DeleteTools(names...)
+
+	s.gated.mu.Lock()
+	s.gated.tools = nil
+	s.gated.registered = nil
+	s.gated.mu.Unlock()
+	if err := s.registerTools(); err != nil {
+		coreLogger.Error("Failed to register the tools again: %v", err)
+	}
+}
+
+// reloadToolOptions replaces the options the tools are called with by those of the
+// configuration, keeping the file and symbol caches, fallback and index loaded by Start
+func (s *Server) reloadToolOptions() {
+	opts := tools.Options{LargeFileBytes: s.config.LargeFileSize, Context: s.config.Context, Files: s.files}
+	if previous := s.toolOptions.Load(); previous != nil {
+		opts.SymbolIndex, opts.Fallback = previous.SymbolIndex, previous.Fallback
+	}
+	if s.index != nil {
+		opts.Precomputed = tools.NewPrecomputedIndex(s.index, s.config.IndexMode == "prefer", s.config.LargeFileSize)
+	}
+	s.toolOptions.Store(&opts)
+}
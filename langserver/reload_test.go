@@ -0,0 +1,126 @@
+package langserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedFields(t *testing.T) {
+	old := Config{WorkspaceDir: "/ws", LSPCommand: "gopls", ContextLines: 5, Settings: map[string]any{"a": 1}}
+	assert.Empty(t, changedFields(old, old))
+
+	next := old
+	next.ContextLines = 3
+	next.LSPArgs = []string{"-rpc.trace"}
+	next.Settings = map[string]any{"a": 2}
+	assert.Equal(t, []string{"ContextLines", "LSPArgs", "Settings"}, changedFields(old, next))
+}
+
+func TestReloadEffects(t *testing.T) {
+	tests := []struct {
+		name       string
+		changed    []string
+		restart    bool
+		reregister bool
+		resend     bool
+		err        string
+	}{
+		{name: "read by tool calls", changed: []string{"ContextLines", "Hooks", "Include"}},
+		{name: "server command", changed: []string{"LSPArgs"}, restart: true},
+		{name: "tools", changed: []string{"DisabledTools", "ToolOverrides"}, reregister: true},
+		{name: "settings", changed: []string{"Settings"}, resend: true},
+		{name: "read-only", changed: []string{"ReadOnly"}, restart: true, reregister: true},
+		{name: "python interpreter", changed: []string{"PythonInterpreter"}, reregister: true, resend: true},
+		{name: "workspace", changed: []string{"ContextLines", "WorkspaceDir"}, err: "WorkspaceDir can't be changed"},
+		{name: "index", changed: []string{"Index"}, err: "Index can't be changed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restart, reregister, resend, err := reloadEffects(tt.changed)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.restart, restart, "restart")
+			assert.Equal(t, tt.reregister, reregister, "reregister")
+			assert.Equal(t, tt.resend, resend, "resend")
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	fake := newFakeLanguageServer(t)
+	config := Config{WorkspaceDir: t.TempDir(), LSPAddress: fake.address}
+	s, err := New(config)
+	require.NoError(t, err)
+	require.NoError(t, s.Start())
+	defer s.Close(context.Background())
+	mcpServer := server.NewMCPServer("test", "1.0", server.WithToolCapabilities(true))
+	require.NoError(t, s.Register(mcpServer))
+	require.Contains(t, toolDescriptions(t, mcpServer), "grep")
+	client := s.running()
+
+	// Nothing changed
+	changed, err := s.Reload(config)
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+
+	// Settings read once at startup are rejected, leaving the configuration as it was
+	fixed := config
+	fixed.IndexMode = "prefer"
+	_, err = s.Reload(fixed)
+	assert.ErrorContains(t, err, "IndexMode can't be changed")
+	assert.Equal(t, "fallback", s.config.IndexMode)
+
+	// Settings read by the tool calls apply to the next call
+	config.ContextLines = 2
+	config.LargeFileSize = 1024
+	changed, err = s.Reload(config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ContextLines", "LargeFileSize"}, changed)
+	assert.Equal(t, 2, s.config.ContextLines)
+	assert.Equal(t, int64(1024), s.toolOptions.Load().LargeFileBytes)
+	assert.Same(t, s.files, s.toolOptions.Load().Files)
+	assert.Same(t, client, s.running())
+
+	// Settings are sent to the language server again
+	before := fake.received("workspace/didChangeConfiguration")
+	config.Settings = map[string]any{"gopls": map[string]any{"staticcheck": true}}
+	changed, err = s.Reload(config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Settings"}, changed)
+	assert.Eventually(t, func() bool {
+		return fake.received("workspace/didChangeConfiguration") == before+1
+	}, time.Second, 10*time.Millisecond)
+	assert.Same(t, client, s.running())
+
+	// The tools are registered again
+	config.DisabledTools = []string{"grep"}
+	changed, err = s.Reload(config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DisabledTools"}, changed)
+	assert.NotContains(t, toolDescriptions(t, mcpServer), "grep")
+	assert.Contains(t, toolDescriptions(t, mcpServer), "read_source")
+
+	// Tools that don't exist are rejected
+	unknown := config
+	unknown.DisabledTools = []string{"no_such_tool"}
+	_, err = s.Reload(unknown)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"grep"}, s.config.DisabledTools)
+
+	// The language server restarts with the settings it is started with
+	config.MaxOpenFiles = 10
+	changed, err = s.Reload(config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MaxOpenFiles"}, changed)
+	assert.NotSame(t, client, s.running())
+	connections, _ := fake.stats()
+	assert.Equal(t, 2, connections)
+}
@@ -0,0 +1,151 @@
+package langserver
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/internal/utilities"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// URI of the resource listing the diagnostics of the whole workspace
+const diagnosticsResourceURI = "diagnostics://workspace"
+
+// Diagnostics usually arrive in bursts, one notification per file, so updates to the
+// diagnostics resource are announced at most once per this delay
+const diagnosticsUpdateDelay = 500 * time.Millisecond
+
+// registerResources exposes the workspace files and diagnostics as MCP resources. Files
+// are listed as file:// resources, and large files are read a page at a time by adding
+// ?page=N to their URI.
+func (s *Server) registerResources() {
+	template := mcp.NewResourceTemplate("file://{+path}{?page}", "Workspace file",
+		mcp.WithTemplateDescription(fmt.Sprintf("A file in the workspace. Files longer than %d lines are split into pages, read page N with ?page=N.", tools.FileResourcePageLines)),
+	)
+	s.mcpServer.AddResourceTemplate(template, s.readFileResource)
+
+	s.refreshFileResources(s.ctx)
+}
+
+// refreshFileResources lists the files in the workspace and replaces the registered
+// resources if the set of files changed since the last refresh
+func (s *Server) refreshFileResources(ctx context.Context) {
+	files, err := tools.ListWorkspaceFiles(ctx, s.config.WorkspaceDir)
+	if err != nil {
+		coreLogger.Error("Failed to list workspace files: %v", err)
+		return
+	}
+
+	s.resourcesMu.Lock()
+	defer s.resourcesMu.Unlock()
+	if s.fileResources != nil && slices.Equal(files, s.fileResources) {
+		return
+	}
+	s.fileResources = files
+
+	resources := make([]server.ServerResource, 0, len(files)+1)
+	resources = append(resources, server.ServerResource{
+		Resource: mcp.NewResource(diagnosticsResourceURI, "Workspace diagnostics",
+			mcp.WithResourceDescription("Diagnostics the language server has published for the workspace. Updated notifications are sent when they change."),
+			mcp.WithMIMEType("text/plain"),
+		),
+		Handler: s.readDiagnosticsResource,
+	})
+	for _, path := range files {
+		name, err := filepath.Rel(s.config.WorkspaceDir, path)
+		if err != nil {
+			name = path
+		}
+		resources = append(resources, server.ServerResource{
+			Resource: mcp.NewResource(string(protocol.URIFromPath(path)), filepath.ToSlash(name),
+				mcp.WithMIMEType("text/plain"),
+			),
+			Handler: s.readFileResource,
+		})
+	}
+	s.mcpServer.SetResources(resources...)
+}
+
+// readFileResource reads a page of a workspace file resource
+func (s *Server) readFileResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	u, err := url.Parse(request.Params.URI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource URI: %v", err)
+	}
+
+	page := 1
+	if p := u.Query().Get("page"); p != "" {
+		page, err = strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page: %v", err)
+		}
+	}
+
+	u.RawQuery = ""
+	uri, err := protocol.ParseDocumentUri(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource URI: %v", err)
+	}
+	filePath := uri.Path()
+	if _, inside := utilities.RelativePath(s.config.WorkspaceDir, filePath); !inside {
+		return nil, fmt.Errorf("%s is outside the workspace", filePath)
+	}
+	if s.config.Sandbox {
+		if err := s.checkSandbox(filePath); err != nil {
+			return nil, err
+		}
+	}
+
+	text, pages, err := tools.ReadFilePage(filePath, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		Meta:     mcp.NewMetaFromMap(map[string]any{"page": page, "pages": pages}),
+		URI:      request.Params.URI,
+		MIMEType: "text/plain",
+		Text:     text,
+	}}, nil
+}
+
+// readDiagnosticsResource lists the diagnostics the language server has published
+func (s *Server) readDiagnosticsResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	s.lspMu.RLock()
+	client := s.lspClient
+	text := "Language server is not running"
+	if client != nil {
+		text = tools.GetWorkspaceDiagnostics(client)
+	}
+	s.lspMu.RUnlock()
+
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      diagnosticsResourceURI,
+		MIMEType: "text/plain",
+		Text:     text,
+	}}, nil
+}
+
+// diagnosticsChanged tells MCP clients that the diagnostics resource changed. mcp-go
+// does not handle resources/subscribe requests, so every client is notified.
+func (s *Server) diagnosticsChanged(protocol.DocumentUri) {
+	if !s.diagnosticsPending.CompareAndSwap(false, true) {
+		return
+	}
+	time.AfterFunc(diagnosticsUpdateDelay, func() {
+		s.diagnosticsPending.Store(false)
+		if s.mcpServer != nil {
+			s.mcpServer.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{
+				"uri": diagnosticsResourceURI,
+			})
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package langserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFileResourceOutsideWorkspace(t *testing.T) {
+	parent := t.TempDir()
+	workspace := filepath.Join(parent, "ws")
+	require.NoError(t, os.MkdirAll(workspace, 0755))
+	dotted := filepath.Join(workspace, "..notes.txt")
+	require.NoError(t, os.WriteFile(dotted, []byte("notes\n"), 0644))
+	outside := filepath.Join(parent, "secret.txt")
+	require.NoError(t, os.WriteFile(outside, []byte("secret\n"), 0644))
+
+	s, err := New(Config{WorkspaceDir: workspace, LSPAddress: "localhost:1"})
+	require.NoError(t, err)
+	read := func(path string) ([]mcp.ResourceContents, error) {
+		request := mcp.ReadResourceRequest{}
+		request.Params.URI = string(protocol.URIFromPath(path))
+		return s.readFileResource(context.Background(), request)
+	}
+
+	// A file whose name starts with ".." is inside the workspace
+	contents, err := read(dotted)
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	assert.Contains(t, contents[0].(mcp.TextResourceContents).Text, "notes")
+
+	_, err = read(outside)
+	assert.ErrorContains(t, err, "outside the workspace")
+}
@@ -0,0 +1,129 @@
+package langserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// pathArgNames are the arguments holding file paths, at the top level of a tool call or in
+// the objects of its arrays, such as the targets of the batch tools and the files of
+// read_files
+var pathArgNames = map[string]bool{
+	"filePath": true,
+	"newPath":  true,
+	"path":     true,
+}
+
+// sandboxTool rejects the calls of a tool with a path argument naming a file outside the
+// workspace and the directories of SandboxAllow, as the paths come from a model and the
+// server runs with the user's permissions
+func (s *Server) sandboxTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := s.checkPathArgs(request.GetArguments(), false); err != nil {
+			coreLogger.Warn("Rejected %s call: %v", request.Params.Name, err)
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// checkPathArgs checks the paths in an argument value with checkSandbox
+func (s *Server) checkPathArgs(value any, path bool) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for name, arg := range v {
+			if err := s.checkPathArgs(arg, pathArgNames[name]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, arg := range v {
+			if err := s.checkPathArgs(arg, false); err != nil {
+				return err
+			}
+		}
+	case string:
+		if path && v != "" {
+			return s.checkSandbox(s.resolvePath(v))
+		}
+	}
+	return nil
+}
+
+// checkSandbox returns an error if a path is outside the workspace and the directories
+// of SandboxAllow once symlinks are followed, so that neither ".." nor a symlink in the
+// workspace leads a tool elsewhere. Paths that don't exist yet are checked through the
+// directory they would be created in. The entries of jar: and zip: URIs are checked
+// through their archive, which is read locally, and the URIs of documents the language
+// server provides are allowed. Other URIs are rejected.
+func (s *Server) checkSandbox(path string) error {
+	if protocol.IsNonFileURI(path) {
+		uri := path
+		archive, ok := lsp.ArchivePath(uri)
+		if !ok {
+			scheme, _, _ := strings.Cut(uri, ":")
+			if s.lspClient.ProvidesScheme(scheme) {
+				return nil
+			}
+			return fmt.Errorf("%s is neither a file nor a document the language server provides", uri)
+		}
+		path = archive
+	}
+	real, err := realPath(path)
+	if err != nil {
+		return fmt.Errorf("cannot resolve %s: %v", path, err)
+	}
+	for _, dir := range append([]string{s.config.WorkspaceDir}, s.config.SandboxAllow...) {
+		root, err := realPath(dir)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(root, real); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is outside the workspace, tools may only access files in %s", path,
+		strings.Join(append([]string{s.config.WorkspaceDir}, s.config.SandboxAllow...), ", "))
+}
+
+// realPath returns an absolute path with the symlinks of its longest existing part
+// resolved, followed by the part that doesn't exist. A dangling symlink is resolved to
+// its target, where writing through it would create the file.
+func realPath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rest := ""
+	for {
+		real, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(real, rest), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if target, err := os.Readlink(path); err == nil {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			return realPath(filepath.Join(target, rest))
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(path, rest), nil
+		}
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = parent
+	}
+}
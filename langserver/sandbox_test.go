@@ -0,0 +1,93 @@
+package langserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isaacphi/mcp-language-server/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandbox(t *testing.T) {
+	parent := t.TempDir()
+	workspace := filepath.Join(parent, "ws")
+	sibling := filepath.Join(parent, "ws-other")
+	allowed := filepath.Join(parent, "cache")
+	for _, dir := range []string{filepath.Join(workspace, "src"), sibling, allowed} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	secret := filepath.Join(sibling, "secret.go")
+	require.NoError(t, os.WriteFile(secret, []byte("package secret\n"), 0644))
+	require.NoError(t, os.Symlink(secret, filepath.Join(workspace, "link.go")))
+	require.NoError(t, os.Symlink(sibling, filepath.Join(workspace, "linkdir")))
+	require.NoError(t, os.Symlink(filepath.Join(sibling, "new.go"), filepath.Join(workspace, "dangling.go")))
+	require.NoError(t, os.Symlink(filepath.Join(workspace, "src"), filepath.Join(workspace, "inside")))
+
+	s, err := New(Config{WorkspaceDir: workspace, LSPAddress: "localhost:1", Sandbox: true, SandboxAllow: []string{allowed}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		allowed bool
+	}{
+		{name: "relative", args: map[string]any{"filePath": "src/main.go"}, allowed: true},
+		{name: "absolute inside", args: map[string]any{"filePath": filepath.Join(workspace, "src", "main.go")}, allowed: true},
+		{name: "new file", args: map[string]any{"filePath": "src/new/dir/file.go"}, allowed: true},
+		{name: "dotdot staying inside", args: map[string]any{"filePath": "src/../main.go"}, allowed: true},
+		{name: "symlink inside", args: map[string]any{"filePath": "inside/main.go"}, allowed: true},
+		{name: "allowed directory", args: map[string]any{"filePath": filepath.Join(allowed, "dep.go")}, allowed: true},
+		{name: "other arguments", args: map[string]any{"query": "../../etc/passwd", "filePath": "main.go"}, allowed: true},
+		{name: "server document", args: map[string]any{"filePath": "jdt://contents/rt.jar/java.lang/String.class"}, allowed: true},
+		{name: "archive inside", args: map[string]any{"filePath": "jar:" + string(protocol.URIFromPath(filepath.Join(allowed, "lib.jar"))) + "!/A.java"}, allowed: true},
+		{name: "archive outside", args: map[string]any{"filePath": "zip:" + string(protocol.URIFromPath(filepath.Join(sibling, "other.zip"))) + "!/secret"}},
+		{name: "archive through a symlink", args: map[string]any{"filePath": "jar:" + string(protocol.URIFromPath(filepath.Join(workspace, "linkdir", "lib.jar"))) + "!/A.java"}},
+		{name: "dotdot", args: map[string]any{"filePath": "../ws-other/secret.go"}},
+		{name: "dotdot through a directory", args: map[string]any{"filePath": "src/../../ws-other/secret.go"}},
+		{name: "absolute outside", args: map[string]any{"filePath": "/etc/passwd"}},
+		{name: "sibling with the workspace as prefix", args: map[string]any{"filePath": secret}},
+		{name: "symlink to a file outside", args: map[string]any{"filePath": "link.go"}},
+		{name: "symlink to a directory outside", args: map[string]any{"filePath": "linkdir/secret.go"}},
+		{name: "dangling symlink to outside", args: map[string]any{"filePath": "dangling.go"}},
+		{name: "newPath", args: map[string]any{"filePath": "src/main.go", "newPath": "../ws-other/main.go"}},
+		{name: "path", args: map[string]any{"path": "/etc"}},
+		{name: "path in an array of objects", args: map[string]any{
+			"targets": []any{
+				map[string]any{"filePath": "src/main.go"},
+				map[string]any{"filePath": secret},
+			},
+		}},
+		{name: "path in a nested object", args: map[string]any{
+			"files": []any{map[string]any{"range": map[string]any{"path": "../ws-other"}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.checkPathArgs(tt.args, false)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "outside the workspace")
+			}
+		})
+	}
+
+	// Documents of schemes the language server doesn't provide are not read
+	err = s.checkPathArgs(map[string]any{"filePath": "zip:https://example.com/lib.zip!/a.go"}, false)
+	assert.ErrorContains(t, err, "neither a file nor a document the language server provides")
+	err = s.checkPathArgs(map[string]any{"filePath": "virtual:/generated.go"}, false)
+	assert.Error(t, err)
+}
+
+func TestSandboxAllowRelative(t *testing.T) {
+	workspace := t.TempDir()
+	cache := filepath.Join(t.TempDir(), "cache")
+	allow := []string{"vendor", cache}
+
+	s, err := New(Config{WorkspaceDir: workspace, LSPAddress: "localhost:1", Sandbox: true, SandboxAllow: allow})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(workspace, "vendor"), cache}, s.config.SandboxAllow)
+	assert.Equal(t, []string{"vendor", cache}, allow)
+}
@@ -0,0 +1,687 @@
+// Package langserver exposes a language server to MCP clients as a set of tools.
+//
+// The mcp-language-server command serves these tools over stdio, but a Go program that
+// hosts its own MCP server can mount them next to its other tools instead of spawning a
+// subprocess:
+//
+//	ls, err := langserver.New(langserver.Config{
+//		WorkspaceDir: "/path/to/project",
+//		LSPCommand:   "gopls",
+//	})
+//	if err != nil { ... }
+//	if err := ls.Start(); err != nil { ... }
+//	defer ls.Close(context.Background())
+//
+//	if err := ls.Register(mcpServer); err != nil { ... }
+package langserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/hooks"
+	"github.com/isaacphi/mcp-language-server/internal/index"
+	"github.com/isaacphi/mcp-language-server/internal/logging"
+	"github.com/isaacphi/mcp-language-server/internal/lsp"
+	"github.com/isaacphi/mcp-language-server/internal/syntax"
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/isaacphi/mcp-language-server/internal/watcher"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Create a logger for the core component
+var coreLogger = logging.NewLogger(logging.Core)
+
+// Instructions tells agents how to use the language server tools. Hosts that register the
+// tools onto their own server may want to include it in their server instructions.
+const Instructions = `
+- This server exposes a language server to enable agents to navigate and edit codebases more easily, by giving access to semantic tools from the LSP server like get definition, references, rename, etc.
+- Semantic tools are generally more accurate than text-based tools like grep or search+replace. However, you may still need to fallback to text-based approaches when the LSP server works poorly, such as: when the code does not compile.
+	- Beware: For reading tools like references, this usually won't manifest as an error from the language server, rather it will appear as zero results or incomplete results.
+- When using references etc tools, the line + column number MUST be inside the function/variable etc name, NOT on keywords (eg. type, struct) or whitespace.
+- Position based tools also accept a symbolName instead of line + column. Prefer it when you do not know the exact column.
+- If you get this error "no identifier found", it means your column/line number is incorrect. It does NOT mean there are zero references / results etc.
+`
+
+// Hook is a shell command run in the workspace after a mutating tool completes
+type Hook = hooks.Hook
+
+// Launcher runs the language server in a container or on another host
+type Launcher = lsp.Launcher
+
+// PathMapping maps a directory of the host to the directory the language server sees
+type PathMapping = lsp.PathMapping
+
+// Config configures a Server
+type Config struct {
+	// WorkspaceDir is the root of the workspace the language server is started in
+	WorkspaceDir string
+	// LSPCommand and LSPArgs start the language server, e.g. "gopls"
+	LSPCommand string
+	LSPArgs    []string
+	// LSPAddress connects to a language server already listening on it instead of
+	// starting one: "host:port" for TCP or "unix:" followed by the path of a unix socket.
+	// LSPCommand and LSPArgs then only tell which server it is and may be empty.
+	LSPAddress string
+	// LSPDir is the working directory of the language server, relative to WorkspaceDir,
+	// WorkspaceDir itself if empty
+	LSPDir string
+	// LSPEnv sets environment variables of the language server over those it inherits,
+	// e.g. {"GOFLAGS": "-tags=integration"}, and LSPPath puts directories in front of its
+	// PATH, where LSPCommand is looked up first, for toolchains such as a node version
+	// managed by nvm. $VAR and ${VAR} in them are expanded from the inherited environment,
+	// and relative LSPPath directories are relative to WorkspaceDir.
+	LSPEnv  map[string]string
+	LSPPath []string
+	// LSPLauncher, if set, runs the language server in a container or on another host,
+	// e.g. {Kind: "docker", Target: "devcontainer"}, where the workspace must be at the
+	// same path unless PathMap maps it. LSPDir, LSPEnv and LSPPath then apply there, with
+	// the variables expanded from its environment and relative LSPPath directories left
+	// to its shell.
+	LSPLauncher *Launcher
+	// PathMap maps directories of the host to the directories a language server in a
+	// container or on another host sees them as, translating the paths and file URIs of
+	// every message exchanged with it. Relative host directories are relative to
+	// WorkspaceDir.
+	PathMap []PathMapping
+	// IdleTimeout shuts down the language server after this period of inactivity and
+	// restarts it on the next tool call. Zero disables it.
+	IdleTimeout time.Duration
+	// ToolTimeout bounds how long a tool call may run. Tools that gather results from
+	// several files return those found so far when it expires. ToolTimeouts overrides it
+	// for individual tools by name. Zero disables it.
+	ToolTimeout  time.Duration
+	ToolTimeouts map[string]time.Duration
+	// ZeroIndexed makes the tools take 0-indexed lines and columns, for MCP clients that
+	// pass positions the way LSP numbers them, instead of the 1-indexed positions editors
+	// show. Results keep showing 1-indexed positions.
+	ZeroIndexed bool
+	// ContextLines is the default number of context lines shown around results
+	ContextLines int
+	// Context changes how the lines around results are chosen and rendered, for agents
+	// wanting more or less of them: whole enclosing functions, fixed numbers of lines
+	// before and after results, or other separators between ranges of lines
+	Context tools.ContextStyle
+	// Hooks are run after mutating tools such as edit_file complete
+	Hooks []Hook
+	// ReadOnly registers only the tools that don't change files and rejects the edits the
+	// language server asks to apply, whatever ApplyEdits says
+	ReadOnly bool
+	// Sandbox rejects tool calls with file paths outside WorkspaceDir and the directories
+	// of SandboxAllow, such as dependency caches, following ".." and symlinks, for agents
+	// that must not read or change other files of the user. Relative SandboxAllow
+	// directories are relative to WorkspaceDir.
+	Sandbox      bool
+	SandboxAllow []string
+	// Tools, unless empty, limits the registered tools to those named, and DisabledTools
+	// leaves out tools by name
+	Tools         []string
+	DisabledTools []string
+	// ToolOverrides changes the name and description tools are exposed to MCP clients
+	// with, by tool name. The other settings keep referring to tools by their own names.
+	ToolOverrides map[string]ToolOverride
+	// Approval decides whether the mutating tools may write the files they change. Its
+	// globs are relative to WorkspaceDir. The zero policy allows every write.
+	Approval ApprovalPolicy
+	// Include and Exclude are the default globs scoping reference, implementation and
+	// symbol search results
+	Include []string
+	Exclude []string
+	// ApplyEdits decides which edits the language server may ask to apply, for example in
+	// response to a code action: "allow", "workspace" (the default, only files inside the
+	// workspace) or "deny"
+	ApplyEdits string
+	// Settings answer the language server's workspace/configuration requests, by section,
+	// e.g. {"python": {"analysis": {"typeCheckingMode": "strict"}}}. Dotted keys such as
+	// "python.analysis.typeCheckingMode" are nested into the sections they name.
+	Settings map[string]any
+	// PythonInterpreter is the Python interpreter, or the virtualenv directory holding it,
+	// that Python language servers resolve imports with, relative to WorkspaceDir. It is
+	// given to them in the settings that name it, over those in Settings, and can be
+	// changed with the python_interpreter tool.
+	PythonInterpreter string
+	// MessageAction decides how the prompts of the language server are answered:
+	// "dismiss" (the default) chooses none of their actions, "first" the first one, and
+	// any other value is the title of the action to choose when a prompt offers it
+	MessageAction string
+	// QueryOpen decides whether read-only tools such as hover and references open the
+	// files they query: "open" (the default) keeps them open, "ttl" closes them after
+	// QueryOpenTTL without queries and "skip" doesn't open them, for servers that answer
+	// queries from the files on disk
+	QueryOpen    string
+	QueryOpenTTL time.Duration
+	// MaxOpenFiles caps how many files are open in the language server at once, closing
+	// the least recently used ones. Zero means no cap.
+	MaxOpenFiles int
+	// MaxConcurrentRequests caps how many requests the language server handles at once,
+	// for servers such as jdtls that slow down when flooded by batch tools. Requests
+	// beyond it wait, with tool calls taking turns. Zero means no cap.
+	MaxConcurrentRequests int
+	// SymbolCache is the file keeping the document and workspace symbols the tools got,
+	// relative to WorkspaceDir, so that after a restart symbol names resolve and outlines
+	// show before the language server finishes indexing. Empty disables it.
+	SymbolCache string
+	// Fallback reads symbols from the syntax of files when the language server has none
+	// for them, such as files in languages it doesn't handle, so that definition,
+	// symbol_search and package_api still work approximately, with their results marked:
+	// "tree-sitter", "ctags" or empty for none
+	Fallback string
+	// CtagsFile is the tags file the ctags fallback reads, relative to WorkspaceDir. If it
+	// is empty or doesn't exist, ctags indexes the workspace itself.
+	CtagsFile string
+	// Index is a precomputed SCIP (.scip) or LSIF index, relative to WorkspaceDir, that
+	// definition, references and hover answer from, for workspaces too large to run a
+	// language server for each agent. Its results are marked as possibly stale.
+	Index string
+	// IndexMode decides when the index is used: "fallback" (the default) when the
+	// language server fails or finds nothing, and "prefer" before asking the server
+	IndexMode string
+	// VerifyEdits makes mutating tools such as edit_file wait for the diagnostics of the
+	// files they changed and report the ones they introduced and resolved
+	VerifyEdits bool
+	// WarmUp opens up to this many representative files of the workspace and asks for the
+	// workspace symbols once the language server started, so that it indexes the workspace
+	// before the first tool call needs it. Zero disables it.
+	WarmUp int
+	// RelativePaths shows the paths in tool results relative to the workspace directory
+	// instead of absolute. Tools accept relative paths either way.
+	RelativePaths bool
+	// MaxOutput caps the characters in a tool result, shortening the code shown around
+	// results and then leaving out files when it is exceeded. MaxOutputs overrides it for
+	// individual tools by name. Zero means no cap.
+	MaxOutput  int
+	MaxOutputs map[string]int
+	// LargeFileSize is the size in bytes above which files, such as generated code and
+	// bundles, are not read whole to show the code around results: only the lines around
+	// the results are read, with a note saying so. Zero means no limit.
+	LargeFileSize int64
+	// TraceLSP receives every message exchanged with the language server, one line per
+	// message, with payloads truncated to TraceMaxPayload bytes (zero for no limit)
+	TraceLSP        io.Writer
+	TraceMaxPayload int
+	// Record receives a recording of the session to replay later: every tool call with
+	// its result and every message exchanged with the language server, one JSON object per
+	// line
+	Record io.Writer
+	// Replay is a recording made with Record, relative to WorkspaceDir, that stands in for
+	// the language server: the tools run as usual, and each message they send the server is
+	// answered with what the server answered to the same message in the recording. Tool
+	// results differing from the recorded ones are logged. LSPCommand defaults to the
+	// recorded command.
+	Replay string
+}
+
+// Server owns a language server process and the MCP tools that use it
+type Server struct {
+	config           Config
+	lspClient        *lsp.Client
+	mcpServer        *server.MCPServer
+	ctx              context.Context
+	cancelFunc       context.CancelFunc
+	closeOnce        sync.Once
+	workspaceWatcher *watcher.WorkspaceWatcher
+	hookRunner       *hooks.Runner
+	journal          *tools.Journal
+	// The names of all tools, registered or not, to check the tool configuration against
+	knownTools map[string]bool
+	// The tools registered only while the language server supports their request
+	gated           gatedTools
+	confirmations   confirmations
+	applyEditPolicy lsp.ApplyEditPolicy
+	queryOpenMode   lsp.QueryOpenMode
+	tracer          *lsp.Tracer
+	recorder        *lsp.Recorder
+	replay          *lsp.Recording
+	fallback        syntax.Provider
+	// index is the precomputed index loaded from Index, if any
+	index *index.Index
+	// files caches the files the tools read, invalidated by the workspace watcher
+	files *tools.FileCache
+	// toolOptions are the options the tools are called with, replaced as a whole when the
+	// configuration is reloaded
+	toolOptions atomic.Pointer[tools.Options]
+	// The Python interpreter the language server is pointed at, from PythonInterpreter
+	// until the python_interpreter tool changes it
+	python   string
+	pythonMu sync.Mutex
+
+	// lspMu guards lspClient and workspaceWatcher. Tool calls hold a read lock
+	// for their duration so the idle monitor never stops the server mid-call.
+	lspMu         sync.RWMutex
+	watcherCancel context.CancelFunc
+	lastUsed      atomic.Int64
+	// Files that were open when the server was last stopped for inactivity, with the
+	// sessions that opened them
+	resyncFiles map[string][]string
+
+	// resourcesMu guards fileResources, the workspace files last registered as resources
+	resourcesMu        sync.Mutex
+	fileResources      []string
+	diagnosticsPending atomic.Bool
+
+	// callsMu guards calls, which cancels the in-flight tool calls
+	callsMu sync.Mutex
+	calls   map[callKey]context.CancelFunc
+
+	// editMu is held for writing by tools that modify files and for reading by
+	// tools that need a consistent view of several files
+	editMu sync.RWMutex
+
+	// reloadMu serializes the reloads of the configuration, see Reload
+	reloadMu sync.Mutex
+}
+
+// New validates the configuration and returns a Server. The language server is not
+// started until Start is called.
+func New(config Config) (*Server, error) {
+	if config.WorkspaceDir == "" {
+		return nil, fmt.Errorf("workspace directory is required")
+	}
+
+	workspaceDir, err := filepath.Abs(config.WorkspaceDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for workspace: %v", err)
+	}
+	config.WorkspaceDir = workspaceDir
+
+	if _, err := os.Stat(config.WorkspaceDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("workspace directory does not exist: %s", config.WorkspaceDir)
+	}
+
+	if config.SymbolCache != "" && !filepath.IsAbs(config.SymbolCache) {
+		config.SymbolCache = filepath.Join(config.WorkspaceDir, config.SymbolCache)
+	}
+
+	if config.ContextLines < 0 {
+		return nil, fmt.Errorf("context lines must not be negative")
+	}
+	if err := tools.ValidateContextStyle(config.Context); err != nil {
+		return nil, err
+	}
+
+	if config.IdleTimeout < 0 {
+		return nil, fmt.Errorf("idle timeout must not be negative")
+	}
+
+	if config.ToolTimeout < 0 {
+		return nil, fmt.Errorf("tool timeout must not be negative")
+	}
+	for name, timeout := range config.ToolTimeouts {
+		if timeout < 0 {
+			return nil, fmt.Errorf("tool timeout for %s must not be negative", name)
+		}
+	}
+
+	config.Approval.Root = workspaceDir
+	if _, err := tools.ParseApprovalAction(string(config.Approval.Default)); err != nil {
+		return nil, fmt.Errorf("approval policy: %v", err)
+	}
+	for i, rule := range config.Approval.Rules {
+		if _, err := tools.ParseApprovalAction(string(rule.Action)); err != nil || rule.Action == "" {
+			return nil, fmt.Errorf("approval rule %d: action must be allow, confirm or deny", i)
+		}
+	}
+
+	applyEditPolicy, err := lsp.ParseApplyEditPolicy(config.ApplyEdits)
+	if err != nil {
+		return nil, err
+	}
+	if config.ReadOnly {
+		applyEditPolicy = lsp.ApplyEditDeny
+	}
+
+	// The caller's slice is left as it was
+	config.SandboxAllow = slices.Clone(config.SandboxAllow)
+	for i, dir := range config.SandboxAllow {
+		if !filepath.IsAbs(dir) {
+			config.SandboxAllow[i] = filepath.Join(config.WorkspaceDir, dir)
+		}
+	}
+
+	if config.PythonInterpreter != "" {
+		if !filepath.IsAbs(config.PythonInterpreter) {
+			config.PythonInterpreter = filepath.Join(config.WorkspaceDir, config.PythonInterpreter)
+		}
+		if config.PythonInterpreter, err = tools.ResolvePythonInterpreter(config.PythonInterpreter); err != nil {
+			return nil, fmt.Errorf("python interpreter: %v", err)
+		}
+	}
+
+	if config.CtagsFile != "" && !filepath.IsAbs(config.CtagsFile) {
+		config.CtagsFile = filepath.Join(config.WorkspaceDir, config.CtagsFile)
+	}
+	fallback, err := syntax.NewProvider(config.Fallback, config.WorkspaceDir, config.CtagsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Index != "" && !filepath.IsAbs(config.Index) {
+		config.Index = filepath.Join(config.WorkspaceDir, config.Index)
+	}
+	switch config.IndexMode {
+	case "":
+		config.IndexMode = "fallback"
+	case "fallback", "prefer":
+	default:
+		return nil, fmt.Errorf("index mode must be fallback or prefer, got %q", config.IndexMode)
+	}
+
+	queryOpenMode, err := lsp.ParseQueryOpenMode(config.QueryOpen)
+	if err != nil {
+		return nil, err
+	}
+	if config.QueryOpenTTL < 0 {
+		return nil, fmt.Errorf("query open TTL must not be negative")
+	}
+	if config.MaxOpenFiles < 0 {
+		return nil, fmt.Errorf("max open files must not be negative")
+	}
+	if config.MaxConcurrentRequests < 0 {
+		return nil, fmt.Errorf("max concurrent requests must not be negative")
+	}
+	if config.MaxOutput < 0 {
+		return nil, fmt.Errorf("max output must not be negative")
+	}
+	for name, limit := range config.MaxOutputs {
+		if limit < 0 {
+			return nil, fmt.Errorf("max output for %s must not be negative", name)
+		}
+	}
+	if config.LargeFileSize < 0 {
+		return nil, fmt.Errorf("large file size must not be negative")
+	}
+	if config.WarmUp < 0 {
+		return nil, fmt.Errorf("warm-up files must not be negative")
+	}
+
+	if config.LSPDir == "" {
+		config.LSPDir = config.WorkspaceDir
+	} else if !filepath.IsAbs(config.LSPDir) {
+		config.LSPDir = filepath.Join(config.WorkspaceDir, config.LSPDir)
+	}
+	pathMap := make([]PathMapping, len(config.PathMap))
+	for i, mapping := range config.PathMap {
+		if mapping.Host == "" || !path.IsAbs(mapping.Server) {
+			return nil, fmt.Errorf("path mapping %q to %q: the server directory must be absolute", mapping.Host, mapping.Server)
+		}
+		if !filepath.IsAbs(mapping.Host) {
+			mapping.Host = filepath.Join(config.WorkspaceDir, mapping.Host)
+		}
+		pathMap[i] = PathMapping{Host: filepath.Clean(mapping.Host), Server: path.Clean(mapping.Server)}
+	}
+	config.PathMap = pathMap
+	for key := range config.LSPEnv {
+		if key == "" || strings.Contains(key, "=") {
+			return nil, fmt.Errorf("invalid LSP environment variable name %q", key)
+		}
+	}
+	if config.LSPLauncher != nil {
+		// The variables and directories are those of where the server runs
+		if err := config.LSPLauncher.Validate(); err != nil {
+			return nil, err
+		}
+		if config.LSPAddress != "" {
+			return nil, fmt.Errorf("LSP address and launcher are exclusive")
+		}
+		if _, err := exec.LookPath(config.LSPLauncher.Kind); err != nil {
+			return nil, fmt.Errorf("LSP launcher not found: %s", config.LSPLauncher.Kind)
+		}
+	} else {
+		if info, err := os.Stat(config.LSPDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("LSP working directory does not exist: %s", config.LSPDir)
+		}
+		env := make(map[string]string, len(config.LSPEnv))
+		for key, value := range config.LSPEnv {
+			env[key] = os.ExpandEnv(value)
+		}
+		config.LSPEnv = env
+		dirs := make([]string, len(config.LSPPath))
+		for i, dir := range config.LSPPath {
+			dirs[i] = os.ExpandEnv(dir)
+			if !filepath.IsAbs(dirs[i]) {
+				dirs[i] = filepath.Join(config.WorkspaceDir, dirs[i])
+			}
+		}
+		config.LSPPath = dirs
+	}
+
+	var replay *lsp.Recording
+	if config.Replay != "" {
+		if !filepath.IsAbs(config.Replay) {
+			config.Replay = filepath.Join(config.WorkspaceDir, config.Replay)
+		}
+		if config.LSPAddress != "" || config.LSPLauncher != nil || len(config.PathMap) > 0 {
+			return nil, fmt.Errorf("a replay can't be combined with an LSP address, launcher or path mapping")
+		}
+		if replay, err = lsp.LoadRecording(config.Replay); err != nil {
+			return nil, err
+		}
+		// The recorded command decides the language specific tools
+		if config.LSPCommand == "" && len(replay.Command) > 0 {
+			config.LSPCommand, config.LSPArgs = replay.Command[0], replay.Command[1:]
+		}
+	}
+
+	// Validate LSP command
+	if config.LSPAddress != "" {
+		if _, _, err := lsp.ParseAddress(config.LSPAddress); err != nil {
+			return nil, fmt.Errorf("LSP address: %v", err)
+		}
+	} else if config.LSPCommand == "" && replay == nil {
+		return nil, fmt.Errorf("LSP command is required")
+	} else if config.LSPLauncher == nil && replay == nil {
+		// With a launcher, the command is looked up where it runs, and a replay runs none
+		if _, err := config.processOptions().LookPath(config.LSPCommand); err != nil {
+			return nil, fmt.Errorf("LSP command not found: %s", config.LSPCommand)
+		}
+	}
+
+	if config.TraceMaxPayload < 0 {
+		return nil, fmt.Errorf("trace payload limit must not be negative")
+	}
+	var tracer *lsp.Tracer
+	if config.TraceLSP != nil {
+		tracer = lsp.NewTracer(config.TraceLSP, config.TraceMaxPayload)
+	}
+	var recorder *lsp.Recorder
+	if config.Record != nil {
+		recorder = lsp.NewRecorder(config.Record)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		config:          config,
+		ctx:             ctx,
+		cancelFunc:      cancel,
+		hookRunner:      hooks.NewRunner(config.WorkspaceDir, config.Hooks),
+		journal:         tools.NewJournal(),
+		applyEditPolicy: applyEditPolicy,
+		queryOpenMode:   queryOpenMode,
+		tracer:          tracer,
+		recorder:        recorder,
+		replay:          replay,
+		fallback:        fallback,
+		files:           tools.NewFileCache(tools.DefaultFileCacheBytes),
+		python:          config.PythonInterpreter,
+	}, nil
+}
+
+// Start spawns and initializes the language server, and starts the idle monitor if an
+// idle timeout is configured
+func (s *Server) Start() error {
+	opts := &tools.Options{LargeFileBytes: s.config.LargeFileSize, Context: s.config.Context, Files: s.files}
+	if s.config.SymbolCache != "" {
+		server := strings.Join(append([]string{s.config.LSPCommand}, s.config.LSPArgs...), " ")
+		if s.config.LSPCommand == "" {
+			server = s.config.LSPAddress
+		}
+		opts.SymbolIndex = tools.OpenSymbolCache(s.config.SymbolCache, server)
+		go s.saveSymbolCache(opts.SymbolIndex)
+	}
+	if s.fallback != nil {
+		opts.Fallback = tools.NewSyntacticFallback(s.config.WorkspaceDir, s.fallback)
+	}
+	if s.config.Index != "" {
+		idx, err := index.Load(s.config.Index, s.config.WorkspaceDir)
+		if err != nil {
+			return err
+		}
+		documents, symbols := idx.Stats()
+		coreLogger.Info("Loaded index %s with %d documents and %d symbols", s.config.Index, documents, symbols)
+		s.index = idx
+		opts.Precomputed = tools.NewPrecomputedIndex(idx, s.config.IndexMode == "prefer", s.config.LargeFileSize)
+	}
+	s.toolOptions.Store(opts)
+
+	s.lspMu.Lock()
+	err := s.startLSP()
+	s.lspMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if s.config.IdleTimeout > 0 {
+		go s.monitorIdle()
+	}
+	if s.config.WarmUp > 0 {
+		go s.warmUp()
+	}
+	return nil
+}
+
+// Register adds the language server tools and resources to an MCP server. Every tool call
+// makes sure the language server is running first, so the MCP server needs no extra
+// middleware.
+func (s *Server) Register(mcpServer *server.MCPServer) error {
+	s.mcpServer = mcpServer
+	if err := s.registerTools(); err != nil {
+		return fmt.Errorf("tool registration failed: %v", err)
+	}
+	if err := s.checkToolConfig(); err != nil {
+		return err
+	}
+	s.registerResources()
+	mcpServer.AddNotificationHandler("notifications/cancelled", s.handleCancelled)
+	return nil
+}
+
+// AddHooks adds the hooks the server relies on to an MCP server's hooks: they let clients
+// cancel tool calls, refresh the workspace file resources before they are listed, and
+// close the files an MCP session opened once it ends. Pass them to the MCP server with
+// server.WithHooks.
+func (s *Server) AddHooks(hooks *server.Hooks) {
+	hooks.AddBeforeCallTool(recordRequestID)
+	hooks.AddBeforeListResources(func(ctx context.Context, id any, message *mcp.ListResourcesRequest) {
+		s.refreshFileResources(ctx)
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		s.CloseSession(ctx, session.SessionID())
+	})
+}
+
+// CloseSession closes the files opened on behalf of an MCP session that no other
+// session still has open
+func (s *Server) CloseSession(ctx context.Context, sessionID string) {
+	s.lspMu.RLock()
+	defer s.lspMu.RUnlock()
+
+	if s.lspClient != nil {
+		s.lspClient.CloseSessionFiles(ctx, sessionID)
+		return
+	}
+
+	// The language server is stopped, so only forget the session's files
+	for path, sessions := range s.resyncFiles {
+		sessions = slices.DeleteFunc(sessions, func(id string) bool { return id == sessionID })
+		if len(sessions) == 0 {
+			delete(s.resyncFiles, path)
+		} else {
+			s.resyncFiles[path] = sessions
+		}
+	}
+}
+
+// Close stops the idle monitor and file watchers, lets the tool calls in progress finish
+// writing files for up to drainTimeout, and shuts down the language server, killing it
+// if it doesn't exit. Tool calls fail once it is closed. It is safe to call more than
+// once.
+func (s *Server) Close(ctx context.Context) {
+	s.closeOnce.Do(func() { s.close(ctx) })
+}
+
+func (s *Server) close(ctx context.Context) {
+	s.lspMu.RLock()
+	client := s.lspClient
+	s.lspMu.RUnlock()
+
+	s.cancelFunc()
+
+	drainCtx, cancel := context.WithTimeout(ctx, drainTimeout)
+	defer cancel()
+	if lockWithin(drainCtx, &s.editMu) {
+		defer s.editMu.Unlock()
+	} else {
+		coreLogger.Warn("Edits still in progress, shutting down anyway")
+	}
+	if lockWithin(drainCtx, &s.lspMu) {
+		defer s.lspMu.Unlock()
+		s.lspClient = nil
+		s.workspaceWatcher = nil
+		s.watcherCancel = nil
+	} else {
+		coreLogger.Warn("Tool calls still in progress, shutting down anyway")
+	}
+
+	if client != nil {
+		shutdownLSPClient(ctx, client)
+	}
+	if opts := s.toolOptions.Load(); opts != nil {
+		if err := opts.SymbolIndex.Save(); err != nil {
+			coreLogger.Error("Failed to save the symbol cache: %v", err)
+		}
+	}
+}
+
+// addTool registers a tool whose handler runs against a live language server, unless the
+// configuration disables it. Tools that are not read-only also go through
+// editMiddleware, and are left out in read-only mode.
+func (s *Server) addTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	name := tool.Name
+	if !s.exposeTool(&tool) {
+		return
+	}
+	if readOnly := tool.Annotations.ReadOnlyHint; readOnly != nil && !*readOnly {
+		if s.config.ReadOnly {
+			coreLogger.Debug("Read-only mode, not registering %s", name)
+			return
+		}
+		handler = s.editMiddleware(&tool, handler)
+	}
+	if s.config.Sandbox {
+		handler = s.sandboxTool(handler)
+	}
+	if s.config.ZeroIndexed {
+		handler = zeroIndexedTool(&tool, handler)
+	}
+	handler = s.lspMiddleware(handler)
+	if tool.Name != name {
+		handler = renamedTool(name, handler)
+	}
+	s.registerTool(name, tool, handler)
+}
+
+// WorkspaceDir returns the absolute path of the workspace root
+func (s *Server) WorkspaceDir() string {
+	return s.config.WorkspaceDir
+}
@@ -0,0 +1,1969 @@
+package langserver
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/isaacphi/mcp-language-server/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// scopeParam is the schema of the scope argument of the tools that take include and
+// exclude globs
+var scopeParam = mcp.WithString("scope",
+	mcp.Description("Restrict results to the files changed in git: \"diff\" for the files changed on the current branch, including uncommitted and untracked ones, \"staged\" for the files staged for commit, or \"all\" (the default)"),
+	mcp.Enum(string(tools.ScopeAll), string(tools.ScopeDiff), string(tools.ScopeStaged)),
+)
+
+func (s *Server) registerTools() error {
+	coreLogger.Debug("Registering MCP tools")
+
+	applyTextEditTool := mcp.NewTool("edit_file",
+		mcp.WithDescription("Apply multiple text edits to a file. Each edit replaces whole lines, or a column range when startColumn and endColumn are given. All edits are validated against the current file contents before anything is written, and the language server is notified of the new contents."),
+		mcp.WithArray("edits",
+			mcp.Required(),
+			mcp.Description("List of edits to apply"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"startLine": map[string]any{
+						"type":        "number",
+						"description": "Start line to replace, inclusive, one-indexed",
+					},
+					"endLine": map[string]any{
+						"type":        "number",
+						"description": "End line to replace, inclusive, one-indexed",
+					},
+					"startColumn": map[string]any{
+						"type":        "number",
+						"description": "Start column to replace, inclusive, one-indexed. Requires endColumn.",
+					},
+					"endColumn": map[string]any{
+						"type":        "number",
+						"description": "End column to replace, exclusive, one-indexed. Requires startColumn.",
+					},
+					"newText": map[string]any{
+						"type":        "string",
+						"description": "Replacement text. Replace with the new text. Leave blank to remove lines.",
+					},
+				},
+				"required": []string{"startLine", "endLine"},
+			}),
+		),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("Path to the file to edit"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Return the unified diff of the edits without writing the file"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true), // can perform destructive updates
+	)
+
+	s.addTool(applyTextEditTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+
+		// Extract edits array
+		editsArg, ok := request.GetArguments()["edits"]
+		if !ok {
+			return mcp.NewToolResultError("edits is required"), nil
+		}
+
+		// Type assert and convert the edits
+		editsArray, ok := editsArg.([]any)
+		if !ok {
+			return mcp.NewToolResultError("edits must be an array"), nil
+		}
+
+		var edits []tools.TextEdit
+		for _, editItem := range editsArray {
+			editMap, ok := editItem.(map[string]any)
+			if !ok {
+				return mcp.NewToolResultError("each edit must be an object"), nil
+			}
+
+			startLine, ok := editMap["startLine"].(float64)
+			if !ok {
+				return mcp.NewToolResultError("startLine must be a number"), nil
+			}
+
+			endLine, ok := editMap["endLine"].(float64)
+			if !ok {
+				return mcp.NewToolResultError("endLine must be a number"), nil
+			}
+
+			// Columns are optional
+			startColumn, _ := editMap["startColumn"].(float64)
+			endColumn, _ := editMap["endColumn"].(float64)
+
+			newText, _ := editMap["newText"].(string) // newText can be empty
+
+			edits = append(edits, tools.TextEdit{
+				StartLine:   int(startLine),
+				EndLine:     int(endLine),
+				StartColumn: int(startColumn),
+				EndColumn:   int(endColumn),
+				NewText:     newText,
+			})
+		}
+
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing edit_file for file: %s", filePath)
+		dryRun := request.GetBool("dryRun", false)
+		writes := &tools.Writes{}
+		response, err := tools.ApplyTextEdits(tools.WithWrites(ctx, writes), s.lspClient, filePath, edits, dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to apply edits: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to apply edits: %v", err)), nil
+		}
+		if !dryRun {
+			response += s.verifyEdits(ctx, writes)
+			response += s.hookRunner.Run(s.ctx, "edit_file")
+		}
+		return mcp.NewToolResultText(response), nil
+	})
+
+	readDefinitionTool := mcp.NewTool("definition",
+		mcp.WithDescription("Read the source code definition of a symbol (function, type, constant, etc.) from the codebase. Returns the complete implementation code where the symbol is defined."),
+		mcp.WithString("symbolName",
+			mcp.Required(),
+			mcp.Description("The name of the symbol whose definition you want to find (e.g. 'mypackage.MyFunction', 'MyType.MyMethod')"),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only return results in files matching these globs, e.g. [\"internal/**\", \"*.go\"]. Replaces the server's configured includes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Leave out results in files matching these globs, e.g. [\"vendor/\", \"*_test.go\"]. Added to the server's configured excludes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		scopeParam,
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(readDefinitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		symbolName, err := request.RequireString("symbolName")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		filter, err := s.pathFilterArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing definition for symbol: %s", symbolName)
+		text, err := tools.ReadDefinition(ctx, s.lspClient, symbolName, filter)
+		if err != nil {
+			coreLogger.Error("Failed to get definition: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get definition: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	symbolSearchTool := mcp.NewTool("symbol_search",
+		mcp.WithDescription("Search the workspace for symbols by a fuzzy query, like an editor's go to symbol: the letters of the query must appear in order, and matches at the start of words rank first, so 'gfd' finds GetFullDefinition. Returns the matching symbols from best to worst with their kind, container and location."),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("The fuzzy query, e.g. 'HandleReq' or 'hreq'"),
+		),
+		mcp.WithArray("kinds",
+			mcp.Description("Only return symbols of these kinds: groups \"types\", \"functions\", \"variables\" and \"modules\", or LSP symbol kinds such as \"class\", \"method\" or \"constant\""),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithNumber("maxResults",
+			mcp.Description(fmt.Sprintf("Maximum number of symbols to return (default %d, 0 for no limit)", tools.DefaultMaxSymbols)),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Number of symbols to skip, for paging through the results"),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only return symbols in files matching these globs, e.g. [\"internal/**\", \"*.go\"]. Replaces the server's configured includes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Leave out symbols in files matching these globs, e.g. [\"vendor/\", \"*_test.go\"]. Added to the server's configured excludes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		scopeParam,
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(symbolSearchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query, err := request.RequireString("query")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		kinds, err := tools.ParseSymbolKinds(request.GetStringSlice("kinds", nil))
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		page := tools.Pagination{
+			MaxResults: request.GetInt("maxResults", tools.DefaultMaxSymbols),
+			Offset:     request.GetInt("offset", 0),
+		}
+		if page.MaxResults < 0 || page.Offset < 0 {
+			return mcp.NewToolResultError("invalid argument: maxResults and offset must be >= 0"), nil
+		}
+
+		filter, err := s.pathFilterArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing symbol_search for query: %s", query)
+		text, err := tools.SearchSymbols(ctx, s.lspClient, query, kinds, page, filter)
+		if err != nil {
+			coreLogger.Error("Failed to search symbols: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to search symbols: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	grepTool := mcp.NewTool("grep",
+		mcp.WithDescription("Search the text of the workspace's files for a regular expression, like ripgrep, skipping gitignored and binary files. Returns the matching lines by file, optionally with the symbol each match is in. Use this for strings, comments and languages the language server doesn't know, or when its index isn't ready; prefer symbol_search and references for code."),
+		mcp.WithString("pattern",
+			mcp.Required(),
+			mcp.Description("The regular expression to search for, in RE2 syntax, e.g. 'func \\w+Handler'"),
+		),
+		mcp.WithBoolean("literal",
+			mcp.Description("Match the pattern as plain text rather than as a regular expression (default false)"),
+		),
+		mcp.WithBoolean("ignoreCase",
+			mcp.Description("Match regardless of case (default false)"),
+		),
+		mcp.WithBoolean("symbols",
+			mcp.Description("Name the symbol each match is in, such as Server.Start, using the language server's document symbols. Slower, since every file with matches is opened (default false)"),
+		),
+		mcp.WithNumber("maxResults",
+			mcp.Description(fmt.Sprintf("Maximum number of matches to return (default %d, 0 for no limit)", tools.DefaultMaxGrepMatches)),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only search files matching these globs, e.g. [\"internal/**\", \"*.go\"]. Replaces the server's configured includes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Don't search files matching these globs, e.g. [\"vendor/\", \"*_test.go\"]. Added to the server's configured excludes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		scopeParam,
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(grepTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		pattern, err := request.RequireString("pattern")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		opts := tools.GrepOptions{
+			Literal:    request.GetBool("literal", false),
+			IgnoreCase: request.GetBool("ignoreCase", false),
+			Symbols:    request.GetBool("symbols", false),
+			MaxResults: request.GetInt("maxResults", tools.DefaultMaxGrepMatches),
+		}
+		if opts.MaxResults < 0 {
+			return mcp.NewToolResultError("invalid argument: maxResults must be >= 0"), nil
+		}
+
+		filter, err := s.pathFilterArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing grep for pattern: %s", pattern)
+		text, err := tools.Grep(ctx, s.lspClient, s.config.WorkspaceDir, pattern, opts, filter)
+		if err != nil {
+			coreLogger.Error("Failed to search text: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to search text: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	projectTreeTool := mcp.NewTool("project_tree",
+		mcp.WithDescription("Show the layout of the workspace, or of a directory in it, before asking about symbols: a tree of directories and files with the number and size of the files in each directory, or with packages set, the list of directories holding source files and their languages. Gitignored files are left out."),
+		mcp.WithString("path",
+			mcp.Description("The directory to show. Defaults to the workspace directory."),
+		),
+		mcp.WithNumber("depth",
+			mcp.Description(fmt.Sprintf("How many levels of directories to expand (default %d, 0 for all). Deeper files are counted in their directory.", tools.DefaultTreeDepth)),
+		),
+		mcp.WithBoolean("packages",
+			mcp.Description("List the directories holding source files, with their file counts, sizes and languages, instead of a tree (default false)"),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only show files matching these globs, e.g. [\"internal/**\", \"*.go\"]. Replaces the server's configured includes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Leave out files matching these globs, e.g. [\"vendor/\", \"*_test.go\"]. Added to the server's configured excludes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		scopeParam,
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(projectTreeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := s.config.WorkspaceDir
+		if path := request.GetString("path", ""); path != "" {
+			dir = s.resolvePath(path)
+		}
+
+		opts := tools.TreeOptions{
+			Depth:    request.GetInt("depth", tools.DefaultTreeDepth),
+			Packages: request.GetBool("packages", false),
+		}
+		if opts.Depth < 0 {
+			return mcp.NewToolResultError("invalid argument: depth must be >= 0"), nil
+		}
+
+		filter, err := s.pathFilterArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing project_tree for directory: %s", dir)
+		text, err := tools.ProjectTree(ctx, s.config.WorkspaceDir, dir, opts, filter)
+		if err != nil {
+			coreLogger.Error("Failed to list project tree: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to list project tree: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	packageAPITool := mcp.NewTool("package_api",
+		mcp.WithDescription("List the exported API of a package or module directory: its exported types with their fields and methods, functions, variables and constants, with their signatures and locations. Use this to write code against a package without reading all of its files. Test files are left out."),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("The directory of the package, e.g. 'internal/tools'"),
+		),
+		mcp.WithBoolean("recursive",
+			mcp.Description("Include the files in the subdirectories of the directory too (default false)"),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only list symbols in files matching these globs, e.g. [\"*.go\"]. Replaces the server's configured includes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Leave out symbols in files matching these globs, e.g. [\"*_gen.go\"]. Added to the server's configured excludes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(packageAPITool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir, err := request.RequireString("path")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		dir = s.resolvePath(dir)
+		recursive := request.GetBool("recursive", false)
+
+		filter, err := s.pathFilterArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing package_api for directory: %s", dir)
+		text, err := tools.PackageAPI(ctx, s.lspClient, s.config.WorkspaceDir, dir, recursive, filter)
+		if err != nil {
+			coreLogger.Error("Failed to list package API: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to list package API: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	readSourceTool := mcp.NewTool("read_source",
+		mcp.WithDescription("Read part of a file with line numbers, either a line range or the full range of a symbol in the file. Use this instead of reading whole files when you only need a section of them."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to read, or the URI of a dependency source from another tool's results, e.g. jar:file:///.../lib-sources.jar!/com/example/A.java"),
+		),
+		mcp.WithNumber("startLine",
+			mcp.Description("The first line to read (1-indexed). Defaults to the start of the file."),
+		),
+		mcp.WithNumber("endLine",
+			mcp.Description("The last line to read, inclusive (1-indexed). Defaults to the end of the file."),
+		),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of a symbol in the file to read instead of a line range, e.g. 'MyFunction' or 'MyType.MyMethod'"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(readSourceTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+
+		startLine := request.GetInt("startLine", 0)
+		endLine := request.GetInt("endLine", 0)
+		symbolName := request.GetString("symbolName", "")
+
+		coreLogger.Debug("Executing read_source for file: %s lines: %d-%d symbol: %s", filePath, startLine, endLine, symbolName)
+		text, err := tools.ReadSource(ctx, s.lspClient, filePath, startLine, endLine, symbolName)
+		if err != nil {
+			coreLogger.Error("Failed to read source: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to read source: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	readFilesTool := mcp.NewTool("read_files",
+		mcp.WithDescription("Read several files, or line ranges and symbols within them, in one call. All files are read from the same point in time, so no edit can land between them. Each file is returned with the language server's document version and a content hash."),
+		mcp.WithArray("files",
+			mcp.Required(),
+			mcp.Description("The files to read"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"filePath": map[string]any{
+						"type":        "string",
+						"description": "The path to the file to read",
+					},
+					"startLine": map[string]any{
+						"type":        "number",
+						"description": "The first line to read, one-indexed. Defaults to the start of the file.",
+					},
+					"endLine": map[string]any{
+						"type":        "number",
+						"description": "The last line to read, inclusive, one-indexed. Defaults to the end of the file.",
+					},
+					"symbolName": map[string]any{
+						"type":        "string",
+						"description": "The name of a symbol in the file to read instead of a line range",
+					},
+				},
+				"required": []string{"filePath"},
+			}),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(readFilesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract files array
+		filesArg, ok := request.GetArguments()["files"]
+		if !ok {
+			return mcp.NewToolResultError("files is required"), nil
+		}
+
+		filesArray, ok := filesArg.([]any)
+		if !ok {
+			return mcp.NewToolResultError("files must be an array"), nil
+		}
+
+		var requests []tools.FileReadRequest
+		for _, fileItem := range filesArray {
+			fileMap, ok := fileItem.(map[string]any)
+			if !ok {
+				return mcp.NewToolResultError("each file must be an object"), nil
+			}
+
+			filePath, ok := fileMap["filePath"].(string)
+			if !ok || filePath == "" {
+				return mcp.NewToolResultError("filePath must be a non-empty string"), nil
+			}
+			filePath = s.resolvePath(filePath)
+
+			startLine, _ := fileMap["startLine"].(float64)
+			endLine, _ := fileMap["endLine"].(float64)
+			symbolName, _ := fileMap["symbolName"].(string)
+
+			requests = append(requests, tools.FileReadRequest{
+				FilePath:   filePath,
+				StartLine:  int(startLine),
+				EndLine:    int(endLine),
+				SymbolName: symbolName,
+			})
+		}
+
+		s.editMu.RLock()
+		defer s.editMu.RUnlock()
+
+		coreLogger.Debug("Executing read_files for %d files", len(requests))
+		text, err := tools.ReadFiles(ctx, s.lspClient, requests)
+		if err != nil {
+			coreLogger.Error("Failed to read files: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to read files: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	findReferencesTool := mcp.NewTool("references",
+		mcp.WithDescription("Find all usages and references of a symbol at the specified position throughout the codebase. Returns a list of all files and locations where the symbol appears. The symbol can be given either by position or by name."),
+		mcp.WithString("filePath",
+			mcp.Description("The path to the file containing the symbol to find references for. Required unless symbolName is given, in which case the symbol is looked up in this file only."),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of the symbol, as an alternative to line and column. Use a qualified name like Type.Method to disambiguate."),
+		),
+		mcp.WithNumber("contextLines",
+			mcp.Description("Lines of context to include around each result. Defaults to the server's configured value."),
+		),
+		mcp.WithNumber("maxResults",
+			mcp.Description("Maximum number of references to return (default 100, 0 for no limit)"),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Number of references to skip, for paging through large result sets"),
+		),
+		mcp.WithNumber("maxResultsPerFile",
+			mcp.Description("Maximum number of references to return per file (0 for no limit)"),
+		),
+		mcp.WithBoolean("includeDeclaration",
+			mcp.Description("Include the declaration of the symbol in the references (default false)"),
+		),
+		mcp.WithBoolean("groupByKind",
+			mcp.Description("Group references into sections by kind: declarations, writes, reads, imports and test files, with a count of each. Useful to assess the impact of changing the symbol (default false)."),
+		),
+		mcp.WithBoolean("blame",
+			mcp.Description("Annotate each reference with the commit, author and date that last changed its line, from git blame, to judge how stale or active a call site is (default false)"),
+		),
+		mcp.WithBoolean("includeGenerated",
+			mcp.Description("Show the lines around references in binary files and generated files, such as those marked \"Code generated ... DO NOT EDIT\", which are otherwise only counted (default false)"),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only return results in files matching these globs, e.g. [\"internal/**\", \"*.go\"]. Replaces the server's configured includes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Leave out results in files matching these globs, e.g. [\"vendor/\", \"*_test.go\"]. Added to the server's configured excludes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		scopeParam,
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(findReferencesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, line, column, err := s.positionArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		contextLines, err := s.contextLinesArg(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		page, err := paginationArgs(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		opts := tools.ReferenceOptions{
+			IncludeDeclaration: request.GetBool("includeDeclaration", false),
+			GroupByKind:        request.GetBool("groupByKind", false),
+			Blame:              request.GetBool("blame", false),
+			IncludeGenerated:   request.GetBool("includeGenerated", false),
+		}
+
+		filter, err := s.pathFilterArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing references for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.FindReferences(ctx, s.lspClient, filePath, line, column, contextLines, page, filter, opts)
+		if err != nil {
+			coreLogger.Error("Failed to find references: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find references: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	callersTool := mcp.NewTool("callers",
+		mcp.WithDescription("Find the callers of a function at the specified position. Returns a table of the functions referencing it with their signatures and call sites, which is useful to check before changing the function's signature."),
+		mcp.WithString("filePath",
+			mcp.Description("The path to the file containing the function. Required unless symbolName is given, in which case the symbol is looked up in this file only."),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number where the function is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number where the function is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of the function, as an alternative to line and column. Use a qualified name like Type.Method to disambiguate."),
+		),
+		mcp.WithNumber("maxResults",
+			mcp.Description("Maximum number of call sites to return (default 100, 0 for no limit)"),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Number of call sites to skip, for paging through large result sets"),
+		),
+		mcp.WithNumber("maxResultsPerFile",
+			mcp.Description("Maximum number of call sites to return per file (0 for no limit)"),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only return results in files matching these globs, e.g. [\"internal/**\", \"*.go\"]. Replaces the server's configured includes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Leave out results in files matching these globs, e.g. [\"vendor/\", \"*_test.go\"]. Added to the server's configured excludes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		scopeParam,
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(callersTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, line, column, err := s.positionArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		page, err := paginationArgs(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		filter, err := s.pathFilterArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing callers for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.FindCallers(ctx, s.lspClient, filePath, line, column, page, filter)
+		if err != nil {
+			coreLogger.Error("Failed to find callers: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find callers: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	findKeyUsagesTool := mcp.NewTool("find_key_usages",
+		mcp.WithDescription("Find where a string key, such as a config key or string constant, is used across the workspace. Combines a text search for the literal with references to any constant declared with it as its value, so usages in YAML, JSON, templates etc. are found as well as symbol references."),
+		mcp.WithString("key",
+			mcp.Required(),
+			mcp.Description("The string literal to search for, without quotes"),
+		),
+		mcp.WithNumber("contextLines",
+			mcp.Description("Lines of context to include around each result. Defaults to the server's configured value."),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(findKeyUsagesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		key, err := request.RequireString("key")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		contextLines, err := s.contextLinesArg(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing find_key_usages for key: %s", key)
+		text, err := tools.FindKeyUsages(ctx, s.lspClient, s.config.WorkspaceDir, key, contextLines)
+		if err != nil {
+			coreLogger.Error("Failed to find key usages: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find key usages: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	getDiagnosticsTool := mcp.NewTool("diagnostics",
+		mcp.WithDescription("Get diagnostic information for a specific file from the language server."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to get diagnostics for"),
+		),
+		mcp.WithNumber("contextLines",
+			mcp.Description("Lines of context to include around each diagnostic. Defaults to the server's configured value."),
+		),
+		mcp.WithBoolean("showLineNumbers",
+			mcp.Description("If true, adds line numbers to the output"),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(getDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+
+		contextLines, err := s.contextLinesArg(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		showLineNumbers := request.GetBool("showLineNumbers", true)
+
+		coreLogger.Debug("Executing diagnostics for file: %s", filePath)
+		text, err := tools.GetDiagnosticsForFile(ctx, s.lspClient, filePath, contextLines, showLineNumbers)
+		if err != nil {
+			coreLogger.Error("Failed to get diagnostics: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get diagnostics: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	projectDiagnosticsTool := mcp.NewTool("project_diagnostics",
+		mcp.WithDescription("Summarize the diagnostics of the whole workspace: counts by severity and by directory, followed by the diagnostics from most to least severe. Use this after making edits to see what is broken right now."),
+		mcp.WithArray("include",
+			mcp.Description("Only check files matching these globs, e.g. [\"internal/**\", \"*.go\"]. Replaces the server's configured includes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Leave out files matching these globs, e.g. [\"vendor/\", \"*_test.go\"]. Added to the server's configured excludes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		scopeParam,
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(projectDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filter, err := s.pathFilterArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing project_diagnostics")
+		text, err := tools.ProjectDiagnostics(ctx, s.lspClient, s.config.WorkspaceDir, filter)
+		if err != nil {
+			coreLogger.Error("Failed to get project diagnostics: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get project diagnostics: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	explainDiagnosticTool := mcp.NewTool("explain_diagnostic",
+		mcp.WithDescription("Explain a diagnostic in a file. Returns a single report with the code around it, related information from the language server, the definitions of the symbols involved and the code actions available to fix it."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the diagnostic"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number where the diagnostic starts (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number where the diagnostic starts (1-indexed). If omitted, any diagnostic on the line matches."),
+		),
+		mcp.WithNumber("endLine",
+			mcp.Description("The line number where the diagnostic ends (1-indexed). Defaults to line."),
+		),
+		mcp.WithNumber("endColumn",
+			mcp.Description("The column number where the diagnostic ends (1-indexed)"),
+		),
+		mcp.WithString("code",
+			mcp.Description("The diagnostic code to match, as reported by the diagnostics tool"),
+		),
+		mcp.WithNumber("contextLines",
+			mcp.Description("Lines of context to include around the diagnostic. Defaults to the server's configured value."),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(explainDiagnosticTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		contextLines, err := s.contextLinesArg(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		query := tools.DiagnosticQuery{
+			Line:      line,
+			Column:    request.GetInt("column", 0),
+			EndLine:   request.GetInt("endLine", 0),
+			EndColumn: request.GetInt("endColumn", 0),
+			Code:      request.GetString("code", ""),
+		}
+		if query.EndLine != 0 && query.EndLine < query.Line {
+			return mcp.NewToolResultError("invalid argument: endLine must not be before line"), nil
+		}
+
+		coreLogger.Debug("Executing explain_diagnostic for file: %s line: %d", filePath, line)
+		text, err := tools.ExplainDiagnostic(ctx, s.lspClient, filePath, query, contextLines)
+		if err != nil {
+			coreLogger.Error("Failed to explain diagnostic: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to explain diagnostic: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	fixDiagnosticsTool := mcp.NewTool("fix_diagnostics",
+		mcp.WithDescription("Apply the quick fixes the language server offers for the diagnostics in a file, check the file again and repeat, to make it compile-clean in one call. Returns the fixes applied, the diagnostics that remain and a diff of the changes."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to fix"),
+		),
+		mcp.WithNumber("maxRounds",
+			mcp.Description(fmt.Sprintf("Maximum number of rounds of fixes to apply, checking the file again after each (default %d)", tools.DefaultFixRounds)),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Return the fixes of the first round and their unified diff without writing any files"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+
+	s.addTool(fixDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+
+		maxRounds := request.GetInt("maxRounds", tools.DefaultFixRounds)
+		if maxRounds < 1 {
+			return mcp.NewToolResultError("invalid argument: maxRounds must be at least 1"), nil
+		}
+
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing fix_diagnostics for file: %s", filePath)
+		dryRun := request.GetBool("dryRun", false)
+		writes := &tools.Writes{}
+		text, err := tools.FixDiagnostics(tools.WithWrites(ctx, writes), s.lspClient, filePath, maxRounds, dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to fix diagnostics: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to fix diagnostics: %v", err)), nil
+		}
+		if !dryRun {
+			text += s.verifyEdits(ctx, writes)
+			text += s.hookRunner.Run(s.ctx, "fix_diagnostics")
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	// Uncomment to add codelens tools
+	//
+	// getCodeLensTool := mcp.NewTool("get_codelens",
+	// 	mcp.WithDescription("Get code lens hints for a given file from the language server."),
+	// 	mcp.WithString("filePath",
+	// 		mcp.Required(),
+	// 		mcp.Description("The path to the file to get code lens information for"),
+	// 	),
+	// )
+	//
+	// s.addTool(getCodeLensTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// 	// Extract arguments
+	// 	filePath, err := request.RequireString("filePath")
+	// 	if err != nil {
+	// 		return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+	// 	}
+	//
+	// 	coreLogger.Debug("Executing get_codelens for file: %s", filePath)
+	// 	text, err := tools.GetCodeLens(ctx, s.lspClient, filePath)
+	// 	if err != nil {
+	// 		coreLogger.Error("Failed to get code lens: %v", err)
+	// 		return mcp.NewToolResultError(fmt.Sprintf("failed to get code lens: %v", err)), nil
+	// 	}
+	// 	return mcp.NewToolResultText(text), nil
+	// })
+	//
+	// executeCodeLensTool := mcp.NewTool("execute_codelens",
+	// 	mcp.WithDescription("Execute a code lens command for a given file and lens index."),
+	// 	mcp.WithString("filePath",
+	// 		mcp.Required(),
+	// 		mcp.Description("The path to the file containing the code lens to execute"),
+	// 	),
+	// 	mcp.WithNumber("index",
+	// 		mcp.Required(),
+	// 		mcp.Description("The index of the code lens to execute (from get_codelens output), 1 indexed"),
+	// 	),
+	// )
+	//
+	// s.addTool(executeCodeLensTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// 	// Extract arguments
+	// 	filePath, err := request.RequireString("filePath")
+	// 	if err != nil {
+	// 		return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+	// 	}
+	//
+	// 	index, err := request.RequireInt("index")
+	// 	if err != nil {
+	// 		return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+	// 	}
+	//
+	// 	coreLogger.Debug("Executing execute_codelens for file: %s index: %d", filePath, index)
+	// 	text, err := tools.ExecuteCodeLens(ctx, s.lspClient, filePath, index)
+	// 	if err != nil {
+	// 		coreLogger.Error("Failed to execute code lens: %v", err)
+	// 		return mcp.NewToolResultError(fmt.Sprintf("failed to execute code lens: %v", err)), nil
+	// 	}
+	// 	return mcp.NewToolResultText(text), nil
+	// })
+
+	hoverTool := mcp.NewTool("hover",
+		mcp.WithDescription("Get hover information (type, documentation) for a symbol at the specified position."),
+		mcp.WithString("filePath",
+			mcp.Description("The path to the file to get hover information for. Required unless symbolName is given, in which case the symbol is looked up in this file only."),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number where the hover is requested (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number where the hover is requested (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of the symbol, as an alternative to line and column. Use a qualified name like Type.Method to disambiguate."),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(hoverTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		filePath, line, column, err := s.positionArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing hover for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GetHoverInfo(ctx, s.lspClient, filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to get hover information: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get hover information: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	goTestTool := mcp.NewTool("go_test",
+		mcp.WithDescription("Run the Go test, benchmark, fuzz test or example at the specified position in a _test.go file with gopls, and return the output of go test. Only available with gopls."),
+		mcp.WithString("filePath",
+			mcp.Description("The path to the test file. Required unless symbolName is given, in which case the test is looked up in this file only."),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("A line number within the test function (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("A column number within the test function (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of the test function, such as TestParse, as an alternative to line and column."),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false), // runs code from the workspace
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+
+	s.addTool(goTestTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, line, column, err := s.positionArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing go_test for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GoTest(ctx, s.lspClient, filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to run go test: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to run go test: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	rustRunnablesTool := mcp.NewTool("rust_runnables",
+		mcp.WithDescription("List the runnables rust-analyzer finds in a Rust file or at a position, such as tests, test modules and binaries, with the cargo command running each, or run one of them by label and return its stdout and stderr with its exit status. Only available with rust-analyzer."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the Rust file"),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number to find runnables at (1-indexed). Without it, the runnables of the whole file are listed."),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number to find runnables at (1-indexed, default 1)"),
+		),
+		mcp.WithString("run",
+			mcp.Description("The label of the runnable to run, as listed by an earlier call, such as \"test tests::parse\". Without it, the runnables are only listed."),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false), // runs code from the workspace
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+
+	s.addTool(rustRunnablesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+		line := request.GetInt("line", 0)
+		column := request.GetInt("column", 0)
+		run := request.GetString("run", "")
+
+		coreLogger.Debug("Executing rust_runnables for file: %s line: %d column: %d run: %q", filePath, line, column, run)
+		text, err := tools.RustRunnables(ctx, s.lspClient, filePath, line, column, run)
+		if err != nil {
+			coreLogger.Error("Failed to get runnables: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get runnables: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	switchSourceHeaderTool := mcp.NewTool("switch_source_header",
+		mcp.WithDescription("Find the header of a C or C++ source file, or the source file of a header, such as foo.h for foo.cpp, with clangd. Only available with clangd."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the source or header file"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(switchSourceHeaderTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+
+		coreLogger.Debug("Executing switch_source_header for file: %s", filePath)
+		text, err := tools.SwitchSourceHeader(ctx, s.lspClient, filePath)
+		if err != nil {
+			coreLogger.Error("Failed to switch between source and header: %v", err)
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	typeDefinitionTool := mcp.NewTool("type_definition",
+		mcp.WithDescription("Get the type definition of a symbol at the specified position. Returns the location(s) where the type is defined."),
+		mcp.WithString("filePath",
+			mcp.Description("The path to the file containing the symbol. Required unless symbolName is given, in which case the symbol is looked up in this file only."),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of the symbol, as an alternative to line and column. Use a qualified name like Type.Method to disambiguate."),
+		),
+		mcp.WithNumber("contextLines",
+			mcp.Description("Lines of context to include around each result. Defaults to the server's configured value."),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(typeDefinitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, line, column, err := s.positionArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		contextLines, err := s.contextLinesArg(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing type_definition for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GetTypeDefinition(ctx, s.lspClient, filePath, line, column, contextLines)
+		if err != nil {
+			coreLogger.Error("Failed to get type definition: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get type definition: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	definitionBodyTool := mcp.NewTool("definition_body",
+		mcp.WithDescription("Go to the definition of a symbol at the specified position and return its complete source, such as the whole function or type body, rather than a few lines of context."),
+		mcp.WithString("filePath",
+			mcp.Description("The path to the file containing the symbol. Required unless symbolName is given, in which case the symbol is looked up in this file only."),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of the symbol, as an alternative to line and column. Use a qualified name like Type.Method to disambiguate."),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(definitionBodyTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, line, column, err := s.positionArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing definition_body for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GetDefinitionBody(ctx, s.lspClient, filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to get definition body: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get definition body: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	explainSymbolTool := mcp.NewTool("explain_symbol",
+		mcp.WithDescription("Explain a symbol in one call: its hover information, the complete source of its definition, the source of its type's definition and how many references it has in which files. Use this instead of calling hover, definition_body, type_definition and references one after another."),
+		mcp.WithString("filePath",
+			mcp.Description("The path to the file containing the symbol. Required unless symbolName is given, in which case the symbol is looked up in this file only."),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of the symbol, as an alternative to line and column. Use a qualified name like Type.Method to disambiguate."),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(explainSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, line, column, err := s.positionArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing explain_symbol for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.ExplainSymbol(ctx, s.lspClient, filePath, line, column)
+		if err != nil {
+			coreLogger.Error("Failed to explain symbol: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to explain symbol: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	dependencySourceTool := mcp.NewTool("dependency_source",
+		mcp.WithDescription("Find a symbol defined in a third-party dependency, such as a Go module, a Python package in site-packages or a package in node_modules, and return its complete source. Use this to read library internals the same way as project code."),
+		mcp.WithString("symbolName",
+			mcp.Required(),
+			mcp.Description("The name of the symbol as the project refers to it, e.g. http.Client or requests.get"),
+		),
+		mcp.WithString("filePath",
+			mcp.Description("A project file that uses the symbol, to find it faster and to pick the right one when several dependencies define the name"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(dependencySourceTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		symbolName, err := request.RequireString("symbolName")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath := s.resolvePath(request.GetString("filePath", ""))
+
+		coreLogger.Debug("Executing dependency_source for symbol: %s file: %s", symbolName, filePath)
+		text, err := tools.DependencySource(ctx, s.lspClient, s.config.WorkspaceDir, filePath, symbolName)
+		if err != nil {
+			coreLogger.Error("Failed to get dependency source: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get dependency source: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	implementationTool := mcp.NewTool("implementation",
+		mcp.WithDescription("Find all implementations of an interface or abstract method at the specified position. Returns the location(s) of concrete implementations."),
+		mcp.WithString("filePath",
+			mcp.Description("The path to the file containing the symbol. Required unless symbolName is given, in which case the symbol is looked up in this file only."),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of the symbol, as an alternative to line and column. Use a qualified name like Type.Method to disambiguate."),
+		),
+		mcp.WithNumber("contextLines",
+			mcp.Description("Lines of context to include around each result. Defaults to the server's configured value."),
+		),
+		mcp.WithArray("include",
+			mcp.Description("Only return results in files matching these globs, e.g. [\"internal/**\", \"*.go\"]. Replaces the server's configured includes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("exclude",
+			mcp.Description("Leave out results in files matching these globs, e.g. [\"vendor/\", \"*_test.go\"]. Added to the server's configured excludes."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		scopeParam,
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(implementationTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, line, column, err := s.positionArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		contextLines, err := s.contextLinesArg(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		filter, err := s.pathFilterArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		coreLogger.Debug("Executing implementation for file: %s line: %d column: %d", filePath, line, column)
+		text, err := tools.GetImplementation(ctx, s.lspClient, filePath, line, column, contextLines, filter)
+		if err != nil {
+			coreLogger.Error("Failed to get implementation: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get implementation: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	renameSymbolTool := mcp.NewTool("rename_symbol",
+		mcp.WithDescription("Rename a symbol (variable, function, class, etc.) at the specified position and update all references throughout the codebase."),
+		mcp.WithString("filePath",
+			mcp.Description("The path to the file containing the symbol to rename. Required unless symbolName is given, in which case the symbol is looked up in this file only."),
+		),
+		mcp.WithNumber("line",
+			mcp.Description("The line number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithNumber("column",
+			mcp.Description("The column number where the symbol is located (1-indexed). Required unless symbolName is given."),
+		),
+		mcp.WithString("symbolName",
+			mcp.Description("The name of the symbol, as an alternative to line and column. Use a qualified name like Type.Method to disambiguate."),
+		),
+		mcp.WithString("newName",
+			mcp.Required(),
+			mcp.Description("The new name for the symbol"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Return the unified diff of the rename without writing any files"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false), // cannot perform destructive updates
+	)
+
+	s.addTool(renameSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract arguments
+		newName, err := request.RequireString("newName")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		filePath, line, column, err := s.positionArgs(ctx, request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing rename_symbol for file: %s line: %d column: %d newName: %s", filePath, line, column, newName)
+		dryRun := request.GetBool("dryRun", false)
+		writes := &tools.Writes{}
+		text, err := tools.RenameSymbol(tools.WithWrites(ctx, writes), s.lspClient, filePath, line, column, newName, dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to rename symbol: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to rename symbol: %v", err)), nil
+		}
+		if !dryRun {
+			text += s.verifyEdits(ctx, writes)
+			text += s.hookRunner.Run(s.ctx, "rename_symbol")
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	moveFileTool := mcp.NewTool("move_file",
+		mcp.WithDescription("Move or rename a file or directory and update the code referring to it, such as imports, as the language server computes them. Use this instead of moving files with shell commands, which leaves imports broken."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path of the file or directory to move"),
+		),
+		mcp.WithString("newPath",
+			mcp.Required(),
+			mcp.Description("The path to move it to. Missing parent directories are created."),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Return the unified diff of the updates without moving or writing any files"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+
+	s.addTool(moveFileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		newPath, err := request.RequireString("newPath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath, newPath = s.resolvePath(filePath), s.resolvePath(newPath)
+
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing move_file from: %s to: %s", filePath, newPath)
+		dryRun := request.GetBool("dryRun", false)
+		writes := &tools.Writes{}
+		text, err := tools.MoveFile(tools.WithWrites(ctx, writes), s.lspClient, filePath, newPath, dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to move file: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to move file: %v", err)), nil
+		}
+		if !dryRun {
+			text += s.verifyEdits(ctx, writes)
+			text += s.hookRunner.Run(s.ctx, "move_file")
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	tsRenameFileTool := mcp.NewTool("ts_rename_file",
+		mcp.WithDescription("Move or rename a TypeScript or JavaScript file or directory with typescript-language-server's rename file command, which updates the imports and references to it across the project as the editor does. The server applies the updates itself, so they can't be previewed; use move_file with dryRun to review a move first. Only available with typescript-language-server."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path of the file or directory to move"),
+		),
+		mcp.WithString("newPath",
+			mcp.Required(),
+			mcp.Description("The path to move it to. Missing parent directories are created."),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+
+	s.addTool(tsRenameFileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		newPath, err := request.RequireString("newPath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath, newPath = s.resolvePath(filePath), s.resolvePath(newPath)
+
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing ts_rename_file from: %s to: %s", filePath, newPath)
+		writes := &tools.Writes{}
+		text, err := tools.TSRenameFile(tools.WithWrites(ctx, writes), s.lspClient, filePath, newPath)
+		if err != nil {
+			coreLogger.Error("Failed to rename file: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to rename file: %v", err)), nil
+		}
+		text += s.verifyEdits(ctx, writes)
+		text += s.hookRunner.Run(s.ctx, "ts_rename_file")
+		return mcp.NewToolResultText(text), nil
+	})
+
+	createFileTool := mcp.NewTool("create_file",
+		mcp.WithDescription("Create a new file with the given content, creating missing parent directories, and tell the language server about it so that servers that react to new files, such as jdtls and the TypeScript server, stay consistent. Fails if the file exists; use edit_file to change existing files."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path of the file to create"),
+		),
+		mcp.WithString("content",
+			mcp.Description("The content of the new file. Defaults to an empty file."),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Return the unified diff of the new file and of the edits the server makes for it without writing any files"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+
+	s.addTool(createFileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+		content := request.GetString("content", "")
+
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing create_file for file: %s", filePath)
+		dryRun := request.GetBool("dryRun", false)
+		writes := &tools.Writes{}
+		text, err := tools.CreateFile(tools.WithWrites(ctx, writes), s.lspClient, filePath, content, dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to create file: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to create file: %v", err)), nil
+		}
+		if !dryRun {
+			text += s.verifyEdits(ctx, writes)
+			text += s.hookRunner.Run(s.ctx, "create_file")
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	deleteFileTool := mcp.NewTool("delete_file",
+		mcp.WithDescription("Delete a file, or a directory with recursive set, and tell the language server about it, applying the edits it returns for the deletion so that servers that react to deleted files stay consistent."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path of the file or directory to delete"),
+		),
+		mcp.WithBoolean("recursive",
+			mcp.Description("Allow deleting a directory with everything in it (default false)"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Return the unified diff of the edits for the deletion without deleting or writing any files"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+
+	s.addTool(deleteFileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+		if filepath.Clean(filePath) == filepath.Clean(s.config.WorkspaceDir) {
+			return mcp.NewToolResultError("invalid argument: cannot delete the workspace directory"), nil
+		}
+
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing delete_file for file: %s", filePath)
+		dryRun := request.GetBool("dryRun", false)
+		writes := &tools.Writes{}
+		text, err := tools.DeleteFile(tools.WithWrites(ctx, writes), s.lspClient, filePath, request.GetBool("recursive", false), dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to delete file: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to delete file: %v", err)), nil
+		}
+		if !dryRun {
+			text += s.verifyEdits(ctx, writes)
+			text += s.hookRunner.Run(s.ctx, "delete_file")
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	extractTool := mcp.NewTool("extract",
+		mcp.WithDescription("Extract a range of code into a new function, method, variable or constant with the language server's refactoring, which works out the parameters, return values and declaration place, and give it a name. Returns the diff of the change."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the code to extract"),
+		),
+		mcp.WithString("kind",
+			mcp.Required(),
+			mcp.Description("What to extract the code into"),
+			mcp.Enum("function", "method", "variable", "constant"),
+		),
+		mcp.WithNumber("startLine",
+			mcp.Required(),
+			mcp.Description("The first line of the code to extract (1-indexed)"),
+		),
+		mcp.WithNumber("startColumn",
+			mcp.Description("The column the code starts at (1-indexed). Defaults to the start of the line."),
+		),
+		mcp.WithNumber("endLine",
+			mcp.Required(),
+			mcp.Description("The last line of the code to extract (1-indexed)"),
+		),
+		mcp.WithNumber("endColumn",
+			mcp.Description("The column after the end of the code (1-indexed, exclusive). Defaults to the end of the line."),
+		),
+		mcp.WithString("newName",
+			mcp.Description("The name of the new function, method, variable or constant. Defaults to the name the server picks."),
+		),
+		mcp.WithString("title",
+			mcp.Description("Part of the title of the refactoring to apply when the server offers several, such as 'module scope'. The other titles are listed in the result."),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Return the unified diff of the refactoring without writing any files"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+
+	s.addTool(extractTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+		kind, err := request.RequireString("kind")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		sel, err := selectionArgs(request)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing extract for file: %s lines: %d-%d kind: %s", filePath, sel.StartLine, sel.EndLine, kind)
+		dryRun := request.GetBool("dryRun", false)
+		writes := &tools.Writes{}
+		text, err := tools.Extract(tools.WithWrites(ctx, writes), s.lspClient, filePath, sel, kind,
+			request.GetString("newName", ""), request.GetString("title", ""), dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to extract: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to extract: %v", err)), nil
+		}
+		if !dryRun {
+			text += s.verifyEdits(ctx, writes)
+			text += s.hookRunner.Run(s.ctx, "extract")
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	inlineTool := mcp.NewTool("inline",
+		mcp.WithDescription("Inline the variable, constant or function call at a position with the language server's refactoring, replacing it with its value or body while keeping the code's meaning, unlike a text substitution. Returns the diff of the change."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file containing the symbol to inline"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number of the symbol or call to inline (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number of the symbol or call to inline (1-indexed)"),
+		),
+		mcp.WithString("title",
+			mcp.Description("Part of the title of the refactoring to apply when the server offers several, such as 'all usages'. The other titles are listed in the result."),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Return the unified diff of the refactoring without writing any files"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+
+	s.addTool(inlineTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing inline for file: %s line: %d column: %d", filePath, line, column)
+		dryRun := request.GetBool("dryRun", false)
+		writes := &tools.Writes{}
+		text, err := tools.Inline(tools.WithWrites(ctx, writes), s.lspClient, filePath, line, column,
+			request.GetString("title", ""), dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to inline: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to inline: %v", err)), nil
+		}
+		if !dryRun {
+			text += s.verifyEdits(ctx, writes)
+			text += s.hookRunner.Run(s.ctx, "inline")
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	fixAllTool := mcp.NewTool("fix_all",
+		mcp.WithDescription("Apply the language server's fix-all code action to a file, its source.fixAll action, which fixes every problem of the kinds it knows in one edit, such as the TypeScript server's missing awaits and unreachable code or the autofixes of ESLint and Ruff. Returns the diff of the change."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to fix"),
+		),
+		mcp.WithString("title",
+			mcp.Description("Part of the title of the fix-all action to apply when the server offers several. The other titles are listed in the result."),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Return the unified diff of the fixes without writing any files"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+
+	s.addTool(fixAllTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing fix_all for file: %s", filePath)
+		dryRun := request.GetBool("dryRun", false)
+		writes := &tools.Writes{}
+		text, err := tools.FixAll(tools.WithWrites(ctx, writes), s.lspClient, filePath, request.GetString("title", ""), dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to fix all: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to fix all: %v", err)), nil
+		}
+		if !dryRun {
+			text += s.verifyEdits(ctx, writes)
+			text += s.hookRunner.Run(s.ctx, "fix_all")
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	generateTool := mcp.NewTool("generate",
+		mcp.WithDescription("Generate code at a position with the language server's code actions: fill in the fields of a struct literal, declare the missing methods of a type for an interface it must implement, or add the missing cases of a switch or match. Returns the diff of the change."),
+		mcp.WithString("filePath",
+			mcp.Required(),
+			mcp.Description("The path to the file to generate code in"),
+		),
+		mcp.WithString("kind",
+			mcp.Required(),
+			mcp.Description("What to generate: fill_struct with the position in a struct literal, implement_interface with the position on the code that needs the type to implement the interface, such as an assignment to an interface variable, or fill_switch with the position on a switch or match statement"),
+			mcp.Enum("fill_struct", "implement_interface", "fill_switch"),
+		),
+		mcp.WithNumber("line",
+			mcp.Required(),
+			mcp.Description("The line number to generate code at (1-indexed)"),
+		),
+		mcp.WithNumber("column",
+			mcp.Required(),
+			mcp.Description("The column number to generate code at (1-indexed)"),
+		),
+		mcp.WithString("title",
+			mcp.Description("Part of the title of the code action to apply when the server offers several. The other titles are listed in the result."),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Return the unified diff of the generated code without writing any files"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+
+	s.addTool(generateTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filePath, err := request.RequireString("filePath")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		filePath = s.resolvePath(filePath)
+		kind, err := request.RequireString("kind")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid argument", err), nil
+		}
+
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing generate for file: %s line: %d column: %d kind: %s", filePath, line, column, kind)
+		dryRun := request.GetBool("dryRun", false)
+		writes := &tools.Writes{}
+		text, err := tools.Generate(tools.WithWrites(ctx, writes), s.lspClient, filePath, line, column, kind,
+			request.GetString("title", ""), dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to generate: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to generate: %v", err)), nil
+		}
+		if !dryRun {
+			text += s.verifyEdits(ctx, writes)
+			text += s.hookRunner.Run(s.ctx, "generate")
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	pythonInterpreterTool := mcp.NewTool("python_interpreter",
+		mcp.WithDescription("Show the Python interpreter the language server resolves imports with, its version, virtualenv and site-packages, or point the server at another interpreter or virtualenv. Use it when imports of installed packages are reported as unresolved or definitions into them are missing."),
+		mcp.WithString("path",
+			mcp.Description("The interpreter or virtualenv directory, such as .venv, to point the language server at. Without it, the current interpreter is shown."),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+
+	s.addTool(pythonInterpreterTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path := request.GetString("path", "")
+
+		coreLogger.Debug("Executing python_interpreter with path: %q", path)
+		text, err := s.pythonInterpreter(ctx, path)
+		if err != nil {
+			coreLogger.Error("Failed to get or set the Python interpreter: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get or set the Python interpreter: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	serverLogsTool := mcp.NewTool("server_logs",
+		mcp.WithDescription("Show the last lines the language server wrote to stderr. Use it when tools fail or return nothing unexpectedly, to see whether the language server reports errors such as a broken build configuration."),
+		mcp.WithNumber("lines",
+			mcp.Description("Number of lines to show, from the end (default 100, 0 for all kept)"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(serverLogsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		lines := request.GetInt("lines", 100)
+		if lines < 0 {
+			return mcp.NewToolResultErrorFromErr("invalid argument", fmt.Errorf("lines must not be negative")), nil
+		}
+
+		coreLogger.Debug("Executing server_logs for %d lines", lines)
+		text := s.lspClient.ServerLogs(lines)
+		if text == "" {
+			text = "The language server wrote nothing to stderr."
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	warmUpTool := mcp.NewTool("warm_up",
+		mcp.WithDescription("Get the language server to index the workspace: open representative files of the workspace's main language, one per directory first, ask for the workspace symbols and wait for the indexing to finish. Use it at the start of a session on a large workspace, so that later queries don't wait for the index or miss results while it builds."),
+		mcp.WithNumber("maxFiles",
+			mcp.Description(fmt.Sprintf("Maximum number of files to open (default %d)", tools.DefaultWarmUpFiles)),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(warmUpTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		maxFiles := request.GetInt("maxFiles", tools.DefaultWarmUpFiles)
+		if maxFiles < 0 {
+			return mcp.NewToolResultErrorFromErr("invalid argument", fmt.Errorf("maxFiles must not be negative")), nil
+		}
+
+		coreLogger.Debug("Executing warm_up with up to %d files", maxFiles)
+		text, err := tools.WarmUp(ctx, s.lspClient, s.config.WorkspaceDir, maxFiles)
+		if err != nil {
+			coreLogger.Error("Failed to warm up the language server: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to warm up the language server: %v", err)), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	listEditsTool := mcp.NewTool("list_edits",
+		mcp.WithDescription("List the recent edits made by this server's tools that changed files, most recent first, with the files each one changed. The most recent one can be reverted with undo_last_edit."),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+
+	s.addTool(listEditsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		coreLogger.Debug("Executing list_edits")
+		return mcp.NewToolResultText(s.journal.List()), nil
+	})
+
+	undoLastEditTool := mcp.NewTool("undo_last_edit",
+		mcp.WithDescription("Revert the most recent edit made by this server's tools, restoring the files it changed to their previous contents and deleting the files it created. Call it repeatedly to revert earlier edits. Refuses if the files changed since the edit, unless force is set."),
+		mcp.WithBoolean("force",
+			mcp.Description("Restore the files even if they changed since the edit, losing those changes (default false)"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Return the unified diff of the undo without restoring any files"),
+		),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+
+	s.addTool(undoLastEditTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		s.editMu.Lock()
+		defer s.editMu.Unlock()
+
+		coreLogger.Debug("Executing undo_last_edit")
+		dryRun := request.GetBool("dryRun", false)
+		writes := &tools.Writes{}
+		text, err := tools.UndoLastEdit(tools.WithWrites(ctx, writes), s.lspClient, s.journal, request.GetBool("force", false), dryRun)
+		if err != nil {
+			coreLogger.Error("Failed to undo edit: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to undo edit: %v", err)), nil
+		}
+		if !dryRun {
+			text += s.verifyEdits(ctx, writes)
+			text += s.hookRunner.Run(s.ctx, "undo_last_edit")
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+
+	s.registerBatchTools()
+
+	coreLogger.Info("Successfully registered all MCP tools")
+	return nil
+}
+
+// contextLinesArg returns the contextLines argument of a tool call, falling back
+// to the configured default when it is not provided
+func (s *Server) contextLinesArg(request mcp.CallToolRequest) (int, error) {
+	contextLines := request.GetInt("contextLines", s.config.ContextLines)
+	if contextLines < 0 {
+		return 0, fmt.Errorf("contextLines must be >= 0, got %d", contextLines)
+	}
+	return contextLines, nil
+}
+
+// positionArgs returns the file and 1-indexed position targeted by a position based tool.
+// The position is given either as line and column or as a symbolName, which is resolved
+// through the language server's symbol information. A column that isn't on an identifier
+// is moved to the nearest identifier on its line, with a note in the result.
+func (s *Server) positionArgs(ctx context.Context, request mcp.CallToolRequest) (string, int, int, error) {
+	filePath := s.resolvePath(request.GetString("filePath", ""))
+
+	if symbolName := request.GetString("symbolName", ""); symbolName != "" {
+		return tools.ResolveSymbolPosition(ctx, s.lspClient, filePath, symbolName)
+	}
+
+	if filePath == "" {
+		return "", 0, 0, fmt.Errorf("filePath is required unless symbolName is given")
+	}
+
+	line, err := request.RequireInt("line")
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	column, err := request.RequireInt("column")
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	// Columns that miss the identifier they were meant for are moved onto the nearest one
+	column, note := tools.SnapToIdentifier(ctx, filePath, line, column)
+	if note != "" {
+		addCallNote(ctx, note)
+	}
+	return filePath, line, column, nil
+}
+
+// Default number of results returned by tools that support pagination
+const defaultMaxResults = 100
+
+// paginationArgs returns the maxResults, offset and maxResultsPerFile arguments of a tool call
+func paginationArgs(request mcp.CallToolRequest) (tools.Pagination, error) {
+	page := tools.Pagination{
+		MaxResults: request.GetInt("maxResults", defaultMaxResults),
+		Offset:     request.GetInt("offset", 0),
+		MaxPerFile: request.GetInt("maxResultsPerFile", 0),
+	}
+	if page.MaxResults < 0 || page.Offset < 0 || page.MaxPerFile < 0 {
+		return tools.Pagination{}, fmt.Errorf("maxResults, offset and maxResultsPerFile must be >= 0")
+	}
+	return page, nil
+}
+
+// selectionArgs returns the startLine, startColumn, endLine and endColumn arguments of a
+// tool call
+func selectionArgs(request mcp.CallToolRequest) (tools.Selection, error) {
+	startLine, err := request.RequireInt("startLine")
+	if err != nil {
+		return tools.Selection{}, err
+	}
+	endLine, err := request.RequireInt("endLine")
+	if err != nil {
+		return tools.Selection{}, err
+	}
+	sel := tools.Selection{
+		StartLine:   startLine,
+		StartColumn: request.GetInt("startColumn", 1),
+		EndLine:     endLine,
+		EndColumn:   request.GetInt("endColumn", 0),
+	}
+	if sel.StartLine < 1 || sel.EndLine < sel.StartLine {
+		return tools.Selection{}, fmt.Errorf("startLine must be >= 1 and endLine must not be before it")
+	}
+	return sel, nil
+}
+
+// pathFilterArgs returns the include and exclude globs of a tool call combined with the
+// configured defaults, limited to the files in its git scope. Excludes are added to the
+// defaults while includes replace them.
+func (s *Server) pathFilterArgs(ctx context.Context, request mcp.CallToolRequest) (tools.PathFilter, error) {
+	scope, err := tools.ParseGitScope(request.GetString("scope", ""))
+	if err != nil {
+		return tools.PathFilter{}, err
+	}
+	files, err := tools.ChangedFiles(ctx, s.config.WorkspaceDir, scope)
+	if err != nil {
+		return tools.PathFilter{}, fmt.Errorf("failed to list the files in scope %s: %w", scope, err)
+	}
+	return tools.PathFilter{
+		Root:    s.config.WorkspaceDir,
+		Include: request.GetStringSlice("include", s.config.Include),
+		Exclude: append(slices.Clone(s.config.Exclude), request.GetStringSlice("exclude", nil)...),
+		Files:   files,
+	}, nil
+}
+
+// verifyEdits reports the diagnostics introduced and resolved by the writes of a mutating
+// tool call, if edit verification is enabled
+func (s *Server) verifyEdits(ctx context.Context, writes *tools.Writes) string {
+	if !s.config.VerifyEdits {
+		return ""
+	}
+	return tools.VerifyEdits(ctx, s.lspClient, writes)
+}
@@ -0,0 +1,87 @@
+package langserver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ToolOverride changes how a tool is exposed to MCP clients
+type ToolOverride struct {
+	// Name replaces the tool's name. Empty keeps it.
+	Name string
+	// Description replaces the tool's description. Empty keeps it.
+	Description string
+}
+
+// exposeTool decides whether a tool is registered under the Tools and DisabledTools
+// settings, and applies its ToolOverride. It returns false for tools to leave out.
+func (s *Server) exposeTool(tool *mcp.Tool) bool {
+	if s.knownTools == nil {
+		s.knownTools = make(map[string]bool)
+	}
+	s.knownTools[tool.Name] = true
+
+	if !s.toolEnabled(tool.Name) {
+		coreLogger.Debug("Tool %s is disabled, not registering it", tool.Name)
+		return false
+	}
+	if override, ok := s.config.ToolOverrides[tool.Name]; ok {
+		if override.Name != "" {
+			tool.Name = override.Name
+		}
+		if override.Description != "" {
+			tool.Description = override.Description
+		}
+	}
+	return true
+}
+
+// toolEnabled reports whether the Tools and DisabledTools settings let a tool through
+func (s *Server) toolEnabled(name string) bool {
+	return (len(s.config.Tools) == 0 || slices.Contains(s.config.Tools, name)) &&
+		!slices.Contains(s.config.DisabledTools, name)
+}
+
+// renamedTool restores the tool's own name in the requests to a tool exposed under
+// another name, so that the settings keyed by tool name, such as ToolTimeouts and the
+// hooks, and the metrics keep using it
+func renamedTool(name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		request.Params.Name = name
+		return next(ctx, request)
+	}
+}
+
+// checkToolConfig reports tools named in the Tools, DisabledTools and ToolOverrides
+// settings that don't exist, and overrides that expose two tools under the same name
+func (s *Server) checkToolConfig() error {
+	names := slices.Concat(s.config.Tools, s.config.DisabledTools)
+	for name := range s.config.ToolOverrides {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if !s.knownTools[name] {
+			return fmt.Errorf("unknown tool %q in the tool configuration", name)
+		}
+	}
+
+	exposed := make(map[string]string)
+	for name := range s.knownTools {
+		if !s.toolEnabled(name) {
+			continue
+		}
+		as := name
+		if override := s.config.ToolOverrides[name]; override.Name != "" {
+			as = override.Name
+		}
+		if other, ok := exposed[as]; ok {
+			return fmt.Errorf("tools %s and %s are both exposed as %q", other, name, as)
+		}
+		exposed[as] = name
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolDescriptions lists the tools an MCP server exposes with their descriptions
+func toolDescriptions(t *testing.T, mcpServer *server.MCPServer) map[string]string {
+	response := mcpServer.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	raw, err := json.Marshal(response)
+	require.NoError(t, err)
+	var list struct {
+		Result struct {
+			Tools []struct {
+				Name        string `json:"name"`
+				Description string `json:"description"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &list))
+	tools := make(map[string]string)
+	for _, tool := range list.Result.Tools {
+		tools[tool.Name] = tool.Description
+	}
+	return tools
+}
+
+func TestToolConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		tools     []string
+		disabled  []string
+		overrides map[string]ToolOverride
+		exposed   []string
+		hidden    []string
+		err       string
+	}{
+		{name: "all tools", exposed: []string{"grep", "read_source", "edit_file"}},
+		{name: "enabled", tools: []string{"grep", "read_source"}, exposed: []string{"grep", "read_source"}, hidden: []string{"edit_file", "project_tree"}},
+		{name: "disabled", disabled: []string{"grep"}, exposed: []string{"read_source", "edit_file"}, hidden: []string{"grep"}},
+		{name: "enabled and disabled", tools: []string{"grep", "read_source"}, disabled: []string{"grep"}, exposed: []string{"read_source"}, hidden: []string{"grep", "edit_file"}},
+		{
+			name:      "renamed",
+			overrides: map[string]ToolOverride{"grep": {Name: "search_text"}},
+			exposed:   []string{"search_text", "read_source"},
+			hidden:    []string{"grep"},
+		},
+		{
+			name:      "renamed and enabled by its own name",
+			tools:     []string{"grep"},
+			overrides: map[string]ToolOverride{"grep": {Name: "search_text"}},
+			exposed:   []string{"search_text"},
+			hidden:    []string{"grep", "read_source"},
+		},
+		{
+			name:      "renamed and disabled",
+			disabled:  []string{"grep"},
+			overrides: map[string]ToolOverride{"grep": {Name: "search_text"}},
+			hidden:    []string{"grep", "search_text"},
+		},
+		{
+			name:      "renamed to the name of a disabled tool",
+			disabled:  []string{"read_source"},
+			overrides: map[string]ToolOverride{"grep": {Name: "read_source"}},
+			exposed:   []string{"read_source"},
+			hidden:    []string{"grep"},
+		},
+		{name: "unknown enabled tool", tools: []string{"grep", "no_such_tool"}, err: `unknown tool "no_such_tool"`},
+		{name: "unknown disabled tool", disabled: []string{"no_such_tool"}, err: `unknown tool "no_such_tool"`},
+		{name: "unknown overridden tool", overrides: map[string]ToolOverride{"no_such_tool": {Name: "x"}}, err: `unknown tool "no_such_tool"`},
+		{
+			name:      "two tools under one name",
+			overrides: map[string]ToolOverride{"grep": {Name: "read_source"}},
+			err:       `both exposed as "read_source"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(Config{
+				WorkspaceDir:  t.TempDir(),
+				LSPAddress:    "localhost:1",
+				Tools:         tt.tools,
+				DisabledTools: tt.disabled,
+				ToolOverrides: tt.overrides,
+			})
+			require.NoError(t, err)
+			mcpServer := server.NewMCPServer("test", "1.0", server.WithToolCapabilities(true))
+			err = s.Register(mcpServer)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			exposed := toolDescriptions(t, mcpServer)
+			for _, name := range tt.exposed {
+				assert.Contains(t, exposed, name)
+			}
+			for _, name := range tt.hidden {
+				assert.NotContains(t, exposed, name)
+			}
+		})
+	}
+}
+
+func TestToolOverrideDescription(t *testing.T) {
+	s, err := New(Config{
+		WorkspaceDir:  t.TempDir(),
+		LSPAddress:    "localhost:1",
+		ToolOverrides: map[string]ToolOverride{"grep": {Description: "Search the code"}},
+	})
+	require.NoError(t, err)
+	mcpServer := server.NewMCPServer("test", "1.0", server.WithToolCapabilities(true))
+	require.NoError(t, s.Register(mcpServer))
+	assert.Equal(t, "Search the code", toolDescriptions(t, mcpServer)["grep"])
+}
+
+func TestRenamedTool(t *testing.T) {
+	var called string
+	handler := renamedTool("grep", func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		called = request.Params.Name
+		return nil, nil
+	})
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "search_text"
+	_, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "grep", called)
+}
@@ -2,150 +2,210 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/isaacphi/mcp-language-server/internal/logging"
 	"github.com/isaacphi/mcp-language-server/internal/lsp"
-	"github.com/isaacphi/mcp-language-server/internal/watcher"
+	"github.com/isaacphi/mcp-language-server/langserver"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-// Create a logger for the core component
-var coreLogger = logging.NewLogger(logging.Core)
+// Create loggers for the core component and the MCP transports
+var (
+	coreLogger         = logging.NewLogger(logging.Core)
+	mcpTransportLogger = logging.NewLogger(logging.MCP)
+)
 
-const serverInstructions = `
-- This server exposes a language server to enable agents to navigate and edit codebases more easily, by giving access to semantic tools from the LSP server like get definition, references, rename, etc.
-- Semantic tools are generally more accurate than text-based tools like grep or search+replace. However, you may still need to fallback to text-based approaches when the LSP server works poorly, such as: when the code does not compile.
-	- Beware: For reading tools like references, this usually won't manifest as an error from the language server, rather it will appear as zero results or incomplete results.
-- When using references etc tools, the line + column number MUST be inside the function/variable etc name, NOT on keywords (eg. type, struct) or whitespace.
-- If you get this error "no identifier found", it means your column/line number is incorrect. It does NOT mean there are zero references / results etc.
-`
+// The version reported to MCP clients and recorded in exported indexes
+const version = "v0.0.2"
 
 type config struct {
-	workspaceDir string
-	lspCommand   string
-	lspArgs      []string
-}
-
-type mcpServer struct {
-	config           config
-	lspClient        *lsp.Client
-	mcpServer        *server.MCPServer
-	ctx              context.Context
-	cancelFunc       context.CancelFunc
-	workspaceWatcher *watcher.WorkspaceWatcher
+	langserver.Config
+	configFile string
+	listen     string
+	logLevel   string
+	logFile    string
+	traceLSP   string
+	record     string
+	lspExec    string
+	// The settings of the flags alone, which the configuration file is applied over again
+	// when it is reloaded
+	flags langserver.Config
 }
 
-func parseConfig() (*config, error) {
+// parseConfig reads the flags common to serving and exporting from args, with the flags
+// already defined on fs
+func parseConfig(fs *flag.FlagSet, args []string) (*config, error) {
 	cfg := &config{}
-	flag.StringVar(&cfg.workspaceDir, "workspace", "", "Path to workspace directory")
-	flag.StringVar(&cfg.lspCommand, "lsp", "", "LSP command to run (args should be passed after --)")
-	flag.Parse()
-
-	// Get remaining args after -- as LSP arguments
-	cfg.lspArgs = flag.Args()
-
-	// Validate workspace directory
-	if cfg.workspaceDir == "" {
-		return nil, fmt.Errorf("workspace directory is required")
+	fs.StringVar(&cfg.WorkspaceDir, "workspace", "", "Path to workspace directory")
+	fs.StringVar(&cfg.LSPCommand, "lsp", "", "LSP command to run (args should be passed after --)")
+	fs.StringVar(&cfg.LSPAddress, "lsp-connect", "", "Connect to a language server already listening on host:port or unix:/path/to/socket instead of starting --lsp, which then only tells which server it is")
+	fs.StringVar(&cfg.lspExec, "lsp-exec", "", "Run the language server in a container or on another host where the workspace is at the same path: docker:<container>, podman:<container> or ssh:<destination>")
+	fs.Func("path-map", "Map a host directory to the directory the language server sees it as, as host=server, e.g. .=/workspaces/app, translating the paths exchanged with it (repeatable)", func(value string) error {
+		host, server, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("expected host=server, got %q", value)
+		}
+		cfg.PathMap = append(cfg.PathMap, langserver.PathMapping{Host: host, Server: server})
+		return nil
+	})
+	fs.StringVar(&cfg.LSPDir, "lsp-dir", "", "Working directory of the language server, relative to the workspace (defaults to the workspace)")
+	fs.IntVar(&cfg.ContextLines, "context-lines", 5, "Default number of context lines shown around results")
+	fs.BoolVar(&cfg.ZeroIndexed, "zero-indexed", false, "Take 0-indexed lines and columns in tool calls, as LSP numbers them, instead of 1-indexed ones")
+	fs.StringVar(&cfg.Context.Mode, "context-mode", "symbol", "Lines shown around results: symbol (context lines within the enclosing symbol), function (the whole enclosing symbol) or lines (context lines whatever the symbols)")
+	fs.IntVar(&cfg.Context.Before, "context-before", 0, "Fixed number of lines shown before each result instead of context lines, when it or --context-after is set")
+	fs.IntVar(&cfg.Context.After, "context-after", 0, "Fixed number of lines shown after each result instead of context lines, when it or --context-before is set")
+	fs.StringVar(&cfg.Context.Separator, "context-separator", "ellipsis", "Shown between ranges of lines that aren't adjacent: ellipsis (...), count (the number of lines left out) or none")
+	fs.StringVar(&cfg.configFile, "config", "", "Path to a JSON configuration file")
+	fs.StringVar(&cfg.ApplyEdits, "apply-edits", "workspace", "Which edits the language server may apply on its own: allow, workspace (only files in the workspace) or deny")
+	fs.StringVar(&cfg.PythonInterpreter, "python", "", "Python interpreter or virtualenv directory, relative to the workspace, that Python language servers resolve imports with (empty to let them choose)")
+	fs.StringVar(&cfg.MessageAction, "message-action", "dismiss", "How to answer the prompts of the language server: dismiss, first (choose the first action) or the title of the action to choose")
+	fs.StringVar(&cfg.QueryOpen, "query-open", "open", "Whether read-only tools open the files they query: open, ttl (close them after --query-open-ttl without queries) or skip")
+	fs.DurationVar(&cfg.QueryOpenTTL, "query-open-ttl", 5*time.Minute, "How long files opened for queries stay open with --query-open=ttl")
+	fs.StringVar(&cfg.Fallback, "fallback", "", "Read symbols from the syntax of files the language server has none for, marking the results as approximate: tree-sitter or ctags (empty for none)")
+	fs.StringVar(&cfg.CtagsFile, "ctags-file", "", "Tags file the ctags fallback reads, relative to the workspace (empty or missing to have ctags index the workspace)")
+	fs.StringVar(&cfg.Index, "index", "", "Precomputed SCIP (.scip) or LSIF index, relative to the workspace, that definition, references and hover answer from (empty for none)")
+	fs.StringVar(&cfg.IndexMode, "index-mode", "fallback", "When the index answers: fallback (when the language server fails or finds nothing) or prefer (before asking the server)")
+	fs.StringVar(&cfg.SymbolCache, "symbol-cache", "", "File caching document and workspace symbols across restarts, relative to the workspace, to answer from while the language server indexes (empty to disable)")
+	fs.IntVar(&cfg.MaxConcurrentRequests, "max-concurrent-requests", 0, "Maximum number of requests the language server handles at once, with tool calls taking turns beyond it (0 for no limit)")
+	fs.IntVar(&cfg.MaxOpenFiles, "max-open-files", 500, "Maximum number of files open in the language server at once, closing the least recently used ones first (0 for no limit)")
+	fs.IntVar(&cfg.WarmUp, "warm-up", 0, "Open up to this many representative files and ask for the workspace symbols at startup, so that the language server indexes the workspace before the first tool call (0 disables)")
+	fs.BoolVar(&cfg.VerifyEdits, "verify-edits", true, "Report the diagnostics that mutating tools such as edit_file introduce and resolve, waiting for the language server to check the changed files")
+	fs.BoolVar(&cfg.ReadOnly, "read-only", false, "Only register the tools that don't change files and reject the edits the language server asks to apply")
+	fs.BoolVar(&cfg.Sandbox, "sandbox", false, "Reject tool calls with file paths outside the workspace, following .. and symlinks, and the directories allowed in the configuration file")
+	fs.BoolVar(&cfg.RelativePaths, "relative-paths", false, "Show paths in tool results relative to the workspace directory")
+	fs.IntVar(&cfg.MaxOutput, "max-output", 100000, "Maximum number of characters in a tool result, shortening code context and then leaving out files beyond it (0 for no limit)")
+	fs.Int64Var(&cfg.LargeFileSize, "large-file-size", 16<<20, "Size in bytes above which files are not read whole to show the code around results, only the lines around them (0 for no limit)")
+	fs.StringVar(&cfg.listen, "listen", "", "Serve MCP over HTTP on this address, e.g. localhost:8080, instead of stdio")
+	fs.DurationVar(&cfg.ToolTimeout, "tool-timeout", 2*time.Minute, "Maximum time a tool call may wait on the language server before returning the results found so far (0 disables)")
+	fs.DurationVar(&cfg.IdleTimeout, "idle-timeout", 0, "Shut down the language server after this period of inactivity and restart it on the next tool call (0 disables)")
+	fs.StringVar(&cfg.logLevel, "log-level", "", "Log level of all components: debug, info, warn or error (defaults to $LOG_LEVEL or info)")
+	fs.StringVar(&cfg.logFile, "log-file", "", "Write logs to this file instead of stderr")
+	fs.StringVar(&cfg.traceLSP, "trace-lsp", "", "Write every message exchanged with the language server to this file")
+	fs.IntVar(&cfg.TraceMaxPayload, "trace-max-payload", 4096, "Truncate message payloads in the --trace-lsp file to this many bytes (0 for no limit)")
+	fs.StringVar(&cfg.record, "record", "", "Record the tool calls with their results and the messages exchanged with the language server to this file, to replay with --replay")
+	fs.StringVar(&cfg.Replay, "replay", "", "Answer from a recording made with --record, relative to the workspace, instead of a language server, logging the tool results that differ from the recorded ones")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
 	}
 
-	workspaceDir, err := filepath.Abs(cfg.workspaceDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get absolute path for workspace: %v", err)
+	if err := logging.Setup(cfg.logLevel, cfg.logFile); err != nil {
+		return nil, err
 	}
-	cfg.workspaceDir = workspaceDir
 
-	if _, err := os.Stat(cfg.workspaceDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("workspace directory does not exist: %s", cfg.workspaceDir)
+	if cfg.traceLSP != "" {
+		f, err := os.OpenFile(cfg.traceLSP, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open LSP trace file: %v", err)
+		}
+		cfg.TraceLSP = f
+	}
+	if cfg.record != "" {
+		f, err := os.Create(cfg.record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open recording file: %v", err)
+		}
+		cfg.Record = f
 	}
 
-	// Validate LSP command
-	if cfg.lspCommand == "" {
-		return nil, fmt.Errorf("LSP command is required")
+	// Get remaining args after -- as LSP arguments
+	cfg.LSPArgs = fs.Args()
+
+	if cfg.lspExec != "" {
+		launcher, err := lsp.ParseLauncher(cfg.lspExec)
+		if err != nil {
+			return nil, err
+		}
+		cfg.LSPLauncher = &launcher
 	}
 
-	if _, err := exec.LookPath(cfg.lspCommand); err != nil {
-		return nil, fmt.Errorf("LSP command not found: %s", cfg.lspCommand)
+	// The configuration is read again on reloads, after changing to the workspace directory
+	for _, path := range []*string{&cfg.WorkspaceDir, &cfg.configFile} {
+		if *path != "" {
+			abs, err := filepath.Abs(*path)
+			if err != nil {
+				return nil, err
+			}
+			*path = abs
+		}
+	}
+	cfg.flags = cloneConfig(cfg.Config)
+	if cfg.configFile != "" {
+		fc, err := loadConfigFile(cfg.configFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := fc.apply(cfg); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %v", cfg.configFile, err)
+		}
 	}
 
 	return cfg, nil
 }
 
-func newServer(config *config) (*mcpServer, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	return &mcpServer{
-		config:     *config,
-		ctx:        ctx,
-		cancelFunc: cancel,
-	}, nil
-}
-
-func (s *mcpServer) initializeLSP() error {
-	if err := os.Chdir(s.config.workspaceDir); err != nil {
+// start runs the language server and serves its tools over stdio, or over HTTP on the
+// listen address if one is given
+func start(ls *langserver.Server, workspaceDir, listen string, done <-chan struct{}) error {
+	if err := os.Chdir(workspaceDir); err != nil {
 		return fmt.Errorf("failed to change to workspace directory: %v", err)
 	}
 
-	client, err := lsp.NewClient(s.config.lspCommand, s.config.lspArgs...)
-	if err != nil {
-		return fmt.Errorf("failed to create LSP client: %v", err)
-	}
-	s.lspClient = client
-	s.workspaceWatcher = watcher.NewWorkspaceWatcher(client)
-
-	initResult, err := client.InitializeLSPClient(s.ctx, s.config.workspaceDir)
-	if err != nil {
-		return fmt.Errorf("initialize failed: %v", err)
-	}
-
-	coreLogger.Debug("Server capabilities: %+v", initResult.Capabilities)
-
-	go s.workspaceWatcher.WatchWorkspace(s.ctx, s.config.workspaceDir)
-	return client.WaitForServerReady(s.ctx)
-}
-
-func (s *mcpServer) start() error {
-	if err := s.initializeLSP(); err != nil {
+	if err := ls.Start(); err != nil {
 		return err
 	}
 
-	s.mcpServer = server.NewMCPServer(
+	hooks := &server.Hooks{}
+	ls.AddHooks(hooks)
+
+	mcpServer := server.NewMCPServer(
 		"MCP Language Server",
-		"v0.0.2",
+		version,
 		server.WithLogging(),
 		server.WithRecovery(),
-		server.WithInstructions(strings.TrimSpace(serverInstructions)),
+		server.WithHooks(hooks),
+		server.WithResourceCapabilities(false, true),
+		server.WithInstructions(strings.TrimSpace(langserver.Instructions)),
 	)
 
-	err := s.registerTools()
-	if err != nil {
-		return fmt.Errorf("tool registration failed: %v", err)
+	if err := ls.Register(mcpServer); err != nil {
+		return err
 	}
 
-	return server.ServeStdio(s.mcpServer)
+	if listen != "" {
+		return serveHTTP(mcpServer, ls, listen, done)
+	}
+	// stdout carries the MCP messages, so nothing else may write to it
+	return server.ServeStdio(mcpServer, server.WithErrorLogger(logging.NewStdLogger(logging.MCP, logging.LevelError)))
 }
 
 func main() {
-	coreLogger.Info("MCP Language Server starting")
-
 	done := make(chan struct{})
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	config, err := parseConfig()
+	if len(os.Args) > 1 && os.Args[1] == "export-scip" {
+		if err := exportSCIP(os.Args[2:]); err != nil {
+			coreLogger.Fatal("%v", err)
+		}
+		return
+	}
+
+	config, err := parseConfig(flag.CommandLine, os.Args[1:])
 	if err != nil {
 		coreLogger.Fatal("%v", err)
 	}
+	coreLogger.Info("MCP Language Server starting")
 
-	server, err := newServer(config)
+	ls, err := langserver.New(config.Config)
 	if err != nil {
 		coreLogger.Fatal("%v", err)
 	}
@@ -177,79 +237,68 @@ func main() {
 		}
 	}()
 
+	if config.configFile != "" {
+		go watchConfig(config, ls, done)
+	}
+
 	// Handle shutdown triggers
 	go func() {
 		select {
 		case sig := <-sigChan:
 			coreLogger.Info("Received signal %v in PID: %d", sig, os.Getpid())
-			cleanup(server, done)
+			go func() {
+				<-sigChan
+				coreLogger.Warn("Received a second signal, exiting without cleanup")
+				os.Exit(1)
+			}()
+			cleanup(ls, done)
 		case <-parentDeath:
 			coreLogger.Info("Parent death detected, initiating shutdown")
-			cleanup(server, done)
+			cleanup(ls, done)
 		}
 	}()
 
-	if err := server.start(); err != nil {
+	err = start(ls, ls.WorkspaceDir(), config.listen, done)
+	// The stdio transport stops when the client closes stdin, or cancels itself on
+	// SIGINT and SIGTERM, either way the language server must be shut down
+	cleanup(ls, done)
+	if err != nil && !errors.Is(err, context.Canceled) {
 		coreLogger.Error("Server error: %v", err)
-		cleanup(server, done)
 		os.Exit(1)
 	}
 
-	<-done
 	coreLogger.Info("Server shutdown complete for PID: %d", os.Getpid())
 	os.Exit(0)
 }
 
-func cleanup(s *mcpServer, done chan struct{}) {
-	coreLogger.Info("Cleanup initiated for PID: %d", os.Getpid())
+// How long cleanup may take before the process exits regardless
+const shutdownTimeout = 5 * time.Second
 
-	// Create a context with timeout for shutdown operations
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+var cleanupOnce sync.Once
 
-	if s.lspClient != nil {
-		coreLogger.Info("Closing open files")
-		s.lspClient.CloseAllFiles(ctx)
+// cleanup shuts down the language server and closes done, once, however many shutdown
+// triggers fire. Callers return once it completed.
+func cleanup(ls *langserver.Server, done chan struct{}) {
+	cleanupOnce.Do(func() {
+		coreLogger.Info("Cleanup initiated for PID: %d", os.Getpid())
 
-		// Create a shorter timeout context for the shutdown request
-		shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 500*time.Millisecond)
-		defer shutdownCancel()
+		// Create a context with timeout for shutdown operations
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
 
-		// Run shutdown in a goroutine with timeout to avoid blocking if LSP doesn't respond
-		shutdownDone := make(chan struct{})
+		closed := make(chan struct{})
 		go func() {
-			coreLogger.Info("Sending shutdown request")
-			if err := s.lspClient.Shutdown(shutdownCtx); err != nil {
-				coreLogger.Error("Shutdown request failed: %v", err)
-			}
-			close(shutdownDone)
+			ls.Close(ctx)
+			close(closed)
 		}()
-
-		// Wait for shutdown with timeout
+		// The language server is killed within the timeout, but don't hang on it
 		select {
-		case <-shutdownDone:
-			coreLogger.Info("Shutdown request completed")
-		case <-time.After(1 * time.Second):
-			coreLogger.Warn("Shutdown request timed out, proceeding with exit")
-		}
-
-		coreLogger.Info("Sending exit notification")
-		if err := s.lspClient.Exit(ctx); err != nil {
-			coreLogger.Error("Exit notification failed: %v", err)
-		}
-
-		coreLogger.Info("Closing LSP client")
-		if err := s.lspClient.Close(); err != nil {
-			coreLogger.Error("Failed to close LSP client: %v", err)
+		case <-closed:
+		case <-time.After(shutdownTimeout + 2*time.Second):
+			coreLogger.Warn("Language server shutdown did not complete in time")
 		}
-	}
 
-	// Send signal to the done channel
-	select {
-	case <-done: // Channel already closed
-	default:
 		close(done)
-	}
-
-	coreLogger.Info("Cleanup completed for PID: %d", os.Getpid())
+		coreLogger.Info("Cleanup completed for PID: %d", os.Getpid())
+	})
 }
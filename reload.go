@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/isaacphi/mcp-language-server/langserver"
+)
+
+// How long to wait for more changes to the configuration file before reloading it, as
+// editors write files in several steps
+const reloadDelay = 300 * time.Millisecond
+
+// watchConfig reloads the configuration file into the language server when it changes or
+// the process receives SIGHUP, until done is closed
+func watchConfig(cfg *config, ls *langserver.Server, done <-chan struct{}) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	// Watch the directory, as editors replace files rather than write them in place
+	var changes <-chan fsnotify.Event
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		coreLogger.Error("Failed to watch the config file, reload it with SIGHUP: %v", err)
+	} else {
+		defer func() {
+			if err := watcher.Close(); err != nil {
+				coreLogger.Error("Failed to stop watching the config file: %v", err)
+			}
+		}()
+		if err := watcher.Add(filepath.Dir(cfg.configFile)); err != nil {
+			coreLogger.Error("Failed to watch the config file, reload it with SIGHUP: %v", err)
+		} else {
+			changes = watcher.Events
+		}
+	}
+
+	var timer <-chan time.Time
+	for {
+		select {
+		case event := <-changes:
+			if filepath.Clean(event.Name) == cfg.configFile && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				timer = time.After(reloadDelay)
+			}
+		case <-hangup:
+			coreLogger.Info("Received SIGHUP, reloading %s", cfg.configFile)
+			reload(cfg, ls)
+		case <-timer:
+			timer = nil
+			coreLogger.Info("%s changed, reloading it", cfg.configFile)
+			reload(cfg, ls)
+		case <-done:
+			return
+		}
+	}
+}
+
+// reload applies the configuration file to the language server, keeping the current
+// configuration if it is invalid
+func reload(cfg *config, ls *langserver.Server) {
+	next, err := cfg.reloadConfig()
+	if err != nil {
+		coreLogger.Error("Failed to reload the configuration, keeping the current one: %v", err)
+		return
+	}
+	changed, err := ls.Reload(next)
+	switch {
+	case err != nil:
+		coreLogger.Error("Failed to reload the configuration: %v", err)
+	case len(changed) == 0:
+		coreLogger.Info("The configuration didn't change")
+	default:
+		coreLogger.Info("Reloaded the configuration, changed: %v", changed)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/isaacphi/mcp-language-server/internal/logging"
+	"github.com/isaacphi/mcp-language-server/internal/metrics"
+	"github.com/isaacphi/mcp-language-server/langserver"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// serveHTTP serves the MCP server over the network on addr until done is closed.
+// Clients using the streamable HTTP transport connect to /mcp, and clients using the
+// older SSE transport connect to /sse and post their messages to /message. Every client
+// gets its own session on the shared language server. Prometheus metrics are served at
+// /metrics.
+func serveHTTP(mcpServer *server.MCPServer, ls *langserver.Server, addr string, done <-chan struct{}) error {
+	mux := http.NewServeMux()
+	httpServer := &http.Server{
+		Addr:     addr,
+		Handler:  mux,
+		ErrorLog: logging.NewStdLogger(logging.MCP, logging.LevelError),
+	}
+
+	streamable := server.NewStreamableHTTPServer(mcpServer,
+		server.WithStreamableHTTPServer(httpServer),
+		server.WithSessionIdManager(&sessionIDManager{ls: ls}),
+		server.WithLogger(mcpLogger{}),
+	)
+	sse := server.NewSSEServer(mcpServer, server.WithHTTPServer(httpServer))
+	mux.Handle("/mcp", streamable)
+	mux.Handle("/sse", sse.SSEHandler())
+	mux.Handle("/message", sse.MessageHandler())
+	mux.Handle("/metrics", metrics.Handler())
+
+	go func() {
+		<-done
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			coreLogger.Error("Failed to shut down HTTP server: %v", err)
+		}
+	}()
+
+	coreLogger.Info("Serving MCP over HTTP on %s (streamable HTTP at /mcp, SSE at /sse, metrics at /metrics)", addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// sessionIDManager closes the language server session of streamable HTTP clients that
+// terminate their MCP session, as the MCP server's session hooks do not run for them
+type sessionIDManager struct {
+	server.InsecureStatefulSessionIdManager
+	ls *langserver.Server
+}
+
+func (m *sessionIDManager) Terminate(sessionID string) (bool, error) {
+	notAllowed, err := m.InsecureStatefulSessionIdManager.Terminate(sessionID)
+	if err == nil && !notAllowed {
+		m.ls.CloseSession(context.Background(), sessionID)
+	}
+	return notAllowed, err
+}
+
+// mcpLogger sends the logs of the MCP transports to the mcp logging component
+type mcpLogger struct{}
+
+func (mcpLogger) Infof(format string, v ...any) {
+	mcpTransportLogger.Info(format, v...)
+}
+
+func (mcpLogger) Errorf(format string, v ...any) {
+	mcpTransportLogger.Error(format, v...)
+}